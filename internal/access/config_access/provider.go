@@ -22,15 +22,22 @@ func Register(cfg *sdkconfig.SDKConfig) {
 		return
 	}
 
-	sdkaccess.RegisterProvider(
-		sdkaccess.AccessProviderTypeConfigAPIKey,
-		newProvider(sdkaccess.DefaultAccessProviderName, keys),
-	)
+	p := newProvider(sdkaccess.DefaultAccessProviderName, keys)
+	for key := range p.keys {
+		p.keys[key] = cfg.APIKeyName(key)
+	}
+	p.cookie = strings.TrimSpace(cfg.BrowserAuth.Cookie)
+	p.queryParam = strings.TrimSpace(cfg.BrowserAuth.QueryParam)
+	sdkaccess.RegisterProvider(sdkaccess.AccessProviderTypeConfigAPIKey, p)
 }
 
 type provider struct {
 	name string
-	keys map[string]struct{}
+	// keys maps each accepted API key to its configured name or fingerprint.
+	keys map[string]string
+	// cookie and queryParam name the optional browser-auth sources of the API key.
+	cookie     string
+	queryParam string
 }
 
 func newProvider(name string, keys []string) *provider {
@@ -38,9 +45,9 @@ func newProvider(name string, keys []string) *provider {
 	if providerName == "" {
 		providerName = sdkaccess.DefaultAccessProviderName
 	}
-	keySet := make(map[string]struct{}, len(keys))
+	keySet := make(map[string]string, len(keys))
 	for _, key := range keys {
-		keySet[key] = struct{}{}
+		keySet[key] = ""
 	}
 	return &provider{name: providerName, keys: keySet}
 }
@@ -64,42 +71,66 @@ func (p *provider) Authenticate(_ context.Context, r *http.Request) (*sdkaccess.
 	authHeaderAnthropic := r.Header.Get("X-Api-Key")
 	queryKey := ""
 	queryAuthToken := ""
+	queryBrowserKey := ""
 	if r.URL != nil {
 		queryKey = r.URL.Query().Get("key")
 		queryAuthToken = r.URL.Query().Get("auth_token")
+		if p.queryParam != "" {
+			queryBrowserKey = r.URL.Query().Get(p.queryParam)
+		}
 	}
-	if authHeader == "" && authHeaderGoogle == "" && authHeaderAnthropic == "" && queryKey == "" && queryAuthToken == "" {
+	cookieKey := ""
+	if p.cookie != "" {
+		if cookie, errCookie := r.Cookie(p.cookie); errCookie == nil {
+			cookieKey = strings.TrimSpace(cookie.Value)
+		}
+	}
+	if authHeader == "" && authHeaderGoogle == "" && authHeaderAnthropic == "" && queryKey == "" && queryAuthToken == "" && queryBrowserKey == "" && cookieKey == "" {
 		return nil, sdkaccess.NewNoCredentialsError()
 	}
 
 	apiKey := extractBearerToken(authHeader)
 
 	candidates := []struct {
-		value  string
-		source string
+		value   string
+		source  string
+		browser bool
 	}{
-		{apiKey, "authorization"},
-		{authHeaderGoogle, "x-goog-api-key"},
-		{authHeaderAnthropic, "x-api-key"},
-		{queryKey, "query-key"},
-		{queryAuthToken, "query-auth-token"},
+		{apiKey, "authorization", false},
+		{authHeaderGoogle, "x-goog-api-key", false},
+		{authHeaderAnthropic, "x-api-key", false},
+		{queryKey, "query-key", false},
+		{queryAuthToken, "query-auth-token", false},
+		{queryBrowserKey, "query-browser-auth", true},
+		{cookieKey, "cookie", true},
 	}
 
+	originRejected := false
 	for _, candidate := range candidates {
 		if candidate.value == "" {
 			continue
 		}
-		if _, ok := p.keys[candidate.value]; ok {
+		if name, ok := p.keys[candidate.value]; ok {
+			// Browsers attach cookies to cross-site requests, including simple POSTs that skip
+			// the CORS preflight, so browser-auth keys require an explicitly allowed Origin.
+			if candidate.browser && !sdkaccess.BrowserOriginListed(r) {
+				originRejected = true
+				continue
+			}
 			return &sdkaccess.Result{
 				Provider:  p.Identifier(),
 				Principal: candidate.value,
 				Metadata: map[string]string{
-					"source": candidate.source,
+					"source":   candidate.source,
+					"key_name": name,
 				},
 			}, nil
 		}
 	}
 
+	if originRejected {
+		return nil, sdkaccess.NewForbiddenOriginError()
+	}
 	return nil, sdkaccess.NewInvalidCredentialError()
 }
 
@@ -0,0 +1,202 @@
+// Package alerting delivers operational alerts (credential failures, provider outages, budget
+// and error-rate thresholds) to webhook, Slack, Discord and SMTP sinks.
+package alerting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// Alert severities.
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+const sinkTimeout = 15 * time.Second
+
+// Event is a single alert.
+type Event struct {
+	// Type is one of the config.AlertEvent* constants.
+	Type string `json:"event"`
+	// Severity is info, warning or critical.
+	Severity string `json:"severity"`
+	// Provider is the affected provider, when applicable.
+	Provider string `json:"provider,omitempty"`
+	// AuthID is the affected credential, when applicable.
+	AuthID string `json:"auth_id,omitempty"`
+	// Subject is a one-line summary.
+	Subject string `json:"subject"`
+	// Message holds the details.
+	Message string `json:"message,omitempty"`
+	// Fields carries structured context for generic webhook consumers.
+	Fields map[string]any `json:"fields,omitempty"`
+	// Time is when the event occurred.
+	Time time.Time `json:"time"`
+	// Key identifies the alert for throttling; it defaults to type, provider and auth.
+	Key string `json:"-"`
+}
+
+func (e Event) throttleKey() string {
+	if e.Key != "" {
+		return e.Key
+	}
+	return e.Type + "|" + e.Provider + "|" + e.AuthID
+}
+
+// SinkResult reports the delivery outcome for one sink.
+type SinkResult struct {
+	Sink  string `json:"sink"`
+	Type  string `json:"type"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// ErrUnknownSink is returned by Fire when the named sink is not configured.
+var ErrUnknownSink = errors.New("alerting: unknown sink")
+
+// Dispatcher routes events to sinks with per-key throttling.
+type Dispatcher struct {
+	mu   sync.Mutex
+	cfg  config.AlertingConfig
+	sent map[string]time.Time
+	now  func() time.Time
+	// deliver is swapped in tests.
+	deliver func(ctx context.Context, sink config.AlertSink, event Event) error
+}
+
+// NewDispatcher creates a dispatcher without sinks.
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{sent: make(map[string]time.Time), now: time.Now, deliver: deliverToSink}
+}
+
+// Configure replaces the sinks, rules and thresholds.
+func (d *Dispatcher) Configure(cfg config.AlertingConfig) {
+	d.mu.Lock()
+	d.cfg = cfg
+	d.mu.Unlock()
+}
+
+// Config returns the active alerting configuration.
+func (d *Dispatcher) Config() config.AlertingConfig {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.cfg
+}
+
+// Emit delivers the event asynchronously to every routed sink unless an alert with the same key
+// was sent within the throttle window. It reports whether the event was dispatched.
+func (d *Dispatcher) Emit(event Event) bool {
+	if event.Time.IsZero() {
+		event.Time = d.now()
+	}
+	d.mu.Lock()
+	cfg := d.cfg
+	if !cfg.Enabled() {
+		d.mu.Unlock()
+		return false
+	}
+	key := event.throttleKey()
+	window := time.Duration(cfg.Throttle()) * time.Second
+	if last, ok := d.sent[key]; ok && event.Time.Sub(last) < window {
+		d.mu.Unlock()
+		return false
+	}
+	d.sent[key] = event.Time
+	for k, last := range d.sent {
+		if event.Time.Sub(last) >= window {
+			delete(d.sent, k)
+		}
+	}
+	deliver := d.deliver
+	d.mu.Unlock()
+
+	sinks := routeSinks(cfg, event)
+	if len(sinks) == 0 {
+		return false
+	}
+	go func() {
+		for _, sink := range sinks {
+			ctx, cancel := context.WithTimeout(context.Background(), sinkTimeout)
+			if err := deliver(ctx, sink, event); err != nil {
+				log.Warnf("alerting: sink %s: %v", sink.Name, err)
+			}
+			cancel()
+		}
+	}()
+	return true
+}
+
+// routeSinks returns the sinks matched by the routing rules; without rules every sink matches.
+func routeSinks(cfg config.AlertingConfig, event Event) []config.AlertSink {
+	if len(cfg.Rules) == 0 {
+		return cfg.Sinks
+	}
+	selected := make(map[string]struct{})
+	for _, rule := range cfg.Rules {
+		if !rule.Matches(event.Type, event.Provider) {
+			continue
+		}
+		for _, name := range rule.Sinks {
+			selected[name] = struct{}{}
+		}
+	}
+	out := make([]config.AlertSink, 0, len(selected))
+	for _, sink := range cfg.Sinks {
+		if _, ok := selected[sink.Name]; ok {
+			out = append(out, sink)
+		}
+	}
+	return out
+}
+
+// Fire sends the event synchronously to one named sink, or to all sinks when sinkName is empty,
+// bypassing routing and throttling. It backs the management test-fire endpoint.
+func Fire(ctx context.Context, cfg config.AlertingConfig, sinkName string, event Event) ([]SinkResult, error) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	sinkName = strings.TrimSpace(sinkName)
+	results := make([]SinkResult, 0, len(cfg.Sinks))
+	for _, sink := range cfg.Sinks {
+		if sinkName != "" && sink.Name != sinkName {
+			continue
+		}
+		sinkCtx, cancel := context.WithTimeout(ctx, sinkTimeout)
+		err := deliverToSink(sinkCtx, sink, event)
+		cancel()
+		result := SinkResult{Sink: sink.Name, Type: sink.Type, OK: err == nil}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+	}
+	if sinkName != "" && len(results) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSink, sinkName)
+	}
+	return results, nil
+}
+
+var defaultDispatcher = NewDispatcher()
+
+// Configure applies the alerting section of cfg to the default dispatcher and monitor.
+func Configure(cfg *config.Config) {
+	if cfg == nil {
+		return
+	}
+	defaultDispatcher.Configure(cfg.Alerting)
+	defaultMonitor.configure(cfg.Alerting)
+}
+
+// Emit sends an event through the default dispatcher.
+func Emit(event Event) bool {
+	return defaultDispatcher.Emit(event)
+}
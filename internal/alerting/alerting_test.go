@@ -0,0 +1,143 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+func TestDispatcher_RoutesAndThrottles(t *testing.T) {
+	delivered := make(chan string, 8)
+	d := NewDispatcher()
+	d.deliver = func(_ context.Context, sink config.AlertSink, event Event) error {
+		delivered <- sink.Name + ":" + event.Type
+		return nil
+	}
+	d.Configure(config.AlertingConfig{
+		Sinks: []config.AlertSink{{Name: "slack", Type: config.AlertSinkSlack}, {Name: "mail", Type: config.AlertSinkSMTP}},
+		Rules: []config.AlertRule{{Events: []string{config.AlertEventRefreshFailed}, Providers: []string{"claude"}, Sinks: []string{"mail"}}},
+	})
+
+	event := Event{Type: config.AlertEventRefreshFailed, Provider: "claude", AuthID: "a1"}
+	if !d.Emit(event) {
+		t.Fatalf("expected first event to be dispatched")
+	}
+	if got := <-delivered; got != "mail:refresh-failed" {
+		t.Fatalf("delivered to %q, want mail", got)
+	}
+	if d.Emit(event) {
+		t.Fatalf("expected repeat within throttle window to be suppressed")
+	}
+	if d.Emit(Event{Type: config.AlertEventRefreshFailed, Provider: "gemini", AuthID: "a2"}) {
+		t.Fatalf("expected event without matching rule to be dropped")
+	}
+	select {
+	case got := <-delivered:
+		t.Fatalf("unexpected delivery %q", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestFire_SlackAndDiscordPayloads(t *testing.T) {
+	bodies := make(chan map[string]string, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		bodies <- payload
+	}))
+	defer server.Close()
+
+	cfg := config.AlertingConfig{Sinks: []config.AlertSink{
+		{Name: "slack", Type: config.AlertSinkSlack, URL: server.URL},
+		{Name: "discord", Type: config.AlertSinkDiscord, URL: server.URL},
+	}}
+	results, err := Fire(context.Background(), cfg, "", Event{Type: config.AlertEventTest, Severity: SeverityInfo, Subject: "hello"})
+	if err != nil || len(results) != 2 || !results[0].OK || !results[1].OK {
+		t.Fatalf("results = %+v, err = %v", results, err)
+	}
+	if text := (<-bodies)["text"]; !strings.Contains(text, "*[INFO] hello*") {
+		t.Fatalf("slack text = %q", text)
+	}
+	if content := (<-bodies)["content"]; !strings.Contains(content, "**[INFO] hello**") {
+		t.Fatalf("discord content = %q", content)
+	}
+	if _, err = Fire(context.Background(), cfg, "missing", Event{}); err == nil {
+		t.Fatalf("expected unknown sink error")
+	}
+}
+
+func newTestMonitor(cfg config.AlertingConfig) (*monitor, *[]Event, *time.Time) {
+	var events []Event
+	now := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	m := newMonitor(func(e Event) bool {
+		events = append(events, e)
+		return true
+	})
+	m.now = func() time.Time { return now }
+	m.cfg = cfg
+	return m, &events, &now
+}
+
+func TestMonitor_BudgetThresholdsFireOncePerPeriod(t *testing.T) {
+	m, events, now := newTestMonitor(config.AlertingConfig{
+		Sinks:   []config.AlertSink{{Name: "hook", Type: config.AlertSinkWebhook}},
+		Budgets: []config.AlertBudget{{Name: "daily", Period: "day", Providers: []string{"claude"}, MaxTokens: 100, ThresholdsPercent: []float64{80, 100}}},
+	})
+	record := coreusage.Record{Provider: "claude", Model: "m", RequestedAt: *now, Detail: coreusage.Detail{TotalTokens: 45}}
+	m.observeUsage(record)
+	m.observeUsage(record)
+	if len(*events) != 1 || (*events)[0].Fields["threshold_percent"] != 80.0 {
+		t.Fatalf("expected the 80%% threshold, got %+v", *events)
+	}
+	m.observeUsage(record)
+	m.observeUsage(record)
+	if len(*events) != 2 || (*events)[1].Severity != SeverityCritical {
+		t.Fatalf("expected one critical 100%% alert, got %+v", *events)
+	}
+	record.RequestedAt = now.Add(24 * time.Hour)
+	m.observeUsage(record)
+	if len(*events) != 2 {
+		t.Fatalf("expected usage to reset in the next period, got %+v", *events)
+	}
+}
+
+func TestMonitor_ProviderUnavailableAndCredentialDisabled(t *testing.T) {
+	m, events, now := newTestMonitor(config.AlertingConfig{
+		Sinks:              []config.AlertSink{{Name: "hook", Type: config.AlertSinkWebhook}},
+		UnavailableMinutes: 5,
+	})
+	m.observeCredentials([]Credential{{ID: "a", Provider: "claude"}, {ID: "b", Provider: "claude"}})
+	down := []Credential{{ID: "a", Provider: "claude", Disabled: true}, {ID: "b", Provider: "claude", Unavailable: true, NextRetryAfter: now.Add(time.Hour)}}
+	m.observeCredentials(down)
+	if len(*events) != 1 || (*events)[0].Type != config.AlertEventCredentialDisabled || (*events)[0].AuthID != "a" {
+		t.Fatalf("expected credential-disabled for a, got %+v", *events)
+	}
+	*now = now.Add(6 * time.Minute)
+	m.observeCredentials(down)
+	m.observeCredentials(down)
+	if len(*events) != 2 || (*events)[1].Type != config.AlertEventProviderUnavailable {
+		t.Fatalf("expected a single provider-unavailable alert, got %+v", *events)
+	}
+}
+
+func TestMonitor_ExpiredCooldownCountsAsUsable(t *testing.T) {
+	m, events, now := newTestMonitor(config.AlertingConfig{
+		Sinks:              []config.AlertSink{{Name: "hook", Type: config.AlertSinkWebhook}},
+		UnavailableMinutes: 5,
+	})
+	idle := []Credential{{ID: "a", Provider: "claude", Unavailable: true, NextRetryAfter: now.Add(time.Minute)}}
+	m.observeCredentials(idle)
+	*now = now.Add(6 * time.Minute)
+	m.observeCredentials(idle)
+	m.observeCredentials(idle)
+	if len(*events) != 0 {
+		t.Fatalf("expected no alert once the cooldown has expired, got %+v", *events)
+	}
+}
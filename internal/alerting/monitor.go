@@ -0,0 +1,319 @@
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+const credentialCheckInterval = 30 * time.Second
+
+// Credential is the view of an auth the monitor needs to detect disabled credentials and
+// provider-wide outages. Unavailable counts only until NextRetryAfter: the flag is cleared by the
+// next successful request, so a credential left idle after its cooldown is usable again.
+type Credential struct {
+	ID             string
+	Provider       string
+	Label          string
+	Disabled       bool
+	Errored        bool
+	Unavailable    bool
+	NextRetryAfter time.Time
+	StatusMessage  string
+}
+
+// monitor derives threshold events from usage records and credential snapshots.
+type monitor struct {
+	mu       sync.Mutex
+	cfg      config.AlertingConfig
+	emit     func(Event) bool
+	now      func() time.Time
+	outcomes map[string][]outcome // provider -> request outcomes inside the error-rate window
+	budgets  map[string]*budgetUsage
+	// credentials holds the previous snapshot; nil until the first observation.
+	credentials map[string]Credential
+	// unavailableSince tracks when each provider lost its last usable credential.
+	unavailableSince map[string]time.Time
+	outageAlerted    map[string]bool
+}
+
+type outcome struct {
+	at     time.Time
+	failed bool
+}
+
+type budgetUsage struct {
+	periodStart time.Time
+	tokens      int64
+	requests    int64
+	fired       map[float64]bool
+}
+
+func newMonitor(emit func(Event) bool) *monitor {
+	return &monitor{
+		emit:             emit,
+		now:              time.Now,
+		outcomes:         make(map[string][]outcome),
+		budgets:          make(map[string]*budgetUsage),
+		unavailableSince: make(map[string]time.Time),
+		outageAlerted:    make(map[string]bool),
+	}
+}
+
+var (
+	defaultMonitor     = newMonitor(Emit)
+	usageSinkRegistrar sync.Once
+)
+
+func (m *monitor) configure(cfg config.AlertingConfig) {
+	m.mu.Lock()
+	m.cfg = cfg
+	m.mu.Unlock()
+	if cfg.Enabled() && (cfg.ErrorRate.ThresholdPercent > 0 || len(cfg.Budgets) > 0) {
+		usageSinkRegistrar.Do(func() {
+			coreusage.RegisterPlugin(usageSink{})
+		})
+	}
+}
+
+// usageSink feeds usage records to the default monitor.
+type usageSink struct{}
+
+func (usageSink) HandleUsage(_ context.Context, record coreusage.Record) {
+	if record.Hedged {
+		return
+	}
+	defaultMonitor.observeUsage(record)
+}
+
+func (m *monitor) observeUsage(record coreusage.Record) {
+	var events []Event
+	m.mu.Lock()
+	cfg := m.cfg
+	if !cfg.Enabled() {
+		m.mu.Unlock()
+		return
+	}
+	now := record.RequestedAt
+	if now.IsZero() {
+		now = m.now()
+	}
+	if cfg.ErrorRate.ThresholdPercent > 0 {
+		if event, ok := m.observeOutcomeLocked(cfg.ErrorRate, record.Provider, record.Failed, now); ok {
+			events = append(events, event)
+		}
+	}
+	for _, budget := range cfg.Budgets {
+		if !budget.Matches(record.Provider, record.Model, record.APIKey) {
+			continue
+		}
+		events = append(events, m.observeBudgetLocked(budget, record, now)...)
+	}
+	m.mu.Unlock()
+	for _, event := range events {
+		m.emit(event)
+	}
+}
+
+func (m *monitor) observeOutcomeLocked(rate config.AlertErrorRate, provider string, failed bool, now time.Time) (Event, bool) {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	window := time.Duration(rate.Window()) * time.Second
+	kept := m.outcomes[provider][:0]
+	for _, o := range m.outcomes[provider] {
+		if now.Sub(o.at) < window {
+			kept = append(kept, o)
+		}
+	}
+	kept = append(kept, outcome{at: now, failed: failed})
+	m.outcomes[provider] = kept
+	if len(kept) < rate.Minimum() {
+		return Event{}, false
+	}
+	failures := 0
+	for _, o := range kept {
+		if o.failed {
+			failures++
+		}
+	}
+	percent := float64(failures) * 100 / float64(len(kept))
+	if percent < rate.ThresholdPercent {
+		return Event{}, false
+	}
+	return Event{
+		Type:     config.AlertEventErrorRateSpike,
+		Severity: SeverityWarning,
+		Provider: provider,
+		Subject:  fmt.Sprintf("%s error rate at %.0f%%", provider, percent),
+		Message:  fmt.Sprintf("%d of the last %d requests to %s failed within %s.", failures, len(kept), provider, window),
+		Fields:   map[string]any{"error_rate_percent": percent, "requests": len(kept), "failures": failures},
+		Time:     now,
+	}, true
+}
+
+func (m *monitor) observeBudgetLocked(budget config.AlertBudget, record coreusage.Record, now time.Time) []Event {
+	start := periodStart(budget.Period, now)
+	usage := m.budgets[budget.Name]
+	if usage == nil || !usage.periodStart.Equal(start) {
+		usage = &budgetUsage{periodStart: start, fired: make(map[float64]bool)}
+		m.budgets[budget.Name] = usage
+	}
+	usage.requests++
+	usage.tokens += record.Detail.TotalTokens
+
+	percent := 0.0
+	if budget.MaxTokens > 0 {
+		percent = float64(usage.tokens) * 100 / float64(budget.MaxTokens)
+	}
+	if budget.MaxRequests > 0 {
+		if p := float64(usage.requests) * 100 / float64(budget.MaxRequests); p > percent {
+			percent = p
+		}
+	}
+	var events []Event
+	for _, threshold := range budget.ThresholdsPercent {
+		if percent < threshold || usage.fired[threshold] {
+			continue
+		}
+		usage.fired[threshold] = true
+		severity := SeverityWarning
+		if threshold >= 100 {
+			severity = SeverityCritical
+		}
+		events = append(events, Event{
+			Type:     config.AlertEventBudgetThreshold,
+			Severity: severity,
+			Subject:  fmt.Sprintf("budget %s reached %.0f%%", budget.Name, threshold),
+			Message:  fmt.Sprintf("Budget %s used %d tokens and %d requests this %s.", budget.Name, usage.tokens, usage.requests, budget.Period),
+			Fields: map[string]any{
+				"budget": budget.Name, "threshold_percent": threshold, "tokens": usage.tokens,
+				"requests": usage.requests, "period_start": start.Format(time.RFC3339),
+			},
+			Time: now,
+			// Each threshold fires once per period regardless of the throttle window.
+			Key: fmt.Sprintf("%s|%s|%s|%v", config.AlertEventBudgetThreshold, budget.Name, start.Format(time.RFC3339), threshold),
+		})
+	}
+	return events
+}
+
+func periodStart(period string, now time.Time) time.Time {
+	now = now.UTC()
+	if period == "month" {
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// observeCredentials compares the snapshot with the previous one, alerting on credentials that
+// became disabled or errored and on providers left without a usable credential.
+func (m *monitor) observeCredentials(list []Credential) {
+	var events []Event
+	m.mu.Lock()
+	cfg := m.cfg
+	if !cfg.Enabled() {
+		m.credentials = nil
+		m.mu.Unlock()
+		return
+	}
+	now := m.now()
+	current := make(map[string]Credential, len(list))
+	usable := make(map[string]bool)
+	for _, cred := range list {
+		current[cred.ID] = cred
+		provider := strings.ToLower(cred.Provider)
+		if _, seen := usable[provider]; !seen {
+			usable[provider] = false
+		}
+		coolingDown := cred.Unavailable && cred.NextRetryAfter.After(now)
+		if !cred.Disabled && !cred.Errored && !coolingDown {
+			usable[provider] = true
+		}
+		previous, known := m.credentials[cred.ID]
+		if m.credentials == nil || !known {
+			continue
+		}
+		if (cred.Disabled || cred.Errored) && !previous.Disabled && !previous.Errored {
+			state := "errored"
+			if cred.Disabled {
+				state = "disabled"
+			}
+			name := cred.Label
+			if name == "" {
+				name = cred.ID
+			}
+			events = append(events, Event{
+				Type:     config.AlertEventCredentialDisabled,
+				Severity: SeverityWarning,
+				Provider: cred.Provider,
+				AuthID:   cred.ID,
+				Subject:  fmt.Sprintf("%s credential %s %s", cred.Provider, name, state),
+				Message:  cred.StatusMessage,
+				Time:     now,
+			})
+		}
+	}
+	m.credentials = current
+
+	threshold := time.Duration(cfg.UnavailableAfter()) * time.Minute
+	for provider, ok := range usable {
+		if ok {
+			delete(m.unavailableSince, provider)
+			delete(m.outageAlerted, provider)
+			continue
+		}
+		since, tracked := m.unavailableSince[provider]
+		if !tracked {
+			m.unavailableSince[provider] = now
+			continue
+		}
+		if m.outageAlerted[provider] || now.Sub(since) < threshold {
+			continue
+		}
+		m.outageAlerted[provider] = true
+		events = append(events, Event{
+			Type:     config.AlertEventProviderUnavailable,
+			Severity: SeverityCritical,
+			Provider: provider,
+			Subject:  fmt.Sprintf("all %s credentials unavailable", provider),
+			Message:  fmt.Sprintf("No %s credential has been usable since %s.", provider, since.UTC().Format(time.RFC3339)),
+			Fields:   map[string]any{"unavailable_since": since.UTC().Format(time.RFC3339)},
+			Time:     now,
+		})
+	}
+	for provider := range m.unavailableSince {
+		if _, ok := usable[provider]; !ok {
+			delete(m.unavailableSince, provider)
+			delete(m.outageAlerted, provider)
+		}
+	}
+	m.mu.Unlock()
+	for _, event := range events {
+		m.emit(event)
+	}
+}
+
+// WatchCredentials polls source until ctx is done and raises credential-disabled and
+// provider-unavailable alerts.
+func WatchCredentials(ctx context.Context, source func() []Credential) {
+	if source == nil {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(credentialCheckInterval)
+		defer ticker.Stop()
+		defaultMonitor.observeCredentials(source())
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				defaultMonitor.observeCredentials(source())
+			}
+		}
+	}()
+}
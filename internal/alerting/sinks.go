@@ -0,0 +1,177 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+// discordContentLimit is the maximum message length Discord accepts.
+const discordContentLimit = 2000
+
+var webhookClient = &http.Client{Timeout: sinkTimeout}
+
+func deliverToSink(ctx context.Context, sink config.AlertSink, event Event) error {
+	switch sink.Type {
+	case config.AlertSinkWebhook:
+		return postJSON(ctx, sink, event)
+	case config.AlertSinkSlack:
+		return postJSON(ctx, sink, map[string]string{"text": formatText(event, "*")})
+	case config.AlertSinkDiscord:
+		content := formatText(event, "**")
+		if len(content) > discordContentLimit {
+			content = content[:discordContentLimit-3] + "..."
+		}
+		return postJSON(ctx, sink, map[string]string{"content": content})
+	case config.AlertSinkSMTP:
+		return sendMail(ctx, sink, event)
+	default:
+		return fmt.Errorf("unsupported sink type %q", sink.Type)
+	}
+}
+
+func postJSON(ctx context.Context, sink config.AlertSink, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sink.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range sink.Headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+		return fmt.Errorf("webhook returned %d: %s", resp.StatusCode, strings.TrimSpace(string(snippet)))
+	}
+	return nil
+}
+
+// formatText renders the event as chat text; bold is the platform's emphasis marker.
+func formatText(event Event, bold string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s[%s] %s%s", bold, strings.ToUpper(event.Severity), event.Subject, bold)
+	if event.Message != "" {
+		b.WriteString("\n")
+		b.WriteString(event.Message)
+	}
+	for _, line := range fieldLines(event) {
+		b.WriteString("\n• ")
+		b.WriteString(line)
+	}
+	return b.String()
+}
+
+func fieldLines(event Event) []string {
+	lines := make([]string, 0, len(event.Fields)+3)
+	lines = append(lines, "event: "+event.Type)
+	if event.Provider != "" {
+		lines = append(lines, "provider: "+event.Provider)
+	}
+	if event.AuthID != "" {
+		lines = append(lines, "credential: "+event.AuthID)
+	}
+	keys := make([]string, 0, len(event.Fields))
+	for key := range event.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		lines = append(lines, fmt.Sprintf("%s: %v", key, event.Fields[key]))
+	}
+	return lines
+}
+
+func sendMail(ctx context.Context, sink config.AlertSink, event Event) error {
+	addr := net.JoinHostPort(sink.SMTPHost, strconv.Itoa(sink.SMTPPort))
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", sink.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(sink.To, ", "))
+	fmt.Fprintf(&msg, "Subject: [CLIProxyAPI] [%s] %s\r\n", strings.ToUpper(event.Severity), event.Subject)
+	fmt.Fprintf(&msg, "Date: %s\r\n", event.Time.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n")
+	if event.Message != "" {
+		msg.WriteString(event.Message + "\r\n\r\n")
+	}
+	for _, line := range fieldLines(event) {
+		msg.WriteString(line + "\r\n")
+	}
+
+	var auth smtp.Auth
+	if sink.Username != "" {
+		auth = smtp.PlainAuth("", sink.Username, sink.Password, sink.SMTPHost)
+	}
+
+	dialer := &net.Dialer{Timeout: sinkTimeout}
+	var conn net.Conn
+	var err error
+	if sink.SMTPPort == 465 {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{ServerName: sink.SMTPHost})
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	client, err := smtp.NewClient(conn, sink.SMTPHost)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer func() { _ = client.Close() }()
+	if sink.SMTPPort != 465 {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err = client.StartTLS(&tls.Config{ServerName: sink.SMTPHost}); err != nil {
+				return err
+			}
+		}
+	}
+	if auth != nil {
+		if err = client.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err = client.Mail(sink.From); err != nil {
+		return err
+	}
+	for _, rcpt := range sink.To {
+		if err = client.Rcpt(strings.TrimSpace(rcpt)); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(msg.Bytes()); err != nil {
+		_ = w.Close()
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
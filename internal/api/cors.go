@@ -0,0 +1,231 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+)
+
+const defaultCORSMethods = "GET, POST, PUT, PATCH, DELETE, OPTIONS"
+
+// corsPolicies holds the compiled CORS policy of every route group and is swapped atomically
+// on config reload.
+type corsPolicies struct {
+	current atomic.Pointer[corsPolicySet]
+}
+
+type corsPolicySet struct {
+	v1         *compiledCORSPolicy
+	v1beta     *compiledCORSPolicy
+	amp        *compiledCORSPolicy
+	management *compiledCORSPolicy
+	fallback   *compiledCORSPolicy
+}
+
+type compiledCORSPolicy struct {
+	disabled       bool
+	anyOrigin      bool
+	origins        []string
+	methods        string
+	anyHeader      bool
+	headers        string
+	exposedHeaders string
+	credentials    bool
+	maxAge         string
+}
+
+func newCORSPolicies(cfg config.CORSConfig) *corsPolicies {
+	p := &corsPolicies{}
+	p.update(cfg)
+	return p
+}
+
+// update compiles cfg and makes it effective for subsequent requests.
+func (p *corsPolicies) update(cfg config.CORSConfig) {
+	fallback := compileCORSPolicy(cfg.Default)
+	pick := func(policy *config.CORSPolicy) *compiledCORSPolicy {
+		if policy == nil {
+			return fallback
+		}
+		return compileCORSPolicy(policy)
+	}
+	p.current.Store(&corsPolicySet{
+		v1:         pick(cfg.V1),
+		v1beta:     pick(cfg.V1Beta),
+		amp:        pick(cfg.Amp),
+		management: pick(cfg.Management),
+		fallback:   fallback,
+	})
+}
+
+// compileCORSPolicy prepares policy for matching. A nil policy allows any origin.
+func compileCORSPolicy(policy *config.CORSPolicy) *compiledCORSPolicy {
+	if policy == nil {
+		return &compiledCORSPolicy{anyOrigin: true, methods: defaultCORSMethods, anyHeader: true}
+	}
+	compiled := &compiledCORSPolicy{
+		disabled:       policy.Disable,
+		methods:        defaultCORSMethods,
+		anyHeader:      len(policy.AllowedHeaders) == 0,
+		exposedHeaders: strings.Join(policy.ExposedHeaders, ", "),
+		credentials:    policy.AllowCredentials,
+	}
+	for _, origin := range policy.AllowedOrigins {
+		if origin == "*" {
+			compiled.anyOrigin = true
+			continue
+		}
+		compiled.origins = append(compiled.origins, strings.ToLower(strings.TrimRight(origin, "/")))
+	}
+	if len(policy.AllowedMethods) > 0 {
+		compiled.methods = strings.Join(policy.AllowedMethods, ", ")
+	}
+	for _, header := range policy.AllowedHeaders {
+		if header == "*" {
+			compiled.anyHeader = true
+		}
+	}
+	if !compiled.anyHeader {
+		compiled.headers = strings.Join(policy.AllowedHeaders, ", ")
+	}
+	if policy.MaxAge > 0 {
+		compiled.maxAge = strconv.Itoa(policy.MaxAge)
+	}
+	return compiled
+}
+
+// forPath returns the policy of the route group serving path.
+func (s *corsPolicySet) forPath(path string) *compiledCORSPolicy {
+	switch {
+	case strings.HasPrefix(path, "/v0/management"):
+		return s.management
+	case path == "/v1beta" || strings.HasPrefix(path, "/v1beta/"):
+		return s.v1beta
+	case path == "/v1" || strings.HasPrefix(path, "/v1/"):
+		return s.v1
+	case isAmpPath(path):
+		return s.amp
+	default:
+		return s.fallback
+	}
+}
+
+// isAmpPath reports whether path belongs to the routes registered by the Amp module.
+func isAmpPath(path string) bool {
+	if path == "/api" || strings.HasPrefix(path, "/api/") || path == "/threads.rss" || path == "/news.rss" {
+		return true
+	}
+	for _, prefix := range []string{"/threads", "/docs", "/settings", "/auth"} {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// allowOrigin returns the Access-Control-Allow-Origin value for origin, or "" when the origin
+// is not allowed.
+func (p *compiledCORSPolicy) allowOrigin(origin string) string {
+	if p.disabled {
+		return ""
+	}
+	if p.anyOrigin {
+		// Any origin never carries credentials; config validation rejects the combination.
+		return "*"
+	}
+	normalized := strings.ToLower(strings.TrimRight(origin, "/"))
+	for _, pattern := range p.origins {
+		if matchOriginPattern(pattern, normalized) {
+			return origin
+		}
+	}
+	return ""
+}
+
+// matchOriginPattern matches origin against pattern, where each "*" matches any run of
+// characters other than "/" and ":".
+func matchOriginPattern(pattern, origin string) bool {
+	star := strings.IndexByte(pattern, '*')
+	if star < 0 {
+		return pattern == origin
+	}
+	prefix, rest := pattern[:star], pattern[star+1:]
+	if !strings.HasPrefix(origin, prefix) {
+		return false
+	}
+	remaining := origin[len(prefix):]
+	for i := 0; i <= len(remaining); i++ {
+		if matchOriginPattern(rest, remaining[i:]) {
+			return true
+		}
+		if i < len(remaining) && (remaining[i] == '/' || remaining[i] == ':') {
+			return false
+		}
+	}
+	return false
+}
+
+// middleware applies the policy of the requested route group. Preflight requests are answered
+// directly: 204 when the origin is allowed, 403 otherwise.
+func (p *corsPolicies) middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		policy := p.current.Load().forPath(c.Request.URL.Path)
+		// Streaming handlers leave Access-Control-Allow-Origin to this middleware.
+		c.Set(handlers.CORSHandledKey, true)
+
+		origin := c.GetHeader("Origin")
+		allowed := policy.allowOrigin(origin)
+		credentials := policy.credentials && allowed != "*"
+		if origin != "" {
+			// Browser-auth keys and websocket upgrades consult this decision downstream.
+			decision := sdkaccess.OriginDecision{Allowed: allowed != "", Listed: allowed != "" && allowed != "*"}
+			c.Request = c.Request.WithContext(sdkaccess.WithOriginDecision(c.Request.Context(), decision))
+		}
+		if allowed != "" {
+			header := c.Writer.Header()
+			header.Set("Access-Control-Allow-Origin", allowed)
+			if allowed != "*" {
+				header.Add("Vary", "Origin")
+			}
+			header.Set("Access-Control-Allow-Methods", policy.methods)
+			switch {
+			case !policy.anyHeader:
+				header.Set("Access-Control-Allow-Headers", policy.headers)
+			case credentials:
+				// Browsers ignore the "*" wildcard on credentialed requests.
+				if requested := c.GetHeader("Access-Control-Request-Headers"); requested != "" {
+					header.Set("Access-Control-Allow-Headers", requested)
+					header.Add("Vary", "Access-Control-Request-Headers")
+				}
+			default:
+				header.Set("Access-Control-Allow-Headers", "*")
+			}
+			if credentials {
+				header.Set("Access-Control-Allow-Credentials", "true")
+			}
+			if policy.exposedHeaders != "" {
+				header.Set("Access-Control-Expose-Headers", policy.exposedHeaders)
+			}
+			if policy.maxAge != "" {
+				header.Set("Access-Control-Max-Age", policy.maxAge)
+			}
+		}
+
+		if c.Request.Method == http.MethodOptions {
+			if allowed == "" && origin != "" {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,242 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	gin "github.com/gin-gonic/gin"
+	configaccess "github.com/router-for-me/CLIProxyAPI/v6/internal/access/config_access"
+	proxyconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
+)
+
+func newCORSTestEngine(policies *corsPolicies) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(policies.middleware())
+	ok := func(c *gin.Context) { c.String(http.StatusOK, "ok") }
+	engine.POST("/v1/chat/completions", ok)
+	engine.POST("/v1beta/models", ok)
+	engine.GET("/v0/management/config", ok)
+	engine.GET("/api/user", ok)
+	return engine
+}
+
+func doCORSRequest(engine *gin.Engine, method, path, origin string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	if method == http.MethodOptions {
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		req.Header.Set("Access-Control-Request-Headers", "authorization, content-type")
+	}
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMatchOriginPattern(t *testing.T) {
+	cases := []struct {
+		pattern string
+		origin  string
+		want    bool
+	}{
+		{"https://app.example.com", "https://app.example.com", true},
+		{"https://*.example.com", "https://a.example.com", true},
+		{"https://*.example.com", "https://a.b.example.com", true},
+		{"https://*.example.com", "https://example.com", false},
+		{"https://*.example.com", "https://evil.com/x.example.com", false},
+		{"https://*.example.com", "http://a.example.com", false},
+		{"http://localhost:*", "http://localhost:5173", true},
+		{"http://localhost:*", "http://localhost", false},
+	}
+	for _, tc := range cases {
+		if got := matchOriginPattern(tc.pattern, tc.origin); got != tc.want {
+			t.Errorf("matchOriginPattern(%q, %q) = %t, want %t", tc.pattern, tc.origin, got, tc.want)
+		}
+	}
+}
+
+func TestCORSDefaultAllowsAnyOrigin(t *testing.T) {
+	engine := newCORSTestEngine(newCORSPolicies(proxyconfig.CORSConfig{}))
+
+	rec := doCORSRequest(engine, http.MethodOptions, "/v1/chat/completions", "https://anything.test")
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("preflight status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want *", got)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Headers"); got != "*" {
+		t.Errorf("Access-Control-Allow-Headers = %q, want *", got)
+	}
+}
+
+func TestCORSPerRouteGroupPolicies(t *testing.T) {
+	cfg := &proxyconfig.Config{CORS: proxyconfig.CORSConfig{
+		Default: &proxyconfig.CORSPolicy{
+			AllowedOrigins:   []string{"https://*.intranet.example.com"},
+			AllowedHeaders:   []string{"Authorization", "Content-Type"},
+			AllowCredentials: true,
+			MaxAge:           600,
+		},
+		V1Beta:     &proxyconfig.CORSPolicy{AllowedOrigins: []string{"*"}},
+		Management: &proxyconfig.CORSPolicy{Disable: true},
+	}}
+	cfg.SanitizeCORS()
+	engine := newCORSTestEngine(newCORSPolicies(cfg.CORS))
+
+	rec := doCORSRequest(engine, http.MethodOptions, "/v1/chat/completions", "https://tools.intranet.example.com")
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("v1 preflight status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+	header := rec.Header()
+	if got := header.Get("Access-Control-Allow-Origin"); got != "https://tools.intranet.example.com" {
+		t.Errorf("Access-Control-Allow-Origin = %q", got)
+	}
+	if got := header.Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Errorf("Access-Control-Allow-Credentials = %q", got)
+	}
+	if got := header.Get("Access-Control-Allow-Headers"); got != "Authorization, Content-Type" {
+		t.Errorf("Access-Control-Allow-Headers = %q", got)
+	}
+	if got := header.Get("Access-Control-Max-Age"); got != "600" {
+		t.Errorf("Access-Control-Max-Age = %q", got)
+	}
+	if got := header.Get("Vary"); got != "Origin" {
+		t.Errorf("Vary = %q", got)
+	}
+
+	rec = doCORSRequest(engine, http.MethodOptions, "/v1/chat/completions", "https://evil.test")
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("disallowed preflight status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+
+	rec = doCORSRequest(engine, http.MethodPost, "/v1/chat/completions", "https://evil.test")
+	if rec.Code != http.StatusOK || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("disallowed simple request: status %d, allow-origin %q", rec.Code, rec.Header().Get("Access-Control-Allow-Origin"))
+	}
+
+	rec = doCORSRequest(engine, http.MethodPost, "/v1beta/models", "https://evil.test")
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("v1beta Access-Control-Allow-Origin = %q, want *", got)
+	}
+
+	rec = doCORSRequest(engine, http.MethodGet, "/api/user", "https://a.intranet.example.com")
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://a.intranet.example.com" {
+		t.Errorf("amp falls back to default: Access-Control-Allow-Origin = %q", got)
+	}
+
+	rec = doCORSRequest(engine, http.MethodOptions, "/v0/management/config", "https://a.intranet.example.com")
+	if rec.Code != http.StatusForbidden || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("disabled management preflight: status %d, allow-origin %q", rec.Code, rec.Header().Get("Access-Control-Allow-Origin"))
+	}
+}
+
+func TestCORSPolicyUpdate(t *testing.T) {
+	policies := newCORSPolicies(proxyconfig.CORSConfig{})
+	engine := newCORSTestEngine(policies)
+
+	policies.update(proxyconfig.CORSConfig{Default: &proxyconfig.CORSPolicy{AllowedOrigins: []string{"https://app.example.com"}}})
+
+	if rec := doCORSRequest(engine, http.MethodOptions, "/v1/chat/completions", "https://other.example.com"); rec.Code != http.StatusForbidden {
+		t.Errorf("preflight after update status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+	rec := doCORSRequest(engine, http.MethodPost, "/v1/chat/completions", "https://app.example.com")
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Errorf("Access-Control-Allow-Origin = %q", got)
+	}
+}
+
+func TestCORSAnyOriginNeverAllowsCredentials(t *testing.T) {
+	for _, origins := range [][]string{{"*"}, {"https://*"}, {"http://*:8080"}} {
+		cfg := &proxyconfig.Config{CORS: proxyconfig.CORSConfig{
+			V1: &proxyconfig.CORSPolicy{AllowedOrigins: origins, AllowCredentials: true},
+		}}
+		if err := cfg.ValidateCORS(); err == nil {
+			t.Errorf("ValidateCORS accepted %v with allow-credentials", origins)
+		}
+	}
+	cfg := &proxyconfig.Config{CORS: proxyconfig.CORSConfig{
+		V1: &proxyconfig.CORSPolicy{AllowedOrigins: []string{"https://*.example.com"}, AllowCredentials: true},
+	}}
+	if err := cfg.ValidateCORS(); err != nil {
+		t.Errorf("ValidateCORS rejected a subdomain wildcard: %v", err)
+	}
+
+	engine := newCORSTestEngine(newCORSPolicies(proxyconfig.CORSConfig{
+		Default: &proxyconfig.CORSPolicy{AllowedOrigins: []string{"*"}, AllowCredentials: true},
+	}))
+	rec := doCORSRequest(engine, http.MethodPost, "/v1/chat/completions", "https://evil.test")
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want *", got)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Errorf("Access-Control-Allow-Credentials = %q, want none", got)
+	}
+}
+
+func TestBrowserAuthCookieRequiresListedOrigin(t *testing.T) {
+	cfg := &proxyconfig.Config{}
+	cfg.APIKeys = []string{"browser-key"}
+	cfg.BrowserAuth.Cookie = "cliproxy_key"
+	cfg.CORS.V1 = &proxyconfig.CORSPolicy{AllowedOrigins: []string{"https://app.example.com"}, AllowCredentials: true}
+	configaccess.Register(&cfg.SDKConfig)
+	t.Cleanup(func() { configaccess.Register(nil) })
+	manager := sdkaccess.NewManager()
+	manager.SetProviders(sdkaccess.RegisteredProviders())
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(newCORSPolicies(cfg.CORS).middleware())
+	engine.POST("/v1/chat/completions", AuthMiddleware(manager), func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+
+	send := func(origin string) int {
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader("{}"))
+		req.Header.Set("Content-Type", "text/plain")
+		req.AddCookie(&http.Cookie{Name: "cliproxy_key", Value: "browser-key"})
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := send("https://app.example.com"); code != http.StatusOK {
+		t.Fatalf("listed origin status = %d, want %d", code, http.StatusOK)
+	}
+	if code := send("https://evil.test"); code != http.StatusForbidden {
+		t.Fatalf("cross-origin cookie status = %d, want %d", code, http.StatusForbidden)
+	}
+	if code := send(""); code != http.StatusForbidden {
+		t.Fatalf("cookie without origin status = %d, want %d", code, http.StatusForbidden)
+	}
+}
+
+func TestCheckWebsocketOriginFollowsPolicy(t *testing.T) {
+	cfg := proxyconfig.CORSConfig{V1: &proxyconfig.CORSPolicy{AllowedOrigins: []string{"https://app.example.com"}}}
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(newCORSPolicies(cfg).middleware())
+	engine.GET("/v1/responses", func(c *gin.Context) {
+		if !sdkaccess.CheckWebsocketOrigin(c.Request) {
+			c.Status(http.StatusForbidden)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	for origin, want := range map[string]int{
+		"":                        http.StatusOK,
+		"https://app.example.com": http.StatusOK,
+		"https://evil.test":       http.StatusForbidden,
+	} {
+		if rec := doCORSRequest(engine, http.MethodGet, "/v1/responses", origin); rec.Code != want {
+			t.Errorf("origin %q status = %d, want %d", origin, rec.Code, want)
+		}
+	}
+}
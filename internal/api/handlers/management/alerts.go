@@ -0,0 +1,61 @@
+package management
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/alerting"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+// TestFireAlert sends a synthetic alert to one sink (or all sinks) so operators can verify
+// delivery without waiting for a real incident.
+func (h *Handler) TestFireAlert(c *gin.Context) {
+	var req struct {
+		Sink     string `json:"sink"`
+		Event    string `json:"event"`
+		Provider string `json:"provider"`
+		Message  string `json:"message"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+			return
+		}
+	}
+	if h.cfg == nil || !h.cfg.Alerting.Enabled() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no alert sinks configured"})
+		return
+	}
+
+	eventType := strings.TrimSpace(req.Event)
+	if eventType == "" {
+		eventType = config.AlertEventTest
+	}
+	message := strings.TrimSpace(req.Message)
+	if message == "" {
+		message = "Test alert fired from the management API."
+	}
+	event := alerting.Event{
+		Type:     eventType,
+		Severity: alerting.SeverityInfo,
+		Provider: strings.TrimSpace(req.Provider),
+		Subject:  "test alert (" + eventType + ")",
+		Message:  message,
+		Fields:   map[string]any{"test": true},
+		Time:     time.Now(),
+	}
+	results, err := alerting.Fire(c.Request.Context(), h.cfg.Alerting, req.Sink, event)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, alerting.ErrUnknownSink) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
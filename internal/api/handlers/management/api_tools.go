@@ -14,7 +14,9 @@ import (
 	"github.com/fxamacker/cbor/v2"
 	"github.com/gin-gonic/gin"
 	claudeauth "github.com/router-for-me/CLIProxyAPI/v6/internal/auth/claude"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/geminicli"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/proxyutil"
 	log "github.com/sirupsen/logrus"
@@ -528,7 +530,16 @@ func (h *Handler) refreshClaudeOAuthAccessToken(ctx context.Context, auth *corea
 	}
 
 	// 1. Call Claude OAuth token refresh.
-	svc := claudeauth.NewClaudeAuth(h.cfg)
+	proxyURL := strings.TrimSpace(auth.ProxyURL)
+	var upstreamTLS config.UpstreamTLS
+	if h.cfg != nil {
+		if proxyURL == "" {
+			proxyURL = strings.TrimSpace(h.cfg.ProxyURL)
+		}
+		upstreamTLS = h.cfg.UpstreamTLS
+	}
+	upstreamTLS = upstreamTLS.Merge(util.UpstreamTLSFromAttrs(auth.Attributes))
+	svc := claudeauth.NewClaudeAuthWithUpstream(proxyURL, util.UpstreamTLSOptions(upstreamTLS))
 	tokenData, errRefresh := svc.RefreshTokens(ctx, refreshToken)
 	if errRefresh != nil {
 		return "", errRefresh
@@ -781,14 +792,20 @@ func (h *Handler) apiCallTransport(auth *coreauth.Auth) http.RoundTripper {
 			proxyCandidates = append(proxyCandidates, proxyStr)
 		}
 	}
+	var upstreamTLS config.UpstreamTLS
 	if h != nil && h.cfg != nil {
 		if proxyStr := strings.TrimSpace(h.cfg.ProxyURL); proxyStr != "" {
 			proxyCandidates = append(proxyCandidates, proxyStr)
 		}
+		upstreamTLS = h.cfg.UpstreamTLS
 	}
+	if auth != nil {
+		upstreamTLS = upstreamTLS.Merge(util.UpstreamTLSFromAttrs(auth.Attributes))
+	}
+	tlsOpts := util.UpstreamTLSOptions(upstreamTLS)
 
 	for _, proxyStr := range proxyCandidates {
-		if transport := buildProxyTransport(proxyStr); transport != nil {
+		if transport := buildProxyTransport(proxyStr, tlsOpts); transport != nil {
 			return transport
 		}
 	}
@@ -799,11 +816,16 @@ func (h *Handler) apiCallTransport(auth *coreauth.Auth) http.RoundTripper {
 	}
 	clone := transport.Clone()
 	clone.Proxy = nil
+	if tlsConfig, errTLS := proxyutil.BuildTLSConfig(tlsOpts); errTLS != nil {
+		log.WithError(errTLS).Debug("build upstream TLS config failed")
+	} else if tlsConfig != nil {
+		clone.TLSClientConfig = tlsConfig
+	}
 	return clone
 }
 
-func buildProxyTransport(proxyStr string) *http.Transport {
-	transport, _, errBuild := proxyutil.BuildHTTPTransport(proxyStr)
+func buildProxyTransport(proxyStr string, tlsOpts proxyutil.TLSOptions) *http.Transport {
+	transport, _, errBuild := proxyutil.BuildHTTPTransportWithTLS(proxyStr, tlsOpts)
 	if errBuild != nil {
 		log.WithError(errBuild).Debug("build proxy transport failed")
 		return nil
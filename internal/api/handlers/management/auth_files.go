@@ -424,6 +424,9 @@ func (h *Handler) buildAuthFileEntry(auth *coreauth.Auth) gin.H {
 	if !auth.NextRetryAfter.IsZero() {
 		entry["next_retry_after"] = auth.NextRetryAfter
 	}
+	if auth.LastProbe != nil {
+		entry["last_probe"] = auth.LastProbe
+	}
 	if path != "" {
 		entry["path"] = path
 		entry["source"] = "file"
@@ -1129,6 +1132,56 @@ func (h *Handler) PatchAuthFileStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": "ok", "disabled": *req.Disabled})
 }
 
+// ProbeAuthFiles validates one credential (by name or ID) or all of them on demand.
+func (h *Handler) ProbeAuthFiles(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+
+	var req struct {
+		Name string `json:"name"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+			return
+		}
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = strings.TrimSpace(c.Query("name"))
+	}
+
+	ctx := c.Request.Context()
+	if name == "" || name == "*" {
+		c.JSON(http.StatusOK, gin.H{"results": h.authManager.ProbeAll(ctx)})
+		return
+	}
+
+	targetID := ""
+	if auth, ok := h.authManager.GetByID(name); ok {
+		targetID = auth.ID
+	} else {
+		for _, auth := range h.authManager.List() {
+			if auth.FileName == name {
+				targetID = auth.ID
+				break
+			}
+		}
+	}
+	if targetID == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "auth file not found"})
+		return
+	}
+	result, err := h.authManager.ProbeAuth(ctx, targetID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("probe failed: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"results": []coreauth.ProbeResult{result}})
+}
+
 // PatchAuthFileFields updates editable fields (prefix, proxy_url, priority, note) of an auth file.
 func (h *Handler) PatchAuthFileFields(c *gin.Context) {
 	if h.authManager == nil {
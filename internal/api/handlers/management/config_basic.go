@@ -1,6 +1,7 @@
 package management
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -28,8 +29,58 @@ func (h *Handler) GetConfig(c *gin.Context) {
 		c.JSON(200, gin.H{})
 		return
 	}
-	c.JSON(200, h.cfg)
+	c.JSON(200, h.maskSecretRefs(h.cfg))
+}
+
+// maskSecretRefs replaces values resolved from config secret references (${env:...},
+// ${file:...}, ${cmd:...}) with the reference text so plaintext secrets never leave the process.
+func (h *Handler) maskSecretRefs(payload any) any {
+	return h.maskSecretRefsAt("", payload)
+}
+
+// maskSecretRefsAt is maskSecretRefs for a payload found at path (e.g. "ampcode") in the config.
+func (h *Handler) maskSecretRefsAt(path string, payload any) any {
+	if h == nil || h.cfg == nil || len(h.cfg.SecretRefs()) == 0 {
+		return payload
+	}
+	raw, errMarshal := json.Marshal(payload)
+	if errMarshal != nil {
+		return payload
+	}
+	var generic any
+	if errUnmarshal := json.Unmarshal(raw, &generic); errUnmarshal != nil {
+		return payload
+	}
+	return h.cfg.MaskSecretRefsAt(path, generic)
+}
 
+// unmaskSecretRefsAt reverses maskSecretRefsAt on target, a value decoded from a request body
+// for the config at path. References echoed back from a GET keep their resolved value; any other
+// reference is rejected so the caller can answer 400 before changing the config.
+func (h *Handler) unmaskSecretRefsAt(path string, target any) error {
+	raw, errMarshal := json.Marshal(target)
+	if errMarshal != nil {
+		return errMarshal
+	}
+	if !bytes.Contains(raw, []byte("${")) {
+		return nil
+	}
+	var generic any
+	if errUnmarshal := json.Unmarshal(raw, &generic); errUnmarshal != nil {
+		return errUnmarshal
+	}
+	var cfg *config.Config
+	if h != nil {
+		cfg = h.cfg
+	}
+	unmasked, errUnmask := cfg.UnmaskSecretRefsAt(path, generic)
+	if errUnmask != nil {
+		return errUnmask
+	}
+	if raw, errMarshal = json.Marshal(unmasked); errMarshal != nil {
+		return errMarshal
+	}
+	return json.Unmarshal(raw, target)
 }
 
 type releaseInfo struct {
@@ -120,6 +171,17 @@ func (h *Handler) PutConfigYAML(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_yaml", "message": err.Error()})
 		return
 	}
+	// File and command secret references run on the host; only keep the ones already configured.
+	current, _ := os.ReadFile(h.configFilePath)
+	if err = config.ValidateUploadedSecretRefs(body, current); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_config", "message": err.Error()})
+		return
+	}
+	// Stdio MCP servers launch host commands for the same reason.
+	if err = config.ValidateUploadedMCPServers(body, current); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_config", "message": err.Error()})
+		return
+	}
 	// Validate config using LoadConfigOptional with optional=false to enforce parsing
 	tmpDir := filepath.Dir(h.configFilePath)
 	tmpFile, err := os.CreateTemp(tmpDir, "config-validate-*.yaml")
@@ -321,7 +383,7 @@ func (h *Handler) PutRoutingStrategy(c *gin.Context) {
 // Proxy URL
 func (h *Handler) GetProxyURL(c *gin.Context) { c.JSON(200, gin.H{"proxy-url": h.cfg.ProxyURL}) }
 func (h *Handler) PutProxyURL(c *gin.Context) {
-	h.updateStringField(c, func(v string) { h.cfg.ProxyURL = v })
+	h.updateStringField(c, "proxy-url", func(v string) { h.cfg.ProxyURL = v })
 }
 func (h *Handler) DeleteProxyURL(c *gin.Context) {
 	h.cfg.ProxyURL = ""
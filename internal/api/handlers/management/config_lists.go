@@ -10,7 +10,7 @@ import (
 )
 
 // Generic helpers for list[string]
-func (h *Handler) putStringList(c *gin.Context, set func([]string), after func()) {
+func (h *Handler) putStringList(c *gin.Context, path string, set func([]string), after func()) {
 	data, err := c.GetRawData()
 	if err != nil {
 		c.JSON(400, gin.H{"error": "failed to read body"})
@@ -27,6 +27,10 @@ func (h *Handler) putStringList(c *gin.Context, set func([]string), after func()
 		}
 		arr = obj.Items
 	}
+	if err = h.unmaskSecretRefsAt(path, &arr); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
 	set(arr)
 	if after != nil {
 		after()
@@ -34,7 +38,7 @@ func (h *Handler) putStringList(c *gin.Context, set func([]string), after func()
 	h.persist(c)
 }
 
-func (h *Handler) patchStringList(c *gin.Context, target *[]string, after func()) {
+func (h *Handler) patchStringList(c *gin.Context, path string, target *[]string, after func()) {
 	var body struct {
 		Old   *string `json:"old"`
 		New   *string `json:"new"`
@@ -45,6 +49,15 @@ func (h *Handler) patchStringList(c *gin.Context, target *[]string, after func()
 		c.JSON(400, gin.H{"error": "invalid body"})
 		return
 	}
+	for _, value := range []*string{body.Old, body.New, body.Value} {
+		if value == nil {
+			continue
+		}
+		if err := h.unmaskSecretRefsAt(path+"[]", value); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+	}
 	if body.Index != nil && body.Value != nil && *body.Index >= 0 && *body.Index < len(*target) {
 		(*target)[*body.Index] = *body.Value
 		if after != nil {
@@ -105,14 +118,16 @@ func (h *Handler) deleteFromStringList(c *gin.Context, target *[]string, after f
 }
 
 // api-keys
-func (h *Handler) GetAPIKeys(c *gin.Context) { c.JSON(200, gin.H{"api-keys": h.cfg.APIKeys}) }
+func (h *Handler) GetAPIKeys(c *gin.Context) {
+	c.JSON(200, h.maskSecretRefs(gin.H{"api-keys": h.cfg.APIKeys}))
+}
 func (h *Handler) PutAPIKeys(c *gin.Context) {
-	h.putStringList(c, func(v []string) {
+	h.putStringList(c, "api-keys", func(v []string) {
 		h.cfg.APIKeys = append([]string(nil), v...)
 	}, nil)
 }
 func (h *Handler) PatchAPIKeys(c *gin.Context) {
-	h.patchStringList(c, &h.cfg.APIKeys, func() {})
+	h.patchStringList(c, "api-keys", &h.cfg.APIKeys, func() {})
 }
 func (h *Handler) DeleteAPIKeys(c *gin.Context) {
 	h.deleteFromStringList(c, &h.cfg.APIKeys, func() {})
@@ -120,7 +135,7 @@ func (h *Handler) DeleteAPIKeys(c *gin.Context) {
 
 // gemini-api-key: []GeminiKey
 func (h *Handler) GetGeminiKeys(c *gin.Context) {
-	c.JSON(200, gin.H{"gemini-api-key": h.cfg.GeminiKey})
+	c.JSON(200, h.maskSecretRefs(gin.H{"gemini-api-key": h.cfg.GeminiKey}))
 }
 func (h *Handler) PutGeminiKeys(c *gin.Context) {
 	data, err := c.GetRawData()
@@ -139,6 +154,10 @@ func (h *Handler) PutGeminiKeys(c *gin.Context) {
 		}
 		arr = obj.Items
 	}
+	if err = h.unmaskSecretRefsAt("gemini-api-key", &arr); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
 	h.cfg.GeminiKey = append([]config.GeminiKey(nil), arr...)
 	h.cfg.SanitizeGeminiKeys()
 	h.persist(c)
@@ -161,6 +180,16 @@ func (h *Handler) PatchGeminiKey(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "invalid body"})
 		return
 	}
+	if err := h.unmaskSecretRefsAt("gemini-api-key[]", body.Value); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if body.Match != nil {
+		if err := h.unmaskSecretRefsAt("gemini-api-key[].api-key", body.Match); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+	}
 	targetIndex := -1
 	if body.Index != nil && *body.Index >= 0 && *body.Index < len(h.cfg.GeminiKey) {
 		targetIndex = *body.Index
@@ -243,7 +272,7 @@ func (h *Handler) DeleteGeminiKey(c *gin.Context) {
 
 // claude-api-key: []ClaudeKey
 func (h *Handler) GetClaudeKeys(c *gin.Context) {
-	c.JSON(200, gin.H{"claude-api-key": h.cfg.ClaudeKey})
+	c.JSON(200, h.maskSecretRefs(gin.H{"claude-api-key": h.cfg.ClaudeKey}))
 }
 func (h *Handler) PutClaudeKeys(c *gin.Context) {
 	data, err := c.GetRawData()
@@ -262,6 +291,10 @@ func (h *Handler) PutClaudeKeys(c *gin.Context) {
 		}
 		arr = obj.Items
 	}
+	if err = h.unmaskSecretRefsAt("claude-api-key", &arr); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
 	for i := range arr {
 		normalizeClaudeKey(&arr[i])
 	}
@@ -288,6 +321,16 @@ func (h *Handler) PatchClaudeKey(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "invalid body"})
 		return
 	}
+	if err := h.unmaskSecretRefsAt("claude-api-key[]", body.Value); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if body.Match != nil {
+		if err := h.unmaskSecretRefsAt("claude-api-key[].api-key", body.Match); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+	}
 	targetIndex := -1
 	if body.Index != nil && *body.Index >= 0 && *body.Index < len(h.cfg.ClaudeKey) {
 		targetIndex = *body.Index
@@ -362,7 +405,7 @@ func (h *Handler) DeleteClaudeKey(c *gin.Context) {
 
 // openai-compatibility: []OpenAICompatibility
 func (h *Handler) GetOpenAICompat(c *gin.Context) {
-	c.JSON(200, gin.H{"openai-compatibility": normalizedOpenAICompatibilityEntries(h.cfg.OpenAICompatibility)})
+	c.JSON(200, h.maskSecretRefs(gin.H{"openai-compatibility": normalizedOpenAICompatibilityEntries(h.cfg.OpenAICompatibility)}))
 }
 func (h *Handler) PutOpenAICompat(c *gin.Context) {
 	data, err := c.GetRawData()
@@ -381,6 +424,10 @@ func (h *Handler) PutOpenAICompat(c *gin.Context) {
 		}
 		arr = obj.Items
 	}
+	if err = h.unmaskSecretRefsAt("openai-compatibility", &arr); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
 	filtered := make([]config.OpenAICompatibility, 0, len(arr))
 	for i := range arr {
 		normalizeOpenAICompatibilityEntry(&arr[i])
@@ -410,6 +457,10 @@ func (h *Handler) PatchOpenAICompat(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "invalid body"})
 		return
 	}
+	if err := h.unmaskSecretRefsAt("openai-compatibility[]", body.Value); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
 	targetIndex := -1
 	if body.Index != nil && *body.Index >= 0 && *body.Index < len(h.cfg.OpenAICompatibility) {
 		targetIndex = *body.Index
@@ -488,7 +539,7 @@ func (h *Handler) DeleteOpenAICompat(c *gin.Context) {
 
 // vertex-api-key: []VertexCompatKey
 func (h *Handler) GetVertexCompatKeys(c *gin.Context) {
-	c.JSON(200, gin.H{"vertex-api-key": h.cfg.VertexCompatAPIKey})
+	c.JSON(200, h.maskSecretRefs(gin.H{"vertex-api-key": h.cfg.VertexCompatAPIKey}))
 }
 func (h *Handler) PutVertexCompatKeys(c *gin.Context) {
 	data, err := c.GetRawData()
@@ -507,6 +558,10 @@ func (h *Handler) PutVertexCompatKeys(c *gin.Context) {
 		}
 		arr = obj.Items
 	}
+	if err = h.unmaskSecretRefsAt("vertex-api-key", &arr); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
 	for i := range arr {
 		normalizeVertexCompatKey(&arr[i])
 		if arr[i].APIKey == "" {
@@ -537,6 +592,16 @@ func (h *Handler) PatchVertexCompatKey(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "invalid body"})
 		return
 	}
+	if errUnmask := h.unmaskSecretRefsAt("vertex-api-key[]", body.Value); errUnmask != nil {
+		c.JSON(400, gin.H{"error": errUnmask.Error()})
+		return
+	}
+	if body.Match != nil {
+		if errUnmask := h.unmaskSecretRefsAt("vertex-api-key[].api-key", body.Match); errUnmask != nil {
+			c.JSON(400, gin.H{"error": errUnmask.Error()})
+			return
+		}
+	}
 	targetIndex := -1
 	if body.Index != nil && *body.Index >= 0 && *body.Index < len(h.cfg.VertexCompatAPIKey) {
 		targetIndex = *body.Index
@@ -813,7 +878,7 @@ func (h *Handler) DeleteOAuthModelAlias(c *gin.Context) {
 
 // codex-api-key: []CodexKey
 func (h *Handler) GetCodexKeys(c *gin.Context) {
-	c.JSON(200, gin.H{"codex-api-key": h.cfg.CodexKey})
+	c.JSON(200, h.maskSecretRefs(gin.H{"codex-api-key": h.cfg.CodexKey}))
 }
 func (h *Handler) PutCodexKeys(c *gin.Context) {
 	data, err := c.GetRawData()
@@ -832,6 +897,10 @@ func (h *Handler) PutCodexKeys(c *gin.Context) {
 		}
 		arr = obj.Items
 	}
+	if err = h.unmaskSecretRefsAt("codex-api-key", &arr); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
 	// Filter out codex entries with empty base-url (treat as removed)
 	filtered := make([]config.CodexKey, 0, len(arr))
 	for i := range arr {
@@ -865,6 +934,16 @@ func (h *Handler) PatchCodexKey(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "invalid body"})
 		return
 	}
+	if err := h.unmaskSecretRefsAt("codex-api-key[]", body.Value); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if body.Match != nil {
+		if err := h.unmaskSecretRefsAt("codex-api-key[].api-key", body.Match); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+	}
 	targetIndex := -1
 	if body.Index != nil && *body.Index >= 0 && *body.Index < len(h.cfg.CodexKey) {
 		targetIndex = *body.Index
@@ -1082,7 +1161,7 @@ func (h *Handler) GetAmpCode(c *gin.Context) {
 		c.JSON(200, gin.H{"ampcode": config.AmpCode{}})
 		return
 	}
-	c.JSON(200, gin.H{"ampcode": h.cfg.AmpCode})
+	c.JSON(200, h.maskSecretRefs(gin.H{"ampcode": h.cfg.AmpCode}))
 }
 
 // GetAmpUpstreamURL returns the ampcode upstream URL.
@@ -1096,7 +1175,7 @@ func (h *Handler) GetAmpUpstreamURL(c *gin.Context) {
 
 // PutAmpUpstreamURL updates the ampcode upstream URL.
 func (h *Handler) PutAmpUpstreamURL(c *gin.Context) {
-	h.updateStringField(c, func(v string) { h.cfg.AmpCode.UpstreamURL = strings.TrimSpace(v) })
+	h.updateStringField(c, "ampcode.upstream-url", func(v string) { h.cfg.AmpCode.UpstreamURL = strings.TrimSpace(v) })
 }
 
 // DeleteAmpUpstreamURL clears the ampcode upstream URL.
@@ -1111,12 +1190,12 @@ func (h *Handler) GetAmpUpstreamAPIKey(c *gin.Context) {
 		c.JSON(200, gin.H{"upstream-api-key": ""})
 		return
 	}
-	c.JSON(200, gin.H{"upstream-api-key": h.cfg.AmpCode.UpstreamAPIKey})
+	c.JSON(200, h.maskSecretRefsAt("ampcode", gin.H{"upstream-api-key": h.cfg.AmpCode.UpstreamAPIKey}))
 }
 
 // PutAmpUpstreamAPIKey updates the ampcode upstream API key.
 func (h *Handler) PutAmpUpstreamAPIKey(c *gin.Context) {
-	h.updateStringField(c, func(v string) { h.cfg.AmpCode.UpstreamAPIKey = strings.TrimSpace(v) })
+	h.updateStringField(c, "ampcode.upstream-api-key", func(v string) { h.cfg.AmpCode.UpstreamAPIKey = strings.TrimSpace(v) })
 }
 
 // DeleteAmpUpstreamAPIKey clears the ampcode upstream API key.
@@ -1234,7 +1313,7 @@ func (h *Handler) GetAmpUpstreamAPIKeys(c *gin.Context) {
 		c.JSON(200, gin.H{"upstream-api-keys": []config.AmpUpstreamAPIKeyEntry{}})
 		return
 	}
-	c.JSON(200, gin.H{"upstream-api-keys": h.cfg.AmpCode.UpstreamAPIKeys})
+	c.JSON(200, h.maskSecretRefsAt("ampcode", gin.H{"upstream-api-keys": h.cfg.AmpCode.UpstreamAPIKeys}))
 }
 
 // PutAmpUpstreamAPIKeys replaces all ampcode upstream API keys mappings.
@@ -1246,6 +1325,10 @@ func (h *Handler) PutAmpUpstreamAPIKeys(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "invalid body"})
 		return
 	}
+	if err := h.unmaskSecretRefsAt("ampcode.upstream-api-keys", &body.Value); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
 	// Normalize entries: trim whitespace, filter empty
 	normalized := normalizeAmpUpstreamAPIKeyEntries(body.Value)
 	h.cfg.AmpCode.UpstreamAPIKeys = normalized
@@ -1262,6 +1345,10 @@ func (h *Handler) PatchAmpUpstreamAPIKeys(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "invalid body"})
 		return
 	}
+	if err := h.unmaskSecretRefsAt("ampcode.upstream-api-keys", &body.Value); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
 
 	existing := make(map[string]int)
 	for i, entry := range h.cfg.AmpCode.UpstreamAPIKeys {
@@ -0,0 +1,105 @@
+package management
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func serveManagement(handler gin.HandlerFunc, method string, body []byte) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(rec)
+	ctx.Request = httptest.NewRequest(method, "/v0/management/test", bytes.NewReader(body))
+	ctx.Request.Header.Set("Content-Type", "application/json")
+	handler(ctx)
+	return rec
+}
+
+func TestSecretRefs_GetPutRoundTrip(t *testing.T) {
+	t.Setenv("MANAGEMENT_PASSWORD", "")
+	t.Setenv("CLIPROXY_TEST_ROUNDTRIP_CLAUDE", "sk-claude-from-env")
+	t.Setenv(config.SecretRefSourcesEnv, "file")
+	gin.SetMode(gin.TestMode)
+
+	dir := t.TempDir()
+	secretPath := filepath.Join(dir, "client-key")
+	if err := os.WriteFile(secretPath, []byte("client-key-from-file\n"), 0o600); err != nil {
+		t.Fatalf("write secret file: %v", err)
+	}
+	configPath := filepath.Join(dir, "config.yaml")
+	yamlData := `api-keys:
+  - "${file:` + secretPath + `}"
+claude-api-key:
+  - api-key: "${env:CLIPROXY_TEST_ROUNDTRIP_CLAUDE}"
+    base-url: "https://claude.example.com"
+`
+	if err := os.WriteFile(configPath, []byte(yamlData), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	h := NewHandler(cfg, configPath, nil)
+
+	var claude struct {
+		Keys json.RawMessage `json:"claude-api-key"`
+	}
+	if err = json.Unmarshal(serveManagement(h.GetClaudeKeys, http.MethodGet, nil).Body.Bytes(), &claude); err != nil {
+		t.Fatalf("decode claude keys: %v", err)
+	}
+	if !strings.Contains(string(claude.Keys), "${env:CLIPROXY_TEST_ROUNDTRIP_CLAUDE}") {
+		t.Fatalf("GET did not mask the claude key: %s", claude.Keys)
+	}
+	if rec := serveManagement(h.PutClaudeKeys, http.MethodPut, claude.Keys); rec.Code != http.StatusOK {
+		t.Fatalf("PUT claude keys status = %d, body %s", rec.Code, rec.Body.String())
+	}
+	if got := h.cfg.ClaudeKey[0].APIKey; got != "sk-claude-from-env" {
+		t.Fatalf("live claude api-key = %q, want the resolved value", got)
+	}
+
+	var apiKeys struct {
+		Keys json.RawMessage `json:"api-keys"`
+	}
+	if err = json.Unmarshal(serveManagement(h.GetAPIKeys, http.MethodGet, nil).Body.Bytes(), &apiKeys); err != nil {
+		t.Fatalf("decode api keys: %v", err)
+	}
+	if rec := serveManagement(h.PutAPIKeys, http.MethodPut, apiKeys.Keys); rec.Code != http.StatusOK {
+		t.Fatalf("PUT api keys status = %d, body %s", rec.Code, rec.Body.String())
+	}
+	if len(h.cfg.APIKeys) != 1 || h.cfg.APIKeys[0] != "client-key-from-file" {
+		t.Fatalf("live api-keys = %v, want the resolved value", h.cfg.APIKeys)
+	}
+
+	saved, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("read saved config: %v", err)
+	}
+	if strings.Contains(string(saved), "sk-claude-from-env") || strings.Contains(string(saved), "client-key-from-file") {
+		t.Fatalf("saved config leaked resolved secrets:\n%s", saved)
+	}
+	if !strings.Contains(string(saved), "${env:CLIPROXY_TEST_ROUNDTRIP_CLAUDE}") || !strings.Contains(string(saved), "${file:"+secretPath+"}") {
+		t.Fatalf("saved config lost its references:\n%s", saved)
+	}
+
+	for _, body := range []string{
+		`[{"api-key":"${env:CLIPROXY_TEST_ROUNDTRIP_OTHER}","base-url":"https://claude.example.com"}]`,
+		`[{"api-key":"${cmd:id}","base-url":"https://claude.example.com"}]`,
+		`[{"api-key":"sk-claude-from-env","base-url":"${env:CLIPROXY_TEST_ROUNDTRIP_CLAUDE}"}]`,
+	} {
+		if rec := serveManagement(h.PutClaudeKeys, http.MethodPut, []byte(body)); rec.Code != http.StatusBadRequest {
+			t.Fatalf("PUT %s status = %d, want 400", body, rec.Code)
+		}
+		if got := h.cfg.ClaudeKey[0]; got.APIKey != "sk-claude-from-env" || got.BaseURL != "https://claude.example.com" {
+			t.Fatalf("rejected PUT changed the live config: %+v", got)
+		}
+	}
+}
@@ -310,7 +310,9 @@ func (h *Handler) updateIntField(c *gin.Context, set func(int)) {
 	h.persist(c)
 }
 
-func (h *Handler) updateStringField(c *gin.Context, set func(string)) {
+// updateStringField sets the string config value at path. Path locates the value for secret
+// reference checks (e.g. "ampcode.upstream-api-key").
+func (h *Handler) updateStringField(c *gin.Context, path string, set func(string)) {
 	var body struct {
 		Value *string `json:"value"`
 	}
@@ -318,6 +320,10 @@ func (h *Handler) updateStringField(c *gin.Context, set func(string)) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
 		return
 	}
+	if err := h.unmaskSecretRefsAt(path, body.Value); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	set(*body.Value)
 	h.persist(c)
 }
@@ -3,6 +3,7 @@ package management
 import (
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
@@ -31,3 +32,29 @@ func (h *Handler) GetStaticModelDefinitions(c *gin.Context) {
 		"models":  models,
 	})
 }
+
+// GetModelLifecycle lists deprecated and retired models and the retirements due within the
+// configured warning window.
+func (h *Handler) GetModelLifecycle(c *gin.Context) {
+	now := time.Now()
+	warnDays := 30
+	if h.cfg != nil && h.cfg.ModelLifecycle.WarnDays > 0 {
+		warnDays = h.cfg.ModelLifecycle.WarnDays
+	}
+	models := registry.ModelLifecycles(now)
+	horizon := now.AddDate(0, 0, warnDays)
+	upcoming := make([]registry.ModelLifecycle, 0)
+	for _, model := range models {
+		if model.State != registry.LifecycleRetired && !model.RetireAt.IsZero() && model.RetireAt.Before(horizon) {
+			upcoming = append(upcoming, model)
+		}
+	}
+	if models == nil {
+		models = []registry.ModelLifecycle{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"warn-days": warnDays,
+		"models":    models,
+		"upcoming":  upcoming,
+	})
+}
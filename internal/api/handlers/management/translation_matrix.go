@@ -0,0 +1,25 @@
+package management
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+// GetTranslationMatrix lists the optional request features of every source format and, for
+// each registered source->target translator, which of them it carries over or drops.
+func (h *Handler) GetTranslationMatrix(c *gin.Context) {
+	pairs := sdktranslator.TranslationMatrix()
+	features := make(map[string][]sdktranslator.Feature)
+	for _, pair := range pairs {
+		from := pair.From.String()
+		if _, ok := features[from]; !ok {
+			features[from] = sdktranslator.Features(pair.From)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"features": features,
+		"pairs":    pairs,
+	})
+}
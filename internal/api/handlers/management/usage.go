@@ -3,6 +3,8 @@ package management
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -77,3 +79,61 @@ func (h *Handler) ImportUsageStatistics(c *gin.Context) {
 		"failed_requests": snapshot.FailureCount,
 	})
 }
+
+// QueryUsageEvents queries the durable usage event store.
+// Query parameters: from/to (RFC3339, YYYY-MM-DD or unix seconds), group_by (comma separated
+// list of key, model, provider, auth, source, day, hour), page and page_size.
+func (h *Handler) QueryUsageEvents(c *gin.Context) {
+	store := usage.ActiveEventStore()
+	if store == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "usage store not configured"})
+		return
+	}
+
+	var query usage.EventQuery
+	var err error
+	if query.From, err = parseUsageQueryTime(c.Query("from")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from"})
+		return
+	}
+	if query.To, err = parseUsageQueryTime(c.Query("to")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to"})
+		return
+	}
+	if raw := strings.TrimSpace(c.Query("group_by")); raw != "" {
+		query.GroupBy = strings.Split(raw, ",")
+	}
+	if raw := strings.TrimSpace(c.Query("page")); raw != "" {
+		if query.Page, err = strconv.Atoi(raw); err != nil || query.Page < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid page"})
+			return
+		}
+	}
+	if raw := strings.TrimSpace(c.Query("page_size")); raw != "" {
+		if query.PageSize, err = strconv.Atoi(raw); err != nil || query.PageSize < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid page_size"})
+			return
+		}
+	}
+
+	result, err := store.Query(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func parseUsageQueryTime(raw string) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	if seconds, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	if ts, err := time.Parse(time.RFC3339, raw); err == nil {
+		return ts, nil
+	}
+	return time.ParseInLocation("2006-01-02", raw, time.Local)
+}
@@ -12,6 +12,8 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
 )
 
 const requestBodyOverrideContextKey = "REQUEST_BODY_OVERRIDE"
@@ -294,6 +296,9 @@ func (w *ResponseWriterWrapper) Finalize(c *gin.Context) error {
 		}
 
 		w.streamWriter.SetFirstChunkTimestamp(w.firstChunkTimestamp)
+		if metaWriter, ok := w.streamWriter.(logging.MetadataStreamingLogWriter); ok {
+			metaWriter.SetMetadata(w.requestMetadata(c))
+		}
 
 		// Write API Request and Response to the streaming log before closing
 		apiRequest := w.extractAPIRequest(c)
@@ -312,7 +317,7 @@ func (w *ResponseWriterWrapper) Finalize(c *gin.Context) error {
 		return nil
 	}
 
-	return w.logRequest(w.extractRequestBody(c), finalStatusCode, w.cloneHeaders(), w.body.Bytes(), w.extractAPIRequest(c), w.extractAPIResponse(c), w.extractAPIResponseTimestamp(c), slicesAPIResponseError, forceLog)
+	return w.logRequest(c, w.extractRequestBody(c), finalStatusCode, w.cloneHeaders(), w.body.Bytes(), w.extractAPIRequest(c), w.extractAPIResponse(c), w.extractAPIResponseTimestamp(c), slicesAPIResponseError, forceLog)
 }
 
 func (w *ResponseWriterWrapper) cloneHeaders() map[string][]string {
@@ -363,6 +368,36 @@ func (w *ResponseWriterWrapper) extractAPIResponseTimestamp(c *gin.Context) time
 	return time.Time{}
 }
 
+// requestMetadata collects the client key name and the usage record stored by the executor.
+// Keys authenticated by a provider that does not name them are logged masked.
+func (w *ResponseWriterWrapper) requestMetadata(c *gin.Context) logging.RequestMetadata {
+	var meta logging.RequestMetadata
+	if c == nil {
+		return meta
+	}
+	if metadata, exists := c.Get("accessMetadata"); exists {
+		if values, ok := metadata.(map[string]string); ok {
+			meta.ClientKey = values["key_name"]
+		}
+	}
+	if apiKey, exists := c.Get("apiKey"); exists && meta.ClientKey == "" {
+		if key, ok := apiKey.(string); ok && key != "" {
+			meta.ClientKey = util.HideAPIKey(key)
+		}
+	}
+	if value, exists := c.Get(logging.UsageRecordContextKey); exists {
+		if record, ok := value.(coreusage.Record); ok {
+			meta.Model = record.Model
+			meta.Provider = record.Provider
+			meta.AuthID = record.AuthID
+			meta.AuthIndex = record.AuthIndex
+			detail := record.Detail
+			meta.Usage = &detail
+		}
+	}
+	return meta
+}
+
 func (w *ResponseWriterWrapper) extractRequestBody(c *gin.Context) []byte {
 	if c != nil {
 		if bodyOverride, isExist := c.Get(requestBodyOverrideContextKey); isExist {
@@ -384,11 +419,31 @@ func (w *ResponseWriterWrapper) extractRequestBody(c *gin.Context) []byte {
 	return nil
 }
 
-func (w *ResponseWriterWrapper) logRequest(requestBody []byte, statusCode int, headers map[string][]string, body []byte, apiRequestBody, apiResponseBody []byte, apiResponseTimestamp time.Time, apiResponseErrors []*interfaces.ErrorMessage, forceLog bool) error {
+func (w *ResponseWriterWrapper) logRequest(c *gin.Context, requestBody []byte, statusCode int, headers map[string][]string, body []byte, apiRequestBody, apiResponseBody []byte, apiResponseTimestamp time.Time, apiResponseErrors []*interfaces.ErrorMessage, forceLog bool) error {
 	if w.requestInfo == nil {
 		return nil
 	}
 
+	if loggerWithMetadata, ok := w.logger.(logging.MetadataRequestLogger); ok {
+		return loggerWithMetadata.LogRequestWithMetadata(
+			w.requestMetadata(c),
+			w.requestInfo.URL,
+			w.requestInfo.Method,
+			w.requestInfo.Headers,
+			requestBody,
+			statusCode,
+			headers,
+			body,
+			apiRequestBody,
+			apiResponseBody,
+			apiResponseErrors,
+			forceLog,
+			w.requestInfo.RequestID,
+			w.requestInfo.Timestamp,
+			apiResponseTimestamp,
+		)
+	}
+
 	if loggerWithOptions, ok := w.logger.(interface {
 		LogRequestWithOptions(string, string, map[string][]string, []byte, int, map[string][]string, []byte, []byte, []byte, []*interfaces.ErrorMessage, bool, string, time.Time, time.Time) error
 	}); ok {
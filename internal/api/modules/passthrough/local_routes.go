@@ -0,0 +1,228 @@
+package passthrough
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers/claude"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers/gemini"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers/openai"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// localRoute is a compiled passthrough local route. segments holds the literal path segments;
+// wildcard names the trailing catch-all parameter, if any.
+type localRoute struct {
+	method   string
+	segments []string
+	wildcard string
+	handler  string
+}
+
+// localHandlers returns our API handlers keyed by their passthrough handler name.
+func localHandlers(base *handlers.BaseAPIHandler) map[string]gin.HandlerFunc {
+	openaiHandlers := openai.NewOpenAIAPIHandler(base)
+	responsesHandlers := openai.NewOpenAIResponsesAPIHandler(base)
+	claudeHandlers := claude.NewClaudeCodeAPIHandler(base)
+	geminiHandlers := gemini.NewGeminiAPIHandler(base)
+	return map[string]gin.HandlerFunc{
+		config.PassthroughHandlerOpenAIChat:        openaiHandlers.ChatCompletions,
+		config.PassthroughHandlerOpenAICompletions: openaiHandlers.Completions,
+		config.PassthroughHandlerOpenAIResponses:   responsesHandlers.Responses,
+		config.PassthroughHandlerOpenAIModels:      openaiHandlers.OpenAIModels,
+		config.PassthroughHandlerClaudeMessages:    claudeHandlers.ClaudeMessages,
+		config.PassthroughHandlerClaudeCountTokens: claudeHandlers.ClaudeCountTokens,
+		config.PassthroughHandlerClaudeModels:      claudeHandlers.ClaudeModels,
+		config.PassthroughHandlerGemini:            geminiHandlers.GeminiHandler,
+		config.PassthroughHandlerGeminiModels:      geminiHandlers.GeminiModels,
+	}
+}
+
+func compileLocalRoutes(routes []config.PassthroughLocalRoute) []localRoute {
+	out := make([]localRoute, 0, len(routes))
+	for _, route := range routes {
+		compiled := localRoute{method: route.Method, handler: route.Handler}
+		for _, segment := range strings.Split(strings.Trim(route.Path, "/"), "/") {
+			if strings.HasPrefix(segment, "*") {
+				compiled.wildcard = strings.TrimPrefix(segment, "*")
+				break
+			}
+			if segment != "" {
+				compiled.segments = append(compiled.segments, segment)
+			}
+		}
+		out = append(out, compiled)
+	}
+	return out
+}
+
+// matchLocalRoute returns the first route matching method and path, together with the value of
+// its wildcard parameter.
+func matchLocalRoute(routes []localRoute, method, path string) (localRoute, gin.Params, bool) {
+	var parts []string
+	if trimmed := strings.Trim(path, "/"); trimmed != "" {
+		parts = strings.Split(trimmed, "/")
+	}
+	for _, route := range routes {
+		if route.method != method || len(parts) < len(route.segments) {
+			continue
+		}
+		matched := true
+		for i, segment := range route.segments {
+			if parts[i] != segment {
+				matched = false
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+		rest := parts[len(route.segments):]
+		if route.wildcard == "" {
+			if len(rest) == 0 {
+				return route, nil, true
+			}
+			continue
+		}
+		if len(rest) == 0 {
+			continue
+		}
+		return route, gin.Params{{Key: route.wildcard, Value: strings.Join(rest, "/")}}, true
+	}
+	return localRoute{}, nil, false
+}
+
+// serveLocal serves a request through handler when its model, or the mapping of its model, is
+// available locally, and forwards it to the upstream otherwise. Without an upstream the handler
+// runs anyway so the client receives our error response.
+func (m *Module) serveLocal(c *gin.Context, state *moduleState, route localRoute, handler gin.HandlerFunc) {
+	if c.Request.Method == http.MethodGet {
+		handler(c)
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		log.Errorf("passthrough module %s: failed to read request body: %v", state.entry.Name, err)
+		handler(c)
+		return
+	}
+	restoreBody := func() { c.Request.Body = io.NopCloser(bytes.NewReader(body)) }
+	restoreBody()
+
+	requested := requestModel(body, c, route)
+	if requested == "" {
+		handler(c)
+		return
+	}
+
+	baseModel := thinking.ParseSuffix(requested).ModelName
+	mapped := ""
+	var providers []string
+	if state.entry.ForceModelMappings {
+		mapped, providers = m.resolveMapping(requested, baseModel)
+		if mapped == "" {
+			providers = util.GetProviderName(baseModel)
+		}
+	} else {
+		providers = util.GetProviderName(baseModel)
+		if len(providers) == 0 {
+			mapped, providers = m.resolveMapping(requested, baseModel)
+		}
+	}
+
+	if len(providers) == 0 {
+		if state.proxy != nil {
+			log.Debugf("passthrough module %s: no local provider for model %s, forwarding upstream", state.entry.Name, requested)
+			m.serveProxy(c, state)
+			return
+		}
+		log.Warnf("passthrough module %s: no provider available for model %s", state.entry.Name, requested)
+		handler(c)
+		return
+	}
+
+	if mapped == "" {
+		handler(c)
+		return
+	}
+
+	log.Debugf("passthrough module %s: model mapping %s -> %s", state.entry.Name, requested, mapped)
+	body = rewriteRequestModel(body, c, route, mapped)
+	restoreBody()
+	if state.entry.DisableResponseRewrite {
+		handler(c)
+		return
+	}
+	rewriter := newResponseRewriter(c.Writer, requested)
+	c.Writer = rewriter
+	handler(c)
+	rewriter.flush()
+}
+
+// resolveMapping applies the module's model mappings, keeping the thinking suffix of the request
+// unless the target sets its own. It returns the mapped model and its providers.
+func (m *Module) resolveMapping(requested, baseModel string) (string, []string) {
+	mapped := strings.TrimSpace(m.modelMapper.MapModel(requested))
+	if mapped == "" {
+		mapped = strings.TrimSpace(m.modelMapper.MapModel(baseModel))
+	}
+	if mapped == "" {
+		return "", nil
+	}
+	if suffix := thinking.ParseSuffix(requested); suffix.HasSuffix && !thinking.ParseSuffix(mapped).HasSuffix {
+		mapped += "(" + suffix.RawSuffix + ")"
+	}
+	providers := util.GetProviderName(thinking.ParseSuffix(mapped).ModelName)
+	if len(providers) == 0 {
+		return "", nil
+	}
+	return mapped, providers
+}
+
+// requestModel extracts the requested model from the JSON body or, for the gemini handler, from
+// the model:method wildcard parameter.
+func requestModel(body []byte, c *gin.Context, route localRoute) string {
+	if route.handler == config.PassthroughHandlerGemini {
+		action := strings.TrimPrefix(c.Param(route.wildcard), "/")
+		if idx := strings.Index(action, ":"); idx > 0 {
+			return action[:idx]
+		}
+		return ""
+	}
+	if result := gjson.GetBytes(body, "model"); result.Type == gjson.String {
+		return result.String()
+	}
+	return ""
+}
+
+// rewriteRequestModel points the request at the mapped model.
+func rewriteRequestModel(body []byte, c *gin.Context, route localRoute, mapped string) []byte {
+	if route.handler == config.PassthroughHandlerGemini {
+		for i := range c.Params {
+			if c.Params[i].Key != route.wildcard {
+				continue
+			}
+			action := strings.TrimPrefix(c.Params[i].Value, "/")
+			if idx := strings.Index(action, ":"); idx > 0 {
+				c.Params[i].Value = mapped + action[idx:]
+			}
+		}
+		return body
+	}
+	updated, err := sjson.SetBytes(body, "model", mapped)
+	if err != nil {
+		log.Warnf("passthrough module: failed to rewrite model in request body: %v", err)
+		return body
+	}
+	return updated
+}
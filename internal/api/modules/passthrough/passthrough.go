@@ -0,0 +1,183 @@
+// Package passthrough implements config-driven routing modules that proxy a vendor API under a
+// mount path while serving selected routes through our own providers, generalizing the Amp
+// module to arbitrary upstreams.
+package passthrough
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/api/modules"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/api/modules/amp"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	log "github.com/sirupsen/logrus"
+)
+
+// Module implements modules.RouteModuleV2 for one passthrough-modules entry. A module is bound to
+// its mount path for its whole lifetime; configuration updates replace the upstream, credentials,
+// local routes and model mappings in place.
+type Module struct {
+	mountPath    string
+	modelMapper  *amp.DefaultModelMapper
+	handlers     map[string]gin.HandlerFunc
+	state        atomic.Pointer[moduleState]
+	registerOnce sync.Once
+}
+
+// moduleState is the hot-reloadable part of a module.
+type moduleState struct {
+	entry  config.PassthroughModule
+	proxy  *httputil.ReverseProxy
+	routes []localRoute
+	// queryParam is the browser-auth query parameter that may carry the client key.
+	queryParam string
+}
+
+// New creates the module serving mountPath. The module stays disabled until Register or
+// OnConfigUpdated sees an enabled entry with the same mount path.
+func New(mountPath string) *Module {
+	m := &Module{
+		mountPath:   mountPath,
+		modelMapper: amp.NewModelMapper(nil),
+	}
+	m.state.Store(&moduleState{entry: config.PassthroughModule{Disable: true, MountPath: mountPath}})
+	return m
+}
+
+// Name returns the module identifier.
+func (m *Module) Name() string {
+	return "passthrough:" + m.mountPath
+}
+
+// MountPath returns the route prefix served by the module.
+func (m *Module) MountPath() string {
+	return m.mountPath
+}
+
+// Enabled reports whether the module currently serves requests.
+func (m *Module) Enabled() bool {
+	return !m.state.Load().entry.Disable
+}
+
+// Register attaches the module's catch-all routes under its mount path. Routes are registered
+// once; a conflict with an existing route is reported as an error instead of a panic.
+func (m *Module) Register(ctx modules.Context) (err error) {
+	m.registerOnce.Do(func() {
+		defer func() {
+			if rec := recover(); rec != nil {
+				err = fmt.Errorf("passthrough module %s: failed to register routes: %v", m.mountPath, rec)
+			}
+		}()
+
+		if m.handlers == nil {
+			m.handlers = localHandlers(ctx.BaseHandler)
+		}
+		if errUpdate := m.OnConfigUpdated(ctx.Config); errUpdate != nil {
+			err = errUpdate
+			return
+		}
+
+		chain := []gin.HandlerFunc{m.availabilityMiddleware()}
+		if ctx.AuthMiddleware != nil {
+			chain = append(chain, ctx.AuthMiddleware)
+		}
+		chain = append(chain, m.serve)
+		ctx.Engine.Any(m.mountPath, chain...)
+		ctx.Engine.Any(m.mountPath+"/*path", chain...)
+		log.Debugf("passthrough module routes registered under %s", m.mountPath)
+	})
+	return err
+}
+
+// OnConfigUpdated applies the passthrough-modules entry matching the module's mount path. When the
+// entry is gone or disabled, the module answers 404 until it is configured again.
+func (m *Module) OnConfigUpdated(cfg *config.Config) error {
+	entry, found := m.entryFrom(cfg)
+	if !found || entry.Disable {
+		if m.Enabled() {
+			log.Infof("passthrough module %s disabled", m.mountPath)
+		}
+		m.state.Store(&moduleState{entry: config.PassthroughModule{Disable: true, MountPath: m.mountPath}})
+		return nil
+	}
+
+	next := &moduleState{entry: entry, routes: compileLocalRoutes(entry.LocalRoutes), queryParam: cfg.BrowserAuth.QueryParam}
+	if entry.UpstreamURL != "" {
+		proxy, err := newReverseProxy(entry, cfg.SDKConfig)
+		if err != nil {
+			return fmt.Errorf("passthrough module %s: %w", entry.Name, err)
+		}
+		next.proxy = proxy
+	}
+	m.modelMapper.UpdateMappings(entry.ModelMappings)
+	m.state.Store(next)
+	return nil
+}
+
+func (m *Module) entryFrom(cfg *config.Config) (config.PassthroughModule, bool) {
+	if cfg == nil {
+		return config.PassthroughModule{}, false
+	}
+	for _, entry := range cfg.PassthroughModules {
+		if entry.MountPath == m.mountPath {
+			return entry, true
+		}
+	}
+	return config.PassthroughModule{}, false
+}
+
+// availabilityMiddleware answers 404 while the module is disabled, before authentication runs.
+func (m *Module) availabilityMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !m.Enabled() {
+			logging.SkipGinRequestLogging(c)
+			c.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+		c.Next()
+	}
+}
+
+// serve dispatches a request to a local route when one matches, and to the upstream otherwise.
+func (m *Module) serve(c *gin.Context) {
+	state := m.state.Load()
+	rest := strings.TrimPrefix(c.Request.URL.Path, m.mountPath)
+	if rest == "" {
+		rest = "/"
+	}
+	if route, params, ok := matchLocalRoute(state.routes, c.Request.Method, rest); ok {
+		if handler := m.handlers[route.handler]; handler != nil {
+			// Local parameters shadow the mount's own catch-all parameter.
+			c.Params = append(params, c.Params...)
+			m.serveLocal(c, state, route, handler)
+			return
+		}
+	}
+	m.serveProxy(c, state)
+}
+
+// serveProxy forwards the request to the upstream, or answers 503 without one.
+func (m *Module) serveProxy(c *gin.Context, state *moduleState) {
+	if state.proxy == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": fmt.Sprintf("%s upstream proxy not available", state.entry.Name)})
+		return
+	}
+	// ReverseProxy panics with ErrAbortHandler when the client goes away mid-stream.
+	defer func() {
+		if rec := recover(); rec != nil {
+			if err, ok := rec.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				return
+			}
+			panic(rec)
+		}
+	}()
+	stripClientCredentials(c, state.queryParam)
+	state.proxy.ServeHTTP(c.Writer, c.Request)
+}
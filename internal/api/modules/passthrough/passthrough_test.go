@@ -0,0 +1,276 @@
+package passthrough
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/api/modules"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+)
+
+type upstreamCall struct {
+	path   string
+	query  string
+	header http.Header
+	body   string
+}
+
+func newTestUpstream(t *testing.T) (*httptest.Server, *[]upstreamCall) {
+	t.Helper()
+	var calls []upstreamCall
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		calls = append(calls, upstreamCall{path: r.URL.Path, query: r.URL.RawQuery, header: r.Header.Clone(), body: string(body)})
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"from":"upstream"}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+// echoModelHandler answers with the model it received.
+func echoModelHandler(c *gin.Context) {
+	var req struct {
+		Model string `json:"model"`
+	}
+	_ = c.ShouldBindJSON(&req)
+	c.JSON(http.StatusOK, gin.H{"model": req.Model, "seen_model": req.Model})
+}
+
+func newTestModule(t *testing.T, cfg *config.Config) (*Module, *gin.Engine) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	cfg.SanitizePassthroughModules()
+	engine := gin.New()
+	module := New(cfg.PassthroughModules[0].MountPath)
+	module.handlers = map[string]gin.HandlerFunc{
+		config.PassthroughHandlerOpenAIChat: echoModelHandler,
+		config.PassthroughHandlerGemini: func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"action": c.Param("action")})
+		},
+	}
+	auth := func(c *gin.Context) {
+		c.Set("apiKey", "client-key")
+		c.Next()
+	}
+	if err := modules.RegisterModule(modules.Context{Engine: engine, Config: cfg, AuthMiddleware: auth}, module); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	return module, engine
+}
+
+// closeNotifyRecorder satisfies the http.CloseNotifier check ReverseProxy performs through gin.
+type closeNotifyRecorder struct {
+	*httptest.ResponseRecorder
+}
+
+func (closeNotifyRecorder) CloseNotify() <-chan bool { return make(chan bool) }
+
+func doRequest(engine *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer client-key")
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(closeNotifyRecorder{rec}, req)
+	return rec
+}
+
+func TestPassthroughProxiesWithInjectedCredentials(t *testing.T) {
+	upstream, calls := newTestUpstream(t)
+	cfg := &config.Config{PassthroughModules: []config.PassthroughModule{{
+		MountPath:      "vendor/",
+		UpstreamURL:    upstream.URL + "/base",
+		UpstreamAPIKey: "vendor-key",
+		Headers:        map[string]string{"X-Vendor-Client": "cliproxy"},
+	}}}
+	_, engine := newTestModule(t, cfg)
+
+	rec := doRequest(engine, http.MethodGet, "/vendor/v1/account?key=client-key&page=2", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body.String())
+	}
+	if len(*calls) != 1 {
+		t.Fatalf("expected 1 upstream call, got %d", len(*calls))
+	}
+	call := (*calls)[0]
+	if call.path != "/base/v1/account" {
+		t.Errorf("upstream path = %q", call.path)
+	}
+	if call.query != "page=2" {
+		t.Errorf("upstream query = %q, want client key removed", call.query)
+	}
+	if got := call.header.Get("Authorization"); got != "Bearer vendor-key" {
+		t.Errorf("Authorization = %q", got)
+	}
+	if got := call.header.Get("X-Vendor-Client"); got != "cliproxy" {
+		t.Errorf("X-Vendor-Client = %q", got)
+	}
+}
+
+func TestPassthroughUsesConfiguredProxy(t *testing.T) {
+	var proxied []string
+	forwardProxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"from":"proxy"}`))
+	}))
+	t.Cleanup(forwardProxy.Close)
+
+	cfg := &config.Config{PassthroughModules: []config.PassthroughModule{{
+		MountPath:   "/vendor",
+		UpstreamURL: "http://vendor.invalid/base",
+	}}}
+	cfg.ProxyURL = forwardProxy.URL
+	_, engine := newTestModule(t, cfg)
+
+	rec := doRequest(engine, http.MethodGet, "/vendor/v1/account", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body.String())
+	}
+	if len(proxied) != 1 || proxied[0] != "http://vendor.invalid/base/v1/account" {
+		t.Fatalf("proxied requests = %v, want the upstream request routed through proxy-url", proxied)
+	}
+}
+
+func TestPassthroughForwardsOnlyAllowedHeaders(t *testing.T) {
+	upstream, calls := newTestUpstream(t)
+	cfg := &config.Config{PassthroughModules: []config.PassthroughModule{{
+		MountPath:      "vendor/",
+		UpstreamURL:    upstream.URL,
+		UpstreamAPIKey: "vendor-key",
+		ForwardHeaders: []string{"x-vendor-trace"},
+	}}}
+	cfg.BrowserAuth.QueryParam = "cpa_key"
+	_, engine := newTestModule(t, cfg)
+
+	req := httptest.NewRequest(http.MethodGet, "/vendor/v1/events?cpa_key=client-key&page=2", nil)
+	req.Header.Set("Cookie", "cpa_key=client-key")
+	req.Header.Set("Authorization", "Bearer client-key")
+	req.Header.Set("Proxy-Authorization", "Basic client-key")
+	req.Header.Set("X-Vendor-Trace", "trace-1")
+	req.Header.Set("X-Other", "dropped")
+	req.Header.Set("Accept", "text/event-stream")
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(closeNotifyRecorder{rec}, req)
+	if rec.Code != http.StatusOK || len(*calls) != 1 {
+		t.Fatalf("status = %d, calls = %d", rec.Code, len(*calls))
+	}
+	call := (*calls)[0]
+	if call.query != "page=2" {
+		t.Errorf("upstream query = %q, want browser-auth key removed", call.query)
+	}
+	for _, name := range []string{"Cookie", "Proxy-Authorization", "X-Other"} {
+		if got := call.header.Get(name); got != "" {
+			t.Errorf("%s forwarded: %q", name, got)
+		}
+	}
+	if got := call.header.Get("Authorization"); got != "Bearer vendor-key" {
+		t.Errorf("Authorization = %q", got)
+	}
+	if call.header.Get("X-Vendor-Trace") != "trace-1" || call.header.Get("Accept") != "text/event-stream" {
+		t.Errorf("allowed headers missing: %v", call.header)
+	}
+}
+
+func TestPassthroughLocalRoutesServeAvailableModelsLocally(t *testing.T) {
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient("test-client-passthrough", "codex", []*registry.ModelInfo{
+		{ID: "passthrough-local-model", OwnedBy: "openai", Type: "codex"},
+	})
+	defer reg.UnregisterClient("test-client-passthrough")
+
+	upstream, calls := newTestUpstream(t)
+	cfg := &config.Config{PassthroughModules: []config.PassthroughModule{{
+		MountPath:   "/vendor",
+		UpstreamURL: upstream.URL,
+		LocalRoutes: []config.PassthroughLocalRoute{
+			{Path: "/v1/chat/completions", Handler: config.PassthroughHandlerOpenAIChat},
+			{Path: "/v1beta/models/*action", Handler: config.PassthroughHandlerGemini},
+		},
+		ModelMappings: []config.AmpModelMapping{{From: "vendor-large", To: "passthrough-local-model"}},
+	}}}
+	_, engine := newTestModule(t, cfg)
+
+	rec := doRequest(engine, http.MethodPost, "/vendor/v1/chat/completions", `{"model":"passthrough-local-model"}`)
+	if got := rec.Body.String(); got != `{"model":"passthrough-local-model","seen_model":"passthrough-local-model"}` {
+		t.Errorf("local response = %s", got)
+	}
+
+	rec = doRequest(engine, http.MethodPost, "/vendor/v1/chat/completions", `{"model":"vendor-large(high)"}`)
+	var mapped struct {
+		Model     string `json:"model"`
+		SeenModel string `json:"seen_model"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &mapped); err != nil {
+		t.Fatalf("decode mapped response: %v (%s)", err, rec.Body.String())
+	}
+	if mapped.SeenModel != "passthrough-local-model(high)" || mapped.Model != "vendor-large(high)" {
+		t.Errorf("mapped response = %+v", mapped)
+	}
+
+	rec = doRequest(engine, http.MethodPost, "/vendor/v1beta/models/vendor-large:generateContent", `{}`)
+	if got := rec.Body.String(); got != `{"action":"passthrough-local-model:generateContent"}` {
+		t.Errorf("gemini response = %s", got)
+	}
+
+	if len(*calls) != 0 {
+		t.Fatalf("expected no upstream calls, got %d", len(*calls))
+	}
+	rec = doRequest(engine, http.MethodPost, "/vendor/v1/chat/completions", `{"model":"vendor-only"}`)
+	if got := rec.Body.String(); got != `{"from":"upstream"}` {
+		t.Errorf("unavailable model response = %s", got)
+	}
+	if len(*calls) != 1 || (*calls)[0].body != `{"model":"vendor-only"}` {
+		t.Errorf("expected unavailable model to be proxied unchanged, got %+v", *calls)
+	}
+}
+
+func TestPassthroughConfigUpdate(t *testing.T) {
+	upstream, calls := newTestUpstream(t)
+	cfg := &config.Config{PassthroughModules: []config.PassthroughModule{{MountPath: "/vendor"}}}
+	module, engine := newTestModule(t, cfg)
+
+	if rec := doRequest(engine, http.MethodGet, "/vendor/v1/account", ""); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status without upstream = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+
+	updated := &config.Config{PassthroughModules: []config.PassthroughModule{{MountPath: "/vendor", UpstreamURL: upstream.URL}}}
+	updated.SanitizePassthroughModules()
+	if err := module.OnConfigUpdated(updated); err != nil {
+		t.Fatalf("OnConfigUpdated: %v", err)
+	}
+	if rec := doRequest(engine, http.MethodGet, "/vendor/v1/account", ""); rec.Code != http.StatusOK || len(*calls) != 1 {
+		t.Errorf("status after adding upstream = %d, calls %d", rec.Code, len(*calls))
+	}
+
+	if err := module.OnConfigUpdated(&config.Config{}); err != nil {
+		t.Fatalf("OnConfigUpdated: %v", err)
+	}
+	if rec := doRequest(engine, http.MethodGet, "/vendor/v1/account", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("status after removal = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestSanitizePassthroughModulesRejectsReservedMountPaths(t *testing.T) {
+	cfg := &config.Config{PassthroughModules: []config.PassthroughModule{
+		{MountPath: "/v1"},
+		{MountPath: "/api/vendor"},
+		{MountPath: "/vendor/*path"},
+		{MountPath: " /vendor/ "},
+		{MountPath: "/vendor"},
+	}}
+	cfg.SanitizePassthroughModules()
+	if len(cfg.PassthroughModules) != 1 {
+		t.Fatalf("expected 1 module, got %+v", cfg.PassthroughModules)
+	}
+	entry := cfg.PassthroughModules[0]
+	if entry.MountPath != "/vendor" || entry.Name != "/vendor" || entry.AuthHeader != "Authorization" || entry.AuthScheme != "Bearer" {
+		t.Errorf("unexpected sanitized entry %+v", entry)
+	}
+}
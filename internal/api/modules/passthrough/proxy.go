@@ -0,0 +1,165 @@
+package passthrough
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/proxyutil"
+	log "github.com/sirupsen/logrus"
+)
+
+// newReverseProxy creates the reverse proxy for entry. The mount path is stripped from the
+// request path and the upstream credentials and headers are injected. Upstream connections honor
+// the global proxy-url and upstream-tls settings, like the provider executors.
+func newReverseProxy(entry config.PassthroughModule, sdkCfg config.SDKConfig) (*httputil.ReverseProxy, error) {
+	parsed, err := url.Parse(entry.UpstreamURL)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid upstream url %q", entry.UpstreamURL)
+	}
+
+	mountPath := entry.MountPath
+	credential := entry.UpstreamAPIKey
+	if credential != "" && entry.AuthScheme != "" {
+		credential = entry.AuthScheme + " " + credential
+	}
+
+	forwarded := make(map[string]struct{}, len(forwardedHeaders)+len(entry.ForwardHeaders))
+	for _, name := range forwardedHeaders {
+		forwarded[name] = struct{}{}
+	}
+	for _, name := range entry.ForwardHeaders {
+		forwarded[http.CanonicalHeaderKey(strings.TrimSpace(name))] = struct{}{}
+	}
+
+	transport, _, errTransport := proxyutil.BuildHTTPTransportWithTLS(sdkCfg.ProxyURL, util.UpstreamTLSOptions(sdkCfg.UpstreamTLS))
+	if errTransport != nil {
+		return nil, errTransport
+	}
+
+	proxy := httputil.NewSingleHostReverseProxy(parsed)
+	if transport != nil {
+		proxy.Transport = transport
+	}
+	originalDirector := proxy.Director
+	proxy.Director = func(req *http.Request) {
+		req.URL.Path = strings.TrimPrefix(req.URL.Path, mountPath)
+		req.URL.RawPath = ""
+		originalDirector(req)
+		req.Host = parsed.Host
+
+		req.Header = allowedHeaders(req.Header, forwarded)
+		for name, value := range entry.Headers {
+			req.Header.Set(name, value)
+		}
+		if credential != "" {
+			req.Header.Set(entry.AuthHeader, credential)
+		}
+	}
+
+	proxy.ModifyResponse = func(resp *http.Response) error {
+		if resp.StatusCode >= 500 {
+			log.Errorf("passthrough module %s: upstream responded with error [%d]", entry.Name, resp.StatusCode)
+		}
+		return nil
+	}
+
+	proxy.ErrorHandler = func(rw http.ResponseWriter, req *http.Request, err error) {
+		if errors.Is(err, context.Canceled) {
+			return
+		}
+		log.Errorf("passthrough module %s: upstream proxy error for %s %s: %v", entry.Name, req.Method, req.URL.Path, err)
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusBadGateway)
+		_, _ = rw.Write([]byte(`{"error":"passthrough_upstream_proxy_error","message":"Failed to reach upstream"}`))
+	}
+
+	return proxy, nil
+}
+
+// forwardedHeaders are the client headers passed to the upstream by default: content negotiation,
+// caching, protocol upgrades and vendor API versioning. Credentials and cookies are never listed.
+var forwardedHeaders = []string{
+	"Accept",
+	"Accept-Encoding",
+	"Accept-Language",
+	"Anthropic-Beta",
+	"Anthropic-Version",
+	"Cache-Control",
+	"Connection",
+	"Content-Encoding",
+	"Content-Length",
+	"Content-Type",
+	"Idempotency-Key",
+	"If-Match",
+	"If-Modified-Since",
+	"If-None-Match",
+	"Last-Event-Id",
+	"Openai-Beta",
+	"Range",
+	"Sec-Websocket-Extensions",
+	"Sec-Websocket-Key",
+	"Sec-Websocket-Protocol",
+	"Sec-Websocket-Version",
+	"Te",
+	"Upgrade",
+	"User-Agent",
+}
+
+// allowedHeaders returns the headers of header whose canonical name is in allowed.
+func allowedHeaders(header http.Header, allowed map[string]struct{}) http.Header {
+	out := make(http.Header, len(allowed))
+	for name, values := range header {
+		if _, ok := allowed[http.CanonicalHeaderKey(name)]; ok {
+			out[name] = values
+		}
+	}
+	return out
+}
+
+// stripClientCredentials removes the client's proxy API key from the query so it never reaches the
+// upstream; headers are filtered by the proxy's allowlist. Query credentials, including the
+// browser-auth queryParam, are only removed when they match the authenticated key.
+func stripClientCredentials(c *gin.Context, queryParam string) {
+	req := c.Request
+	clientKey := c.GetString("apiKey")
+	if clientKey == "" || req.URL.RawQuery == "" {
+		return
+	}
+	query := req.URL.Query()
+	changed := false
+	names := []string{"key", "auth_token"}
+	if queryParam != "" {
+		names = append(names, queryParam)
+	}
+	for _, name := range names {
+		values, ok := query[name]
+		if !ok {
+			continue
+		}
+		kept := values[:0]
+		for _, value := range values {
+			if value != clientKey {
+				kept = append(kept, value)
+			}
+		}
+		if len(kept) != len(values) {
+			changed = true
+			if len(kept) == 0 {
+				query.Del(name)
+			} else {
+				query[name] = kept
+			}
+		}
+	}
+	if changed {
+		req.URL.RawQuery = query.Encode()
+	}
+}
@@ -0,0 +1,102 @@
+package passthrough
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// maxBufferedResponseBytes caps buffering of non-streaming responses before the rewriter
+// switches to rewriting chunks as they are written.
+const maxBufferedResponseBytes = 2 * 1024 * 1024
+
+// modelFieldPaths lists the JSON paths that carry the model name in responses of every format.
+var modelFieldPaths = []string{"model", "message.model", "modelVersion", "response.model", "response.modelVersion"}
+
+// responseRewriter restores the model the client requested in responses produced for a mapped
+// model. JSON responses are buffered and rewritten on flush; SSE responses are rewritten line by
+// line as they stream.
+type responseRewriter struct {
+	gin.ResponseWriter
+	body      bytes.Buffer
+	model     string
+	streaming bool
+}
+
+func newResponseRewriter(w gin.ResponseWriter, model string) *responseRewriter {
+	return &responseRewriter{ResponseWriter: w, model: model}
+}
+
+// Write buffers JSON bodies and rewrites SSE chunks immediately.
+func (rw *responseRewriter) Write(data []byte) (int, error) {
+	if !rw.streaming && rw.body.Len() == 0 && strings.Contains(rw.Header().Get("Content-Type"), "text/event-stream") {
+		rw.streaming = true
+	}
+	if !rw.streaming && rw.body.Len()+len(data) > maxBufferedResponseBytes {
+		log.Warnf("passthrough response rewriter: buffer exceeded %d bytes, switching to streaming", maxBufferedResponseBytes)
+		rw.streaming = true
+		if rw.body.Len() > 0 {
+			buffered := rw.rewriteChunk(rw.body.Bytes())
+			rw.body.Reset()
+			if _, err := rw.ResponseWriter.Write(buffered); err != nil {
+				return 0, err
+			}
+		}
+	}
+	if !rw.streaming {
+		return rw.body.Write(data)
+	}
+	if _, err := rw.ResponseWriter.Write(rw.rewriteChunk(data)); err != nil {
+		return 0, err
+	}
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	// Report the caller's length; the rewritten chunk may differ in size.
+	return len(data), nil
+}
+
+// WriteString routes string writes through Write so they are rewritten too.
+func (rw *responseRewriter) WriteString(s string) (int, error) {
+	return rw.Write([]byte(s))
+}
+
+// flush writes the rewritten buffered body.
+func (rw *responseRewriter) flush() {
+	if rw.streaming || rw.body.Len() == 0 {
+		return
+	}
+	if _, err := rw.ResponseWriter.Write(rw.rewriteJSON(rw.body.Bytes())); err != nil {
+		log.Warnf("passthrough response rewriter: failed to write rewritten response: %v", err)
+	}
+}
+
+func (rw *responseRewriter) rewriteJSON(data []byte) []byte {
+	for _, path := range modelFieldPaths {
+		if gjson.GetBytes(data, path).Exists() {
+			data, _ = sjson.SetBytes(data, path, rw.model)
+		}
+	}
+	return data
+}
+
+// rewriteChunk rewrites the JSON payload of every "data:" line in an SSE chunk.
+func (rw *responseRewriter) rewriteChunk(chunk []byte) []byte {
+	lines := bytes.Split(chunk, []byte("\n"))
+	for i, line := range lines {
+		payload, ok := bytes.CutPrefix(line, []byte("data:"))
+		if !ok {
+			continue
+		}
+		payload = bytes.TrimLeft(payload, " ")
+		if len(payload) > 0 && payload[0] == '{' {
+			lines[i] = append([]byte("data: "), rw.rewriteJSON(payload)...)
+		}
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
@@ -24,8 +24,10 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/api/middleware"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/api/modules"
 	ampmodule "github.com/router-for-me/CLIProxyAPI/v6/internal/api/modules/amp"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/api/modules/passthrough"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/auth/kiro"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/filestore"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/managementasset"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
@@ -33,6 +35,7 @@ import (
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers/claude"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers/files"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers/gemini"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers/openai"
 	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
@@ -61,6 +64,15 @@ type ServerOption func(*serverOptionConfig)
 func defaultRequestLoggerFactory(cfg *config.Config, configPath string) logging.RequestLogger {
 	configDir := filepath.Dir(configPath)
 	logsDir := logging.ResolveLogDirectory(cfg)
+	if cfg.StructuredRequestLog.Enable {
+		var upload logging.SegmentUploader
+		if uploader, ok := sdkAuth.GetTokenStore().(interface {
+			PutRequestLogSegment(context.Context, string, []byte) error
+		}); ok {
+			upload = uploader.PutRequestLogSegment
+		}
+		return logging.NewStructuredRequestLogger(cfg.RequestLog, logsDir, configDir, cfg.StructuredRequestLog, upload)
+	}
 	return logging.NewFileRequestLogger(cfg.RequestLog, logsDir, configDir, cfg.ErrorLogsMaxFiles)
 }
 
@@ -156,12 +168,20 @@ type Server struct {
 	wsAuthChanged func(bool, bool)
 	wsAuthEnabled atomic.Bool
 
+	// cors holds the per-route-group CORS policies, swapped on config reload.
+	cors *corsPolicies
+
 	// management handler
 	mgmt *managementHandlers.Handler
 
 	// ampModule is the Amp routing module for model mapping hot-reload
 	ampModule *ampmodule.AmpModule
 
+	// passthroughModules holds the passthrough-modules routing modules keyed by mount path.
+	// Modules are never unregistered; removed entries are disabled in place.
+	passthroughMu      sync.Mutex
+	passthroughModules map[string]*passthrough.Module
+
 	// managementRoutesRegistered tracks whether the management routes have been attached to the engine.
 	managementRoutesRegistered atomic.Bool
 	// managementRoutesEnabled controls whether management endpoints serve real handlers.
@@ -230,7 +250,8 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 		}
 	}
 
-	engine.Use(corsMiddleware())
+	cors := newCORSPolicies(cfg.CORS)
+	engine.Use(cors.middleware())
 	wd, err := os.Getwd()
 	if err != nil {
 		wd = configFilePath
@@ -248,6 +269,7 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 		accessManager:       accessManager,
 		requestLogger:       requestLogger,
 		loggerToggle:        toggle,
+		cors:                cors,
 		configFilePath:      configFilePath,
 		currentPath:         wd,
 		envManagementSecret: envManagementSecret,
@@ -262,6 +284,7 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	}
 	managementasset.SetCurrentConfig(cfg)
 	auth.SetQuotaCooldownDisabled(cfg.DisableCooling)
+	configureFileStore(cfg)
 	// Initialize management handler
 	s.mgmt = managementHandlers.NewHandler(cfg, configFilePath, authManager)
 	if optionState.localPassword != "" {
@@ -289,6 +312,9 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 		log.Errorf("Failed to register Amp module: %v", err)
 	}
 
+	// Register passthrough modules declared in the configuration.
+	s.syncPassthroughModules(cfg)
+
 	// Apply additional router configurators from options
 	if optionState.routerConfigurator != nil {
 		optionState.routerConfigurator(engine, s.handlers, cfg)
@@ -329,6 +355,8 @@ func (s *Server) setupRoutes() {
 	geminiCLIHandlers := gemini.NewGeminiCLIAPIHandler(s.handlers)
 	claudeCodeHandlers := claude.NewClaudeCodeAPIHandler(s.handlers)
 	openaiResponsesHandlers := openai.NewOpenAIResponsesAPIHandler(s.handlers)
+	openaiAudioHandlers := openai.NewOpenAIAudioAPIHandler(s.handlers)
+	filesHandlers := files.NewFilesAPIHandler(s.handlers)
 
 	// OpenAI compatible API routes
 	v1 := s.engine.Group("/v1")
@@ -342,6 +370,13 @@ func (s *Server) setupRoutes() {
 		v1.GET("/responses", openaiResponsesHandlers.ResponsesWebsocket)
 		v1.POST("/responses", openaiResponsesHandlers.Responses)
 		v1.POST("/responses/compact", openaiResponsesHandlers.Compact)
+		v1.POST("/audio/transcriptions", openaiAudioHandlers.AudioTranscriptions)
+		v1.POST("/audio/speech", openaiAudioHandlers.AudioSpeech)
+		v1.POST("/files", filesHandlers.Upload)
+		v1.GET("/files", filesHandlers.List)
+		v1.GET("/files/:id", filesHandlers.Get)
+		v1.GET("/files/:id/content", filesHandlers.Content)
+		v1.DELETE("/files/:id", filesHandlers.Delete)
 	}
 
 	// Gemini compatible API routes
@@ -351,6 +386,19 @@ func (s *Server) setupRoutes() {
 		v1beta.GET("/models", geminiHandlers.GeminiModels)
 		v1beta.POST("/models/*action", geminiHandlers.GeminiHandler)
 		v1beta.GET("/models/*action", geminiHandlers.GeminiGetHandler)
+		v1beta.POST("/cachedContents", geminiHandlers.CreateCachedContent)
+		v1beta.GET("/cachedContents", geminiHandlers.ListCachedContents)
+		v1beta.GET("/cachedContents/:id", geminiHandlers.GetCachedContent)
+		v1beta.PATCH("/cachedContents/:id", geminiHandlers.UpdateCachedContent)
+		v1beta.DELETE("/cachedContents/:id", geminiHandlers.DeleteCachedContent)
+	}
+
+	// Gemini Live websocket, served on the same path as the upstream API
+	live := s.engine.Group("/ws")
+	live.Use(AuthMiddleware(s.accessManager))
+	{
+		live.GET("/google.ai.generativelanguage.v1beta.GenerativeService.BidiGenerateContent", geminiHandlers.LiveWebsocket)
+		live.GET("/google.ai.generativelanguage.v1alpha.GenerativeService.BidiGenerateContent", geminiHandlers.LiveWebsocket)
 	}
 
 	// Root endpoint
@@ -527,6 +575,7 @@ func (s *Server) registerManagementRoutes() {
 	mgmt.Use(s.managementAvailabilityMiddleware(), s.mgmt.Middleware())
 	{
 		mgmt.GET("/usage", s.mgmt.GetUsageStatistics)
+		mgmt.GET("/usage/query", s.mgmt.QueryUsageEvents)
 		mgmt.GET("/usage/export", s.mgmt.ExportUsageStatistics)
 		mgmt.POST("/usage/import", s.mgmt.ImportUsageStatistics)
 		mgmt.GET("/config", s.mgmt.GetConfig)
@@ -663,11 +712,15 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.GET("/auth-files", s.mgmt.ListAuthFiles)
 		mgmt.GET("/auth-files/models", s.mgmt.GetAuthFileModels)
 		mgmt.GET("/model-definitions/:channel", s.mgmt.GetStaticModelDefinitions)
+		mgmt.GET("/model-lifecycle", s.mgmt.GetModelLifecycle)
+		mgmt.GET("/translation-matrix", s.mgmt.GetTranslationMatrix)
 		mgmt.GET("/auth-files/download", s.mgmt.DownloadAuthFile)
 		mgmt.POST("/auth-files", s.mgmt.UploadAuthFile)
 		mgmt.DELETE("/auth-files", s.mgmt.DeleteAuthFile)
 		mgmt.PATCH("/auth-files/status", s.mgmt.PatchAuthFileStatus)
 		mgmt.PATCH("/auth-files/fields", s.mgmt.PatchAuthFileFields)
+		mgmt.POST("/auth-files/probe", s.mgmt.ProbeAuthFiles)
+		mgmt.POST("/alerts/test", s.mgmt.TestFireAlert)
 		mgmt.POST("/vertex/import", s.mgmt.ImportVertexCredential)
 
 		mgmt.GET("/anthropic-auth-url", s.mgmt.RequestAnthropicToken)
@@ -882,31 +935,14 @@ func (s *Server) Stop(ctx context.Context) error {
 	if err := s.server.Shutdown(ctx); err != nil {
 		return fmt.Errorf("failed to shutdown HTTP server: %v", err)
 	}
+	if s.handlers != nil {
+		s.handlers.Close()
+	}
 
 	log.Debug("API server stopped")
 	return nil
 }
 
-// corsMiddleware returns a Gin middleware handler that adds CORS headers
-// to every response, allowing cross-origin requests.
-//
-// Returns:
-//   - gin.HandlerFunc: The CORS middleware handler
-func corsMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "*")
-
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(http.StatusNoContent)
-			return
-		}
-
-		c.Next()
-	}
-}
-
 func (s *Server) applyAccessConfig(oldCfg, newCfg *config.Config) {
 	if s == nil || s.accessManager == nil || newCfg == nil {
 		return
@@ -962,6 +998,10 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 		auth.SetQuotaCooldownDisabled(cfg.DisableCooling)
 	}
 
+	if oldCfg == nil || oldCfg.Files != cfg.Files || oldCfg.AuthDir != cfg.AuthDir {
+		configureFileStore(cfg)
+	}
+
 	if s.handlers != nil && s.handlers.AuthManager != nil {
 		s.handlers.AuthManager.SetRetryConfig(cfg.RequestRetry, time.Duration(cfg.MaxRetryInterval)*time.Second, cfg.MaxRetryCredentials)
 	}
@@ -1004,6 +1044,9 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 	}
 
 	s.applyAccessConfig(oldCfg, cfg)
+	if s.cors != nil {
+		s.cors.update(cfg.CORS)
+	}
 	s.cfg = cfg
 	s.wsAuthEnabled.Store(cfg.WebsocketAuth)
 	if oldCfg != nil && s.wsAuthChanged != nil && oldCfg.WebsocketAuth != cfg.WebsocketAuth {
@@ -1033,6 +1076,10 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 		}
 	}
 
+	if oldCfg == nil || !reflect.DeepEqual(oldCfg.PassthroughModules, cfg.PassthroughModules) {
+		s.syncPassthroughModules(cfg)
+	}
+
 	// Count client sources from configuration and auth store.
 	tokenStore := sdkAuth.GetTokenStore()
 	if dirSetter, ok := tokenStore.(interface{ SetBaseDir(string) }); ok {
@@ -1061,6 +1108,43 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 	)
 }
 
+// syncPassthroughModules updates the existing passthrough modules and registers a module for every
+// new mount path in cfg.
+func (s *Server) syncPassthroughModules(cfg *config.Config) {
+	s.passthroughMu.Lock()
+	defer s.passthroughMu.Unlock()
+
+	if s.passthroughModules == nil {
+		s.passthroughModules = make(map[string]*passthrough.Module)
+	}
+	for mountPath, module := range s.passthroughModules {
+		if err := module.OnConfigUpdated(cfg); err != nil {
+			log.Errorf("failed to update passthrough module %s: %v", mountPath, err)
+		}
+	}
+
+	ctx := modules.Context{
+		Engine:         s.engine,
+		BaseHandler:    s.handlers,
+		Config:         cfg,
+		AuthMiddleware: AuthMiddleware(s.accessManager),
+	}
+	for _, entry := range cfg.PassthroughModules {
+		if _, exists := s.passthroughModules[entry.MountPath]; exists {
+			continue
+		}
+		module := passthrough.New(entry.MountPath)
+		if err := modules.RegisterModule(ctx, module); err != nil {
+			log.Errorf("Failed to register passthrough module %s: %v", entry.Name, err)
+			continue
+		}
+		s.passthroughModules[entry.MountPath] = module
+		if module.Enabled() {
+			log.Infof("passthrough module %s mounted at %s", entry.Name, entry.MountPath)
+		}
+	}
+}
+
 func (s *Server) SetWebsocketAuthChangeHandler(fn func(bool, bool)) {
 	if s == nil {
 		return
@@ -1070,6 +1154,27 @@ func (s *Server) SetWebsocketAuthChangeHandler(fn func(bool, bool)) {
 
 // (management handlers moved to internal/api/handlers/management)
 
+// configureFileStore installs the backend behind /v1/files: the token store's bucket when the
+// store offers one, otherwise a directory on disk.
+func configureFileStore(cfg *config.Config) {
+	filestore.SetMaxSize(cfg.Files.MaxSizeBytes())
+	if provider, ok := sdkAuth.GetTokenStore().(interface{ FileBackend() filestore.Backend }); ok {
+		filestore.SetBackend(provider.FileBackend())
+		return
+	}
+	dir := cfg.Files.Dir
+	if dir == "" {
+		authDir, err := util.ResolveAuthDir(cfg.AuthDir)
+		if err != nil || authDir == "" {
+			log.Warnf("file store disabled: cannot resolve auth directory: %v", err)
+			filestore.SetBackend(nil)
+			return
+		}
+		dir = filepath.Join(authDir, ".files")
+	}
+	filestore.SetBackend(filestore.NewDiskBackend(dir))
+}
+
 // AuthMiddleware returns a Gin middleware handler that authenticates requests
 // using the configured authentication providers. When no providers are available,
 // it allows all requests (legacy behaviour).
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/proxyutil"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -69,6 +70,15 @@ func NewClaudeAuth(cfg *config.Config) *ClaudeAuth {
 	}
 }
 
+// NewClaudeAuthWithUpstream creates an Anthropic authentication service that connects through
+// proxyURL with the upstream TLS options tlsOpts instead of the global settings, so refreshes
+// of a credential honor its own proxy-url and upstream-tls overrides.
+func NewClaudeAuthWithUpstream(proxyURL string, tlsOpts proxyutil.TLSOptions) *ClaudeAuth {
+	return &ClaudeAuth{
+		httpClient: NewAnthropicHttpClientWithUpstream(proxyURL, tlsOpts),
+	}
+}
+
 // GenerateAuthURL creates the OAuth authorization URL with PKCE.
 // This method generates a secure authorization URL including PKCE challenge codes
 // for the OAuth2 flow with Anthropic's API.
@@ -8,6 +8,9 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+
+	tls "github.com/refraction-networking/utls"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/proxyutil"
 )
 
 // ---------------------------------------------------------------------------
@@ -310,3 +313,32 @@ func TestRefreshTokens_EmptyRefreshTokenReturnsError(t *testing.T) {
 		t.Fatal("RefreshTokens('') should return error")
 	}
 }
+
+// ---------------------------------------------------------------------------
+// Upstream TLS Tests
+// ---------------------------------------------------------------------------
+
+func TestBuildUtlsBaseConfig_AppliesUpstreamTLS(t *testing.T) {
+	t.Parallel()
+
+	base, err := buildUtlsBaseConfig(proxyutil.TLSOptions{ServerName: "claude.internal", MinVersion: "1.3"})
+	if err != nil {
+		t.Fatalf("buildUtlsBaseConfig() error = %v", err)
+	}
+	if base == nil {
+		t.Fatal("expected utls config for custom TLS options")
+	}
+	if base.ServerName != "claude.internal" {
+		t.Fatalf("server name = %q, want %q", base.ServerName, "claude.internal")
+	}
+	if base.MinVersion != tls.VersionTLS13 {
+		t.Fatalf("min version = %x, want %x", base.MinVersion, tls.VersionTLS13)
+	}
+
+	if zero, errZero := buildUtlsBaseConfig(proxyutil.TLSOptions{}); errZero != nil || zero != nil {
+		t.Fatalf("zero options = (%v, %v), want (nil, nil)", zero, errZero)
+	}
+	if _, errCA := buildUtlsBaseConfig(proxyutil.TLSOptions{CAFiles: []string{"/nonexistent/ca.pem"}}); errCA == nil {
+		t.Fatal("expected error for missing CA file")
+	}
+}
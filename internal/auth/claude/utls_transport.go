@@ -8,6 +8,7 @@ import (
 	"sync"
 
 	tls "github.com/refraction-networking/utls"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/proxyutil"
 	log "github.com/sirupsen/logrus"
@@ -26,25 +27,57 @@ type utlsRoundTripper struct {
 	pending map[string]*sync.Cond
 	// dialer is used to create network connections, supporting proxies
 	dialer proxy.Dialer
+	// tlsBase carries upstream TLS settings (custom roots, client certificate, min version)
+	tlsBase *tls.Config
 }
 
-// newUtlsRoundTripper creates a new utls-based round tripper with optional proxy support
-func newUtlsRoundTripper(cfg *config.SDKConfig) *utlsRoundTripper {
+// newUtlsRoundTripper creates a new utls-based round tripper with optional proxy and upstream TLS support
+func newUtlsRoundTripper(proxyURL string, tlsOpts proxyutil.TLSOptions) *utlsRoundTripper {
 	var dialer proxy.Dialer = proxy.Direct
-	if cfg != nil {
-		proxyDialer, mode, errBuild := proxyutil.BuildDialer(cfg.ProxyURL)
-		if errBuild != nil {
-			log.Errorf("failed to configure proxy dialer for %q: %v", cfg.ProxyURL, errBuild)
-		} else if mode != proxyutil.ModeInherit && proxyDialer != nil {
-			dialer = proxyDialer
-		}
+	var tlsBase *tls.Config
+	proxyDialer, mode, errBuild := proxyutil.BuildDialer(proxyURL)
+	if errBuild != nil {
+		log.Errorf("failed to configure proxy dialer for %q: %v", proxyURL, errBuild)
+	} else if mode != proxyutil.ModeInherit && proxyDialer != nil {
+		dialer = proxyDialer
+	}
+	base, errTLS := buildUtlsBaseConfig(tlsOpts)
+	if errTLS != nil {
+		log.Errorf("failed to configure upstream TLS: %v", errTLS)
+	} else {
+		tlsBase = base
 	}
 
 	return &utlsRoundTripper{
 		connections: make(map[string]*http2.ClientConn),
 		pending:     make(map[string]*sync.Cond),
 		dialer:      dialer,
+		tlsBase:     tlsBase,
+	}
+}
+
+// buildUtlsBaseConfig converts the configuration built by proxyutil.BuildTLSConfig into a utls
+// configuration. It returns nil when no custom setting is configured.
+func buildUtlsBaseConfig(opts proxyutil.TLSOptions) (*tls.Config, error) {
+	std, errBuild := proxyutil.BuildTLSConfig(opts)
+	if errBuild != nil || std == nil {
+		return nil, errBuild
+	}
+	base := &tls.Config{
+		RootCAs:    std.RootCAs,
+		ServerName: std.ServerName,
+		MinVersion: std.MinVersion,
 	}
+	for _, cert := range std.Certificates {
+		base.Certificates = append(base.Certificates, tls.Certificate{
+			Certificate:                 cert.Certificate,
+			PrivateKey:                  cert.PrivateKey,
+			OCSPStaple:                  cert.OCSPStaple,
+			SignedCertificateTimestamps: cert.SignedCertificateTimestamps,
+			Leaf:                        cert.Leaf,
+		})
+	}
+	return base, nil
 }
 
 // getOrCreateConnection gets an existing connection or creates a new one.
@@ -105,6 +138,12 @@ func (t *utlsRoundTripper) createConnection(host, addr string) (*http2.ClientCon
 	}
 
 	tlsConfig := &tls.Config{ServerName: host}
+	if t.tlsBase != nil {
+		tlsConfig = t.tlsBase.Clone()
+		if tlsConfig.ServerName == "" {
+			tlsConfig.ServerName = host
+		}
+	}
 	tlsConn := tls.UClient(conn, tlsConfig, tls.HelloChrome_Auto)
 
 	if err := tlsConn.Handshake(); err != nil {
@@ -156,7 +195,16 @@ func (t *utlsRoundTripper) RoundTrip(req *http.Request) (*http.Response, error)
 // for Anthropic domains by using utls with Chrome fingerprint.
 // It accepts optional SDK configuration for proxy settings.
 func NewAnthropicHttpClient(cfg *config.SDKConfig) *http.Client {
+	if cfg == nil {
+		return NewAnthropicHttpClientWithUpstream("", proxyutil.TLSOptions{})
+	}
+	return NewAnthropicHttpClientWithUpstream(cfg.ProxyURL, util.UpstreamTLSOptions(cfg.UpstreamTLS))
+}
+
+// NewAnthropicHttpClientWithUpstream creates the utls client for an explicit proxy URL and
+// upstream TLS options, such as the effective settings of a single credential.
+func NewAnthropicHttpClientWithUpstream(proxyURL string, tlsOpts proxyutil.TLSOptions) *http.Client {
 	return &http.Client{
-		Transport: newUtlsRoundTripper(cfg),
+		Transport: newUtlsRoundTripper(proxyURL, tlsOpts),
 	}
 }
@@ -0,0 +1,118 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	value  []byte
+	expire time.Time
+}
+
+// MemoryStore keeps entries in process memory. Expired entries are purged periodically.
+type MemoryStore struct {
+	mu          sync.RWMutex
+	entries     map[string]map[string]memoryEntry
+	cleanupOnce sync.Once
+	stop        chan struct{}
+	stopOnce    sync.Once
+}
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries: make(map[string]map[string]memoryEntry),
+		stop:    make(chan struct{}),
+	}
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(_ context.Context, namespace, key string) ([]byte, bool, error) {
+	s.mu.RLock()
+	entry, ok := s.entries[namespace][key]
+	s.mu.RUnlock()
+	if !ok || !entry.expire.After(time.Now()) {
+		return nil, false, nil
+	}
+	return append([]byte(nil), entry.value...), true, nil
+}
+
+// Set implements Store.
+func (s *MemoryStore) Set(_ context.Context, namespace, key string, value []byte, ttl time.Duration) error {
+	s.cleanupOnce.Do(s.startCleanup)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bucket, ok := s.entries[namespace]
+	if !ok {
+		bucket = make(map[string]memoryEntry)
+		s.entries[namespace] = bucket
+	}
+	bucket[key] = memoryEntry{value: append([]byte(nil), value...), expire: time.Now().Add(ttl)}
+	return nil
+}
+
+// Delete implements Store.
+func (s *MemoryStore) Delete(_ context.Context, namespace, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries[namespace], key)
+	return nil
+}
+
+// DeletePrefix implements Store.
+func (s *MemoryStore) DeletePrefix(_ context.Context, namespace, prefix string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if prefix == "" {
+		delete(s.entries, namespace)
+		return nil
+	}
+	for key := range s.entries[namespace] {
+		if strings.HasPrefix(key, prefix) {
+			delete(s.entries[namespace], key)
+		}
+	}
+	return nil
+}
+
+// Close stops the background cleanup.
+func (s *MemoryStore) Close() error {
+	s.stopOnce.Do(func() { close(s.stop) })
+	return nil
+}
+
+// startCleanup launches a background goroutine that periodically removes expired entries.
+func (s *MemoryStore) startCleanup() {
+	go func() {
+		ticker := time.NewTicker(CacheCleanupInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				s.purgeExpired()
+			}
+		}
+	}()
+}
+
+// purgeExpired removes expired entries and empty namespaces.
+func (s *MemoryStore) purgeExpired() {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for namespace, bucket := range s.entries {
+		for key, entry := range bucket {
+			if !entry.expire.After(now) {
+				delete(bucket, key)
+			}
+		}
+		if len(bucket) == 0 {
+			delete(s.entries, namespace)
+		}
+	}
+}
@@ -8,12 +8,6 @@ import (
 	"time"
 )
 
-// SignatureEntry holds a cached thinking signature with timestamp
-type SignatureEntry struct {
-	Signature string
-	Timestamp time.Time
-}
-
 const (
 	// SignatureCacheTTL is how long signatures are valid
 	SignatureCacheTTL = 3 * time.Hour
@@ -26,19 +20,15 @@ const (
 
 	// CacheCleanupInterval controls how often stale entries are purged
 	CacheCleanupInterval = 10 * time.Minute
-)
 
-// signatureCache stores signatures by model group -> textHash -> SignatureEntry
-var signatureCache sync.Map
+	// SignatureRefreshInterval is how long a read waits before extending a signature's TTL
+	// again, so hot signatures are not rewritten to the store on every lookup.
+	SignatureRefreshInterval = 15 * time.Minute
+)
 
-// cacheCleanupOnce ensures the background cleanup goroutine starts only once
-var cacheCleanupOnce sync.Once
-
-// groupCache is the inner map type
-type groupCache struct {
-	mu      sync.RWMutex
-	entries map[string]SignatureEntry
-}
+// signatureNamespace is the cache store namespace of thinking signatures. Keys are
+// "<model group>:<text hash>".
+const signatureNamespace = "signature"
 
 // hashText creates a stable, Unicode-safe key from text content
 func hashText(text string) string {
@@ -46,51 +36,51 @@ func hashText(text string) string {
 	return hex.EncodeToString(h[:])[:SignatureTextHashLen]
 }
 
-// getOrCreateGroupCache gets or creates a cache bucket for a model group
-func getOrCreateGroupCache(groupKey string) *groupCache {
-	// Start background cleanup on first access
-	cacheCleanupOnce.Do(startCacheCleanup)
+func signatureKey(groupKey, text string) string {
+	return groupKey + ":" + hashText(text)
+}
 
-	if val, ok := signatureCache.Load(groupKey); ok {
-		return val.(*groupCache)
+// signatureRefreshes records when this process last wrote each signature, so reads extend the
+// sliding TTL at most once per SignatureRefreshInterval.
+var signatureRefreshes = struct {
+	sync.Mutex
+	written   map[string]time.Time
+	lastPurge time.Time
+}{written: make(map[string]time.Time)}
+
+// markSignatureWritten records a write of key at now, dropping records older than the TTL
+// once per CacheCleanupInterval.
+func markSignatureWritten(key string, now time.Time) {
+	signatureRefreshes.Lock()
+	defer signatureRefreshes.Unlock()
+	signatureRefreshes.written[key] = now
+	if now.Sub(signatureRefreshes.lastPurge) < CacheCleanupInterval {
+		return
+	}
+	signatureRefreshes.lastPurge = now
+	for k, writtenAt := range signatureRefreshes.written {
+		if now.Sub(writtenAt) > SignatureCacheTTL {
+			delete(signatureRefreshes.written, k)
+		}
 	}
-	sc := &groupCache{entries: make(map[string]SignatureEntry)}
-	actual, _ := signatureCache.LoadOrStore(groupKey, sc)
-	return actual.(*groupCache)
 }
 
-// startCacheCleanup launches a background goroutine that periodically
-// removes caches where all entries have expired.
-func startCacheCleanup() {
-	go func() {
-		ticker := time.NewTicker(CacheCleanupInterval)
-		defer ticker.Stop()
-		for range ticker.C {
-			purgeExpiredCaches()
-		}
-	}()
+// signatureRefreshDue reports whether a read of key at now should extend its TTL.
+func signatureRefreshDue(key string, now time.Time) bool {
+	signatureRefreshes.Lock()
+	defer signatureRefreshes.Unlock()
+	writtenAt, ok := signatureRefreshes.written[key]
+	return !ok || now.Sub(writtenAt) >= SignatureRefreshInterval
 }
 
-// purgeExpiredCaches removes caches with no valid (non-expired) entries.
-func purgeExpiredCaches() {
-	now := time.Now()
-	signatureCache.Range(func(key, value any) bool {
-		sc := value.(*groupCache)
-		sc.mu.Lock()
-		// Remove expired entries
-		for k, entry := range sc.entries {
-			if now.Sub(entry.Timestamp) > SignatureCacheTTL {
-				delete(sc.entries, k)
-			}
-		}
-		isEmpty := len(sc.entries) == 0
-		sc.mu.Unlock()
-		// Remove cache bucket if empty
-		if isEmpty {
-			signatureCache.Delete(key)
+func forgetSignatureWrites(prefix string) {
+	signatureRefreshes.Lock()
+	defer signatureRefreshes.Unlock()
+	for k := range signatureRefreshes.written {
+		if strings.HasPrefix(k, prefix) {
+			delete(signatureRefreshes.written, k)
 		}
-		return true
-	})
+	}
 }
 
 // CacheSignature stores a thinking signature for a given model group and text.
@@ -102,17 +92,9 @@ func CacheSignature(modelName, text, signature string) {
 	if len(signature) < MinValidSignatureLen {
 		return
 	}
-
-	groupKey := GetModelGroup(modelName)
-	textHash := hashText(text)
-	sc := getOrCreateGroupCache(groupKey)
-	sc.mu.Lock()
-	defer sc.mu.Unlock()
-
-	sc.entries[textHash] = SignatureEntry{
-		Signature: signature,
-		Timestamp: time.Now(),
-	}
+	key := signatureKey(GetModelGroup(modelName), text)
+	Save(signatureNamespace, key, []byte(signature), SignatureCacheTTL)
+	markSignatureWritten(key, time.Now())
 }
 
 // GetCachedSignature retrieves a cached signature for a given model group and text.
@@ -120,62 +102,36 @@ func CacheSignature(modelName, text, signature string) {
 func GetCachedSignature(modelName, text string) string {
 	groupKey := GetModelGroup(modelName)
 
-	if text == "" {
-		if groupKey == "gemini" {
-			return "skip_thought_signature_validator"
-		}
-		return ""
+	key := signatureKey(groupKey, text)
+	var signature []byte
+	found := false
+	if text != "" {
+		signature, found = Load(signatureNamespace, key)
 	}
-	val, ok := signatureCache.Load(groupKey)
-	if !ok {
+	if !found || len(signature) == 0 {
 		if groupKey == "gemini" {
 			return "skip_thought_signature_validator"
 		}
 		return ""
 	}
-	sc := val.(*groupCache)
-
-	textHash := hashText(text)
 
-	now := time.Now()
-
-	sc.mu.Lock()
-	entry, exists := sc.entries[textHash]
-	if !exists {
-		sc.mu.Unlock()
-		if groupKey == "gemini" {
-			return "skip_thought_signature_validator"
-		}
-		return ""
+	// Sliding expiration, extended lazily: a signature read within SignatureRefreshInterval of its
+	// last write still has most of its TTL left.
+	if now := time.Now(); signatureRefreshDue(key, now) {
+		Save(signatureNamespace, key, signature, SignatureCacheTTL)
+		markSignatureWritten(key, now)
 	}
-	if now.Sub(entry.Timestamp) > SignatureCacheTTL {
-		delete(sc.entries, textHash)
-		sc.mu.Unlock()
-		if groupKey == "gemini" {
-			return "skip_thought_signature_validator"
-		}
-		return ""
-	}
-
-	// Refresh TTL on access (sliding expiration).
-	entry.Timestamp = now
-	sc.entries[textHash] = entry
-	sc.mu.Unlock()
-
-	return entry.Signature
+	return string(signature)
 }
 
 // ClearSignatureCache clears signature cache for a specific model group or all groups.
 func ClearSignatureCache(modelName string) {
-	if modelName == "" {
-		signatureCache.Range(func(key, _ any) bool {
-			signatureCache.Delete(key)
-			return true
-		})
-		return
+	prefix := ""
+	if modelName != "" {
+		prefix = GetModelGroup(modelName) + ":"
 	}
-	groupKey := GetModelGroup(modelName)
-	signatureCache.Delete(groupKey)
+	RemovePrefix(signatureNamespace, prefix)
+	forgetSignatureWrites(prefix)
 }
 
 // HasValidSignature checks if a signature is valid (non-empty and long enough)
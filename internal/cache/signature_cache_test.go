@@ -1,6 +1,8 @@
 package cache
 
 import (
+	"context"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -208,3 +210,43 @@ func TestCacheSignature_ExpirationLogic(t *testing.T) {
 	// but the logic is verified by the implementation
 	_ = time.Now() // Acknowledge we're not testing time passage
 }
+
+// countingStore counts writes to the wrapped store.
+type countingStore struct {
+	*MemoryStore
+	sets atomic.Int32
+}
+
+func (s *countingStore) Set(ctx context.Context, namespace, key string, value []byte, ttl time.Duration) error {
+	s.sets.Add(1)
+	return s.MemoryStore.Set(ctx, namespace, key, value, ttl)
+}
+
+func TestGetCachedSignature_ExtendsTTLLazily(t *testing.T) {
+	store := &countingStore{MemoryStore: NewMemoryStore()}
+	previous := SetStore(store)
+	defer func() {
+		SetStore(previous)
+		_ = store.Close()
+	}()
+	ClearSignatureCache("")
+
+	text := "hot thinking text"
+	sig := "validSig1234567890123456789012345678901234567890123456"
+	CacheSignature(testModelName, text, sig)
+	for i := 0; i < 5; i++ {
+		if got := GetCachedSignature(testModelName, text); got != sig {
+			t.Fatalf("GetCachedSignature = %q, want %q", got, sig)
+		}
+	}
+	if sets := store.sets.Load(); sets != 1 {
+		t.Fatalf("store writes = %d, want 1 (reads inside the refresh interval must not rewrite)", sets)
+	}
+
+	key := signatureKey(GetModelGroup(testModelName), text)
+	markSignatureWritten(key, time.Now().Add(-SignatureRefreshInterval))
+	GetCachedSignature(testModelName, text)
+	if sets := store.sets.Load(); sets != 2 {
+		t.Fatalf("store writes = %d, want 2 after the refresh interval", sets)
+	}
+}
@@ -0,0 +1,217 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+	_ "modernc.org/sqlite"
+)
+
+const (
+	defaultCacheTable     = "cache_entries"
+	defaultCacheStoreFile = "cache.db"
+)
+
+// SQLStore keeps entries in a SQLite or Postgres table so they survive restarts and, with
+// Postgres, are shared between replicas. Expired rows are ignored on read and purged
+// periodically.
+type SQLStore struct {
+	db       *sql.DB
+	postgres bool
+	table    string
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// OpenSQLStore connects to the configured SQLite or Postgres database and ensures the cache
+// table exists. authDir is used to place the default SQLite database file.
+func OpenSQLStore(ctx context.Context, cfg config.CacheStoreConfig, authDir string) (*SQLStore, error) {
+	table := strings.TrimSpace(cfg.Table)
+	if table == "" {
+		table = defaultCacheTable
+	}
+	store := &SQLStore{stop: make(chan struct{})}
+	var err error
+	switch strings.ToLower(strings.TrimSpace(cfg.Driver)) {
+	case config.CacheStoreSQLite, "sqlite3":
+		path := strings.TrimSpace(cfg.Path)
+		if path == "" {
+			path = filepath.Join(authDir, defaultCacheStoreFile)
+		}
+		if errMkdir := os.MkdirAll(filepath.Dir(path), 0o700); errMkdir != nil {
+			return nil, fmt.Errorf("cache store: create directory: %w", errMkdir)
+		}
+		store.db, err = sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+		if err == nil {
+			// SQLite allows a single writer; serialising through one connection avoids SQLITE_BUSY.
+			store.db.SetMaxOpenConns(1)
+		}
+		store.table = quoteIdentifier(table)
+	case config.CacheStorePostgres, "postgresql", "pgx":
+		store.postgres = true
+		dsn := strings.TrimSpace(cfg.DSN)
+		if dsn == "" {
+			dsn = lookupStoreEnv("PGSTORE_DSN")
+		}
+		if dsn == "" {
+			return nil, fmt.Errorf("cache store: postgres DSN is required (set cache-store.dsn or PGSTORE_DSN)")
+		}
+		store.db, err = sql.Open("pgx", dsn)
+		schema := strings.TrimSpace(cfg.Schema)
+		if schema == "" {
+			schema = lookupStoreEnv("PGSTORE_SCHEMA")
+		}
+		store.table = quoteIdentifier(table)
+		if schema != "" {
+			store.table = quoteIdentifier(schema) + "." + store.table
+			if err == nil {
+				if _, errSchema := store.db.ExecContext(ctx, "CREATE SCHEMA IF NOT EXISTS "+quoteIdentifier(schema)); errSchema != nil {
+					_ = store.db.Close()
+					return nil, fmt.Errorf("cache store: create schema: %w", errSchema)
+				}
+			}
+		}
+	default:
+		return nil, fmt.Errorf("cache store: unsupported driver %q", cfg.Driver)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cache store: open database: %w", err)
+	}
+	if errSchema := store.ensureSchema(ctx); errSchema != nil {
+		_ = store.db.Close()
+		return nil, errSchema
+	}
+	go store.runCleanup()
+	return store, nil
+}
+
+func lookupStoreEnv(key string) string {
+	if value, ok := os.LookupEnv(key); ok && strings.TrimSpace(value) != "" {
+		return strings.TrimSpace(value)
+	}
+	return strings.TrimSpace(os.Getenv(strings.ToLower(key)))
+}
+
+func quoteIdentifier(identifier string) string {
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
+
+func (s *SQLStore) ensureSchema(ctx context.Context) error {
+	if err := s.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("cache store: ping database: %w", err)
+	}
+	valueType := "BLOB"
+	if s.postgres {
+		valueType = "BYTEA"
+	}
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			namespace TEXT NOT NULL,
+			cache_key TEXT NOT NULL,
+			value %s NOT NULL,
+			expires_at BIGINT NOT NULL,
+			PRIMARY KEY (namespace, cache_key)
+		)
+	`, s.table, valueType)); err != nil {
+		return fmt.Errorf("cache store: create table: %w", err)
+	}
+	indexName := quoteIdentifier(strings.Trim(strings.ReplaceAll(s.table, `"."`, "_"), `"`) + "_expires_at_idx")
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (expires_at)", indexName, s.table)); err != nil {
+		return fmt.Errorf("cache store: create index: %w", err)
+	}
+	return nil
+}
+
+// Get implements Store.
+func (s *SQLStore) Get(ctx context.Context, namespace, key string) ([]byte, bool, error) {
+	var value []byte
+	err := s.db.QueryRowContext(ctx, fmt.Sprintf(
+		"SELECT value FROM %s WHERE namespace = %s AND cache_key = %s AND expires_at > %s",
+		s.table, s.placeholder(1), s.placeholder(2), s.placeholder(3)),
+		namespace, key, time.Now().UnixMilli()).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// Set implements Store.
+func (s *SQLStore) Set(ctx context.Context, namespace, key string, value []byte, ttl time.Duration) error {
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf(`
+		INSERT INTO %s (namespace, cache_key, value, expires_at) VALUES (%s, %s, %s, %s)
+		ON CONFLICT (namespace, cache_key) DO UPDATE SET value = excluded.value, expires_at = excluded.expires_at`,
+		s.table, s.placeholder(1), s.placeholder(2), s.placeholder(3), s.placeholder(4)),
+		namespace, key, value, time.Now().Add(ttl).UnixMilli())
+	return err
+}
+
+// Delete implements Store.
+func (s *SQLStore) Delete(ctx context.Context, namespace, key string) error {
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE namespace = %s AND cache_key = %s",
+		s.table, s.placeholder(1), s.placeholder(2)), namespace, key)
+	return err
+}
+
+// DeletePrefix implements Store.
+func (s *SQLStore) DeletePrefix(ctx context.Context, namespace, prefix string) error {
+	if prefix == "" {
+		_, err := s.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE namespace = %s", s.table, s.placeholder(1)), namespace)
+		return err
+	}
+	// substr avoids LIKE so prefixes containing wildcards match literally.
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE namespace = %s AND substr(cache_key, 1, %s) = %s",
+		s.table, s.placeholder(1), s.placeholder(2), s.placeholder(3)),
+		namespace, utf8.RuneCountInString(prefix), prefix)
+	return err
+}
+
+// Close stops the background cleanup and releases the database connection.
+func (s *SQLStore) Close() error {
+	if s == nil || s.db == nil {
+		return nil
+	}
+	s.stopOnce.Do(func() { close(s.stop) })
+	return s.db.Close()
+}
+
+// runCleanup periodically deletes expired rows until the store is closed.
+func (s *SQLStore) runCleanup() {
+	ticker := time.NewTicker(CacheCleanupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			if err := s.purgeExpired(context.Background()); err != nil {
+				log.Debugf("cache store: purge expired entries failed: %v", err)
+			}
+		}
+	}
+}
+
+func (s *SQLStore) purgeExpired(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE expires_at <= %s", s.table, s.placeholder(1)), time.Now().UnixMilli())
+	return err
+}
+
+func (s *SQLStore) placeholder(n int) string {
+	if s.postgres {
+		return fmt.Sprintf("$%d", n)
+	}
+	return "?"
+}
@@ -0,0 +1,149 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// storeOpTimeout bounds a single cache lookup or write so a slow shared backend degrades to a
+// cache miss instead of stalling the request.
+const storeOpTimeout = 2 * time.Second
+
+// Store is a namespaced key/value cache with per-entry expiry. Implementations never return
+// expired entries and must be safe for concurrent use.
+type Store interface {
+	// Get returns the value stored under key, or ok=false when it is missing or expired.
+	Get(ctx context.Context, namespace, key string) (value []byte, ok bool, err error)
+	// Set stores value under key until ttl elapses, replacing any previous value.
+	Set(ctx context.Context, namespace, key string, value []byte, ttl time.Duration) error
+	// Delete removes key.
+	Delete(ctx context.Context, namespace, key string) error
+	// DeletePrefix removes every key of namespace starting with prefix. An empty prefix
+	// clears the namespace.
+	DeletePrefix(ctx context.Context, namespace, prefix string) error
+	// Close releases the resources held by the store.
+	Close() error
+}
+
+var (
+	activeStore   atomic.Pointer[storeHolder]
+	activeStoreMu sync.Mutex
+	defaultStore  = sync.OnceValue(func() Store { return NewMemoryStore() })
+)
+
+type storeHolder struct {
+	store Store
+}
+
+// CurrentStore returns the configured cache store, or the process-wide in-memory store when
+// none was configured.
+func CurrentStore() Store {
+	if holder := activeStore.Load(); holder != nil {
+		return holder.store
+	}
+	return defaultStore()
+}
+
+// SetStore replaces the cache store and returns the previous one. Passing nil restores the
+// in-memory default. The caller owns closing the returned store.
+func SetStore(store Store) Store {
+	previous := CurrentStore()
+	if store == nil {
+		activeStore.Store(nil)
+	} else {
+		activeStore.Store(&storeHolder{store: store})
+	}
+	return previous
+}
+
+// OpenStore opens the backend selected by cfg. authDir is used to place the default SQLite
+// database file.
+func OpenStore(ctx context.Context, cfg config.CacheStoreConfig, authDir string) (Store, error) {
+	switch strings.ToLower(strings.TrimSpace(cfg.Driver)) {
+	case "", config.CacheStoreMemory:
+		return NewMemoryStore(), nil
+	default:
+		return OpenSQLStore(ctx, cfg, authDir)
+	}
+}
+
+// InitStore opens the configured cache store and makes it current. It is a no-op for the
+// in-memory default.
+func InitStore(cfg *config.Config) error {
+	if cfg == nil {
+		return nil
+	}
+	driver := strings.ToLower(strings.TrimSpace(cfg.CacheStore.Driver))
+	if driver == "" || driver == config.CacheStoreMemory {
+		return nil
+	}
+	activeStoreMu.Lock()
+	defer activeStoreMu.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	store, err := OpenStore(ctx, cfg.CacheStore, cfg.AuthDir)
+	if err != nil {
+		return err
+	}
+	if previous := activeStore.Swap(&storeHolder{store: store}); previous != nil {
+		_ = previous.store.Close()
+	}
+	log.Infof("cache store: using %s backend", driver)
+	return nil
+}
+
+// StopStore closes the configured cache store and falls back to the in-memory default.
+func StopStore() {
+	activeStoreMu.Lock()
+	defer activeStoreMu.Unlock()
+	if previous := activeStore.Swap(nil); previous != nil {
+		if err := previous.store.Close(); err != nil {
+			log.Warnf("cache store: close failed: %v", err)
+		}
+	}
+}
+
+// Load reads key from the current store. Backend errors are logged and reported as a miss.
+func Load(namespace, key string) ([]byte, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeOpTimeout)
+	defer cancel()
+	value, ok, err := CurrentStore().Get(ctx, namespace, key)
+	if err != nil {
+		log.Debugf("cache store: get %s failed: %v", namespace, err)
+		return nil, false
+	}
+	return value, ok
+}
+
+// Save writes key to the current store. Backend errors are logged and otherwise ignored.
+func Save(namespace, key string, value []byte, ttl time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeOpTimeout)
+	defer cancel()
+	if err := CurrentStore().Set(ctx, namespace, key, value, ttl); err != nil {
+		log.Debugf("cache store: set %s failed: %v", namespace, err)
+	}
+}
+
+// Remove deletes key from the current store. Backend errors are logged and otherwise ignored.
+func Remove(namespace, key string) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeOpTimeout)
+	defer cancel()
+	if err := CurrentStore().Delete(ctx, namespace, key); err != nil {
+		log.Debugf("cache store: delete %s failed: %v", namespace, err)
+	}
+}
+
+// RemovePrefix deletes every key of namespace starting with prefix from the current store.
+func RemovePrefix(namespace, prefix string) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeOpTimeout)
+	defer cancel()
+	if err := CurrentStore().DeletePrefix(ctx, namespace, prefix); err != nil {
+		log.Debugf("cache store: delete prefix %s failed: %v", namespace, err)
+	}
+}
@@ -0,0 +1,130 @@
+package cache
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func testStoreContract(t *testing.T, store Store) {
+	t.Helper()
+	ctx := context.Background()
+
+	if _, ok, err := store.Get(ctx, "ns", "missing"); err != nil || ok {
+		t.Fatalf("Get(missing) = ok %v, err %v; want miss", ok, err)
+	}
+	if err := store.Set(ctx, "ns", "a:1", []byte("one"), time.Hour); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := store.Set(ctx, "ns", "a:1", []byte("uno"), time.Hour); err != nil {
+		t.Fatalf("Set overwrite: %v", err)
+	}
+	if value, ok, err := store.Get(ctx, "ns", "a:1"); err != nil || !ok || string(value) != "uno" {
+		t.Fatalf("Get(a:1) = %q, %v, %v; want uno", value, ok, err)
+	}
+	if _, ok, _ := store.Get(ctx, "other", "a:1"); ok {
+		t.Fatal("expected namespaces to be isolated")
+	}
+
+	if err := store.Set(ctx, "ns", "expired", []byte("x"), -time.Second); err != nil {
+		t.Fatalf("Set expired: %v", err)
+	}
+	if _, ok, _ := store.Get(ctx, "ns", "expired"); ok {
+		t.Fatal("expected expired entry to be a miss")
+	}
+
+	_ = store.Set(ctx, "ns", "a:2", []byte("two"), time.Hour)
+	_ = store.Set(ctx, "ns", "a_%:3", []byte("three"), time.Hour)
+	_ = store.Set(ctx, "ns", "b:1", []byte("bee"), time.Hour)
+	if err := store.DeletePrefix(ctx, "ns", "a:"); err != nil {
+		t.Fatalf("DeletePrefix: %v", err)
+	}
+	for _, key := range []string{"a:1", "a:2"} {
+		if _, ok, _ := store.Get(ctx, "ns", key); ok {
+			t.Fatalf("expected %s to be removed by prefix", key)
+		}
+	}
+	for _, key := range []string{"a_%:3", "b:1"} {
+		if _, ok, _ := store.Get(ctx, "ns", key); !ok {
+			t.Fatalf("expected %s to survive prefix delete", key)
+		}
+	}
+
+	if err := store.Delete(ctx, "ns", "b:1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, ok, _ := store.Get(ctx, "ns", "b:1"); ok {
+		t.Fatal("expected b:1 to be deleted")
+	}
+	if err := store.DeletePrefix(ctx, "ns", ""); err != nil {
+		t.Fatalf("DeletePrefix(all): %v", err)
+	}
+	if _, ok, _ := store.Get(ctx, "ns", "a_%:3"); ok {
+		t.Fatal("expected namespace to be cleared")
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+	defer func() { _ = store.Close() }()
+	testStoreContract(t, store)
+}
+
+func TestSQLStore_SQLite(t *testing.T) {
+	store, err := OpenStore(context.Background(), config.CacheStoreConfig{Driver: "sqlite"}, t.TempDir())
+	if err != nil {
+		t.Fatalf("OpenStore: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	testStoreContract(t, store)
+}
+
+func TestSQLStore_SurvivesReopen(t *testing.T) {
+	cfg := config.CacheStoreConfig{Driver: "sqlite", Path: filepath.Join(t.TempDir(), "cache.db")}
+	ctx := context.Background()
+
+	first, err := OpenStore(ctx, cfg, "")
+	if err != nil {
+		t.Fatalf("OpenStore: %v", err)
+	}
+	if err = first.Set(ctx, "ns", "key", []byte("value"), time.Hour); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	_ = first.Close()
+
+	second, err := OpenStore(ctx, cfg, "")
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer func() { _ = second.Close() }()
+	if value, ok, err := second.Get(ctx, "ns", "key"); err != nil || !ok || string(value) != "value" {
+		t.Fatalf("Get after reopen = %q, %v, %v", value, ok, err)
+	}
+}
+
+func TestSignatureCache_UsesCurrentStore(t *testing.T) {
+	store, err := OpenStore(context.Background(), config.CacheStoreConfig{Driver: "sqlite"}, t.TempDir())
+	if err != nil {
+		t.Fatalf("OpenStore: %v", err)
+	}
+	previous := SetStore(store)
+	defer func() {
+		SetStore(previous)
+		_ = store.Close()
+	}()
+
+	sig := "validSig1234567890123456789012345678901234567890123456"
+	CacheSignature("claude-sonnet-4-5", "thinking text", sig)
+
+	// A second replica sharing the store sees the signature.
+	raw, ok, err := store.Get(context.Background(), signatureNamespace, signatureKey("claude", "thinking text"))
+	if err != nil || !ok || string(raw) != sig {
+		t.Fatalf("expected signature in shared store, got %q, %v, %v", raw, ok, err)
+	}
+	if got := GetCachedSignature("claude-opus-4-1", "thinking text"); got != sig {
+		t.Fatalf("GetCachedSignature = %q, want %q", got, sig)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/api"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy"
@@ -48,6 +49,14 @@ func StartService(cfg *config.Config, configPath string, localPassword string) {
 		log.Warnf("failed to init usage persistence: %v", err)
 	}
 	defer usage.StopPersistence()
+	if err := usage.InitEventStore(cfg); err != nil {
+		log.Warnf("failed to init usage store: %v", err)
+	}
+	defer usage.StopEventStore()
+	if err := cache.InitStore(cfg); err != nil {
+		log.Warnf("failed to init cache store: %v", err)
+	}
+	defer cache.StopStore()
 
 	service, err := builder.Build()
 	if err != nil {
@@ -75,11 +84,19 @@ func StartServiceBackground(cfg *config.Config, configPath string, localPassword
 	if err := usage.InitPersistence(cfg.AuthDir); err != nil {
 		log.Warnf("failed to init usage persistence: %v", err)
 	}
+	if err := usage.InitEventStore(cfg); err != nil {
+		log.Warnf("failed to init usage store: %v", err)
+	}
+	if err := cache.InitStore(cfg); err != nil {
+		log.Warnf("failed to init cache store: %v", err)
+	}
 
 	service, err := builder.Build()
 	if err != nil {
 		log.Errorf("failed to build proxy service: %v", err)
 		close(doneCh)
+		cache.StopStore()
+		usage.StopEventStore()
 		usage.StopPersistence()
 		return cancelFn, doneCh
 	}
@@ -87,6 +104,8 @@ func StartServiceBackground(cfg *config.Config, configPath string, localPassword
 	go func() {
 		defer close(doneCh)
 		defer usage.StopPersistence()
+		defer usage.StopEventStore()
+		defer cache.StopStore()
 		if err := service.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
 			log.Errorf("proxy service exited with error: %v", err)
 		}
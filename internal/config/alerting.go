@@ -0,0 +1,302 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Alert event types.
+const (
+	// AlertEventRefreshFailed fires when a credential token refresh fails.
+	AlertEventRefreshFailed = "refresh-failed"
+	// AlertEventCredentialDisabled fires when a credential is disabled or marked errored.
+	AlertEventCredentialDisabled = "credential-disabled"
+	// AlertEventProviderUnavailable fires when every credential of a provider stayed unavailable
+	// for unavailable-minutes.
+	AlertEventProviderUnavailable = "provider-unavailable"
+	// AlertEventBudgetThreshold fires when a budget crosses one of its thresholds.
+	AlertEventBudgetThreshold = "budget-threshold"
+	// AlertEventErrorRateSpike fires when a provider's error rate exceeds the configured threshold.
+	AlertEventErrorRateSpike = "error-rate-spike"
+	// AlertEventTest is emitted by the management test-fire endpoint.
+	AlertEventTest = "test"
+)
+
+// Alert sink types.
+const (
+	AlertSinkWebhook = "webhook"
+	AlertSinkSlack   = "slack"
+	AlertSinkDiscord = "discord"
+	AlertSinkSMTP    = "smtp"
+)
+
+const (
+	// DefaultAlertThrottleSeconds suppresses repeats of the same alert within this window.
+	DefaultAlertThrottleSeconds = 900
+	// DefaultAlertUnavailableMinutes is how long a provider must stay unavailable before alerting.
+	DefaultAlertUnavailableMinutes = 5
+	// DefaultAlertErrorRateWindowSeconds is the sliding window used for error-rate alerts.
+	DefaultAlertErrorRateWindowSeconds = 300
+	// DefaultAlertErrorRateMinRequests is the minimum sample size for error-rate alerts.
+	DefaultAlertErrorRateMinRequests = 20
+)
+
+// AlertingConfig configures alert sinks, routing rules and event triggers.
+type AlertingConfig struct {
+	// Sinks lists the alert destinations.
+	Sinks []AlertSink `yaml:"sinks,omitempty" json:"sinks,omitempty"`
+
+	// Rules route events to sinks. Without rules every event goes to every sink.
+	Rules []AlertRule `yaml:"rules,omitempty" json:"rules,omitempty"`
+
+	// ThrottleSeconds suppresses repeats of the same event for the same subject. Default is 900.
+	ThrottleSeconds int `yaml:"throttle-seconds,omitempty" json:"throttle-seconds,omitempty"`
+
+	// UnavailableMinutes is how long all credentials of a provider must be unavailable before
+	// provider-unavailable fires. Default is 5.
+	UnavailableMinutes int `yaml:"unavailable-minutes,omitempty" json:"unavailable-minutes,omitempty"`
+
+	// ErrorRate configures error-rate-spike alerts.
+	ErrorRate AlertErrorRate `yaml:"error-rate,omitempty" json:"error-rate,omitempty"`
+
+	// Budgets configures budget-threshold alerts.
+	Budgets []AlertBudget `yaml:"budgets,omitempty" json:"budgets,omitempty"`
+}
+
+// AlertSink is one alert destination.
+type AlertSink struct {
+	// Name identifies the sink in rules and the test-fire endpoint.
+	Name string `yaml:"name" json:"name"`
+
+	// Type is one of: webhook (generic JSON), slack, discord, smtp.
+	Type string `yaml:"type" json:"type"`
+
+	// URL is the webhook endpoint for webhook, slack and discord sinks.
+	URL string `yaml:"url,omitempty" json:"url,omitempty"`
+
+	// Headers are added to webhook requests.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// SMTPHost and SMTPPort address the mail server. Port 465 uses implicit TLS; other ports
+	// upgrade with STARTTLS when the server offers it.
+	SMTPHost string `yaml:"smtp-host,omitempty" json:"smtp-host,omitempty"`
+	SMTPPort int    `yaml:"smtp-port,omitempty" json:"smtp-port,omitempty"`
+
+	// Username and Password authenticate against the mail server (PLAIN).
+	Username string `yaml:"username,omitempty" json:"username,omitempty"`
+	Password string `yaml:"password,omitempty" json:"password,omitempty"`
+
+	// From and To are the envelope sender and recipients.
+	From string   `yaml:"from,omitempty" json:"from,omitempty"`
+	To   []string `yaml:"to,omitempty" json:"to,omitempty"`
+}
+
+// AlertRule routes matching events to sinks.
+type AlertRule struct {
+	// Events lists the event types the rule applies to. Empty matches every event.
+	Events []string `yaml:"events,omitempty" json:"events,omitempty"`
+
+	// Providers optionally narrows the rule to these providers.
+	Providers []string `yaml:"providers,omitempty" json:"providers,omitempty"`
+
+	// Sinks names the destinations for matching events.
+	Sinks []string `yaml:"sinks" json:"sinks"`
+}
+
+// AlertErrorRate configures error-rate-spike detection per provider.
+type AlertErrorRate struct {
+	// ThresholdPercent is the failure percentage that triggers the alert. 0 disables it.
+	ThresholdPercent float64 `yaml:"threshold-percent,omitempty" json:"threshold-percent,omitempty"`
+
+	// WindowSeconds is the sliding window. Default is 300.
+	WindowSeconds int `yaml:"window-seconds,omitempty" json:"window-seconds,omitempty"`
+
+	// MinRequests is the minimum number of requests in the window. Default is 20.
+	MinRequests int `yaml:"min-requests,omitempty" json:"min-requests,omitempty"`
+}
+
+// AlertBudget tracks token or request usage against a limit per period.
+type AlertBudget struct {
+	// Name labels the budget in alerts.
+	Name string `yaml:"name" json:"name"`
+
+	// Period is "day" (default) or "month", in UTC.
+	Period string `yaml:"period,omitempty" json:"period,omitempty"`
+
+	// Providers, Models and APIKeys optionally narrow the usage counted (models support "*").
+	Providers []string `yaml:"providers,omitempty" json:"providers,omitempty"`
+	Models    []string `yaml:"models,omitempty" json:"models,omitempty"`
+	APIKeys   []string `yaml:"api-keys,omitempty" json:"api-keys,omitempty"`
+
+	// MaxTokens and MaxRequests are the limits; at least one must be set.
+	MaxTokens   int64 `yaml:"max-tokens,omitempty" json:"max-tokens,omitempty"`
+	MaxRequests int64 `yaml:"max-requests,omitempty" json:"max-requests,omitempty"`
+
+	// ThresholdsPercent lists the usage percentages that fire alerts. Default is [80, 100].
+	ThresholdsPercent []float64 `yaml:"thresholds-percent,omitempty" json:"thresholds-percent,omitempty"`
+}
+
+// Matches reports whether the rule applies to the event type and provider.
+func (r AlertRule) Matches(event, provider string) bool {
+	if len(r.Events) > 0 && !containsFold(r.Events, event) {
+		return false
+	}
+	if len(r.Providers) > 0 && !containsFold(r.Providers, provider) {
+		return false
+	}
+	return true
+}
+
+// Matches reports whether a usage record falls under the budget.
+func (b AlertBudget) Matches(provider, model, apiKey string) bool {
+	if len(b.Providers) > 0 && !containsFold(b.Providers, provider) {
+		return false
+	}
+	if len(b.APIKeys) > 0 && !containsFold(b.APIKeys, apiKey) {
+		return false
+	}
+	if len(b.Models) == 0 {
+		return true
+	}
+	model = strings.ToLower(strings.TrimSpace(model))
+	for _, pattern := range b.Models {
+		if matchWildcardPattern(strings.ToLower(strings.TrimSpace(pattern)), model) {
+			return true
+		}
+	}
+	return false
+}
+
+// Throttle returns the effective throttle window in seconds.
+func (c AlertingConfig) Throttle() int {
+	if c.ThrottleSeconds <= 0 {
+		return DefaultAlertThrottleSeconds
+	}
+	return c.ThrottleSeconds
+}
+
+// UnavailableAfter returns the effective provider unavailability threshold in minutes.
+func (c AlertingConfig) UnavailableAfter() int {
+	if c.UnavailableMinutes <= 0 {
+		return DefaultAlertUnavailableMinutes
+	}
+	return c.UnavailableMinutes
+}
+
+// Window returns the effective error-rate window in seconds.
+func (r AlertErrorRate) Window() int {
+	if r.WindowSeconds <= 0 {
+		return DefaultAlertErrorRateWindowSeconds
+	}
+	return r.WindowSeconds
+}
+
+// Minimum returns the effective minimum sample size.
+func (r AlertErrorRate) Minimum() int {
+	if r.MinRequests <= 0 {
+		return DefaultAlertErrorRateMinRequests
+	}
+	return r.MinRequests
+}
+
+// Enabled reports whether any sink is configured.
+func (c AlertingConfig) Enabled() bool {
+	return len(c.Sinks) > 0
+}
+
+func containsFold(values []string, value string) bool {
+	value = strings.TrimSpace(value)
+	for _, candidate := range values {
+		if strings.EqualFold(strings.TrimSpace(candidate), value) {
+			return true
+		}
+	}
+	return false
+}
+
+// SanitizeAlerting drops sinks without a usable destination, rules referencing unknown sinks,
+// and budgets without limits, and fills budget defaults.
+func (cfg *Config) SanitizeAlerting() {
+	if cfg == nil {
+		return
+	}
+	alerting := &cfg.Alerting
+	sinks := make([]AlertSink, 0, len(alerting.Sinks))
+	known := make(map[string]struct{}, len(alerting.Sinks))
+	for _, sink := range alerting.Sinks {
+		sink.Name = strings.TrimSpace(sink.Name)
+		sink.Type = strings.ToLower(strings.TrimSpace(sink.Type))
+		sink.URL = strings.TrimSpace(sink.URL)
+		if sink.Name == "" {
+			log.Warn("alerting: sink without name ignored")
+			continue
+		}
+		if _, dup := known[sink.Name]; dup {
+			log.Warnf("alerting: duplicate sink %q ignored", sink.Name)
+			continue
+		}
+		switch sink.Type {
+		case AlertSinkWebhook, AlertSinkSlack, AlertSinkDiscord:
+			if sink.URL == "" {
+				log.Warnf("alerting: sink %q has no url, ignored", sink.Name)
+				continue
+			}
+		case AlertSinkSMTP:
+			if strings.TrimSpace(sink.SMTPHost) == "" || strings.TrimSpace(sink.From) == "" || len(sink.To) == 0 {
+				log.Warnf("alerting: smtp sink %q needs smtp-host, from and to, ignored", sink.Name)
+				continue
+			}
+			if sink.SMTPPort <= 0 {
+				sink.SMTPPort = 587
+			}
+		default:
+			log.Warnf("alerting: sink %q has unknown type %q, ignored", sink.Name, sink.Type)
+			continue
+		}
+		known[sink.Name] = struct{}{}
+		sinks = append(sinks, sink)
+	}
+	alerting.Sinks = sinks
+
+	rules := make([]AlertRule, 0, len(alerting.Rules))
+	for _, rule := range alerting.Rules {
+		targets := make([]string, 0, len(rule.Sinks))
+		for _, name := range rule.Sinks {
+			name = strings.TrimSpace(name)
+			if _, ok := known[name]; ok {
+				targets = append(targets, name)
+			} else {
+				log.Warnf("alerting: rule references unknown sink %q", name)
+			}
+		}
+		if len(targets) == 0 {
+			continue
+		}
+		rule.Sinks = targets
+		rules = append(rules, rule)
+	}
+	alerting.Rules = rules
+
+	budgets := make([]AlertBudget, 0, len(alerting.Budgets))
+	for i, budget := range alerting.Budgets {
+		if budget.MaxTokens <= 0 && budget.MaxRequests <= 0 {
+			log.Warnf("alerting: budget %q has no max-tokens or max-requests, ignored", budget.Name)
+			continue
+		}
+		budget.Name = strings.TrimSpace(budget.Name)
+		if budget.Name == "" {
+			budget.Name = fmt.Sprintf("budget-%d", i+1)
+		}
+		budget.Period = strings.ToLower(strings.TrimSpace(budget.Period))
+		if budget.Period != "month" {
+			budget.Period = "day"
+		}
+		if len(budget.ThresholdsPercent) == 0 {
+			budget.ThresholdsPercent = []float64{80, 100}
+		}
+		budgets = append(budgets, budget)
+	}
+	alerting.Budgets = budgets
+}
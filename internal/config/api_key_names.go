@@ -0,0 +1,26 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// APIKeyName returns the name configured for a client API key in api-key-names, or a short
+// fingerprint ("key-" and 12 hex digits of its SHA-256) when the key has no name. Logs and
+// external services use it to tell clients apart without seeing their keys.
+func (c *SDKConfig) APIKeyName(key string) string {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return ""
+	}
+	if c != nil {
+		for name, named := range c.APIKeyNames {
+			if strings.TrimSpace(named) == key && strings.TrimSpace(name) != "" {
+				return strings.TrimSpace(name)
+			}
+		}
+	}
+	sum := sha256.Sum256([]byte(key))
+	return "key-" + hex.EncodeToString(sum[:6])
+}
@@ -0,0 +1,46 @@
+package config
+
+import "strings"
+
+// Cache store drivers accepted by cache-store.driver.
+const (
+	CacheStoreMemory   = "memory"
+	CacheStoreSQLite   = "sqlite"
+	CacheStorePostgres = "postgres"
+)
+
+// CacheStoreConfig selects where short-lived conversation state is kept: Antigravity thinking
+// signatures, Codex prompt cache IDs and generated Claude user IDs. The in-memory default is
+// lost on restart and not shared between replicas; SQLite survives restarts and Postgres is
+// shared by every replica pointing at the same database. Changes take effect after a restart.
+type CacheStoreConfig struct {
+	// Driver selects the backend: "memory" (default), "sqlite" or "postgres".
+	Driver string `yaml:"driver,omitempty" json:"driver,omitempty"`
+
+	// Path is the SQLite database file. Defaults to cache.db inside auth-dir.
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
+
+	// DSN is the Postgres connection string. Defaults to the PGSTORE_DSN environment variable.
+	DSN string `yaml:"dsn,omitempty" json:"-"`
+
+	// Schema optionally places the cache table in a Postgres schema. Defaults to PGSTORE_SCHEMA.
+	Schema string `yaml:"schema,omitempty" json:"schema,omitempty"`
+
+	// Table overrides the cache table name. Defaults to "cache_entries".
+	Table string `yaml:"table,omitempty" json:"table,omitempty"`
+}
+
+// SanitizeCacheStore normalizes the cache store driver name.
+func (cfg *Config) SanitizeCacheStore() {
+	if cfg == nil {
+		return
+	}
+	driver := strings.ToLower(strings.TrimSpace(cfg.CacheStore.Driver))
+	switch driver {
+	case "sqlite3":
+		driver = CacheStoreSQLite
+	case "postgresql", "pgx":
+		driver = CacheStorePostgres
+	}
+	cfg.CacheStore.Driver = driver
+}
@@ -63,9 +63,21 @@ type Config struct {
 	// When exceeded, the oldest error log files are deleted. Default is 10. Set to 0 to disable cleanup.
 	ErrorLogsMaxFiles int `yaml:"error-logs-max-files" json:"error-logs-max-files"`
 
+	// StructuredRequestLog writes request logs as JSON lines with sampling, redaction and rotation.
+	StructuredRequestLog StructuredRequestLogConfig `yaml:"structured-request-log,omitempty" json:"structured-request-log,omitempty"`
+
 	// UsageStatisticsEnabled toggles in-memory usage aggregation; when false, usage data is discarded.
 	UsageStatisticsEnabled bool `yaml:"usage-statistics-enabled" json:"usage-statistics-enabled"`
 
+	// UsageStore configures a durable SQL sink that records every usage event.
+	UsageStore UsageStoreConfig `yaml:"usage-store,omitempty" json:"usage-store,omitempty"`
+
+	// CORS configures the browser-origin policy per route group.
+	CORS CORSConfig `yaml:"cors,omitempty" json:"cors,omitempty"`
+
+	// CacheStore selects the backend of the signature, Codex prompt and user ID caches.
+	CacheStore CacheStoreConfig `yaml:"cache-store,omitempty" json:"cache-store,omitempty"`
+
 	// DisableCooling disables quota cooldown scheduling when true.
 	DisableCooling bool `yaml:"disable-cooling" json:"disable-cooling"`
 
@@ -80,9 +92,25 @@ type Config struct {
 	// QuotaExceeded defines the behavior when a quota is exceeded.
 	QuotaExceeded QuotaExceeded `yaml:"quota-exceeded" json:"quota-exceeded"`
 
+	// CooldownPolicies maps upstream failures per provider (and optionally model) to cooldown,
+	// disable, model-unsupported or circuit-breaker actions, overriding the built-in handling.
+	CooldownPolicies []CooldownPolicy `yaml:"cooldown-policies,omitempty" json:"cooldown-policies,omitempty"`
+
+	// HealthProbe configures background credential validation.
+	HealthProbe HealthProbeConfig `yaml:"health-probe,omitempty" json:"health-probe,omitempty"`
+
+	// Alerting configures webhook and email alerts for credential, quota and budget events.
+	Alerting AlertingConfig `yaml:"alerting,omitempty" json:"alerting,omitempty"`
+
+	// Files configures the proxy-side store behind the Anthropic and OpenAI /v1/files endpoints.
+	Files FilesConfig `yaml:"files,omitempty" json:"files,omitempty"`
+
 	// Routing controls credential selection behavior.
 	Routing RoutingConfig `yaml:"routing" json:"routing"`
 
+	// ContextManagement shrinks conversations that exceed the target model's context window.
+	ContextManagement ContextManagementConfig `yaml:"context-management,omitempty" json:"context-management,omitempty"`
+
 	// WebsocketAuth enables or disables authentication for the WebSocket API.
 	WebsocketAuth bool `yaml:"ws-auth" json:"ws-auth"`
 
@@ -114,6 +142,9 @@ type Config struct {
 	// These are used as fallbacks when the client does not send its own headers.
 	ClaudeHeaderDefaults ClaudeHeaderDefaults `yaml:"claude-header-defaults" json:"claude-header-defaults"`
 
+	// PromptCaching controls the cache_control breakpoints added to Claude requests.
+	PromptCaching PromptCachingConfig `yaml:"prompt-caching,omitempty" json:"prompt-caching,omitempty"`
+
 	// OpenAICompatibility defines OpenAI API compatibility configurations for external providers.
 	OpenAICompatibility []OpenAICompatibility `yaml:"openai-compatibility" json:"openai-compatibility"`
 
@@ -124,6 +155,9 @@ type Config struct {
 	// AmpCode contains Amp CLI upstream configuration, management restrictions, and model mappings.
 	AmpCode AmpCode `yaml:"ampcode" json:"ampcode"`
 
+	// PassthroughModules mount reverse proxies to vendor APIs with local model routing.
+	PassthroughModules []PassthroughModule `yaml:"passthrough-modules,omitempty" json:"passthrough-modules,omitempty"`
+
 	OAuthExcludedModels map[string][]string `yaml:"oauth-excluded-models,omitempty" json:"oauth-excluded-models,omitempty"`
 
 	// OAuthProviderPriority sets a default priority for all OAuth/file-backed auth entries
@@ -149,6 +183,10 @@ type Config struct {
 	IncognitoBrowser bool `yaml:"incognito-browser" json:"incognito-browser"`
 
 	legacyMigrationPending bool `yaml:"-" json:"-"`
+
+	// secretRefs records the ${env:...}, ${file:...} or ${cmd:...} references resolved at load
+	// time by value path so secrets are never persisted or exposed in plaintext.
+	secretRefs map[secretRefKey]string `yaml:"-" json:"-"`
 }
 
 // ClaudeHeaderDefaults configures default header values injected into Claude API requests.
@@ -226,11 +264,34 @@ type QuotaExceeded struct {
 	ClaudeQuotaThresholds map[string]float64 `yaml:"claude-quota-threshold" json:"claude-quota-threshold"`
 }
 
+// UsageStoreConfig configures the durable usage event store.
+// Changes take effect after a restart.
+type UsageStoreConfig struct {
+	// Driver selects the backend: "sqlite" or "postgres". Empty disables the store.
+	Driver string `yaml:"driver,omitempty" json:"driver,omitempty"`
+
+	// Path is the SQLite database file. Defaults to usage_events.db inside auth-dir.
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
+
+	// DSN is the Postgres connection string. Defaults to the PGSTORE_DSN environment variable
+	// so the usage store can share the Postgres-backed config store database.
+	DSN string `yaml:"dsn,omitempty" json:"-"`
+
+	// Schema optionally places the usage table in a Postgres schema. Defaults to PGSTORE_SCHEMA.
+	Schema string `yaml:"schema,omitempty" json:"schema,omitempty"`
+
+	// Table overrides the usage events table name. Defaults to "usage_events".
+	Table string `yaml:"table,omitempty" json:"table,omitempty"`
+}
+
 // RoutingConfig configures how credentials are selected for requests.
 type RoutingConfig struct {
 	// Strategy selects the credential selection strategy.
 	// Supported values: "round-robin" (default), "fill-first".
 	Strategy string `yaml:"strategy,omitempty" json:"strategy,omitempty"`
+
+	// Hedging configures speculative duplicate requests for latency-critical models.
+	Hedging HedgingConfig `yaml:"hedging,omitempty" json:"hedging,omitempty"`
 }
 
 // OAuthModelAlias defines a model ID alias for a specific channel.
@@ -420,6 +481,9 @@ type ClaudeKey struct {
 	// ProxyURL overrides the global proxy setting for this API key if provided.
 	ProxyURL string `yaml:"proxy-url" json:"proxy-url"`
 
+	// UpstreamTLS overrides the global upstream-tls settings for this API key.
+	UpstreamTLS *UpstreamTLS `yaml:"upstream-tls,omitempty" json:"upstream-tls,omitempty"`
+
 	// Models defines upstream model names and aliases for request routing.
 	Models []ClaudeModel `yaml:"models" json:"models"`
 
@@ -471,6 +535,9 @@ type CodexKey struct {
 	// ProxyURL overrides the global proxy setting for this API key if provided.
 	ProxyURL string `yaml:"proxy-url" json:"proxy-url"`
 
+	// UpstreamTLS overrides the global upstream-tls settings for this API key.
+	UpstreamTLS *UpstreamTLS `yaml:"upstream-tls,omitempty" json:"upstream-tls,omitempty"`
+
 	// Models defines upstream model names and aliases for request routing.
 	Models []CodexModel `yaml:"models" json:"models"`
 
@@ -515,6 +582,9 @@ type GeminiKey struct {
 	// ProxyURL optionally overrides the global proxy for this API key.
 	ProxyURL string `yaml:"proxy-url,omitempty" json:"proxy-url,omitempty"`
 
+	// UpstreamTLS overrides the global upstream-tls settings for this API key.
+	UpstreamTLS *UpstreamTLS `yaml:"upstream-tls,omitempty" json:"upstream-tls,omitempty"`
+
 	// Models defines upstream model names and aliases for request routing.
 	Models []GeminiModel `yaml:"models,omitempty" json:"models,omitempty"`
 
@@ -610,6 +680,9 @@ type OpenAICompatibility struct {
 
 	// Headers optionally adds extra HTTP headers for requests sent to this provider.
 	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// UpstreamTLS overrides the global upstream-tls settings for this provider.
+	UpstreamTLS *UpstreamTLS `yaml:"upstream-tls,omitempty" json:"upstream-tls,omitempty"`
 }
 
 // OpenAICompatibilityAPIKey represents an API key configuration with optional proxy setting.
@@ -619,6 +692,9 @@ type OpenAICompatibilityAPIKey struct {
 
 	// ProxyURL overrides the global proxy setting for this API key if provided.
 	ProxyURL string `yaml:"proxy-url,omitempty" json:"proxy-url,omitempty"`
+
+	// UpstreamTLS overrides the provider-level upstream-tls settings for this API key.
+	UpstreamTLS *UpstreamTLS `yaml:"upstream-tls,omitempty" json:"upstream-tls,omitempty"`
 }
 
 // OpenAICompatibilityModel represents a model configuration for OpenAI compatibility,
@@ -687,13 +763,27 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	cfg.AmpCode.RestrictManagementToLocalhost = false // Default to false: API key auth is sufficient
 	cfg.RemoteManagement.PanelGitHubRepository = DefaultPanelGitHubRepository
 	cfg.IncognitoBrowser = false // Default to normal browser (AWS uses incognito by force)
-	if err = yaml.Unmarshal(data, &cfg); err != nil {
+	var root yaml.Node
+	if err = yaml.Unmarshal(data, &root); err != nil {
 		if optional {
 			// In cloud deploy mode, if YAML parsing fails, return empty config instead of error.
 			return &Config{}, nil
 		}
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
+	// Resolve ${env:...}, ${file:...} and ${cmd:...} secret references before decoding.
+	cfg.secretRefs = make(map[secretRefKey]string)
+	if err = resolveSecretRefsInNode(&root, cfg.secretRefs); err != nil {
+		return nil, fmt.Errorf("failed to resolve config secret reference: %w", err)
+	}
+	if len(root.Content) > 0 {
+		if err = root.Decode(&cfg); err != nil {
+			if optional {
+				return &Config{}, nil
+			}
+			return nil, fmt.Errorf("failed to parse config file: %w", err)
+		}
+	}
 
 	// NOTE: Startup legacy key migration is intentionally disabled.
 	// Reason: avoid mutating config.yaml during server startup.
@@ -718,11 +808,21 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 		if errHash != nil {
 			return nil, fmt.Errorf("failed to hash remote management key: %w", errHash)
 		}
-		cfg.RemoteManagement.SecretKey = hashed
+		const secretKeyPath = "remote-management.secret-key"
+		plainKey := secretRefKey{path: secretKeyPath, value: cfg.RemoteManagement.SecretKey}
+		if ref, isRef := cfg.secretRefs[plainKey]; isRef {
+			// Referenced secrets stay external: hash in memory only and keep the
+			// reference when the config is saved.
+			delete(cfg.secretRefs, plainKey)
+			cfg.secretRefs[secretRefKey{path: secretKeyPath, value: hashed}] = ref
+			cfg.RemoteManagement.SecretKey = hashed
+		} else {
+			cfg.RemoteManagement.SecretKey = hashed
 
-		// Persist the hashed value back to the config file to avoid re-hashing on next startup.
-		// Preserve YAML comments and ordering; update only the nested key.
-		_ = SaveConfigPreserveCommentsUpdateNestedScalar(configFile, []string{"remote-management", "secret-key"}, hashed)
+			// Persist the hashed value back to the config file to avoid re-hashing on next startup.
+			// Preserve YAML comments and ordering; update only the nested key.
+			_ = SaveConfigPreserveCommentsUpdateNestedScalar(configFile, []string{"remote-management", "secret-key"}, hashed)
+		}
 	}
 
 	cfg.RemoteManagement.PanelGitHubRepository = strings.TrimSpace(cfg.RemoteManagement.PanelGitHubRepository)
@@ -771,12 +871,69 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	// Sanitize OpenAI compatibility providers: drop entries without base-url
 	cfg.SanitizeOpenAICompatibility()
 
+	// Normalize upstream TLS settings (global and per-key overrides).
+	cfg.SanitizeUpstreamTLS()
+
 	// Normalize OAuth provider model exclusion map.
 	cfg.OAuthExcludedModels = NormalizeOAuthExcludedModels(cfg.OAuthExcludedModels)
 
 	// Normalize global OAuth model name aliases.
 	cfg.SanitizeOAuthModelAlias()
 
+	// Apply hedging policy defaults.
+	cfg.SanitizeHedging()
+
+	// Drop MCP servers without a name or endpoint.
+	cfg.SanitizeMCP()
+
+	// Drop hooks without a name or endpoint and apply hook defaults.
+	cfg.SanitizeHooks()
+
+	// Normalize prompt caching TTLs.
+	cfg.SanitizePromptCaching()
+
+	// Drop unknown context management policies and apply defaults.
+	cfg.SanitizeContextManagement()
+
+	// Normalize the retired model action.
+	cfg.SanitizeModelLifecycle()
+
+	// Drop fan-out models without targets and normalize their modes.
+	cfg.SanitizeFanOut()
+
+	// Clamp structured output repair retries.
+	cfg.SanitizeStructuredOutput()
+
+	// Normalize strict translation modes.
+	cfg.SanitizeStrictTranslation()
+
+	// Normalize the cache store driver.
+	cfg.SanitizeCacheStore()
+
+	// Apply structured request log defaults.
+	cfg.SanitizeStructuredRequestLog()
+
+	// Normalize CORS policies and browser auth names.
+	cfg.SanitizeCORS()
+	if err = cfg.ValidateCORS(); err != nil {
+		return nil, fmt.Errorf("invalid cors config: %w", err)
+	}
+
+	// Drop passthrough modules with missing or conflicting mount paths.
+	cfg.SanitizePassthroughModules()
+
+	// Drop cooldown policy rules with unknown actions or invalid patterns.
+	cfg.SanitizeCooldownPolicies()
+
+	// Normalize health probe providers and clamp the probe interval.
+	cfg.SanitizeHealthProbe()
+
+	// Drop unusable alert sinks, rules and budgets.
+	cfg.SanitizeAlerting()
+
+	// Trim the file store directory and clamp the upload limit.
+	cfg.SanitizeFiles()
+
 	// Validate raw payload rules and drop invalid entries.
 	cfg.SanitizePayloadRules()
 
@@ -1074,6 +1231,46 @@ func (cfg *Config) SanitizeOpenAICompatibility() {
 	cfg.OpenAICompatibility = out
 }
 
+// SanitizeUpstreamTLS trims the global and per-key upstream TLS settings and
+// drops per-key overrides that end up empty.
+func (cfg *Config) SanitizeUpstreamTLS() {
+	if cfg == nil {
+		return
+	}
+	cfg.UpstreamTLS.Sanitize()
+	for i := range cfg.ClaudeKey {
+		cfg.ClaudeKey[i].UpstreamTLS = sanitizeUpstreamTLSOverride(cfg.ClaudeKey[i].UpstreamTLS)
+	}
+	for i := range cfg.CodexKey {
+		cfg.CodexKey[i].UpstreamTLS = sanitizeUpstreamTLSOverride(cfg.CodexKey[i].UpstreamTLS)
+	}
+	for i := range cfg.GeminiKey {
+		cfg.GeminiKey[i].UpstreamTLS = sanitizeUpstreamTLSOverride(cfg.GeminiKey[i].UpstreamTLS)
+	}
+	for i := range cfg.VertexCompatAPIKey {
+		cfg.VertexCompatAPIKey[i].UpstreamTLS = sanitizeUpstreamTLSOverride(cfg.VertexCompatAPIKey[i].UpstreamTLS)
+	}
+	for i := range cfg.OpenAICompatibility {
+		compat := &cfg.OpenAICompatibility[i]
+		compat.UpstreamTLS = sanitizeUpstreamTLSOverride(compat.UpstreamTLS)
+		for j := range compat.APIKeyEntries {
+			compat.APIKeyEntries[j].UpstreamTLS = sanitizeUpstreamTLSOverride(compat.APIKeyEntries[j].UpstreamTLS)
+		}
+	}
+}
+
+func sanitizeUpstreamTLSOverride(in *UpstreamTLS) *UpstreamTLS {
+	if in == nil {
+		return nil
+	}
+	out := *in
+	out.Sanitize()
+	if out.IsZero() {
+		return nil
+	}
+	return &out
+}
+
 // SanitizeCodexKeys removes Codex API key entries missing a BaseURL.
 // It trims whitespace and preserves order for remaining entries.
 func (cfg *Config) SanitizeCodexKeys() {
@@ -1286,6 +1483,11 @@ func SaveConfigPreserveComments(configFile string, cfg *Config) error {
 		return fmt.Errorf("expected generated root mapping node")
 	}
 
+	// Write secret references back instead of the values they resolved to.
+	if err = restoreSecretRefsInNode(generated.Content[0], persistCfg.secretRefs); err != nil {
+		return err
+	}
+
 	// Remove deprecated sections before merging back the sanitized config.
 	removeLegacyAuthBlock(original.Content[0])
 	removeLegacyOpenAICompatAPIKeys(original.Content[0])
@@ -138,3 +138,36 @@ quota-exceeded:
 		)
 	}
 }
+
+func TestConfig_FanOut_ParsesAndSanitizes(t *testing.T) {
+	yamlData := `
+fan-out:
+  - name: " panel "
+    models: ["gpt-5", " ", "panel", "gemini-2.5-pro"]
+    mode: JUDGE
+    judge-model: "gpt-5"
+  - name: "no-judge"
+    models: ["gpt-5"]
+    mode: judge
+  - name: "empty"
+    models: []
+  - name: "PANEL"
+    models: ["gpt-5"]
+`
+	var cfg Config
+	if err := yaml.Unmarshal([]byte(yamlData), &cfg); err != nil {
+		t.Fatalf("failed to unmarshal yaml: %v", err)
+	}
+	cfg.SanitizeFanOut()
+
+	want := []FanOutModel{
+		{Name: "panel", Models: []string{"gpt-5", "gemini-2.5-pro"}, Mode: FanOutModeJudge, JudgeModel: "gpt-5"},
+		{Name: "no-judge", Models: []string{"gpt-5"}, Mode: FanOutModeAll},
+	}
+	if !reflect.DeepEqual(cfg.FanOut, want) {
+		t.Fatalf("FanOut = %+v, want %+v", cfg.FanOut, want)
+	}
+	if _, ok := cfg.FanOutModelFor("Panel"); !ok {
+		t.Fatal("FanOutModelFor should match case-insensitively")
+	}
+}
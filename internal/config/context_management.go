@@ -0,0 +1,88 @@
+package config
+
+import "strings"
+
+const (
+	// ContextPolicyTruncate drops the oldest conversation turns, keeping tool calls and their
+	// results together.
+	ContextPolicyTruncate = "truncate"
+	// ContextPolicyStrip replaces images and tool results of older turns with short placeholders.
+	ContextPolicyStrip = "strip"
+	// ContextPolicySummarize replaces the middle of the conversation with a summary written by
+	// SummaryModel.
+	ContextPolicySummarize = "summarize"
+)
+
+// ContextManagementConfig shrinks conversations that would exceed the context window of the
+// model a request is sent to, for example after a fallback to a model with a smaller window.
+type ContextManagementConfig struct {
+	// Enable turns context management on.
+	Enable bool `yaml:"enable" json:"enable"`
+
+	// Policies are applied in order until the request fits. Defaults to ["truncate"].
+	Policies []string `yaml:"policies,omitempty" json:"policies,omitempty"`
+
+	// Models limits context management to matching upstream models; supports "*" wildcards.
+	// Empty applies it to every model with a known context window.
+	Models []string `yaml:"models,omitempty" json:"models,omitempty"`
+
+	// KeepRecentTurns is the number of latest turns never stripped or summarized. Defaults to 4.
+	KeepRecentTurns int `yaml:"keep-recent-turns,omitempty" json:"keep-recent-turns,omitempty"`
+
+	// ThresholdPercent is the share of the context window a request may use, leaving room for
+	// tokenizer estimation errors. Defaults to 90.
+	ThresholdPercent int `yaml:"threshold-percent,omitempty" json:"threshold-percent,omitempty"`
+
+	// SummaryModel is the model used by the summarize policy, typically a cheap one.
+	SummaryModel string `yaml:"summary-model,omitempty" json:"summary-model,omitempty"`
+}
+
+// AppliesTo reports whether context management is enabled for the upstream model.
+func (c ContextManagementConfig) AppliesTo(model string) bool {
+	if !c.Enable {
+		return false
+	}
+	if len(c.Models) == 0 {
+		return true
+	}
+	model = strings.ToLower(strings.TrimSpace(model))
+	for _, pattern := range c.Models {
+		if matchWildcardPattern(strings.ToLower(pattern), model) {
+			return true
+		}
+	}
+	return false
+}
+
+// SanitizeContextManagement drops unknown policies and applies defaults.
+func (cfg *Config) SanitizeContextManagement() {
+	if cfg == nil {
+		return
+	}
+	c := &cfg.ContextManagement
+	policies := make([]string, 0, len(c.Policies))
+	for _, policy := range normalizeStringList(c.Policies) {
+		switch policy = strings.ToLower(policy); policy {
+		case ContextPolicyTruncate, ContextPolicyStrip:
+		case ContextPolicySummarize:
+			if strings.TrimSpace(c.SummaryModel) == "" {
+				continue
+			}
+		default:
+			continue
+		}
+		policies = append(policies, policy)
+	}
+	if len(policies) == 0 {
+		policies = []string{ContextPolicyTruncate}
+	}
+	c.Policies = policies
+	c.Models = normalizeStringList(c.Models)
+	c.SummaryModel = strings.TrimSpace(c.SummaryModel)
+	if c.KeepRecentTurns <= 0 {
+		c.KeepRecentTurns = 4
+	}
+	if c.ThresholdPercent <= 0 || c.ThresholdPercent > 100 {
+		c.ThresholdPercent = 90
+	}
+}
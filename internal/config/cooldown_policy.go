@@ -0,0 +1,190 @@
+package config
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Cooldown policy actions.
+const (
+	// CooldownActionCooldown pauses the credential (or model) for a fixed duration or backoff curve.
+	CooldownActionCooldown = "cooldown"
+	// CooldownActionDisable disables the credential until an operator re-enables it.
+	CooldownActionDisable = "disable"
+	// CooldownActionModelUnsupported marks the model unsupported on the credential for 12 hours.
+	CooldownActionModelUnsupported = "model-unsupported"
+	// CooldownActionCircuitBreaker opens a circuit after repeated failures and probes it once the
+	// open window elapses.
+	CooldownActionCircuitBreaker = "circuit-breaker"
+	// CooldownActionIgnore keeps the credential selectable.
+	CooldownActionIgnore = "ignore"
+)
+
+const (
+	// DefaultCircuitFailureThreshold is the number of consecutive failures that opens a circuit.
+	DefaultCircuitFailureThreshold = 5
+	// DefaultCircuitOpenSeconds is how long an open circuit blocks the credential.
+	DefaultCircuitOpenSeconds = 60
+)
+
+// CooldownPolicy maps upstream failures of one provider to credential actions.
+// Policies are evaluated in order; the first policy matching provider and model whose
+// rule matches the failure wins. Failures without a matching rule use the built-in handling.
+type CooldownPolicy struct {
+	// Provider is the provider identifier (e.g. "kiro", "github-copilot"). "*" matches all providers.
+	Provider string `yaml:"provider" json:"provider"`
+
+	// Models optionally narrows the policy to matching models (supports "*" wildcards).
+	Models []string `yaml:"models,omitempty" json:"models,omitempty"`
+
+	// Rules are evaluated in order; the first matching rule decides the action.
+	Rules []CooldownRule `yaml:"rules" json:"rules"`
+}
+
+// CooldownRule matches a failure by status code and/or error message and names the action to take.
+type CooldownRule struct {
+	// StatusCodes lists the HTTP status codes this rule applies to. Empty matches any status.
+	StatusCodes []int `yaml:"status-codes,omitempty" json:"status-codes,omitempty"`
+
+	// MessagePattern is a case-insensitive regular expression matched against the error message.
+	MessagePattern string `yaml:"message-pattern,omitempty" json:"message-pattern,omitempty"`
+
+	// Action is one of: cooldown, disable, model-unsupported, circuit-breaker, ignore.
+	Action string `yaml:"action" json:"action"`
+
+	// CooldownSeconds is the cooldown applied by the cooldown action.
+	CooldownSeconds int `yaml:"cooldown-seconds,omitempty" json:"cooldown-seconds,omitempty"`
+
+	// BackoffMaxSeconds turns the cooldown into an exponential curve: each consecutive failure
+	// doubles cooldown-seconds up to this cap. <= cooldown-seconds keeps a fixed cooldown.
+	BackoffMaxSeconds int `yaml:"backoff-max-seconds,omitempty" json:"backoff-max-seconds,omitempty"`
+
+	// HonorRetryAfter uses the upstream Retry-After hint instead of the configured cooldown
+	// when present. Default is true.
+	HonorRetryAfter *bool `yaml:"honor-retry-after,omitempty" json:"honor-retry-after,omitempty"`
+
+	// FailureThreshold is the number of consecutive matching failures that opens the circuit.
+	// Default is 5.
+	FailureThreshold int `yaml:"failure-threshold,omitempty" json:"failure-threshold,omitempty"`
+
+	// OpenSeconds is how long an open circuit blocks the credential before a probe is allowed.
+	// Default is 60.
+	OpenSeconds int `yaml:"open-seconds,omitempty" json:"open-seconds,omitempty"`
+}
+
+// MatchesProvider reports whether the policy applies to the provider and model.
+func (p CooldownPolicy) MatchesProvider(provider, model string) bool {
+	policyProvider := strings.ToLower(strings.TrimSpace(p.Provider))
+	if policyProvider != "*" && policyProvider != strings.ToLower(strings.TrimSpace(provider)) {
+		return false
+	}
+	if len(p.Models) == 0 {
+		return true
+	}
+	model = strings.ToLower(strings.TrimSpace(model))
+	for _, pattern := range p.Models {
+		if matchWildcardPattern(strings.ToLower(strings.TrimSpace(pattern)), model) {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches reports whether the rule applies to the status code and error message.
+func (r CooldownRule) Matches(statusCode int, message string) bool {
+	if len(r.StatusCodes) > 0 {
+		matched := false
+		for _, code := range r.StatusCodes {
+			if code == statusCode {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if r.MessagePattern != "" {
+		re := compileCooldownPattern(r.MessagePattern)
+		if re == nil || !re.MatchString(message) {
+			return false
+		}
+	}
+	return true
+}
+
+// RetryAfterHonored reports whether upstream Retry-After hints take precedence.
+func (r CooldownRule) RetryAfterHonored() bool {
+	return r.HonorRetryAfter == nil || *r.HonorRetryAfter
+}
+
+// CircuitThreshold returns the effective failure threshold.
+func (r CooldownRule) CircuitThreshold() int {
+	if r.FailureThreshold <= 0 {
+		return DefaultCircuitFailureThreshold
+	}
+	return r.FailureThreshold
+}
+
+// CircuitOpenSeconds returns the effective open window.
+func (r CooldownRule) CircuitOpenSeconds() int {
+	if r.OpenSeconds <= 0 {
+		return DefaultCircuitOpenSeconds
+	}
+	return r.OpenSeconds
+}
+
+var cooldownPatterns sync.Map // pattern -> *regexp.Regexp
+
+func compileCooldownPattern(pattern string) *regexp.Regexp {
+	if cached, ok := cooldownPatterns.Load(pattern); ok {
+		re, _ := cached.(*regexp.Regexp)
+		return re
+	}
+	re, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		re = nil
+	}
+	cooldownPatterns.Store(pattern, re)
+	return re
+}
+
+// SanitizeCooldownPolicies normalizes policy entries and drops rules with an unknown action or an
+// invalid message pattern, as well as policies left without rules.
+func (cfg *Config) SanitizeCooldownPolicies() {
+	if cfg == nil || len(cfg.CooldownPolicies) == 0 {
+		return
+	}
+	policies := make([]CooldownPolicy, 0, len(cfg.CooldownPolicies))
+	for _, policy := range cfg.CooldownPolicies {
+		policy.Provider = strings.ToLower(strings.TrimSpace(policy.Provider))
+		if policy.Provider == "" {
+			continue
+		}
+		rules := make([]CooldownRule, 0, len(policy.Rules))
+		for _, rule := range policy.Rules {
+			rule.Action = strings.ToLower(strings.TrimSpace(rule.Action))
+			switch rule.Action {
+			case CooldownActionCooldown, CooldownActionDisable, CooldownActionModelUnsupported,
+				CooldownActionCircuitBreaker, CooldownActionIgnore:
+			default:
+				log.Warnf("cooldown-policies: provider %s: unknown action %q, rule ignored", policy.Provider, rule.Action)
+				continue
+			}
+			if rule.MessagePattern != "" && compileCooldownPattern(rule.MessagePattern) == nil {
+				log.Warnf("cooldown-policies: provider %s: invalid message-pattern %q, rule ignored", policy.Provider, rule.MessagePattern)
+				continue
+			}
+			rules = append(rules, rule)
+		}
+		if len(rules) == 0 {
+			continue
+		}
+		policy.Rules = rules
+		policies = append(policies, policy)
+	}
+	cfg.CooldownPolicies = policies
+}
@@ -0,0 +1,118 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CORSPolicy describes which browser origins may call a group of routes.
+type CORSPolicy struct {
+	// Disable sends no CORS headers and rejects preflight requests, blocking cross-origin
+	// browser access to the routes.
+	Disable bool `yaml:"disable,omitempty" json:"disable,omitempty"`
+
+	// AllowedOrigins lists origins allowed to call the routes. "*" allows any origin and
+	// wildcards match a single position, e.g. "https://*.intranet.example.com".
+	AllowedOrigins []string `yaml:"allowed-origins,omitempty" json:"allowed-origins,omitempty"`
+
+	// AllowedMethods defaults to GET, POST, PUT, PATCH, DELETE and OPTIONS.
+	AllowedMethods []string `yaml:"allowed-methods,omitempty" json:"allowed-methods,omitempty"`
+
+	// AllowedHeaders defaults to "*", which allows every header the browser asks for.
+	AllowedHeaders []string `yaml:"allowed-headers,omitempty" json:"allowed-headers,omitempty"`
+
+	// ExposedHeaders lists response headers readable by browser scripts.
+	ExposedHeaders []string `yaml:"exposed-headers,omitempty" json:"exposed-headers,omitempty"`
+
+	// AllowCredentials lets browsers send cookies. The matching origin is echoed instead of "*".
+	// It requires explicitly listed origins; "*" and bare host wildcards are rejected.
+	AllowCredentials bool `yaml:"allow-credentials,omitempty" json:"allow-credentials,omitempty"`
+
+	// MaxAge caches preflight results for this many seconds. 0 leaves it to the browser.
+	MaxAge int `yaml:"max-age,omitempty" json:"max-age,omitempty"`
+}
+
+// CORSConfig holds per-route-group CORS policies. A group without a policy uses Default; when
+// Default is unset too, any origin is allowed, which matches the behavior without a cors section.
+type CORSConfig struct {
+	// Default applies to every route group without its own policy.
+	Default *CORSPolicy `yaml:"default,omitempty" json:"default,omitempty"`
+	// V1 applies to the /v1 OpenAI and Claude compatible routes.
+	V1 *CORSPolicy `yaml:"v1,omitempty" json:"v1,omitempty"`
+	// V1Beta applies to the /v1beta Gemini compatible routes.
+	V1Beta *CORSPolicy `yaml:"v1beta,omitempty" json:"v1beta,omitempty"`
+	// Amp applies to the Amp CLI routes (/api, /threads, /auth, ...).
+	Amp *CORSPolicy `yaml:"amp,omitempty" json:"amp,omitempty"`
+	// Management applies to /v0/management.
+	Management *CORSPolicy `yaml:"management,omitempty" json:"management,omitempty"`
+}
+
+// BrowserAuthConfig lets browser clients that cannot set request headers, such as EventSource,
+// send the proxy API key in a cookie or query parameter. Such keys are only accepted from origins
+// listed explicitly in the CORS policy of the route group.
+type BrowserAuthConfig struct {
+	// Cookie names a cookie carrying the proxy API key.
+	Cookie string `yaml:"cookie,omitempty" json:"cookie,omitempty"`
+
+	// QueryParam names an additional query parameter carrying the proxy API key. The "key" and
+	// "auth_token" parameters are always accepted.
+	QueryParam string `yaml:"query-param,omitempty" json:"query-param,omitempty"`
+}
+
+// SanitizeCORS trims origin, method and header lists of every CORS policy.
+func (cfg *Config) SanitizeCORS() {
+	if cfg == nil {
+		return
+	}
+	for _, policy := range []*CORSPolicy{cfg.CORS.Default, cfg.CORS.V1, cfg.CORS.V1Beta, cfg.CORS.Amp, cfg.CORS.Management} {
+		if policy == nil {
+			continue
+		}
+		policy.AllowedOrigins = normalizeStringList(policy.AllowedOrigins)
+		policy.AllowedMethods = normalizeStringList(policy.AllowedMethods)
+		for i, method := range policy.AllowedMethods {
+			policy.AllowedMethods[i] = strings.ToUpper(method)
+		}
+		policy.AllowedHeaders = normalizeStringList(policy.AllowedHeaders)
+		policy.ExposedHeaders = normalizeStringList(policy.ExposedHeaders)
+		if policy.MaxAge < 0 {
+			policy.MaxAge = 0
+		}
+	}
+	cfg.BrowserAuth.Cookie = strings.TrimSpace(cfg.BrowserAuth.Cookie)
+	cfg.BrowserAuth.QueryParam = strings.TrimSpace(cfg.BrowserAuth.QueryParam)
+}
+
+// ValidateCORS rejects policies that would let any website make credentialed requests.
+func (cfg *Config) ValidateCORS() error {
+	if cfg == nil {
+		return nil
+	}
+	groups := []struct {
+		name   string
+		policy *CORSPolicy
+	}{
+		{"default", cfg.CORS.Default}, {"v1", cfg.CORS.V1}, {"v1beta", cfg.CORS.V1Beta},
+		{"amp", cfg.CORS.Amp}, {"management", cfg.CORS.Management},
+	}
+	for _, group := range groups {
+		if group.policy == nil || !group.policy.AllowCredentials {
+			continue
+		}
+		for _, origin := range group.policy.AllowedOrigins {
+			if isAnyOriginPattern(origin) {
+				return fmt.Errorf("cors.%s: allowed origin %q cannot be combined with allow-credentials", group.name, origin)
+			}
+		}
+	}
+	return nil
+}
+
+// isAnyOriginPattern reports whether an allowed origin matches every host, e.g. "*" or "https://*".
+func isAnyOriginPattern(origin string) bool {
+	host := origin
+	if _, rest, ok := strings.Cut(origin, "://"); ok {
+		host = rest
+	}
+	return strings.HasPrefix(host, "*") && !strings.HasPrefix(host, "*.")
+}
@@ -0,0 +1,105 @@
+package config
+
+import "strings"
+
+const (
+	// FanOutModeAll returns every successful candidate as a separate choice.
+	FanOutModeAll = "all"
+	// FanOutModeFirst returns the first successful candidate and cancels the rest.
+	FanOutModeFirst = "first"
+	// FanOutModeJudge asks the judge model to pick the best candidate.
+	FanOutModeJudge = "judge"
+
+	// DefaultFanOutTimeoutSeconds bounds a fan-out request when timeout-seconds is unset.
+	DefaultFanOutTimeoutSeconds = 300
+)
+
+// FanOutModel defines a virtual model that sends one OpenAI Chat Completions request to several
+// models concurrently and combines their answers.
+type FanOutModel struct {
+	// Name is the virtual model name clients request.
+	Name string `yaml:"name" json:"name"`
+
+	// Models lists the models the request is sent to.
+	Models []string `yaml:"models" json:"models"`
+
+	// Mode is "all" (default), "first" or "judge".
+	Mode string `yaml:"mode,omitempty" json:"mode,omitempty"`
+
+	// JudgeModel picks the best candidate in judge mode.
+	JudgeModel string `yaml:"judge-model,omitempty" json:"judge-model,omitempty"`
+
+	// JudgePrompt replaces the default instructions given to the judge model.
+	JudgePrompt string `yaml:"judge-prompt,omitempty" json:"judge-prompt,omitempty"`
+
+	// TimeoutSeconds bounds the whole fan-out, including the judge call. Default is 300.
+	TimeoutSeconds int `yaml:"timeout-seconds,omitempty" json:"timeout-seconds,omitempty"`
+}
+
+// FanOutModelFor returns the fan-out definition whose name matches model, case-insensitively.
+func (c *SDKConfig) FanOutModelFor(model string) (FanOutModel, bool) {
+	if c == nil {
+		return FanOutModel{}, false
+	}
+	model = strings.TrimSpace(model)
+	for _, entry := range c.FanOut {
+		if strings.EqualFold(entry.Name, model) {
+			return entry, true
+		}
+	}
+	return FanOutModel{}, false
+}
+
+// SanitizeFanOut drops fan-out models without a name or targets, normalizes the mode and
+// falls back to "all" when judge mode has no judge model.
+func (cfg *Config) SanitizeFanOut() {
+	if cfg == nil || len(cfg.FanOut) == 0 {
+		return
+	}
+	seen := make(map[string]struct{}, len(cfg.FanOut))
+	out := make([]FanOutModel, 0, len(cfg.FanOut))
+	for _, entry := range cfg.FanOut {
+		entry.Name = strings.TrimSpace(entry.Name)
+		key := strings.ToLower(entry.Name)
+		if key == "" {
+			continue
+		}
+		if _, dup := seen[key]; dup {
+			continue
+		}
+		models := make([]string, 0, len(entry.Models))
+		for _, model := range entry.Models {
+			if trimmed := strings.TrimSpace(model); trimmed != "" && !strings.EqualFold(trimmed, entry.Name) {
+				models = append(models, trimmed)
+			}
+		}
+		if len(models) == 0 {
+			continue
+		}
+		entry.Models = models
+		entry.JudgeModel = strings.TrimSpace(entry.JudgeModel)
+		switch mode := strings.ToLower(strings.TrimSpace(entry.Mode)); mode {
+		case FanOutModeFirst, FanOutModeJudge:
+			entry.Mode = mode
+		default:
+			entry.Mode = FanOutModeAll
+		}
+		if entry.Mode == FanOutModeJudge && entry.JudgeModel == "" {
+			entry.Mode = FanOutModeAll
+		}
+		if entry.TimeoutSeconds < 0 {
+			entry.TimeoutSeconds = 0
+		}
+		seen[key] = struct{}{}
+		out = append(out, entry)
+	}
+	cfg.FanOut = out
+}
+
+// Timeout returns the effective fan-out timeout in seconds.
+func (m FanOutModel) Timeout() int {
+	if m.TimeoutSeconds <= 0 {
+		return DefaultFanOutTimeoutSeconds
+	}
+	return m.TimeoutSeconds
+}
@@ -0,0 +1,37 @@
+package config
+
+import "strings"
+
+// DefaultFilesMaxSizeMB caps a single upload to the proxy file store.
+const DefaultFilesMaxSizeMB = 100
+
+// FilesConfig configures the proxy-side store behind the /v1/files endpoints. Uploaded files
+// are inlined into requests that reference them by file_id.
+type FilesConfig struct {
+	// Dir overrides the directory holding uploads. Defaults to ".files" under auth-dir.
+	// Ignored when the object storage backend is enabled.
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+
+	// MaxSizeMB caps a single upload. Default is 100.
+	MaxSizeMB int `yaml:"max-size-mb,omitempty" json:"max-size-mb,omitempty"`
+}
+
+// MaxSizeBytes returns the effective upload limit in bytes.
+func (c FilesConfig) MaxSizeBytes() int64 {
+	size := c.MaxSizeMB
+	if size <= 0 {
+		size = DefaultFilesMaxSizeMB
+	}
+	return int64(size) << 20
+}
+
+// SanitizeFiles trims the upload directory and clamps the size limit.
+func (cfg *Config) SanitizeFiles() {
+	if cfg == nil {
+		return
+	}
+	cfg.Files.Dir = strings.TrimSpace(cfg.Files.Dir)
+	if cfg.Files.MaxSizeMB < 0 {
+		cfg.Files.MaxSizeMB = 0
+	}
+}
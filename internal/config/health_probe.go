@@ -0,0 +1,69 @@
+package config
+
+import "strings"
+
+const (
+	// DefaultHealthProbeTimeoutSeconds bounds a single credential probe.
+	DefaultHealthProbeTimeoutSeconds = 20
+	// minHealthProbeIntervalSeconds keeps background probes from hammering upstreams.
+	minHealthProbeIntervalSeconds = 60
+)
+
+// HealthProbeConfig controls background credential validation.
+type HealthProbeConfig struct {
+	// IntervalSeconds is how often each credential is probed. 0 disables background probes;
+	// on-demand probes through the management API are always available.
+	IntervalSeconds int `yaml:"interval-seconds,omitempty" json:"interval-seconds,omitempty"`
+
+	// TimeoutSeconds bounds a single probe. Default is 20.
+	TimeoutSeconds int `yaml:"timeout-seconds,omitempty" json:"timeout-seconds,omitempty"`
+
+	// Providers optionally limits background probes to these providers.
+	Providers []string `yaml:"providers,omitempty" json:"providers,omitempty"`
+}
+
+// Timeout returns the effective per-probe timeout in seconds.
+func (c HealthProbeConfig) Timeout() int {
+	if c.TimeoutSeconds <= 0 {
+		return DefaultHealthProbeTimeoutSeconds
+	}
+	return c.TimeoutSeconds
+}
+
+// CoversProvider reports whether background probes apply to the provider.
+func (c HealthProbeConfig) CoversProvider(provider string) bool {
+	if len(c.Providers) == 0 {
+		return true
+	}
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	for _, candidate := range c.Providers {
+		if candidate == provider {
+			return true
+		}
+	}
+	return false
+}
+
+// SanitizeHealthProbe normalizes provider names and clamps the probe interval.
+func (cfg *Config) SanitizeHealthProbe() {
+	if cfg == nil {
+		return
+	}
+	probe := &cfg.HealthProbe
+	if probe.IntervalSeconds < 0 {
+		probe.IntervalSeconds = 0
+	}
+	if probe.IntervalSeconds > 0 && probe.IntervalSeconds < minHealthProbeIntervalSeconds {
+		probe.IntervalSeconds = minHealthProbeIntervalSeconds
+	}
+	if probe.TimeoutSeconds < 0 {
+		probe.TimeoutSeconds = 0
+	}
+	providers := make([]string, 0, len(probe.Providers))
+	for _, provider := range probe.Providers {
+		if provider = strings.ToLower(strings.TrimSpace(provider)); provider != "" {
+			providers = append(providers, provider)
+		}
+	}
+	probe.Providers = providers
+}
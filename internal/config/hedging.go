@@ -0,0 +1,118 @@
+package config
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultHedgingDelayMs is the wait before a hedged request is launched when no delay is configured.
+	DefaultHedgingDelayMs = 2000
+	// DefaultHedgingMaxExtraPercent caps hedged requests to this share of eligible requests.
+	DefaultHedgingMaxExtraPercent = 10
+)
+
+// HedgingConfig configures speculative duplicate requests for latency-critical models.
+// When the first upstream chunk has not arrived within the hedge delay, the same request is
+// launched on another credential (or provider); the first response wins and the other is cancelled.
+type HedgingConfig struct {
+	// Enable turns hedging on. Only models matching Models are hedged.
+	Enable bool `yaml:"enable" json:"enable"`
+
+	// Models lists the client-visible model names eligible for hedging.
+	// Supports "*" wildcards (e.g., "claude-sonnet-*"); "*" enables all models.
+	Models []string `yaml:"models,omitempty" json:"models,omitempty"`
+
+	// DelayMs is how long to wait for the first response chunk before launching the hedge.
+	// Defaults to DefaultHedgingDelayMs.
+	DelayMs int `yaml:"delay-ms,omitempty" json:"delay-ms,omitempty"`
+
+	// Adaptive uses the p95 time-to-first-token observed for the model as the delay once
+	// enough samples are available, falling back to DelayMs otherwise.
+	Adaptive bool `yaml:"adaptive,omitempty" json:"adaptive,omitempty"`
+
+	// MaxExtraPercent caps hedged requests to this percentage of eligible requests
+	// per model and minute, bounding the extra upstream spend. Defaults to DefaultHedgingMaxExtraPercent.
+	MaxExtraPercent int `yaml:"max-extra-percent,omitempty" json:"max-extra-percent,omitempty"`
+}
+
+// MatchesModel reports whether hedging is enabled for the given model.
+func (h HedgingConfig) MatchesModel(model string) bool {
+	if !h.Enable {
+		return false
+	}
+	model = strings.ToLower(strings.TrimSpace(model))
+	if model == "" {
+		return false
+	}
+	for _, pattern := range h.Models {
+		if matchWildcardPattern(strings.ToLower(strings.TrimSpace(pattern)), model) {
+			return true
+		}
+	}
+	return false
+}
+
+// SanitizeHedging trims model patterns and clamps the hedging limits.
+func (cfg *Config) SanitizeHedging() {
+	if cfg == nil {
+		return
+	}
+	h := &cfg.Routing.Hedging
+	models := make([]string, 0, len(h.Models))
+	for _, pattern := range h.Models {
+		if trimmed := strings.TrimSpace(pattern); trimmed != "" {
+			models = append(models, trimmed)
+		}
+	}
+	h.Models = models
+	if h.DelayMs < 0 {
+		h.DelayMs = 0
+	}
+	if h.MaxExtraPercent < 0 {
+		h.MaxExtraPercent = 0
+	}
+	if h.MaxExtraPercent > 100 {
+		h.MaxExtraPercent = 100
+	}
+}
+
+// Delay returns the configured hedge delay, applying DefaultHedgingDelayMs when unset.
+func (h HedgingConfig) Delay() time.Duration {
+	if h.DelayMs <= 0 {
+		return DefaultHedgingDelayMs * time.Millisecond
+	}
+	return time.Duration(h.DelayMs) * time.Millisecond
+}
+
+// ExtraPercent returns the hedge budget percentage, applying DefaultHedgingMaxExtraPercent when unset.
+func (h HedgingConfig) ExtraPercent() int {
+	if h.MaxExtraPercent <= 0 {
+		return DefaultHedgingMaxExtraPercent
+	}
+	return h.MaxExtraPercent
+}
+
+// matchWildcardPattern matches value against pattern where '*' matches any sequence of characters.
+func matchWildcardPattern(pattern, value string) bool {
+	if pattern == "" {
+		return false
+	}
+	if !strings.Contains(pattern, "*") {
+		return pattern == value
+	}
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(value, parts[0]) {
+		return false
+	}
+	value = value[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		idx := strings.Index(value, part)
+		if idx < 0 {
+			return false
+		}
+		value = value[idx+len(part):]
+	}
+	return strings.HasSuffix(value, last)
+}
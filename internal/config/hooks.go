@@ -0,0 +1,176 @@
+package config
+
+import "strings"
+
+const (
+	// HookTransportHTTP posts JSON documents to the hook URL.
+	HookTransportHTTP = "http"
+	// HookTransportGRPC calls the unary gRPC method cliproxy.hooks.v1.Hooks/Handle.
+	HookTransportGRPC = "grpc"
+
+	// HookStagePreRequest runs before the request is executed and may change its body.
+	HookStagePreRequest = "pre-request"
+	// HookStagePostResponse runs after the response is complete and may change its body.
+	HookStagePostResponse = "post-response"
+
+	// HookFailOpen ignores hook errors and timeouts.
+	HookFailOpen = "fail-open"
+	// HookFailClosed rejects the request when the hook errors or times out.
+	HookFailClosed = "fail-closed"
+
+	// HookStreamObserve streams responses to the client unchanged and notifies the post hook
+	// once the stream has ended; its decision is not applied.
+	HookStreamObserve = "observe"
+	// HookStreamBuffer holds streamed responses until the post hook has approved, rejected or
+	// replaced them.
+	HookStreamBuffer = "buffer"
+
+	// DefaultHookTimeoutMillis bounds a hook call when timeout-ms is unset.
+	DefaultHookTimeoutMillis = 2000
+)
+
+// HookConfig describes an out-of-process plugin that inspects requests before execution and
+// responses after it. The plugin may approve, reject with a message, or return a modified body.
+type HookConfig struct {
+	// Name identifies the hook in logs and in the documents sent to it; it must be unique.
+	Name string `yaml:"name" json:"name"`
+
+	// Transport is "http" (default) or "grpc".
+	Transport string `yaml:"transport,omitempty" json:"transport,omitempty"`
+
+	// URL is the hook endpoint. gRPC hooks use http:// for plaintext HTTP/2 and https:// for TLS.
+	URL string `yaml:"url" json:"url"`
+
+	// Headers are sent with every call, as HTTP headers or gRPC metadata.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// Stages lists "pre-request" and/or "post-response". Empty runs both.
+	Stages []string `yaml:"stages,omitempty" json:"stages,omitempty"`
+
+	// Models restricts the hook to matching models (supports "*" wildcards). Empty matches all.
+	Models []string `yaml:"models,omitempty" json:"models,omitempty"`
+
+	// APIKeys restricts the hook to requests authenticated with these client API keys.
+	APIKeys []string `yaml:"api-keys,omitempty" json:"api-keys,omitempty"`
+
+	// Formats restricts the hook to these source formats, e.g. "openai", "claude", "gemini".
+	Formats []string `yaml:"formats,omitempty" json:"formats,omitempty"`
+
+	// TimeoutMillis bounds one call. Default is 2000.
+	TimeoutMillis int `yaml:"timeout-ms,omitempty" json:"timeout-ms,omitempty"`
+
+	// FailurePolicy is "fail-open" (default) or "fail-closed".
+	FailurePolicy string `yaml:"failure-policy,omitempty" json:"failure-policy,omitempty"`
+
+	// StreamMode is "observe" (default) or "buffer" and applies to post-response hooks of
+	// streaming requests.
+	StreamMode string `yaml:"stream-mode,omitempty" json:"stream-mode,omitempty"`
+}
+
+// HasStage reports whether the hook runs at stage.
+func (h HookConfig) HasStage(stage string) bool {
+	if len(h.Stages) == 0 {
+		return true
+	}
+	for _, s := range h.Stages {
+		if s == stage {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches reports whether the hook applies to a request for model in format, authenticated
+// with apiKey.
+func (h HookConfig) Matches(format, model, apiKey string) bool {
+	if len(h.Formats) > 0 {
+		matched := false
+		for _, f := range h.Formats {
+			if strings.EqualFold(f, format) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(h.Models) > 0 {
+		matched := false
+		for _, pattern := range h.Models {
+			if matchWildcardPattern(strings.ToLower(pattern), strings.ToLower(model)) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(h.APIKeys) > 0 {
+		for _, key := range h.APIKeys {
+			if key == apiKey {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// SanitizeHooks drops hooks without a name or URL, duplicates and unknown transports, and
+// normalizes stages, policies and stream modes.
+func (c *Config) SanitizeHooks() {
+	if c == nil || len(c.Hooks) == 0 {
+		return
+	}
+	out := make([]HookConfig, 0, len(c.Hooks))
+	seen := make(map[string]struct{}, len(c.Hooks))
+	for _, hook := range c.Hooks {
+		hook.Name = strings.TrimSpace(hook.Name)
+		hook.URL = strings.TrimSpace(hook.URL)
+		if hook.Name == "" || hook.URL == "" {
+			continue
+		}
+		if _, dup := seen[hook.Name]; dup {
+			continue
+		}
+		hook.Transport = strings.ToLower(strings.TrimSpace(hook.Transport))
+		switch hook.Transport {
+		case "":
+			hook.Transport = HookTransportHTTP
+		case HookTransportHTTP, HookTransportGRPC:
+		default:
+			continue
+		}
+		stages := make([]string, 0, len(hook.Stages))
+		for _, stage := range hook.Stages {
+			stage = strings.ToLower(strings.TrimSpace(stage))
+			if stage == HookStagePreRequest || stage == HookStagePostResponse {
+				stages = append(stages, stage)
+			}
+		}
+		if len(hook.Stages) > 0 && len(stages) == 0 {
+			continue
+		}
+		hook.Stages = stages
+		hook.Models = normalizeStringList(hook.Models)
+		hook.Formats = normalizeStringList(hook.Formats)
+		if hook.TimeoutMillis <= 0 {
+			hook.TimeoutMillis = DefaultHookTimeoutMillis
+		}
+		if strings.ToLower(strings.TrimSpace(hook.FailurePolicy)) == HookFailClosed {
+			hook.FailurePolicy = HookFailClosed
+		} else {
+			hook.FailurePolicy = HookFailOpen
+		}
+		if strings.ToLower(strings.TrimSpace(hook.StreamMode)) == HookStreamBuffer {
+			hook.StreamMode = HookStreamBuffer
+		} else {
+			hook.StreamMode = HookStreamObserve
+		}
+		seen[hook.Name] = struct{}{}
+		out = append(out, hook)
+	}
+	c.Hooks = out
+}
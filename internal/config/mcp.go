@@ -0,0 +1,188 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DefaultMCPMaxIterations caps model/tool round trips when mcp.max-iterations is unset.
+	DefaultMCPMaxIterations = 8
+	// DefaultMCPToolTimeoutSeconds bounds a single MCP tool call when timeout-seconds is unset.
+	DefaultMCPToolTimeoutSeconds = 60
+)
+
+// MCPConfig configures the server-side MCP tool bridge. Tools of matching servers are
+// attached to requests and tool calls targeting them are executed inside the proxy.
+type MCPConfig struct {
+	// Servers lists the MCP servers whose tools may be attached to requests.
+	Servers []MCPServer `yaml:"servers,omitempty" json:"servers,omitempty"`
+
+	// MaxIterations caps the number of model calls made for one client request while
+	// the model keeps calling bridged tools. Default is 8.
+	MaxIterations int `yaml:"max-iterations,omitempty" json:"max-iterations,omitempty"`
+}
+
+// MCPServer describes a single MCP server reachable over stdio or streamable HTTP.
+type MCPServer struct {
+	// Name identifies the server in logs; it must be unique.
+	Name string `yaml:"name" json:"name"`
+
+	// Transport is "stdio" or "http". When empty it is inferred from Command/URL.
+	Transport string `yaml:"transport,omitempty" json:"transport,omitempty"`
+
+	// Command and Args launch a stdio server.
+	Command string   `yaml:"command,omitempty" json:"command,omitempty"`
+	Args    []string `yaml:"args,omitempty" json:"args,omitempty"`
+
+	// Env adds environment variables to the stdio server process.
+	Env map[string]string `yaml:"env,omitempty" json:"env,omitempty"`
+
+	// URL is the streamable HTTP endpoint of the server.
+	URL string `yaml:"url,omitempty" json:"url,omitempty"`
+
+	// Headers are sent with every HTTP request to the server (e.g. Authorization).
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// Models restricts the server to requests for matching models (supports "*" wildcards).
+	// Empty matches every model.
+	Models []string `yaml:"models,omitempty" json:"models,omitempty"`
+
+	// APIKeys restricts the server to requests authenticated with these client API keys.
+	// Empty matches every key.
+	APIKeys []string `yaml:"api-keys,omitempty" json:"api-keys,omitempty"`
+
+	// Tools allow-lists the tool names exposed from this server. Empty exposes all tools.
+	Tools []string `yaml:"tools,omitempty" json:"tools,omitempty"`
+
+	// TimeoutSeconds bounds a single tool call. Default is 60.
+	TimeoutSeconds int `yaml:"timeout-seconds,omitempty" json:"timeout-seconds,omitempty"`
+}
+
+// Enabled reports whether at least one MCP server is configured.
+func (c MCPConfig) Enabled() bool {
+	return len(c.Servers) > 0
+}
+
+// Iterations returns the effective iteration cap.
+func (c MCPConfig) Iterations() int {
+	if c.MaxIterations <= 0 {
+		return DefaultMCPMaxIterations
+	}
+	return c.MaxIterations
+}
+
+// TransportKind returns the effective transport, inferring it when unset.
+func (s MCPServer) TransportKind() string {
+	transport := strings.ToLower(strings.TrimSpace(s.Transport))
+	switch transport {
+	case "stdio":
+		return "stdio"
+	case "http", "streamable-http", "streamable_http":
+		return "http"
+	}
+	if strings.TrimSpace(s.URL) != "" {
+		return "http"
+	}
+	return "stdio"
+}
+
+// Matches reports whether the server applies to the given model and client API key.
+func (s MCPServer) Matches(model, apiKey string) bool {
+	if len(s.Models) > 0 {
+		matched := false
+		for _, pattern := range s.Models {
+			if matchWildcardPattern(strings.ToLower(strings.TrimSpace(pattern)), strings.ToLower(model)) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(s.APIKeys) > 0 {
+		for _, key := range s.APIKeys {
+			if key == apiKey {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// AllowsTool reports whether the named tool is exposed from this server.
+func (s MCPServer) AllowsTool(name string) bool {
+	if len(s.Tools) == 0 {
+		return true
+	}
+	for _, tool := range s.Tools {
+		if matchWildcardPattern(strings.TrimSpace(tool), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// SanitizeMCP drops MCP servers without a name or endpoint.
+func (c *Config) SanitizeMCP() {
+	if c == nil || len(c.MCP.Servers) == 0 {
+		return
+	}
+	out := c.MCP.Servers[:0]
+	seen := make(map[string]struct{}, len(c.MCP.Servers))
+	for _, server := range c.MCP.Servers {
+		server.Name = strings.TrimSpace(server.Name)
+		server.Command = strings.TrimSpace(server.Command)
+		server.URL = strings.TrimSpace(server.URL)
+		if server.Name == "" || (server.Command == "" && server.URL == "") {
+			continue
+		}
+		if _, dup := seen[server.Name]; dup {
+			continue
+		}
+		seen[server.Name] = struct{}{}
+		out = append(out, server)
+	}
+	c.MCP.Servers = out
+}
+
+// ValidateUploadedMCPServers rejects stdio MCP servers in a config uploaded through the
+// management API unless the current config already contains a stdio server with the same name,
+// command, arguments and environment. A stdio server runs its command on the host, so
+// management clients may keep the servers an operator wrote but cannot add or change them.
+func ValidateUploadedMCPServers(uploaded, current []byte) error {
+	var uploadedCfg, currentCfg Config
+	if err := yaml.Unmarshal(uploaded, &uploadedCfg); err != nil {
+		return err
+	}
+	existing := make(map[string]MCPServer)
+	if yaml.Unmarshal(current, &currentCfg) == nil {
+		for _, server := range currentCfg.MCP.Servers {
+			if server.TransportKind() == "stdio" {
+				existing[strings.TrimSpace(server.Name)] = server
+			}
+		}
+	}
+	for _, server := range uploadedCfg.MCP.Servers {
+		if server.TransportKind() != "stdio" {
+			continue
+		}
+		name := strings.TrimSpace(server.Name)
+		if prev, ok := existing[name]; !ok || !sameStdioLaunch(prev, server) {
+			return fmt.Errorf("mcp server %q: stdio servers cannot be added or changed through the management API", name)
+		}
+	}
+	return nil
+}
+
+// sameStdioLaunch reports whether a and b launch the same process.
+func sameStdioLaunch(a, b MCPServer) bool {
+	return strings.TrimSpace(a.Command) == strings.TrimSpace(b.Command) &&
+		slices.Equal(a.Args, b.Args) && maps.Equal(a.Env, b.Env)
+}
@@ -0,0 +1,19 @@
+package config
+
+import "testing"
+
+func TestValidateUploadedMCPServers(t *testing.T) {
+	current := []byte("mcp:\n  servers:\n    - name: fs\n      command: npx\n      args: [\"-y\", \"@mcp/fs\"]\n")
+	if err := ValidateUploadedMCPServers([]byte("mcp:\n  servers:\n    - name: fs\n      command: npx\n      args: [\"-y\", \"@mcp/fs\"]\n      tools: [\"read_file\"]\n    - name: web\n      url: https://mcp.example.com/mcp\n"), current); err != nil {
+		t.Fatalf("existing stdio server or http server rejected: %v", err)
+	}
+	if err := ValidateUploadedMCPServers([]byte("mcp:\n  servers:\n    - name: shell\n      command: sh\n      args: [\"-c\", \"id\"]\n"), current); err == nil {
+		t.Fatal("expected new stdio server to be rejected")
+	}
+	if err := ValidateUploadedMCPServers([]byte("mcp:\n  servers:\n    - name: fs\n      command: sh\n      args: [\"-c\", \"id\"]\n"), current); err == nil {
+		t.Fatal("expected changed stdio command to be rejected")
+	}
+	if err := ValidateUploadedMCPServers([]byte("mcp:\n  servers:\n    - name: fs\n      command: npx\n      args: [\"-y\", \"@mcp/fs\"]\n      env:\n        NODE_OPTIONS: --require /tmp/x.js\n"), current); err == nil {
+		t.Fatal("expected changed stdio environment to be rejected")
+	}
+}
@@ -0,0 +1,37 @@
+package config
+
+import "strings"
+
+const (
+	// RetiredModelRedirect sends requests for retired models to their replacement.
+	RetiredModelRedirect = "redirect"
+	// RetiredModelReject fails requests for retired models with 410 Gone.
+	RetiredModelReject = "reject"
+)
+
+// ModelLifecycleConfig controls how deprecated and retired models are handled.
+type ModelLifecycleConfig struct {
+	// RetiredModels is "redirect" (default) or "reject". Retired models without a replacement are
+	// always rejected.
+	RetiredModels string `yaml:"retired-models,omitempty" json:"retired-models,omitempty"`
+
+	// WarnDays is how far ahead management and the TUI list upcoming retirements. Defaults to 30.
+	WarnDays int `yaml:"warn-days,omitempty" json:"warn-days,omitempty"`
+}
+
+// SanitizeModelLifecycle normalizes the retired model action and applies defaults.
+func (cfg *Config) SanitizeModelLifecycle() {
+	if cfg == nil {
+		return
+	}
+	l := &cfg.ModelLifecycle
+	switch strings.ToLower(strings.TrimSpace(l.RetiredModels)) {
+	case RetiredModelReject:
+		l.RetiredModels = RetiredModelReject
+	default:
+		l.RetiredModels = RetiredModelRedirect
+	}
+	if l.WarnDays <= 0 {
+		l.WarnDays = 30
+	}
+}
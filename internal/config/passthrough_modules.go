@@ -0,0 +1,169 @@
+package config
+
+import "strings"
+
+// Local route handler names accepted in passthrough module local-routes.
+const (
+	PassthroughHandlerOpenAIChat        = "openai-chat-completions"
+	PassthroughHandlerOpenAICompletions = "openai-completions"
+	PassthroughHandlerOpenAIResponses   = "openai-responses"
+	PassthroughHandlerOpenAIModels      = "openai-models"
+	PassthroughHandlerClaudeMessages    = "claude-messages"
+	PassthroughHandlerClaudeCountTokens = "claude-count-tokens"
+	PassthroughHandlerClaudeModels      = "claude-models"
+	PassthroughHandlerGemini            = "gemini"
+	PassthroughHandlerGeminiModels      = "gemini-models"
+)
+
+// passthroughReservedPrefixes are mount paths owned by built-in routes.
+var passthroughReservedPrefixes = []string{
+	"/v0", "/v1", "/v1beta", "/v1internal:method", "/ws", "/api", "/threads", "/docs", "/settings", "/auth",
+	"/management.html", "/keep-alive",
+}
+
+// PassthroughModule mounts a reverse proxy to a vendor API under MountPath. Requests on the local
+// routes are served by our providers when the requested model is available locally, and are
+// forwarded to the upstream otherwise; every other request under the mount path is proxied.
+type PassthroughModule struct {
+	// Name identifies the module in logs. Defaults to the mount path.
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+
+	// Disable turns the module off without removing it; its routes answer 404.
+	Disable bool `yaml:"disable,omitempty" json:"disable,omitempty"`
+
+	// MountPath is the route prefix the module serves, e.g. "/vendor".
+	MountPath string `yaml:"mount-path" json:"mount-path"`
+
+	// UpstreamURL is the vendor base URL proxied requests are sent to. Without it only the local
+	// routes are served.
+	UpstreamURL string `yaml:"upstream-url,omitempty" json:"upstream-url,omitempty"`
+
+	// UpstreamAPIKey is injected into proxied requests in place of the client's credentials.
+	UpstreamAPIKey string `yaml:"upstream-api-key,omitempty" json:"upstream-api-key,omitempty"`
+
+	// AuthHeader carries UpstreamAPIKey. Defaults to "Authorization".
+	AuthHeader string `yaml:"auth-header,omitempty" json:"auth-header,omitempty"`
+
+	// AuthScheme prefixes UpstreamAPIKey in AuthHeader. Defaults to "Bearer" for the
+	// Authorization header and to no prefix otherwise.
+	AuthScheme string `yaml:"auth-scheme,omitempty" json:"auth-scheme,omitempty"`
+
+	// Headers are set on every proxied request.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// ForwardHeaders extends the built-in allowlist of client headers forwarded to the upstream.
+	// All other client headers, including cookies and credentials, are dropped.
+	ForwardHeaders []string `yaml:"forward-headers,omitempty" json:"forward-headers,omitempty"`
+
+	// LocalRoutes lists the paths, relative to MountPath, that may be served by our providers.
+	LocalRoutes []PassthroughLocalRoute `yaml:"local-routes,omitempty" json:"local-routes,omitempty"`
+
+	// ModelMappings route models unavailable locally to models that are.
+	ModelMappings []AmpModelMapping `yaml:"model-mappings,omitempty" json:"model-mappings,omitempty"`
+
+	// ForceModelMappings applies model mappings before checking for local providers.
+	ForceModelMappings bool `yaml:"force-model-mappings,omitempty" json:"force-model-mappings,omitempty"`
+
+	// DisableResponseRewrite keeps the mapped model name in responses instead of restoring the
+	// model the client requested.
+	DisableResponseRewrite bool `yaml:"disable-response-rewrite,omitempty" json:"disable-response-rewrite,omitempty"`
+}
+
+// PassthroughLocalRoute binds a path under a passthrough module to one of our API handlers.
+type PassthroughLocalRoute struct {
+	// Path is relative to the mount path. A final "*name" segment matches the rest of the path,
+	// e.g. "/v1beta/models/*action" for the gemini handler.
+	Path string `yaml:"path" json:"path"`
+
+	// Handler names the API handler, e.g. "openai-chat-completions" or "claude-messages".
+	Handler string `yaml:"handler" json:"handler"`
+
+	// Method defaults to POST for generation handlers and GET for model listings.
+	Method string `yaml:"method,omitempty" json:"method,omitempty"`
+}
+
+// IsPassthroughHandler reports whether name is a known passthrough local route handler.
+func IsPassthroughHandler(name string) bool {
+	switch name {
+	case PassthroughHandlerOpenAIChat, PassthroughHandlerOpenAICompletions, PassthroughHandlerOpenAIResponses,
+		PassthroughHandlerOpenAIModels, PassthroughHandlerClaudeMessages, PassthroughHandlerClaudeCountTokens,
+		PassthroughHandlerClaudeModels, PassthroughHandlerGemini, PassthroughHandlerGeminiModels:
+		return true
+	default:
+		return false
+	}
+}
+
+// SanitizePassthroughModules normalizes mount paths and drops modules whose mount path is
+// missing, duplicated or owned by a built-in route, as well as local routes with unknown handlers.
+func (cfg *Config) SanitizePassthroughModules() {
+	if cfg == nil || len(cfg.PassthroughModules) == 0 {
+		return
+	}
+	seen := make(map[string]struct{}, len(cfg.PassthroughModules))
+	out := make([]PassthroughModule, 0, len(cfg.PassthroughModules))
+	for _, entry := range cfg.PassthroughModules {
+		entry.MountPath = normalizeMountPath(entry.MountPath)
+		if entry.MountPath == "" || isReservedMountPath(entry.MountPath) {
+			continue
+		}
+		key := strings.ToLower(entry.MountPath)
+		if _, dup := seen[key]; dup {
+			continue
+		}
+		seen[key] = struct{}{}
+		entry.Name = strings.TrimSpace(entry.Name)
+		if entry.Name == "" {
+			entry.Name = entry.MountPath
+		}
+		entry.UpstreamURL = strings.TrimRight(strings.TrimSpace(entry.UpstreamURL), "/")
+		entry.UpstreamAPIKey = strings.TrimSpace(entry.UpstreamAPIKey)
+		entry.AuthHeader = strings.TrimSpace(entry.AuthHeader)
+		if entry.AuthHeader == "" {
+			entry.AuthHeader = "Authorization"
+		}
+		entry.AuthScheme = strings.TrimSpace(entry.AuthScheme)
+		if entry.AuthScheme == "" && strings.EqualFold(entry.AuthHeader, "Authorization") {
+			entry.AuthScheme = "Bearer"
+		}
+		routes := make([]PassthroughLocalRoute, 0, len(entry.LocalRoutes))
+		for _, route := range entry.LocalRoutes {
+			route.Handler = strings.ToLower(strings.TrimSpace(route.Handler))
+			route.Path = "/" + strings.Trim(strings.TrimSpace(route.Path), "/")
+			if !IsPassthroughHandler(route.Handler) {
+				continue
+			}
+			route.Method = strings.ToUpper(strings.TrimSpace(route.Method))
+			if route.Method == "" {
+				route.Method = "POST"
+				if strings.HasSuffix(route.Handler, "-models") {
+					route.Method = "GET"
+				}
+			}
+			routes = append(routes, route)
+		}
+		entry.LocalRoutes = routes
+		out = append(out, entry)
+	}
+	cfg.PassthroughModules = out
+}
+
+// normalizeMountPath returns path with a single leading slash and no trailing slash, or "" when
+// path is empty, the root or contains route wildcards.
+func normalizeMountPath(path string) string {
+	path = strings.Trim(strings.TrimSpace(path), "/")
+	if path == "" || strings.ContainsAny(path, "*:") {
+		return ""
+	}
+	return "/" + path
+}
+
+func isReservedMountPath(path string) bool {
+	lower := strings.ToLower(path)
+	for _, prefix := range passthroughReservedPrefixes {
+		if lower == prefix || strings.HasPrefix(lower, prefix+"/") || strings.HasPrefix(prefix, lower+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package config
+
+import "strings"
+
+const (
+	// PromptCacheTTL5m is the default Claude cache lifetime.
+	PromptCacheTTL5m = "5m"
+	// PromptCacheTTL1h requests the extended one-hour Claude cache lifetime.
+	PromptCacheTTL1h = "1h"
+)
+
+// PromptCachingConfig controls the cache_control breakpoints the proxy adds to Claude requests,
+// whatever format the client used.
+type PromptCachingConfig struct {
+	// Disable stops automatic breakpoints. Breakpoints sent by clients are kept.
+	Disable bool `yaml:"disable,omitempty" json:"disable,omitempty"`
+
+	// TTL is the lifetime of added breakpoints: "5m" (default) or "1h".
+	TTL string `yaml:"ttl,omitempty" json:"ttl,omitempty"`
+
+	// Models overrides the settings per model. The first matching entry wins.
+	Models []PromptCachingModel `yaml:"models,omitempty" json:"models,omitempty"`
+}
+
+// PromptCachingModel overrides prompt caching for models matching Name.
+type PromptCachingModel struct {
+	// Name is the upstream model name; supports "*" wildcards.
+	Name string `yaml:"name" json:"name"`
+
+	// Disable turns automatic breakpoints off for the model.
+	Disable bool `yaml:"disable,omitempty" json:"disable,omitempty"`
+
+	// TTL overrides the global TTL for the model.
+	TTL string `yaml:"ttl,omitempty" json:"ttl,omitempty"`
+}
+
+// ForModel reports whether breakpoints are added for model and with which TTL.
+func (p PromptCachingConfig) ForModel(model string) (enabled bool, ttl string) {
+	enabled, ttl = !p.Disable, p.TTL
+	model = strings.ToLower(strings.TrimSpace(model))
+	for _, entry := range p.Models {
+		if !matchWildcardPattern(strings.ToLower(entry.Name), model) {
+			continue
+		}
+		enabled = !entry.Disable
+		if entry.TTL != "" {
+			ttl = entry.TTL
+		}
+		break
+	}
+	if ttl == "" {
+		ttl = PromptCacheTTL5m
+	}
+	return enabled, ttl
+}
+
+// SanitizePromptCaching normalizes TTLs and drops model entries without a name.
+func (cfg *Config) SanitizePromptCaching() {
+	if cfg == nil {
+		return
+	}
+	p := &cfg.PromptCaching
+	p.TTL = normalizePromptCacheTTL(p.TTL)
+	models := make([]PromptCachingModel, 0, len(p.Models))
+	for _, entry := range p.Models {
+		entry.Name = strings.TrimSpace(entry.Name)
+		if entry.Name == "" {
+			continue
+		}
+		entry.TTL = normalizePromptCacheTTL(entry.TTL)
+		models = append(models, entry)
+	}
+	p.Models = models
+}
+
+// normalizePromptCacheTTL maps a configured TTL to "5m", "1h" or empty when unset or unknown.
+func normalizePromptCacheTTL(ttl string) string {
+	switch strings.ToLower(strings.TrimSpace(ttl)) {
+	case PromptCacheTTL5m:
+		return PromptCacheTTL5m
+	case PromptCacheTTL1h, "60m":
+		return PromptCacheTTL1h
+	default:
+		return ""
+	}
+}
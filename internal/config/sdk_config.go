@@ -4,11 +4,17 @@
 // debug settings, proxy configuration, and API keys.
 package config
 
+import "strings"
+
 // SDKConfig represents the application's configuration, loaded from a YAML file.
 type SDKConfig struct {
 	// ProxyURL is the URL of an optional proxy server to use for outbound requests.
 	ProxyURL string `yaml:"proxy-url" json:"proxy-url"`
 
+	// UpstreamTLS configures custom CA bundles, client certificates and TLS versions
+	// for outbound upstream connections. Per-key upstream-tls settings override it.
+	UpstreamTLS UpstreamTLS `yaml:"upstream-tls,omitempty" json:"upstream-tls,omitempty"`
+
 	// ForceModelPrefix requires explicit model prefixes (e.g., "teamA/gemini-3-pro-preview")
 	// to target prefixed credentials. When false, unprefixed model requests may use prefixed
 	// credentials as well.
@@ -20,6 +26,13 @@ type SDKConfig struct {
 	// APIKeys is a list of keys for authenticating clients to this proxy server.
 	APIKeys []string `yaml:"api-keys" json:"api-keys"`
 
+	// APIKeyNames names client API keys (name -> key) in structured request logs and hook calls.
+	// Unnamed keys are identified by a short fingerprint.
+	APIKeyNames map[string]string `yaml:"api-key-names,omitempty" json:"api-key-names,omitempty"`
+
+	// BrowserAuth additionally accepts the proxy API key from a cookie or query parameter.
+	BrowserAuth BrowserAuthConfig `yaml:"browser-auth,omitempty" json:"browser-auth,omitempty"`
+
 	// PassthroughHeaders controls whether upstream response headers are forwarded to downstream clients.
 	// Default is false (disabled).
 	PassthroughHeaders bool `yaml:"passthrough-headers" json:"passthrough-headers"`
@@ -30,6 +43,24 @@ type SDKConfig struct {
 	// NonStreamKeepAliveInterval controls how often blank lines are emitted for non-streaming responses.
 	// <= 0 disables keep-alives. Value is in seconds.
 	NonStreamKeepAliveInterval int `yaml:"nonstream-keepalive-interval,omitempty" json:"nonstream-keepalive-interval,omitempty"`
+
+	// MCP configures MCP servers whose tools are attached to requests and executed by the proxy.
+	MCP MCPConfig `yaml:"mcp,omitempty" json:"mcp,omitempty"`
+
+	// FanOut defines virtual models that send one request to several models and combine the answers.
+	FanOut []FanOutModel `yaml:"fan-out,omitempty" json:"fan-out,omitempty"`
+
+	// StructuredOutput configures validation and repair of JSON answers requested via response_format.
+	StructuredOutput StructuredOutputConfig `yaml:"structured-output,omitempty" json:"structured-output,omitempty"`
+
+	// StrictTranslation rejects or flags requests using features the target translator would drop.
+	StrictTranslation StrictTranslationConfig `yaml:"strict-translation,omitempty" json:"strict-translation,omitempty"`
+
+	// Hooks lists out-of-process plugins that inspect, reject or rewrite requests and responses.
+	Hooks []HookConfig `yaml:"hooks,omitempty" json:"hooks,omitempty"`
+
+	// ModelLifecycle controls redirects and warnings for deprecated and retired models.
+	ModelLifecycle ModelLifecycleConfig `yaml:"model-lifecycle,omitempty" json:"model-lifecycle,omitempty"`
 }
 
 // StreamingConfig holds server streaming behavior configuration.
@@ -42,4 +73,75 @@ type StreamingConfig struct {
 	// to allow auth rotation / transient recovery.
 	// <= 0 disables bootstrap retries. Default is 0.
 	BootstrapRetries int `yaml:"bootstrap-retries,omitempty" json:"bootstrap-retries,omitempty"`
+
+	// MidStreamRetries controls how many times a stream interrupted after bytes were sent may be resumed
+	// on another credential, using the text already delivered as an assistant prefill.
+	// Only text answers of Claude-backed models on the Claude Messages and OpenAI Chat Completions
+	// endpoints are resumed. <= 0 disables mid-stream failover. Default is 0.
+	MidStreamRetries int `yaml:"midstream-retries,omitempty" json:"midstream-retries,omitempty"`
+}
+
+// UpstreamTLS holds TLS settings applied to outbound connections to upstream providers.
+type UpstreamTLS struct {
+	// CAFiles lists PEM bundles trusted in addition to the system roots
+	// (e.g. a corporate TLS inspection CA).
+	CAFiles []string `yaml:"ca-files,omitempty" json:"ca-files,omitempty"`
+
+	// ClientCert is the path to a PEM client certificate presented for mutual TLS.
+	ClientCert string `yaml:"client-cert,omitempty" json:"client-cert,omitempty"`
+
+	// ClientKey is the path to the PEM private key matching ClientCert.
+	ClientKey string `yaml:"client-key,omitempty" json:"client-key,omitempty"`
+
+	// ServerName overrides the server name used for SNI and certificate verification.
+	ServerName string `yaml:"server-name,omitempty" json:"server-name,omitempty"`
+
+	// MinVersion sets the minimum TLS version: "1.0", "1.1", "1.2" or "1.3".
+	MinVersion string `yaml:"min-version,omitempty" json:"min-version,omitempty"`
+}
+
+// IsZero reports whether no upstream TLS setting is configured.
+func (t UpstreamTLS) IsZero() bool {
+	return len(t.CAFiles) == 0 && t.ClientCert == "" && t.ClientKey == "" && t.ServerName == "" && t.MinVersion == ""
+}
+
+// Merge returns t overridden by the non-empty fields of override.
+// CA files are combined so per-key bundles extend the global trust roots.
+func (t UpstreamTLS) Merge(override UpstreamTLS) UpstreamTLS {
+	out := t
+	if len(override.CAFiles) > 0 {
+		out.CAFiles = append(append([]string(nil), t.CAFiles...), override.CAFiles...)
+	}
+	if override.ClientCert != "" || override.ClientKey != "" {
+		out.ClientCert = override.ClientCert
+		out.ClientKey = override.ClientKey
+	}
+	if override.ServerName != "" {
+		out.ServerName = override.ServerName
+	}
+	if override.MinVersion != "" {
+		out.MinVersion = override.MinVersion
+	}
+	return out
+}
+
+// Sanitize trims whitespace and drops empty CA entries.
+func (t *UpstreamTLS) Sanitize() {
+	if t == nil {
+		return
+	}
+	files := make([]string, 0, len(t.CAFiles))
+	for _, file := range t.CAFiles {
+		if trimmed := strings.TrimSpace(file); trimmed != "" {
+			files = append(files, trimmed)
+		}
+	}
+	if len(files) == 0 {
+		files = nil
+	}
+	t.CAFiles = files
+	t.ClientCert = strings.TrimSpace(t.ClientCert)
+	t.ClientKey = strings.TrimSpace(t.ClientKey)
+	t.ServerName = strings.TrimSpace(t.ServerName)
+	t.MinVersion = strings.TrimSpace(t.MinVersion)
 }
@@ -0,0 +1,350 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// secretRefCommandTimeout bounds how long a ${cmd:...} secret reference may run.
+const secretRefCommandTimeout = 10 * time.Second
+
+// SecretRefSourcesEnv lists the secret reference sources enabled in addition to env,
+// e.g. "file,cmd". File and command references can read any file or run any command
+// as the proxy user, so they are only honoured when the host opts in.
+const SecretRefSourcesEnv = "CLIPROXY_SECRET_REF_SOURCES"
+
+// secretRefPattern matches scalar values that are entirely a secret reference,
+// e.g. ${env:CLAUDE_API_KEY}, ${file:/run/secrets/claude} or ${cmd:pass show claude}.
+var secretRefPattern = regexp.MustCompile(`^\$\{(env|file|cmd):(.+)\}$`)
+
+// secretRefKey identifies a resolved secret by the value it resolved to and the path of that
+// value. Sequence indexes are left out of the path ("claude-api-key[].api-key") so references
+// survive entries being added, removed or reordered.
+type secretRefKey struct {
+	path  string
+	value string
+}
+
+// IsSecretRef reports whether value is a secret reference such as ${env:NAME}.
+func IsSecretRef(value string) bool {
+	return secretRefPattern.MatchString(strings.TrimSpace(value))
+}
+
+// secretRefSource returns the source of a secret reference, or "" when value is not one.
+func secretRefSource(value string) string {
+	match := secretRefPattern.FindStringSubmatch(strings.TrimSpace(value))
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+// isHostSecretRef reports whether value is a ${file:...} or ${cmd:...} reference.
+func isHostSecretRef(value string) bool {
+	source := secretRefSource(value)
+	return source == "file" || source == "cmd"
+}
+
+// secretRefSourceEnabled reports whether the host enabled a secret reference source.
+func secretRefSourceEnabled(source string) bool {
+	if source == "env" {
+		return true
+	}
+	for _, enabled := range strings.Split(os.Getenv(SecretRefSourcesEnv), ",") {
+		if strings.EqualFold(strings.TrimSpace(enabled), source) {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveSecretRef resolves a single secret reference to its plaintext value.
+// Supported sources:
+//   - ${env:NAME}: the environment variable NAME (must be set)
+//   - ${file:/path}: the file contents with surrounding whitespace trimmed
+//   - ${cmd:command}: the trimmed stdout of a shell command
+//
+// The file and cmd sources are disabled unless listed in CLIPROXY_SECRET_REF_SOURCES.
+func ResolveSecretRef(ref string) (string, error) {
+	match := secretRefPattern.FindStringSubmatch(strings.TrimSpace(ref))
+	if match == nil {
+		return "", fmt.Errorf("invalid secret reference")
+	}
+	source, target := match[1], strings.TrimSpace(match[2])
+	if !secretRefSourceEnabled(source) {
+		return "", fmt.Errorf("secret reference source %q is disabled; set %s=%s to enable it", source, SecretRefSourcesEnv, source)
+	}
+	switch source {
+	case "env":
+		value, ok := os.LookupEnv(target)
+		if !ok {
+			return "", fmt.Errorf("secret reference ${env:%s}: environment variable not set", target)
+		}
+		return strings.TrimSpace(value), nil
+	case "file":
+		data, errRead := os.ReadFile(target)
+		if errRead != nil {
+			return "", fmt.Errorf("secret reference ${file:%s}: %w", target, errRead)
+		}
+		return strings.TrimSpace(string(data)), nil
+	case "cmd":
+		ctx, cancel := context.WithTimeout(context.Background(), secretRefCommandTimeout)
+		defer cancel()
+		var cmd *exec.Cmd
+		if runtime.GOOS == "windows" {
+			cmd = exec.CommandContext(ctx, "cmd", "/C", target)
+		} else {
+			cmd = exec.CommandContext(ctx, "sh", "-c", target)
+		}
+		out, errRun := cmd.Output()
+		if errRun != nil {
+			// Never echo the command output; it may contain the secret.
+			return "", fmt.Errorf("secret reference ${cmd:...}: %w", errRun)
+		}
+		return strings.TrimSpace(string(out)), nil
+	default:
+		return "", fmt.Errorf("unsupported secret reference source: %s", source)
+	}
+}
+
+// ValidateUploadedSecretRefs rejects ${file:...} and ${cmd:...} references in a config uploaded
+// through the management API unless the current config already contains the same reference.
+// Management clients can therefore keep the references an operator wrote but cannot add new
+// ones to read host files or run commands.
+func ValidateUploadedSecretRefs(uploaded, current []byte) error {
+	var uploadedRoot, currentRoot yaml.Node
+	if err := yaml.Unmarshal(uploaded, &uploadedRoot); err != nil {
+		return err
+	}
+	allowed := make(map[string]struct{})
+	if yaml.Unmarshal(current, &currentRoot) == nil {
+		walkSecretRefScalars(&currentRoot, "", func(_ string, node *yaml.Node) error {
+			allowed[strings.TrimSpace(node.Value)] = struct{}{}
+			return nil
+		})
+	}
+	return walkSecretRefScalars(&uploadedRoot, "", func(_ string, node *yaml.Node) error {
+		ref := strings.TrimSpace(node.Value)
+		if !isHostSecretRef(ref) {
+			return nil
+		}
+		if _, ok := allowed[ref]; !ok {
+			return fmt.Errorf("line %d: %s secret references cannot be added through the management API", node.Line, secretRefSource(ref))
+		}
+		return nil
+	})
+}
+
+// walkSecretRefScalars calls fn for every string scalar value holding a secret reference,
+// passing the path of the value.
+func walkSecretRefScalars(node *yaml.Node, path string, fn func(path string, node *yaml.Node) error) error {
+	if node == nil {
+		return nil
+	}
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			if err := walkSecretRefScalars(child, path, fn); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		for _, child := range node.Content {
+			if err := walkSecretRefScalars(child, path+"[]", fn); err != nil {
+				return err
+			}
+		}
+	case yaml.MappingNode:
+		// Only values can hold references; keys are left untouched.
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if err := walkSecretRefScalars(node.Content[i+1], joinSecretRefPath(path, node.Content[i].Value), fn); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		if node.Tag != "!!str" && node.Tag != "" {
+			return nil
+		}
+		if IsSecretRef(node.Value) {
+			return fn(path, node)
+		}
+	}
+	return nil
+}
+
+func joinSecretRefPath(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+// resolveSecretRefsInNode walks a YAML node tree, replacing every string scalar that is a
+// secret reference with its resolved value. Each reference is recorded in refs under the
+// path of its value so it can be restored when the config is saved or displayed.
+func resolveSecretRefsInNode(node *yaml.Node, refs map[secretRefKey]string) error {
+	return walkSecretRefScalars(node, "", func(path string, node *yaml.Node) error {
+		ref := strings.TrimSpace(node.Value)
+		resolved, err := ResolveSecretRef(ref)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		if resolved == "" {
+			return fmt.Errorf("line %d: secret reference resolved to an empty value", node.Line)
+		}
+		node.Value = resolved
+		node.Tag = "!!str"
+		refs[secretRefKey{path: path, value: resolved}] = ref
+		return nil
+	})
+}
+
+// restoreSecretRefsInNode writes the original references back into a generated YAML node tree
+// so plaintext secrets are never written to disk. A value is only restored at the path it was
+// loaded from, so equal strings elsewhere in the document are left alone. File and command
+// references that did not come from the loaded file (e.g. set through the management API) are
+// rejected.
+func restoreSecretRefsInNode(node *yaml.Node, refs map[secretRefKey]string) error {
+	return restoreSecretRefsAt(node, "", refs)
+}
+
+func restoreSecretRefsAt(node *yaml.Node, path string, refs map[secretRefKey]string) error {
+	if node == nil {
+		return nil
+	}
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			if err := restoreSecretRefsAt(child, path, refs); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		for _, child := range node.Content {
+			if err := restoreSecretRefsAt(child, path+"[]", refs); err != nil {
+				return err
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if err := restoreSecretRefsAt(node.Content[i+1], joinSecretRefPath(path, node.Content[i].Value), refs); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		if node.Tag != "!!str" {
+			return nil
+		}
+		if isHostSecretRef(node.Value) {
+			return fmt.Errorf("%s: %s secret references cannot be set at runtime", path, secretRefSource(node.Value))
+		}
+		if ref, ok := refs[secretRefKey{path: path, value: node.Value}]; ok {
+			node.Value = ref
+		}
+	}
+	return nil
+}
+
+// SecretRefs returns a copy of the value path to reference mapping collected at load time.
+func (cfg *Config) SecretRefs() map[string]string {
+	if cfg == nil || len(cfg.secretRefs) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(cfg.secretRefs))
+	for key, ref := range cfg.secretRefs {
+		out[key.path] = ref
+	}
+	return out
+}
+
+// MaskSecretRefs walks a JSON-compatible value (maps, slices, strings) shaped like the config
+// and replaces strings resolved from a secret reference with the reference itself.
+func (cfg *Config) MaskSecretRefs(value any) any {
+	return cfg.MaskSecretRefsAt("", value)
+}
+
+// MaskSecretRefsAt is MaskSecretRefs for a value found at path (e.g. "ampcode") in the config.
+func (cfg *Config) MaskSecretRefsAt(path string, value any) any {
+	if cfg == nil || len(cfg.secretRefs) == 0 {
+		return value
+	}
+	return maskSecretRefValue(value, path, cfg.secretRefs)
+}
+
+func maskSecretRefValue(value any, path string, refs map[secretRefKey]string) any {
+	switch typed := value.(type) {
+	case string:
+		if ref, ok := refs[secretRefKey{path: path, value: typed}]; ok {
+			return ref
+		}
+		return typed
+	case map[string]any:
+		for key, item := range typed {
+			typed[key] = maskSecretRefValue(item, joinSecretRefPath(path, key), refs)
+		}
+		return typed
+	case []any:
+		for i, item := range typed {
+			typed[i] = maskSecretRefValue(item, path+"[]", refs)
+		}
+		return typed
+	default:
+		return value
+	}
+}
+
+// UnmaskSecretRefsAt reverses MaskSecretRefsAt for a value submitted through the management
+// API. A reference equal to the one loaded at the same path is replaced with its resolved value,
+// so a masked GET response can be sent back unchanged. Any other reference is rejected: it was
+// not resolved at load time and would otherwise be used as a literal secret.
+func (cfg *Config) UnmaskSecretRefsAt(path string, value any) (any, error) {
+	var refs map[secretRefKey]string
+	if cfg != nil {
+		refs = cfg.secretRefs
+	}
+	return unmaskSecretRefValue(value, path, refs)
+}
+
+func unmaskSecretRefValue(value any, path string, refs map[secretRefKey]string) (any, error) {
+	switch typed := value.(type) {
+	case string:
+		ref := strings.TrimSpace(typed)
+		if !IsSecretRef(ref) {
+			return typed, nil
+		}
+		for key, loaded := range refs {
+			if key.path == path && loaded == ref {
+				return key.value, nil
+			}
+		}
+		return nil, fmt.Errorf("%s: %s secret references can only be kept as loaded from the config file", path, secretRefSource(ref))
+	case map[string]any:
+		for key, item := range typed {
+			unmasked, err := unmaskSecretRefValue(item, joinSecretRefPath(path, key), refs)
+			if err != nil {
+				return nil, err
+			}
+			typed[key] = unmasked
+		}
+		return typed, nil
+	case []any:
+		for i, item := range typed {
+			unmasked, err := unmaskSecretRefValue(item, path+"[]", refs)
+			if err != nil {
+				return nil, err
+			}
+			typed[i] = unmasked
+		}
+		return typed, nil
+	default:
+		return value, nil
+	}
+}
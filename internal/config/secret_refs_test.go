@@ -0,0 +1,181 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfig_ResolvesSecretReferences(t *testing.T) {
+	dir := t.TempDir()
+	secretPath := filepath.Join(dir, "codex-key")
+	if err := os.WriteFile(secretPath, []byte("sk-from-file\n"), 0o600); err != nil {
+		t.Fatalf("write secret file: %v", err)
+	}
+	t.Setenv("CLIPROXY_TEST_CLAUDE_KEY", "sk-from-env")
+	t.Setenv(SecretRefSourcesEnv, "file")
+
+	configPath := filepath.Join(dir, "config.yaml")
+	yamlData := `port: 8317
+claude-api-key:
+  - api-key: "${env:CLIPROXY_TEST_CLAUDE_KEY}"
+codex-api-key:
+  - api-key: ${file:` + secretPath + `}
+    base-url: "https://example.com"
+`
+	if err := os.WriteFile(configPath, []byte(yamlData), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig returned error: %v", err)
+	}
+	if got := cfg.ClaudeKey[0].APIKey; got != "sk-from-env" {
+		t.Fatalf("claude api-key = %q, want sk-from-env", got)
+	}
+	if got := cfg.CodexKey[0].APIKey; got != "sk-from-file" {
+		t.Fatalf("codex api-key = %q, want sk-from-file", got)
+	}
+
+	masked, ok := cfg.MaskSecretRefs(map[string]any{
+		"claude-api-key": []any{map[string]any{"api-key": "sk-from-env"}},
+		"codex-api-key":  []any{map[string]any{"api-key": "sk-from-env"}},
+	}).(map[string]any)
+	if !ok {
+		t.Fatalf("masked value has unexpected type")
+	}
+	if got := masked["claude-api-key"].([]any)[0].(map[string]any)["api-key"]; got != "${env:CLIPROXY_TEST_CLAUDE_KEY}" {
+		t.Fatalf("masked claude api-key = %v, want env reference", got)
+	}
+	if got := masked["codex-api-key"].([]any)[0].(map[string]any)["api-key"]; got != "sk-from-env" {
+		t.Fatalf("equal value at another path was masked: %v", got)
+	}
+
+	cfg.Debug = true
+	if err = SaveConfigPreserveComments(configPath, cfg); err != nil {
+		t.Fatalf("SaveConfigPreserveComments returned error: %v", err)
+	}
+	saved, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("read saved config: %v", err)
+	}
+	if strings.Contains(string(saved), "sk-from-env") || strings.Contains(string(saved), "sk-from-file") {
+		t.Fatalf("saved config leaked resolved secrets:\n%s", saved)
+	}
+	if !strings.Contains(string(saved), "${env:CLIPROXY_TEST_CLAUDE_KEY}") {
+		t.Fatalf("saved config lost env reference:\n%s", saved)
+	}
+}
+
+func TestLoadConfig_UnsetSecretReferenceFails(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	yamlData := `api-keys:
+  - "${env:CLIPROXY_TEST_UNSET_SECRET}"
+`
+	if err := os.WriteFile(configPath, []byte(yamlData), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	if _, err := LoadConfig(configPath); err == nil {
+		t.Fatal("expected error for unset environment variable reference")
+	}
+}
+
+func TestLoadConfig_ManagementSecretReferenceNotRewritten(t *testing.T) {
+	t.Setenv("CLIPROXY_TEST_MGMT_SECRET", "management-secret")
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	yamlData := `remote-management:
+  secret-key: "${env:CLIPROXY_TEST_MGMT_SECRET}"
+`
+	if err := os.WriteFile(configPath, []byte(yamlData), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig returned error: %v", err)
+	}
+	if !looksLikeBcrypt(cfg.RemoteManagement.SecretKey) {
+		t.Fatalf("expected in-memory secret to be hashed, got %q", cfg.RemoteManagement.SecretKey)
+	}
+	saved, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("read config: %v", err)
+	}
+	if string(saved) != yamlData {
+		t.Fatalf("config file was rewritten:\n%s", saved)
+	}
+}
+
+func TestLoadConfig_HostSecretReferencesNeedOptIn(t *testing.T) {
+	dir := t.TempDir()
+	secretPath := filepath.Join(dir, "secret")
+	if err := os.WriteFile(secretPath, []byte("sk-from-file"), 0o600); err != nil {
+		t.Fatalf("write secret file: %v", err)
+	}
+	configPath := filepath.Join(dir, "config.yaml")
+	yamlData := "api-keys:\n  - \"${file:" + secretPath + "}\"\n"
+	if err := os.WriteFile(configPath, []byte(yamlData), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	t.Setenv(SecretRefSourcesEnv, "")
+	if _, err := LoadConfig(configPath); err == nil {
+		t.Fatal("expected file reference to be rejected without opt-in")
+	}
+	t.Setenv(SecretRefSourcesEnv, "cmd, file")
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig returned error: %v", err)
+	}
+	if len(cfg.APIKeys) != 1 || cfg.APIKeys[0] != "sk-from-file" {
+		t.Fatalf("api-keys = %v, want sk-from-file", cfg.APIKeys)
+	}
+}
+
+func TestSaveConfig_RestoresSecretReferencesByPath(t *testing.T) {
+	t.Setenv("CLIPROXY_TEST_SHARED_SECRET", "shared-value")
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	yamlData := `claude-api-key:
+  - api-key: "${env:CLIPROXY_TEST_SHARED_SECRET}"
+`
+	if err := os.WriteFile(configPath, []byte(yamlData), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig returned error: %v", err)
+	}
+	cfg.ClaudeKey = append([]ClaudeKey{{APIKey: "other-key", Prefix: "shared-value"}}, cfg.ClaudeKey...)
+	if err = SaveConfigPreserveComments(configPath, cfg); err != nil {
+		t.Fatalf("SaveConfigPreserveComments returned error: %v", err)
+	}
+	saved, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("read saved config: %v", err)
+	}
+	if !strings.Contains(string(saved), "prefix: shared-value") {
+		t.Fatalf("equal value at another path was rewritten:\n%s", saved)
+	}
+	if !strings.Contains(string(saved), "${env:CLIPROXY_TEST_SHARED_SECRET}") || strings.Count(string(saved), "shared-value") != 1 {
+		t.Fatalf("moved entry lost its reference:\n%s", saved)
+	}
+
+	cfg.ClaudeKey[0].APIKey = "${cmd:id}"
+	if err = SaveConfigPreserveComments(configPath, cfg); err == nil {
+		t.Fatal("expected command reference set at runtime to be rejected")
+	}
+}
+
+func TestValidateUploadedSecretRefs(t *testing.T) {
+	current := []byte("api-keys:\n  - \"${cmd:pass show proxy}\"\n")
+	if err := ValidateUploadedSecretRefs([]byte("api-keys:\n  - \"${cmd:pass show proxy}\"\n  - \"${env:KEY}\"\n"), current); err != nil {
+		t.Fatalf("existing command reference rejected: %v", err)
+	}
+	if err := ValidateUploadedSecretRefs([]byte("api-keys:\n  - \"${cmd:cat /etc/passwd}\"\n"), current); err == nil {
+		t.Fatal("expected new command reference to be rejected")
+	}
+	if err := ValidateUploadedSecretRefs([]byte("claude-api-key:\n  - api-key: ${file:/etc/shadow}\n"), nil); err == nil {
+		t.Fatal("expected new file reference to be rejected")
+	}
+}
@@ -0,0 +1,69 @@
+package config
+
+import "strings"
+
+const (
+	// StrictTranslationOff forwards requests even when the translator drops some of their fields.
+	StrictTranslationOff = "off"
+	// StrictTranslationWarn forwards such requests and names the dropped fields in a response header.
+	StrictTranslationWarn = "warn"
+	// StrictTranslationReject answers such requests with 400 naming the dropped fields.
+	StrictTranslationReject = "reject"
+)
+
+// StrictTranslationConfig controls how requests using features the target translator would
+// drop (for example logprobs, n or seed on backends without them) are handled.
+type StrictTranslationConfig struct {
+	// Mode is "off" (default), "warn" or "reject".
+	Mode string `yaml:"mode,omitempty" json:"mode,omitempty"`
+
+	// APIKeys overrides Mode for requests authenticated with the given client API keys.
+	APIKeys map[string]string `yaml:"api-keys,omitempty" json:"api-keys,omitempty"`
+}
+
+// NormalizeStrictTranslationMode returns the canonical mode for value, or "" when unknown.
+func NormalizeStrictTranslationMode(value string) string {
+	switch mode := strings.ToLower(strings.TrimSpace(value)); mode {
+	case StrictTranslationOff, StrictTranslationWarn, StrictTranslationReject:
+		return mode
+	case "false", "0", "disabled":
+		return StrictTranslationOff
+	case "true", "1", "strict", "enabled":
+		return StrictTranslationReject
+	}
+	return ""
+}
+
+// ModeFor returns the effective mode for a client API key.
+func (c StrictTranslationConfig) ModeFor(apiKey string) string {
+	if mode, ok := c.APIKeys[apiKey]; ok && apiKey != "" {
+		return mode
+	}
+	if c.Mode == "" {
+		return StrictTranslationOff
+	}
+	return c.Mode
+}
+
+// SanitizeStrictTranslation normalizes modes and drops per-key entries with unknown modes.
+func (cfg *Config) SanitizeStrictTranslation() {
+	if cfg == nil {
+		return
+	}
+	cfg.StrictTranslation.Mode = NormalizeStrictTranslationMode(cfg.StrictTranslation.Mode)
+	if len(cfg.StrictTranslation.APIKeys) == 0 {
+		cfg.StrictTranslation.APIKeys = nil
+		return
+	}
+	keys := make(map[string]string, len(cfg.StrictTranslation.APIKeys))
+	for key, mode := range cfg.StrictTranslation.APIKeys {
+		key = strings.TrimSpace(key)
+		if mode = NormalizeStrictTranslationMode(mode); key != "" && mode != "" {
+			keys[key] = mode
+		}
+	}
+	if len(keys) == 0 {
+		keys = nil
+	}
+	cfg.StrictTranslation.APIKeys = keys
+}
@@ -0,0 +1,29 @@
+package config
+
+// MaxStructuredOutputRepairRetries caps structured-output.repair-retries.
+const MaxStructuredOutputRepairRetries = 3
+
+// StructuredOutputConfig controls proxy-side checks of JSON answers requested through
+// OpenAI response_format or Responses text.format.
+type StructuredOutputConfig struct {
+	// Validate checks non-streaming JSON answers against the requested schema and rejects
+	// answers that still do not conform after the repair retries.
+	Validate bool `yaml:"validate,omitempty" json:"validate,omitempty"`
+
+	// RepairRetries controls how many times a nonconforming answer is sent back to the model
+	// together with the validation errors. <= 0 disables repair. Capped at 3.
+	RepairRetries int `yaml:"repair-retries,omitempty" json:"repair-retries,omitempty"`
+}
+
+// SanitizeStructuredOutput clamps the repair retry count.
+func (cfg *Config) SanitizeStructuredOutput() {
+	if cfg == nil {
+		return
+	}
+	if cfg.StructuredOutput.RepairRetries < 0 {
+		cfg.StructuredOutput.RepairRetries = 0
+	}
+	if cfg.StructuredOutput.RepairRetries > MaxStructuredOutputRepairRetries {
+		cfg.StructuredOutput.RepairRetries = MaxStructuredOutputRepairRetries
+	}
+}
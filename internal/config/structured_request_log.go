@@ -0,0 +1,117 @@
+package config
+
+import "strings"
+
+// Defaults applied to structured-request-log by SanitizeStructuredRequestLog.
+const (
+	DefaultStructuredRequestLogFile         = "requests.jsonl"
+	DefaultStructuredRequestLogMaxBodyBytes = 64 * 1024
+	DefaultStructuredRequestLogMaxSizeMB    = 100
+	DefaultStructuredRequestLogMaxBackups   = 10
+)
+
+// StructuredRequestLogConfig switches request logging from one text file per request to a
+// JSONL stream with one object per request. It only takes effect while request-log is enabled
+// (or for error logs when it is disabled). Changes take effect after a restart.
+type StructuredRequestLogConfig struct {
+	// Enable writes request logs as JSON lines instead of text files.
+	Enable bool `yaml:"enable" json:"enable"`
+
+	// File is the active segment name inside the logs directory. Defaults to "requests.jsonl".
+	File string `yaml:"file,omitempty" json:"file,omitempty"`
+
+	// MaxBodyBytes truncates each logged body (client request, response and the translated
+	// upstream exchange) to this many bytes. 0 applies the 64 KiB default; -1 omits bodies.
+	MaxBodyBytes int `yaml:"max-body-bytes,omitempty" json:"max-body-bytes,omitempty"`
+
+	// SampleRates keeps only a fraction of requests per status class ("2xx", "3xx", "4xx",
+	// "5xx"). Rates are between 0 and 1; classes not listed are always logged.
+	SampleRates map[string]float64 `yaml:"sample-rates,omitempty" json:"sample-rates,omitempty"`
+
+	// RedactHeaders lists additional request and response headers whose values are replaced.
+	// Authorization, Proxy-Authorization, Cookie, Set-Cookie, X-Api-Key and X-Goog-Api-Key are
+	// always redacted.
+	RedactHeaders []string `yaml:"redact-headers,omitempty" json:"redact-headers,omitempty"`
+
+	// RedactBodyFields lists JSON paths (gjson syntax, "#" matches every array element) whose
+	// values are replaced in JSON request and response bodies, e.g. "messages.#.content".
+	RedactBodyFields []string `yaml:"redact-body-fields,omitempty" json:"redact-body-fields,omitempty"`
+
+	// MaxSizeMB rotates the active segment once it grows beyond this size. Defaults to 100.
+	MaxSizeMB int `yaml:"max-size-mb,omitempty" json:"max-size-mb,omitempty"`
+
+	// MaxBackups limits the number of closed segments kept on disk. Defaults to 10; -1 keeps all.
+	MaxBackups int `yaml:"max-backups,omitempty" json:"max-backups,omitempty"`
+
+	// Compress gzips closed segments.
+	Compress bool `yaml:"compress,omitempty" json:"compress,omitempty"`
+
+	// Upload copies closed segments to the object store bucket when the object-backed token
+	// store is in use.
+	Upload bool `yaml:"upload,omitempty" json:"upload,omitempty"`
+}
+
+// SanitizeStructuredRequestLog applies defaults, clamps sample rates and normalizes the
+// redaction lists.
+func (cfg *Config) SanitizeStructuredRequestLog() {
+	if cfg == nil {
+		return
+	}
+	c := &cfg.StructuredRequestLog
+	c.File = strings.TrimSpace(c.File)
+	if c.File == "" {
+		c.File = DefaultStructuredRequestLogFile
+	}
+	if c.MaxBodyBytes == 0 {
+		c.MaxBodyBytes = DefaultStructuredRequestLogMaxBodyBytes
+	} else if c.MaxBodyBytes < 0 {
+		c.MaxBodyBytes = -1
+	}
+	if c.MaxSizeMB <= 0 {
+		c.MaxSizeMB = DefaultStructuredRequestLogMaxSizeMB
+	}
+	if c.MaxBackups == 0 {
+		c.MaxBackups = DefaultStructuredRequestLogMaxBackups
+	} else if c.MaxBackups < 0 {
+		c.MaxBackups = -1
+	}
+	if len(c.SampleRates) > 0 {
+		rates := make(map[string]float64, len(c.SampleRates))
+		for class, rate := range c.SampleRates {
+			class = strings.ToLower(strings.TrimSpace(class))
+			if class == "" {
+				continue
+			}
+			if rate < 0 {
+				rate = 0
+			}
+			if rate > 1 {
+				rate = 1
+			}
+			rates[class] = rate
+		}
+		c.SampleRates = rates
+	}
+	c.RedactHeaders = normalizeStringList(c.RedactHeaders)
+	c.RedactBodyFields = normalizeStringList(c.RedactBodyFields)
+}
+
+func normalizeStringList(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	seen := make(map[string]struct{}, len(values))
+	out := make([]string, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if _, dup := seen[value]; dup {
+			continue
+		}
+		seen[value] = struct{}{}
+		out = append(out, value)
+	}
+	return out
+}
@@ -28,6 +28,9 @@ type VertexCompatKey struct {
 	// ProxyURL optionally overrides the global proxy for this API key.
 	ProxyURL string `yaml:"proxy-url,omitempty" json:"proxy-url,omitempty"`
 
+	// UpstreamTLS overrides the global upstream-tls settings for this API key.
+	UpstreamTLS *UpstreamTLS `yaml:"upstream-tls,omitempty" json:"upstream-tls,omitempty"`
+
 	// Headers optionally adds extra HTTP headers for requests sent with this key.
 	// Commonly used for cookies, user-agent, and other authentication headers.
 	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
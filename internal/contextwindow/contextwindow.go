@@ -0,0 +1,173 @@
+// Package contextwindow shrinks conversation payloads that would exceed the context window of
+// the model they are sent to. Tokens are estimated locally with tiktoken; the configured policies
+// drop the oldest turns, strip old images and tool results, or replace the middle of the
+// conversation with a summary.
+package contextwindow
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// Header is the response header describing the context management applied to a request.
+const Header = "X-CPA-Context-Management"
+
+// Summarizer condenses a transcript of earlier conversation turns.
+type Summarizer func(ctx context.Context, transcript string) (string, error)
+
+// Options describes how a payload is fitted.
+type Options struct {
+	// Format is the payload schema: FormatOpenAI, FormatClaude or FormatOpenAIResponses.
+	Format string
+	// Model is the target model; it selects the tokenizer.
+	Model string
+	// Limit is the token budget of the request.
+	Limit int
+	// Policies are applied in order until the payload fits.
+	Policies []string
+	// KeepRecentTurns is the number of latest turns never stripped or summarized.
+	KeepRecentTurns int
+	// Summarize is required by the summarize policy.
+	Summarize Summarizer
+}
+
+// Report records what Fit changed.
+type Report struct {
+	Applied         []string
+	TokensBefore    int
+	TokensAfter     int
+	DroppedTurns    int
+	StrippedBlocks  int
+	SummarizedTurns int
+}
+
+// Changed reports whether the payload was modified.
+func (r Report) Changed() bool {
+	return len(r.Applied) > 0
+}
+
+// String renders the report for the response header, for example
+// "strip,truncate; tokens=182000->118000; stripped=14; dropped-turns=3".
+func (r Report) String() string {
+	parts := []string{strings.Join(r.Applied, ","), fmt.Sprintf("tokens=%d->%d", r.TokensBefore, r.TokensAfter)}
+	if r.StrippedBlocks > 0 {
+		parts = append(parts, fmt.Sprintf("stripped=%d", r.StrippedBlocks))
+	}
+	if r.SummarizedTurns > 0 {
+		parts = append(parts, fmt.Sprintf("summarized-turns=%d", r.SummarizedTurns))
+	}
+	if r.DroppedTurns > 0 {
+		parts = append(parts, fmt.Sprintf("dropped-turns=%d", r.DroppedTurns))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Fit applies the configured policies until the payload fits opts.Limit. Payloads that already
+// fit, or whose format has no supported conversation, are returned unchanged. The latest turn
+// is always kept, so a payload may still exceed the limit after Fit.
+func Fit(ctx context.Context, payload []byte, opts Options) ([]byte, Report) {
+	var report Report
+	if opts.Limit <= 0 || len(payload) == 0 {
+		return payload, report
+	}
+	tokens, err := newCounter(opts.Model)
+	if err != nil {
+		log.Debugf("context window: tokenizer unavailable for %s: %v", opts.Model, err)
+		return payload, report
+	}
+	total := tokens.count(string(payload))
+	report.TokensBefore, report.TokensAfter = total, total
+	if total <= opts.Limit {
+		return payload, report
+	}
+	conv := parseConversation(opts.Format, payload)
+	if conv == nil || len(conv.turns) == 0 {
+		return payload, report
+	}
+	for i := range conv.turns {
+		conv.turns[i].tokens = tokens.countItems(conv.turns[i].items)
+	}
+	keep := max(opts.KeepRecentTurns, 1)
+
+	for _, policy := range opts.Policies {
+		if total <= opts.Limit {
+			break
+		}
+		saved := 0
+		switch policy {
+		case config.ContextPolicyStrip:
+			stripped := 0
+			for i := 0; i < len(conv.turns)-keep; i++ {
+				t := &conv.turns[i]
+				n := 0
+				for j, item := range t.items {
+					var count int
+					t.items[j], count = conv.stripItem(item)
+					n += count
+				}
+				if n == 0 {
+					continue
+				}
+				stripped += n
+				before := t.tokens
+				t.tokens = tokens.countItems(t.items)
+				saved += before - t.tokens
+			}
+			report.StrippedBlocks += stripped
+			if stripped == 0 {
+				continue
+			}
+		case config.ContextPolicySummarize:
+			// The first turn usually states the task; it and the latest turns stay verbatim.
+			middle := len(conv.turns) - keep - 1
+			if opts.Summarize == nil || middle < 2 {
+				continue
+			}
+			summarized := conv.turns[1 : 1+middle]
+			summary, errSummarize := opts.Summarize(ctx, transcript(summarized))
+			if errSummarize != nil || strings.TrimSpace(summary) == "" {
+				log.Warnf("context window: summarization skipped: %v", errSummarize)
+				continue
+			}
+			replacement := turn{items: []string{conv.summaryItem(strings.TrimSpace(summary))}}
+			replacement.tokens = tokens.countItems(replacement.items)
+			for _, t := range summarized {
+				saved += t.tokens
+			}
+			saved -= replacement.tokens
+			turns := make([]turn, 0, len(conv.turns)-middle+1)
+			turns = append(turns, conv.turns[0], replacement)
+			conv.turns = append(turns, conv.turns[1+middle:]...)
+			report.SummarizedTurns += middle
+		case config.ContextPolicyTruncate:
+			dropped := 0
+			for len(conv.turns) > 1 && total-saved > opts.Limit {
+				saved += conv.turns[0].tokens
+				conv.turns = conv.turns[1:]
+				dropped++
+			}
+			report.DroppedTurns += dropped
+			if dropped == 0 {
+				continue
+			}
+		default:
+			continue
+		}
+		total -= saved
+		report.Applied = append(report.Applied, policy)
+	}
+	if !report.Changed() {
+		return payload, report
+	}
+	out, err := conv.encode(payload)
+	if err != nil {
+		log.Warnf("context window: failed to rewrite %s payload: %v", opts.Format, err)
+		return payload, Report{TokensBefore: report.TokensBefore, TokensAfter: report.TokensBefore}
+	}
+	report.TokensAfter = tokens.count(string(out))
+	return out, report
+}
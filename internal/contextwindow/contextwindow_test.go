@@ -0,0 +1,140 @@
+package contextwindow
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/tidwall/gjson"
+)
+
+var filler = strings.Repeat("lorem ipsum dolor sit amet ", 200)
+
+func claudeConversation(turns int) []byte {
+	messages := make([]string, 0, turns*4)
+	for i := 0; i < turns; i++ {
+		messages = append(messages,
+			fmt.Sprintf(`{"role":"user","content":"task %d %s"}`, i, filler),
+			fmt.Sprintf(`{"role":"assistant","content":[{"type":"tool_use","id":"call_%d","name":"read","input":{"path":"f%d"}}]}`, i, i),
+			fmt.Sprintf(`{"role":"user","content":[{"type":"tool_result","tool_use_id":"call_%d","content":"%s"}]}`, i, filler),
+			fmt.Sprintf(`{"role":"assistant","content":"done %d"}`, i),
+		)
+	}
+	return []byte(`{"system":"be brief","messages":[` + strings.Join(messages, ",") + `]}`)
+}
+
+func TestFitTruncateKeepsToolPairs(t *testing.T) {
+	payload := claudeConversation(10)
+	out, report := Fit(context.Background(), payload, Options{
+		Format:   FormatClaude,
+		Model:    "claude-sonnet-4",
+		Limit:    5000,
+		Policies: []string{config.ContextPolicyTruncate},
+	})
+	if report.DroppedTurns == 0 || report.TokensAfter > 5000 || report.TokensAfter >= report.TokensBefore {
+		t.Fatalf("report = %+v", report)
+	}
+	messages := gjson.GetBytes(out, "messages").Array()
+	if len(messages)%4 != 0 || len(messages) == 0 {
+		t.Fatalf("expected whole turns, got %d messages", len(messages))
+	}
+	if messages[0].Get("role").String() != "user" || !strings.HasPrefix(messages[0].Get("content").String(), "task") {
+		t.Errorf("expected a turn to start the conversation, got %.80s", messages[0].Raw)
+	}
+	if last := messages[len(messages)-1].Get("content").String(); last != "done 9" {
+		t.Errorf("expected the latest turn to be kept, got %q", last)
+	}
+	if gjson.GetBytes(out, "system").String() != "be brief" {
+		t.Errorf("system prompt changed: %s", gjson.GetBytes(out, "system").Raw)
+	}
+}
+
+func TestFitStripsOldToolResultsAndImages(t *testing.T) {
+	image := `{"type":"image_url","image_url":{"url":"data:image/png;base64,` + strings.Repeat("A", 4000) + `"}}`
+	payload := []byte(`{"messages":[
+		{"role":"system","content":"sys"},
+		{"role":"user","content":[{"type":"text","text":"look"},` + image + `]},
+		{"role":"assistant","tool_calls":[{"id":"c1","type":"function","function":{"name":"read","arguments":"{}"}}]},
+		{"role":"tool","tool_call_id":"c1","content":"` + filler + `"},
+		{"role":"user","content":"next"},
+		{"role":"assistant","content":"ok"}
+	]}`)
+	out, report := Fit(context.Background(), payload, Options{
+		Format:          FormatOpenAI,
+		Model:           "gpt-4o",
+		Limit:           300,
+		Policies:        []string{config.ContextPolicyStrip},
+		KeepRecentTurns: 1,
+	})
+	if report.StrippedBlocks != 2 || strings.Join(report.Applied, ",") != "strip" {
+		t.Fatalf("report = %+v", report)
+	}
+	if got := gjson.GetBytes(out, "messages.1.content.1.text").String(); got != strippedMedia {
+		t.Errorf("image not stripped: %s", gjson.GetBytes(out, "messages.1").Raw)
+	}
+	if got := gjson.GetBytes(out, "messages.3.content").String(); got != strippedToolResult {
+		t.Errorf("tool result not stripped: %s", got)
+	}
+	if gjson.GetBytes(out, "messages.2.tool_calls.0.id").String() != "c1" {
+		t.Errorf("tool call lost: %s", out)
+	}
+}
+
+func TestFitSummarizesMiddle(t *testing.T) {
+	items := []string{`{"type":"message","role":"developer","content":"dev"}`}
+	for i := 0; i < 6; i++ {
+		items = append(items,
+			fmt.Sprintf(`{"type":"message","role":"user","content":[{"type":"input_text","text":"step %d %s"}]}`, i, filler),
+			fmt.Sprintf(`{"type":"function_call","call_id":"c%d","name":"shell","arguments":"{}"}`, i),
+			fmt.Sprintf(`{"type":"function_call_output","call_id":"c%d","output":"ok %d"}`, i, i),
+		)
+	}
+	payload := []byte(`{"input":[` + strings.Join(items, ",") + `]}`)
+
+	var transcriptSeen string
+	out, report := Fit(context.Background(), payload, Options{
+		Format:          FormatOpenAIResponses,
+		Model:           "gpt-5",
+		Limit:           2500,
+		Policies:        []string{config.ContextPolicySummarize},
+		KeepRecentTurns: 2,
+		Summarize: func(_ context.Context, transcript string) (string, error) {
+			transcriptSeen = transcript
+			return "steps 1-3 ran the shell", nil
+		},
+	})
+	if report.SummarizedTurns != 3 || report.TokensAfter >= report.TokensBefore {
+		t.Fatalf("report = %+v", report)
+	}
+	if !strings.Contains(transcriptSeen, "step 1") || strings.Contains(transcriptSeen, "step 0") || strings.Contains(transcriptSeen, "step 4") {
+		t.Errorf("unexpected transcript: %.200s", transcriptSeen)
+	}
+	input := gjson.GetBytes(out, "input").Array()
+	if len(input) != 1+3+1+6 {
+		t.Fatalf("expected developer, first turn, summary and two recent turns, got %d items", len(input))
+	}
+	if text := input[4].Get("content.0.text").String(); text != summaryPrefix+"steps 1-3 ran the shell" {
+		t.Errorf("summary item = %s", input[4].Raw)
+	}
+	if !strings.HasPrefix(input[5].Get("content.0.text").String(), "step 4") {
+		t.Errorf("expected recent turns after the summary, got %s", input[5].Raw)
+	}
+}
+
+func TestFitLeavesFittingPayload(t *testing.T) {
+	payload := claudeConversation(1)
+	out, report := Fit(context.Background(), payload, Options{
+		Format:   FormatClaude,
+		Model:    "claude-sonnet-4",
+		Limit:    200000,
+		Policies: []string{config.ContextPolicyTruncate},
+	})
+	if report.Changed() || string(out) != string(payload) {
+		t.Errorf("payload changed: %+v", report)
+	}
+	if got := (Report{Applied: []string{"strip", "truncate"}, TokensBefore: 10, TokensAfter: 5, StrippedBlocks: 2, DroppedTurns: 1}).String(); got != "strip,truncate; tokens=10->5; stripped=2; dropped-turns=1" {
+		t.Errorf("report string = %q", got)
+	}
+}
@@ -0,0 +1,222 @@
+package contextwindow
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Source formats with conversation support.
+const (
+	FormatOpenAI          = "openai"
+	FormatClaude          = "claude"
+	FormatOpenAIResponses = "openai-response"
+)
+
+const (
+	strippedMedia      = "[attachment removed to fit the context window]"
+	strippedToolResult = "[tool result removed to fit the context window]"
+	summaryPrefix      = "Summary of the earlier conversation:\n"
+	// maxTranscriptValue caps each text value sent to the summarizer so large tool outputs do
+	// not dominate the transcript.
+	maxTranscriptValue = 2000
+)
+
+// conversation splits the message list of a payload into pinned leading items (system and
+// developer messages) and turns. A turn starts with a user message and runs until the next one,
+// so tool calls and their results always share a turn.
+type conversation struct {
+	format string
+	path   string
+	pinned []string
+	turns  []turn
+}
+
+type turn struct {
+	items  []string
+	tokens int
+}
+
+func parseConversation(format string, payload []byte) *conversation {
+	path := ""
+	switch format {
+	case FormatOpenAI, FormatClaude:
+		path = "messages"
+	case FormatOpenAIResponses:
+		path = "input"
+	default:
+		return nil
+	}
+	items := gjson.GetBytes(payload, path)
+	if !items.IsArray() {
+		return nil
+	}
+	conv := &conversation{format: format, path: path}
+	for _, item := range items.Array() {
+		switch {
+		case conv.startsTurn(item):
+			conv.turns = append(conv.turns, turn{items: []string{item.Raw}})
+		case len(conv.turns) == 0:
+			conv.pinned = append(conv.pinned, item.Raw)
+		default:
+			last := &conv.turns[len(conv.turns)-1]
+			last.items = append(last.items, item.Raw)
+		}
+	}
+	return conv
+}
+
+func (c *conversation) startsTurn(item gjson.Result) bool {
+	switch c.format {
+	case FormatClaude:
+		if item.Get("role").String() != "user" {
+			return false
+		}
+		for _, block := range item.Get("content").Array() {
+			if block.Get("type").String() == "tool_result" {
+				return false
+			}
+		}
+		return true
+	case FormatOpenAIResponses:
+		itemType := item.Get("type").String()
+		return (itemType == "" || itemType == "message") && item.Get("role").String() == "user"
+	default:
+		return item.Get("role").String() == "user"
+	}
+}
+
+// encode writes the conversation back into the payload.
+func (c *conversation) encode(payload []byte) ([]byte, error) {
+	items := make([]string, 0, len(c.pinned)+len(c.turns))
+	items = append(items, c.pinned...)
+	for _, t := range c.turns {
+		items = append(items, t.items...)
+	}
+	return sjson.SetRawBytes(payload, c.path, []byte("["+strings.Join(items, ",")+"]"))
+}
+
+// stripItem replaces media and tool results in one item with placeholders and returns the number
+// of replaced blocks.
+func (c *conversation) stripItem(raw string) (string, int) {
+	item := gjson.Parse(raw)
+	stripped := 0
+	set := func(path string, value any) {
+		if updated, err := sjson.Set(raw, path, value); err == nil {
+			raw = updated
+			stripped++
+		}
+	}
+	switch c.format {
+	case FormatClaude:
+		for i, block := range item.Get("content").Array() {
+			switch block.Get("type").String() {
+			case "image", "document":
+				set(contentPath(i), map[string]any{"type": "text", "text": strippedMedia})
+			case "tool_result":
+				if block.Get("content").String() != strippedToolResult {
+					set(contentPath(i)+".content", strippedToolResult)
+				}
+			}
+		}
+	case FormatOpenAI:
+		if item.Get("role").String() == "tool" {
+			if item.Get("content").String() != strippedToolResult {
+				set("content", strippedToolResult)
+			}
+			break
+		}
+		for i, part := range item.Get("content").Array() {
+			switch part.Get("type").String() {
+			case "image_url", "input_audio", "file":
+				set(contentPath(i), map[string]any{"type": "text", "text": strippedMedia})
+			}
+		}
+	case FormatOpenAIResponses:
+		if item.Get("type").String() == "function_call_output" {
+			if item.Get("output").String() != strippedToolResult {
+				set("output", strippedToolResult)
+			}
+			break
+		}
+		for i, part := range item.Get("content").Array() {
+			switch part.Get("type").String() {
+			case "input_image", "input_file":
+				set(contentPath(i), map[string]any{"type": "input_text", "text": strippedMedia})
+			}
+		}
+	}
+	return raw, stripped
+}
+
+func contentPath(index int) string {
+	return "content." + strconv.Itoa(index)
+}
+
+// summaryItem builds the user message that replaces summarized turns.
+func (c *conversation) summaryItem(summary string) string {
+	text := summaryPrefix + summary
+	var raw string
+	switch c.format {
+	case FormatClaude:
+		raw, _ = sjson.Set(`{"role":"user","content":[{"type":"text"}]}`, "content.0.text", text)
+	case FormatOpenAIResponses:
+		raw, _ = sjson.Set(`{"type":"message","role":"user","content":[{"type":"input_text"}]}`, "content.0.text", text)
+	default:
+		raw, _ = sjson.Set(`{"role":"user"}`, "content", text)
+	}
+	return raw
+}
+
+// transcript renders turns as plain text for the summarizer.
+func transcript(turns []turn) string {
+	var b strings.Builder
+	for _, t := range turns {
+		for _, raw := range t.items {
+			item := gjson.Parse(raw)
+			label := item.Get("role").String()
+			if label == "" {
+				label = item.Get("type").String()
+			}
+			var text strings.Builder
+			collectTranscriptText(item, "", &text)
+			if text.Len() == 0 {
+				continue
+			}
+			b.WriteString(label)
+			b.WriteString(": ")
+			b.WriteString(strings.TrimSpace(text.String()))
+			b.WriteString("\n\n")
+		}
+	}
+	return b.String()
+}
+
+func collectTranscriptText(value gjson.Result, key string, text *strings.Builder) {
+	switch {
+	case (key == "input" || key == "arguments") && value.IsObject():
+		writeTranscriptValue(text, value.Raw)
+	case value.IsObject(), value.IsArray():
+		value.ForEach(func(k, v gjson.Result) bool {
+			collectTranscriptText(v, k.String(), text)
+			return true
+		})
+	case value.Type == gjson.String:
+		switch key {
+		case "text", "content", "output", "arguments", "input", "name":
+			if !isInlineMedia(key, value.String()) {
+				writeTranscriptValue(text, value.String())
+			}
+		}
+	}
+}
+
+func writeTranscriptValue(text *strings.Builder, value string) {
+	if runes := []rune(value); len(runes) > maxTranscriptValue {
+		value = string(runes[:maxTranscriptValue]) + "…"
+	}
+	text.WriteString(value)
+	text.WriteByte(' ')
+}
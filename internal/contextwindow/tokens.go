@@ -0,0 +1,99 @@
+package contextwindow
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/tidwall/gjson"
+	"github.com/tiktoken-go/tokenizer"
+)
+
+const (
+	// mediaTokens is the estimate used for an inline image or file; their real cost depends on
+	// resolution and provider and cannot be derived from the base64 payload.
+	mediaTokens = 1000
+	// itemOverheadTokens approximates the per-message framing tokens added by providers.
+	itemOverheadTokens = 4
+)
+
+var codecs sync.Map
+
+// counter estimates token counts for one target model.
+type counter struct {
+	codec      tokenizer.Codec
+	adjustment float64
+}
+
+// newCounter picks the tiktoken encoding closest to the model. Claude models are counted with
+// cl100k and a 1.1 adjustment, matching the executors' token counting.
+func newCounter(model string) (*counter, error) {
+	model = strings.ToLower(strings.TrimSpace(model))
+	encoding, adjustment := tokenizer.Cl100kBase, 1.0
+	switch {
+	case strings.Contains(model, "claude"):
+		adjustment = 1.1
+	case strings.HasPrefix(model, "gpt-4o"), strings.HasPrefix(model, "gpt-4.1"), strings.HasPrefix(model, "gpt-5"),
+		strings.HasPrefix(model, "o1"), strings.HasPrefix(model, "o3"), strings.HasPrefix(model, "o4"):
+		encoding = tokenizer.O200kBase
+	}
+	if cached, ok := codecs.Load(encoding); ok {
+		return &counter{codec: cached.(tokenizer.Codec), adjustment: adjustment}, nil
+	}
+	codec, err := tokenizer.Get(encoding)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := codecs.LoadOrStore(encoding, codec)
+	return &counter{codec: actual.(tokenizer.Codec), adjustment: adjustment}, nil
+}
+
+// count estimates the tokens of a JSON document from its string values. Inline media is counted
+// with a flat estimate.
+func (c *counter) count(raw string) int {
+	var text strings.Builder
+	media := 0
+	collectStrings(gjson.Parse(raw), "", &text, &media)
+	tokens, err := c.codec.Count(text.String())
+	if err != nil {
+		tokens = text.Len() / 4
+	}
+	if c.adjustment > 0 && c.adjustment != 1.0 {
+		tokens = int(float64(tokens) * c.adjustment)
+	}
+	return tokens + media*mediaTokens
+}
+
+// countItems estimates the tokens of conversation items including per-item framing.
+func (c *counter) countItems(items []string) int {
+	if len(items) == 0 {
+		return 0
+	}
+	return c.count("["+strings.Join(items, ",")+"]") + len(items)*itemOverheadTokens
+}
+
+func collectStrings(value gjson.Result, key string, text *strings.Builder, media *int) {
+	switch {
+	case value.IsObject(), value.IsArray():
+		value.ForEach(func(k, v gjson.Result) bool {
+			collectStrings(v, k.String(), text, media)
+			return true
+		})
+	case value.Type == gjson.String:
+		s := value.String()
+		if isInlineMedia(key, s) {
+			*media++
+			return
+		}
+		text.WriteString(s)
+		text.WriteByte('\n')
+	}
+}
+
+// isInlineMedia reports whether a string value carries base64 media (data URLs, Claude image
+// sources, OpenAI file data).
+func isInlineMedia(key, value string) bool {
+	if strings.HasPrefix(value, "data:") {
+		return true
+	}
+	return (key == "data" || key == "file_data") && len(value) > 256
+}
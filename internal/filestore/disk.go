@@ -0,0 +1,153 @@
+package filestore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// The sidecar extensions avoid .json so the auth watcher never mistakes uploads for credentials
+// when the directory lives under auth-dir.
+const (
+	diskMetaExt    = ".meta"
+	diskContentExt = ".bin"
+)
+
+// DiskBackend stores each file as a content blob plus a JSON metadata sidecar.
+type DiskBackend struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewDiskBackend returns a backend rooted at dir. The directory is created on first write.
+func NewDiskBackend(dir string) *DiskBackend {
+	return &DiskBackend{dir: dir}
+}
+
+// Put writes the content before the metadata so a listed file always has content.
+func (b *DiskBackend) Put(_ context.Context, file File, data []byte) error {
+	if !ValidID(file.ID) {
+		return fmt.Errorf("filestore: invalid file id %q", file.ID)
+	}
+	meta, err := json.Marshal(file)
+	if err != nil {
+		return fmt.Errorf("filestore: marshal metadata: %w", err)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err = os.MkdirAll(b.dir, 0o700); err != nil {
+		return fmt.Errorf("filestore: create directory: %w", err)
+	}
+	if err = os.WriteFile(b.path(file.ID, diskContentExt), data, 0o600); err != nil {
+		return fmt.Errorf("filestore: write content: %w", err)
+	}
+	if err = os.WriteFile(b.path(file.ID, diskMetaExt), meta, 0o600); err != nil {
+		_ = os.Remove(b.path(file.ID, diskContentExt))
+		return fmt.Errorf("filestore: write metadata: %w", err)
+	}
+	return nil
+}
+
+// Stat reads the metadata sidecar of id.
+func (b *DiskBackend) Stat(_ context.Context, id string) (File, error) {
+	if !ValidID(id) {
+		return File{}, ErrNotFound
+	}
+	return b.readMeta(b.path(id, diskMetaExt))
+}
+
+// Get reads the metadata and content of id.
+func (b *DiskBackend) Get(ctx context.Context, id string) (File, []byte, error) {
+	file, err := b.Stat(ctx, id)
+	if err != nil {
+		return File{}, nil, err
+	}
+	data, err := os.ReadFile(b.path(id, diskContentExt))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return File{}, nil, ErrNotFound
+		}
+		return File{}, nil, fmt.Errorf("filestore: read content: %w", err)
+	}
+	return file, data, nil
+}
+
+// List reads every metadata sidecar in the directory.
+func (b *DiskBackend) List(_ context.Context) ([]File, error) {
+	entries, err := os.ReadDir(b.dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("filestore: read directory: %w", err)
+	}
+	files := make([]File, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), diskMetaExt) {
+			continue
+		}
+		file, errMeta := b.readMeta(filepath.Join(b.dir, entry.Name()))
+		if errMeta != nil {
+			continue
+		}
+		files = append(files, file)
+	}
+	SortNewestFirst(files)
+	return files, nil
+}
+
+// Delete removes both the metadata and the content of id.
+func (b *DiskBackend) Delete(_ context.Context, id string) error {
+	if !ValidID(id) {
+		return ErrNotFound
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	err := os.Remove(b.path(id, diskMetaExt))
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("filestore: delete metadata: %w", err)
+	}
+	if err = os.Remove(b.path(id, diskContentExt)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("filestore: delete content: %w", err)
+	}
+	return nil
+}
+
+func (b *DiskBackend) path(id, ext string) string {
+	return filepath.Join(b.dir, id+ext)
+}
+
+func (b *DiskBackend) readMeta(path string) (File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return File{}, ErrNotFound
+		}
+		return File{}, fmt.Errorf("filestore: read metadata: %w", err)
+	}
+	var file File
+	if err = json.Unmarshal(data, &file); err != nil {
+		return File{}, fmt.Errorf("filestore: parse metadata: %w", err)
+	}
+	return file, nil
+}
+
+// SortNewestFirst orders files newest first, breaking ties by ID for stable pagination.
+func SortNewestFirst(files []File) {
+	sort.Slice(files, func(i, j int) bool {
+		if !files[i].CreatedAt.Equal(files[j].CreatedAt) {
+			return files[i].CreatedAt.After(files[j].CreatedAt)
+		}
+		return files[i].ID < files[j].ID
+	})
+}
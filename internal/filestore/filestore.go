@@ -0,0 +1,106 @@
+// Package filestore keeps files uploaded through the Anthropic and OpenAI Files APIs so that
+// requests referencing them by file_id can be served by backends without a file API.
+package filestore
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned when a file ID is not present in the store.
+var ErrNotFound = errors.New("filestore: file not found")
+
+// File describes a stored upload.
+type File struct {
+	ID        string    `json:"id"`
+	Filename  string    `json:"filename"`
+	MimeType  string    `json:"mime_type"`
+	Bytes     int64     `json:"bytes"`
+	Purpose   string    `json:"purpose,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	// Owner identifies the client API key that uploaded the file. Only that key can see,
+	// read, delete or reference it.
+	Owner string `json:"owner,omitempty"`
+}
+
+// Backend persists file metadata and content.
+type Backend interface {
+	Put(ctx context.Context, file File, data []byte) error
+	// Stat returns the metadata of id or ErrNotFound.
+	Stat(ctx context.Context, id string) (File, error)
+	// Get returns the metadata and content of id or ErrNotFound.
+	Get(ctx context.Context, id string) (File, []byte, error)
+	// List returns every stored file, newest first.
+	List(ctx context.Context) ([]File, error)
+	// Delete removes id. Deleting a missing file returns ErrNotFound.
+	Delete(ctx context.Context, id string) error
+}
+
+// defaultMaxSize applies until SetMaxSize is called.
+const defaultMaxSize int64 = 100 << 20
+
+var (
+	backendMu sync.RWMutex
+	backend   Backend
+	maxSize   = defaultMaxSize
+)
+
+// SetBackend installs the process-wide backend.
+func SetBackend(b Backend) {
+	backendMu.Lock()
+	backend = b
+	backendMu.Unlock()
+}
+
+// Current returns the process-wide backend, or nil when the file store is not configured.
+func Current() Backend {
+	backendMu.RLock()
+	defer backendMu.RUnlock()
+	return backend
+}
+
+// SetMaxSize sets the largest accepted upload in bytes.
+func SetMaxSize(size int64) {
+	if size <= 0 {
+		size = defaultMaxSize
+	}
+	backendMu.Lock()
+	maxSize = size
+	backendMu.Unlock()
+}
+
+// MaxSize returns the largest accepted upload in bytes.
+func MaxSize() int64 {
+	backendMu.RLock()
+	defer backendMu.RUnlock()
+	return maxSize
+}
+
+const idAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+var validID = regexp.MustCompile(`^file[-_][A-Za-z0-9]{8,64}$`)
+
+// NewID returns a random file ID with prefix, "file_" for Anthropic and "file-" for OpenAI.
+func NewID(prefix string) (string, error) {
+	buf := make([]byte, 24)
+	limit := big.NewInt(int64(len(idAlphabet)))
+	for i := range buf {
+		n, err := rand.Int(rand.Reader, limit)
+		if err != nil {
+			return "", err
+		}
+		buf[i] = idAlphabet[n.Int64()]
+	}
+	return prefix + string(buf), nil
+}
+
+// ValidID reports whether id has the shape of an ID issued by NewID. Backends use it to keep
+// client-supplied IDs from escaping their storage namespace.
+func ValidID(id string) bool {
+	return validID.MatchString(id)
+}
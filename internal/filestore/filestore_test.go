@@ -0,0 +1,159 @@
+package filestore
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/tidwall/gjson"
+)
+
+func TestDiskBackendRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	backend := NewDiskBackend(t.TempDir())
+	older := File{ID: "file_older0001", Filename: "a.txt", MimeType: "text/plain", Bytes: 5, CreatedAt: time.Unix(100, 0).UTC()}
+	newer := File{ID: "file_newer0001", Filename: "b.pdf", MimeType: "application/pdf", Bytes: 3, CreatedAt: time.Unix(200, 0).UTC()}
+	if err := backend.Put(ctx, older, []byte("hello")); err != nil {
+		t.Fatalf("Put older: %v", err)
+	}
+	if err := backend.Put(ctx, newer, []byte("pdf")); err != nil {
+		t.Fatalf("Put newer: %v", err)
+	}
+
+	files, err := backend.List(ctx)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(files) != 2 || files[0].ID != newer.ID || files[1].ID != older.ID {
+		t.Fatalf("List = %+v, want newest first", files)
+	}
+
+	file, data, err := backend.Get(ctx, older.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if file.Filename != "a.txt" || string(data) != "hello" {
+		t.Fatalf("Get = %+v %q", file, data)
+	}
+
+	if err = backend.Delete(ctx, older.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err = backend.Stat(ctx, older.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Stat after delete err = %v, want ErrNotFound", err)
+	}
+	if err = backend.Delete(ctx, older.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("second Delete err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestDiskBackendRejectsUnsafeIDs(t *testing.T) {
+	ctx := context.Background()
+	backend := NewDiskBackend(t.TempDir())
+	if err := backend.Put(ctx, File{ID: "../escape"}, []byte("x")); err == nil {
+		t.Fatal("Put accepted a path traversal id")
+	}
+	if _, err := backend.Stat(ctx, "file_../../etc"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Stat err = %v, want ErrNotFound", err)
+	}
+}
+
+func installTestBackend(t *testing.T) Backend {
+	t.Helper()
+	backend := NewDiskBackend(t.TempDir())
+	SetBackend(backend)
+	t.Cleanup(func() { SetBackend(nil) })
+	return backend
+}
+
+func TestResolveReferencesClaude(t *testing.T) {
+	ctx := context.Background()
+	backend := installTestBackend(t)
+	_ = backend.Put(ctx, File{ID: "file_pdf000001", Filename: "report.pdf", MimeType: "application/pdf", CreatedAt: time.Now()}, []byte("%PDF"))
+	_ = backend.Put(ctx, File{ID: "file_txt000001", Filename: "notes.txt", MimeType: "text/plain", CreatedAt: time.Now()}, []byte("plain notes"))
+
+	payload := []byte(`{"messages":[{"role":"user","content":[
+		{"type":"document","source":{"type":"file","file_id":"file_pdf000001"}},
+		{"type":"document","source":{"type":"file","file_id":"file_txt000001"}},
+		{"type":"image","source":{"type":"file","file_id":"file_unknown01"}},
+		{"type":"tool_result","tool_use_id":"t1","content":[{"type":"document","source":{"type":"file","file_id":"file_pdf000001"}}]}
+	]}]}`)
+	out, err := ResolveReferences(ctx, "", constant.Claude, payload)
+	if err != nil {
+		t.Fatalf("ResolveReferences: %v", err)
+	}
+	content := gjson.GetBytes(out, "messages.0.content")
+	if got := content.Get("0.source.type").String(); got != "base64" {
+		t.Fatalf("pdf source type = %q, want base64", got)
+	}
+	if got := content.Get("0.source.data").String(); got != base64.StdEncoding.EncodeToString([]byte("%PDF")) {
+		t.Fatalf("pdf data = %q", got)
+	}
+	if got := content.Get("0.source.media_type").String(); got != "application/pdf" {
+		t.Fatalf("pdf media type = %q", got)
+	}
+	if got := content.Get("1.source.type").String(); got != "text" {
+		t.Fatalf("text document source type = %q, want text", got)
+	}
+	if got := content.Get("1.source.data").String(); got != "plain notes" {
+		t.Fatalf("text document data = %q", got)
+	}
+	if got := content.Get("2.source.file_id").String(); got != "file_unknown01" {
+		t.Fatalf("unknown reference was rewritten: %s", content.Get("2").Raw)
+	}
+	if got := content.Get("3.content.0.source.type").String(); got != "base64" {
+		t.Fatalf("tool_result document source type = %q, want base64", got)
+	}
+}
+
+func TestResolveReferencesOpenAI(t *testing.T) {
+	ctx := context.Background()
+	backend := installTestBackend(t)
+	_ = backend.Put(ctx, File{ID: "file-pdf000001", Filename: "report.pdf", MimeType: "application/pdf", CreatedAt: time.Now()}, []byte("%PDF"))
+	wantURL := "data:application/pdf;base64," + base64.StdEncoding.EncodeToString([]byte("%PDF"))
+
+	chat := []byte(`{"messages":[{"role":"user","content":[{"type":"text","text":"hi"},{"type":"file","file":{"file_id":"file-pdf000001"}}]}]}`)
+	out, err := ResolveReferences(ctx, "", constant.OpenAI, chat)
+	if err != nil {
+		t.Fatalf("ResolveReferences chat: %v", err)
+	}
+	file := gjson.GetBytes(out, "messages.0.content.1.file")
+	if file.Get("file_data").String() != wantURL || file.Get("filename").String() != "report.pdf" || file.Get("file_id").Exists() {
+		t.Fatalf("chat file part = %s", file.Raw)
+	}
+
+	responses := []byte(`{"input":[{"role":"user","content":[{"type":"input_file","file_id":"file-pdf000001"},{"type":"input_image","file_id":"file-pdf000001"}]}]}`)
+	out, err = ResolveReferences(ctx, "", constant.OpenaiResponse, responses)
+	if err != nil {
+		t.Fatalf("ResolveReferences responses: %v", err)
+	}
+	content := gjson.GetBytes(out, "input.0.content")
+	if content.Get("0.file_data").String() != wantURL || content.Get("0.filename").String() != "report.pdf" || content.Get("0.file_id").Exists() {
+		t.Fatalf("responses input_file = %s", content.Get("0").Raw)
+	}
+	if content.Get("1.image_url").String() != wantURL || content.Get("1.file_id").Exists() {
+		t.Fatalf("responses input_image = %s", content.Get("1").Raw)
+	}
+}
+
+func TestResolveReferencesSkipsOtherOwners(t *testing.T) {
+	ctx := context.Background()
+	backend := installTestBackend(t)
+	_ = backend.Put(ctx, File{ID: "file_own000001", Filename: "a.txt", MimeType: "text/plain", Owner: "owner-a", CreatedAt: time.Now()}, []byte("mine"))
+
+	payload := []byte(`{"messages":[{"role":"user","content":[{"type":"document","source":{"type":"file","file_id":"file_own000001"}}]}]}`)
+	out, err := ResolveReferences(ctx, "owner-b", constant.Claude, payload)
+	if err != nil {
+		t.Fatalf("ResolveReferences: %v", err)
+	}
+	if got := gjson.GetBytes(out, "messages.0.content.0.source.file_id").String(); got != "file_own000001" {
+		t.Fatalf("another owner's file was inlined: %s", out)
+	}
+	out, _ = ResolveReferences(ctx, "owner-a", constant.Claude, payload)
+	if got := gjson.GetBytes(out, "messages.0.content.0.source.data").String(); got != "mine" {
+		t.Fatalf("owner's file not inlined: %s", out)
+	}
+}
@@ -0,0 +1,222 @@
+package filestore
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ResolveReferences replaces file_id references to stored files with inline base64 content in
+// a request of the given handler format (claude, openai or openai-response). Only files of owner
+// are resolved; references to other IDs are left untouched so upstream-issued IDs still pass
+// through.
+func ResolveReferences(ctx context.Context, owner, format string, payload []byte) ([]byte, error) {
+	b := Current()
+	if b == nil || len(payload) == 0 || !strings.Contains(string(payload), "file_id") {
+		return payload, nil
+	}
+	r := &resolver{ctx: ctx, owner: owner, backend: b, cache: make(map[string]*resolvedFile)}
+	switch format {
+	case constant.Claude:
+		gjson.GetBytes(payload, "messages").ForEach(func(i, message gjson.Result) bool {
+			r.claudeContent(fmt.Sprintf("messages.%d.content", i.Int()), message.Get("content"))
+			return r.err == nil
+		})
+	case constant.OpenAI:
+		gjson.GetBytes(payload, "messages").ForEach(func(i, message gjson.Result) bool {
+			r.openAIChatContent(fmt.Sprintf("messages.%d.content", i.Int()), message.Get("content"))
+			return r.err == nil
+		})
+	case constant.OpenaiResponse:
+		gjson.GetBytes(payload, "input").ForEach(func(i, item gjson.Result) bool {
+			r.responsesContent(fmt.Sprintf("input.%d.content", i.Int()), item.Get("content"))
+			return r.err == nil
+		})
+	default:
+		return payload, nil
+	}
+	if r.err != nil {
+		return payload, r.err
+	}
+	out := payload
+	for _, edit := range r.edits {
+		var err error
+		if edit.delete {
+			out, err = sjson.DeleteBytes(out, edit.path)
+		} else {
+			out, err = sjson.SetRawBytes(out, edit.path, edit.raw)
+		}
+		if err != nil {
+			return payload, fmt.Errorf("filestore: rewrite %s: %w", edit.path, err)
+		}
+	}
+	return out, nil
+}
+
+type resolvedFile struct {
+	file File
+	data []byte
+}
+
+func (f *resolvedFile) base64() string {
+	return base64.StdEncoding.EncodeToString(f.data)
+}
+
+func (f *resolvedFile) dataURL() string {
+	mimeType := f.file.MimeType
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+	return "data:" + mimeType + ";base64," + f.base64()
+}
+
+type payloadEdit struct {
+	path   string
+	raw    []byte
+	delete bool
+}
+
+type resolver struct {
+	ctx     context.Context
+	owner   string
+	backend Backend
+	cache   map[string]*resolvedFile
+	edits   []payloadEdit
+	err     error
+}
+
+// load returns nil for IDs the store does not know or that belong to another owner.
+func (r *resolver) load(id string) *resolvedFile {
+	if id == "" || r.err != nil {
+		return nil
+	}
+	if cached, ok := r.cache[id]; ok {
+		return cached
+	}
+	file, data, err := r.backend.Get(r.ctx, id)
+	if err != nil || file.Owner != r.owner {
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			r.err = err
+		}
+		r.cache[id] = nil
+		return nil
+	}
+	resolved := &resolvedFile{file: file, data: data}
+	r.cache[id] = resolved
+	return resolved
+}
+
+func (r *resolver) set(path string, value any) {
+	raw, err := sjson.SetBytes([]byte(`{}`), "v", value)
+	if err != nil {
+		r.err = err
+		return
+	}
+	r.edits = append(r.edits, payloadEdit{path: path, raw: []byte(gjson.GetBytes(raw, "v").Raw)})
+}
+
+func (r *resolver) setRaw(path string, raw []byte) {
+	r.edits = append(r.edits, payloadEdit{path: path, raw: raw})
+}
+
+func (r *resolver) remove(path string) {
+	r.edits = append(r.edits, payloadEdit{path: path, delete: true})
+}
+
+// claudeContent rewrites {"source":{"type":"file","file_id":...}} on image and document blocks,
+// including blocks nested in tool results.
+func (r *resolver) claudeContent(path string, content gjson.Result) {
+	if !content.IsArray() {
+		return
+	}
+	content.ForEach(func(j, part gjson.Result) bool {
+		partPath := fmt.Sprintf("%s.%d", path, j.Int())
+		switch part.Get("type").String() {
+		case "tool_result":
+			r.claudeContent(partPath+".content", part.Get("content"))
+		case "image", "document":
+			source := part.Get("source")
+			if source.Get("type").String() != "file" {
+				return true
+			}
+			resolved := r.load(source.Get("file_id").String())
+			if resolved == nil {
+				return r.err == nil
+			}
+			r.setRaw(partPath+".source", claudeInlineSource(part.Get("type").String(), resolved))
+		}
+		return r.err == nil
+	})
+}
+
+func claudeInlineSource(blockType string, f *resolvedFile) []byte {
+	mimeType := f.file.MimeType
+	if blockType == "document" && strings.HasPrefix(mimeType, "text/") {
+		source, _ := sjson.SetBytes([]byte(`{"type":"text","media_type":"text/plain"}`), "data", string(f.data))
+		return source
+	}
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+	source := []byte(`{"type":"base64"}`)
+	source, _ = sjson.SetBytes(source, "media_type", mimeType)
+	source, _ = sjson.SetBytes(source, "data", f.base64())
+	return source
+}
+
+// openAIChatContent rewrites {"type":"file","file":{"file_id":...}} parts to file_data.
+func (r *resolver) openAIChatContent(path string, content gjson.Result) {
+	if !content.IsArray() {
+		return
+	}
+	content.ForEach(func(j, part gjson.Result) bool {
+		if part.Get("type").String() != "file" {
+			return true
+		}
+		resolved := r.load(part.Get("file.file_id").String())
+		if resolved == nil {
+			return r.err == nil
+		}
+		partPath := fmt.Sprintf("%s.%d.file", path, j.Int())
+		r.set(partPath+".file_data", resolved.dataURL())
+		if !part.Get("file.filename").Exists() {
+			r.set(partPath+".filename", resolved.file.Filename)
+		}
+		r.remove(partPath + ".file_id")
+		return true
+	})
+}
+
+// responsesContent rewrites input_file and input_image items that carry a file_id.
+func (r *resolver) responsesContent(path string, content gjson.Result) {
+	if !content.IsArray() {
+		return
+	}
+	content.ForEach(func(j, part gjson.Result) bool {
+		partType := part.Get("type").String()
+		if partType != "input_file" && partType != "input_image" {
+			return true
+		}
+		resolved := r.load(part.Get("file_id").String())
+		if resolved == nil {
+			return r.err == nil
+		}
+		partPath := fmt.Sprintf("%s.%d", path, j.Int())
+		if partType == "input_image" {
+			r.set(partPath+".image_url", resolved.dataURL())
+		} else {
+			r.set(partPath+".file_data", resolved.dataURL())
+			if !part.Get("filename").Exists() {
+				r.set(partPath+".filename", resolved.file.Filename)
+			}
+		}
+		r.remove(partPath + ".file_id")
+		return true
+	})
+}
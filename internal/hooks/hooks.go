@@ -0,0 +1,222 @@
+// Package hooks calls out-of-process request and response plugins. A hook receives a JSON
+// document describing the request (and, after execution, the response and usage) and answers
+// with a decision to approve, reject or modify it.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	log "github.com/sirupsen/logrus"
+)
+
+// Decision actions returned by hooks. An empty action approves.
+const (
+	ActionApprove = "approve"
+	ActionReject  = "reject"
+	ActionModify  = "modify"
+)
+
+// Call is the document sent to a hook. KeyName identifies the client API key by its
+// api-key-names entry or fingerprint; the key itself is never sent.
+type Call struct {
+	Stage        string `json:"stage"`
+	Hook         string `json:"hook"`
+	RequestID    string `json:"request_id,omitempty"`
+	SourceFormat string `json:"source_format"`
+	Model        string `json:"model"`
+	KeyName      string `json:"key_name,omitempty"`
+	Stream       bool   `json:"stream"`
+	// Body is the request body.
+	Body json.RawMessage `json:"body,omitempty"`
+	// Response is the response body of post-response calls. Streamed responses are sent as a
+	// JSON string holding the raw event stream.
+	Response json.RawMessage   `json:"response,omitempty"`
+	Usage    *coreusage.Detail `json:"usage,omitempty"`
+}
+
+// Decision is a hook's answer. Body replaces the request body in the pre-request stage and the
+// response body in the post-response stage; for streamed responses it may be a JSON string.
+type Decision struct {
+	Action  string          `json:"action,omitempty"`
+	Message string          `json:"message,omitempty"`
+	Status  int             `json:"status,omitempty"`
+	Body    json.RawMessage `json:"body,omitempty"`
+}
+
+// Rejection stops a request, either because a hook rejected it or because a fail-closed hook
+// could not be reached.
+type Rejection struct {
+	Hook    string
+	Status  int
+	Message string
+}
+
+// Error implements error.
+func (r *Rejection) Error() string {
+	return r.Message
+}
+
+// ErrorMessage converts the rejection into the handler error type.
+func (r *Rejection) ErrorMessage() *interfaces.ErrorMessage {
+	return &interfaces.ErrorMessage{StatusCode: r.Status, Error: r}
+}
+
+// transport sends an encoded call to a hook and returns the encoded decision.
+type transport func(ctx context.Context, hook config.HookConfig, payload []byte) ([]byte, error)
+
+// Manager holds the configured hooks and the clients used to reach them.
+type Manager struct {
+	mu         sync.RWMutex
+	hooks      []config.HookConfig
+	transports map[string]transport
+}
+
+// NewManager creates a manager for the given hooks.
+func NewManager(hooks []config.HookConfig) *Manager {
+	m := &Manager{
+		transports: map[string]transport{
+			config.HookTransportHTTP: newHTTPTransport(),
+			config.HookTransportGRPC: newGRPCTransport(),
+		},
+	}
+	m.Update(hooks)
+	return m
+}
+
+// Update replaces the configured hooks.
+func (m *Manager) Update(hooks []config.HookConfig) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append([]config.HookConfig(nil), hooks...)
+}
+
+// Matching returns the hooks of stage applying to a request, in configuration order.
+func (m *Manager) Matching(stage, format, model, apiKey string) []config.HookConfig {
+	if m == nil {
+		return nil
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var out []config.HookConfig
+	for _, hook := range m.hooks {
+		if hook.HasStage(stage) && hook.Matches(format, model, apiKey) {
+			out = append(out, hook)
+		}
+	}
+	return out
+}
+
+// Apply runs hooks in order on body, which is the request body in the pre-request stage and the
+// response body in the post-response stage. Each hook sees the body returned by the previous
+// one. It returns the final body, or the rejection that stopped the request.
+func (m *Manager) Apply(ctx context.Context, hooks []config.HookConfig, call Call, body []byte) ([]byte, *Rejection) {
+	if call.Stage == config.HookStagePostResponse {
+		call.Body = encodeBody(call.Body)
+	}
+	for _, hook := range hooks {
+		call.Hook = hook.Name
+		if call.Stage == config.HookStagePostResponse {
+			call.Response = encodeBody(body)
+		} else {
+			call.Body = encodeBody(body)
+		}
+		decision, err := m.call(ctx, hook, call)
+		if err != nil {
+			if hook.FailurePolicy == config.HookFailClosed {
+				log.Warnf("hook %s failed, rejecting request: %v", hook.Name, err)
+				return nil, &Rejection{Hook: hook.Name, Status: http.StatusBadGateway, Message: fmt.Sprintf("hook %s unavailable", hook.Name)}
+			}
+			log.Warnf("hook %s failed, continuing: %v", hook.Name, err)
+			continue
+		}
+		switch decision.Action {
+		case ActionReject:
+			status := decision.Status
+			if status < 400 || status > 599 {
+				status = http.StatusForbidden
+			}
+			message := decision.Message
+			if message == "" {
+				message = fmt.Sprintf("request rejected by hook %s", hook.Name)
+			}
+			return nil, &Rejection{Hook: hook.Name, Status: status, Message: message}
+		case ActionModify:
+			body = decodeBody(decision.Body)
+		}
+	}
+	return body, nil
+}
+
+// call sends one call to hook within its timeout and validates the decision.
+func (m *Manager) call(ctx context.Context, hook config.HookConfig, call Call) (Decision, error) {
+	m.mu.RLock()
+	send := m.transports[hook.Transport]
+	m.mu.RUnlock()
+	if send == nil {
+		return Decision{}, fmt.Errorf("unsupported transport %q", hook.Transport)
+	}
+	payload, err := json.Marshal(call)
+	if err != nil {
+		return Decision{}, fmt.Errorf("encode call: %w", err)
+	}
+
+	timeout := time.Duration(hook.TimeoutMillis) * time.Millisecond
+	if timeout <= 0 {
+		timeout = config.DefaultHookTimeoutMillis * time.Millisecond
+	}
+	callCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	raw, err := send(callCtx, hook, payload)
+	if err != nil {
+		return Decision{}, err
+	}
+
+	var decision Decision
+	if len(bytes.TrimSpace(raw)) > 0 {
+		if err = json.Unmarshal(raw, &decision); err != nil {
+			return Decision{}, fmt.Errorf("decode decision: %w", err)
+		}
+	}
+	switch decision.Action {
+	case "", ActionApprove:
+		decision.Action = ActionApprove
+	case ActionReject:
+	case ActionModify:
+		if len(decision.Body) == 0 {
+			return Decision{}, fmt.Errorf("modify decision without body")
+		}
+	default:
+		return Decision{}, fmt.Errorf("unknown action %q", decision.Action)
+	}
+	return decision, nil
+}
+
+// encodeBody embeds body as raw JSON, or as a JSON string when it is not valid JSON.
+func encodeBody(body []byte) json.RawMessage {
+	if len(body) == 0 {
+		return nil
+	}
+	if json.Valid(body) {
+		return body
+	}
+	encoded, _ := json.Marshal(string(body))
+	return encoded
+}
+
+// decodeBody reverses encodeBody: JSON strings are unquoted, other JSON values are kept.
+func decodeBody(raw json.RawMessage) []byte {
+	var text string
+	if len(raw) > 0 && raw[0] == '"' && json.Unmarshal(raw, &text) == nil {
+		return []byte(text)
+	}
+	return raw
+}
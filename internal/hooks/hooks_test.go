@@ -0,0 +1,170 @@
+package hooks
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func newHookServer(t *testing.T, handle func(call Call) string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var call Call
+		if err := json.NewDecoder(r.Body).Decode(&call); err != nil {
+			t.Errorf("decode call: %v", err)
+		}
+		_, _ = w.Write([]byte(handle(call)))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func sanitized(hooks ...config.HookConfig) []config.HookConfig {
+	cfg := &config.Config{}
+	cfg.Hooks = hooks
+	cfg.SanitizeHooks()
+	return cfg.Hooks
+}
+
+func TestApplyHTTPDecisions(t *testing.T) {
+	var seen []Call
+	srv := newHookServer(t, func(call Call) string {
+		seen = append(seen, call)
+		switch call.Hook {
+		case "modify":
+			return `{"action":"modify","body":{"model":"gpt-5","metadata":{"tenant":"a"}}}`
+		case "reject":
+			return `{"action":"reject","message":"prompt contains secrets","status":422}`
+		default:
+			return ``
+		}
+	})
+	hooks := sanitized(
+		config.HookConfig{Name: "approve", URL: srv.URL},
+		config.HookConfig{Name: "modify", URL: srv.URL},
+	)
+	manager := NewManager(hooks)
+	call := Call{Stage: config.HookStagePreRequest, SourceFormat: "openai", Model: "gpt-5", KeyName: "client-key"}
+
+	body, rejection := manager.Apply(context.Background(), hooks, call, []byte(`{"model":"gpt-5"}`))
+	if rejection != nil {
+		t.Fatalf("unexpected rejection: %v", rejection)
+	}
+	if string(body) != `{"model":"gpt-5","metadata":{"tenant":"a"}}` {
+		t.Errorf("body = %s", body)
+	}
+	if len(seen) != 2 || seen[0].KeyName != "client-key" || string(seen[1].Body) != `{"model":"gpt-5"}` {
+		t.Errorf("unexpected calls %+v", seen)
+	}
+
+	rejecting := sanitized(config.HookConfig{Name: "reject", URL: srv.URL})
+	_, rejection = manager.Apply(context.Background(), rejecting, call, []byte(`{}`))
+	if rejection == nil || rejection.Status != 422 || rejection.Message != "prompt contains secrets" {
+		t.Errorf("rejection = %+v", rejection)
+	}
+}
+
+func TestApplyPostResponseStreamBody(t *testing.T) {
+	srv := newHookServer(t, func(call Call) string {
+		var stream string
+		if err := json.Unmarshal(call.Response, &stream); err != nil || stream != "data: {}\n\n" {
+			t.Errorf("response = %s", call.Response)
+		}
+		return `{"action":"modify","body":"data: {\"redacted\":true}\n\n"}`
+	})
+	hooks := sanitized(config.HookConfig{Name: "redact", URL: srv.URL})
+	call := Call{Stage: config.HookStagePostResponse, Stream: true, Body: json.RawMessage(`{"stream":true}`)}
+	body, rejection := NewManager(hooks).Apply(context.Background(), hooks, call, []byte("data: {}\n\n"))
+	if rejection != nil {
+		t.Fatalf("unexpected rejection: %v", rejection)
+	}
+	if string(body) != "data: {\"redacted\":true}\n\n" {
+		t.Errorf("body = %q", body)
+	}
+}
+
+func TestApplyFailurePolicies(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	open := sanitized(config.HookConfig{Name: "slow", URL: srv.URL, TimeoutMillis: 20})
+	call := Call{Stage: config.HookStagePreRequest}
+	body, rejection := NewManager(open).Apply(context.Background(), open, call, []byte(`{"a":1}`))
+	if rejection != nil || string(body) != `{"a":1}` {
+		t.Errorf("fail-open: body %s, rejection %v", body, rejection)
+	}
+
+	closed := sanitized(config.HookConfig{Name: "slow", URL: srv.URL, TimeoutMillis: 20, FailurePolicy: "fail-closed"})
+	_, rejection = NewManager(closed).Apply(context.Background(), closed, call, []byte(`{"a":1}`))
+	if rejection == nil || rejection.Status != http.StatusBadGateway {
+		t.Errorf("fail-closed: rejection %+v", rejection)
+	}
+}
+
+func TestApplyGRPC(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != GRPCMethod || r.ProtoMajor != 2 || r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("unexpected request %s %s %v", r.Proto, r.URL.Path, r.Header)
+		}
+		frame, _ := io.ReadAll(r.Body)
+		var in wrapperspb.BytesValue
+		if err := proto.Unmarshal(frame[5:], &in); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		var call Call
+		if err := json.Unmarshal(in.GetValue(), &call); err != nil || call.Model != "claude-sonnet" {
+			t.Errorf("call = %s", in.GetValue())
+		}
+		message, _ := proto.Marshal(wrapperspb.Bytes([]byte(`{"action":"reject","message":"blocked"}`)))
+		out := make([]byte, 5+len(message))
+		binary.BigEndian.PutUint32(out[1:5], uint32(len(message)))
+		copy(out[5:], message)
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+		_, _ = w.Write(out)
+		w.Header().Set("Grpc-Status", "0")
+	}))
+	srv.Config.Protocols = new(http.Protocols)
+	srv.Config.Protocols.SetUnencryptedHTTP2(true)
+	srv.Start()
+	defer srv.Close()
+
+	hooks := sanitized(config.HookConfig{
+		Name:      "grpc",
+		Transport: "grpc",
+		URL:       srv.URL,
+		Headers:   map[string]string{"Authorization": "Bearer token"},
+	})
+	call := Call{Stage: config.HookStagePreRequest, Model: "claude-sonnet"}
+	_, rejection := NewManager(hooks).Apply(context.Background(), hooks, call, []byte(`{}`))
+	if rejection == nil || rejection.Status != http.StatusForbidden || rejection.Message != "blocked" {
+		t.Errorf("rejection = %+v", rejection)
+	}
+}
+
+func TestMatching(t *testing.T) {
+	manager := NewManager(sanitized(
+		config.HookConfig{Name: "pre", URL: "http://hook", Stages: []string{"pre-request"}, Models: []string{"gpt-*"}},
+		config.HookConfig{Name: "tenant", URL: "http://hook", APIKeys: []string{"k1"}, Formats: []string{"claude"}},
+	))
+	if got := manager.Matching(config.HookStagePreRequest, "openai", "gpt-5", "k1"); len(got) != 1 || got[0].Name != "pre" {
+		t.Errorf("openai pre hooks = %+v", got)
+	}
+	if got := manager.Matching(config.HookStagePostResponse, "claude", "claude-opus", "k1"); len(got) != 1 || got[0].Name != "tenant" {
+		t.Errorf("claude post hooks = %+v", got)
+	}
+	if got := manager.Matching(config.HookStagePostResponse, "claude", "claude-opus", "k2"); len(got) != 0 {
+		t.Errorf("other key hooks = %+v", got)
+	}
+}
@@ -0,0 +1,141 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// GRPCMethod is the unary method called on gRPC hooks. Its request and response messages are
+// google.protobuf.BytesValue holding the same JSON documents the HTTP transport exchanges.
+const GRPCMethod = "/cliproxy.hooks.v1.Hooks/Handle"
+
+// maxDecisionBytes caps the size of a hook answer.
+const maxDecisionBytes = 32 << 20
+
+// newHTTPTransport posts the call as JSON and reads the decision from a 2xx response body.
+func newHTTPTransport() transport {
+	client := &http.Client{}
+	return func(ctx context.Context, hook config.HookConfig, payload []byte) ([]byte, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		for name, value := range hook.Headers {
+			req.Header.Set(name, value)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = resp.Body.Close() }()
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxDecisionBytes))
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return nil, fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		}
+		return body, nil
+	}
+}
+
+// newGRPCTransport performs a unary gRPC call over HTTP/2, using TLS for https:// URLs and
+// plaintext HTTP/2 otherwise.
+func newGRPCTransport() transport {
+	protocols := new(http.Protocols)
+	protocols.SetHTTP2(true)
+	protocols.SetUnencryptedHTTP2(true)
+	client := &http.Client{Transport: &http.Transport{Protocols: protocols}}
+
+	return func(ctx context.Context, hook config.HookConfig, payload []byte) ([]byte, error) {
+		target, err := grpcURL(hook.URL)
+		if err != nil {
+			return nil, err
+		}
+		message, err := proto.Marshal(wrapperspb.Bytes(payload))
+		if err != nil {
+			return nil, err
+		}
+		frame := make([]byte, 5+len(message))
+		binary.BigEndian.PutUint32(frame[1:5], uint32(len(message)))
+		copy(frame[5:], message)
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(frame))
+		if err != nil {
+			return nil, err
+		}
+		for name, value := range hook.Headers {
+			req.Header.Set(name, value)
+		}
+		req.Header.Set("Content-Type", "application/grpc+proto")
+		req.Header.Set("TE", "trailers")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = resp.Body.Close() }()
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxDecisionBytes))
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("http status %d", resp.StatusCode)
+		}
+		// Trailers-only responses carry the status in the headers.
+		status := resp.Trailer.Get("Grpc-Status")
+		statusMessage := resp.Trailer.Get("Grpc-Message")
+		if status == "" {
+			status = resp.Header.Get("Grpc-Status")
+			statusMessage = resp.Header.Get("Grpc-Message")
+		}
+		if status != "0" {
+			return nil, fmt.Errorf("grpc status %s: %s", status, statusMessage)
+		}
+		if len(body) == 0 {
+			return nil, nil
+		}
+		if len(body) < 5 || body[0] != 0 {
+			return nil, fmt.Errorf("unsupported grpc response frame")
+		}
+		size := binary.BigEndian.Uint32(body[1:5])
+		if int(size) > len(body)-5 {
+			return nil, fmt.Errorf("truncated grpc response frame")
+		}
+		var decision wrapperspb.BytesValue
+		if err = proto.Unmarshal(body[5:5+size], &decision); err != nil {
+			return nil, fmt.Errorf("decode grpc response: %w", err)
+		}
+		return decision.GetValue(), nil
+	}
+}
+
+// grpcURL appends GRPCMethod to the hook address, accepting grpc:// and grpcs:// aliases for
+// http:// and https://.
+func grpcURL(address string) (string, error) {
+	parsed, err := url.Parse(address)
+	if err != nil {
+		return "", fmt.Errorf("invalid hook url %q: %w", address, err)
+	}
+	switch parsed.Scheme {
+	case "http", "grpc":
+		parsed.Scheme = "http"
+	case "https", "grpcs":
+		parsed.Scheme = "https"
+	default:
+		return "", fmt.Errorf("invalid hook url %q", address)
+	}
+	parsed.Path = strings.TrimRight(parsed.Path, "/") + GRPCMethod
+	return parsed.String(), nil
+}
@@ -0,0 +1,223 @@
+package logging
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// segmentUploadTimeout bounds the upload of one closed segment.
+const segmentUploadTimeout = 2 * time.Minute
+
+// SegmentUploader copies a closed request log segment to durable storage. name is the base name
+// of the segment file.
+type SegmentUploader func(ctx context.Context, name string, data []byte) error
+
+// segmentWriter appends lines to an active file and rotates it into timestamped segments once it
+// exceeds maxBytes. Closed segments are optionally gzipped and uploaded in the background, and
+// the oldest segments beyond maxBackups are removed.
+type segmentWriter struct {
+	mu         sync.Mutex
+	dir        string
+	name       string
+	maxBytes   int64
+	maxBackups int
+	compress   bool
+	upload     SegmentUploader
+	file       *os.File
+	size       int64
+	pending    sync.WaitGroup
+	// finalizeMu serialises background finalization so pruning never sees a half-compressed segment.
+	finalizeMu sync.Mutex
+}
+
+func newSegmentWriter(dir, name string, maxBytes int64, maxBackups int, compress bool, upload SegmentUploader) *segmentWriter {
+	return &segmentWriter{
+		dir:        dir,
+		name:       name,
+		maxBytes:   maxBytes,
+		maxBackups: maxBackups,
+		compress:   compress,
+		upload:     upload,
+	}
+}
+
+// Write appends line to the active segment, rotating first when it would exceed the size limit.
+func (w *segmentWriter) Write(line []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file != nil && w.maxBytes > 0 && w.size > 0 && w.size+int64(len(line)) > w.maxBytes {
+		if err := w.rotateLocked(); err != nil {
+			return err
+		}
+	}
+	if w.file == nil {
+		if err := w.openLocked(); err != nil {
+			return err
+		}
+	}
+	n, err := w.file.Write(line)
+	w.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write structured request log: %w", err)
+	}
+	return nil
+}
+
+// Close closes the active segment and waits for pending compression and uploads. The active
+// segment is kept in place and appended to on the next start.
+func (w *segmentWriter) Close() error {
+	w.mu.Lock()
+	var err error
+	if w.file != nil {
+		err = w.file.Close()
+		w.file = nil
+		w.size = 0
+	}
+	w.mu.Unlock()
+	w.pending.Wait()
+	return err
+}
+
+func (w *segmentWriter) openLocked() error {
+	if err := os.MkdirAll(w.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create logs directory: %w", err)
+	}
+	file, err := os.OpenFile(filepath.Join(w.dir, w.name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open structured request log: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to stat structured request log: %w", err)
+	}
+	w.file = file
+	w.size = info.Size()
+	return nil
+}
+
+func (w *segmentWriter) rotateLocked() error {
+	if err := w.file.Close(); err != nil {
+		log.WithError(err).Warn("failed to close structured request log segment")
+	}
+	w.file = nil
+	w.size = 0
+
+	stem, ext := w.stem()
+	closed := filepath.Join(w.dir, fmt.Sprintf("%s-%s%s", stem, time.Now().UTC().Format("20060102T150405.000"), ext))
+	if err := os.Rename(filepath.Join(w.dir, w.name), closed); err != nil {
+		return fmt.Errorf("failed to rotate structured request log: %w", err)
+	}
+	w.pending.Add(1)
+	go func() {
+		defer w.pending.Done()
+		w.finalize(closed)
+	}()
+	return nil
+}
+
+// finalize compresses and uploads a closed segment, then prunes old segments.
+func (w *segmentWriter) finalize(path string) {
+	w.finalizeMu.Lock()
+	defer w.finalizeMu.Unlock()
+
+	if w.compress {
+		compressed, err := gzipFile(path)
+		if err != nil {
+			log.WithError(err).Warnf("failed to compress request log segment %s", filepath.Base(path))
+		} else {
+			path = compressed
+		}
+	}
+	if w.upload != nil {
+		if data, err := os.ReadFile(path); err != nil {
+			log.WithError(err).Warnf("failed to read request log segment %s", filepath.Base(path))
+		} else {
+			ctx, cancel := context.WithTimeout(context.Background(), segmentUploadTimeout)
+			if errUpload := w.upload(ctx, filepath.Base(path), data); errUpload != nil {
+				log.WithError(errUpload).Warnf("failed to upload request log segment %s", filepath.Base(path))
+			}
+			cancel()
+		}
+	}
+	w.prune()
+}
+
+// prune removes the oldest closed segments beyond maxBackups.
+func (w *segmentWriter) prune() {
+	if w.maxBackups < 0 {
+		return
+	}
+	stem, ext := w.stem()
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return
+	}
+	var segments []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, stem+"-") {
+			continue
+		}
+		if strings.HasSuffix(name, ext) || strings.HasSuffix(name, ext+".gz") {
+			segments = append(segments, name)
+		}
+	}
+	if len(segments) <= w.maxBackups {
+		return
+	}
+	// Segment names embed a sortable UTC timestamp.
+	sort.Strings(segments)
+	for _, name := range segments[:len(segments)-w.maxBackups] {
+		if errRemove := os.Remove(filepath.Join(w.dir, name)); errRemove != nil && !os.IsNotExist(errRemove) {
+			log.WithError(errRemove).Warnf("failed to remove request log segment %s", name)
+		}
+	}
+}
+
+func (w *segmentWriter) stem() (string, string) {
+	ext := filepath.Ext(w.name)
+	return strings.TrimSuffix(w.name, ext), ext
+}
+
+// gzipFile compresses path into path.gz and removes the original.
+func gzipFile(path string) (string, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = src.Close() }()
+
+	target := path + ".gz"
+	dst, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return "", err
+	}
+	zw := gzip.NewWriter(dst)
+	if _, err = io.Copy(zw, src); err == nil {
+		err = zw.Close()
+	}
+	if errClose := dst.Close(); err == nil {
+		err = errClose
+	}
+	if err != nil {
+		_ = os.Remove(target)
+		return "", err
+	}
+	_ = src.Close()
+	if errRemove := os.Remove(path); errRemove != nil {
+		log.WithError(errRemove).Warnf("failed to remove uncompressed request log segment %s", filepath.Base(path))
+	}
+	return target, nil
+}
@@ -0,0 +1,489 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// UsageRecordContextKey is the Gin context key under which executors store the usage record of
+// the request so structured request logs can report provider, auth and token usage.
+const UsageRecordContextKey = "REQUEST_LOG_USAGE"
+
+const redactedValue = "[REDACTED]"
+
+// alwaysRedactedHeaders are credential headers never written to structured request logs.
+var alwaysRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key", "X-Goog-Api-Key"}
+
+// RequestMetadata carries the routing and usage details of a request that are not part of the
+// HTTP exchange itself.
+type RequestMetadata struct {
+	// ClientKey is the configured name (or fingerprint) of the API key the client authenticated with.
+	ClientKey string
+	Model     string
+	Provider  string
+	AuthID    string
+	AuthIndex string
+	// Usage is the token usage reported by the executor, nil when unknown.
+	Usage *coreusage.Detail
+}
+
+// MetadataRequestLogger is implemented by request loggers that record RequestMetadata next to
+// the request. The middleware prefers it over LogRequestWithOptions.
+type MetadataRequestLogger interface {
+	LogRequestWithMetadata(meta RequestMetadata, url, method string, requestHeaders map[string][]string, body []byte, statusCode int, responseHeaders map[string][]string, response, apiRequest, apiResponse []byte, apiResponseErrors []*interfaces.ErrorMessage, force bool, requestID string, requestTimestamp, apiResponseTimestamp time.Time) error
+}
+
+// MetadataStreamingLogWriter is implemented by streaming log writers that record RequestMetadata.
+// The middleware calls SetMetadata before Close.
+type MetadataStreamingLogWriter interface {
+	SetMetadata(meta RequestMetadata)
+}
+
+// StructuredRequestLogger implements RequestLogger by appending one JSON object per request to a
+// size-rotated JSONL file. Bodies are redacted and truncated, credential headers are never
+// written and requests can be sampled by status class.
+type StructuredRequestLogger struct {
+	enabled       atomic.Bool
+	cfg           config.StructuredRequestLogConfig
+	redactHeaders map[string]struct{}
+	segments      *segmentWriter
+	// sample returns a number in [0, 1) compared against the sample rate; replaced in tests.
+	sample func() float64
+}
+
+// NewStructuredRequestLogger creates a structured request logger writing cfg.File inside logsDir.
+// A relative logsDir is resolved against configDir. upload, when non-nil, receives every closed
+// segment if cfg.Upload is set.
+func NewStructuredRequestLogger(enabled bool, logsDir, configDir string, cfg config.StructuredRequestLogConfig, upload SegmentUploader) *StructuredRequestLogger {
+	if !filepath.IsAbs(logsDir) && configDir != "" {
+		logsDir = filepath.Join(configDir, logsDir)
+	}
+	if strings.TrimSpace(cfg.File) == "" {
+		cfg.File = config.DefaultStructuredRequestLogFile
+	}
+	if cfg.MaxBodyBytes == 0 {
+		cfg.MaxBodyBytes = config.DefaultStructuredRequestLogMaxBodyBytes
+	}
+	if cfg.MaxSizeMB <= 0 {
+		cfg.MaxSizeMB = config.DefaultStructuredRequestLogMaxSizeMB
+	}
+	if !cfg.Upload {
+		upload = nil
+	}
+
+	redact := make(map[string]struct{}, len(alwaysRedactedHeaders)+len(cfg.RedactHeaders))
+	for _, header := range append(append([]string(nil), alwaysRedactedHeaders...), cfg.RedactHeaders...) {
+		redact[strings.ToLower(strings.TrimSpace(header))] = struct{}{}
+	}
+
+	l := &StructuredRequestLogger{
+		cfg:           cfg,
+		redactHeaders: redact,
+		segments:      newSegmentWriter(logsDir, cfg.File, int64(cfg.MaxSizeMB)*1024*1024, cfg.MaxBackups, cfg.Compress, upload),
+		sample:        rand.Float64,
+	}
+	l.enabled.Store(enabled)
+	return l
+}
+
+// IsEnabled returns whether request logging is currently enabled.
+func (l *StructuredRequestLogger) IsEnabled() bool {
+	return l.enabled.Load()
+}
+
+// SetEnabled updates the request logging enabled state.
+func (l *StructuredRequestLogger) SetEnabled(enabled bool) {
+	l.enabled.Store(enabled)
+}
+
+// Close closes the active segment and waits for pending compression and uploads.
+func (l *StructuredRequestLogger) Close() error {
+	return l.segments.Close()
+}
+
+// LogRequest appends a non-streaming request/response cycle.
+func (l *StructuredRequestLogger) LogRequest(url, method string, requestHeaders map[string][]string, body []byte, statusCode int, responseHeaders map[string][]string, response, apiRequest, apiResponse []byte, apiResponseErrors []*interfaces.ErrorMessage, requestID string, requestTimestamp, apiResponseTimestamp time.Time) error {
+	return l.LogRequestWithMetadata(RequestMetadata{}, url, method, requestHeaders, body, statusCode, responseHeaders, response, apiRequest, apiResponse, apiResponseErrors, false, requestID, requestTimestamp, apiResponseTimestamp)
+}
+
+// LogRequestWithOptions appends a request; force writes it even when logging is disabled.
+func (l *StructuredRequestLogger) LogRequestWithOptions(url, method string, requestHeaders map[string][]string, body []byte, statusCode int, responseHeaders map[string][]string, response, apiRequest, apiResponse []byte, apiResponseErrors []*interfaces.ErrorMessage, force bool, requestID string, requestTimestamp, apiResponseTimestamp time.Time) error {
+	return l.LogRequestWithMetadata(RequestMetadata{}, url, method, requestHeaders, body, statusCode, responseHeaders, response, apiRequest, apiResponse, apiResponseErrors, force, requestID, requestTimestamp, apiResponseTimestamp)
+}
+
+// LogRequestWithMetadata implements MetadataRequestLogger.
+func (l *StructuredRequestLogger) LogRequestWithMetadata(meta RequestMetadata, url, method string, requestHeaders map[string][]string, body []byte, statusCode int, responseHeaders map[string][]string, response, apiRequest, apiResponse []byte, apiResponseErrors []*interfaces.ErrorMessage, force bool, requestID string, requestTimestamp, apiResponseTimestamp time.Time) error {
+	if !l.IsEnabled() && !force {
+		return nil
+	}
+	if !l.sampled(statusCode) {
+		return nil
+	}
+	// The text logger's decompression helpers carry no state.
+	var text FileRequestLogger
+	if decompressed, err := text.decompressResponse(responseHeaders, response); err == nil {
+		response = decompressed
+	}
+	entry := l.buildEntry(meta, url, method, requestHeaders, body, statusCode, responseHeaders, response, apiRequest, apiResponse, apiResponseErrors, requestID, requestTimestamp, apiResponseTimestamp)
+	return l.write(entry)
+}
+
+// LogStreamingRequest returns a writer that buffers the streamed response and appends the entry
+// when closed.
+func (l *StructuredRequestLogger) LogStreamingRequest(url, method string, headers map[string][]string, body []byte, requestID string) (StreamingLogWriter, error) {
+	if !l.IsEnabled() {
+		return &NoOpStreamingLogWriter{}, nil
+	}
+	requestHeaders := make(map[string][]string, len(headers))
+	for key, values := range headers {
+		requestHeaders[key] = append([]string(nil), values...)
+	}
+	return &structuredStreamingLogWriter{
+		logger:         l,
+		url:            url,
+		method:         method,
+		requestHeaders: requestHeaders,
+		requestBody:    bytes.Clone(body),
+		requestID:      requestID,
+		timestamp:      time.Now(),
+	}, nil
+}
+
+// sampled applies the sample rate of the status class.
+func (l *StructuredRequestLogger) sampled(statusCode int) bool {
+	if len(l.cfg.SampleRates) == 0 {
+		return true
+	}
+	rate, ok := l.cfg.SampleRates[strconv.Itoa(statusCode/100)+"xx"]
+	if !ok {
+		return true
+	}
+	return l.sample() < rate
+}
+
+func (l *StructuredRequestLogger) write(entry *structuredRequestLogEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode structured request log: %w", err)
+	}
+	return l.segments.Write(append(line, '\n'))
+}
+
+// structuredRequestLogEntry is one line of the structured request log.
+type structuredRequestLogEntry struct {
+	Timestamp        time.Time           `json:"timestamp"`
+	RequestID        string              `json:"request_id,omitempty"`
+	Method           string              `json:"method"`
+	URL              string              `json:"url"`
+	Status           int                 `json:"status"`
+	Streaming        bool                `json:"streaming"`
+	DurationMs       int64               `json:"duration_ms"`
+	TTFBMs           int64               `json:"ttfb_ms,omitempty"`
+	ClientKey        string              `json:"client_key,omitempty"`
+	Model            string              `json:"model,omitempty"`
+	Provider         string              `json:"provider,omitempty"`
+	AuthID           string              `json:"auth_id,omitempty"`
+	AuthIndex        string              `json:"auth_index,omitempty"`
+	Usage            *structuredUsage    `json:"usage,omitempty"`
+	Errors           []string            `json:"errors,omitempty"`
+	RequestHeaders   map[string][]string `json:"request_headers,omitempty"`
+	ResponseHeaders  map[string][]string `json:"response_headers,omitempty"`
+	RequestBody      json.RawMessage     `json:"request_body,omitempty"`
+	ResponseBody     json.RawMessage     `json:"response_body,omitempty"`
+	UpstreamRequest  json.RawMessage     `json:"upstream_request,omitempty"`
+	UpstreamResponse json.RawMessage     `json:"upstream_response,omitempty"`
+	Truncated        []string            `json:"truncated,omitempty"`
+}
+
+type structuredUsage struct {
+	InputTokens     int64 `json:"input_tokens"`
+	OutputTokens    int64 `json:"output_tokens"`
+	ReasoningTokens int64 `json:"reasoning_tokens"`
+	CachedTokens    int64 `json:"cached_tokens"`
+	TotalTokens     int64 `json:"total_tokens"`
+}
+
+func (l *StructuredRequestLogger) buildEntry(meta RequestMetadata, url, method string, requestHeaders map[string][]string, body []byte, statusCode int, responseHeaders map[string][]string, response, apiRequest, apiResponse []byte, apiResponseErrors []*interfaces.ErrorMessage, requestID string, requestTimestamp, apiResponseTimestamp time.Time) *structuredRequestLogEntry {
+	if requestTimestamp.IsZero() {
+		requestTimestamp = time.Now()
+	}
+	entry := &structuredRequestLogEntry{
+		Timestamp:       requestTimestamp.UTC(),
+		RequestID:       requestID,
+		Method:          method,
+		URL:             url,
+		Status:          statusCode,
+		DurationMs:      time.Since(requestTimestamp).Milliseconds(),
+		ClientKey:       meta.ClientKey,
+		Model:           meta.Model,
+		Provider:        meta.Provider,
+		AuthID:          meta.AuthID,
+		AuthIndex:       meta.AuthIndex,
+		RequestHeaders:  l.redactHeaderValues(requestHeaders),
+		ResponseHeaders: l.redactHeaderValues(responseHeaders),
+	}
+	if !apiResponseTimestamp.IsZero() {
+		entry.TTFBMs = apiResponseTimestamp.Sub(requestTimestamp).Milliseconds()
+	}
+	if entry.Model == "" {
+		entry.Model = gjson.GetBytes(body, "model").String()
+	}
+	if meta.Usage != nil {
+		entry.Usage = &structuredUsage{
+			InputTokens:     meta.Usage.InputTokens,
+			OutputTokens:    meta.Usage.OutputTokens,
+			ReasoningTokens: meta.Usage.ReasoningTokens,
+			CachedTokens:    meta.Usage.CachedTokens,
+			TotalTokens:     meta.Usage.TotalTokens,
+		}
+	}
+	for _, apiErr := range apiResponseErrors {
+		if apiErr == nil || apiErr.Error == nil {
+			continue
+		}
+		entry.Errors = append(entry.Errors, fmt.Sprintf("%d: %s", apiErr.StatusCode, apiErr.Error.Error()))
+	}
+	if l.cfg.MaxBodyBytes < 0 {
+		return entry
+	}
+	entry.RequestBody = l.encodeBody(entry, "request_body", body, true)
+	entry.ResponseBody = l.encodeBody(entry, "response_body", response, true)
+	// The upstream exchange is a text transcript with masked headers; redact the JSON bodies in it.
+	entry.UpstreamRequest = l.encodeBody(entry, "upstream_request", l.redactTranscriptBodies(apiRequest), false)
+	entry.UpstreamResponse = l.encodeBody(entry, "upstream_response", l.redactTranscriptBodies(apiResponse), false)
+	return entry
+}
+
+// encodeBody returns body as embedded JSON when it is a JSON document that fits the size limit,
+// and as a (possibly truncated) JSON string otherwise.
+func (l *StructuredRequestLogger) encodeBody(entry *structuredRequestLogEntry, field string, body []byte, redact bool) json.RawMessage {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	isJSON := redact && gjson.ValidBytes(body)
+	if redact {
+		body = l.redactBody(body)
+	}
+	limit := l.cfg.MaxBodyBytes
+	if limit > 0 && len(body) > limit {
+		entry.Truncated = append(entry.Truncated, field)
+		body = body[:limit]
+		isJSON = false
+	}
+	if isJSON {
+		compacted := &bytes.Buffer{}
+		if err := json.Compact(compacted, body); err == nil {
+			return compacted.Bytes()
+		}
+	}
+	encoded, _ := json.Marshal(strings.ToValidUTF8(string(body), "�"))
+	return encoded
+}
+
+func (l *StructuredRequestLogger) redactHeaderValues(headers map[string][]string) map[string][]string {
+	if len(headers) == 0 {
+		return nil
+	}
+	out := make(map[string][]string, len(headers))
+	for key, values := range headers {
+		if _, redact := l.redactHeaders[strings.ToLower(key)]; redact {
+			out[key] = []string{redactedValue}
+			continue
+		}
+		out[key] = append([]string(nil), values...)
+	}
+	return out
+}
+
+// redactBody applies the configured body redactions to a JSON document, or to every JSON line
+// (optionally prefixed with "data:") of a stream.
+func (l *StructuredRequestLogger) redactBody(body []byte) []byte {
+	if len(l.cfg.RedactBodyFields) == 0 {
+		return body
+	}
+	if gjson.ValidBytes(body) {
+		return l.redactJSON(body)
+	}
+	lines := bytes.Split(body, []byte("\n"))
+	for i, line := range lines {
+		payload := bytes.TrimSpace(bytes.TrimPrefix(line, []byte("data:")))
+		if len(payload) == 0 || (payload[0] != '{' && payload[0] != '[') || !gjson.ValidBytes(payload) {
+			continue
+		}
+		offset := bytes.Index(line, payload)
+		redacted := append([]byte(nil), line[:offset]...)
+		redacted = append(redacted, l.redactJSON(payload)...)
+		lines[i] = append(redacted, line[offset+len(payload):]...)
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
+
+// redactTranscriptBodies applies redactBody to every "Body:" section of an upstream request or
+// response transcript. A section ends at the next "=== API ..." heading.
+func (l *StructuredRequestLogger) redactTranscriptBodies(transcript []byte) []byte {
+	if len(l.cfg.RedactBodyFields) == 0 || len(transcript) == 0 {
+		return transcript
+	}
+	sections := bytes.Split(transcript, []byte("\nBody:\n"))
+	for i := 1; i < len(sections); i++ {
+		end := bytes.Index(sections[i], []byte("\n\n=== "))
+		if end < 0 {
+			end = len(bytes.TrimRight(sections[i], "\n"))
+		}
+		redacted := append([]byte(nil), l.redactBody(sections[i][:end])...)
+		sections[i] = append(redacted, sections[i][end:]...)
+	}
+	return bytes.Join(sections, []byte("\nBody:\n"))
+}
+
+func (l *StructuredRequestLogger) redactJSON(body []byte) []byte {
+	for _, path := range l.cfg.RedactBodyFields {
+		body = redactJSONPath(body, path)
+	}
+	return body
+}
+
+// redactJSONPath replaces the value at path. A "#" segment matches every element of an array.
+func redactJSONPath(body []byte, path string) []byte {
+	segments := strings.Split(path, ".")
+	for i, segment := range segments {
+		if segment != "#" {
+			continue
+		}
+		prefix := strings.Join(segments[:i], ".")
+		rest := strings.Join(segments[i+1:], ".")
+		array := gjson.ParseBytes(body)
+		if prefix != "" {
+			array = gjson.GetBytes(body, prefix)
+		}
+		if !array.IsArray() {
+			return body
+		}
+		for idx := range len(array.Array()) {
+			elementPath := strconv.Itoa(idx)
+			if prefix != "" {
+				elementPath = prefix + "." + elementPath
+			}
+			if rest != "" {
+				elementPath += "." + rest
+			}
+			body = redactJSONPath(body, elementPath)
+		}
+		return body
+	}
+	if !gjson.GetBytes(body, path).Exists() {
+		return body
+	}
+	if redacted, err := sjson.SetBytes(body, path, redactedValue); err == nil {
+		return redacted
+	}
+	return body
+}
+
+// structuredStreamingLogWriter buffers a streaming response up to the body size limit and
+// appends the entry on Close.
+type structuredStreamingLogWriter struct {
+	logger          *StructuredRequestLogger
+	url             string
+	method          string
+	requestHeaders  map[string][]string
+	requestBody     []byte
+	requestID       string
+	timestamp       time.Time
+	mu              sync.Mutex
+	response        bytes.Buffer
+	truncated       bool
+	status          int
+	responseHeaders map[string][]string
+	apiRequest      []byte
+	apiResponse     []byte
+	firstChunk      time.Time
+	meta            RequestMetadata
+}
+
+// WriteChunkAsync buffers a response chunk until the body size limit is reached.
+func (w *structuredStreamingLogWriter) WriteChunkAsync(chunk []byte) {
+	limit := w.logger.cfg.MaxBodyBytes
+	if limit < 0 {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if limit > 0 && w.response.Len()+len(chunk) > limit {
+		// Keep one byte beyond the limit so encodeBody marks the body as truncated.
+		remaining := limit + 1 - w.response.Len()
+		if remaining > 0 {
+			w.response.Write(chunk[:min(remaining, len(chunk))])
+		}
+		return
+	}
+	w.response.Write(chunk)
+}
+
+// WriteStatus records the response status and headers.
+func (w *structuredStreamingLogWriter) WriteStatus(status int, headers map[string][]string) error {
+	if status == 0 {
+		return nil
+	}
+	w.status = status
+	w.responseHeaders = make(map[string][]string, len(headers))
+	for key, values := range headers {
+		w.responseHeaders[key] = append([]string(nil), values...)
+	}
+	return nil
+}
+
+// WriteAPIRequest records the upstream request transcript.
+func (w *structuredStreamingLogWriter) WriteAPIRequest(apiRequest []byte) error {
+	w.apiRequest = bytes.Clone(apiRequest)
+	return nil
+}
+
+// WriteAPIResponse records the upstream response transcript.
+func (w *structuredStreamingLogWriter) WriteAPIResponse(apiResponse []byte) error {
+	w.apiResponse = bytes.Clone(apiResponse)
+	return nil
+}
+
+// SetFirstChunkTimestamp records the time to first byte.
+func (w *structuredStreamingLogWriter) SetFirstChunkTimestamp(timestamp time.Time) {
+	if !timestamp.IsZero() {
+		w.firstChunk = timestamp
+	}
+}
+
+// SetMetadata implements MetadataStreamingLogWriter.
+func (w *structuredStreamingLogWriter) SetMetadata(meta RequestMetadata) {
+	w.meta = meta
+}
+
+// Close appends the entry unless it is sampled out.
+func (w *structuredStreamingLogWriter) Close() error {
+	status := w.status
+	if status == 0 {
+		status = 200
+	}
+	if !w.logger.sampled(status) {
+		return nil
+	}
+	w.mu.Lock()
+	response := bytes.Clone(w.response.Bytes())
+	w.mu.Unlock()
+	entry := w.logger.buildEntry(w.meta, w.url, w.method, w.requestHeaders, w.requestBody, status, w.responseHeaders, response, w.apiRequest, w.apiResponse, nil, w.requestID, w.timestamp, w.firstChunk)
+	entry.Streaming = true
+	return w.logger.write(entry)
+}
@@ -0,0 +1,244 @@
+package logging
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	"github.com/tidwall/gjson"
+)
+
+func readStructuredEntries(t *testing.T, path string) []gjson.Result {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open log: %v", err)
+	}
+	defer func() { _ = file.Close() }()
+	var entries []gjson.Result
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 1024*1024), 1024*1024)
+	for scanner.Scan() {
+		if !json.Valid(scanner.Bytes()) {
+			t.Fatalf("invalid JSON line: %s", scanner.Text())
+		}
+		entries = append(entries, gjson.ParseBytes(append([]byte(nil), scanner.Bytes()...)))
+	}
+	return entries
+}
+
+func TestStructuredRequestLoggerWritesRedactedEntry(t *testing.T) {
+	dir := t.TempDir()
+	logger := NewStructuredRequestLogger(true, dir, "", config.StructuredRequestLogConfig{
+		RedactHeaders:    []string{"X-Secret"},
+		RedactBodyFields: []string{"messages.#.content", "metadata.user_id"},
+	}, nil)
+	defer func() { _ = logger.Close() }()
+
+	requestHeaders := map[string][]string{
+		"Authorization": {"Bearer sk-secret"},
+		"X-Secret":      {"hidden"},
+		"Content-Type":  {"application/json"},
+	}
+	body := []byte(`{"model":"gpt-5","messages":[{"role":"user","content":"hi"},{"role":"user","content":"there"}],"metadata":{"user_id":"u1"}}`)
+	meta := RequestMetadata{
+		ClientKey: "team-a",
+		Model:     "gpt-5",
+		Provider:  "codex",
+		AuthIndex: "3",
+		Usage:     &coreusage.Detail{InputTokens: 10, OutputTokens: 5, TotalTokens: 15},
+	}
+	started := time.Now().Add(-50 * time.Millisecond)
+	apiErrors := []*interfaces.ErrorMessage{{StatusCode: 429, Error: errors.New("rate limited")}}
+	err := logger.LogRequestWithMetadata(meta, "/v1/chat/completions", "POST", requestHeaders, body, 200,
+		map[string][]string{"Content-Type": {"application/json"}}, []byte(`{"id":"x"}`),
+		[]byte("=== API REQUEST 1 ===\nBody:\n{\"metadata\":{\"user_id\":\"u1\"}}\n\n"),
+		[]byte("=== API RESPONSE 1 ===\nBody:\ndata: {\"metadata\":{\"user_id\":\"u1\"}}\n\ndata: [DONE]"), apiErrors, false, "req-1", started, started.Add(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("LogRequestWithMetadata: %v", err)
+	}
+
+	entries := readStructuredEntries(t, filepath.Join(dir, config.DefaultStructuredRequestLogFile))
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	entry := entries[0]
+	checks := map[string]string{
+		"request_id":                      "req-1",
+		"status":                          "200",
+		"client_key":                      "team-a",
+		"model":                           "gpt-5",
+		"provider":                        "codex",
+		"auth_index":                      "3",
+		"usage.total_tokens":              "15",
+		"ttfb_ms":                         "10",
+		"errors.0":                        "429: rate limited",
+		"request_headers.Authorization.0": redactedValue,
+		"request_headers.X-Secret.0":      redactedValue,
+		"request_headers.Content-Type.0":  "application/json",
+		"request_body.messages.0.content": redactedValue,
+		"request_body.messages.1.content": redactedValue,
+		"request_body.messages.0.role":    "user",
+		"request_body.metadata.user_id":   redactedValue,
+		"response_body.id":                "x",
+	}
+	for path, want := range checks {
+		if got := entry.Get(path).String(); got != want {
+			t.Errorf("%s = %q, want %q", path, got, want)
+		}
+	}
+	if entry.Get("duration_ms").Int() < 50 {
+		t.Errorf("duration_ms = %d, want >= 50", entry.Get("duration_ms").Int())
+	}
+	if upstream := entry.Get("upstream_request").String(); !strings.HasPrefix(upstream, "=== API REQUEST 1") || strings.Contains(upstream, "u1") {
+		t.Errorf("upstream_request = %s", entry.Get("upstream_request").Raw)
+	}
+	if upstream := entry.Get("upstream_response").String(); strings.Contains(upstream, "u1") || !strings.Contains(upstream, "data: [DONE]") {
+		t.Errorf("upstream_response = %s", entry.Get("upstream_response").Raw)
+	}
+}
+
+func TestStructuredRequestLoggerTruncatesAndOmitsBodies(t *testing.T) {
+	dir := t.TempDir()
+	logger := NewStructuredRequestLogger(true, dir, "", config.StructuredRequestLogConfig{MaxBodyBytes: 8}, nil)
+	if err := logger.LogRequest("/v1/messages", "POST", nil, []byte(`{"model":"claude-sonnet"}`), 200, nil, []byte("0123456789abcdef"), nil, nil, nil, "", time.Now(), time.Time{}); err != nil {
+		t.Fatalf("LogRequest: %v", err)
+	}
+	_ = logger.Close()
+
+	entry := readStructuredEntries(t, filepath.Join(dir, config.DefaultStructuredRequestLogFile))[0]
+	if got := entry.Get("request_body").String(); got != `{"model"` {
+		t.Errorf("request_body = %q", got)
+	}
+	if got := entry.Get("response_body").String(); got != "01234567" {
+		t.Errorf("response_body = %q", got)
+	}
+	if got := entry.Get("truncated").Raw; got != `["request_body","response_body"]` {
+		t.Errorf("truncated = %s", got)
+	}
+	if got := entry.Get("model").String(); got != "claude-sonnet" {
+		t.Errorf("model = %q, want fallback from request body", got)
+	}
+
+	omitDir := t.TempDir()
+	omitting := NewStructuredRequestLogger(true, omitDir, "", config.StructuredRequestLogConfig{MaxBodyBytes: -1}, nil)
+	_ = omitting.LogRequest("/v1/messages", "POST", nil, []byte(`{"model":"m"}`), 200, nil, []byte("ok"), nil, nil, nil, "", time.Now(), time.Time{})
+	_ = omitting.Close()
+	entry = readStructuredEntries(t, filepath.Join(omitDir, config.DefaultStructuredRequestLogFile))[0]
+	if entry.Get("request_body").Exists() || entry.Get("response_body").Exists() {
+		t.Errorf("expected bodies to be omitted: %s", entry.Raw)
+	}
+}
+
+func TestStructuredRequestLoggerSamplesByStatusClass(t *testing.T) {
+	dir := t.TempDir()
+	logger := NewStructuredRequestLogger(true, dir, "", config.StructuredRequestLogConfig{
+		SampleRates: map[string]float64{"2xx": 0.25, "5xx": 0},
+	}, nil)
+	logger.sample = func() float64 { return 0.5 }
+
+	for _, status := range []int{200, 404, 500} {
+		_ = logger.LogRequest("/v1/models", "POST", nil, nil, status, nil, nil, nil, nil, nil, "", time.Now(), time.Time{})
+	}
+	logger.sample = func() float64 { return 0.1 }
+	_ = logger.LogRequest("/v1/models", "POST", nil, nil, 201, nil, nil, nil, nil, nil, "", time.Now(), time.Time{})
+	_ = logger.Close()
+
+	entries := readStructuredEntries(t, filepath.Join(dir, config.DefaultStructuredRequestLogFile))
+	var statuses []int64
+	for _, entry := range entries {
+		statuses = append(statuses, entry.Get("status").Int())
+	}
+	if len(statuses) != 2 || statuses[0] != 404 || statuses[1] != 201 {
+		t.Fatalf("logged statuses = %v, want [404 201]", statuses)
+	}
+}
+
+func TestStructuredRequestLoggerStreaming(t *testing.T) {
+	dir := t.TempDir()
+	logger := NewStructuredRequestLogger(true, dir, "", config.StructuredRequestLogConfig{}, nil)
+	writer, err := logger.LogStreamingRequest("/v1/chat/completions", "POST", map[string][]string{"X-Api-Key": {"k"}}, []byte(`{"model":"gpt-5","stream":true}`), "req-s")
+	if err != nil {
+		t.Fatalf("LogStreamingRequest: %v", err)
+	}
+	_ = writer.WriteStatus(200, map[string][]string{"Content-Type": {"text/event-stream"}})
+	writer.WriteChunkAsync([]byte("data: {\"a\":1}\n\n"))
+	writer.WriteChunkAsync([]byte("data: [DONE]\n\n"))
+	writer.(MetadataStreamingLogWriter).SetMetadata(RequestMetadata{Provider: "codex", Usage: &coreusage.Detail{TotalTokens: 7}})
+	if err = writer.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	_ = logger.Close()
+
+	entry := readStructuredEntries(t, filepath.Join(dir, config.DefaultStructuredRequestLogFile))[0]
+	if !entry.Get("streaming").Bool() || entry.Get("provider").String() != "codex" || entry.Get("usage.total_tokens").Int() != 7 {
+		t.Fatalf("unexpected streaming entry: %s", entry.Raw)
+	}
+	if got := entry.Get("response_body").String(); got != "data: {\"a\":1}\n\ndata: [DONE]\n\n" {
+		t.Errorf("response_body = %q", got)
+	}
+	if got := entry.Get("request_headers.X-Api-Key.0").String(); got != redactedValue {
+		t.Errorf("X-Api-Key = %q", got)
+	}
+}
+
+func TestStructuredRequestLoggerRotatesCompressesAndUploads(t *testing.T) {
+	dir := t.TempDir()
+	var (
+		mu       sync.Mutex
+		uploaded []string
+	)
+	upload := func(_ context.Context, name string, data []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if len(data) == 0 {
+			t.Errorf("empty upload for %s", name)
+		}
+		uploaded = append(uploaded, name)
+		return nil
+	}
+	logger := NewStructuredRequestLogger(true, dir, "", config.StructuredRequestLogConfig{
+		MaxBackups: 2,
+		Compress:   true,
+		Upload:     true,
+	}, upload)
+	// Rotate after every line.
+	logger.segments.maxBytes = 1
+
+	for i := 0; i < 4; i++ {
+		_ = logger.LogRequest("/v1/models", "POST", nil, nil, 200, nil, nil, nil, nil, nil, "", time.Now(), time.Time{})
+		// Segment names carry millisecond timestamps.
+		time.Sleep(2 * time.Millisecond)
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(uploaded) != 3 {
+		t.Fatalf("expected 3 uploaded segments, got %v", uploaded)
+	}
+	for _, name := range uploaded {
+		if !strings.HasPrefix(name, "requests-") || !strings.HasSuffix(name, ".jsonl.gz") {
+			t.Errorf("unexpected segment name %q", name)
+		}
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, "requests-*.jsonl.gz"))
+	if len(matches) != 2 {
+		t.Errorf("expected 2 retained segments, got %v", matches)
+	}
+	if entries := readStructuredEntries(t, filepath.Join(dir, config.DefaultStructuredRequestLogFile)); len(entries) != 1 {
+		t.Errorf("expected active segment to hold the last entry, got %d", len(entries))
+	}
+}
@@ -0,0 +1,261 @@
+// Package mcp implements a minimal Model Context Protocol client used to bridge tools of
+// configured MCP servers into proxied model requests. It supports the stdio and streamable
+// HTTP transports and only the tool-related parts of the protocol.
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+const (
+	protocolVersion = "2025-06-18"
+	clientName      = "cli-proxy-api"
+	connectTimeout  = 30 * time.Second
+)
+
+// Tool describes a tool exposed by an MCP server.
+type Tool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"inputSchema,omitempty"`
+}
+
+// CallResult is the flattened outcome of a tool call.
+type CallResult struct {
+	// Text holds the textual tool output; non-text content parts are JSON encoded.
+	Text string
+	// IsError reports whether the tool signalled a failure.
+	IsError bool
+}
+
+type rpcRequest struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      *int64 `json:"id,omitempty"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("mcp error %d: %s", e.Code, e.Message)
+}
+
+type rpcMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// transport carries JSON-RPC messages to a single MCP server.
+type transport interface {
+	// call sends a request and waits for the matching response.
+	call(ctx context.Context, req rpcRequest) (json.RawMessage, error)
+	// notify sends a notification that expects no response.
+	notify(ctx context.Context, req rpcRequest) error
+	close() error
+}
+
+// Client is a lazily connected MCP client for one configured server.
+type Client struct {
+	server config.MCPServer
+
+	mu     sync.Mutex
+	conn   transport
+	tools  []Tool
+	nextID int64
+}
+
+// NewClient creates a client for the server. No connection is made until first use.
+func NewClient(server config.MCPServer) *Client {
+	return &Client{server: server}
+}
+
+// Name returns the configured server name.
+func (c *Client) Name() string {
+	return c.server.Name
+}
+
+// Server returns the server configuration.
+func (c *Client) Server() config.MCPServer {
+	return c.server
+}
+
+// Tools returns the tools exposed by the server, connecting on first use.
+func (c *Client) Tools(ctx context.Context) ([]Tool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.ensureLocked(ctx); err != nil {
+		return nil, err
+	}
+	return c.tools, nil
+}
+
+// CallTool invokes a tool with the given JSON arguments.
+func (c *Client) CallTool(ctx context.Context, name string, arguments json.RawMessage) (CallResult, error) {
+	timeout := time.Duration(c.server.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = config.DefaultMCPToolTimeoutSeconds * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if len(strings.TrimSpace(string(arguments))) == 0 {
+		arguments = json.RawMessage(`{}`)
+	}
+	c.mu.Lock()
+	if err := c.ensureLocked(ctx); err != nil {
+		c.mu.Unlock()
+		return CallResult{}, err
+	}
+	conn := c.conn
+	req := c.requestLocked("tools/call", map[string]any{"name": name, "arguments": arguments})
+	c.mu.Unlock()
+
+	raw, err := conn.call(ctx, req)
+	if err != nil {
+		var rpcErr *rpcError
+		if !errors.As(err, &rpcErr) {
+			c.reset(conn)
+		}
+		return CallResult{}, err
+	}
+	return parseCallResult(raw)
+}
+
+// Close terminates the connection to the server.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.close()
+	c.conn = nil
+	c.tools = nil
+	return err
+}
+
+// reset drops a broken connection so the next call reconnects.
+func (c *Client) reset(conn transport) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn != conn {
+		return
+	}
+	_ = c.conn.close()
+	c.conn = nil
+	c.tools = nil
+}
+
+func (c *Client) requestLocked(method string, params any) rpcRequest {
+	c.nextID++
+	id := c.nextID
+	return rpcRequest{JSONRPC: "2.0", ID: &id, Method: method, Params: params}
+}
+
+func (c *Client) ensureLocked(ctx context.Context) error {
+	if c.conn != nil {
+		return nil
+	}
+	connectCtx, cancel := context.WithTimeout(ctx, connectTimeout)
+	defer cancel()
+
+	var (
+		conn transport
+		err  error
+	)
+	switch c.server.TransportKind() {
+	case "http":
+		conn = newHTTPTransport(c.server)
+	default:
+		conn, err = newStdioTransport(c.server)
+		if err != nil {
+			return fmt.Errorf("mcp %s: start server: %w", c.server.Name, err)
+		}
+	}
+
+	if _, err = conn.call(connectCtx, c.requestLocked("initialize", map[string]any{
+		"protocolVersion": protocolVersion,
+		"capabilities":    map[string]any{},
+		"clientInfo":      map[string]any{"name": clientName, "version": "1.0.0"},
+	})); err != nil {
+		_ = conn.close()
+		return fmt.Errorf("mcp %s: initialize: %w", c.server.Name, err)
+	}
+	if err = conn.notify(connectCtx, rpcRequest{JSONRPC: "2.0", Method: "notifications/initialized"}); err != nil {
+		_ = conn.close()
+		return fmt.Errorf("mcp %s: initialized notification: %w", c.server.Name, err)
+	}
+
+	var tools []Tool
+	cursor := ""
+	for {
+		params := map[string]any{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+		raw, errList := conn.call(connectCtx, c.requestLocked("tools/list", params))
+		if errList != nil {
+			_ = conn.close()
+			return fmt.Errorf("mcp %s: list tools: %w", c.server.Name, errList)
+		}
+		var page struct {
+			Tools      []Tool `json:"tools"`
+			NextCursor string `json:"nextCursor"`
+		}
+		if errDecode := json.Unmarshal(raw, &page); errDecode != nil {
+			_ = conn.close()
+			return fmt.Errorf("mcp %s: decode tools: %w", c.server.Name, errDecode)
+		}
+		tools = append(tools, page.Tools...)
+		if page.NextCursor == "" || page.NextCursor == cursor {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	c.conn = conn
+	c.tools = tools
+	return nil
+}
+
+func parseCallResult(raw json.RawMessage) (CallResult, error) {
+	var payload struct {
+		Content           []json.RawMessage `json:"content"`
+		StructuredContent json.RawMessage   `json:"structuredContent"`
+		IsError           bool              `json:"isError"`
+	}
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return CallResult{}, fmt.Errorf("mcp: decode tool result: %w", err)
+	}
+	parts := make([]string, 0, len(payload.Content))
+	for _, item := range payload.Content {
+		var part struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		}
+		if err := json.Unmarshal(item, &part); err == nil && part.Type == "text" {
+			parts = append(parts, part.Text)
+			continue
+		}
+		parts = append(parts, string(item))
+	}
+	if len(parts) == 0 && len(payload.StructuredContent) > 0 {
+		parts = append(parts, string(payload.StructuredContent))
+	}
+	return CallResult{Text: strings.Join(parts, "\n"), IsError: payload.IsError}, nil
+}
@@ -0,0 +1,152 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const listToolsTimeout = 15 * time.Second
+
+// Manager owns the clients of all configured MCP servers.
+type Manager struct {
+	mu      sync.Mutex
+	clients []*Client
+}
+
+// NewManager creates a manager for the given configuration.
+func NewManager(cfg config.MCPConfig) *Manager {
+	m := &Manager{}
+	m.Update(cfg)
+	return m
+}
+
+// Update applies a new configuration, closing clients whose server was removed or changed.
+func (m *Manager) Update(cfg config.MCPConfig) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	existing := make(map[string]*Client, len(m.clients))
+	for _, client := range m.clients {
+		existing[client.Name()] = client
+	}
+	clients := make([]*Client, 0, len(cfg.Servers))
+	for _, server := range cfg.Servers {
+		if client, ok := existing[server.Name]; ok && reflect.DeepEqual(client.Server(), server) {
+			clients = append(clients, client)
+			delete(existing, server.Name)
+			continue
+		}
+		clients = append(clients, NewClient(server))
+	}
+	for _, stale := range existing {
+		go func(client *Client) {
+			if err := client.Close(); err != nil {
+				log.Debugf("mcp %s: close: %v", client.Name(), err)
+			}
+		}(stale)
+	}
+	m.clients = clients
+}
+
+// Close terminates every client.
+func (m *Manager) Close() {
+	m.mu.Lock()
+	clients := m.clients
+	m.clients = nil
+	m.mu.Unlock()
+	for _, client := range clients {
+		_ = client.Close()
+	}
+}
+
+// Toolset resolves the tools available to a request for the model and client API key.
+// Tools whose names are listed in exclude (typically the client's own tools) are skipped.
+// It returns nil when no bridged tool applies.
+func (m *Manager) Toolset(ctx context.Context, model, apiKey string, exclude map[string]struct{}) *Toolset {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	clients := append([]*Client(nil), m.clients...)
+	m.mu.Unlock()
+
+	var set *Toolset
+	for _, client := range clients {
+		server := client.Server()
+		if !server.Matches(model, apiKey) {
+			continue
+		}
+		listCtx, cancel := context.WithTimeout(ctx, listToolsTimeout)
+		tools, err := client.Tools(listCtx)
+		cancel()
+		if err != nil {
+			log.Warnf("mcp %s: tools unavailable: %v", server.Name, err)
+			continue
+		}
+		for _, tool := range tools {
+			if !server.AllowsTool(tool.Name) {
+				continue
+			}
+			if _, skip := exclude[tool.Name]; skip {
+				continue
+			}
+			if set == nil {
+				set = &Toolset{owners: make(map[string]*Client)}
+			}
+			if _, dup := set.owners[tool.Name]; dup {
+				continue
+			}
+			set.owners[tool.Name] = client
+			set.tools = append(set.tools, tool)
+		}
+	}
+	return set
+}
+
+// Toolset is the set of bridged tools attached to one request.
+type Toolset struct {
+	tools  []Tool
+	owners map[string]*Client
+}
+
+// Tools returns the bridged tool definitions.
+func (s *Toolset) Tools() []Tool {
+	if s == nil {
+		return nil
+	}
+	return s.tools
+}
+
+// Has reports whether name is a bridged tool.
+func (s *Toolset) Has(name string) bool {
+	if s == nil {
+		return false
+	}
+	_, ok := s.owners[name]
+	return ok
+}
+
+// Call executes a bridged tool. Transport failures are reported as error results so the
+// model can react to them.
+func (s *Toolset) Call(ctx context.Context, name string, arguments json.RawMessage) CallResult {
+	var (
+		result CallResult
+		err    error
+	)
+	if client, ok := s.owners[name]; ok {
+		result, err = client.CallTool(ctx, name, arguments)
+	} else {
+		err = fmt.Errorf("unknown tool %q", name)
+	}
+	if err != nil {
+		log.Warnf("mcp tool %s failed: %v", name, err)
+		return CallResult{Text: fmt.Sprintf("tool call failed: %v", err), IsError: true}
+	}
+	return result
+}
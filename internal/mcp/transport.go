@@ -0,0 +1,342 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+var errTransportClosed = errors.New("mcp: transport closed")
+
+// stdioTransport exchanges newline-delimited JSON-RPC messages with a child process.
+type stdioTransport struct {
+	name  string
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	pending map[string]chan rpcMessage
+	closed  bool
+	done    chan struct{}
+}
+
+func newStdioTransport(server config.MCPServer) (*stdioTransport, error) {
+	cmd := exec.Command(server.Command, server.Args...)
+	if len(server.Env) > 0 {
+		cmd.Env = os.Environ()
+		for key, value := range server.Env {
+			cmd.Env = append(cmd.Env, key+"="+value)
+		}
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err = cmd.Start(); err != nil {
+		return nil, err
+	}
+	t := &stdioTransport{
+		name:    server.Name,
+		cmd:     cmd,
+		stdin:   stdin,
+		pending: make(map[string]chan rpcMessage),
+		done:    make(chan struct{}),
+	}
+	go t.readLoop(stdout)
+	go func() {
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			log.Debugf("mcp %s stderr: %s", server.Name, scanner.Text())
+		}
+	}()
+	return t, nil
+}
+
+func (t *stdioTransport) readLoop(stdout io.Reader) {
+	defer t.shutdown()
+	reader := bufio.NewReaderSize(stdout, 64*1024)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			t.dispatch(line)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (t *stdioTransport) dispatch(line []byte) {
+	var msg rpcMessage
+	if err := json.Unmarshal(line, &msg); err != nil {
+		log.Debugf("mcp %s: ignoring non-JSON output: %s", t.name, strings.TrimSpace(string(line)))
+		return
+	}
+	if msg.Method != "" {
+		if len(msg.ID) > 0 {
+			// Server-initiated requests (ping, roots, sampling) are not supported; answer so the server does not stall.
+			t.reply(msg)
+		}
+		return
+	}
+	key := string(msg.ID)
+	t.mu.Lock()
+	ch, ok := t.pending[key]
+	delete(t.pending, key)
+	t.mu.Unlock()
+	if ok {
+		ch <- msg
+	}
+}
+
+func (t *stdioTransport) reply(req rpcMessage) {
+	response := map[string]any{"jsonrpc": "2.0", "id": req.ID}
+	if req.Method == "ping" {
+		response["result"] = map[string]any{}
+	} else {
+		response["error"] = rpcError{Code: -32601, Message: "method not supported"}
+	}
+	data, err := json.Marshal(response)
+	if err != nil {
+		return
+	}
+	_ = t.write(data)
+}
+
+func (t *stdioTransport) write(data []byte) error {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	if _, err := t.stdin.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *stdioTransport) call(ctx context.Context, req rpcRequest) (json.RawMessage, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("%d", *req.ID)
+	ch := make(chan rpcMessage, 1)
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return nil, errTransportClosed
+	}
+	t.pending[key] = ch
+	t.mu.Unlock()
+
+	if err = t.write(data); err != nil {
+		t.mu.Lock()
+		delete(t.pending, key)
+		t.mu.Unlock()
+		return nil, err
+	}
+	select {
+	case msg := <-ch:
+		if msg.Error != nil {
+			return nil, msg.Error
+		}
+		return msg.Result, nil
+	case <-t.done:
+		return nil, errTransportClosed
+	case <-ctx.Done():
+		t.mu.Lock()
+		delete(t.pending, key)
+		t.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+func (t *stdioTransport) notify(_ context.Context, req rpcRequest) error {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	return t.write(data)
+}
+
+func (t *stdioTransport) shutdown() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return
+	}
+	t.closed = true
+	close(t.done)
+}
+
+func (t *stdioTransport) close() error {
+	_ = t.stdin.Close()
+	if t.cmd.Process != nil {
+		_ = t.cmd.Process.Kill()
+	}
+	err := t.cmd.Wait()
+	t.shutdown()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return nil
+	}
+	return err
+}
+
+// httpTransport speaks the streamable HTTP transport: each message is POSTed and the
+// response arrives either as JSON or as an SSE stream.
+type httpTransport struct {
+	server config.MCPServer
+	client *http.Client
+
+	mu        sync.Mutex
+	sessionID string
+}
+
+func newHTTPTransport(server config.MCPServer) *httpTransport {
+	return &httpTransport{server: server, client: &http.Client{}}
+}
+
+func (t *httpTransport) post(ctx context.Context, req rpcRequest) (*http.Response, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, t.server.URL, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json, text/event-stream")
+	if req.Method != "initialize" {
+		httpReq.Header.Set("MCP-Protocol-Version", protocolVersion)
+	}
+	for key, value := range t.server.Headers {
+		httpReq.Header.Set(key, value)
+	}
+	t.mu.Lock()
+	if t.sessionID != "" {
+		httpReq.Header.Set("Mcp-Session-Id", t.sessionID)
+	}
+	t.mu.Unlock()
+
+	resp, err := t.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	if sessionID := resp.Header.Get("Mcp-Session-Id"); sessionID != "" {
+		t.mu.Lock()
+		t.sessionID = sessionID
+		t.mu.Unlock()
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("mcp: http %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return resp, nil
+}
+
+func (t *httpTransport) call(ctx context.Context, req rpcRequest) (json.RawMessage, error) {
+	resp, err := t.post(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	wantID := fmt.Sprintf("%d", *req.ID)
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/event-stream" {
+		var msg rpcMessage
+		if errDecode := json.NewDecoder(resp.Body).Decode(&msg); errDecode != nil {
+			return nil, fmt.Errorf("mcp: decode response: %w", errDecode)
+		}
+		return messageResult(msg)
+	}
+
+	reader := bufio.NewReaderSize(resp.Body, 64*1024)
+	var data bytes.Buffer
+	for {
+		line, errRead := reader.ReadBytes('\n')
+		trimmed := bytes.TrimRight(line, "\r\n")
+		switch {
+		case bytes.HasPrefix(trimmed, []byte("data:")):
+			data.Write(bytes.TrimSpace(trimmed[5:]))
+		case len(trimmed) == 0 && data.Len() > 0:
+			var msg rpcMessage
+			if errDecode := json.Unmarshal(data.Bytes(), &msg); errDecode == nil && msg.Method == "" && string(msg.ID) == wantID {
+				return messageResult(msg)
+			}
+			data.Reset()
+		}
+		if errRead != nil {
+			if data.Len() > 0 {
+				var msg rpcMessage
+				if errDecode := json.Unmarshal(data.Bytes(), &msg); errDecode == nil && string(msg.ID) == wantID {
+					return messageResult(msg)
+				}
+			}
+			return nil, fmt.Errorf("mcp: stream ended without a response: %w", errRead)
+		}
+	}
+}
+
+func (t *httpTransport) notify(ctx context.Context, req rpcRequest) error {
+	resp, err := t.post(ctx, req)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
+
+func (t *httpTransport) close() error {
+	t.mu.Lock()
+	sessionID := t.sessionID
+	t.sessionID = ""
+	t.mu.Unlock()
+	if sessionID == "" {
+		return nil
+	}
+	// Terminate the session explicitly; servers that do not support it answer 405.
+	req, err := http.NewRequest(http.MethodDelete, t.server.URL, nil)
+	if err != nil {
+		return nil
+	}
+	req.Header.Set("Mcp-Session-Id", sessionID)
+	for key, value := range t.server.Headers {
+		req.Header.Set(key, value)
+	}
+	if resp, errDo := t.client.Do(req); errDo == nil {
+		_ = resp.Body.Close()
+	}
+	return nil
+}
+
+func messageResult(msg rpcMessage) (json.RawMessage, error) {
+	if msg.Error != nil {
+		return nil, msg.Error
+	}
+	return msg.Result, nil
+}
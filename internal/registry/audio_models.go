@@ -0,0 +1,97 @@
+package registry
+
+import "strings"
+
+// GetGeminiAudioModels returns the Gemini text-to-speech models served through generateContent
+// with AUDIO response modality. Regular Gemini models accept audio input directly and are used
+// for transcription.
+func GetGeminiAudioModels() []*ModelInfo {
+	return []*ModelInfo{
+		{
+			ID: "gemini-2.5-flash-preview-tts", Object: "model", Created: 1747699200, OwnedBy: "google", Type: "gemini",
+			DisplayName: "Gemini 2.5 Flash Preview TTS", Name: "models/gemini-2.5-flash-preview-tts",
+			Description:                "Gemini 2.5 Flash text-to-speech preview model.",
+			InputTokenLimit:            8192,
+			OutputTokenLimit:           16384,
+			SupportedGenerationMethods: []string{"generateContent", "countTokens"},
+			SupportedEndpoints:         []string{"/audio/speech"},
+			SupportedInputModalities:   []string{"TEXT"},
+			SupportedOutputModalities:  []string{"AUDIO"},
+		},
+		{
+			ID: "gemini-2.5-pro-preview-tts", Object: "model", Created: 1747699200, OwnedBy: "google", Type: "gemini",
+			DisplayName: "Gemini 2.5 Pro Preview TTS", Name: "models/gemini-2.5-pro-preview-tts",
+			Description:                "Gemini 2.5 Pro text-to-speech preview model.",
+			InputTokenLimit:            8192,
+			OutputTokenLimit:           16384,
+			SupportedGenerationMethods: []string{"generateContent", "countTokens"},
+			SupportedEndpoints:         []string{"/audio/speech"},
+			SupportedInputModalities:   []string{"TEXT"},
+			SupportedOutputModalities:  []string{"AUDIO"},
+		},
+	}
+}
+
+// GetOpenAIAudioModels returns the OpenAI transcription and speech models available to API keys.
+func GetOpenAIAudioModels() []*ModelInfo {
+	transcription := func(id, name string) *ModelInfo {
+		return &ModelInfo{
+			ID: id, Object: "model", Created: 1677532384, OwnedBy: "openai", Type: "openai", DisplayName: name,
+			SupportedEndpoints:        []string{"/audio/transcriptions"},
+			SupportedInputModalities:  []string{"AUDIO"},
+			SupportedOutputModalities: []string{"TEXT"},
+		}
+	}
+	speech := func(id, name string) *ModelInfo {
+		return &ModelInfo{
+			ID: id, Object: "model", Created: 1699046400, OwnedBy: "openai", Type: "openai", DisplayName: name,
+			SupportedEndpoints:        []string{"/audio/speech"},
+			SupportedInputModalities:  []string{"TEXT"},
+			SupportedOutputModalities: []string{"AUDIO"},
+		}
+	}
+	return []*ModelInfo{
+		transcription("whisper-1", "Whisper"),
+		transcription("gpt-4o-transcribe", "GPT-4o Transcribe"),
+		transcription("gpt-4o-mini-transcribe", "GPT-4o mini Transcribe"),
+		speech("tts-1", "TTS 1"),
+		speech("tts-1-hd", "TTS 1 HD"),
+		speech("gpt-4o-mini-tts", "GPT-4o mini TTS"),
+	}
+}
+
+// AudioOnly reports whether the model is served only by the audio endpoints: it lists nothing
+// but /audio/ endpoints and no generation methods.
+func (m *ModelInfo) AudioOnly() bool {
+	if m == nil || len(m.SupportedEndpoints) == 0 || len(m.SupportedGenerationMethods) > 0 {
+		return false
+	}
+	for _, endpoint := range m.SupportedEndpoints {
+		if !strings.HasPrefix(endpoint, "/audio/") {
+			return false
+		}
+	}
+	return true
+}
+
+// audioOnly reports whether every provider of the registration serves the model only on the
+// audio endpoints.
+func (reg *ModelRegistration) audioOnly() bool {
+	if reg == nil || !reg.Info.AudioOnly() {
+		return false
+	}
+	for provider, info := range reg.InfoByProvider {
+		if reg.Providers[provider] > 0 && !info.AudioOnly() {
+			return false
+		}
+	}
+	return true
+}
+
+// IsAudioOnlyModel reports whether modelID may only be requested through the audio endpoints.
+// Such models are left out of model listings.
+func (r *ModelRegistry) IsAudioOnlyModel(modelID string) bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.models[modelID].audioOnly()
+}
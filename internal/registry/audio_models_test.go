@@ -0,0 +1,29 @@
+package registry
+
+import "testing"
+
+func TestAudioOnlyModelsAreHiddenFromListings(t *testing.T) {
+	r := newTestModelRegistry()
+	models := append([]*ModelInfo{{ID: "gpt-4o"}}, GetOpenAIAudioModels()...)
+	r.RegisterClient("client-audio", "codex", models)
+	r.RegisterClient("client-tts", "gemini", GetGeminiAudioModels())
+
+	listed := make(map[string]bool)
+	for _, model := range r.GetAvailableModels("openai") {
+		listed[model["id"].(string)] = true
+	}
+	if !listed["gpt-4o"] || !listed["gemini-2.5-flash-preview-tts"] {
+		t.Fatalf("chat and Gemini TTS models missing from listing: %v", listed)
+	}
+	for _, id := range []string{"whisper-1", "tts-1"} {
+		if listed[id] {
+			t.Errorf("audio-only model %s listed", id)
+		}
+		if !r.IsAudioOnlyModel(id) {
+			t.Errorf("IsAudioOnlyModel(%s) = false", id)
+		}
+	}
+	if r.IsAudioOnlyModel("gemini-2.5-flash-preview-tts") {
+		t.Error("Gemini TTS model served through generateContent reported as audio-only")
+	}
+}
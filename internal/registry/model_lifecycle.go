@@ -0,0 +1,126 @@
+package registry
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Model lifecycle states.
+const (
+	LifecycleActive     = "active"
+	LifecycleDeprecated = "deprecated"
+	LifecycleRetired    = "retired"
+)
+
+// ModelLifecycle describes where a model is in its vendor lifecycle.
+type ModelLifecycle struct {
+	ID           string    `json:"id"`
+	State        string    `json:"state"`
+	DeprecatedAt time.Time `json:"deprecated_at,omitzero"`
+	RetireAt     time.Time `json:"retire_at,omitzero"`
+	Replacement  string    `json:"replacement,omitempty"`
+}
+
+// Lifecycle evaluates the model's lifecycle fields at now. A model is deprecated once its
+// deprecation date has passed or a retirement date is announced, and retired from RetireAt on.
+func (m *ModelInfo) Lifecycle(now time.Time) ModelLifecycle {
+	if m == nil {
+		return ModelLifecycle{State: LifecycleActive}
+	}
+	lifecycle := ModelLifecycle{ID: m.ID, State: LifecycleActive, Replacement: strings.TrimSpace(m.Replacement)}
+	lifecycle.DeprecatedAt, _ = parseLifecycleTime(m.DeprecatedAt)
+	lifecycle.RetireAt, _ = parseLifecycleTime(m.RetireAt)
+	switch {
+	case !lifecycle.RetireAt.IsZero() && !now.Before(lifecycle.RetireAt):
+		lifecycle.State = LifecycleRetired
+	case !lifecycle.RetireAt.IsZero(), !lifecycle.DeprecatedAt.IsZero() && !now.Before(lifecycle.DeprecatedAt):
+		lifecycle.State = LifecycleDeprecated
+	}
+	return lifecycle
+}
+
+// LookupModelLifecycle returns the lifecycle of a model known to the registry or the static
+// catalog. Unknown models are reported as active.
+func LookupModelLifecycle(modelID string, now time.Time) ModelLifecycle {
+	if info := LookupModelInfo(modelID); info != nil {
+		return info.Lifecycle(now)
+	}
+	return ModelLifecycle{ID: modelID, State: LifecycleActive}
+}
+
+// ModelLifecycles lists every deprecated or retired model of the registry and the static
+// catalog, ordered by retirement date. Registered definitions take precedence over the catalog.
+func ModelLifecycles(now time.Time) []ModelLifecycle {
+	seen := make(map[string]struct{})
+	var out []ModelLifecycle
+	add := func(info *ModelInfo) {
+		if info == nil || info.ID == "" {
+			return
+		}
+		if _, ok := seen[info.ID]; ok {
+			return
+		}
+		seen[info.ID] = struct{}{}
+		if lifecycle := info.Lifecycle(now); lifecycle.State != LifecycleActive {
+			out = append(out, lifecycle)
+		}
+	}
+
+	r := GetGlobalRegistry()
+	r.mutex.RLock()
+	for _, registration := range r.models {
+		if registration != nil {
+			add(registration.Info)
+		}
+	}
+	r.mutex.RUnlock()
+
+	data := getModels()
+	for _, models := range [][]*ModelInfo{
+		data.Claude, data.Gemini, data.Vertex, data.GeminiCLI, data.AIStudio, data.CodexFree,
+		data.CodexTeam, data.CodexPlus, data.CodexPro, data.Qwen, data.IFlow, data.Kimi, data.Antigravity,
+	} {
+		for _, info := range models {
+			add(info)
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i].RetireAt, out[j].RetireAt
+		if a.IsZero() != b.IsZero() {
+			return b.IsZero()
+		}
+		if !a.Equal(b) {
+			return a.Before(b)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// parseLifecycleTime accepts a date ("2006-01-02", midnight UTC) or an RFC 3339 timestamp.
+func parseLifecycleTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func validateModelLifecycle(model *ModelInfo) error {
+	if _, err := parseLifecycleTime(model.DeprecatedAt); err != nil {
+		return fmt.Errorf("invalid deprecated_at: %w", err)
+	}
+	if _, err := parseLifecycleTime(model.RetireAt); err != nil {
+		return fmt.Errorf("invalid retire_at: %w", err)
+	}
+	if strings.TrimSpace(model.Replacement) == model.ID {
+		return fmt.Errorf("model is its own replacement")
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package registry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestModelInfoLifecycle(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name  string
+		info  ModelInfo
+		state string
+	}{
+		{"no dates", ModelInfo{ID: "a"}, LifecycleActive},
+		{"deprecation ahead", ModelInfo{ID: "a", DeprecatedAt: "2026-04-01"}, LifecycleActive},
+		{"deprecated", ModelInfo{ID: "a", DeprecatedAt: "2026-02-01"}, LifecycleDeprecated},
+		{"retirement announced", ModelInfo{ID: "a", RetireAt: "2026-06-01"}, LifecycleDeprecated},
+		{"retired", ModelInfo{ID: "a", DeprecatedAt: "2025-12-01", RetireAt: "2026-03-01T00:00:00Z"}, LifecycleRetired},
+	}
+	for _, tc := range cases {
+		if got := tc.info.Lifecycle(now).State; got != tc.state {
+			t.Errorf("%s: state = %s, want %s", tc.name, got, tc.state)
+		}
+	}
+}
+
+func TestRetiredModelsHiddenFromListing(t *testing.T) {
+	r := newTestModelRegistry()
+	r.RegisterClient("lifecycle-client", "openai", []*ModelInfo{
+		{ID: "old-model", OwnedBy: "vendor", RetireAt: "2020-01-01", Replacement: "new-model"},
+		{ID: "sunsetting-model", OwnedBy: "vendor", RetireAt: "2999-01-01"},
+		{ID: "new-model", OwnedBy: "vendor"},
+	})
+
+	ids := map[string]map[string]any{}
+	for _, model := range r.GetAvailableModels("openai") {
+		ids[model["id"].(string)] = model
+	}
+	if _, ok := ids["old-model"]; ok {
+		t.Errorf("retired model listed: %v", ids)
+	}
+	if model, ok := ids["sunsetting-model"]; !ok || model["retire_at"] != "2999-01-01" {
+		t.Errorf("deprecated model missing or without retire_at: %v", model)
+	}
+	if _, ok := ids["new-model"]; !ok {
+		t.Errorf("replacement missing: %v", ids)
+	}
+}
+
+func TestValidateModelLifecycle(t *testing.T) {
+	if err := validateModelSection("claude", []*ModelInfo{{ID: "a", RetireAt: "soon"}}); err == nil {
+		t.Error("expected invalid retire_at to be rejected")
+	}
+	if err := validateModelSection("claude", []*ModelInfo{{ID: "a", Replacement: "a"}}); err == nil {
+		t.Error("expected self replacement to be rejected")
+	}
+	if err := validateModelSection("claude", []*ModelInfo{{ID: "a", DeprecatedAt: "2026-01-01", RetireAt: "2026-06-01T00:00:00Z", Replacement: "b"}}); err != nil {
+		t.Errorf("valid lifecycle rejected: %v", err)
+	}
+}
@@ -58,6 +58,13 @@ type ModelInfo struct {
 	// This is optional and currently used for Gemini thinking budget normalization.
 	Thinking *ThinkingSupport `json:"thinking,omitempty"`
 
+	// DeprecatedAt is when the vendor deprecated the model ("2006-01-02" or RFC 3339).
+	DeprecatedAt string `json:"deprecated_at,omitempty"`
+	// RetireAt is when the vendor stops serving the model ("2006-01-02" or RFC 3339).
+	RetireAt string `json:"retire_at,omitempty"`
+	// Replacement is the model ID that retired requests are redirected to.
+	Replacement string `json:"replacement,omitempty"`
+
 	// UserDefined indicates this model was defined through config file's models[]
 	// array (e.g., openai-compatibility.*.models[], *-api-key.models[]).
 	// UserDefined models have thinking configuration passed through without validation.
@@ -824,6 +831,20 @@ func (r *ModelRegistry) buildAvailableModelsLocked(handlerType string, now time.
 			effectiveClients = 0
 		}
 
+		// Audio-only models are reachable through the audio endpoints alone.
+		if registration.audioOnly() {
+			continue
+		}
+
+		// Retired models are hidden; the listing expires when the next one retires.
+		lifecycle := registration.Info.Lifecycle(now)
+		if lifecycle.State == LifecycleRetired {
+			continue
+		}
+		if !lifecycle.RetireAt.IsZero() && (expiresAt.IsZero() || lifecycle.RetireAt.Before(expiresAt)) {
+			expiresAt = lifecycle.RetireAt
+		}
+
 		if effectiveClients > 0 || (availableClients > 0 && (expiredClients > 0 || cooldownSuspended > 0) && otherSuspended == 0) {
 			model := r.convertModelToMap(registration.Info, handlerType)
 			if model != nil {
@@ -1147,6 +1168,15 @@ func (r *ModelRegistry) convertModelToMap(model *ModelInfo, handlerType string)
 		if len(model.SupportedEndpoints) > 0 {
 			result["supported_endpoints"] = model.SupportedEndpoints
 		}
+		if model.DeprecatedAt != "" {
+			result["deprecated_at"] = model.DeprecatedAt
+		}
+		if model.RetireAt != "" {
+			result["retire_at"] = model.RetireAt
+		}
+		if model.Replacement != "" {
+			result["replacement"] = model.Replacement
+		}
 		return result
 
 	case "claude", "kiro", "antigravity":
@@ -367,6 +367,9 @@ func validateModelSection(section string, models []*ModelInfo) error {
 			return fmt.Errorf("%s contains duplicate model id %q", section, modelID)
 		}
 		seen[modelID] = struct{}{}
+		if err := validateModelLifecycle(model); err != nil {
+			return fmt.Errorf("%s model %q: %w", section, modelID, err)
+		}
 	}
 	return nil
 }
@@ -1743,7 +1743,8 @@ func (e *AntigravityExecutor) buildRequest(ctx context.Context, auth *cliproxyau
 	if auth != nil {
 		authID = auth.ID
 	}
-	payload = geminiToAntigravity(modelName, payload, projectID, authID)
+	cacheSession := strings.Contains(modelName, "claude") && promptCachingEnabled(e.cfg, modelName)
+	payload = geminiToAntigravity(modelName, payload, projectID, authID, cacheSession)
 	payload, _ = sjson.SetBytes(payload, "model", modelName)
 
 	useAntigravitySchema := strings.Contains(modelName, "claude") || strings.Contains(modelName, "gemini-3-pro") || strings.Contains(modelName, "gemini-3.1-pro")
@@ -2030,7 +2031,9 @@ func resolveCustomAntigravityBaseURL(auth *cliproxyauth.Auth) string {
 	return ""
 }
 
-func geminiToAntigravity(modelName string, payload []byte, projectID string, authID string) []byte {
+// geminiToAntigravity wraps a Gemini request for the Antigravity API. cacheSession keeps the
+// session identifier constant across the turns of a conversation, see generateStableSessionID.
+func geminiToAntigravity(modelName string, payload []byte, projectID string, authID string, cacheSession bool) []byte {
 	template := payload
 	template, _ = sjson.SetBytes(template, "model", modelName)
 	template, _ = sjson.SetBytes(template, "userAgent", "antigravity")
@@ -2057,7 +2060,7 @@ func geminiToAntigravity(modelName string, payload []byte, projectID string, aut
 		template, _ = sjson.SetBytes(template, "requestId", generateImageGenRequestID())
 	} else {
 		template, _ = sjson.SetBytes(template, "requestId", generateRequestID())
-		template, _ = sjson.SetBytes(template, "request.sessionId", generateStableSessionID(payload, authID, modelName, projectID))
+		template, _ = sjson.SetBytes(template, "request.sessionId", generateStableSessionID(payload, authID, modelName, projectID, cacheSession))
 	}
 
 	template, _ = sjson.DeleteBytes(template, "request.safetySettings")
@@ -2083,7 +2086,11 @@ func generateSessionID() string {
 	return "-" + strconv.FormatInt(n, 10)
 }
 
-func generateStableSessionID(payload []byte, authID, modelName, projectID string) string {
+// generateStableSessionID derives the session identifier of an Antigravity request from the auth
+// and the first user message. The trailing seed is random unless cacheSession is set: Antigravity
+// has no cache_control breakpoints for Claude models and only reuses its prompt cache within a
+// session, so prompt caching needs every turn of a conversation to carry the same identifier.
+func generateStableSessionID(payload []byte, authID, modelName, projectID string, cacheSession bool) string {
 	firstUserText := ""
 	contents := gjson.GetBytes(payload, "request.contents")
 	if contents.IsArray() {
@@ -2109,12 +2116,16 @@ func generateStableSessionID(payload []byte, authID, modelName, projectID string
 		uuidBytes[0:4], uuidBytes[4:6], uuidBytes[6:8], uuidBytes[8:10], uuidBytes[10:16])
 
 	// Generate random seed hex to match real traffic pattern.
-	randSourceMutex.Lock()
 	seedBytes := make([]byte, 8)
-	for i := range seedBytes {
-		seedBytes[i] = byte(randSource.Intn(256))
+	if cacheSession {
+		copy(seedBytes, h[16:24])
+	} else {
+		randSourceMutex.Lock()
+		for i := range seedBytes {
+			seedBytes[i] = byte(randSource.Intn(256))
+		}
+		randSourceMutex.Unlock()
 	}
-	randSourceMutex.Unlock()
 	seedHex := hex.EncodeToString(seedBytes)
 
 	return fmt.Sprintf("-%s:%s:%s:seed-%s", stableUUID, modelName, projectID, seedHex)
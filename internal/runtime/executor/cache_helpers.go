@@ -1,78 +1,48 @@
 package executor
 
 import (
-	"sync"
+	"encoding/json"
 	"time"
-)
-
-type codexCache struct {
-	ID     string
-	Expire time.Time
-}
 
-// codexCacheMap stores prompt cache IDs keyed by model+user_id.
-// Protected by codexCacheMu. Entries expire after 1 hour.
-var (
-	codexCacheMap = make(map[string]codexCache)
-	codexCacheMu  sync.RWMutex
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
 )
 
-// codexCacheCleanupInterval controls how often expired entries are purged.
-const codexCacheCleanupInterval = 15 * time.Minute
-
-// codexCacheCleanupOnce ensures the background cleanup goroutine starts only once.
-var codexCacheCleanupOnce sync.Once
-
-// startCodexCacheCleanup launches a background goroutine that periodically
-// removes expired entries from codexCacheMap to prevent memory leaks.
-func startCodexCacheCleanup() {
-	go func() {
-		ticker := time.NewTicker(codexCacheCleanupInterval)
-		defer ticker.Stop()
-
-		for range ticker.C {
-			purgeExpiredCodexCache()
-		}
-	}()
+type codexCache struct {
+	ID     string    `json:"id"`
+	Expire time.Time `json:"expire"`
 }
 
-// purgeExpiredCodexCache removes entries that have expired.
-func purgeExpiredCodexCache() {
-	now := time.Now()
-
-	codexCacheMu.Lock()
-	defer codexCacheMu.Unlock()
-
-	for key, cache := range codexCacheMap {
-		if cache.Expire.Before(now) {
-			delete(codexCacheMap, key)
-		}
-	}
-}
+// codexCacheNamespace is the cache store namespace of Codex prompt cache IDs keyed by
+// model+user_id. Entries expire after 1 hour.
+const codexCacheNamespace = "codex-prompt-cache"
 
 // getCodexCache retrieves a cached entry, returning ok=false if not found or expired.
 func getCodexCache(key string) (codexCache, bool) {
-	codexCacheCleanupOnce.Do(startCodexCacheCleanup)
-	codexCacheMu.RLock()
-	cache, ok := codexCacheMap[key]
-	codexCacheMu.RUnlock()
-	if !ok || cache.Expire.Before(time.Now()) {
+	raw, ok := cache.Load(codexCacheNamespace, key)
+	if !ok {
 		return codexCache{}, false
 	}
-	return cache, true
+	var entry codexCache
+	if err := json.Unmarshal(raw, &entry); err != nil || entry.ID == "" || entry.Expire.Before(time.Now()) {
+		return codexCache{}, false
+	}
+	return entry, true
 }
 
-// setCodexCache stores a cache entry.
-func setCodexCache(key string, cache codexCache) {
-	codexCacheCleanupOnce.Do(startCodexCacheCleanup)
-	codexCacheMu.Lock()
-	codexCacheMap[key] = cache
-	codexCacheMu.Unlock()
+// setCodexCache stores a cache entry until it expires.
+func setCodexCache(key string, entry codexCache) {
+	ttl := time.Until(entry.Expire)
+	if ttl <= 0 {
+		return
+	}
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	cache.Save(codexCacheNamespace, key, raw, ttl)
 }
 
 // deleteCodexCache deletes a cache entry.
 func deleteCodexCache(key string) {
-	codexCacheMu.Lock()
-	delete(codexCacheMap, key)
-	codexCacheMu.Unlock()
+	cache.Remove(codexCacheNamespace, key)
 }
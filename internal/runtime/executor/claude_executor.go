@@ -229,11 +229,11 @@ func (e *ClaudeExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, r
 
 	body = disableThinkingIfToolChoiceForced(body)
 
-	if countCacheControls(body) == 0 {
-		body = ensureCacheControl(body)
-	}
+	body = injectCachedContentPrefixCacheControl(body, req.Payload, opts, from, to, baseModel, stream)
+
+	body = applyPromptCaching(e.cfg, baseModel, body)
 
-	body = enforceCacheControlLimit(body, 4)
+	body = enforceCacheControlLimit(body, maxCacheControlBlocks)
 
 	// Normalize TTL values to prevent ordering violations under prompt-caching-scope-2026-01-05.
 	// A 1h-TTL block must not appear after a 5m-TTL block in evaluation order (tools→system→messages).
@@ -399,11 +399,11 @@ func (e *ClaudeExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.A
 
 	body = disableThinkingIfToolChoiceForced(body)
 
-	if countCacheControls(body) == 0 {
-		body = ensureCacheControl(body)
-	}
+	body = injectCachedContentPrefixCacheControl(body, req.Payload, opts, from, to, baseModel, true)
+
+	body = applyPromptCaching(e.cfg, baseModel, body)
 
-	body = enforceCacheControlLimit(body, 4)
+	body = enforceCacheControlLimit(body, maxCacheControlBlocks)
 
 	// Normalize TTL values to prevent ordering violations under prompt-caching-scope-2026-01-05.
 	body = normalizeCacheControlTTL(body)
@@ -574,7 +574,7 @@ func (e *ClaudeExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Aut
 	}
 
 	// Keep count_tokens requests compatible with Anthropic cache-control constraints too.
-	body = enforceCacheControlLimit(body, 4)
+	body = enforceCacheControlLimit(body, maxCacheControlBlocks)
 	body = normalizeCacheControlTTL(body)
 
 	// Extract betas from body and convert to header (for count_tokens too)
@@ -677,7 +677,7 @@ func (e *ClaudeExecutor) Refresh(ctx context.Context, auth *cliproxyauth.Auth) (
 	if refreshToken == "" {
 		return auth, nil
 	}
-	svc := claudeauth.NewClaudeAuth(e.cfg)
+	svc := claudeauth.NewClaudeAuthWithUpstream(resolveProxyURL(e.cfg, auth), resolveUpstreamTLS(e.cfg, auth))
 	td, err := svc.RefreshTokens(ctx, refreshToken)
 	if err != nil {
 		return nil, err
@@ -1574,24 +1574,84 @@ func applyCloaking(ctx context.Context, cfg *config.Config, auth *cliproxyauth.A
 // 3. The SECOND-TO-LAST user turn (caches conversation history for multi-turn)
 //
 // Up to 4 cache breakpoints are allowed per request. Tools, System, and Messages are INDEPENDENT breakpoints.
+// Sections that already carry a breakpoint are left alone, and breakpoints are only added while
+// the request has free slots, so client- or cloaking-provided breakpoints are never displaced.
 // This enables up to 90% cost reduction on cached tokens (cache read = 0.1x base price).
 // See: https://docs.anthropic.com/en/docs/build-with-claude/prompt-caching
 func ensureCacheControl(payload []byte) []byte {
-	// 1. Inject cache_control into the LAST tool (caches all tool definitions)
-	// Tools are cached first in the hierarchy, so this is the most important breakpoint.
-	payload = injectToolsCacheControl(payload)
-
-	// 2. Inject cache_control into the LAST system prompt element
-	// System is the second level in the cache hierarchy.
-	payload = injectSystemCacheControl(payload)
-
-	// 3. Inject cache_control into messages for multi-turn conversation caching
-	// This caches the conversation history up to the second-to-last user turn.
-	payload = injectMessagesCacheControl(payload)
-
+	free := maxCacheControlBlocks - countCacheControls(payload)
+	steps := []func([]byte) []byte{
+		// 1. Inject cache_control into the LAST tool (caches all tool definitions)
+		// Tools are cached first in the hierarchy, so this is the most important breakpoint.
+		injectToolsCacheControl,
+		// 2. Inject cache_control into the LAST system prompt element
+		// System is the second level in the cache hierarchy.
+		injectSystemCacheControl,
+		// 3. Inject cache_control into messages for multi-turn conversation caching
+		// This caches the conversation history up to the second-to-last user turn.
+		injectMessagesCacheControl,
+	}
+	for _, step := range steps {
+		if free <= 0 {
+			break
+		}
+		before := countCacheControls(payload)
+		payload = step(payload)
+		if countCacheControls(payload) > before {
+			free--
+		}
+	}
 	return payload
 }
 
+// injectCachedContentPrefixCacheControl places a breakpoint at the end of the prefix that was
+// expanded from an emulated Gemini cachedContent, so the cached part maps onto Claude prompt
+// caching. The boundary is found by translating the prefix alone, which keeps it aligned with
+// however the translator merges or drops entries. Payloads that already carry breakpoints are
+// left alone.
+func injectCachedContentPrefixCacheControl(body, payload []byte, opts cliproxyexecutor.Options, from, to sdktranslator.Format, model string, stream bool) []byte {
+	prefix, _ := opts.Metadata[cliproxyexecutor.CachedContentPrefixMetadataKey].(int)
+	if prefix <= 0 || countCacheControls(body) > 0 {
+		return body
+	}
+	contents := gjson.GetBytes(payload, "contents").Array()
+	if prefix > len(contents) {
+		return body
+	}
+	raw := make([]string, 0, prefix)
+	for _, content := range contents[:prefix] {
+		raw = append(raw, content.Raw)
+	}
+	prefixPayload, err := sjson.SetRawBytes(payload, "contents", []byte("["+strings.Join(raw, ",")+"]"))
+	if err != nil {
+		return body
+	}
+	translated := sdktranslator.TranslateRequest(from, to, model, prefixPayload, stream)
+	boundary := int(gjson.GetBytes(translated, "messages.#").Int()) - 1
+	if boundary < 0 || boundary >= int(gjson.GetBytes(body, "messages.#").Int()) {
+		return body
+	}
+	content := gjson.GetBytes(body, fmt.Sprintf("messages.%d.content", boundary))
+	if content.Type == gjson.String {
+		blocks := []map[string]any{{"type": "text", "text": content.String()}}
+		body, _ = sjson.SetBytes(body, fmt.Sprintf("messages.%d.content", boundary), blocks)
+		content = gjson.GetBytes(body, fmt.Sprintf("messages.%d.content", boundary))
+	}
+	last := int(content.Get("#").Int()) - 1
+	if last < 0 {
+		return body
+	}
+	if blockType := content.Get(fmt.Sprintf("%d.type", last)).String(); blockType == "thinking" || blockType == "redacted_thinking" {
+		return body
+	}
+	out, err := sjson.SetBytes(body, fmt.Sprintf("messages.%d.content.%d.cache_control", boundary, last), map[string]string{"type": "ephemeral"})
+	if err != nil {
+		log.Warnf("failed to inject cached content breakpoint: %v", err)
+		return body
+	}
+	return out
+}
+
 func countCacheControls(payload []byte) int {
 	count := 0
 
@@ -1912,3 +1912,38 @@ func TestCheckSystemInstructionsWithMode_StringWithSpecialChars(t *testing.T) {
 		t.Fatalf("blocks[2] text mangled, got %q", blocks[2].Get("text").String())
 	}
 }
+
+func TestInjectCachedContentPrefixCacheControl_MarksPrefixBoundary(t *testing.T) {
+	payload := []byte(`{"systemInstruction":{"parts":[{"text":"be brief"}]},"contents":[
+		{"role":"user","parts":[{"text":"long document"}]},
+		{"role":"model","parts":[{"text":"noted"}]},
+		{"role":"user","parts":[{"text":"question"}]}
+	]}`)
+	from := sdktranslator.FromString("gemini")
+	to := sdktranslator.FromString("claude")
+	body := sdktranslator.TranslateRequest(from, to, "claude-sonnet-4-5", payload, false)
+	opts := cliproxyexecutor.Options{Metadata: map[string]any{cliproxyexecutor.CachedContentPrefixMetadataKey: 2}}
+
+	out := injectCachedContentPrefixCacheControl(body, payload, opts, from, to, "claude-sonnet-4-5", false)
+
+	// The system instruction becomes the first message, so the prefix ends at message 2.
+	if got := gjson.GetBytes(out, "messages.2.content.0.cache_control.type").String(); got != "ephemeral" {
+		t.Fatalf("prefix boundary breakpoint missing: %s", out)
+	}
+	if countCacheControls(out) != 1 {
+		t.Fatalf("expected exactly one breakpoint, got %d", countCacheControls(out))
+	}
+}
+
+func TestInjectCachedContentPrefixCacheControl_IgnoresRequestsWithoutPrefix(t *testing.T) {
+	payload := []byte(`{"contents":[{"role":"user","parts":[{"text":"hi"}]}]}`)
+	from := sdktranslator.FromString("gemini")
+	to := sdktranslator.FromString("claude")
+	body := sdktranslator.TranslateRequest(from, to, "claude-sonnet-4-5", payload, false)
+
+	out := injectCachedContentPrefixCacheControl(body, payload, cliproxyexecutor.Options{}, from, to, "claude-sonnet-4-5", false)
+
+	if !bytes.Equal(out, body) {
+		t.Fatalf("payload changed without a cached prefix: %s", out)
+	}
+}
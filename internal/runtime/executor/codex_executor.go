@@ -81,6 +81,9 @@ func (e *CodexExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, re
 	if opts.Alt == "responses/compact" {
 		return e.executeCompact(ctx, auth, req, opts)
 	}
+	if isAudioAlt(opts.Alt) {
+		return e.executeAudio(ctx, auth, req, opts)
+	}
 	baseModel := thinking.ParseSuffix(req.Model).ModelName
 
 	apiKey, baseURL := codexCreds(auth)
@@ -191,6 +194,21 @@ func (e *CodexExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, re
 	return resp, err
 }
 
+// executeAudio serves the audio endpoints for Codex API keys, which are OpenAI platform keys.
+// ChatGPT OAuth sessions have no access to them.
+func (e *CodexExecutor) executeAudio(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	if auth == nil || auth.Attributes == nil || strings.TrimSpace(auth.Attributes["api_key"]) == "" {
+		return cliproxyexecutor.Response{}, statusErr{code: http.StatusNotImplemented, msg: "/" + opts.Alt + " requires a codex API key"}
+	}
+	apiKey, baseURL := codexCreds(auth)
+	if baseURL == "" {
+		baseURL = "https://api.openai.com/v1"
+	}
+	return executeOpenAIAudio(ctx, e.cfg, e.Identifier(), auth, baseURL, apiKey, req, opts, func(r *http.Request) {
+		util.ApplyCustomHeadersFromAttrs(r, auth.Attributes)
+	})
+}
+
 func (e *CodexExecutor) executeCompact(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
 	baseModel := thinking.ParseSuffix(req.Model).ModelName
 
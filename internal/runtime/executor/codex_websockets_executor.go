@@ -699,6 +699,12 @@ func newProxyAwareWebsocketDialer(cfg *config.Config, auth *cliproxyauth.Auth) *
 		}).DialContext,
 	}
 
+	if tlsConfig, errTLS := proxyutil.BuildTLSConfig(resolveUpstreamTLS(cfg, auth)); errTLS != nil {
+		log.Errorf("codex websockets executor: %v", errTLS)
+	} else if tlsConfig != nil {
+		dialer.TLSClientConfig = tlsConfig
+	}
+
 	proxyURL := ""
 	if auth != nil {
 		proxyURL = strings.TrimSpace(auth.ProxyURL)
@@ -547,3 +547,13 @@ func fixGeminiImageAspectRatio(modelName string, rawJSON []byte) []byte {
 	}
 	return rawJSON
 }
+
+// CachedContentsURL returns the Gemini cachedContents collection URL for auth.
+func (e *GeminiExecutor) CachedContentsURL(auth *cliproxyauth.Auth) (string, error) {
+	return resolveGeminiBaseURL(auth) + "/v1beta/cachedContents", nil
+}
+
+// CachedContentModel returns the models/ resource name for model.
+func (e *GeminiExecutor) CachedContentModel(_ *cliproxyauth.Auth, model string) (string, error) {
+	return "models/" + strings.TrimPrefix(model, "models/"), nil
+}
@@ -0,0 +1,119 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+const (
+	geminiLivePathFormat  = "/ws/google.ai.generativelanguage.%s.GenerativeService.BidiGenerateContent"
+	vertexLivePath        = "/ws/google.cloud.aiplatform.v1.LlmBidiService/BidiGenerateContent"
+	geminiLiveHandshakeTO = 30 * time.Second
+)
+
+// DialLive opens the Gemini Live BidiGenerateContent websocket for auth.
+func (e *GeminiExecutor) DialLive(ctx context.Context, auth *cliproxyauth.Auth, model string, opts cliproxyexecutor.Options) (*cliproxyauth.LiveUpstream, error) {
+	baseModel := thinking.ParseSuffix(strings.TrimPrefix(model, "models/")).ModelName
+	conn, err := dialGeminiLive(ctx, e.cfg, auth, resolveGeminiBaseURL(auth)+geminiLivePath(opts), e.PrepareRequest)
+	if err != nil {
+		return nil, err
+	}
+	return newLiveUpstream(ctx, conn, e.Identifier(), baseModel, "models/"+baseModel, auth), nil
+}
+
+// DialLive opens the Vertex AI Live websocket for auth. Only service account credentials are
+// supported because the Live service does not accept API keys.
+func (e *GeminiVertexExecutor) DialLive(ctx context.Context, auth *cliproxyauth.Auth, model string, _ cliproxyexecutor.Options) (*cliproxyauth.LiveUpstream, error) {
+	if apiKey, _ := vertexAPICreds(auth); strings.TrimSpace(apiKey) != "" {
+		return nil, statusErr{code: http.StatusNotImplemented, msg: "live sessions require vertex service account credentials"}
+	}
+	projectID, location, _, err := vertexCreds(auth)
+	if err != nil {
+		return nil, err
+	}
+	baseModel := thinking.ParseSuffix(strings.TrimPrefix(model, "models/")).ModelName
+	conn, err := dialGeminiLive(ctx, e.cfg, auth, vertexBaseURL(location)+vertexLivePath, e.PrepareRequest)
+	if err != nil {
+		return nil, err
+	}
+	resource := fmt.Sprintf("projects/%s/locations/%s/publishers/google/models/%s", projectID, location, baseModel)
+	return newLiveUpstream(ctx, conn, e.Identifier(), baseModel, resource, auth), nil
+}
+
+// geminiLivePath returns the Live websocket path for the API version the client connected to,
+// defaulting to v1beta.
+func geminiLivePath(opts cliproxyexecutor.Options) string {
+	version := "v1beta"
+	if requested, _ := opts.Metadata[cliproxyexecutor.LiveAPIVersionMetadataKey].(string); requested == "v1alpha" {
+		version = requested
+	}
+	return fmt.Sprintf(geminiLivePathFormat, version)
+}
+
+// newLiveUpstream wraps conn with a usage reporter started at dial time so the published
+// latency covers the whole session.
+func newLiveUpstream(ctx context.Context, conn *websocket.Conn, provider, model, resource string, auth *cliproxyauth.Auth) *cliproxyauth.LiveUpstream {
+	reporter := newUsageReporter(ctx, provider, model, auth)
+	return &cliproxyauth.LiveUpstream{
+		Conn:  conn,
+		Model: resource,
+		Report: func(ctx context.Context, detail usage.Detail, failed bool) {
+			reporter.publishWithOutcome(ctx, detail, failed)
+			reporter.ensurePublished(ctx)
+		},
+	}
+}
+
+// dialGeminiLive dials target after letting prepare attach credentials. Rejected handshakes
+// surface as status errors so the auth manager can apply cooldowns.
+func dialGeminiLive(ctx context.Context, cfg *config.Config, auth *cliproxyauth.Auth, target string, prepare func(*http.Request, *cliproxyauth.Auth) error) (*websocket.Conn, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err = prepare(httpReq, auth); err != nil {
+		return nil, err
+	}
+	wsURL := liveWebsocketURL(target)
+	dialer := newProxyAwareWebsocketDialer(cfg, auth)
+	dialer.HandshakeTimeout = geminiLiveHandshakeTO
+	dialer.EnableCompression = false
+	conn, resp, err := dialer.DialContext(ctx, wsURL, httpReq.Header)
+	if err != nil {
+		body := websocketHandshakeBody(resp)
+		if resp != nil && resp.StatusCode > 0 {
+			msg := strings.TrimSpace(string(body))
+			if msg == "" {
+				msg = err.Error()
+			}
+			return nil, statusErr{code: resp.StatusCode, msg: msg}
+		}
+		return nil, fmt.Errorf("gemini live: dial %s failed: %w", wsURL, err)
+	}
+	closeHTTPResponseBody(resp, "gemini live: close handshake response body error")
+	return conn, nil
+}
+
+func liveWebsocketURL(target string) string {
+	switch {
+	case strings.HasPrefix(target, "https://"):
+		return "wss://" + strings.TrimPrefix(target, "https://")
+	case strings.HasPrefix(target, "http://"):
+		return "ws://" + strings.TrimPrefix(target, "http://")
+	default:
+		return target
+	}
+}
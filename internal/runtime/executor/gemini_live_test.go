@@ -0,0 +1,71 @@
+package executor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+func TestGeminiExecutorDialLive(t *testing.T) {
+	var gotPath, gotKey string
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotKey = r.Header.Get("x-goog-api-key")
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		_ = conn.Close()
+	}))
+	defer srv.Close()
+
+	exec := NewGeminiExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{ID: "gemini-live", Provider: "gemini", Attributes: map[string]string{"api_key": "secret", "base_url": srv.URL}}
+	upstream, err := exec.DialLive(context.Background(), auth, "gemini-live-2.5-flash(8192)", cliproxyexecutor.Options{})
+	if err != nil {
+		t.Fatalf("DialLive: %v", err)
+	}
+	_ = upstream.Conn.Close()
+	if want := "/ws/google.ai.generativelanguage.v1beta.GenerativeService.BidiGenerateContent"; gotPath != want {
+		t.Fatalf("path = %q, want %q", gotPath, want)
+	}
+	if gotKey != "secret" {
+		t.Fatalf("x-goog-api-key = %q", gotKey)
+	}
+	if upstream.Model != "models/gemini-live-2.5-flash" {
+		t.Fatalf("model = %q", upstream.Model)
+	}
+}
+
+func TestGeminiExecutorDialLiveRejectedHandshake(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":{"status":"RESOURCE_EXHAUSTED"}}`, http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	exec := NewGeminiExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{ID: "gemini-live", Provider: "gemini", Attributes: map[string]string{"api_key": "secret", "base_url": srv.URL}}
+	_, err := exec.DialLive(context.Background(), auth, "gemini-live-2.5-flash", cliproxyexecutor.Options{})
+	se, ok := err.(statusErr)
+	if !ok || se.StatusCode() != http.StatusTooManyRequests {
+		t.Fatalf("err = %v, want 429 status error", err)
+	}
+}
+
+func TestGeminiLivePathFollowsRequestedVersion(t *testing.T) {
+	opts := cliproxyexecutor.Options{Metadata: map[string]any{cliproxyexecutor.LiveAPIVersionMetadataKey: "v1alpha"}}
+	if got := geminiLivePath(opts); got != "/ws/google.ai.generativelanguage.v1alpha.GenerativeService.BidiGenerateContent" {
+		t.Fatalf("v1alpha path = %q", got)
+	}
+	opts.Metadata[cliproxyexecutor.LiveAPIVersionMetadataKey] = "v9"
+	if got := geminiLivePath(opts); got != "/ws/google.ai.generativelanguage.v1beta.GenerativeService.BidiGenerateContent" {
+		t.Fatalf("unknown version path = %q", got)
+	}
+}
@@ -1070,3 +1070,26 @@ func (e *GeminiVertexExecutor) resolveVertexConfig(auth *cliproxyauth.Auth) *con
 	}
 	return nil
 }
+
+// CachedContentsURL returns the Vertex cachedContents collection URL for service-account
+// credentials. Express-mode API keys have no project scope and cannot hold caches.
+func (e *GeminiVertexExecutor) CachedContentsURL(auth *cliproxyauth.Auth) (string, error) {
+	if apiKey, _ := vertexAPICreds(auth); strings.TrimSpace(apiKey) != "" {
+		return "", statusErr{code: http.StatusNotImplemented, msg: "cachedContents requires vertex service account credentials"}
+	}
+	projectID, location, _, err := vertexCreds(auth)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/%s/projects/%s/locations/%s/cachedContents", vertexBaseURL(location), vertexAPIVersion, projectID, location), nil
+}
+
+// CachedContentModel returns the fully qualified publisher model name Vertex expects.
+func (e *GeminiVertexExecutor) CachedContentModel(auth *cliproxyauth.Auth, model string) (string, error) {
+	projectID, location, _, err := vertexCreds(auth)
+	if err != nil {
+		return "", err
+	}
+	model = strings.TrimPrefix(model, "models/")
+	return fmt.Sprintf("projects/%s/locations/%s/publishers/google/models/%s", projectID, location, model), nil
+}
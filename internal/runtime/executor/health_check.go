@@ -0,0 +1,123 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"golang.org/x/oauth2"
+)
+
+// maxHealthCheckErrorBody caps how much of a failed probe response is kept in the error message.
+const maxHealthCheckErrorBody = 512
+
+// healthCheckRequester is satisfied by executors exposing HttpRequest.
+type healthCheckRequester interface {
+	HttpRequest(ctx context.Context, auth *cliproxyauth.Auth, req *http.Request) (*http.Response, error)
+}
+
+// checkHealthGET issues a cheap authenticated GET and maps non-2xx responses to statusErr.
+func checkHealthGET(ctx context.Context, requester healthCheckRequester, auth *cliproxyauth.Auth, target string, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := requester.HttpRequest(ctx, auth, req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxHealthCheckErrorBody))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg := strings.TrimSpace(string(body))
+		if msg == "" {
+			msg = http.StatusText(resp.StatusCode)
+		}
+		return statusErr{code: resp.StatusCode, msg: msg}
+	}
+	return nil
+}
+
+// CheckHealth validates Claude credentials through a one-entry models list.
+func (e *ClaudeExecutor) CheckHealth(ctx context.Context, auth *cliproxyauth.Auth) error {
+	apiKey, baseURL := claudeCreds(auth)
+	if strings.TrimSpace(apiKey) == "" {
+		return statusErr{code: http.StatusUnauthorized, msg: "missing access token"}
+	}
+	if baseURL == "" {
+		baseURL = "https://api.anthropic.com"
+	}
+	headers := map[string]string{"Anthropic-Version": "2023-06-01"}
+	if isClaudeOAuthToken(apiKey) {
+		headers["Anthropic-Beta"] = "oauth-2025-04-20"
+	}
+	return checkHealthGET(ctx, e, auth, strings.TrimRight(baseURL, "/")+"/v1/models?limit=1", headers)
+}
+
+// CheckHealth validates Gemini API keys and tokens through a one-entry models list.
+func (e *GeminiExecutor) CheckHealth(ctx context.Context, auth *cliproxyauth.Auth) error {
+	apiKey, bearer := geminiCreds(auth)
+	if apiKey == "" && bearer == "" {
+		return statusErr{code: http.StatusUnauthorized, msg: "missing credentials"}
+	}
+	return checkHealthGET(ctx, e, auth, resolveGeminiBaseURL(auth)+"/v1beta/models?pageSize=1", nil)
+}
+
+// CheckHealth validates Gemini CLI OAuth credentials. Obtaining the token refreshes it when
+// expired; the token is then introspected so revoked grants surface as 401.
+func (e *GeminiCLIExecutor) CheckHealth(ctx context.Context, auth *cliproxyauth.Auth) error {
+	if geminiOAuthMetadata(auth) == nil {
+		return statusErr{code: http.StatusUnauthorized, msg: "missing credentials"}
+	}
+	tokenSource, _, err := prepareGeminiCLITokenSource(ctx, e.cfg, auth)
+	if err != nil {
+		return tokenRefreshHealthError(err)
+	}
+	tok, err := tokenSource.Token()
+	if err != nil {
+		return tokenRefreshHealthError(err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://oauth2.googleapis.com/tokeninfo?access_token="+url.QueryEscape(tok.AccessToken), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0).Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxHealthCheckErrorBody))
+	if resp.StatusCode == http.StatusBadRequest {
+		// tokeninfo answers 400 invalid_token for revoked or unknown tokens.
+		return statusErr{code: http.StatusUnauthorized, msg: strings.TrimSpace(string(body))}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return statusErr{code: resp.StatusCode, msg: strings.TrimSpace(string(body))}
+	}
+	return nil
+}
+
+// tokenRefreshHealthError maps a failed OAuth refresh to a probe error. Only a rejected grant
+// means the credential is bad; network failures and server errors stay transient and carry no
+// status code.
+func tokenRefreshHealthError(err error) error {
+	var retrieveErr *oauth2.RetrieveError
+	if errors.As(err, &retrieveErr) {
+		status := 0
+		if retrieveErr.Response != nil {
+			status = retrieveErr.Response.StatusCode
+		}
+		if retrieveErr.ErrorCode == "invalid_grant" || status == http.StatusBadRequest || status == http.StatusUnauthorized {
+			return statusErr{code: http.StatusUnauthorized, msg: fmt.Sprintf("token refresh failed: %v", err)}
+		}
+	}
+	return fmt.Errorf("token refresh failed: %w", err)
+}
@@ -0,0 +1,67 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"golang.org/x/oauth2"
+)
+
+func TestGeminiCLICheckHealthNetworkErrorIsTransient(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	closedAddr := listener.Addr().String()
+	_ = listener.Close()
+
+	exec := NewGeminiCLIExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{
+		ID:       "gemini-cli-probe",
+		Provider: "gemini-cli",
+		ProxyURL: "http://" + closedAddr,
+		Metadata: map[string]any{
+			"access_token":  "expired",
+			"refresh_token": "refresh",
+			"expiry":        time.Now().Add(-time.Hour).Format(time.RFC3339),
+		},
+	}
+	err = exec.CheckHealth(context.Background(), auth)
+	if err == nil {
+		t.Fatal("expected refresh through an unreachable proxy to fail")
+
+	}
+	var status interface{ StatusCode() int }
+	if errors.As(err, &status) && status.StatusCode() != 0 {
+		t.Fatalf("network failure reported status %d, want transient error", status.StatusCode())
+	}
+}
+
+func TestTokenRefreshHealthErrorRejectedGrant(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"invalid grant", &oauth2.RetrieveError{ErrorCode: "invalid_grant"}, http.StatusUnauthorized},
+		{"bad request", &oauth2.RetrieveError{Response: &http.Response{StatusCode: http.StatusBadRequest}}, http.StatusUnauthorized},
+		{"server error", &oauth2.RetrieveError{Response: &http.Response{StatusCode: http.StatusServiceUnavailable}}, 0},
+		{"timeout", context.DeadlineExceeded, 0},
+	}
+	for _, tc := range cases {
+		got := 0
+		var status interface{ StatusCode() int }
+		if errors.As(tokenRefreshHealthError(tc.err), &status) {
+			got = status.StatusCode()
+		}
+		if got != tc.want {
+			t.Errorf("%s: status = %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
@@ -301,6 +301,11 @@ func newKiroHTTPClientWithPooling(ctx context.Context, cfg *config.Config, auth
 		return newProxyAwareHTTPClient(ctx, cfg, auth, timeout)
 	}
 
+	// Custom upstream TLS settings also need a dedicated transport
+	if !resolveUpstreamTLS(cfg, auth).IsZero() {
+		return newProxyAwareHTTPClient(ctx, cfg, auth, timeout)
+	}
+
 	// No proxy - use pooled client for better performance
 	pooledClient := getKiroPooledHTTPClient()
 
@@ -666,6 +671,7 @@ func (e *KiroExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req
 	from := opts.SourceFormat
 	to := sdktranslator.FromString("kiro")
 	body := sdktranslator.TranslateRequest(from, to, req.Model, bytes.Clone(req.Payload), true)
+	body = withStableKiroConversationID(e.cfg, req.Model, tokenKey, body)
 
 	kiroModelID := e.mapModelToKiro(req.Model)
 
@@ -1106,6 +1112,7 @@ func (e *KiroExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Aut
 	from := opts.SourceFormat
 	to := sdktranslator.FromString("kiro")
 	body := sdktranslator.TranslateRequest(from, to, req.Model, bytes.Clone(req.Payload), true)
+	body = withStableKiroConversationID(e.cfg, req.Model, tokenKey, body)
 
 	kiroModelID := e.mapModelToKiro(req.Model)
 
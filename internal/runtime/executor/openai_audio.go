@@ -0,0 +1,171 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Alt values selecting the OpenAI audio endpoints. Transcription payloads are JSON with the
+// uploaded audio base64-encoded in "file" alongside "filename" and "mime_type"; the remaining
+// fields are sent as multipart form fields. Speech payloads are the OpenAI request as-is.
+const (
+	altAudioTranscriptions = "audio/transcriptions"
+	altAudioSpeech         = "audio/speech"
+)
+
+func isAudioAlt(alt string) bool {
+	return alt == altAudioTranscriptions || alt == altAudioSpeech
+}
+
+// executeOpenAIAudio calls /audio/transcriptions or /audio/speech on an OpenAI-compatible base
+// URL. The upstream body is returned unchanged with its headers so binary speech output and
+// text transcription formats pass through.
+func executeOpenAIAudio(ctx context.Context, cfg *config.Config, provider string, auth *cliproxyauth.Auth, baseURL, apiKey string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, decorate func(*http.Request)) (resp cliproxyexecutor.Response, err error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+	reporter := newUsageReporter(ctx, provider, baseModel, auth)
+	defer reporter.trackFailure(ctx, &err)
+
+	var body []byte
+	var logBody []byte
+	contentType := "application/json"
+	if opts.Alt == altAudioTranscriptions {
+		body, contentType, err = buildTranscriptionForm(req.Payload, baseModel)
+		if err != nil {
+			return resp, statusErr{code: http.StatusBadRequest, msg: err.Error()}
+		}
+		logBody, _ = sjson.SetBytes(req.Payload, "file", fmt.Sprintf("<%d bytes omitted>", base64.StdEncoding.DecodedLen(len(gjson.GetBytes(req.Payload, "file").String()))))
+	} else {
+		body, _ = sjson.SetBytes(req.Payload, "model", baseModel)
+		logBody = body
+	}
+
+	url := strings.TrimSuffix(baseURL, "/") + "/" + opts.Alt
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return resp, err
+	}
+	if decorate != nil {
+		decorate(httpReq)
+	}
+	httpReq.Header.Set("Content-Type", contentType)
+	if apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	httpReq.Header.Del("Accept")
+	var authID, authLabel, authType, authValue string
+	if auth != nil {
+		authID = auth.ID
+		authLabel = auth.Label
+		authType, authValue = auth.AccountInfo()
+	}
+	recordAPIRequest(ctx, cfg, upstreamRequestLog{
+		URL:       url,
+		Method:    http.MethodPost,
+		Headers:   httpReq.Header.Clone(),
+		Body:      logBody,
+		Provider:  provider,
+		AuthID:    authID,
+		AuthLabel: authLabel,
+		AuthType:  authType,
+		AuthValue: authValue,
+	})
+
+	httpResp, err := newProxyAwareHTTPClient(ctx, cfg, auth, 0).Do(httpReq)
+	if err != nil {
+		recordAPIResponseError(ctx, cfg, err)
+		return resp, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("%s executor: close audio response body error: %v", provider, errClose)
+		}
+	}()
+	recordAPIResponseMetadata(ctx, cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		recordAPIResponseError(ctx, cfg, err)
+		return resp, err
+	}
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		appendAPIResponseChunk(ctx, cfg, data)
+		return resp, statusErr{code: httpResp.StatusCode, msg: string(data)}
+	}
+	if opts.Alt == altAudioTranscriptions {
+		appendAPIResponseChunk(ctx, cfg, data)
+		reporter.publish(ctx, parseOpenAIUsage(data))
+	}
+	reporter.ensurePublished(ctx)
+	return cliproxyexecutor.Response{Payload: data, Headers: httpResp.Header.Clone()}, nil
+}
+
+// buildTranscriptionForm turns the transcription payload into the multipart upload expected by
+// OpenAI. Array fields use the "name[]" convention.
+func buildTranscriptionForm(payload []byte, model string) ([]byte, string, error) {
+	audio, err := base64.StdEncoding.DecodeString(gjson.GetBytes(payload, "file").String())
+	if err != nil || len(audio) == 0 {
+		return nil, "", fmt.Errorf("transcription payload has no audio file")
+	}
+	filename := gjson.GetBytes(payload, "filename").String()
+	if filename == "" {
+		filename = "audio"
+	}
+	mimeType := gjson.GetBytes(payload, "mime_type").String()
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+
+	var buf bytes.Buffer
+	form := multipart.NewWriter(&buf)
+	if err = form.WriteField("model", model); err != nil {
+		return nil, "", err
+	}
+	var fieldErr error
+	gjson.ParseBytes(payload).ForEach(func(key, value gjson.Result) bool {
+		switch key.String() {
+		case "model", "file", "filename", "mime_type":
+			return true
+		}
+		if value.IsArray() {
+			for _, item := range value.Array() {
+				if fieldErr = form.WriteField(key.String()+"[]", item.String()); fieldErr != nil {
+					return false
+				}
+			}
+			return true
+		}
+		fieldErr = form.WriteField(key.String(), value.String())
+		return fieldErr == nil
+	})
+	if fieldErr != nil {
+		return nil, "", fieldErr
+	}
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename=%q`, filename))
+	header.Set("Content-Type", mimeType)
+	part, err := form.CreatePart(header)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err = part.Write(audio); err != nil {
+		return nil, "", err
+	}
+	if err = form.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), form.FormDataContentType(), nil
+}
@@ -0,0 +1,80 @@
+package executor
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/sjson"
+)
+
+func TestOpenAICompatExecutorAudioTranscriptionsMultipart(t *testing.T) {
+	var gotPath, gotModel, gotLanguage, gotFile, gotFileType string
+	var gotGranularities []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("parse multipart: %v", err)
+		}
+		gotModel = r.FormValue("model")
+		gotLanguage = r.FormValue("language")
+		gotGranularities = r.MultipartForm.Value["timestamp_granularities[]"]
+		file, header, err := r.FormFile("file")
+		if err == nil {
+			data, _ := io.ReadAll(file)
+			gotFile = string(data)
+			gotFileType = header.Header.Get("Content-Type")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"text":"hello","usage":{"type":"tokens","input_tokens":5,"output_tokens":1,"total_tokens":6}}`))
+	}))
+	defer server.Close()
+
+	executor := NewOpenAICompatExecutor("openai-compatibility", &config.Config{})
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{"base_url": server.URL + "/v1", "api_key": "test"}}
+	payload := []byte(`{"model":"whisper-1","filename":"a.mp3","mime_type":"audio/mpeg","language":"en","timestamp_granularities":["word","segment"]}`)
+	payload, _ = sjson.SetBytes(payload, "file", base64.StdEncoding.EncodeToString([]byte("ID3audio")))
+
+	resp, err := executor.Execute(context.Background(), auth, cliproxyexecutor.Request{Model: "whisper-1", Payload: payload}, cliproxyexecutor.Options{
+		SourceFormat: sdktranslator.FromString("openai-audio"),
+		Alt:          altAudioTranscriptions,
+	})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if gotPath != "/v1/audio/transcriptions" {
+		t.Fatalf("path = %q", gotPath)
+	}
+	if gotModel != "whisper-1" || gotLanguage != "en" {
+		t.Fatalf("form fields model=%q language=%q", gotModel, gotLanguage)
+	}
+	if len(gotGranularities) != 2 {
+		t.Fatalf("timestamp_granularities[] = %v", gotGranularities)
+	}
+	if gotFile != "ID3audio" || gotFileType != "audio/mpeg" {
+		t.Fatalf("file = %q (%s)", gotFile, gotFileType)
+	}
+	if string(resp.Payload) != `{"text":"hello","usage":{"type":"tokens","input_tokens":5,"output_tokens":1,"total_tokens":6}}` {
+		t.Fatalf("payload = %s", resp.Payload)
+	}
+}
+
+func TestCodexExecutorAudioRequiresAPIKey(t *testing.T) {
+	executor := NewCodexExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{Provider: "codex", Metadata: map[string]any{"access_token": "oauth"}}
+	_, err := executor.Execute(context.Background(), auth, cliproxyexecutor.Request{Model: "tts-1", Payload: []byte(`{"input":"hi"}`)}, cliproxyexecutor.Options{
+		SourceFormat: sdktranslator.FromString("openai-audio"),
+		Alt:          altAudioSpeech,
+	})
+	status, ok := err.(statusErr)
+	if !ok || status.code != http.StatusNotImplemented {
+		t.Fatalf("err = %v, want 501 statusErr", err)
+	}
+}
@@ -70,6 +70,20 @@ func (e *OpenAICompatExecutor) HttpRequest(ctx context.Context, auth *cliproxyau
 }
 
 func (e *OpenAICompatExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	if isAudioAlt(opts.Alt) {
+		baseURL, apiKey := e.resolveCredentials(auth)
+		if baseURL == "" {
+			return resp, statusErr{code: http.StatusUnauthorized, msg: "missing provider baseURL"}
+		}
+		return executeOpenAIAudio(ctx, e.cfg, e.Identifier(), auth, baseURL, apiKey, req, opts, func(r *http.Request) {
+			r.Header.Set("User-Agent", "cli-proxy-openai-compat")
+			var attrs map[string]string
+			if auth != nil {
+				attrs = auth.Attributes
+			}
+			util.ApplyCustomHeadersFromAttrs(r, attrs)
+		})
+	}
 	baseModel := thinking.ParseSuffix(req.Model).ModelName
 
 	reporter := newUsageReporter(ctx, e.Identifier(), baseModel, auth)
@@ -0,0 +1,130 @@
+package executor
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// maxCacheControlBlocks is the number of cache_control breakpoints Claude accepts per request.
+const maxCacheControlBlocks = 4
+
+// applyPromptCaching plans cache breakpoints for a Claude Messages payload. It runs after
+// translation, so requests that arrived in OpenAI Chat Completions, Responses or Gemini format are
+// cached the same way as native Claude requests. Breakpoints already present are kept as they are.
+// Added breakpoints use the TTL configured for the model, raised to 1h when a client breakpoint
+// later in evaluation order asks for 1h: a 5m breakpoint ahead of it would otherwise force
+// normalizeCacheControlTTL to downgrade the client's 1h breakpoint.
+func applyPromptCaching(cfg *config.Config, model string, payload []byte) []byte {
+	if !promptCachingEnabled(cfg, model) {
+		return payload
+	}
+	ttl := config.PromptCacheTTL5m
+	if cfg != nil {
+		_, ttl = cfg.PromptCaching.ForModel(model)
+	}
+	existing := make(map[string]struct{})
+	for _, path := range cacheControlPaths(payload) {
+		existing[path] = struct{}{}
+	}
+	payload = ensureCacheControl(payload)
+
+	paths := cacheControlPaths(payload)
+	inherited := make([]string, len(paths))
+	strongest := ttl
+	for i := len(paths) - 1; i >= 0; i-- {
+		inherited[i] = strongest
+		if _, ok := existing[paths[i]]; ok && gjson.GetBytes(payload, paths[i]+".ttl").String() == config.PromptCacheTTL1h {
+			strongest = config.PromptCacheTTL1h
+		}
+	}
+	for i, path := range paths {
+		if _, ok := existing[path]; ok || inherited[i] != config.PromptCacheTTL1h {
+			continue
+		}
+		if updated, err := sjson.SetBytes(payload, path+".ttl", config.PromptCacheTTL1h); err == nil {
+			payload = updated
+		}
+	}
+	return payload
+}
+
+// promptCachingEnabled reports whether prompt caching is enabled for model.
+func promptCachingEnabled(cfg *config.Config, model string) bool {
+	if cfg == nil {
+		return true
+	}
+	enabled, _ := cfg.PromptCaching.ForModel(model)
+	return enabled
+}
+
+// stablePromptCacheID derives a UUID that stays the same across the turns of one conversation
+// on one account, from the account key and the first user message. Upstreams that cache prompts
+// per conversation rather than per breakpoint (Antigravity, Kiro) can only reuse their cache when
+// consecutive turns carry the same conversation identifier.
+func stablePromptCacheID(accountKey, firstUserText string) string {
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(accountKey+":"+firstUserText)).String()
+}
+
+// withStableKiroConversationID sets a stable conversationId on a Kiro-bound request that carries
+// none, so the turns of one conversation share Kiro's server-side prompt cache. Kiro payloads
+// have no cache_control field; the conversation identity is the only caching hint they take.
+func withStableKiroConversationID(cfg *config.Config, model, accountKey string, body []byte) []byte {
+	if !promptCachingEnabled(cfg, model) {
+		return body
+	}
+	messages := gjson.GetBytes(body, "messages")
+	if !messages.IsArray() || len(messages.Array()) == 0 {
+		return body
+	}
+	firstUserText := ""
+	for _, message := range messages.Array() {
+		if message.Get("additional_kwargs.conversationId").String() != "" {
+			return body
+		}
+		if firstUserText == "" && message.Get("role").String() == "user" {
+			firstUserText = message.Get("content").Raw
+		}
+	}
+	if firstUserText == "" {
+		return body
+	}
+	out, err := sjson.SetBytes(body, "messages.0.additional_kwargs.conversationId", stablePromptCacheID(accountKey, firstUserText))
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+// cacheControlPaths lists the cache_control paths of a payload in evaluation order
+// (tools, system, messages).
+func cacheControlPaths(payload []byte) []string {
+	var paths []string
+	for i, tool := range gjson.GetBytes(payload, "tools").Array() {
+		if tool.Get("cache_control").Exists() {
+			paths = append(paths, fmt.Sprintf("tools.%d.cache_control", i))
+		}
+	}
+	if system := gjson.GetBytes(payload, "system"); system.IsArray() {
+		for i, block := range system.Array() {
+			if block.Get("cache_control").Exists() {
+				paths = append(paths, fmt.Sprintf("system.%d.cache_control", i))
+			}
+		}
+	}
+	for mi, message := range gjson.GetBytes(payload, "messages").Array() {
+		content := message.Get("content")
+		if !content.IsArray() {
+			continue
+		}
+		for ci, block := range content.Array() {
+			if block.Get("cache_control").Exists() {
+				paths = append(paths, fmt.Sprintf("messages.%d.content.%d.cache_control", mi, ci))
+			}
+		}
+	}
+	return paths
+}
@@ -0,0 +1,139 @@
+package executor
+
+import (
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func TestApplyPromptCachingTranslatedOpenAIRequest(t *testing.T) {
+	openAI := []byte(`{
+		"model": "claude-sonnet-4",
+		"messages": [
+			{"role": "system", "content": "You are a coding agent."},
+			{"role": "user", "content": "Read main.go"},
+			{"role": "assistant", "content": "Done."},
+			{"role": "user", "content": "Now fix the bug"}
+		],
+		"tools": [
+			{"type": "function", "function": {"name": "read", "parameters": {"type": "object"}}},
+			{"type": "function", "function": {"name": "write", "parameters": {"type": "object"}}}
+		]
+	}`)
+	body := sdktranslator.TranslateRequest(sdktranslator.FromString("openai"), sdktranslator.FromString("claude"), "claude-sonnet-4", openAI, false)
+
+	cfg := &config.Config{PromptCaching: config.PromptCachingConfig{TTL: "1h"}}
+	cfg.SanitizePromptCaching()
+	out := applyPromptCaching(cfg, "claude-sonnet-4", body)
+
+	paths := cacheControlPaths(out)
+	if len(paths) != 3 {
+		t.Fatalf("expected tools, system and message breakpoints, got %v in %s", paths, out)
+	}
+	for _, path := range paths {
+		if ttl := gjson.GetBytes(out, path+".ttl").String(); ttl != "1h" {
+			t.Errorf("%s ttl = %q, want 1h", path, ttl)
+		}
+	}
+}
+
+func TestApplyPromptCachingFillsFreeSlotsOnly(t *testing.T) {
+	body := []byte(`{
+		"tools": [{"name": "a", "input_schema": {"type": "object"}}],
+		"system": [
+			{"type": "text", "text": "s1", "cache_control": {"type": "ephemeral"}},
+			{"type": "text", "text": "s2", "cache_control": {"type": "ephemeral"}},
+			{"type": "text", "text": "s3", "cache_control": {"type": "ephemeral"}}
+		],
+		"messages": [
+			{"role": "user", "content": "first"},
+			{"role": "assistant", "content": "reply"},
+			{"role": "user", "content": "second"}
+		]
+	}`)
+	out := applyPromptCaching(nil, "claude-sonnet-4", body)
+
+	if got := countCacheControls(out); got != maxCacheControlBlocks {
+		t.Fatalf("breakpoints = %d, want %d: %s", got, maxCacheControlBlocks, out)
+	}
+	if !gjson.GetBytes(out, "tools.0.cache_control").Exists() {
+		t.Errorf("expected the free slot to go to the tools: %s", out)
+	}
+	if gjson.GetBytes(out, "tools.0.cache_control.ttl").Exists() {
+		t.Errorf("default TTL should not be written: %s", out)
+	}
+}
+
+func TestApplyPromptCachingPerModel(t *testing.T) {
+	body := []byte(`{"system": "prompt", "messages": [{"role": "user", "content": "hi"}]}`)
+	cfg := &config.Config{PromptCaching: config.PromptCachingConfig{Models: []config.PromptCachingModel{
+		{Name: "claude-3-5-haiku-*", Disable: true},
+		{Name: "claude-opus-*", TTL: "1h"},
+	}}}
+	cfg.SanitizePromptCaching()
+
+	if out := applyPromptCaching(cfg, "claude-3-5-haiku-20241022", body); countCacheControls(out) != 0 {
+		t.Errorf("disabled model got breakpoints: %s", out)
+	}
+	out := applyPromptCaching(cfg, "claude-opus-4-1", body)
+	if gjson.GetBytes(out, "system.0.cache_control.ttl").String() != "1h" {
+		t.Errorf("expected 1h breakpoint on system: %s", out)
+	}
+
+	cfg.PromptCaching.Disable = true
+	if out = applyPromptCaching(cfg, "claude-opus-4-1", body); countCacheControls(out) != 1 {
+		t.Errorf("model entry should override the global switch: %s", out)
+	}
+}
+
+func TestApplyPromptCachingInheritsDownstreamOneHourTTL(t *testing.T) {
+	body := []byte(`{
+		"tools": [{"name": "a", "input_schema": {"type": "object"}}],
+		"system": [{"type": "text", "text": "s1"}],
+		"messages": [
+			{"role": "user", "content": [{"type": "text", "text": "first", "cache_control": {"type": "ephemeral", "ttl": "1h"}}]},
+			{"role": "assistant", "content": "reply"},
+			{"role": "user", "content": "second"}
+		]
+	}`)
+	out := normalizeCacheControlTTL(applyPromptCaching(nil, "claude-sonnet-4", body))
+
+	for _, path := range []string{"tools.0.cache_control", "system.0.cache_control", "messages.0.content.0.cache_control"} {
+		if ttl := gjson.GetBytes(out, path+".ttl").String(); ttl != "1h" {
+			t.Errorf("%s ttl = %q, want 1h: %s", path, ttl, out)
+		}
+	}
+}
+
+func TestWithStableKiroConversationID(t *testing.T) {
+	turn1 := []byte(`{"messages":[{"role":"user","content":"hello"}]}`)
+	turn2 := []byte(`{"messages":[{"role":"user","content":"hello"},{"role":"assistant","content":"hi"},{"role":"user","content":"more"}]}`)
+
+	id1 := gjson.GetBytes(withStableKiroConversationID(nil, "claude-sonnet-4", "acct", turn1), "messages.0.additional_kwargs.conversationId").String()
+	id2 := gjson.GetBytes(withStableKiroConversationID(nil, "claude-sonnet-4", "acct", turn2), "messages.0.additional_kwargs.conversationId").String()
+	if id1 == "" || id1 != id2 {
+		t.Fatalf("conversation ids differ across turns: %q vs %q", id1, id2)
+	}
+	if other := gjson.GetBytes(withStableKiroConversationID(nil, "claude-sonnet-4", "other", turn1), "messages.0.additional_kwargs.conversationId").String(); other == id1 {
+		t.Error("conversation id shared across accounts")
+	}
+
+	client := []byte(`{"messages":[{"role":"user","content":"hello","additional_kwargs":{"conversationId":"client"}}]}`)
+	if got := gjson.GetBytes(withStableKiroConversationID(nil, "claude-sonnet-4", "acct", client), "messages.0.additional_kwargs.conversationId").String(); got != "client" {
+		t.Errorf("client conversation id replaced with %q", got)
+	}
+
+	cfg := &config.Config{PromptCaching: config.PromptCachingConfig{Disable: true}}
+	if out := withStableKiroConversationID(cfg, "claude-sonnet-4", "acct", turn1); gjson.GetBytes(out, "messages.0.additional_kwargs").Exists() {
+		t.Errorf("conversation id set with prompt caching disabled: %s", out)
+	}
+}
+
+func TestAntigravitySessionIDStableWhenCaching(t *testing.T) {
+	payload := []byte(`{"request":{"contents":[{"role":"user","parts":[{"text":"hello"}]}]}}`)
+	if generateStableSessionID(payload, "auth", "claude-sonnet-4", "p", true) != generateStableSessionID(payload, "auth", "claude-sonnet-4", "p", true) {
+		t.Error("cached session id changed between turns")
+	}
+}
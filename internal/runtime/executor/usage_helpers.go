@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
 	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
 	"github.com/tidwall/gjson"
@@ -21,7 +22,9 @@ type usageReporter struct {
 	authID      string
 	authIndex   string
 	apiKey      string
+	sessionKey  string
 	source      string
+	hedged      bool
 	requestedAt time.Time
 	once        sync.Once
 }
@@ -33,7 +36,9 @@ func newUsageReporter(ctx context.Context, provider, model string, auth *cliprox
 		model:       model,
 		requestedAt: time.Now(),
 		apiKey:      apiKey,
+		sessionKey:  sessionKeyFromContext(ctx),
 		source:      resolveUsageSource(auth, apiKey),
+		hedged:      usage.IsHedgedAttempt(ctx),
 	}
 	if auth != nil {
 		reporter.authID = auth.ID
@@ -73,7 +78,9 @@ func (r *usageReporter) publishWithOutcome(ctx context.Context, detail usage.Det
 		return
 	}
 	r.once.Do(func() {
-		usage.PublishRecord(ctx, r.buildRecord(detail, failed))
+		record := r.buildRecord(detail, failed)
+		exposeUsageRecord(ctx, record)
+		usage.PublishRecord(ctx, record)
 	})
 }
 
@@ -86,10 +93,28 @@ func (r *usageReporter) ensurePublished(ctx context.Context) {
 		return
 	}
 	r.once.Do(func() {
-		usage.PublishRecord(ctx, r.buildRecord(usage.Detail{}, false))
+		record := r.buildRecord(usage.Detail{}, false)
+		exposeUsageRecord(ctx, record)
+		usage.PublishRecord(ctx, record)
 	})
 }
 
+// exposeUsageRecord stores the record on the Gin context for structured request logs. A hedged
+// duplicate does not replace the record of the attempt that already reported.
+func exposeUsageRecord(ctx context.Context, record usage.Record) {
+	if ctx == nil {
+		return
+	}
+	ginCtx := ginContextFrom(ctx)
+	if ginCtx == nil {
+		return
+	}
+	if _, exists := ginCtx.Get(logging.UsageRecordContextKey); exists && record.Hedged {
+		return
+	}
+	ginCtx.Set(logging.UsageRecordContextKey, record)
+}
+
 func (r *usageReporter) buildRecord(detail usage.Detail, failed bool) usage.Record {
 	if r == nil {
 		return usage.Record{Detail: detail, Failed: failed}
@@ -104,6 +129,8 @@ func (r *usageReporter) buildRecord(detail usage.Detail, failed bool) usage.Reco
 		RequestedAt: r.requestedAt,
 		Latency:     r.latency(),
 		Failed:      failed,
+		Hedged:      r.hedged,
+		SessionKey:  r.sessionKey,
 		Detail:      detail,
 	}
 }
@@ -119,6 +146,18 @@ func (r *usageReporter) latency() time.Duration {
 	return latency
 }
 
+// sessionKeyFromContext returns the X-Session-Key header of the client request.
+func sessionKeyFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	ginCtx := ginContextFrom(ctx)
+	if ginCtx == nil || ginCtx.Request == nil {
+		return ""
+	}
+	return strings.TrimSpace(ginCtx.GetHeader("X-Session-Key"))
+}
+
 func apiKeyFromContext(ctx context.Context) string {
 	if ctx == nil {
 		return ""
@@ -305,9 +344,11 @@ func parseClaudeUsage(data []byte) usage.Detail {
 		return usage.Detail{}
 	}
 	detail := usage.Detail{
-		InputTokens:  usageNode.Get("input_tokens").Int(),
-		OutputTokens: usageNode.Get("output_tokens").Int(),
-		CachedTokens: usageNode.Get("cache_read_input_tokens").Int(),
+		InputTokens:         usageNode.Get("input_tokens").Int(),
+		OutputTokens:        usageNode.Get("output_tokens").Int(),
+		CachedTokens:        usageNode.Get("cache_read_input_tokens").Int(),
+		CacheReadTokens:     usageNode.Get("cache_read_input_tokens").Int(),
+		CacheCreationTokens: usageNode.Get("cache_creation_input_tokens").Int(),
 	}
 	if detail.CachedTokens == 0 {
 		// fall back to creation tokens when read tokens are absent
@@ -327,9 +368,11 @@ func parseClaudeStreamUsage(line []byte) (usage.Detail, bool) {
 		return usage.Detail{}, false
 	}
 	detail := usage.Detail{
-		InputTokens:  usageNode.Get("input_tokens").Int(),
-		OutputTokens: usageNode.Get("output_tokens").Int(),
-		CachedTokens: usageNode.Get("cache_read_input_tokens").Int(),
+		InputTokens:         usageNode.Get("input_tokens").Int(),
+		OutputTokens:        usageNode.Get("output_tokens").Int(),
+		CachedTokens:        usageNode.Get("cache_read_input_tokens").Int(),
+		CacheReadTokens:     usageNode.Get("cache_read_input_tokens").Int(),
+		CacheCreationTokens: usageNode.Get("cache_creation_input_tokens").Int(),
 	}
 	if detail.CachedTokens == 0 {
 		detail.CachedTokens = usageNode.Get("cache_creation_input_tokens").Int()
@@ -4,49 +4,58 @@ import (
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"os"
-	"sync"
 	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
+	"golang.org/x/sync/singleflight"
 )
 
 type userIDCacheEntry struct {
-	value  string
-	expire time.Time
+	Value  string    `json:"value"`
+	Expire time.Time `json:"expire"`
 }
 
 var (
-	userIDCache            = make(map[string]userIDCacheEntry)
-	userIDCacheMu          sync.RWMutex
-	userIDCacheCleanupOnce sync.Once
-	userIDCacheHashKey     = resolveUserIDCacheHashKey()
+	// userIDLookups collapses concurrent lookups of one API key so they share a user_id, without
+	// holding up requests for other keys while the store is read and written.
+	userIDLookups      singleflight.Group
+	userIDCacheHashKey = resolveUserIDCacheHashKey()
 )
 
 const userIDCacheHashFallback = "executor-user-id-cache:hmac-sha256-v1"
 
 const (
-	userIDTTL                = time.Hour
-	userIDCacheCleanupPeriod = 15 * time.Minute
+	userIDTTL = time.Hour
+	// userIDCacheNamespace is the cache store namespace of generated user IDs keyed by the
+	// HMAC of the API key.
+	userIDCacheNamespace = "claude-user-id"
 )
 
-func startUserIDCacheCleanup() {
-	go func() {
-		ticker := time.NewTicker(userIDCacheCleanupPeriod)
-		defer ticker.Stop()
-		for range ticker.C {
-			purgeExpiredUserIDs()
-		}
-	}()
+func loadUserIDEntry(key string) (userIDCacheEntry, bool) {
+	raw, ok := cache.Load(userIDCacheNamespace, key)
+	if !ok {
+		return userIDCacheEntry{}, false
+	}
+	var entry userIDCacheEntry
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return userIDCacheEntry{}, false
+	}
+	return entry, true
 }
 
-func purgeExpiredUserIDs() {
-	now := time.Now()
-	userIDCacheMu.Lock()
-	for key, entry := range userIDCache {
-		if !entry.expire.After(now) {
-			delete(userIDCache, key)
-		}
+func storeUserIDEntry(key string, entry userIDCacheEntry) {
+	ttl := time.Until(entry.Expire)
+	if ttl <= 0 {
+		cache.Remove(userIDCacheNamespace, key)
+		return
+	}
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return
 	}
-	userIDCacheMu.Unlock()
+	cache.Save(userIDCacheNamespace, key, raw, ttl)
 }
 
 func userIDCacheKey(apiKey string) string {
@@ -69,36 +78,17 @@ func cachedUserID(apiKey string) string {
 		return generateFakeUserID()
 	}
 
-	userIDCacheCleanupOnce.Do(startUserIDCacheCleanup)
-
 	key := userIDCacheKey(apiKey)
-	now := time.Now()
-
-	userIDCacheMu.RLock()
-	entry, ok := userIDCache[key]
-	valid := ok && entry.value != "" && entry.expire.After(now) && isValidUserID(entry.value)
-	userIDCacheMu.RUnlock()
-	if valid {
-		userIDCacheMu.Lock()
-		entry = userIDCache[key]
-		if entry.value != "" && entry.expire.After(now) && isValidUserID(entry.value) {
-			entry.expire = now.Add(userIDTTL)
-			userIDCache[key] = entry
-			userIDCacheMu.Unlock()
-			return entry.value
+	value, _, _ := userIDLookups.Do(key, func() (any, error) {
+		now := time.Now()
+		entry, ok := loadUserIDEntry(key)
+		if !ok || entry.Value == "" || !entry.Expire.After(now) || !isValidUserID(entry.Value) {
+			entry.Value = generateFakeUserID()
 		}
-		userIDCacheMu.Unlock()
-	}
-
-	newID := generateFakeUserID()
-
-	userIDCacheMu.Lock()
-	entry, ok = userIDCache[key]
-	if !ok || entry.value == "" || !entry.expire.After(now) || !isValidUserID(entry.value) {
-		entry.value = newID
-	}
-	entry.expire = now.Add(userIDTTL)
-	userIDCache[key] = entry
-	userIDCacheMu.Unlock()
-	return entry.value
+		// Refresh TTL on every hit (sliding expiration).
+		entry.Expire = now.Add(userIDTTL)
+		storeUserIDEntry(key, entry)
+		return entry.Value, nil
+	})
+	return value.(string)
 }
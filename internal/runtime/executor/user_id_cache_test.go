@@ -1,14 +1,15 @@
 package executor
 
 import (
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
 )
 
 func resetUserIDCache() {
-	userIDCacheMu.Lock()
-	userIDCache = make(map[string]userIDCacheEntry)
-	userIDCacheMu.Unlock()
+	cache.RemovePrefix(userIDCacheNamespace, "")
 }
 
 func TestCachedUserID_ReusesWithinTTL(t *testing.T) {
@@ -30,12 +31,10 @@ func TestCachedUserID_ExpiresAfterTTL(t *testing.T) {
 
 	expiredID := cachedUserID("api-key-expired")
 	cacheKey := userIDCacheKey("api-key-expired")
-	userIDCacheMu.Lock()
-	userIDCache[cacheKey] = userIDCacheEntry{
-		value:  expiredID,
-		expire: time.Now().Add(-time.Minute),
-	}
-	userIDCacheMu.Unlock()
+	storeUserIDEntry(cacheKey, userIDCacheEntry{
+		Value:  expiredID,
+		Expire: time.Now().Add(-time.Minute),
+	})
 
 	newID := cachedUserID("api-key-expired")
 	if newID == expiredID {
@@ -65,22 +64,42 @@ func TestCachedUserID_RenewsTTLOnHit(t *testing.T) {
 	cacheKey := userIDCacheKey(key)
 
 	soon := time.Now()
-	userIDCacheMu.Lock()
-	userIDCache[cacheKey] = userIDCacheEntry{
-		value:  id,
-		expire: soon.Add(2 * time.Second),
-	}
-	userIDCacheMu.Unlock()
+	storeUserIDEntry(cacheKey, userIDCacheEntry{
+		Value:  id,
+		Expire: soon.Add(2 * time.Second),
+	})
 
 	if refreshed := cachedUserID(key); refreshed != id {
 		t.Fatalf("expected cached user_id to be reused before expiry, got %q", refreshed)
 	}
 
-	userIDCacheMu.RLock()
-	entry := userIDCache[cacheKey]
-	userIDCacheMu.RUnlock()
+	entry, ok := loadUserIDEntry(cacheKey)
+	if !ok {
+		t.Fatal("expected cached user_id entry after renewal")
+	}
+	if entry.Expire.Sub(soon) < 30*time.Minute {
+		t.Fatalf("expected TTL to renew, got %v remaining", entry.Expire.Sub(soon))
+	}
+}
+
+func TestCachedUserID_ConcurrentLookupsShareValue(t *testing.T) {
+	resetUserIDCache()
+
+	const callers = 16
+	ids := make([]string, callers)
+	var wg sync.WaitGroup
+	for i := range ids {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ids[i] = cachedUserID("api-key-concurrent")
+		}(i)
+	}
+	wg.Wait()
 
-	if entry.expire.Sub(soon) < 30*time.Minute {
-		t.Fatalf("expected TTL to renew, got %v remaining", entry.expire.Sub(soon))
+	for _, id := range ids[1:] {
+		if id != ids[0] {
+			t.Fatalf("expected concurrent lookups to share a user_id, got %q and %q", ids[0], id)
+		}
 	}
 }
@@ -0,0 +1,137 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/filestore"
+)
+
+const (
+	objectStoreFilesPrefix = "files"
+	objectFileMetaSuffix   = ".meta"
+	objectFileDataSuffix   = ".bin"
+)
+
+// FileBackend returns a file store backend that keeps uploads in the token store bucket under
+// the files/ prefix, sharing the store's client and key prefix.
+func (s *ObjectTokenStore) FileBackend() filestore.Backend {
+	return &objectFileBackend{store: s}
+}
+
+type objectFileBackend struct {
+	store *ObjectTokenStore
+}
+
+func (b *objectFileBackend) Put(ctx context.Context, file filestore.File, data []byte) error {
+	if !filestore.ValidID(file.ID) {
+		return fmt.Errorf("object store: invalid file id %q", file.ID)
+	}
+	if len(data) == 0 {
+		return fmt.Errorf("object store: file %s is empty", file.ID)
+	}
+	meta, err := json.Marshal(file)
+	if err != nil {
+		return fmt.Errorf("object store: marshal file metadata: %w", err)
+	}
+	if err = b.store.putObject(ctx, b.key(file.ID, objectFileDataSuffix), data, file.MimeType); err != nil {
+		return err
+	}
+	if err = b.store.putObject(ctx, b.key(file.ID, objectFileMetaSuffix), meta, "application/json"); err != nil {
+		_ = b.store.deleteObject(ctx, b.key(file.ID, objectFileDataSuffix))
+		return err
+	}
+	return nil
+}
+
+func (b *objectFileBackend) Stat(ctx context.Context, id string) (filestore.File, error) {
+	if !filestore.ValidID(id) {
+		return filestore.File{}, filestore.ErrNotFound
+	}
+	return b.readMeta(ctx, b.store.prefixedKey(b.key(id, objectFileMetaSuffix)))
+}
+
+func (b *objectFileBackend) Get(ctx context.Context, id string) (filestore.File, []byte, error) {
+	file, err := b.Stat(ctx, id)
+	if err != nil {
+		return filestore.File{}, nil, err
+	}
+	data, err := b.read(ctx, b.store.prefixedKey(b.key(id, objectFileDataSuffix)))
+	if err != nil {
+		return filestore.File{}, nil, err
+	}
+	return file, data, nil
+}
+
+func (b *objectFileBackend) List(ctx context.Context) ([]filestore.File, error) {
+	prefix := b.store.prefixedKey(objectStoreFilesPrefix + "/")
+	objectCh := b.store.client.ListObjects(ctx, b.store.cfg.Bucket, minio.ListObjectsOptions{
+		Prefix:    prefix,
+		Recursive: true,
+	})
+	files := make([]filestore.File, 0)
+	for object := range objectCh {
+		if object.Err != nil {
+			return nil, fmt.Errorf("object store: list files: %w", object.Err)
+		}
+		if !strings.HasSuffix(object.Key, objectFileMetaSuffix) {
+			continue
+		}
+		file, err := b.readMeta(ctx, object.Key)
+		if err != nil {
+			continue
+		}
+		files = append(files, file)
+	}
+	filestore.SortNewestFirst(files)
+	return files, nil
+}
+
+func (b *objectFileBackend) Delete(ctx context.Context, id string) error {
+	if _, err := b.Stat(ctx, id); err != nil {
+		return err
+	}
+	if err := b.store.deleteObject(ctx, b.key(id, objectFileMetaSuffix)); err != nil {
+		return err
+	}
+	return b.store.deleteObject(ctx, b.key(id, objectFileDataSuffix))
+}
+
+func (b *objectFileBackend) key(id, suffix string) string {
+	return objectStoreFilesPrefix + "/" + id + suffix
+}
+
+func (b *objectFileBackend) readMeta(ctx context.Context, fullKey string) (filestore.File, error) {
+	data, err := b.read(ctx, fullKey)
+	if err != nil {
+		return filestore.File{}, err
+	}
+	var file filestore.File
+	if err = json.Unmarshal(data, &file); err != nil {
+		return filestore.File{}, fmt.Errorf("object store: parse file metadata %s: %w", fullKey, err)
+	}
+	return file, nil
+}
+
+func (b *objectFileBackend) read(ctx context.Context, fullKey string) ([]byte, error) {
+	reader, err := b.store.client.GetObject(ctx, b.store.cfg.Bucket, fullKey, minio.GetObjectOptions{})
+	if err != nil {
+		if isObjectNotFound(err) {
+			return nil, filestore.ErrNotFound
+		}
+		return nil, fmt.Errorf("object store: get %s: %w", fullKey, err)
+	}
+	defer func() { _ = reader.Close() }()
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		if isObjectNotFound(err) {
+			return nil, filestore.ErrNotFound
+		}
+		return nil, fmt.Errorf("object store: read %s: %w", fullKey, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,26 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const objectStoreRequestLogPrefix = "request-logs"
+
+// PutRequestLogSegment uploads a closed structured request log segment under the request-logs/
+// prefix, sharing the store's client and key prefix.
+func (s *ObjectTokenStore) PutRequestLogSegment(ctx context.Context, name string, data []byte) error {
+	name = strings.TrimSpace(name)
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("object store: invalid request log segment name %q", name)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	contentType := "application/x-ndjson"
+	if strings.HasSuffix(name, ".gz") {
+		contentType = "application/gzip"
+	}
+	return s.putObject(ctx, objectStoreRequestLogPrefix+"/"+name, data, contentType)
+}
@@ -22,6 +22,7 @@ import (
 const (
 	defaultConfigTable = "config_store"
 	defaultAuthTable   = "auth_store"
+	defaultStateTable  = "auth_runtime_state"
 	defaultConfigKey   = "config"
 )
 
@@ -31,6 +32,7 @@ type PostgresStoreConfig struct {
 	Schema      string
 	ConfigTable string
 	AuthTable   string
+	StateTable  string
 	SpoolDir    string
 }
 
@@ -58,6 +60,9 @@ func NewPostgresStore(ctx context.Context, cfg PostgresStoreConfig) (*PostgresSt
 	if cfg.AuthTable == "" {
 		cfg.AuthTable = defaultAuthTable
 	}
+	if cfg.StateTable == "" {
+		cfg.StateTable = defaultStateTable
+	}
 
 	spoolRoot := strings.TrimSpace(cfg.SpoolDir)
 	if spoolRoot == "" {
@@ -140,6 +145,16 @@ func (s *PostgresStore) EnsureSchema(ctx context.Context) error {
 	`, authTable)); err != nil {
 		return fmt.Errorf("postgres store: create auth table: %w", err)
 	}
+	stateTable := s.fullTableName(s.cfg.StateTable)
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id TEXT PRIMARY KEY,
+			content JSONB NOT NULL,
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)
+	`, stateTable)); err != nil {
+		return fmt.Errorf("postgres store: create runtime state table: %w", err)
+	}
 	return nil
 }
 
@@ -522,6 +537,69 @@ func (s *PostgresStore) deleteAuthRecord(ctx context.Context, relID string) erro
 	return nil
 }
 
+// LoadRuntimeStates returns the persisted runtime state of every auth.
+// Runtime state lives in its own table so it is never mirrored into the auth workspace.
+func (s *PostgresStore) LoadRuntimeStates(ctx context.Context) (map[string]*cliproxyauth.RuntimeState, error) {
+	query := fmt.Sprintf("SELECT id, content FROM %s", s.fullTableName(s.cfg.StateTable))
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("postgres store: load runtime state: %w", err)
+	}
+	defer rows.Close()
+
+	states := make(map[string]*cliproxyauth.RuntimeState)
+	for rows.Next() {
+		var (
+			id      string
+			payload []byte
+		)
+		if err = rows.Scan(&id, &payload); err != nil {
+			return nil, fmt.Errorf("postgres store: scan runtime state: %w", err)
+		}
+		var state cliproxyauth.RuntimeState
+		if errUnmarshal := json.Unmarshal(payload, &state); errUnmarshal != nil {
+			log.WithError(errUnmarshal).Warnf("postgres store: skipping runtime state %s with invalid json", id)
+			continue
+		}
+		state.ID = id
+		states[id] = &state
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("postgres store: iterate runtime state: %w", err)
+	}
+	return states, nil
+}
+
+// SaveRuntimeState upserts the runtime state of one auth.
+func (s *PostgresStore) SaveRuntimeState(ctx context.Context, state *cliproxyauth.RuntimeState) error {
+	if state == nil || state.ID == "" {
+		return nil
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("postgres store: marshal runtime state: %w", err)
+	}
+	query := fmt.Sprintf(`
+		INSERT INTO %s (id, content, updated_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (id)
+		DO UPDATE SET content = EXCLUDED.content, updated_at = NOW()
+	`, s.fullTableName(s.cfg.StateTable))
+	if _, err = s.db.ExecContext(ctx, query, state.ID, json.RawMessage(data)); err != nil {
+		return fmt.Errorf("postgres store: upsert runtime state: %w", err)
+	}
+	return nil
+}
+
+// DeleteRuntimeState removes the runtime state of one auth.
+func (s *PostgresStore) DeleteRuntimeState(ctx context.Context, id string) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1", s.fullTableName(s.cfg.StateTable))
+	if _, err := s.db.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("postgres store: delete runtime state: %w", err)
+	}
+	return nil
+}
+
 func (s *PostgresStore) persistConfig(ctx context.Context, data []byte) error {
 	query := fmt.Sprintf(`
 		INSERT INTO %s (id, content, created_at, updated_at)
@@ -311,9 +311,13 @@ func ConvertClaudeRequestToAntigravity(modelName string, inputRawJSON []byte, _
 							partJSON, _ = sjson.SetRawBytes(partJSON, "functionResponse", functionResponseJSON)
 							clientContentJSON, _ = sjson.SetRawBytes(clientContentJSON, "parts.-1", partJSON)
 						}
-					} else if contentTypeResult.Type == gjson.String && contentTypeResult.String() == "image" {
+					} else if contentTypeResult.Type == gjson.String && (contentTypeResult.String() == "image" || contentTypeResult.String() == "document") {
 						sourceResult := contentResult.Get("source")
-						if sourceResult.Get("type").String() == "base64" {
+						if sourceResult.Get("type").String() == "text" {
+							partJSON := []byte(`{}`)
+							partJSON, _ = sjson.SetBytes(partJSON, "text", sourceResult.Get("data").String())
+							clientContentJSON, _ = sjson.SetRawBytes(clientContentJSON, "parts.-1", partJSON)
+						} else if sourceResult.Get("type").String() == "base64" {
 							inlineDataJSON := []byte(`{}`)
 							if mimeType := sourceResult.Get("media_type").String(); mimeType != "" {
 								inlineDataJSON, _ = sjson.SetBytes(inlineDataJSON, "mimeType", mimeType)
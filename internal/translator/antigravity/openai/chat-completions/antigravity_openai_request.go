@@ -105,6 +105,11 @@ func ConvertOpenAIRequestToAntigravity(modelName string, inputRawJSON []byte, _
 		}
 	}
 
+	// Map OpenAI response_format json_object/json_schema -> request.generationConfig.responseMimeType/responseSchema
+	if format, ok := util.OpenAIChatStructuredOutput(rawJSON); ok {
+		out = common.AttachStructuredOutput(out, format, "request.generationConfig")
+	}
+
 	// messages -> systemInstruction + contents
 	messages := gjson.GetBytes(rawJSON, "messages")
 	if messages.IsArray() {
@@ -205,7 +210,14 @@ func ConvertOpenAIRequestToAntigravity(modelName string, inputRawJSON []byte, _
 							if sp := strings.Split(filename, "."); len(sp) > 1 {
 								ext = sp[len(sp)-1]
 							}
-							if mimeType, ok := misc.MimeTypes[ext]; ok {
+							mimeType, ok := misc.MimeTypes[ext]
+							// Inline data URLs carry their own media type, e.g. resolved file_id references.
+							if rest, isDataURL := strings.CutPrefix(fileData, "data:"); isDataURL {
+								if mediaType, data, isBase64 := strings.Cut(rest, ";base64,"); isBase64 && mediaType != "" {
+									mimeType, fileData, ok = mediaType, data, true
+								}
+							}
+							if ok {
 								node, _ = sjson.SetBytes(node, "parts."+itoa(p)+".inlineData.mimeType", mimeType)
 								node, _ = sjson.SetBytes(node, "parts."+itoa(p)+".inlineData.data", fileData)
 								p++
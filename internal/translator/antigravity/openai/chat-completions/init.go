@@ -16,4 +16,5 @@ func init() {
 			NonStream: ConvertAntigravityResponseToOpenAINonStream,
 		},
 	)
+	translator.RegisterCapabilities(OpenAI, Antigravity, translator.FeatureToolChoice, translator.FeatureParallelToolCalls, translator.FeatureLogprobs, translator.FeatureSeed, translator.FeatureStopSequences, translator.FeaturePenalties, translator.FeatureLogitBias, translator.FeatureAudioOutput)
 }
@@ -16,4 +16,5 @@ func init() {
 			NonStream: ConvertAntigravityResponseToOpenAIResponsesNonStream,
 		},
 	)
+	translator.RegisterCapabilities(OpenaiResponse, Antigravity, translator.FeatureToolChoice, translator.FeatureParallelToolCalls, translator.FeatureLogprobs, translator.FeaturePreviousResponseID, translator.FeatureTruncation)
 }
@@ -17,4 +17,5 @@ func init() {
 			TokenCount: GeminiCLITokenCount,
 		},
 	)
+	translator.RegisterCapabilities(GeminiCLI, Claude, translator.FeatureLogprobs, translator.FeatureMultipleCandidates, translator.FeatureSeed, translator.FeatureStructuredOutput, translator.FeatureAudioInput)
 }
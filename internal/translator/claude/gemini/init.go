@@ -17,4 +17,5 @@ func init() {
 			TokenCount: GeminiTokenCount,
 		},
 	)
+	translator.RegisterCapabilities(Gemini, Claude, translator.FeatureLogprobs, translator.FeatureMultipleCandidates, translator.FeatureSeed, translator.FeatureStructuredOutput, translator.FeatureAudioInput)
 }
@@ -16,6 +16,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -327,6 +328,11 @@ func ConvertOpenAIRequestToClaude(modelName string, inputRawJSON []byte, stream
 		}
 	}
 
+	// Map response_format json_object/json_schema to forced use of the structured output tool.
+	if format, ok := util.OpenAIChatStructuredOutput(rawJSON); ok {
+		out = util.ApplyClaudeStructuredOutput(out, format, root.Get("tool_choice").String() == "none")
+	}
+
 	return out
 }
 
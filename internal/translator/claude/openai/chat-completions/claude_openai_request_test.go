@@ -243,3 +243,35 @@ func TestConvertOpenAIRequestToClaude_SystemOnlyInputKeepsFallbackUserMessage(t
 		t.Fatalf("Expected fallback text %q, got %q", "", got)
 	}
 }
+
+func TestConvertOpenAIRequestToClaude_ResponseFormatForcesStructuredOutputTool(t *testing.T) {
+	inputJSON := `{
+		"model": "gpt-4.1",
+		"messages": [{"role": "user", "content": "Name a color"}],
+		"response_format": {
+			"type": "json_schema",
+			"json_schema": {
+				"name": "color",
+				"strict": true,
+				"schema": {"type": "object", "properties": {"name": {"type": "string"}}, "required": ["name"]}
+			}
+		}
+	}`
+
+	result := ConvertOpenAIRequestToClaude("claude-sonnet-4-5", []byte(inputJSON), false)
+	resultJSON := gjson.ParseBytes(result)
+
+	tool := resultJSON.Get(`tools.#(name=="structured_output")`)
+	if !tool.Exists() {
+		t.Fatalf("structured_output tool missing: %s", result)
+	}
+	if got := tool.Get("input_schema.required.0").String(); got != "name" {
+		t.Fatalf("input_schema.required.0 = %q, want %q", got, "name")
+	}
+	if got := resultJSON.Get("tool_choice.type").String(); got != "tool" {
+		t.Fatalf("tool_choice.type = %q, want %q", got, "tool")
+	}
+	if got := resultJSON.Get("tool_choice.name").String(); got != "structured_output" {
+		t.Fatalf("tool_choice.name = %q, want %q", got, "structured_output")
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -27,6 +28,10 @@ type ConvertAnthropicResponseToOpenAIParams struct {
 	FinishReason string
 	// Tool calls accumulator for streaming
 	ToolCallsAccumulator map[int]*ToolCallAccumulator
+	// StructuredBlocks marks content blocks of the structured output tool, streamed as text
+	StructuredBlocks map[int]bool
+	// ClientToolCalls records whether the model called a client tool
+	ClientToolCalls bool
 }
 
 // ToolCallAccumulator holds the state for accumulating tool call data
@@ -130,6 +135,16 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 				toolName := contentBlock.Get("name").String()
 				index := int(root.Get("index").Int())
 
+				// The structured output tool carries the answer requested via response_format.
+				if isStructuredOutputTool(toolName, requestRawJSON) {
+					if (*param).(*ConvertAnthropicResponseToOpenAIParams).StructuredBlocks == nil {
+						(*param).(*ConvertAnthropicResponseToOpenAIParams).StructuredBlocks = make(map[int]bool)
+					}
+					(*param).(*ConvertAnthropicResponseToOpenAIParams).StructuredBlocks[index] = true
+					return [][]byte{}
+				}
+				(*param).(*ConvertAnthropicResponseToOpenAIParams).ClientToolCalls = true
+
 				if (*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator == nil {
 					(*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator = make(map[int]*ToolCallAccumulator)
 				}
@@ -168,6 +183,13 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 				// Tool use input delta - stream argument fragments incrementally
 				if partialJSON := delta.Get("partial_json"); partialJSON.Exists() {
 					index := int(root.Get("index").Int())
+					if (*param).(*ConvertAnthropicResponseToOpenAIParams).StructuredBlocks[index] {
+						if partialJSON.String() == "" {
+							return [][]byte{}
+						}
+						template, _ = sjson.SetBytes(template, "choices.0.delta.content", partialJSON.String())
+						return [][]byte{template}
+					}
 					if (*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator != nil {
 						if accumulator, exists := (*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator[index]; exists {
 							accumulator.Arguments.WriteString(partialJSON.String())
@@ -214,7 +236,11 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 		// Handle message-level changes including stop reason and usage
 		if delta := root.Get("delta"); delta.Exists() {
 			if stopReason := delta.Get("stop_reason"); stopReason.Exists() {
-				(*param).(*ConvertAnthropicResponseToOpenAIParams).FinishReason = mapAnthropicStopReasonToOpenAI(stopReason.String())
+				p := (*param).(*ConvertAnthropicResponseToOpenAIParams)
+				p.FinishReason = mapAnthropicStopReasonToOpenAI(stopReason.String())
+				if len(p.StructuredBlocks) > 0 && !p.ClientToolCalls && p.FinishReason == "tool_calls" {
+					p.FinishReason = "stop"
+				}
 				template, _ = sjson.SetBytes(template, "choices.0.finish_reason", (*param).(*ConvertAnthropicResponseToOpenAIParams).FinishReason)
 			}
 		}
@@ -298,7 +324,7 @@ func ConvertClaudeResponseToOpenAINonStream(_ context.Context, _ string, origina
 	if len(chunks) == 0 {
 		root := gjson.ParseBytes(rawJSON)
 		if root.Get("type").String() == "message" {
-			return convertPlainClaudeResponseToOpenAI(root)
+			return convertPlainClaudeResponseToOpenAI(root, requestRawJSON)
 		}
 	}
 
@@ -312,6 +338,7 @@ func ConvertClaudeResponseToOpenAINonStream(_ context.Context, _ string, origina
 	var contentParts []string
 	var reasoningParts []string
 	toolCallsAccumulator := make(map[int]*ToolCallAccumulator)
+	structuredBlocks := make(map[int]bool)
 
 	for _, chunk := range chunks {
 		root := gjson.ParseBytes(chunk)
@@ -336,6 +363,10 @@ func ConvertClaudeResponseToOpenAINonStream(_ context.Context, _ string, origina
 				} else if blockType == "tool_use" {
 					// Initialize tool call accumulator for this index
 					index := int(root.Get("index").Int())
+					if isStructuredOutputTool(contentBlock.Get("name").String(), requestRawJSON) {
+						structuredBlocks[index] = true
+						continue
+					}
 					toolCallsAccumulator[index] = &ToolCallAccumulator{
 						ID:   contentBlock.Get("id").String(),
 						Name: contentBlock.Get("name").String(),
@@ -362,7 +393,9 @@ func ConvertClaudeResponseToOpenAINonStream(_ context.Context, _ string, origina
 					// Accumulate tool call arguments
 					if partialJSON := delta.Get("partial_json"); partialJSON.Exists() {
 						index := int(root.Get("index").Int())
-						if accumulator, exists := toolCallsAccumulator[index]; exists {
+						if structuredBlocks[index] {
+							contentParts = append(contentParts, partialJSON.String())
+						} else if accumulator, exists := toolCallsAccumulator[index]; exists {
 							accumulator.Arguments.WriteString(partialJSON.String())
 						}
 					}
@@ -446,16 +479,30 @@ func ConvertClaudeResponseToOpenAINonStream(_ context.Context, _ string, origina
 			out, _ = sjson.SetBytes(out, "choices.0.finish_reason", mapAnthropicStopReasonToOpenAI(stopReason))
 		}
 	} else {
-		out, _ = sjson.SetBytes(out, "choices.0.finish_reason", mapAnthropicStopReasonToOpenAI(stopReason))
+		out, _ = sjson.SetBytes(out, "choices.0.finish_reason", structuredOutputFinishReason(stopReason, len(structuredBlocks) > 0))
 	}
 
 	return out
 }
 
+// isStructuredOutputTool reports whether a tool_use block answers a response_format request
+// that the request translator turned into a forced structured output tool call.
+func isStructuredOutputTool(name string, requestRawJSON []byte) bool {
+	return name == util.StructuredOutputToolName && util.ClaudeStructuredOutputRequested(requestRawJSON)
+}
+
+// structuredOutputFinishReason reports a structured output tool call as a regular stop.
+func structuredOutputFinishReason(stopReason string, structured bool) string {
+	if structured && stopReason == "tool_use" {
+		return "stop"
+	}
+	return mapAnthropicStopReasonToOpenAI(stopReason)
+}
+
 // convertPlainClaudeResponseToOpenAI converts a plain (non-SSE) Claude Messages API
 // response into OpenAI chat completion format. This handles responses from endpoints
 // like Copilot's /v1/messages when stream=false.
-func convertPlainClaudeResponseToOpenAI(root gjson.Result) []byte {
+func convertPlainClaudeResponseToOpenAI(root gjson.Result, requestRawJSON []byte) []byte {
 	out := []byte(`{"id":"","object":"chat.completion","created":0,"model":"","choices":[{"index":0,"message":{"role":"assistant","content":""},"finish_reason":"stop"}],"usage":{"prompt_tokens":0,"completion_tokens":0,"total_tokens":0}}`)
 
 	out, _ = sjson.SetBytes(out, "id", root.Get("id").String())
@@ -465,6 +512,7 @@ func convertPlainClaudeResponseToOpenAI(root gjson.Result) []byte {
 	var contentParts []string
 	var reasoningParts []string
 	toolCallsCount := 0
+	structured := false
 
 	content := root.Get("content")
 	if content.IsArray() {
@@ -476,6 +524,11 @@ func convertPlainClaudeResponseToOpenAI(root gjson.Result) []byte {
 			case "thinking":
 				reasoningParts = append(reasoningParts, block.Get("thinking").String())
 			case "tool_use":
+				if isStructuredOutputTool(block.Get("name").String(), requestRawJSON) {
+					contentParts = append(contentParts, block.Get("input").Raw)
+					structured = true
+					continue
+				}
 				idPath := fmt.Sprintf("choices.0.message.tool_calls.%d.id", toolCallsCount)
 				typePath := fmt.Sprintf("choices.0.message.tool_calls.%d.type", toolCallsCount)
 				namePath := fmt.Sprintf("choices.0.message.tool_calls.%d.function.name", toolCallsCount)
@@ -503,7 +556,7 @@ func convertPlainClaudeResponseToOpenAI(root gjson.Result) []byte {
 	if toolCallsCount > 0 {
 		out, _ = sjson.SetBytes(out, "choices.0.finish_reason", "tool_calls")
 	} else {
-		out, _ = sjson.SetBytes(out, "choices.0.finish_reason", mapAnthropicStopReasonToOpenAI(stopReason))
+		out, _ = sjson.SetBytes(out, "choices.0.finish_reason", structuredOutputFinishReason(stopReason, structured))
 	}
 
 	// Map usage
@@ -56,3 +56,54 @@ func TestConvertClaudeResponseToOpenAINonStream_UsageIncludesCachedTokens(t *tes
 		t.Fatalf("expected cached_tokens %d, got %d", 22000, gotCachedTokens)
 	}
 }
+
+func TestConvertClaudeResponseToOpenAI_StructuredOutputToolBecomesContent(t *testing.T) {
+	ctx := context.Background()
+	request := []byte(`{"tools":[{"name":"structured_output","input_schema":{"type":"object"}}],"tool_choice":{"type":"tool","name":"structured_output"}}`)
+	lines := []string{
+		`data: {"type":"message_start","message":{"id":"msg_1","model":"claude","usage":{"input_tokens":3,"output_tokens":0}}}`,
+		`data: {"type":"content_block_start","index":0,"content_block":{"type":"tool_use","id":"toolu_1","name":"structured_output","input":{}}}`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"{\"name\":"}}`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"\"red\"}"}}`,
+		`data: {"type":"content_block_stop","index":0}`,
+		`data: {"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":5}}`,
+		`data: {"type":"message_stop"}`,
+	}
+
+	var param any
+	var content string
+	finishReason := ""
+	for _, line := range lines {
+		for _, chunk := range ConvertClaudeResponseToOpenAI(ctx, "claude", nil, request, []byte(line), &param) {
+			if gjson.GetBytes(chunk, "choices.0.delta.tool_calls").Exists() {
+				t.Fatalf("unexpected tool call chunk: %s", chunk)
+			}
+			content += gjson.GetBytes(chunk, "choices.0.delta.content").String()
+			if fr := gjson.GetBytes(chunk, "choices.0.finish_reason").String(); fr != "" {
+				finishReason = fr
+			}
+		}
+	}
+	if content != `{"name":"red"}` {
+		t.Fatalf("streamed content = %q", content)
+	}
+	if finishReason != "stop" {
+		t.Fatalf("finish_reason = %q, want stop", finishReason)
+	}
+
+	var raw []byte
+	for _, line := range lines {
+		raw = append(raw, line...)
+		raw = append(raw, '\n')
+	}
+	out := ConvertClaudeResponseToOpenAINonStream(ctx, "claude", nil, request, raw, nil)
+	if got := gjson.GetBytes(out, "choices.0.message.content").String(); got != `{"name":"red"}` {
+		t.Fatalf("non-stream content = %q, body %s", got, out)
+	}
+	if got := gjson.GetBytes(out, "choices.0.finish_reason").String(); got != "stop" {
+		t.Fatalf("non-stream finish_reason = %q", got)
+	}
+	if gjson.GetBytes(out, "choices.0.message.tool_calls").Exists() {
+		t.Fatalf("unexpected tool_calls: %s", out)
+	}
+}
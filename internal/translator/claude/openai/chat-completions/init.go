@@ -16,4 +16,5 @@ func init() {
 			NonStream: ConvertClaudeResponseToOpenAINonStream,
 		},
 	)
+	translator.RegisterCapabilities(OpenAI, Claude, translator.FeatureParallelToolCalls, translator.FeatureLogprobs, translator.FeatureMultipleCandidates, translator.FeatureSeed, translator.FeaturePenalties, translator.FeatureLogitBias, translator.FeatureAudioInput, translator.FeatureAudioOutput)
 }
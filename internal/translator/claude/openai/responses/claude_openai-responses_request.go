@@ -11,6 +11,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -389,5 +390,10 @@ func ConvertOpenAIResponsesRequestToClaude(modelName string, inputRawJSON []byte
 		}
 	}
 
+	// Map text.format json_object/json_schema to forced use of the structured output tool.
+	if format, ok := util.OpenAIResponsesStructuredOutput(rawJSON); ok {
+		out = util.ApplyClaudeStructuredOutput(out, format, root.Get("tool_choice").String() == "none")
+	}
+
 	return out
 }
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -25,6 +26,8 @@ type claudeToResponsesState struct {
 	// function call bookkeeping for output aggregation
 	FuncNames   map[int]string // index -> function name
 	FuncCallIDs map[int]string // index -> call id
+	// structured output tool blocks, streamed as message text
+	StructuredBlocks map[int]bool
 	// message text aggregation
 	TextBuf strings.Builder
 	// reasoning state
@@ -92,6 +95,7 @@ func ConvertClaudeResponseToOpenAIResponses(ctx context.Context, modelName strin
 			st.FuncArgsBuf = make(map[int]*strings.Builder)
 			st.FuncNames = make(map[int]string)
 			st.FuncCallIDs = make(map[int]string)
+			st.StructuredBlocks = nil
 			st.InputTokens = 0
 			st.OutputTokens = 0
 			st.UsageSeen = false
@@ -125,8 +129,15 @@ func ConvertClaudeResponseToOpenAIResponses(ctx context.Context, modelName strin
 		}
 		idx := int(root.Get("index").Int())
 		typ := cb.Get("type").String()
-		if typ == "text" {
+		structured := typ == "tool_use" && isStructuredOutputTool(cb.Get("name").String(), requestRawJSON)
+		if typ == "text" || structured {
 			// open message item + content part
+			if structured {
+				if st.StructuredBlocks == nil {
+					st.StructuredBlocks = make(map[int]bool)
+				}
+				st.StructuredBlocks[idx] = true
+			}
 			st.InTextBlock = true
 			st.CurrentMsgID = fmt.Sprintf("msg_%s_0", st.ResponseID)
 			item := []byte(`{"type":"response.output_item.added","sequence_number":0,"output_index":0,"item":{"id":"","type":"message","status":"in_progress","content":[],"role":"assistant"}}`)
@@ -192,7 +203,17 @@ func ConvertClaudeResponseToOpenAIResponses(ctx context.Context, modelName strin
 			}
 		} else if dt == "input_json_delta" {
 			idx := int(root.Get("index").Int())
-			if pj := d.Get("partial_json"); pj.Exists() {
+			if pj := d.Get("partial_json"); pj.Exists() && st.StructuredBlocks[idx] {
+				if pj.String() == "" {
+					return out
+				}
+				msg := []byte(`{"type":"response.output_text.delta","sequence_number":0,"item_id":"","output_index":0,"content_index":0,"delta":"","logprobs":[]}`)
+				msg, _ = sjson.SetBytes(msg, "sequence_number", nextSeq())
+				msg, _ = sjson.SetBytes(msg, "item_id", st.CurrentMsgID)
+				msg, _ = sjson.SetBytes(msg, "delta", pj.String())
+				out = append(out, emitEvent("response.output_text.delta", msg))
+				st.TextBuf.WriteString(pj.String())
+			} else if pj.Exists() {
 				if st.FuncArgsBuf[idx] == nil {
 					st.FuncArgsBuf[idx] = &strings.Builder{}
 				}
@@ -432,6 +453,12 @@ func ConvertClaudeResponseToOpenAIResponses(ctx context.Context, modelName strin
 	return out
 }
 
+// isStructuredOutputTool reports whether a tool_use block answers a text.format request that
+// the request translator turned into a forced structured output tool call.
+func isStructuredOutputTool(name string, requestRawJSON []byte) bool {
+	return name == util.StructuredOutputToolName && util.ClaudeStructuredOutputRequested(requestRawJSON)
+}
+
 // ConvertClaudeResponseToOpenAIResponsesNonStream aggregates Claude SSE into a single OpenAI Responses JSON.
 func ConvertClaudeResponseToOpenAIResponsesNonStream(_ context.Context, _ string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, _ *any) []byte {
 	// Aggregate Claude SSE lines into a single OpenAI Responses JSON (non-stream)
@@ -479,6 +506,7 @@ func ConvertClaudeResponseToOpenAIResponsesNonStream(_ context.Context, _ string
 		args strings.Builder
 	}
 	toolCalls := make(map[int]*toolState)
+	structuredBlocks := make(map[int]bool)
 
 	// Walk through SSE chunks to fill state
 	for _, ch := range chunks {
@@ -506,8 +534,13 @@ func ConvertClaudeResponseToOpenAIResponsesNonStream(_ context.Context, _ string
 			case "text":
 				currentMsgID = "msg_" + responseID + "_0"
 			case "tool_use":
-				currentFCID = cb.Get("id").String()
 				name := cb.Get("name").String()
+				if isStructuredOutputTool(name, requestRawJSON) {
+					structuredBlocks[idx] = true
+					currentMsgID = "msg_" + responseID + "_0"
+					continue
+				}
+				currentFCID = cb.Get("id").String()
 				if toolCalls[idx] == nil {
 					toolCalls[idx] = &toolState{id: currentFCID, name: name}
 				} else {
@@ -533,6 +566,10 @@ func ConvertClaudeResponseToOpenAIResponsesNonStream(_ context.Context, _ string
 			case "input_json_delta":
 				if pj := d.Get("partial_json"); pj.Exists() {
 					idx := int(root.Get("index").Int())
+					if structuredBlocks[idx] {
+						textBuf.WriteString(pj.String())
+						continue
+					}
 					if toolCalls[idx] == nil {
 						toolCalls[idx] = &toolState{}
 					}
@@ -16,4 +16,5 @@ func init() {
 			NonStream: ConvertClaudeResponseToOpenAIResponsesNonStream,
 		},
 	)
+	translator.RegisterCapabilities(OpenaiResponse, Claude, translator.FeatureParallelToolCalls, translator.FeatureLogprobs, translator.FeatureAudioInput, translator.FeaturePreviousResponseID, translator.FeatureTruncation)
 }
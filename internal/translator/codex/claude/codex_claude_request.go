@@ -120,6 +120,14 @@ func ConvertClaudeRequestToCodex(modelName string, inputRawJSON []byte, _ bool)
 				hasContent = true
 			}
 
+			appendFileContent := func(filename, dataURL string) {
+				message, _ = sjson.SetBytes(message, fmt.Sprintf("content.%d.type", contentIndex), "input_file")
+				message, _ = sjson.SetBytes(message, fmt.Sprintf("content.%d.filename", contentIndex), filename)
+				message, _ = sjson.SetBytes(message, fmt.Sprintf("content.%d.file_data", contentIndex), dataURL)
+				contentIndex++
+				hasContent = true
+			}
+
 			messageContentsResult := messageResult.Get("content")
 			if messageContentsResult.IsArray() {
 				messageContentResults := messageContentsResult.Array()
@@ -149,6 +157,26 @@ func ConvertClaudeRequestToCodex(modelName string, inputRawJSON []byte, _ bool)
 								appendImageContent(dataURL)
 							}
 						}
+					case "document":
+						sourceResult := messageContentResult.Get("source")
+						switch sourceResult.Get("type").String() {
+						case "text":
+							appendTextContent(sourceResult.Get("data").String())
+						case "base64":
+							data := sourceResult.Get("data").String()
+							if data == "" {
+								break
+							}
+							mediaType := sourceResult.Get("media_type").String()
+							if mediaType == "" {
+								mediaType = "application/octet-stream"
+							}
+							filename := messageContentResult.Get("title").String()
+							if filename == "" {
+								filename = "document"
+							}
+							appendFileContent(filename, fmt.Sprintf("data:%s;base64,%s", mediaType, data))
+						}
 					case "tool_use":
 						flushMessage()
 						functionCallMessage := []byte(`{"type":"function_call"}`)
@@ -17,4 +17,5 @@ func init() {
 			TokenCount: GeminiCLITokenCount,
 		},
 	)
+	translator.RegisterCapabilities(GeminiCLI, Codex, translator.FeatureToolChoice, translator.FeatureLogprobs, translator.FeatureMultipleCandidates, translator.FeatureSeed, translator.FeatureStopSequences, translator.FeatureStructuredOutput, translator.FeatureAudioInput)
 }
@@ -17,4 +17,5 @@ func init() {
 			TokenCount: GeminiTokenCount,
 		},
 	)
+	translator.RegisterCapabilities(Gemini, Codex, translator.FeatureToolChoice, translator.FeatureLogprobs, translator.FeatureMultipleCandidates, translator.FeatureSeed, translator.FeatureStopSequences, translator.FeatureStructuredOutput, translator.FeatureAudioInput)
 }
@@ -248,6 +248,8 @@ func ConvertOpenAIRequestToCodex(modelName string, inputRawJSON []byte, stream b
 		switch rft {
 		case "text":
 			out, _ = sjson.SetBytes(out, "text.format.type", "text")
+		case "json_object":
+			out, _ = sjson.SetBytes(out, "text.format.type", "json_object")
 		case "json_schema":
 			js := rf.Get("json_schema")
 			if js.Exists() {
@@ -255,6 +257,9 @@ func ConvertOpenAIRequestToCodex(modelName string, inputRawJSON []byte, stream b
 				if v := js.Get("name"); v.Exists() {
 					out, _ = sjson.SetBytes(out, "text.format.name", v.Value())
 				}
+				if v := js.Get("description"); v.Exists() {
+					out, _ = sjson.SetBytes(out, "text.format.description", v.Value())
+				}
 				if v := js.Get("strict"); v.Exists() {
 					out, _ = sjson.SetBytes(out, "text.format.strict", v.Value())
 				}
@@ -16,4 +16,5 @@ func init() {
 			NonStream: ConvertCodexResponseToOpenAINonStream,
 		},
 	)
+	translator.RegisterCapabilities(OpenAI, Codex, translator.FeatureLogprobs, translator.FeatureMultipleCandidates, translator.FeatureSeed, translator.FeatureStopSequences, translator.FeaturePenalties, translator.FeatureLogitBias, translator.FeatureAudioInput, translator.FeatureAudioOutput)
 }
@@ -16,4 +16,5 @@ func init() {
 			NonStream: ConvertCodexResponseToOpenAIResponsesNonStream,
 		},
 	)
+	translator.RegisterCapabilities(OpenaiResponse, Codex, translator.FeatureTruncation)
 }
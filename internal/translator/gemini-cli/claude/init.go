@@ -17,4 +17,5 @@ func init() {
 			TokenCount: ClaudeTokenCount,
 		},
 	)
+	translator.RegisterCapabilities(Claude, GeminiCLI, translator.FeatureStopSequences)
 }
@@ -17,4 +17,5 @@ func init() {
 			TokenCount: GeminiTokenCount,
 		},
 	)
+	translator.RegisterCapabilities(Gemini, GeminiCLI)
 }
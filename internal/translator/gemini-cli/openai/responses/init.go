@@ -16,4 +16,5 @@ func init() {
 			NonStream: ConvertGeminiCLIResponseToOpenAIResponsesNonStream,
 		},
 	)
+	translator.RegisterCapabilities(OpenaiResponse, GeminiCLI, translator.FeatureToolChoice, translator.FeatureParallelToolCalls, translator.FeatureLogprobs, translator.FeaturePreviousResponseID, translator.FeatureTruncation)
 }
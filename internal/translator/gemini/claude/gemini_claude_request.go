@@ -118,8 +118,14 @@ func ConvertClaudeRequestToGemini(modelName string, inputRawJSON []byte, _ bool)
 						part, _ = sjson.SetBytes(part, "functionResponse.response.result", responseData)
 						contentJSON, _ = sjson.SetRawBytes(contentJSON, "parts.-1", part)
 
-					case "image":
+					case "image", "document":
 						source := contentResult.Get("source")
+						if source.Get("type").String() == "text" {
+							part := []byte(`{"text":""}`)
+							part, _ = sjson.SetBytes(part, "text", source.Get("data").String())
+							contentJSON, _ = sjson.SetRawBytes(contentJSON, "parts.-1", part)
+							return true
+						}
 						if source.Get("type").String() != "base64" {
 							return true
 						}
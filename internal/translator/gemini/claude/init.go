@@ -17,4 +17,5 @@ func init() {
 			TokenCount: ClaudeTokenCount,
 		},
 	)
+	translator.RegisterCapabilities(Claude, Gemini, translator.FeatureStopSequences)
 }
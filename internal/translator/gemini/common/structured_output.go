@@ -0,0 +1,18 @@
+package common
+
+import (
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	"github.com/tidwall/sjson"
+)
+
+// AttachStructuredOutput requests JSON output through the generation config at configPath
+// ("generationConfig" or "request.generationConfig"). JSON Schemas are reduced to the subset
+// Gemini accepts for responseSchema.
+func AttachStructuredOutput(out []byte, format util.StructuredOutput, configPath string) []byte {
+	out, _ = sjson.SetBytes(out, configPath+".responseMimeType", "application/json")
+	if format.HasSchema() {
+		schema := util.CleanJSONSchemaForGemini(string(format.Schema))
+		out, _ = sjson.SetRawBytes(out, configPath+".responseSchema", []byte(schema))
+	}
+	return out
+}
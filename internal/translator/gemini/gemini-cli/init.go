@@ -17,4 +17,5 @@ func init() {
 			TokenCount: GeminiCLITokenCount,
 		},
 	)
+	translator.RegisterCapabilities(GeminiCLI, Gemini)
 }
@@ -19,4 +19,5 @@ func init() {
 			TokenCount: GeminiTokenCount,
 		},
 	)
+	translator.RegisterCapabilities(Gemini, Gemini)
 }
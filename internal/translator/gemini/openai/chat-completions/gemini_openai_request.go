@@ -102,6 +102,11 @@ func ConvertOpenAIRequestToGemini(modelName string, inputRawJSON []byte, _ bool)
 		}
 	}
 
+	// Map OpenAI response_format json_object/json_schema -> generationConfig.responseMimeType/responseSchema
+	if format, ok := util.OpenAIChatStructuredOutput(rawJSON); ok {
+		out = common.AttachStructuredOutput(out, format, "generationConfig")
+	}
+
 	// messages -> systemInstruction + contents
 	messages := gjson.GetBytes(rawJSON, "messages")
 	if messages.IsArray() {
@@ -202,7 +207,14 @@ func ConvertOpenAIRequestToGemini(modelName string, inputRawJSON []byte, _ bool)
 							if sp := strings.Split(filename, "."); len(sp) > 1 {
 								ext = sp[len(sp)-1]
 							}
-							if mimeType, ok := misc.MimeTypes[ext]; ok {
+							mimeType, ok := misc.MimeTypes[ext]
+							// Inline data URLs carry their own media type, e.g. resolved file_id references.
+							if rest, isDataURL := strings.CutPrefix(fileData, "data:"); isDataURL {
+								if mediaType, data, isBase64 := strings.Cut(rest, ";base64,"); isBase64 && mediaType != "" {
+									mimeType, fileData, ok = mediaType, data, true
+								}
+							}
+							if ok {
 								node, _ = sjson.SetBytes(node, "parts."+itoa(p)+".inlineData.mime_type", mimeType)
 								node, _ = sjson.SetBytes(node, "parts."+itoa(p)+".inlineData.data", fileData)
 								p++
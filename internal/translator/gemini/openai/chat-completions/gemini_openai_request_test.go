@@ -0,0 +1,47 @@
+package chat_completions
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertOpenAIRequestToGemini_ResponseFormatSetsResponseSchema(t *testing.T) {
+	input := []byte(`{
+		"model": "gemini-2.5-pro",
+		"messages": [{"role": "user", "content": "Name a color"}],
+		"response_format": {
+			"type": "json_schema",
+			"json_schema": {
+				"name": "color",
+				"schema": {"type": "object", "properties": {"name": {"type": "string"}}, "required": ["name"], "additionalProperties": false}
+			}
+		}
+	}`)
+
+	out := ConvertOpenAIRequestToGemini("gemini-2.5-pro", input, false)
+
+	if got := gjson.GetBytes(out, "generationConfig.responseMimeType").String(); got != "application/json" {
+		t.Fatalf("responseMimeType = %q, want application/json", got)
+	}
+	schema := gjson.GetBytes(out, "generationConfig.responseSchema")
+	if got := schema.Get("properties.name.type").String(); got != "string" {
+		t.Fatalf("responseSchema.properties.name.type = %q, body %s", got, out)
+	}
+	if schema.Get("additionalProperties").Exists() {
+		t.Fatalf("additionalProperties should be removed for Gemini: %s", schema.Raw)
+	}
+}
+
+func TestConvertOpenAIRequestToGemini_JSONObjectSetsMimeTypeOnly(t *testing.T) {
+	input := []byte(`{"model":"gemini-2.5-pro","messages":[{"role":"user","content":"hi"}],"response_format":{"type":"json_object"}}`)
+
+	out := ConvertOpenAIRequestToGemini("gemini-2.5-pro", input, false)
+
+	if got := gjson.GetBytes(out, "generationConfig.responseMimeType").String(); got != "application/json" {
+		t.Fatalf("responseMimeType = %q, want application/json", got)
+	}
+	if gjson.GetBytes(out, "generationConfig.responseSchema").Exists() {
+		t.Fatalf("unexpected responseSchema: %s", out)
+	}
+}
@@ -16,4 +16,5 @@ func init() {
 			NonStream: ConvertGeminiResponseToOpenAINonStream,
 		},
 	)
+	translator.RegisterCapabilities(OpenAI, Gemini, translator.FeatureToolChoice, translator.FeatureParallelToolCalls, translator.FeatureLogprobs, translator.FeatureSeed, translator.FeatureStopSequences, translator.FeaturePenalties, translator.FeatureLogitBias, translator.FeatureAudioInput, translator.FeatureAudioOutput)
 }
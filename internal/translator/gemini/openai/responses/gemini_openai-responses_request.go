@@ -205,8 +205,11 @@ func ConvertOpenAIResponsesRequestToGemini(modelName string, inputRawJSON []byte
 								partJSON = []byte(`{"text":""}`)
 								partJSON, _ = sjson.SetBytes(partJSON, "text", text.String())
 							}
-						case "input_image":
+						case "input_image", "input_file":
 							imageURL := contentItem.Get("image_url").String()
+							if imageURL == "" {
+								imageURL = contentItem.Get("file_data").String()
+							}
 							if imageURL == "" {
 								imageURL = contentItem.Get("url").String()
 							}
@@ -433,6 +436,11 @@ func ConvertOpenAIResponsesRequestToGemini(modelName string, inputRawJSON []byte
 		out, _ = sjson.SetBytes(out, "generationConfig.stopSequences", sequences)
 	}
 
+	// Map Responses text.format json_object/json_schema -> generationConfig.responseMimeType/responseSchema
+	if format, ok := util.OpenAIResponsesStructuredOutput(rawJSON); ok {
+		out = common.AttachStructuredOutput(out, format, "generationConfig")
+	}
+
 	// Apply thinking configuration: convert OpenAI Responses API reasoning.effort to Gemini thinkingConfig.
 	// Inline translation-only mapping; capability checks happen later in ApplyThinking.
 	re := root.Get("reasoning.effort")
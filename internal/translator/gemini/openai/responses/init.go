@@ -16,4 +16,5 @@ func init() {
 			NonStream: ConvertGeminiResponseToOpenAIResponsesNonStream,
 		},
 	)
+	translator.RegisterCapabilities(OpenaiResponse, Gemini, translator.FeatureToolChoice, translator.FeatureParallelToolCalls, translator.FeatureLogprobs, translator.FeaturePreviousResponseID, translator.FeatureTruncation)
 }
@@ -17,4 +17,5 @@ func init() {
 			NonStream: ConvertKiroNonStreamToClaude,
 		},
 	)
+	translator.RegisterCapabilities(Claude, Kiro, translator.FeatureStopSequences, translator.FeatureTopK)
 }
@@ -17,4 +17,5 @@ func init() {
 			NonStream: ConvertKiroNonStreamToOpenAI,
 		},
 	)
+	translator.RegisterCapabilities(OpenAI, Kiro, translator.FeatureParallelToolCalls, translator.FeatureLogprobs, translator.FeatureMultipleCandidates, translator.FeatureSeed, translator.FeatureStopSequences, translator.FeaturePenalties, translator.FeatureLogitBias, translator.FeatureAudioInput, translator.FeatureAudioOutput)
 }
\ No newline at end of file
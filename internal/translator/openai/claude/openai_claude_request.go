@@ -157,7 +157,7 @@ func ConvertClaudeRequestToOpenAI(modelName string, inputRawJSON []byte, stream
 					case "redacted_thinking":
 						// Explicitly ignore redacted_thinking - never map to reasoning_content (AC2)
 
-					case "text", "image":
+					case "text", "image", "document":
 						if contentItem, ok := convertClaudeContentPart(part); ok {
 							contentItems = append(contentItems, []byte(contentItem))
 						}
@@ -374,6 +374,37 @@ func convertClaudeContentPart(part gjson.Result) (string, bool) {
 
 		return string(imageContent), true
 
+	case "document":
+		source := part.Get("source")
+		switch source.Get("type").String() {
+		case "text":
+			text := source.Get("data").String()
+			if strings.TrimSpace(text) == "" {
+				return "", false
+			}
+			textContent := []byte(`{"type":"text","text":""}`)
+			textContent, _ = sjson.SetBytes(textContent, "text", text)
+			return string(textContent), true
+		case "base64":
+			data := source.Get("data").String()
+			if data == "" {
+				return "", false
+			}
+			mediaType := source.Get("media_type").String()
+			if mediaType == "" {
+				mediaType = "application/octet-stream"
+			}
+			filename := part.Get("title").String()
+			if filename == "" {
+				filename = "document"
+			}
+			fileContent := []byte(`{"type":"file","file":{"filename":"","file_data":""}}`)
+			fileContent, _ = sjson.SetBytes(fileContent, "file.filename", filename)
+			fileContent, _ = sjson.SetBytes(fileContent, "file.file_data", "data:"+mediaType+";base64,"+data)
+			return string(fileContent), true
+		}
+		return "", false
+
 	default:
 		return "", false
 	}
@@ -696,3 +696,34 @@ func TestConvertClaudeRequestToOpenAI_AssistantThinkingToolUseThinkingSplit(t *t
 		t.Fatalf("Expected reasoning_content %q, got %q", "t1\n\nt2", got)
 	}
 }
+
+func TestConvertClaudeRequestToOpenAI_Documents(t *testing.T) {
+	inputJSON := `{
+		"model": "claude-3-opus",
+		"messages": [
+			{
+				"role": "user",
+				"content": [
+					{"type": "document", "title": "report.pdf", "source": {"type": "base64", "media_type": "application/pdf", "data": "JVBERg=="}},
+					{"type": "document", "source": {"type": "text", "media_type": "text/plain", "data": "plain notes"}}
+				]
+			}
+		]
+	}`
+
+	result := ConvertClaudeRequestToOpenAI("test-model", []byte(inputJSON), false)
+	content := gjson.GetBytes(result, "messages.0.content")
+
+	if got := content.Get("0.type").String(); got != "file" {
+		t.Fatalf("Expected first part type %q, got %q", "file", got)
+	}
+	if got := content.Get("0.file.filename").String(); got != "report.pdf" {
+		t.Fatalf("Unexpected filename: %q", got)
+	}
+	if got := content.Get("0.file.file_data").String(); got != "data:application/pdf;base64,JVBERg==" {
+		t.Fatalf("Unexpected file_data: %q", got)
+	}
+	if got := content.Get("1.text").String(); got != "plain notes" {
+		t.Fatalf("Expected text document to become text part, got %s", content.Get("1").Raw)
+	}
+}
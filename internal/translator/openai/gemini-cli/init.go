@@ -17,4 +17,5 @@ func init() {
 			TokenCount: GeminiCLITokenCount,
 		},
 	)
+	translator.RegisterCapabilities(GeminiCLI, OpenAI, translator.FeatureLogprobs, translator.FeatureSeed, translator.FeatureStructuredOutput, translator.FeatureAudioInput)
 }
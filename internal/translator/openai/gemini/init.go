@@ -17,4 +17,5 @@ func init() {
 			TokenCount: GeminiTokenCount,
 		},
 	)
+	translator.RegisterCapabilities(Gemini, OpenAI, translator.FeatureLogprobs, translator.FeatureSeed, translator.FeatureStructuredOutput, translator.FeatureAudioInput)
 }
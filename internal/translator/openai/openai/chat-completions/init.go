@@ -16,4 +16,5 @@ func init() {
 			NonStream: ConvertOpenAIResponseToOpenAINonStream,
 		},
 	)
+	translator.RegisterCapabilities(OpenAI, OpenAI)
 }
@@ -16,4 +16,5 @@ func init() {
 			NonStream: ConvertOpenAIChatCompletionsResponseToOpenAIResponsesNonStream,
 		},
 	)
+	translator.RegisterCapabilities(OpenaiResponse, OpenAI, translator.FeatureLogprobs, translator.FeatureStructuredOutput, translator.FeatureAudioInput, translator.FeaturePreviousResponseID, translator.FeatureTruncation)
 }
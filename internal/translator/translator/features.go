@@ -0,0 +1,150 @@
+package translator
+
+import (
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+// Optional request features checked by strict translation. Each translator declares the ones
+// it drops with RegisterCapabilities next to its Register call.
+const (
+	FeatureToolChoice         = "tool_choice"
+	FeatureParallelToolCalls  = "parallel_tool_calls"
+	FeatureLogprobs           = "logprobs"
+	FeatureMultipleCandidates = "n"
+	FeatureSeed               = "seed"
+	FeatureStopSequences      = "stop"
+	FeaturePenalties          = "penalties"
+	FeatureLogitBias          = "logit_bias"
+	FeatureStructuredOutput   = "structured_output"
+	FeatureAudioInput         = "audio_input"
+	FeatureAudioOutput        = "audio_output"
+	FeaturePreviousResponseID = "previous_response_id"
+	FeatureTruncation         = "truncation"
+	FeatureTopK               = "top_k"
+	FeatureThinking           = "thinking"
+)
+
+// RegisterCapabilities declares the features of from that the translator to to drops.
+func RegisterCapabilities(from, to string, dropped ...string) {
+	registry.RegisterCapabilities(sdktranslator.FromString(from), sdktranslator.FromString(to), dropped...)
+}
+
+func init() {
+	registry.RegisterFeatures(sdktranslator.FromString(constant.OpenAI),
+		sdktranslator.Feature{Name: FeatureToolChoice, Description: "tool_choice other than auto", Detect: toolChoiceAt("tool_choice")},
+		sdktranslator.Feature{Name: FeatureParallelToolCalls, Description: "parallel_tool_calls", Detect: exists("parallel_tool_calls")},
+		sdktranslator.Feature{Name: FeatureLogprobs, Description: "logprobs / top_logprobs", Detect: func(root gjson.Result) bool {
+			return root.Get("logprobs").Bool() || root.Get("top_logprobs").Exists()
+		}},
+		sdktranslator.Feature{Name: FeatureMultipleCandidates, Description: "n greater than 1", Detect: greaterThanOne("n")},
+		sdktranslator.Feature{Name: FeatureSeed, Description: "seed", Detect: exists("seed")},
+		sdktranslator.Feature{Name: FeatureStopSequences, Description: "stop", Detect: exists("stop")},
+		sdktranslator.Feature{Name: FeaturePenalties, Description: "frequency_penalty / presence_penalty", Detect: func(root gjson.Result) bool {
+			return root.Get("frequency_penalty").Float() != 0 || root.Get("presence_penalty").Float() != 0
+		}},
+		sdktranslator.Feature{Name: FeatureLogitBias, Description: "logit_bias", Detect: exists("logit_bias")},
+		sdktranslator.Feature{Name: FeatureStructuredOutput, Description: "response_format json_object / json_schema", Detect: func(root gjson.Result) bool {
+			return strings.HasPrefix(root.Get("response_format.type").String(), "json_")
+		}},
+		sdktranslator.Feature{Name: FeatureAudioInput, Description: "input_audio message parts", Detect: func(root gjson.Result) bool {
+			return anyPart(root.Get("messages"), "content", func(part gjson.Result) bool {
+				return part.Get("type").String() == "input_audio"
+			})
+		}},
+		sdktranslator.Feature{Name: FeatureAudioOutput, Description: "audio output (audio / modalities)", Detect: func(root gjson.Result) bool {
+			return root.Get("audio").Exists() || root.Get(`modalities.#(=="audio")`).Exists()
+		}},
+	)
+
+	registry.RegisterFeatures(sdktranslator.FromString(constant.OpenaiResponse),
+		sdktranslator.Feature{Name: FeatureToolChoice, Description: "tool_choice other than auto", Detect: toolChoiceAt("tool_choice")},
+		sdktranslator.Feature{Name: FeatureParallelToolCalls, Description: "parallel_tool_calls", Detect: exists("parallel_tool_calls")},
+		sdktranslator.Feature{Name: FeatureLogprobs, Description: "top_logprobs / include message.output_text.logprobs", Detect: func(root gjson.Result) bool {
+			return root.Get("top_logprobs").Exists() || root.Get(`include.#(=="message.output_text.logprobs")`).Exists()
+		}},
+		sdktranslator.Feature{Name: FeatureStructuredOutput, Description: "text.format json_object / json_schema", Detect: func(root gjson.Result) bool {
+			return strings.HasPrefix(root.Get("text.format.type").String(), "json_")
+		}},
+		sdktranslator.Feature{Name: FeatureAudioInput, Description: "input_audio content parts", Detect: func(root gjson.Result) bool {
+			return anyPart(root.Get("input"), "content", func(part gjson.Result) bool {
+				return part.Get("type").String() == "input_audio"
+			})
+		}},
+		sdktranslator.Feature{Name: FeaturePreviousResponseID, Description: "previous_response_id", Detect: exists("previous_response_id")},
+		sdktranslator.Feature{Name: FeatureTruncation, Description: "truncation", Detect: exists("truncation")},
+	)
+
+	registry.RegisterFeatures(sdktranslator.FromString(constant.Claude),
+		sdktranslator.Feature{Name: FeatureToolChoice, Description: "tool_choice other than auto", Detect: func(root gjson.Result) bool {
+			choice := root.Get("tool_choice.type").String()
+			return choice != "" && choice != "auto"
+		}},
+		sdktranslator.Feature{Name: FeatureStopSequences, Description: "stop_sequences", Detect: exists("stop_sequences")},
+		sdktranslator.Feature{Name: FeatureTopK, Description: "top_k", Detect: exists("top_k")},
+		sdktranslator.Feature{Name: FeatureThinking, Description: "thinking", Detect: func(root gjson.Result) bool {
+			return root.Get("thinking.type").Exists() && root.Get("thinking.type").String() != "disabled"
+		}},
+	)
+
+	for _, format := range []string{constant.Gemini, constant.GeminiCLI} {
+		prefix := ""
+		if format == constant.GeminiCLI {
+			prefix = "request."
+		}
+		registry.RegisterFeatures(sdktranslator.FromString(format),
+			sdktranslator.Feature{Name: FeatureToolChoice, Description: "toolConfig.functionCallingConfig", Detect: exists(prefix + "toolConfig.functionCallingConfig.mode")},
+			sdktranslator.Feature{Name: FeatureLogprobs, Description: "generationConfig.responseLogprobs / logprobs", Detect: func(root gjson.Result) bool {
+				return root.Get(prefix+"generationConfig.responseLogprobs").Bool() || root.Get(prefix+"generationConfig.logprobs").Exists()
+			}},
+			sdktranslator.Feature{Name: FeatureMultipleCandidates, Description: "generationConfig.candidateCount greater than 1", Detect: greaterThanOne(prefix + "generationConfig.candidateCount")},
+			sdktranslator.Feature{Name: FeatureSeed, Description: "generationConfig.seed", Detect: exists(prefix + "generationConfig.seed")},
+			sdktranslator.Feature{Name: FeatureStopSequences, Description: "generationConfig.stopSequences", Detect: exists(prefix + "generationConfig.stopSequences")},
+			sdktranslator.Feature{Name: FeatureStructuredOutput, Description: "generationConfig.responseSchema / responseJsonSchema", Detect: func(root gjson.Result) bool {
+				return root.Get(prefix+"generationConfig.responseSchema").Exists() || root.Get(prefix+"generationConfig.responseJsonSchema").Exists()
+			}},
+			sdktranslator.Feature{Name: FeatureAudioInput, Description: "audio inlineData parts", Detect: func(root gjson.Result) bool {
+				return anyPart(root.Get(prefix+"contents"), "parts", func(part gjson.Result) bool {
+					return strings.HasPrefix(part.Get("inlineData.mimeType").String(), "audio/")
+				})
+			}},
+			sdktranslator.Feature{Name: FeatureThinking, Description: "generationConfig.thinkingConfig", Detect: exists(prefix + "generationConfig.thinkingConfig")},
+		)
+	}
+}
+
+func exists(path string) func(gjson.Result) bool {
+	return func(root gjson.Result) bool {
+		return root.Get(path).Exists()
+	}
+}
+
+func greaterThanOne(path string) func(gjson.Result) bool {
+	return func(root gjson.Result) bool {
+		return root.Get(path).Int() > 1
+	}
+}
+
+// toolChoiceAt detects an OpenAI tool_choice that changes the default behavior.
+func toolChoiceAt(path string) func(gjson.Result) bool {
+	return func(root gjson.Result) bool {
+		choice := root.Get(path)
+		return choice.Exists() && !(choice.Type == gjson.String && choice.String() == "auto")
+	}
+}
+
+// anyPart reports whether a part in the partsKey array of any message matches.
+func anyPart(messages gjson.Result, partsKey string, match func(gjson.Result) bool) bool {
+	found := false
+	messages.ForEach(func(_, message gjson.Result) bool {
+		message.Get(partsKey).ForEach(func(_, part gjson.Result) bool {
+			found = match(part)
+			return !found
+		})
+		return !found
+	})
+	return found
+}
@@ -145,6 +145,11 @@ func (c *Client) GetUsage() (map[string]any, error) {
 	return c.getJSON("/v0/management/usage")
 }
 
+// GetModelLifecycle fetches deprecated models and upcoming retirements.
+func (c *Client) GetModelLifecycle() (map[string]any, error) {
+	return c.getJSON("/v0/management/model-lifecycle")
+}
+
 // GetAuthFiles lists auth credential files.
 // API returns {"files": [...]}.
 func (c *Client) GetAuthFiles() ([]map[string]any, error) {
@@ -25,6 +25,7 @@ type dashboardModel struct {
 	lastUsage     map[string]any
 	lastAuthFiles []map[string]any
 	lastAPIKeys   []string
+	lastLifecycle map[string]any
 }
 
 type dashboardDataMsg struct {
@@ -32,6 +33,7 @@ type dashboardDataMsg struct {
 	usage     map[string]any
 	authFiles []map[string]any
 	apiKeys   []string
+	lifecycle map[string]any
 	err       error
 }
 
@@ -58,14 +60,16 @@ func (m dashboardModel) fetchData() tea.Msg {
 			break
 		}
 	}
-	return dashboardDataMsg{config: cfg, usage: usage, authFiles: authFiles, apiKeys: apiKeys, err: err}
+	// Model lifecycle is optional: older servers do not expose it.
+	lifecycle, _ := m.client.GetModelLifecycle()
+	return dashboardDataMsg{config: cfg, usage: usage, authFiles: authFiles, apiKeys: apiKeys, lifecycle: lifecycle, err: err}
 }
 
 func (m dashboardModel) Update(msg tea.Msg) (dashboardModel, tea.Cmd) {
 	switch msg := msg.(type) {
 	case localeChangedMsg:
 		// Re-render immediately with cached data using new locale
-		m.content = m.renderDashboard(m.lastConfig, m.lastUsage, m.lastAuthFiles, m.lastAPIKeys)
+		m.content = m.renderDashboard(m.lastConfig, m.lastUsage, m.lastAuthFiles, m.lastAPIKeys, m.lastLifecycle)
 		m.viewport.SetContent(m.content)
 		// Also fetch fresh data in background
 		return m, m.fetchData
@@ -81,8 +85,9 @@ func (m dashboardModel) Update(msg tea.Msg) (dashboardModel, tea.Cmd) {
 			m.lastUsage = msg.usage
 			m.lastAuthFiles = msg.authFiles
 			m.lastAPIKeys = msg.apiKeys
+			m.lastLifecycle = msg.lifecycle
 
-			m.content = m.renderDashboard(msg.config, msg.usage, msg.authFiles, msg.apiKeys)
+			m.content = m.renderDashboard(msg.config, msg.usage, msg.authFiles, msg.apiKeys, msg.lifecycle)
 		}
 		m.viewport.SetContent(m.content)
 		return m, nil
@@ -121,7 +126,7 @@ func (m dashboardModel) View() string {
 	return m.viewport.View()
 }
 
-func (m dashboardModel) renderDashboard(cfg, usage map[string]any, authFiles []map[string]any, apiKeys []string) string {
+func (m dashboardModel) renderDashboard(cfg, usage map[string]any, authFiles []map[string]any, apiKeys []string, lifecycle map[string]any) string {
 	var sb strings.Builder
 
 	sb.WriteString(titleStyle.Render(T("dashboard_title")))
@@ -290,6 +295,31 @@ func (m dashboardModel) renderDashboard(cfg, usage map[string]any, authFiles []m
 		}
 	}
 
+	// ━━━ Upcoming Model Retirements ━━━
+	if upcoming, ok := lifecycle["upcoming"].([]any); ok && len(upcoming) > 0 {
+		sb.WriteString("\n")
+		sb.WriteString(lipgloss.NewStyle().Bold(true).Foreground(colorHighlight).Render(T("retirements")))
+		sb.WriteString("\n")
+		sb.WriteString(strings.Repeat("─", minInt(m.width, 60)))
+		sb.WriteString("\n")
+
+		header := fmt.Sprintf("  %-40s %-12s %s", T("model"), T("retire_at"), T("replacement"))
+		sb.WriteString(tableHeaderStyle.Render(header))
+		sb.WriteString("\n")
+
+		for _, entry := range upcoming {
+			if model, ok := entry.(map[string]any); ok {
+				retireAt := getString(model, "retire_at")
+				if len(retireAt) > 10 {
+					retireAt = retireAt[:10]
+				}
+				row := fmt.Sprintf("  %-40s %-12s %s", truncate(getString(model, "id"), 40), retireAt, getString(model, "replacement"))
+				sb.WriteString(tableCellStyle.Render(row))
+				sb.WriteString("\n")
+			}
+		}
+	}
+
 	return sb.String()
 }
 
@@ -116,6 +116,9 @@ var zhStrings = map[string]string{
 	"model":            "模型",
 	"requests":         "请求数",
 	"tokens":           "Tokens",
+	"retirements":      "即将下线的模型",
+	"retire_at":        "下线日期",
+	"replacement":      "替代模型",
 	"bool_yes":         "是 ✓",
 	"bool_no":          "否",
 
@@ -267,6 +270,9 @@ var enStrings = map[string]string{
 	"model":            "Model",
 	"requests":         "Requests",
 	"tokens":           "Tokens",
+	"retirements":      "Upcoming Model Retirements",
+	"retire_at":        "Retires",
+	"replacement":      "Replacement",
 	"bool_yes":         "Yes ✓",
 	"bool_no":          "No",
 
@@ -0,0 +1,490 @@
+package usage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	log "github.com/sirupsen/logrus"
+	_ "modernc.org/sqlite"
+)
+
+const (
+	defaultEventTable     = "usage_events"
+	defaultEventStoreFile = "usage_events.db"
+	defaultQueryPageSize  = 100
+	maxQueryPageSize      = 1000
+	eventInsertTimeout    = 5 * time.Second
+)
+
+const (
+	dialectSQLite   = "sqlite"
+	dialectPostgres = "postgres"
+)
+
+// eventGroupColumns maps the group-by names accepted by Query to table columns.
+var eventGroupColumns = map[string]string{
+	"key":      "api_key",
+	"model":    "model",
+	"provider": "provider",
+	"auth":     "auth_index",
+	"source":   "source",
+	"day":      "day",
+	"hour":     "hour",
+}
+
+// EventStore persists every usage record as a row in SQLite or Postgres.
+// It implements coreusage.Plugin so it can be registered as a usage sink.
+type EventStore struct {
+	db      *sql.DB
+	dialect string
+	table   string
+}
+
+// EventQuery filters and groups stored usage events.
+type EventQuery struct {
+	// From and To bound the request time as [From, To). Zero values leave the bound open.
+	From time.Time
+	To   time.Time
+	// GroupBy lists aggregation keys: key, model, provider, auth, source, day, hour.
+	// When empty, individual events are returned.
+	GroupBy  []string
+	Page     int
+	PageSize int
+}
+
+// EventQueryResult is a page of stored events or aggregated groups.
+type EventQueryResult struct {
+	Page     int          `json:"page"`
+	PageSize int          `json:"page_size"`
+	Total    int64        `json:"total"`
+	GroupBy  []string     `json:"group_by,omitempty"`
+	Groups   []EventGroup `json:"groups,omitempty"`
+	Events   []EventRow   `json:"events,omitempty"`
+}
+
+// EventGroup aggregates the events sharing the same group-by values.
+type EventGroup struct {
+	Keys           map[string]string `json:"keys"`
+	Requests       int64             `json:"requests"`
+	SuccessCount   int64             `json:"success_count"`
+	FailureCount   int64             `json:"failure_count"`
+	HedgedRequests int64             `json:"hedged_requests"`
+	AvgLatencyMs   float64           `json:"avg_latency_ms"`
+	Tokens         TokenStats        `json:"tokens"`
+}
+
+// EventRow is a single stored usage event.
+type EventRow struct {
+	ID        int64      `json:"id"`
+	Timestamp time.Time  `json:"timestamp"`
+	APIKey    string     `json:"api_key"`
+	Provider  string     `json:"provider"`
+	Model     string     `json:"model"`
+	AuthID    string     `json:"auth_id"`
+	AuthIndex string     `json:"auth_index"`
+	Source    string     `json:"source"`
+	LatencyMs int64      `json:"latency_ms"`
+	Failed    bool       `json:"failed"`
+	Hedged    bool       `json:"hedged"`
+	Tokens    TokenStats `json:"tokens"`
+}
+
+// OpenEventStore connects to the configured backend and ensures the events table exists.
+// authDir is used to place the default SQLite database file.
+func OpenEventStore(ctx context.Context, cfg config.UsageStoreConfig, authDir string) (*EventStore, error) {
+	dialect := strings.ToLower(strings.TrimSpace(cfg.Driver))
+	table := strings.TrimSpace(cfg.Table)
+	if table == "" {
+		table = defaultEventTable
+	}
+	var (
+		db  *sql.DB
+		err error
+	)
+	switch dialect {
+	case dialectSQLite, "sqlite3":
+		dialect = dialectSQLite
+		path := strings.TrimSpace(cfg.Path)
+		if path == "" {
+			path = filepath.Join(authDir, defaultEventStoreFile)
+		}
+		if errMkdir := os.MkdirAll(filepath.Dir(path), 0o700); errMkdir != nil {
+			return nil, fmt.Errorf("usage store: create directory: %w", errMkdir)
+		}
+		db, err = sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+		if err == nil {
+			// SQLite allows a single writer; serialising through one connection avoids SQLITE_BUSY.
+			db.SetMaxOpenConns(1)
+		}
+	case dialectPostgres, "postgresql", "pgx":
+		dialect = dialectPostgres
+		dsn := strings.TrimSpace(cfg.DSN)
+		if dsn == "" {
+			dsn = lookupStoreEnv("PGSTORE_DSN")
+		}
+		if dsn == "" {
+			return nil, fmt.Errorf("usage store: postgres DSN is required (set usage-store.dsn or PGSTORE_DSN)")
+		}
+		schema := strings.TrimSpace(cfg.Schema)
+		if schema == "" {
+			schema = lookupStoreEnv("PGSTORE_SCHEMA")
+		}
+		if schema != "" {
+			table = quoteIdentifier(schema) + "." + quoteIdentifier(table)
+		} else {
+			table = quoteIdentifier(table)
+		}
+		db, err = sql.Open("pgx", dsn)
+	default:
+		return nil, fmt.Errorf("usage store: unsupported driver %q", cfg.Driver)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("usage store: open database: %w", err)
+	}
+	if dialect == dialectSQLite {
+		table = quoteIdentifier(table)
+	}
+	store := &EventStore{db: db, dialect: dialect, table: table}
+	if errSchema := store.ensureSchema(ctx, cfg.Schema); errSchema != nil {
+		_ = db.Close()
+		return nil, errSchema
+	}
+	return store, nil
+}
+
+func lookupStoreEnv(key string) string {
+	if value, ok := os.LookupEnv(key); ok && strings.TrimSpace(value) != "" {
+		return strings.TrimSpace(value)
+	}
+	return strings.TrimSpace(os.Getenv(strings.ToLower(key)))
+}
+
+func quoteIdentifier(identifier string) string {
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
+
+func (s *EventStore) ensureSchema(ctx context.Context, schema string) error {
+	if err := s.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("usage store: ping database: %w", err)
+	}
+	idColumn := "id INTEGER PRIMARY KEY AUTOINCREMENT"
+	if s.dialect == dialectPostgres {
+		idColumn = "id BIGSERIAL PRIMARY KEY"
+		if schema = strings.TrimSpace(schema); schema == "" {
+			schema = lookupStoreEnv("PGSTORE_SCHEMA")
+		}
+		if schema != "" {
+			if _, err := s.db.ExecContext(ctx, "CREATE SCHEMA IF NOT EXISTS "+quoteIdentifier(schema)); err != nil {
+				return fmt.Errorf("usage store: create schema: %w", err)
+			}
+		}
+	}
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			%s,
+			requested_at BIGINT NOT NULL,
+			day TEXT NOT NULL,
+			hour TEXT NOT NULL,
+			api_key TEXT NOT NULL DEFAULT '',
+			provider TEXT NOT NULL DEFAULT '',
+			model TEXT NOT NULL DEFAULT '',
+			auth_id TEXT NOT NULL DEFAULT '',
+			auth_index TEXT NOT NULL DEFAULT '',
+			source TEXT NOT NULL DEFAULT '',
+			latency_ms BIGINT NOT NULL DEFAULT 0,
+			failed INTEGER NOT NULL DEFAULT 0,
+			hedged INTEGER NOT NULL DEFAULT 0,
+			input_tokens BIGINT NOT NULL DEFAULT 0,
+			output_tokens BIGINT NOT NULL DEFAULT 0,
+			reasoning_tokens BIGINT NOT NULL DEFAULT 0,
+			cached_tokens BIGINT NOT NULL DEFAULT 0,
+			total_tokens BIGINT NOT NULL DEFAULT 0
+		)
+	`, s.table, idColumn)); err != nil {
+		return fmt.Errorf("usage store: create table: %w", err)
+	}
+	indexName := quoteIdentifier(strings.Trim(strings.ReplaceAll(s.table, `"."`, "_"), `"`) + "_requested_at_idx")
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (requested_at)", indexName, s.table)); err != nil {
+		return fmt.Errorf("usage store: create index: %w", err)
+	}
+	return nil
+}
+
+// Close releases the database connection.
+func (s *EventStore) Close() error {
+	if s == nil || s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+// HandleUsage implements coreusage.Plugin by inserting the record as a row.
+func (s *EventStore) HandleUsage(ctx context.Context, record coreusage.Record) {
+	if s == nil || s.db == nil {
+		return
+	}
+	if record.APIKey == "" {
+		record.APIKey = resolveAPIIdentifier(ctx, record)
+	}
+	if !record.Failed {
+		record.Failed = !resolveSuccess(ctx)
+	}
+	insertCtx, cancel := context.WithTimeout(context.Background(), eventInsertTimeout)
+	defer cancel()
+	if err := s.Insert(insertCtx, record); err != nil {
+		log.Warnf("usage store: failed to record usage event: %v", err)
+	}
+}
+
+// Insert writes a single usage record.
+func (s *EventStore) Insert(ctx context.Context, record coreusage.Record) error {
+	timestamp := record.RequestedAt
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	detail := normaliseDetail(record.Detail)
+	query := fmt.Sprintf(`INSERT INTO %s (requested_at, day, hour, api_key, provider, model, auth_id, auth_index, source,
+		latency_ms, failed, hedged, input_tokens, output_tokens, reasoning_tokens, cached_tokens, total_tokens)
+		VALUES (%s)`, s.table, s.placeholders(1, 17))
+	_, err := s.db.ExecContext(ctx, query,
+		timestamp.UnixMilli(),
+		timestamp.Format("2006-01-02"),
+		timestamp.Format("2006-01-02T15"),
+		record.APIKey,
+		record.Provider,
+		record.Model,
+		record.AuthID,
+		record.AuthIndex,
+		record.Source,
+		normaliseLatency(record.Latency),
+		boolToInt(record.Failed),
+		boolToInt(record.Hedged),
+		detail.InputTokens,
+		detail.OutputTokens,
+		detail.ReasoningTokens,
+		detail.CachedTokens,
+		detail.TotalTokens,
+	)
+	return err
+}
+
+// Query returns a page of events, or of aggregated groups when GroupBy is set.
+func (s *EventStore) Query(ctx context.Context, q EventQuery) (EventQueryResult, error) {
+	if s == nil || s.db == nil {
+		return EventQueryResult{}, fmt.Errorf("usage store: not initialized")
+	}
+	if q.Page <= 0 {
+		q.Page = 1
+	}
+	if q.PageSize <= 0 {
+		q.PageSize = defaultQueryPageSize
+	}
+	if q.PageSize > maxQueryPageSize {
+		q.PageSize = maxQueryPageSize
+	}
+	result := EventQueryResult{Page: q.Page, PageSize: q.PageSize}
+
+	groupNames := make([]string, 0, len(q.GroupBy))
+	groupCols := make([]string, 0, len(q.GroupBy))
+	seen := make(map[string]struct{}, len(q.GroupBy))
+	for _, raw := range q.GroupBy {
+		name := strings.ToLower(strings.TrimSpace(raw))
+		if name == "" {
+			continue
+		}
+		column, ok := eventGroupColumns[name]
+		if !ok {
+			return EventQueryResult{}, fmt.Errorf("unsupported group_by %q", raw)
+		}
+		if _, dup := seen[name]; dup {
+			continue
+		}
+		seen[name] = struct{}{}
+		groupNames = append(groupNames, name)
+		groupCols = append(groupCols, column)
+	}
+
+	where, args := s.timeFilter(q.From, q.To)
+	offset := (q.Page - 1) * q.PageSize
+	limitClause := fmt.Sprintf(" LIMIT %d OFFSET %d", q.PageSize, offset)
+
+	if len(groupCols) == 0 {
+		if err := s.db.QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s%s", s.table, where), args...).Scan(&result.Total); err != nil {
+			return EventQueryResult{}, fmt.Errorf("usage store: count events: %w", err)
+		}
+		rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`SELECT id, requested_at, api_key, provider, model, auth_id, auth_index, source,
+			latency_ms, failed, hedged, input_tokens, output_tokens, reasoning_tokens, cached_tokens, total_tokens
+			FROM %s%s ORDER BY requested_at DESC, id DESC%s`, s.table, where, limitClause), args...)
+		if err != nil {
+			return EventQueryResult{}, fmt.Errorf("usage store: query events: %w", err)
+		}
+		defer func() { _ = rows.Close() }()
+		result.Events = make([]EventRow, 0)
+		for rows.Next() {
+			var (
+				row            EventRow
+				requestedAt    int64
+				failed, hedged int64
+			)
+			if err = rows.Scan(&row.ID, &requestedAt, &row.APIKey, &row.Provider, &row.Model, &row.AuthID, &row.AuthIndex, &row.Source,
+				&row.LatencyMs, &failed, &hedged, &row.Tokens.InputTokens, &row.Tokens.OutputTokens, &row.Tokens.ReasoningTokens,
+				&row.Tokens.CachedTokens, &row.Tokens.TotalTokens); err != nil {
+				return EventQueryResult{}, fmt.Errorf("usage store: scan event: %w", err)
+			}
+			row.Timestamp = time.UnixMilli(requestedAt)
+			row.Failed = failed != 0
+			row.Hedged = hedged != 0
+			result.Events = append(result.Events, row)
+		}
+		if err = rows.Err(); err != nil {
+			return EventQueryResult{}, fmt.Errorf("usage store: iterate events: %w", err)
+		}
+		return result, nil
+	}
+
+	result.GroupBy = groupNames
+	groupList := strings.Join(groupCols, ", ")
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM (SELECT %s FROM %s%s GROUP BY %s) grouped", groupList, s.table, where, groupList)
+	if err := s.db.QueryRowContext(ctx, countQuery, args...).Scan(&result.Total); err != nil {
+		return EventQueryResult{}, fmt.Errorf("usage store: count groups: %w", err)
+	}
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`SELECT %s, COUNT(*), COALESCE(SUM(failed), 0), COALESCE(SUM(hedged), 0),
+		COALESCE(AVG(latency_ms), 0), COALESCE(SUM(input_tokens), 0), COALESCE(SUM(output_tokens), 0),
+		COALESCE(SUM(reasoning_tokens), 0), COALESCE(SUM(cached_tokens), 0), COALESCE(SUM(total_tokens), 0)
+		FROM %s%s GROUP BY %s ORDER BY %s%s`, groupList, s.table, where, groupList, groupList, limitClause), args...)
+	if err != nil {
+		return EventQueryResult{}, fmt.Errorf("usage store: query groups: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	result.Groups = make([]EventGroup, 0)
+	for rows.Next() {
+		keys := make([]string, len(groupCols))
+		var group EventGroup
+		dest := make([]any, 0, len(groupCols)+9)
+		for i := range keys {
+			dest = append(dest, &keys[i])
+		}
+		dest = append(dest, &group.Requests, &group.FailureCount, &group.HedgedRequests, &group.AvgLatencyMs,
+			&group.Tokens.InputTokens, &group.Tokens.OutputTokens, &group.Tokens.ReasoningTokens,
+			&group.Tokens.CachedTokens, &group.Tokens.TotalTokens)
+		if err = rows.Scan(dest...); err != nil {
+			return EventQueryResult{}, fmt.Errorf("usage store: scan group: %w", err)
+		}
+		group.SuccessCount = group.Requests - group.FailureCount
+		group.Keys = make(map[string]string, len(groupNames))
+		for i, name := range groupNames {
+			group.Keys[name] = keys[i]
+		}
+		result.Groups = append(result.Groups, group)
+	}
+	if err = rows.Err(); err != nil {
+		return EventQueryResult{}, fmt.Errorf("usage store: iterate groups: %w", err)
+	}
+	return result, nil
+}
+
+func (s *EventStore) timeFilter(from, to time.Time) (string, []any) {
+	var (
+		clauses []string
+		args    []any
+	)
+	if !from.IsZero() {
+		args = append(args, from.UnixMilli())
+		clauses = append(clauses, "requested_at >= "+s.placeholder(len(args)))
+	}
+	if !to.IsZero() {
+		args = append(args, to.UnixMilli())
+		clauses = append(clauses, "requested_at < "+s.placeholder(len(args)))
+	}
+	if len(clauses) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(clauses, " AND "), args
+}
+
+func (s *EventStore) placeholder(n int) string {
+	if s.dialect == dialectPostgres {
+		return fmt.Sprintf("$%d", n)
+	}
+	return "?"
+}
+
+func (s *EventStore) placeholders(start, count int) string {
+	parts := make([]string, count)
+	for i := range parts {
+		parts[i] = s.placeholder(start + i)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func boolToInt(v bool) int {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+var (
+	activeEventStore   atomic.Pointer[EventStore]
+	eventStoreMu       sync.Mutex
+	eventSinkRegistrar sync.Once
+)
+
+// eventStoreSink forwards usage records to the active event store. It is registered once so
+// re-initialising the store never duplicates rows.
+type eventStoreSink struct{}
+
+func (eventStoreSink) HandleUsage(ctx context.Context, record coreusage.Record) {
+	if store := activeEventStore.Load(); store != nil {
+		store.HandleUsage(ctx, record)
+	}
+}
+
+// InitEventStore opens the configured durable usage store and registers it as a usage sink.
+// It is a no-op when no driver is configured.
+func InitEventStore(cfg *config.Config) error {
+	if cfg == nil || strings.TrimSpace(cfg.UsageStore.Driver) == "" {
+		return nil
+	}
+	eventStoreMu.Lock()
+	defer eventStoreMu.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	store, err := OpenEventStore(ctx, cfg.UsageStore, cfg.AuthDir)
+	if err != nil {
+		return err
+	}
+	if previous := activeEventStore.Swap(store); previous != nil {
+		_ = previous.Close()
+	}
+	eventSinkRegistrar.Do(func() {
+		coreusage.RegisterPlugin(eventStoreSink{})
+	})
+	return nil
+}
+
+// StopEventStore closes the active durable usage store.
+func StopEventStore() {
+	eventStoreMu.Lock()
+	defer eventStoreMu.Unlock()
+	if store := activeEventStore.Swap(nil); store != nil {
+		if err := store.Close(); err != nil {
+			log.Warnf("usage store: close failed: %v", err)
+		}
+	}
+}
+
+// ActiveEventStore returns the durable usage store, or nil when none is configured.
+func ActiveEventStore() *EventStore {
+	return activeEventStore.Load()
+}
@@ -0,0 +1,68 @@
+package usage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+func TestEventStore_SQLiteInsertAndQuery(t *testing.T) {
+	ctx := context.Background()
+	store, err := OpenEventStore(ctx, config.UsageStoreConfig{
+		Driver: "sqlite",
+		Path:   filepath.Join(t.TempDir(), "events.db"),
+	}, "")
+	if err != nil {
+		t.Fatalf("OpenEventStore: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	base := time.Date(2026, 3, 1, 10, 0, 0, 0, time.Local)
+	records := []coreusage.Record{
+		{APIKey: "k1", Provider: "claude", Model: "m1", RequestedAt: base, Latency: 100 * time.Millisecond, Detail: coreusage.Detail{InputTokens: 10, OutputTokens: 5}},
+		{APIKey: "k1", Provider: "claude", Model: "m1", RequestedAt: base.Add(time.Hour), Latency: 300 * time.Millisecond, Failed: true, Hedged: true},
+		{APIKey: "k2", Provider: "gemini", Model: "m2", RequestedAt: base.Add(24 * time.Hour), Detail: coreusage.Detail{TotalTokens: 7}},
+	}
+	for _, record := range records {
+		if errInsert := store.Insert(ctx, record); errInsert != nil {
+			t.Fatalf("Insert: %v", errInsert)
+		}
+	}
+
+	events, err := store.Query(ctx, EventQuery{PageSize: 2})
+	if err != nil {
+		t.Fatalf("Query events: %v", err)
+	}
+	if events.Total != 3 || len(events.Events) != 2 {
+		t.Fatalf("events total=%d len=%d, want 3/2", events.Total, len(events.Events))
+	}
+	if events.Events[0].Model != "m2" || events.Events[0].Tokens.TotalTokens != 7 {
+		t.Fatalf("expected newest event first, got %+v", events.Events[0])
+	}
+
+	grouped, err := store.Query(ctx, EventQuery{From: base, To: base.Add(2 * time.Hour), GroupBy: []string{"model", "day"}})
+	if err != nil {
+		t.Fatalf("Query groups: %v", err)
+	}
+	if grouped.Total != 1 || len(grouped.Groups) != 1 {
+		t.Fatalf("groups total=%d len=%d, want 1/1", grouped.Total, len(grouped.Groups))
+	}
+	group := grouped.Groups[0]
+	if group.Keys["model"] != "m1" || group.Keys["day"] != "2026-03-01" {
+		t.Fatalf("group keys = %v", group.Keys)
+	}
+	if group.Requests != 2 || group.SuccessCount != 1 || group.FailureCount != 1 || group.HedgedRequests != 1 {
+		t.Fatalf("group counts = %+v", group)
+	}
+	if group.AvgLatencyMs != 200 || group.Tokens.TotalTokens != 15 {
+		t.Fatalf("group latency/tokens = %v/%d, want 200/15", group.AvgLatencyMs, group.Tokens.TotalTokens)
+	}
+
+	if _, err = store.Query(ctx, EventQuery{GroupBy: []string{"bogus"}}); err == nil {
+		t.Fatalf("expected unsupported group_by to fail")
+	}
+}
@@ -67,6 +67,7 @@ type RequestStatistics struct {
 	successCount  int64
 	failureCount  int64
 	totalTokens   int64
+	hedgedCount   int64
 
 	apis map[string]*apiStats
 
@@ -74,6 +75,9 @@ type RequestStatistics struct {
 	requestsByHour map[int]int64
 	tokensByDay    map[string]int64
 	tokensByHour   map[int]int64
+
+	// promptCache tracks prompt cache reads and writes per client session.
+	promptCache map[string]*promptCacheSession
 }
 
 // apiStats holds aggregated metrics for a single API key.
@@ -85,9 +89,10 @@ type apiStats struct {
 
 // modelStats holds aggregated metrics for a specific model within an API.
 type modelStats struct {
-	TotalRequests int64
-	TotalTokens   int64
-	Details       []RequestDetail
+	TotalRequests  int64
+	TotalTokens    int64
+	HedgedRequests int64
+	Details        []RequestDetail
 }
 
 // RequestDetail stores the timestamp, latency, and token usage for a single request.
@@ -98,6 +103,7 @@ type RequestDetail struct {
 	AuthIndex string     `json:"auth_index"`
 	Tokens    TokenStats `json:"tokens"`
 	Failed    bool       `json:"failed"`
+	Hedged    bool       `json:"hedged,omitempty"`
 }
 
 // TokenStats captures the token usage breakdown for a request.
@@ -107,6 +113,10 @@ type TokenStats struct {
 	ReasoningTokens int64 `json:"reasoning_tokens"`
 	CachedTokens    int64 `json:"cached_tokens"`
 	TotalTokens     int64 `json:"total_tokens"`
+	// CacheReadTokens and CacheCreationTokens are reported by backends that split prompt cache
+	// reads from writes.
+	CacheReadTokens     int64 `json:"cache_read_tokens,omitempty"`
+	CacheCreationTokens int64 `json:"cache_creation_tokens,omitempty"`
 }
 
 // StatisticsSnapshot represents an immutable view of the aggregated metrics.
@@ -115,6 +125,8 @@ type StatisticsSnapshot struct {
 	SuccessCount  int64 `json:"success_count"`
 	FailureCount  int64 `json:"failure_count"`
 	TotalTokens   int64 `json:"total_tokens"`
+	// HedgedRequests counts speculative duplicate requests issued by the hedging policy.
+	HedgedRequests int64 `json:"hedged_requests"`
 
 	APIs map[string]APISnapshot `json:"apis"`
 
@@ -122,6 +134,9 @@ type StatisticsSnapshot struct {
 	RequestsByHour map[string]int64 `json:"requests_by_hour"`
 	TokensByDay    map[string]int64 `json:"tokens_by_day"`
 	TokensByHour   map[string]int64 `json:"tokens_by_hour"`
+
+	// PromptCache holds prompt caching statistics per client session (X-Session-Key).
+	PromptCache map[string]PromptCacheSnapshot `json:"prompt_cache,omitempty"`
 }
 
 // APISnapshot summarises metrics for a single API key.
@@ -133,9 +148,10 @@ type APISnapshot struct {
 
 // ModelSnapshot summarises metrics for a specific model.
 type ModelSnapshot struct {
-	TotalRequests int64           `json:"total_requests"`
-	TotalTokens   int64           `json:"total_tokens"`
-	Details       []RequestDetail `json:"details"`
+	TotalRequests  int64           `json:"total_requests"`
+	TotalTokens    int64           `json:"total_tokens"`
+	HedgedRequests int64           `json:"hedged_requests"`
+	Details        []RequestDetail `json:"details"`
 }
 
 var defaultRequestStatistics = NewRequestStatistics()
@@ -151,6 +167,7 @@ func NewRequestStatistics() *RequestStatistics {
 		requestsByHour: make(map[int]int64),
 		tokensByDay:    make(map[string]int64),
 		tokensByHour:   make(map[int]int64),
+		promptCache:    make(map[string]*promptCacheSession),
 	}
 }
 
@@ -194,6 +211,9 @@ func (s *RequestStatistics) Record(ctx context.Context, record coreusage.Record)
 		s.failureCount++
 	}
 	s.totalTokens += totalTokens
+	if record.Hedged {
+		s.hedgedCount++
+	}
 
 	stats, ok := s.apis[statsKey]
 	if !ok {
@@ -207,12 +227,15 @@ func (s *RequestStatistics) Record(ctx context.Context, record coreusage.Record)
 		AuthIndex: record.AuthIndex,
 		Tokens:    detail,
 		Failed:    failed,
+		Hedged:    record.Hedged,
 	})
 
 	s.requestsByDay[dayKey]++
 	s.requestsByHour[hourKey]++
 	s.tokensByDay[dayKey] += totalTokens
 	s.tokensByHour[hourKey] += totalTokens
+
+	s.recordPromptCache(record, timestamp)
 }
 
 func (s *RequestStatistics) updateAPIStats(stats *apiStats, model string, detail RequestDetail) {
@@ -225,6 +248,9 @@ func (s *RequestStatistics) updateAPIStats(stats *apiStats, model string, detail
 	}
 	modelStatsValue.TotalRequests++
 	modelStatsValue.TotalTokens += detail.Tokens.TotalTokens
+	if detail.Hedged {
+		modelStatsValue.HedgedRequests++
+	}
 	modelStatsValue.Details = append(modelStatsValue.Details, detail)
 }
 
@@ -242,6 +268,7 @@ func (s *RequestStatistics) Snapshot() StatisticsSnapshot {
 	result.SuccessCount = s.successCount
 	result.FailureCount = s.failureCount
 	result.TotalTokens = s.totalTokens
+	result.HedgedRequests = s.hedgedCount
 
 	result.APIs = make(map[string]APISnapshot, len(s.apis))
 	for apiName, stats := range s.apis {
@@ -254,9 +281,10 @@ func (s *RequestStatistics) Snapshot() StatisticsSnapshot {
 			requestDetails := make([]RequestDetail, len(modelStatsValue.Details))
 			copy(requestDetails, modelStatsValue.Details)
 			apiSnapshot.Models[modelName] = ModelSnapshot{
-				TotalRequests: modelStatsValue.TotalRequests,
-				TotalTokens:   modelStatsValue.TotalTokens,
-				Details:       requestDetails,
+				TotalRequests:  modelStatsValue.TotalRequests,
+				TotalTokens:    modelStatsValue.TotalTokens,
+				HedgedRequests: modelStatsValue.HedgedRequests,
+				Details:        requestDetails,
 			}
 		}
 		result.APIs[apiName] = apiSnapshot
@@ -284,6 +312,8 @@ func (s *RequestStatistics) Snapshot() StatisticsSnapshot {
 		result.TokensByHour[key] = v
 	}
 
+	result.PromptCache = s.promptCacheSnapshot()
+
 	return result
 }
 
@@ -371,6 +401,9 @@ func (s *RequestStatistics) recordImported(apiName, modelName string, stats *api
 		s.successCount++
 	}
 	s.totalTokens += totalTokens
+	if detail.Hedged {
+		s.hedgedCount++
+	}
 
 	s.updateAPIStats(stats, modelName, detail)
 
@@ -446,11 +479,13 @@ const httpStatusBadRequest = 400
 
 func normaliseDetail(detail coreusage.Detail) TokenStats {
 	tokens := TokenStats{
-		InputTokens:     detail.InputTokens,
-		OutputTokens:    detail.OutputTokens,
-		ReasoningTokens: detail.ReasoningTokens,
-		CachedTokens:    detail.CachedTokens,
-		TotalTokens:     detail.TotalTokens,
+		InputTokens:         detail.InputTokens,
+		OutputTokens:        detail.OutputTokens,
+		ReasoningTokens:     detail.ReasoningTokens,
+		CachedTokens:        detail.CachedTokens,
+		TotalTokens:         detail.TotalTokens,
+		CacheReadTokens:     detail.CacheReadTokens,
+		CacheCreationTokens: detail.CacheCreationTokens,
 	}
 	if tokens.TotalTokens == 0 {
 		tokens.TotalTokens = detail.InputTokens + detail.OutputTokens + detail.ReasoningTokens
@@ -602,6 +637,7 @@ func (s *RequestStatistics) Prune(cutoff time.Time) {
 	s.requestsByHour = make(map[int]int64)
 	s.tokensByDay = make(map[string]int64)
 	s.tokensByHour = make(map[int]int64)
+	s.prunePromptCache(cutoff)
 
 	// 2. Walk every API → model, drop stale details, remove empty buckets.
 	for apiName, api := range s.apis {
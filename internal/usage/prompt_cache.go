@@ -0,0 +1,104 @@
+package usage
+
+import (
+	"time"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+// maxPromptCacheSessions bounds the number of sessions tracked for prompt cache statistics; the
+// least recently seen session is dropped first.
+const maxPromptCacheSessions = 1024
+
+// promptCacheSession accumulates prompt caching usage for one client session.
+type promptCacheSession struct {
+	Requests            int64
+	InputTokens         int64
+	CacheReadTokens     int64
+	CacheCreationTokens int64
+	LastSeen            time.Time
+}
+
+// PromptCacheSnapshot summarises prompt caching for a client session (X-Session-Key).
+// HitRate is the share of prompt tokens served from the cache.
+type PromptCacheSnapshot struct {
+	Requests            int64     `json:"requests"`
+	InputTokens         int64     `json:"input_tokens"`
+	CacheReadTokens     int64     `json:"cache_read_tokens"`
+	CacheCreationTokens int64     `json:"cache_creation_tokens"`
+	HitRate             float64   `json:"hit_rate"`
+	LastSeen            time.Time `json:"last_seen"`
+}
+
+// recordPromptCache updates the session statistics of a record. Sessions are tracked once they
+// report a cache read or write, so backends without prompt caching do not show up as misses.
+// The caller must hold s.mu.
+func (s *RequestStatistics) recordPromptCache(record coreusage.Record, timestamp time.Time) {
+	if record.SessionKey == "" || record.Failed {
+		return
+	}
+	detail := record.Detail
+	session, ok := s.promptCache[record.SessionKey]
+	if !ok {
+		if detail.CacheReadTokens == 0 && detail.CacheCreationTokens == 0 {
+			return
+		}
+		if s.promptCache == nil {
+			s.promptCache = make(map[string]*promptCacheSession)
+		}
+		if len(s.promptCache) >= maxPromptCacheSessions {
+			s.evictPromptCacheSession()
+		}
+		session = &promptCacheSession{}
+		s.promptCache[record.SessionKey] = session
+	}
+	session.Requests++
+	session.InputTokens += detail.InputTokens
+	session.CacheReadTokens += detail.CacheReadTokens
+	session.CacheCreationTokens += detail.CacheCreationTokens
+	if timestamp.After(session.LastSeen) {
+		session.LastSeen = timestamp
+	}
+}
+
+func (s *RequestStatistics) evictPromptCacheSession() {
+	oldestKey := ""
+	var oldest time.Time
+	for key, session := range s.promptCache {
+		if oldestKey == "" || session.LastSeen.Before(oldest) {
+			oldestKey, oldest = key, session.LastSeen
+		}
+	}
+	delete(s.promptCache, oldestKey)
+}
+
+// prunePromptCache drops sessions not seen since cutoff. The caller must hold s.mu.
+func (s *RequestStatistics) prunePromptCache(cutoff time.Time) {
+	for key, session := range s.promptCache {
+		if session.LastSeen.Before(cutoff) {
+			delete(s.promptCache, key)
+		}
+	}
+}
+
+// promptCacheSnapshot copies the session statistics. The caller must hold s.mu.
+func (s *RequestStatistics) promptCacheSnapshot() map[string]PromptCacheSnapshot {
+	if len(s.promptCache) == 0 {
+		return nil
+	}
+	out := make(map[string]PromptCacheSnapshot, len(s.promptCache))
+	for key, session := range s.promptCache {
+		snapshot := PromptCacheSnapshot{
+			Requests:            session.Requests,
+			InputTokens:         session.InputTokens,
+			CacheReadTokens:     session.CacheReadTokens,
+			CacheCreationTokens: session.CacheCreationTokens,
+			LastSeen:            session.LastSeen,
+		}
+		if prompt := session.InputTokens + session.CacheReadTokens + session.CacheCreationTokens; prompt > 0 {
+			snapshot.HitRate = float64(session.CacheReadTokens) / float64(prompt)
+		}
+		out[key] = snapshot
+	}
+	return out
+}
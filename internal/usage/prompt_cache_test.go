@@ -0,0 +1,38 @@
+package usage
+
+import (
+	"testing"
+	"time"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+func TestPromptCacheSessionHitRate(t *testing.T) {
+	s := NewRequestStatistics()
+	now := time.Now()
+	record := func(session string, detail coreusage.Detail) {
+		s.Record(nil, coreusage.Record{APIKey: "k", Model: "claude-sonnet-4", RequestedAt: now, SessionKey: session, Detail: detail})
+	}
+
+	record("agent-1", coreusage.Detail{InputTokens: 100, CacheCreationTokens: 900})
+	record("agent-1", coreusage.Detail{InputTokens: 100, CacheReadTokens: 900})
+	record("agent-2", coreusage.Detail{InputTokens: 500})
+	record("", coreusage.Detail{InputTokens: 10, CacheReadTokens: 90})
+
+	snap := s.Snapshot()
+	if len(snap.PromptCache) != 1 {
+		t.Fatalf("expected one tracked session, got %+v", snap.PromptCache)
+	}
+	session := snap.PromptCache["agent-1"]
+	if session.Requests != 2 || session.CacheReadTokens != 900 || session.CacheCreationTokens != 900 {
+		t.Errorf("session = %+v", session)
+	}
+	if session.HitRate != 0.45 {
+		t.Errorf("hit rate = %v, want 0.45", session.HitRate)
+	}
+
+	s.Prune(now.Add(time.Minute))
+	if snap = s.Snapshot(); len(snap.PromptCache) != 0 {
+		t.Errorf("expected pruned sessions, got %+v", snap.PromptCache)
+	}
+}
@@ -0,0 +1,324 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tidwall/gjson"
+)
+
+// maxSchemaViolations bounds the violations reported by ValidateJSONSchema so repair prompts
+// and error messages stay readable.
+const maxSchemaViolations = 20
+
+// ValidateJSONSchema checks document against schema and returns the violations found, each
+// prefixed with the JSON path of the offending value. An empty result means the document
+// conforms. It covers the JSON Schema subset accepted by OpenAI structured outputs: type,
+// enum, const, properties, required, additionalProperties, items, prefixItems, anyOf, oneOf,
+// allOf, not, local $ref, and the string, number, array and object size keywords. Unknown
+// keywords are ignored.
+func ValidateJSONSchema(schema, document []byte) []string {
+	if !gjson.ValidBytes(document) {
+		return []string{"$: output is not valid JSON"}
+	}
+	root := gjson.ParseBytes(schema)
+	v := &schemaValidator{root: root}
+	v.validate(root, gjson.ParseBytes(document), "$", 0)
+	return v.violations
+}
+
+type schemaValidator struct {
+	root       gjson.Result
+	violations []string
+}
+
+// maxSchemaDepth stops recursive $ref chains from looping forever.
+const maxSchemaDepth = 64
+
+func (v *schemaValidator) fail(path, format string, args ...any) {
+	if len(v.violations) < maxSchemaViolations {
+		v.violations = append(v.violations, path+": "+fmt.Sprintf(format, args...))
+	}
+}
+
+// matches reports whether value conforms to schema without recording violations.
+func (v *schemaValidator) matches(schema, value gjson.Result, depth int) bool {
+	sub := &schemaValidator{root: v.root}
+	sub.validate(schema, value, "$", depth)
+	return len(sub.violations) == 0
+}
+
+func (v *schemaValidator) validate(schema, value gjson.Result, path string, depth int) {
+	if depth > maxSchemaDepth {
+		v.fail(path, "schema nesting is too deep")
+		return
+	}
+	switch schema.Type {
+	case gjson.True:
+		return
+	case gjson.False:
+		v.fail(path, "no value is allowed here")
+		return
+	}
+	if !schema.IsObject() {
+		return
+	}
+
+	if ref := schema.Get(`\$ref`); ref.Exists() {
+		target, ok := v.resolveRef(ref.String())
+		if !ok {
+			v.fail(path, "unresolvable $ref %q", ref.String())
+			return
+		}
+		v.validate(target, value, path, depth+1)
+	}
+
+	if types := schema.Get("type"); types.Exists() {
+		allowed := types.Array()
+		if !types.IsArray() {
+			allowed = []gjson.Result{types}
+		}
+		ok := false
+		names := make([]string, 0, len(allowed))
+		for _, t := range allowed {
+			names = append(names, t.String())
+			if jsonTypeMatches(t.String(), value) {
+				ok = true
+			}
+		}
+		if !ok {
+			v.fail(path, "expected %s, got %s", strings.Join(names, " or "), jsonTypeName(value))
+			return
+		}
+	}
+
+	if enum := schema.Get("enum"); enum.IsArray() {
+		found := false
+		for _, candidate := range enum.Array() {
+			if jsonEqual(candidate, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.fail(path, "value %s is not one of %s", value.Raw, enum.Raw)
+		}
+	}
+	if constant := schema.Get("const"); constant.Exists() && !jsonEqual(constant, value) {
+		v.fail(path, "value %s does not equal %s", value.Raw, constant.Raw)
+	}
+
+	for _, sub := range schema.Get("allOf").Array() {
+		v.validate(sub, value, path, depth+1)
+	}
+	if anyOf := schema.Get("anyOf"); anyOf.IsArray() {
+		matched := false
+		for _, sub := range anyOf.Array() {
+			if v.matches(sub, value, depth+1) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			v.fail(path, "value does not match any schema in anyOf")
+		}
+	}
+	if oneOf := schema.Get("oneOf"); oneOf.IsArray() {
+		count := 0
+		for _, sub := range oneOf.Array() {
+			if v.matches(sub, value, depth+1) {
+				count++
+			}
+		}
+		if count != 1 {
+			v.fail(path, "value matches %d schemas in oneOf, expected exactly 1", count)
+		}
+	}
+	if not := schema.Get("not"); not.Exists() && v.matches(not, value, depth+1) {
+		v.fail(path, "value must not match the schema in not")
+	}
+
+	switch {
+	case value.IsObject():
+		v.validateObject(schema, value, path, depth)
+	case value.IsArray():
+		v.validateArray(schema, value, path, depth)
+	case value.Type == gjson.String:
+		v.validateString(schema, value, path)
+	case value.Type == gjson.Number:
+		v.validateNumber(schema, value, path)
+	}
+}
+
+func (v *schemaValidator) validateObject(schema, value gjson.Result, path string, depth int) {
+	fields := value.Map()
+	for _, name := range schema.Get("required").Array() {
+		if _, ok := fields[name.String()]; !ok {
+			v.fail(path, "missing required property %q", name.String())
+		}
+	}
+	if limit := schema.Get("minProperties"); limit.Exists() && int64(len(fields)) < limit.Int() {
+		v.fail(path, "expected at least %d properties, got %d", limit.Int(), len(fields))
+	}
+	if limit := schema.Get("maxProperties"); limit.Exists() && int64(len(fields)) > limit.Int() {
+		v.fail(path, "expected at most %d properties, got %d", limit.Int(), len(fields))
+	}
+
+	properties := schema.Get("properties")
+	additional := schema.Get("additionalProperties")
+	value.ForEach(func(key, field gjson.Result) bool {
+		name := key.String()
+		fieldPath := path + "." + name
+		if prop := properties.Get(gjson.Escape(name)); properties.IsObject() && prop.Exists() {
+			v.validate(prop, field, fieldPath, depth+1)
+			return true
+		}
+		switch {
+		case additional.Type == gjson.False:
+			v.fail(path, "unexpected property %q", name)
+		case additional.IsObject():
+			v.validate(additional, field, fieldPath, depth+1)
+		}
+		return true
+	})
+}
+
+func (v *schemaValidator) validateArray(schema, value gjson.Result, path string, depth int) {
+	items := value.Array()
+	if limit := schema.Get("minItems"); limit.Exists() && int64(len(items)) < limit.Int() {
+		v.fail(path, "expected at least %d items, got %d", limit.Int(), len(items))
+	}
+	if limit := schema.Get("maxItems"); limit.Exists() && int64(len(items)) > limit.Int() {
+		v.fail(path, "expected at most %d items, got %d", limit.Int(), len(items))
+	}
+	if schema.Get("uniqueItems").Bool() {
+		for i := range items {
+			for j := i + 1; j < len(items); j++ {
+				if jsonEqual(items[i], items[j]) {
+					v.fail(path, "items %d and %d are equal", i, j)
+				}
+			}
+		}
+	}
+	prefix := schema.Get("prefixItems").Array()
+	itemSchema := schema.Get("items")
+	for i, item := range items {
+		itemPath := fmt.Sprintf("%s[%d]", path, i)
+		if i < len(prefix) {
+			v.validate(prefix[i], item, itemPath, depth+1)
+			continue
+		}
+		if itemSchema.Exists() {
+			v.validate(itemSchema, item, itemPath, depth+1)
+		}
+	}
+}
+
+func (v *schemaValidator) validateString(schema, value gjson.Result, path string) {
+	length := int64(utf8.RuneCountInString(value.String()))
+	if limit := schema.Get("minLength"); limit.Exists() && length < limit.Int() {
+		v.fail(path, "expected at least %d characters, got %d", limit.Int(), length)
+	}
+	if limit := schema.Get("maxLength"); limit.Exists() && length > limit.Int() {
+		v.fail(path, "expected at most %d characters, got %d", limit.Int(), length)
+	}
+	if pattern := schema.Get("pattern"); pattern.Exists() {
+		re, err := regexp.Compile(pattern.String())
+		if err == nil && !re.MatchString(value.String()) {
+			v.fail(path, "value %s does not match pattern %q", value.Raw, pattern.String())
+		}
+	}
+}
+
+func (v *schemaValidator) validateNumber(schema, value gjson.Result, path string) {
+	n := value.Float()
+	if limit := schema.Get("minimum"); limit.Exists() && n < limit.Float() {
+		v.fail(path, "value %s is less than minimum %s", value.Raw, limit.Raw)
+	}
+	if limit := schema.Get("maximum"); limit.Exists() && n > limit.Float() {
+		v.fail(path, "value %s is greater than maximum %s", value.Raw, limit.Raw)
+	}
+	if limit := schema.Get("exclusiveMinimum"); limit.Type == gjson.Number && n <= limit.Float() {
+		v.fail(path, "value %s must be greater than %s", value.Raw, limit.Raw)
+	}
+	if limit := schema.Get("exclusiveMaximum"); limit.Type == gjson.Number && n >= limit.Float() {
+		v.fail(path, "value %s must be less than %s", value.Raw, limit.Raw)
+	}
+	if step := schema.Get("multipleOf"); step.Exists() && step.Float() > 0 {
+		if q := n / step.Float(); math.Abs(q-math.Round(q)) > 1e-9 {
+			v.fail(path, "value %s is not a multiple of %s", value.Raw, step.Raw)
+		}
+	}
+}
+
+// resolveRef resolves a local JSON pointer such as "#/$defs/Item" against the root schema.
+func (v *schemaValidator) resolveRef(ref string) (gjson.Result, bool) {
+	if ref == "#" {
+		return v.root, true
+	}
+	pointer, ok := strings.CutPrefix(ref, "#/")
+	if !ok {
+		return gjson.Result{}, false
+	}
+	current := v.root
+	for _, token := range strings.Split(pointer, "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		current = current.Get(gjson.Escape(token))
+		if !current.Exists() {
+			return gjson.Result{}, false
+		}
+	}
+	return current, true
+}
+
+func jsonTypeMatches(name string, value gjson.Result) bool {
+	switch name {
+	case "object":
+		return value.IsObject()
+	case "array":
+		return value.IsArray()
+	case "string":
+		return value.Type == gjson.String
+	case "number":
+		return value.Type == gjson.Number
+	case "integer":
+		return value.Type == gjson.Number && value.Float() == math.Trunc(value.Float())
+	case "boolean":
+		return value.IsBool()
+	case "null":
+		return value.Type == gjson.Null
+	}
+	return false
+}
+
+func jsonTypeName(value gjson.Result) string {
+	switch {
+	case value.IsObject():
+		return "object"
+	case value.IsArray():
+		return "array"
+	case value.IsBool():
+		return "boolean"
+	}
+	switch value.Type {
+	case gjson.String:
+		return "string"
+	case gjson.Number:
+		return "number"
+	}
+	return "null"
+}
+
+// jsonEqual compares two JSON values structurally, ignoring key order and formatting.
+func jsonEqual(a, b gjson.Result) bool {
+	var left, right any
+	if json.Unmarshal([]byte(a.Raw), &left) != nil || json.Unmarshal([]byte(b.Raw), &right) != nil {
+		return false
+	}
+	return reflect.DeepEqual(left, right)
+}
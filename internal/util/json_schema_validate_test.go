@@ -0,0 +1,48 @@
+package util
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateJSONSchema(t *testing.T) {
+	schema := `{
+		"type": "object",
+		"properties": {
+			"name": {"type": "string", "minLength": 2},
+			"age": {"type": "integer", "minimum": 0},
+			"tags": {"type": "array", "items": {"type": "string"}, "maxItems": 2},
+			"kind": {"enum": ["a", "b"]},
+			"owner": {"$ref": "#/$defs/owner"},
+			"note": {"anyOf": [{"type": "string"}, {"type": "null"}]}
+		},
+		"required": ["name", "age"],
+		"additionalProperties": false,
+		"$defs": {"owner": {"type": "object", "properties": {"id": {"type": "string"}}, "required": ["id"]}}
+	}`
+
+	tests := []struct {
+		name     string
+		document string
+		want     []string
+	}{
+		{name: "valid", document: `{"name":"Al","age":3,"tags":["x"],"kind":"a","owner":{"id":"o1"},"note":null}`},
+		{name: "not json", document: `{"name":`, want: []string{"$: output is not valid JSON"}},
+		{name: "missing required", document: `{"name":"Al"}`, want: []string{`$: missing required property "age"`}},
+		{name: "wrong type", document: `{"name":"Al","age":1.5}`, want: []string{"$.age: expected integer, got number"}},
+		{name: "additional property", document: `{"name":"Al","age":1,"extra":true}`, want: []string{`$: unexpected property "extra"`}},
+		{name: "enum", document: `{"name":"Al","age":1,"kind":"c"}`, want: []string{`$.kind: value "c" is not one of ["a", "b"]`}},
+		{name: "array items", document: `{"name":"Al","age":1,"tags":["x",2,"z"]}`, want: []string{"$.tags: expected at most 2 items, got 3", "$.tags[1]: expected string, got number"}},
+		{name: "ref", document: `{"name":"Al","age":1,"owner":{}}`, want: []string{`$.owner: missing required property "id"`}},
+		{name: "anyOf", document: `{"name":"Al","age":1,"note":5}`, want: []string{"$.note: value does not match any schema in anyOf"}},
+		{name: "min length", document: `{"name":"A","age":1}`, want: []string{"$.name: expected at least 2 characters, got 1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ValidateJSONSchema([]byte(schema), []byte(tt.document))
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Fatalf("violations = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ package util
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/proxyutil"
@@ -13,13 +14,14 @@ import (
 
 // SetProxy configures the provided HTTP client with proxy settings from the configuration.
 // It supports SOCKS5, HTTP, and HTTPS proxies. The function modifies the client's transport
-// to route requests through the configured proxy server.
+// to route requests through the configured proxy server and applies the global upstream TLS
+// settings (custom CA bundles, client certificates, minimum version).
 func SetProxy(cfg *config.SDKConfig, httpClient *http.Client) *http.Client {
 	if cfg == nil || httpClient == nil {
 		return httpClient
 	}
 
-	transport, _, errBuild := proxyutil.BuildHTTPTransport(cfg.ProxyURL)
+	transport, _, errBuild := proxyutil.BuildHTTPTransportWithTLS(cfg.ProxyURL, UpstreamTLSOptions(cfg.UpstreamTLS))
 	if errBuild != nil {
 		log.Errorf("%v", errBuild)
 	}
@@ -28,3 +30,33 @@ func SetProxy(cfg *config.SDKConfig, httpClient *http.Client) *http.Client {
 	}
 	return httpClient
 }
+
+// UpstreamTLSOptions converts configured upstream TLS settings into proxyutil options.
+func UpstreamTLSOptions(tlsCfg config.UpstreamTLS) proxyutil.TLSOptions {
+	return proxyutil.TLSOptions{
+		CAFiles:        tlsCfg.CAFiles,
+		ClientCertFile: tlsCfg.ClientCert,
+		ClientKeyFile:  tlsCfg.ClientKey,
+		ServerName:     tlsCfg.ServerName,
+		MinVersion:     tlsCfg.MinVersion,
+	}
+}
+
+// UpstreamTLSFromAttrs extracts per-credential upstream TLS overrides stored in auth attributes.
+// CA files are stored newline-separated under "tls_ca_files".
+func UpstreamTLSFromAttrs(attrs map[string]string) config.UpstreamTLS {
+	var out config.UpstreamTLS
+	if len(attrs) == 0 {
+		return out
+	}
+	for _, file := range strings.Split(attrs["tls_ca_files"], "\n") {
+		if trimmed := strings.TrimSpace(file); trimmed != "" {
+			out.CAFiles = append(out.CAFiles, trimmed)
+		}
+	}
+	out.ClientCert = strings.TrimSpace(attrs["tls_client_cert"])
+	out.ClientKey = strings.TrimSpace(attrs["tls_client_key"])
+	out.ServerName = strings.TrimSpace(attrs["tls_server_name"])
+	out.MinVersion = strings.TrimSpace(attrs["tls_min_version"])
+	return out
+}
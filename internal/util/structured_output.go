@@ -0,0 +1,116 @@
+package util
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// StructuredOutputToolName is the tool Claude is forced to call when a client requests JSON output.
+// Response translators turn its input back into message text.
+const StructuredOutputToolName = "structured_output"
+
+// StructuredOutput is a JSON output format requested by a client through Chat Completions
+// response_format or Responses text.format.
+type StructuredOutput struct {
+	Name        string
+	Description string
+	// Schema is the raw JSON Schema; it is nil for json_object requests.
+	Schema []byte
+	Strict bool
+}
+
+// HasSchema reports whether the output must follow a JSON Schema rather than be any JSON object.
+func (s StructuredOutput) HasSchema() bool {
+	return len(s.Schema) > 0
+}
+
+// OpenAIChatStructuredOutput returns the JSON output format of a Chat Completions request.
+func OpenAIChatStructuredOutput(rawJSON []byte) (StructuredOutput, bool) {
+	format := gjson.GetBytes(rawJSON, "response_format")
+	switch format.Get("type").String() {
+	case "json_object":
+		return StructuredOutput{}, true
+	case "json_schema":
+		js := format.Get("json_schema")
+		return structuredOutputFrom(js.Get("name"), js.Get("description"), js.Get("schema"), js.Get("strict")), true
+	}
+	return StructuredOutput{}, false
+}
+
+// OpenAIResponsesStructuredOutput returns the JSON output format of a Responses request.
+func OpenAIResponsesStructuredOutput(rawJSON []byte) (StructuredOutput, bool) {
+	format := gjson.GetBytes(rawJSON, "text.format")
+	switch format.Get("type").String() {
+	case "json_object":
+		return StructuredOutput{}, true
+	case "json_schema":
+		return structuredOutputFrom(format.Get("name"), format.Get("description"), format.Get("schema"), format.Get("strict")), true
+	}
+	return StructuredOutput{}, false
+}
+
+func structuredOutputFrom(name, description, schema, strict gjson.Result) StructuredOutput {
+	out := StructuredOutput{
+		Name:        name.String(),
+		Description: description.String(),
+		Strict:      strict.Bool(),
+	}
+	if schema.IsObject() {
+		out.Schema = []byte(schema.Raw)
+	}
+	return out
+}
+
+var claudeToolNameInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// ApplyClaudeStructuredOutput forces a Claude Messages request to answer through the
+// structured_output tool whose input schema is the requested format. When the client also
+// declared its own tools the model may call any tool, so those calls still reach the client.
+func ApplyClaudeStructuredOutput(claudeRequest []byte, format StructuredOutput, toolChoiceNone bool) []byte {
+	schema := format.Schema
+	if !format.HasSchema() {
+		schema = []byte(`{"type":"object"}`)
+	}
+	description := "Respond to the user by calling this tool with the complete answer as its input."
+	if name := strings.TrimSpace(claudeToolNameInvalidChars.ReplaceAllString(format.Name, "_")); name != "" {
+		description += " The answer is a " + name + " object."
+	}
+	if format.Description != "" {
+		description += " " + format.Description
+	}
+	tool := []byte(`{"name":"","description":"","input_schema":{}}`)
+	tool, _ = sjson.SetBytes(tool, "name", StructuredOutputToolName)
+	tool, _ = sjson.SetBytes(tool, "description", description)
+	tool, _ = sjson.SetRawBytes(tool, "input_schema", schema)
+
+	hasClientTools := gjson.GetBytes(claudeRequest, "tools.#").Int() > 0
+	if toolChoiceNone && hasClientTools {
+		claudeRequest, _ = sjson.DeleteBytes(claudeRequest, "tools")
+		hasClientTools = false
+	}
+	claudeRequest, _ = sjson.SetRawBytes(claudeRequest, "tools.-1", tool)
+	switch {
+	case hasClientTools && gjson.GetBytes(claudeRequest, "tool_choice.type").String() == "tool":
+		// The client forced one of its own tools; keep that choice.
+	case hasClientTools:
+		claudeRequest, _ = sjson.SetRawBytes(claudeRequest, "tool_choice", []byte(`{"type":"any"}`))
+	default:
+		choice, _ := sjson.SetBytes([]byte(`{"type":"tool"}`), "name", StructuredOutputToolName)
+		claudeRequest, _ = sjson.SetRawBytes(claudeRequest, "tool_choice", choice)
+	}
+	return claudeRequest
+}
+
+// ClaudeStructuredOutputRequested reports whether a translated Claude request carries the
+// structured_output tool added by ApplyClaudeStructuredOutput.
+func ClaudeStructuredOutputRequested(claudeRequest []byte) bool {
+	found := false
+	gjson.GetBytes(claudeRequest, "tools").ForEach(func(_, tool gjson.Result) bool {
+		found = tool.Get("name").String() == StructuredOutputToolName
+		return !found
+	})
+	return found
+}
@@ -39,6 +39,15 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.UsageStatisticsEnabled != newCfg.UsageStatisticsEnabled {
 		changes = append(changes, fmt.Sprintf("usage-statistics-enabled: %t -> %t", oldCfg.UsageStatisticsEnabled, newCfg.UsageStatisticsEnabled))
 	}
+	if oldCfg.UsageStore.Driver != newCfg.UsageStore.Driver {
+		changes = append(changes, fmt.Sprintf("usage-store.driver: %s -> %s (restart required)", oldCfg.UsageStore.Driver, newCfg.UsageStore.Driver))
+	}
+	if oldCfg.StructuredRequestLog.Enable != newCfg.StructuredRequestLog.Enable {
+		changes = append(changes, fmt.Sprintf("structured-request-log.enable: %t -> %t (restart required)", oldCfg.StructuredRequestLog.Enable, newCfg.StructuredRequestLog.Enable))
+	}
+	if oldCfg.CacheStore.Driver != newCfg.CacheStore.Driver {
+		changes = append(changes, fmt.Sprintf("cache-store.driver: %s -> %s (restart required)", oldCfg.CacheStore.Driver, newCfg.CacheStore.Driver))
+	}
 	if oldCfg.DisableCooling != newCfg.DisableCooling {
 		changes = append(changes, fmt.Sprintf("disable-cooling: %t -> %t", oldCfg.DisableCooling, newCfg.DisableCooling))
 	}
@@ -63,6 +72,15 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.ProxyURL != newCfg.ProxyURL {
 		changes = append(changes, fmt.Sprintf("proxy-url: %s -> %s", formatProxyURL(oldCfg.ProxyURL), formatProxyURL(newCfg.ProxyURL)))
 	}
+	if !reflect.DeepEqual(oldCfg.UpstreamTLS, newCfg.UpstreamTLS) {
+		changes = append(changes, "upstream-tls: updated")
+	}
+	if !reflect.DeepEqual(oldCfg.CORS, newCfg.CORS) {
+		changes = append(changes, "cors: updated")
+	}
+	if oldCfg.BrowserAuth != newCfg.BrowserAuth {
+		changes = append(changes, "browser-auth: updated")
+	}
 	if oldCfg.WebsocketAuth != newCfg.WebsocketAuth {
 		changes = append(changes, fmt.Sprintf("ws-auth: %t -> %t", oldCfg.WebsocketAuth, newCfg.WebsocketAuth))
 	}
@@ -72,6 +90,40 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.NonStreamKeepAliveInterval != newCfg.NonStreamKeepAliveInterval {
 		changes = append(changes, fmt.Sprintf("nonstream-keepalive-interval: %d -> %d", oldCfg.NonStreamKeepAliveInterval, newCfg.NonStreamKeepAliveInterval))
 	}
+	if len(oldCfg.MCP.Servers) != len(newCfg.MCP.Servers) {
+		changes = append(changes, fmt.Sprintf("mcp.servers count: %d -> %d", len(oldCfg.MCP.Servers), len(newCfg.MCP.Servers)))
+	} else if !reflect.DeepEqual(oldCfg.MCP.Servers, newCfg.MCP.Servers) {
+		changes = append(changes, "mcp.servers: updated")
+	}
+	if oldCfg.MCP.MaxIterations != newCfg.MCP.MaxIterations {
+		changes = append(changes, fmt.Sprintf("mcp.max-iterations: %d -> %d", oldCfg.MCP.MaxIterations, newCfg.MCP.MaxIterations))
+	}
+	if len(oldCfg.FanOut) != len(newCfg.FanOut) {
+		changes = append(changes, fmt.Sprintf("fan-out count: %d -> %d", len(oldCfg.FanOut), len(newCfg.FanOut)))
+	} else if !reflect.DeepEqual(oldCfg.FanOut, newCfg.FanOut) {
+		changes = append(changes, "fan-out: updated")
+	}
+	if oldCfg.StructuredOutput.Validate != newCfg.StructuredOutput.Validate {
+		changes = append(changes, fmt.Sprintf("structured-output.validate: %t -> %t", oldCfg.StructuredOutput.Validate, newCfg.StructuredOutput.Validate))
+	}
+	if oldCfg.StructuredOutput.RepairRetries != newCfg.StructuredOutput.RepairRetries {
+		changes = append(changes, fmt.Sprintf("structured-output.repair-retries: %d -> %d", oldCfg.StructuredOutput.RepairRetries, newCfg.StructuredOutput.RepairRetries))
+	}
+	if oldCfg.StrictTranslation.Mode != newCfg.StrictTranslation.Mode {
+		changes = append(changes, fmt.Sprintf("strict-translation.mode: %s -> %s", oldCfg.StrictTranslation.Mode, newCfg.StrictTranslation.Mode))
+	}
+	if !reflect.DeepEqual(oldCfg.StrictTranslation.APIKeys, newCfg.StrictTranslation.APIKeys) {
+		changes = append(changes, "strict-translation.api-keys: updated")
+	}
+	if !reflect.DeepEqual(oldCfg.Hooks, newCfg.Hooks) {
+		changes = append(changes, fmt.Sprintf("hooks: updated (%d -> %d entries)", len(oldCfg.Hooks), len(newCfg.Hooks)))
+	}
+	if oldCfg.ModelLifecycle.RetiredModels != newCfg.ModelLifecycle.RetiredModels {
+		changes = append(changes, fmt.Sprintf("model-lifecycle.retired-models: %s -> %s", oldCfg.ModelLifecycle.RetiredModels, newCfg.ModelLifecycle.RetiredModels))
+	}
+	if oldCfg.ModelLifecycle.WarnDays != newCfg.ModelLifecycle.WarnDays {
+		changes = append(changes, fmt.Sprintf("model-lifecycle.warn-days: %d -> %d", oldCfg.ModelLifecycle.WarnDays, newCfg.ModelLifecycle.WarnDays))
+	}
 
 	// Quota-exceeded behavior
 	if oldCfg.QuotaExceeded.SwitchProject != newCfg.QuotaExceeded.SwitchProject {
@@ -81,9 +133,43 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 		changes = append(changes, fmt.Sprintf("quota-exceeded.switch-preview-model: %t -> %t", oldCfg.QuotaExceeded.SwitchPreviewModel, newCfg.QuotaExceeded.SwitchPreviewModel))
 	}
 
+	if !reflect.DeepEqual(oldCfg.CooldownPolicies, newCfg.CooldownPolicies) {
+		changes = append(changes, fmt.Sprintf("cooldown-policies: updated (%d -> %d policies)", len(oldCfg.CooldownPolicies), len(newCfg.CooldownPolicies)))
+	}
+	if oldCfg.HealthProbe.IntervalSeconds != newCfg.HealthProbe.IntervalSeconds {
+		changes = append(changes, fmt.Sprintf("health-probe.interval-seconds: %d -> %d", oldCfg.HealthProbe.IntervalSeconds, newCfg.HealthProbe.IntervalSeconds))
+	}
+	if oldCfg.HealthProbe.TimeoutSeconds != newCfg.HealthProbe.TimeoutSeconds {
+		changes = append(changes, fmt.Sprintf("health-probe.timeout-seconds: %d -> %d", oldCfg.HealthProbe.TimeoutSeconds, newCfg.HealthProbe.TimeoutSeconds))
+	}
+	if !reflect.DeepEqual(oldCfg.HealthProbe.Providers, newCfg.HealthProbe.Providers) {
+		changes = append(changes, fmt.Sprintf("health-probe.providers: %v -> %v", oldCfg.HealthProbe.Providers, newCfg.HealthProbe.Providers))
+	}
+	if !reflect.DeepEqual(oldCfg.Alerting, newCfg.Alerting) {
+		changes = append(changes, fmt.Sprintf("alerting: updated (%d -> %d sinks, %d -> %d rules)", len(oldCfg.Alerting.Sinks), len(newCfg.Alerting.Sinks), len(oldCfg.Alerting.Rules), len(newCfg.Alerting.Rules)))
+	}
+	if oldCfg.Files.Dir != newCfg.Files.Dir {
+		changes = append(changes, fmt.Sprintf("files.dir: %s -> %s", oldCfg.Files.Dir, newCfg.Files.Dir))
+	}
+	if oldCfg.Files.MaxSizeMB != newCfg.Files.MaxSizeMB {
+		changes = append(changes, fmt.Sprintf("files.max-size-mb: %d -> %d", oldCfg.Files.MaxSizeMB, newCfg.Files.MaxSizeMB))
+	}
+
 	if oldCfg.Routing.Strategy != newCfg.Routing.Strategy {
 		changes = append(changes, fmt.Sprintf("routing.strategy: %s -> %s", oldCfg.Routing.Strategy, newCfg.Routing.Strategy))
 	}
+	if oldCfg.Routing.Hedging.Enable != newCfg.Routing.Hedging.Enable {
+		changes = append(changes, fmt.Sprintf("routing.hedging.enable: %t -> %t", oldCfg.Routing.Hedging.Enable, newCfg.Routing.Hedging.Enable))
+	}
+	if !reflect.DeepEqual(oldCfg.Routing.Hedging, newCfg.Routing.Hedging) && oldCfg.Routing.Hedging.Enable == newCfg.Routing.Hedging.Enable {
+		changes = append(changes, "routing.hedging: updated")
+	}
+	if oldCfg.ContextManagement.Enable != newCfg.ContextManagement.Enable {
+		changes = append(changes, fmt.Sprintf("context-management.enable: %t -> %t", oldCfg.ContextManagement.Enable, newCfg.ContextManagement.Enable))
+	}
+	if !reflect.DeepEqual(oldCfg.ContextManagement, newCfg.ContextManagement) && oldCfg.ContextManagement.Enable == newCfg.ContextManagement.Enable {
+		changes = append(changes, "context-management: updated")
+	}
 
 	// API keys (redacted) and counts
 	if len(oldCfg.APIKeys) != len(newCfg.APIKeys) {
@@ -91,6 +177,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	} else if !reflect.DeepEqual(trimStrings(oldCfg.APIKeys), trimStrings(newCfg.APIKeys)) {
 		changes = append(changes, "api-keys: values updated (count unchanged, redacted)")
 	}
+	if !reflect.DeepEqual(oldCfg.APIKeyNames, newCfg.APIKeyNames) {
+		changes = append(changes, fmt.Sprintf("api-key-names: updated (%d -> %d entries)", len(oldCfg.APIKeyNames), len(newCfg.APIKeyNames)))
+	}
 	if len(oldCfg.GeminiKey) != len(newCfg.GeminiKey) {
 		changes = append(changes, fmt.Sprintf("gemini-api-key count: %d -> %d", len(oldCfg.GeminiKey), len(newCfg.GeminiKey)))
 	} else {
@@ -171,6 +260,17 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 		}
 	}
 
+	// Prompt caching
+	if oldCfg.PromptCaching.Disable != newCfg.PromptCaching.Disable {
+		changes = append(changes, fmt.Sprintf("prompt-caching.disable: %t -> %t", oldCfg.PromptCaching.Disable, newCfg.PromptCaching.Disable))
+	}
+	if oldCfg.PromptCaching.TTL != newCfg.PromptCaching.TTL {
+		changes = append(changes, fmt.Sprintf("prompt-caching.ttl: %s -> %s", oldCfg.PromptCaching.TTL, newCfg.PromptCaching.TTL))
+	}
+	if !reflect.DeepEqual(oldCfg.PromptCaching.Models, newCfg.PromptCaching.Models) {
+		changes = append(changes, fmt.Sprintf("prompt-caching.models: updated (%d -> %d entries)", len(oldCfg.PromptCaching.Models), len(newCfg.PromptCaching.Models)))
+	}
+
 	// Codex keys (do not print key material)
 	if len(oldCfg.CodexKey) != len(newCfg.CodexKey) {
 		changes = append(changes, fmt.Sprintf("codex-api-key count: %d -> %d", len(oldCfg.CodexKey), len(newCfg.CodexKey)))
@@ -241,6 +341,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if !equalUpstreamAPIKeys(oldCfg.AmpCode.UpstreamAPIKeys, newCfg.AmpCode.UpstreamAPIKeys) {
 		changes = append(changes, fmt.Sprintf("ampcode.upstream-api-keys: updated (%d -> %d entries)", oldUpstreamAPIKeysCount, newUpstreamAPIKeysCount))
 	}
+	if !reflect.DeepEqual(oldCfg.PassthroughModules, newCfg.PassthroughModules) {
+		changes = append(changes, fmt.Sprintf("passthrough-modules: updated (%d -> %d entries)", len(oldCfg.PassthroughModules), len(newCfg.PassthroughModules)))
+	}
 
 	if entries, _ := DiffOAuthExcludedModelChanges(oldCfg.OAuthExcludedModels, newCfg.OAuthExcludedModels); len(entries) > 0 {
 		changes = append(changes, entries...)
@@ -118,3 +118,38 @@ func addConfigHeadersToAttrs(headers map[string]string, attrs map[string]string)
 		attrs["header:"+key] = val
 	}
 }
+
+// addUpstreamTLSToAttrs stores per-key upstream TLS overrides in auth attributes.
+// CA files are newline-separated under "tls_ca_files"; other fields use "tls_*" keys.
+func addUpstreamTLSToAttrs(tlsCfg *config.UpstreamTLS, attrs map[string]string) {
+	if tlsCfg == nil || attrs == nil {
+		return
+	}
+	if len(tlsCfg.CAFiles) > 0 {
+		attrs["tls_ca_files"] = strings.Join(tlsCfg.CAFiles, "\n")
+	}
+	if tlsCfg.ClientCert != "" {
+		attrs["tls_client_cert"] = tlsCfg.ClientCert
+	}
+	if tlsCfg.ClientKey != "" {
+		attrs["tls_client_key"] = tlsCfg.ClientKey
+	}
+	if tlsCfg.ServerName != "" {
+		attrs["tls_server_name"] = tlsCfg.ServerName
+	}
+	if tlsCfg.MinVersion != "" {
+		attrs["tls_min_version"] = tlsCfg.MinVersion
+	}
+}
+
+// mergeUpstreamTLSOverride layers a per-key TLS override on top of a provider-level one.
+func mergeUpstreamTLSOverride(provider, key *config.UpstreamTLS) *config.UpstreamTLS {
+	if key == nil {
+		return provider
+	}
+	if provider == nil {
+		return key
+	}
+	merged := provider.Merge(*key)
+	return &merged
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/gorilla/websocket"
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
 )
 
 // Manager exposes a websocket endpoint that proxies Gemini requests to
@@ -56,9 +57,7 @@ func NewManager(opts Options) *Manager {
 		upgrader: websocket.Upgrader{
 			ReadBufferSize:  1024,
 			WriteBufferSize: 1024,
-			CheckOrigin: func(r *http.Request) bool {
-				return true
-			},
+			CheckOrigin:     sdkaccess.CheckWebsocketOrigin,
 		},
 		providerFactory: opts.ProviderFactory,
 		onConnected:     opts.OnConnected,
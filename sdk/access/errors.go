@@ -13,6 +13,7 @@ const (
 	AuthErrorCodeNoCredentials     AuthErrorCode = "no_credentials"
 	AuthErrorCodeInvalidCredential AuthErrorCode = "invalid_credential"
 	AuthErrorCodeNotHandled        AuthErrorCode = "not_handled"
+	AuthErrorCodeForbiddenOrigin   AuthErrorCode = "forbidden_origin"
 	AuthErrorCodeInternal          AuthErrorCode = "internal_error"
 )
 
@@ -70,6 +71,10 @@ func NewInvalidCredentialError() *AuthError {
 	return newAuthError(AuthErrorCodeInvalidCredential, "Invalid API key", http.StatusUnauthorized, nil)
 }
 
+func NewForbiddenOriginError() *AuthError {
+	return newAuthError(AuthErrorCodeForbiddenOrigin, "Origin not allowed for browser credentials", http.StatusForbidden, nil)
+}
+
 func NewNotHandledError() *AuthError {
 	return newAuthError(AuthErrorCodeNotHandled, "authentication provider did not handle request", 0, nil)
 }
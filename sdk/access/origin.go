@@ -0,0 +1,69 @@
+package access
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// OriginDecision records how the CORS policy of the route group serving a request treated its
+// Origin header.
+type OriginDecision struct {
+	// Allowed reports whether the policy lets the origin call the route.
+	Allowed bool
+	// Listed reports whether the origin matched an explicit allowed-origins entry rather than
+	// the "*" wildcard.
+	Listed bool
+}
+
+type originDecisionKey struct{}
+
+// WithOriginDecision returns a copy of ctx carrying the CORS decision for the request Origin.
+func WithOriginDecision(ctx context.Context, decision OriginDecision) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, originDecisionKey{}, decision)
+}
+
+// OriginDecisionFromContext returns the CORS decision recorded by WithOriginDecision.
+func OriginDecisionFromContext(ctx context.Context) (OriginDecision, bool) {
+	if ctx == nil {
+		return OriginDecision{}, false
+	}
+	decision, ok := ctx.Value(originDecisionKey{}).(OriginDecision)
+	return decision, ok
+}
+
+// BrowserOriginListed reports whether r carries an Origin that the route's CORS policy lists
+// explicitly. Keys sent implicitly by browsers, such as cookies, are only honored for such
+// origins so that cross-site pages cannot ride on them.
+func BrowserOriginListed(r *http.Request) bool {
+	if r == nil || strings.TrimSpace(r.Header.Get("Origin")) == "" {
+		return false
+	}
+	decision, ok := OriginDecisionFromContext(r.Context())
+	return ok && decision.Listed
+}
+
+// CheckWebsocketOrigin is a websocket.Upgrader CheckOrigin function. Requests without an Origin
+// come from non-browser clients and are accepted; browser origins must be allowed by the route's
+// CORS policy, or match the request host when no policy was applied.
+func CheckWebsocketOrigin(r *http.Request) bool {
+	if r == nil {
+		return false
+	}
+	origin := strings.TrimSpace(r.Header.Get("Origin"))
+	if origin == "" {
+		return true
+	}
+	if decision, ok := OriginDecisionFromContext(r.Context()); ok {
+		return decision.Allowed
+	}
+	parsed, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(parsed.Host, r.Host)
+}
@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClientKeyOwner identifies the client API key of a request as the owner of resources it
+// creates, such as cached contents and uploaded files. The key is reduced to its SHA-256 digest
+// so stored metadata never holds it. Unauthenticated requests share the empty owner.
+func ClientKeyOwner(c *gin.Context) string {
+	if c == nil {
+		return ""
+	}
+	key := c.GetString("apiKey")
+	if key == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,15 @@
+package handlers
+
+import "github.com/gin-gonic/gin"
+
+// CORSHandledKey is set on the Gin context by servers that apply their own CORS policy.
+const CORSHandledKey = "CORS_HANDLED"
+
+// SetStreamCORSHeader allows any origin to read a streaming response unless the server already
+// applied a CORS policy to the request.
+func SetStreamCORSHeader(c *gin.Context) {
+	if _, handled := c.Get(CORSHandledKey); handled {
+		return
+	}
+	c.Header("Access-Control-Allow-Origin", "*")
+}
@@ -0,0 +1,326 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+	"golang.org/x/net/context"
+)
+
+// defaultFanOutJudgePrompt instructs the judge when the fan-out model has no judge-prompt.
+const defaultFanOutJudgePrompt = "You compare candidate answers to the same conversation. " +
+	"Pick the candidate that is the most correct, complete and helpful. " +
+	"Reply with the number of the best candidate only."
+
+var fanOutJudgeChoice = regexp.MustCompile(`\d+`)
+
+// fanOutBranch is the outcome of one model of a fan-out request.
+type fanOutBranch struct {
+	model   string
+	body    []byte
+	err     *interfaces.ErrorMessage
+	latency time.Duration
+	// cancelled marks branches stopped because another branch already won.
+	cancelled bool
+}
+
+func (b *fanOutBranch) ok() bool {
+	return b.err == nil && len(b.body) > 0
+}
+
+// FanOutModel returns the fan-out definition for modelName, if one is configured.
+func (h *BaseAPIHandler) FanOutModel(modelName string) (config.FanOutModel, bool) {
+	return h.Cfg.FanOutModelFor(modelName)
+}
+
+// ExecuteFanOut sends an OpenAI Chat Completions request to every model of fanOut concurrently
+// and combines the answers according to the fan-out mode. Each branch runs through
+// ExecuteWithAuthManager, so credential selection, retries and usage accounting apply per model;
+// the response reports each branch's usage under "fan_out".
+func (h *BaseAPIHandler) ExecuteFanOut(ctx context.Context, fanOut config.FanOutModel, rawJSON []byte, alt string) ([]byte, *interfaces.ErrorMessage) {
+	if rh := h.hooksFor(ctx, "openai", fanOut.Name, false); rh != nil {
+		body, _, errMsg := h.executeWithHooks(ctx, rh, rawJSON, func(hookedCtx context.Context, payload []byte) ([]byte, http.Header, *interfaces.ErrorMessage) {
+			body, errMsg := h.ExecuteFanOut(hookedCtx, fanOut, payload, alt)
+			return body, nil, errMsg
+		})
+		return body, errMsg
+	}
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(fanOut.Timeout())*time.Second)
+	defer cancel()
+
+	payload, _ := sjson.DeleteBytes(rawJSON, "stream_options")
+	payload, _ = sjson.SetBytes(payload, "stream", false)
+	branches := h.runFanOutBranches(ctx, fanOut, payload, alt)
+
+	var firstErr *interfaces.ErrorMessage
+	successes := make([]*fanOutBranch, 0, len(branches))
+	for _, branch := range branches {
+		if branch.ok() {
+			successes = append(successes, branch)
+		} else if firstErr == nil && !branch.cancelled {
+			firstErr = branch.err
+		}
+	}
+	if len(successes) == 0 {
+		if firstErr == nil {
+			firstErr = &interfaces.ErrorMessage{StatusCode: http.StatusBadGateway, Error: fmt.Errorf("fan-out %s: no model answered", fanOut.Name)}
+		}
+		return nil, firstErr
+	}
+
+	out := []byte(successes[0].body)
+	out, _ = sjson.SetBytes(out, "model", fanOut.Name)
+	out, _ = sjson.SetBytes(out, "fan_out.mode", fanOut.Mode)
+
+	selected := successes
+	usageBranches := successes
+	switch fanOut.Mode {
+	case config.FanOutModeFirst:
+		// Branches are reported in completion order, so the winner comes first.
+		selected = successes[:1]
+		usageBranches = successes[:1]
+	case config.FanOutModeJudge:
+		judge := h.judgeFanOut(ctx, fanOut, rawJSON, successes, alt)
+		selected = []*fanOutBranch{successes[judge.selected]}
+		out, _ = sjson.SetRawBytes(out, "fan_out.judge", judge.report())
+		if judge.branch.ok() {
+			usageBranches = append(append([]*fanOutBranch{}, successes...), judge.branch)
+		}
+	}
+
+	out, _ = sjson.SetRawBytes(out, "choices", fanOutChoices(selected))
+	if usage := sumFanOutUsage(usageBranches); usage != nil {
+		out, _ = sjson.SetRawBytes(out, "usage", usage)
+	}
+	out, _ = sjson.SetRawBytes(out, "fan_out.branches", fanOutBranchReport(branches))
+	return out, nil
+}
+
+// runFanOutBranches executes every branch and returns them in configured order, except in
+// first mode where they are returned in completion order.
+func (h *BaseAPIHandler) runFanOutBranches(ctx context.Context, fanOut config.FanOutModel, payload []byte, alt string) []*fanOutBranch {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type indexed struct {
+		index  int
+		branch *fanOutBranch
+	}
+	results := make(chan indexed, len(fanOut.Models))
+	for i, model := range fanOut.Models {
+		go func(index int, model string) {
+			branchPayload, _ := sjson.SetBytes(payload, "model", model)
+			start := time.Now()
+			body, _, errMsg := h.ExecuteWithAuthManager(ctx, "openai", model, branchPayload, alt)
+			results <- indexed{index: index, branch: &fanOutBranch{model: model, body: body, err: errMsg, latency: time.Since(start)}}
+		}(i, model)
+	}
+
+	ordered := make([]*fanOutBranch, len(fanOut.Models))
+	completed := make([]*fanOutBranch, 0, len(fanOut.Models))
+	won := false
+	for range fanOut.Models {
+		result := <-results
+		branch := result.branch
+		if won {
+			branch.cancelled = !branch.ok()
+		}
+		ordered[result.index] = branch
+		completed = append(completed, branch)
+		if fanOut.Mode == config.FanOutModeFirst && !won && branch.ok() {
+			won = true
+			cancel()
+		}
+	}
+	if fanOut.Mode == config.FanOutModeFirst {
+		return completed
+	}
+	return ordered
+}
+
+// fanOutChoices flattens the choices of the selected branches, renumbering them and
+// annotating each with the model that produced it.
+func fanOutChoices(branches []*fanOutBranch) []byte {
+	choices := []byte(`[]`)
+	index := 0
+	for _, branch := range branches {
+		gjson.GetBytes(branch.body, "choices").ForEach(func(_, choice gjson.Result) bool {
+			raw, _ := sjson.SetBytes([]byte(choice.Raw), "index", index)
+			raw, _ = sjson.SetBytes(raw, "model", branch.model)
+			choices, _ = sjson.SetRawBytes(choices, "-1", raw)
+			index++
+			return true
+		})
+	}
+	return choices
+}
+
+var fanOutUsageFields = []string{"prompt_tokens", "completion_tokens", "total_tokens"}
+
+// sumFanOutUsage adds up the token counts of the given branches.
+func sumFanOutUsage(branches []*fanOutBranch) []byte {
+	totals := make(map[string]int64, len(fanOutUsageFields))
+	found := false
+	for _, branch := range branches {
+		usage := gjson.GetBytes(branch.body, "usage")
+		if !usage.Exists() {
+			continue
+		}
+		found = true
+		for _, field := range fanOutUsageFields {
+			totals[field] += usage.Get(field).Int()
+		}
+	}
+	if !found {
+		return nil
+	}
+	out := []byte(`{}`)
+	for _, field := range fanOutUsageFields {
+		out, _ = sjson.SetBytes(out, field, totals[field])
+	}
+	return out
+}
+
+func fanOutBranchReport(branches []*fanOutBranch) []byte {
+	report := []byte(`[]`)
+	for _, branch := range branches {
+		entry := []byte(`{}`)
+		entry, _ = sjson.SetBytes(entry, "model", branch.model)
+		entry, _ = sjson.SetBytes(entry, "latency_ms", branch.latency.Milliseconds())
+		switch {
+		case branch.ok():
+			entry, _ = sjson.SetBytes(entry, "status", "ok")
+			if usage := gjson.GetBytes(branch.body, "usage"); usage.Exists() {
+				entry, _ = sjson.SetRawBytes(entry, "usage", []byte(usage.Raw))
+			}
+		case branch.cancelled:
+			entry, _ = sjson.SetBytes(entry, "status", "cancelled")
+		default:
+			entry, _ = sjson.SetBytes(entry, "status", "error")
+			if branch.err != nil {
+				entry, _ = sjson.SetBytes(entry, "status_code", branch.err.StatusCode)
+				if branch.err.Error != nil {
+					entry, _ = sjson.SetBytes(entry, "error", branch.err.Error.Error())
+				}
+			}
+		}
+		report, _ = sjson.SetRawBytes(report, "-1", entry)
+	}
+	return report
+}
+
+// fanOutJudgement is the judge's pick; selected indexes the successful candidates.
+type fanOutJudgement struct {
+	model    string
+	selected int
+	branch   *fanOutBranch
+	reason   string
+}
+
+func (j fanOutJudgement) report() []byte {
+	out := []byte(`{}`)
+	out, _ = sjson.SetBytes(out, "model", j.model)
+	out, _ = sjson.SetBytes(out, "selected", j.selected)
+	if j.branch != nil && j.branch.ok() {
+		if usage := gjson.GetBytes(j.branch.body, "usage"); usage.Exists() {
+			out, _ = sjson.SetRawBytes(out, "usage", []byte(usage.Raw))
+		}
+	}
+	if j.reason != "" {
+		out, _ = sjson.SetBytes(out, "fallback", j.reason)
+	}
+	return out
+}
+
+// judgeFanOut asks the judge model to pick the best candidate. When the judge fails or its
+// answer cannot be parsed, the first candidate is kept and the reason is reported.
+func (h *BaseAPIHandler) judgeFanOut(ctx context.Context, fanOut config.FanOutModel, rawJSON []byte, candidates []*fanOutBranch, alt string) fanOutJudgement {
+	judgement := fanOutJudgement{model: fanOut.JudgeModel}
+	if len(candidates) < 2 {
+		judgement.reason = "single candidate"
+		return judgement
+	}
+	prompt := strings.TrimSpace(fanOut.JudgePrompt)
+	if prompt == "" {
+		prompt = defaultFanOutJudgePrompt
+	}
+	request := []byte(`{"messages":[{"role":"system","content":""},{"role":"user","content":""}],"stream":false}`)
+	request, _ = sjson.SetBytes(request, "model", fanOut.JudgeModel)
+	request, _ = sjson.SetBytes(request, "messages.0.content", prompt)
+	request, _ = sjson.SetBytes(request, "messages.1.content", buildFanOutJudgeInput(rawJSON, candidates))
+
+	start := time.Now()
+	body, _, errMsg := h.ExecuteWithAuthManager(ctx, "openai", fanOut.JudgeModel, request, alt)
+	judgement.branch = &fanOutBranch{model: fanOut.JudgeModel, body: body, err: errMsg, latency: time.Since(start)}
+	if !judgement.branch.ok() {
+		judgement.reason = "judge request failed"
+		if errMsg != nil && errMsg.Error != nil {
+			log.Warnf("fan-out %s: judge %s failed: %v", fanOut.Name, fanOut.JudgeModel, errMsg.Error)
+		}
+		return judgement
+	}
+	answer := gjson.GetBytes(body, "choices.0.message.content").String()
+	match := fanOutJudgeChoice.FindString(answer)
+	pick, errAtoi := strconv.Atoi(match)
+	if errAtoi != nil || pick < 1 || pick > len(candidates) {
+		judgement.reason = "judge answer did not name a candidate"
+		return judgement
+	}
+	judgement.selected = pick - 1
+	return judgement
+}
+
+// buildFanOutJudgeInput renders the conversation and the numbered candidate answers as text.
+func buildFanOutJudgeInput(rawJSON []byte, candidates []*fanOutBranch) string {
+	var sb strings.Builder
+	sb.WriteString("Conversation:\n")
+	gjson.GetBytes(rawJSON, "messages").ForEach(func(_, message gjson.Result) bool {
+		text := chatMessageText(message.Get("content"))
+		if text == "" {
+			return true
+		}
+		sb.WriteString("[")
+		sb.WriteString(message.Get("role").String())
+		sb.WriteString("]\n")
+		sb.WriteString(text)
+		sb.WriteString("\n\n")
+		return true
+	})
+	for i, candidate := range candidates {
+		message := gjson.GetBytes(candidate.body, "choices.0.message")
+		sb.WriteString("Candidate ")
+		sb.WriteString(strconv.Itoa(i + 1))
+		sb.WriteString(":\n")
+		sb.WriteString(chatMessageText(message.Get("content")))
+		if calls := message.Get("tool_calls"); calls.Exists() {
+			sb.WriteString("\nTool calls: ")
+			sb.WriteString(calls.Raw)
+		}
+		sb.WriteString("\n\n")
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// chatMessageText returns the text of a Chat Completions message content, joining text parts.
+func chatMessageText(content gjson.Result) string {
+	if content.Type == gjson.String {
+		return content.String()
+	}
+	parts := make([]string, 0)
+	content.ForEach(func(_, part gjson.Result) bool {
+		if part.Get("type").String() == "text" {
+			parts = append(parts, part.Get("text").String())
+		}
+		return true
+	})
+	return strings.Join(parts, "\n")
+}
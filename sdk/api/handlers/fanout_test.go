@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+// fanOutTestExecutor answers "Answer from <model>" and fails for model "fanout-fail".
+// The judge model answers with judgeAnswer; "fanout-slow" waits until cancelled.
+type fanOutTestExecutor struct {
+	judgeAnswer string
+
+	mu     sync.Mutex
+	judged []byte
+}
+
+func (e *fanOutTestExecutor) Identifier() string { return "fanout-test" }
+
+func (e *fanOutTestExecutor) Execute(ctx context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	switch req.Model {
+	case "fanout-fail":
+		return coreexecutor.Response{}, &coreauth.Error{Code: "upstream", Message: "upstream failed", HTTPStatus: http.StatusBadRequest}
+	case "fanout-slow":
+		<-ctx.Done()
+		return coreexecutor.Response{}, ctx.Err()
+	case "fanout-judge":
+		e.mu.Lock()
+		e.judged = append([]byte(nil), req.Payload...)
+		e.mu.Unlock()
+		return coreexecutor.Response{Payload: []byte(`{"id":"judge","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"` + e.judgeAnswer + `"},"finish_reason":"stop"}],"usage":{"prompt_tokens":50,"completion_tokens":1,"total_tokens":51}}`)}, nil
+	}
+	return coreexecutor.Response{Payload: []byte(`{"id":"chatcmpl-` + req.Model + `","object":"chat.completion","created":1,"model":"` + req.Model + `","choices":[{"index":0,"message":{"role":"assistant","content":"Answer from ` + req.Model + `"},"finish_reason":"stop"}],"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}`)}, nil
+}
+
+func (e *fanOutTestExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "ExecuteStream not implemented"}
+}
+
+func (e *fanOutTestExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *fanOutTestExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func (e *fanOutTestExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "HttpRequest not implemented", HTTPStatus: http.StatusNotImplemented}
+}
+
+func newFanOutTestHandler(t *testing.T, authID string, fanOut sdkconfig.FanOutModel, judgeAnswer string) (*BaseAPIHandler, *fanOutTestExecutor) {
+	t.Helper()
+	executor := &fanOutTestExecutor{judgeAnswer: judgeAnswer}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	if _, err := manager.Register(context.Background(), &coreauth.Auth{ID: authID, Provider: "fanout-test", Status: coreauth.StatusActive}); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	models := []*registry.ModelInfo{{ID: "fanout-a"}, {ID: "fanout-b"}, {ID: "fanout-fail"}, {ID: "fanout-slow"}, {ID: "fanout-judge"}}
+	registry.GetGlobalRegistry().RegisterClient(authID, "fanout-test", models)
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(authID) })
+
+	cfg := &sdkconfig.Config{SDKConfig: sdkconfig.SDKConfig{FanOut: []sdkconfig.FanOutModel{fanOut}}}
+	cfg.SanitizeFanOut()
+	return NewBaseAPIHandlers(&cfg.SDKConfig, manager), executor
+}
+
+const fanOutTestRequest = `{"model":"panel","messages":[{"role":"user","content":"Which is better?"}]}`
+
+func TestExecuteFanOut_AllReturnsAnnotatedChoices(t *testing.T) {
+	handler, _ := newFanOutTestHandler(t, "fanout-all", sdkconfig.FanOutModel{Name: "panel", Models: []string{"fanout-a", "fanout-fail", "fanout-b"}}, "")
+	fanOut, ok := handler.FanOutModel("Panel")
+	if !ok {
+		t.Fatal("fan-out model not found")
+	}
+
+	body, errMsg := handler.ExecuteFanOut(context.Background(), fanOut, []byte(fanOutTestRequest), "")
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %+v", errMsg)
+	}
+	if got := gjson.GetBytes(body, "model").String(); got != "panel" {
+		t.Fatalf("model = %q, want panel", got)
+	}
+	choices := gjson.GetBytes(body, "choices").Array()
+	if len(choices) != 2 {
+		t.Fatalf("choices = %s, want 2", gjson.GetBytes(body, "choices").Raw)
+	}
+	if choices[0].Get("model").String() != "fanout-a" || choices[1].Get("model").String() != "fanout-b" || choices[1].Get("index").Int() != 1 {
+		t.Fatalf("choices not annotated: %s", gjson.GetBytes(body, "choices").Raw)
+	}
+	if got := gjson.GetBytes(body, "usage.total_tokens").Int(); got != 30 {
+		t.Fatalf("usage.total_tokens = %d, want 30", got)
+	}
+	branches := gjson.GetBytes(body, "fan_out.branches").Array()
+	if len(branches) != 3 || branches[1].Get("status").String() != "error" || branches[0].Get("usage.total_tokens").Int() != 15 {
+		t.Fatalf("branches = %s", gjson.GetBytes(body, "fan_out.branches").Raw)
+	}
+}
+
+func TestExecuteFanOut_FirstCancelsSlowBranches(t *testing.T) {
+	handler, _ := newFanOutTestHandler(t, "fanout-first", sdkconfig.FanOutModel{Name: "panel", Models: []string{"fanout-slow", "fanout-a"}, Mode: "first"}, "")
+	fanOut, _ := handler.FanOutModel("panel")
+
+	done := make(chan struct{})
+	var body []byte
+	go func() {
+		defer close(done)
+		body, _ = handler.ExecuteFanOut(context.Background(), fanOut, []byte(fanOutTestRequest), "")
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("first mode did not cancel the slow branch")
+	}
+	if got := gjson.GetBytes(body, "choices.#").Int(); got != 1 {
+		t.Fatalf("choices = %d, want 1", got)
+	}
+	if got := gjson.GetBytes(body, "choices.0.model").String(); got != "fanout-a" {
+		t.Fatalf("winner = %q, want fanout-a", got)
+	}
+	if got := gjson.GetBytes(body, "fan_out.branches.1.status").String(); got != "cancelled" {
+		t.Fatalf("slow branch status = %q, want cancelled", got)
+	}
+}
+
+func TestExecuteFanOut_JudgeSelectsCandidate(t *testing.T) {
+	handler, executor := newFanOutTestHandler(t, "fanout-judge-auth", sdkconfig.FanOutModel{Name: "panel", Models: []string{"fanout-a", "fanout-b"}, Mode: "judge", JudgeModel: "fanout-judge"}, "Candidate 2 is best.")
+	fanOut, _ := handler.FanOutModel("panel")
+
+	body, errMsg := handler.ExecuteFanOut(context.Background(), fanOut, []byte(fanOutTestRequest), "")
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %+v", errMsg)
+	}
+	if got := gjson.GetBytes(body, "choices.0.message.content").String(); got != "Answer from fanout-b" {
+		t.Fatalf("selected content = %q", got)
+	}
+	if got := gjson.GetBytes(body, "fan_out.judge.selected").Int(); got != 1 {
+		t.Fatalf("judge.selected = %d, want 1", got)
+	}
+	if got := gjson.GetBytes(body, "usage.total_tokens").Int(); got != 81 {
+		t.Fatalf("usage.total_tokens = %d, want 81 (two branches plus judge)", got)
+	}
+	executor.mu.Lock()
+	judged := string(executor.judged)
+	executor.mu.Unlock()
+	if !strings.Contains(judged, "Which is better?") || !strings.Contains(judged, "Candidate 2:\\nAnswer from fanout-b") {
+		t.Fatalf("judge prompt missing conversation or candidates: %s", judged)
+	}
+}
+
+func TestExecuteFanOut_AllBranchesFail(t *testing.T) {
+	handler, _ := newFanOutTestHandler(t, "fanout-fail-auth", sdkconfig.FanOutModel{Name: "panel", Models: []string{"fanout-fail"}}, "")
+	fanOut, _ := handler.FanOutModel("panel")
+
+	_, errMsg := handler.ExecuteFanOut(context.Background(), fanOut, []byte(fanOutTestRequest), "")
+	if errMsg == nil || errMsg.StatusCode != http.StatusBadRequest {
+		t.Fatalf("errMsg = %+v, want 400", errMsg)
+	}
+}
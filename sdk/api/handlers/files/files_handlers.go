@@ -0,0 +1,378 @@
+// Package files implements the Anthropic and OpenAI /v1/files endpoints on top of the proxy
+// file store. Both APIs share the route; requests carrying Anthropic headers get Anthropic
+// shaped responses and everything else is answered in the OpenAI format.
+package files
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/filestore"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/misc"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	// purposeUserData is the only OpenAI purpose served by the proxy store.
+	purposeUserData = "user_data"
+
+	anthropicIDPrefix = "file_"
+	openAIIDPrefix    = "file-"
+
+	defaultAnthropicListLimit = 20
+	maxAnthropicListLimit     = 1000
+	defaultOpenAIListLimit    = 10000
+)
+
+// FilesAPIHandler serves file uploads for Anthropic and OpenAI clients.
+type FilesAPIHandler struct {
+	*handlers.BaseAPIHandler
+}
+
+// NewFilesAPIHandler creates a files handler.
+func NewFilesAPIHandler(apiHandlers *handlers.BaseAPIHandler) *FilesAPIHandler {
+	return &FilesAPIHandler{BaseAPIHandler: apiHandlers}
+}
+
+// isAnthropicRequest reports whether the client speaks the Anthropic Files API.
+func isAnthropicRequest(c *gin.Context) bool {
+	return c.GetHeader("anthropic-version") != "" || c.GetHeader("anthropic-beta") != ""
+}
+
+// Upload stores a multipart "file" upload.
+func (h *FilesAPIHandler) Upload(c *gin.Context) {
+	anthropic := isAnthropicRequest(c)
+	backend := filestore.Current()
+	if backend == nil {
+		writeFilesError(c, anthropic, http.StatusServiceUnavailable, "file store is not configured")
+		return
+	}
+	limit := filestore.MaxSize()
+	// Leave room for the multipart envelope and the other form fields.
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit+1<<20)
+	header, err := c.FormFile("file")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			writeFilesError(c, anthropic, http.StatusRequestEntityTooLarge, fmt.Sprintf("file exceeds the %d byte limit", limit))
+			return
+		}
+		writeFilesError(c, anthropic, http.StatusBadRequest, "multipart form field 'file' is required")
+		return
+	}
+	purpose := ""
+	if !anthropic {
+		purpose = strings.TrimSpace(c.PostForm("purpose"))
+		if purpose != purposeUserData {
+			writeFilesError(c, anthropic, http.StatusBadRequest, fmt.Sprintf("unsupported purpose %q: only %s is supported", purpose, purposeUserData))
+			return
+		}
+	}
+	if header.Size > limit {
+		writeFilesError(c, anthropic, http.StatusRequestEntityTooLarge, fmt.Sprintf("file exceeds the %d byte limit", limit))
+		return
+	}
+	src, err := header.Open()
+	if err != nil {
+		writeFilesError(c, anthropic, http.StatusBadRequest, "failed to read uploaded file")
+		return
+	}
+	data, err := io.ReadAll(src)
+	_ = src.Close()
+	if err != nil {
+		writeFilesError(c, anthropic, http.StatusBadRequest, "failed to read uploaded file")
+		return
+	}
+	if len(data) == 0 {
+		writeFilesError(c, anthropic, http.StatusBadRequest, "uploaded file is empty")
+		return
+	}
+
+	prefix := openAIIDPrefix
+	if anthropic {
+		prefix = anthropicIDPrefix
+	}
+	id, err := filestore.NewID(prefix)
+	if err != nil {
+		writeFilesError(c, anthropic, http.StatusInternalServerError, "failed to allocate file id")
+		return
+	}
+	filename := filepath.Base(strings.ReplaceAll(header.Filename, "\\", "/"))
+	if filename == "." || filename == "/" || filename == "" {
+		filename = "upload"
+	}
+	file := filestore.File{
+		ID:        id,
+		Filename:  filename,
+		MimeType:  detectMimeType(filename, header.Header.Get("Content-Type"), data),
+		Bytes:     int64(len(data)),
+		Purpose:   purpose,
+		CreatedAt: time.Now().UTC(),
+		Owner:     handlers.ClientKeyOwner(c),
+	}
+	if err = backend.Put(c.Request.Context(), file, data); err != nil {
+		log.Errorf("files: store %s failed: %v", id, err)
+		writeFilesError(c, anthropic, http.StatusInternalServerError, "failed to store file")
+		return
+	}
+	c.Data(http.StatusOK, "application/json", fileObject(anthropic, file))
+}
+
+// List returns the files uploaded with the caller's client key. Anthropic clients page with limit, before_id and after_id;
+// OpenAI clients with limit, after and order, optionally filtered by purpose.
+func (h *FilesAPIHandler) List(c *gin.Context) {
+	anthropic := isAnthropicRequest(c)
+	backend := filestore.Current()
+	if backend == nil {
+		writeFilesError(c, anthropic, http.StatusServiceUnavailable, "file store is not configured")
+		return
+	}
+	all, err := backend.List(c.Request.Context())
+	if err != nil {
+		log.Errorf("files: list failed: %v", err)
+		writeFilesError(c, anthropic, http.StatusInternalServerError, "failed to list files")
+		return
+	}
+	owner := handlers.ClientKeyOwner(c)
+	owned := all[:0]
+	for _, file := range all {
+		if file.Owner == owner {
+			owned = append(owned, file)
+		}
+	}
+	all = owned
+	if !anthropic {
+		if purpose := c.Query("purpose"); purpose != "" {
+			filtered := all[:0]
+			for _, file := range all {
+				if file.Purpose == purpose {
+					filtered = append(filtered, file)
+				}
+			}
+			all = filtered
+		}
+		if c.Query("order") == "asc" {
+			for i, j := 0, len(all)-1; i < j; i, j = i+1, j-1 {
+				all[i], all[j] = all[j], all[i]
+			}
+		}
+	}
+
+	limit := defaultOpenAIListLimit
+	if anthropic {
+		limit = defaultAnthropicListLimit
+	}
+	if raw := c.Query("limit"); raw != "" {
+		parsed, errParse := strconv.Atoi(raw)
+		if errParse != nil || parsed < 1 || (anthropic && parsed > maxAnthropicListLimit) {
+			writeFilesError(c, anthropic, http.StatusBadRequest, "invalid limit")
+			return
+		}
+		limit = parsed
+	}
+	afterID := c.Query("after_id")
+	if !anthropic {
+		afterID = c.Query("after")
+	}
+	page, hasMore := paginate(all, afterID, c.Query("before_id"), limit)
+
+	out := []byte(`{"data":[]}`)
+	for _, file := range page {
+		out, _ = sjson.SetRawBytes(out, "data.-1", fileObject(anthropic, file))
+	}
+	out, _ = sjson.SetBytes(out, "has_more", hasMore)
+	if anthropic {
+		out, _ = sjson.SetBytes(out, "first_id", nil)
+		out, _ = sjson.SetBytes(out, "last_id", nil)
+	} else {
+		out, _ = sjson.SetBytes(out, "object", "list")
+	}
+	if len(page) > 0 {
+		out, _ = sjson.SetBytes(out, "first_id", page[0].ID)
+		out, _ = sjson.SetBytes(out, "last_id", page[len(page)-1].ID)
+	}
+	c.Data(http.StatusOK, "application/json", out)
+}
+
+// Get returns the metadata of one file.
+func (h *FilesAPIHandler) Get(c *gin.Context) {
+	anthropic := isAnthropicRequest(c)
+	backend := filestore.Current()
+	if backend == nil {
+		writeFilesError(c, anthropic, http.StatusServiceUnavailable, "file store is not configured")
+		return
+	}
+	file, err := ownedFile(c, backend)
+	if err != nil {
+		writeLookupError(c, anthropic, c.Param("id"), err)
+		return
+	}
+	c.Data(http.StatusOK, "application/json", fileObject(anthropic, file))
+}
+
+// Content streams the stored bytes of one file.
+func (h *FilesAPIHandler) Content(c *gin.Context) {
+	anthropic := isAnthropicRequest(c)
+	backend := filestore.Current()
+	if backend == nil {
+		writeFilesError(c, anthropic, http.StatusServiceUnavailable, "file store is not configured")
+		return
+	}
+	file, data, err := backend.Get(c.Request.Context(), c.Param("id"))
+	if err == nil && file.Owner != handlers.ClientKeyOwner(c) {
+		err = filestore.ErrNotFound
+	}
+	if err != nil {
+		writeLookupError(c, anthropic, c.Param("id"), err)
+		return
+	}
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": file.Filename}))
+	c.Data(http.StatusOK, file.MimeType, data)
+}
+
+// Delete removes one file.
+func (h *FilesAPIHandler) Delete(c *gin.Context) {
+	anthropic := isAnthropicRequest(c)
+	backend := filestore.Current()
+	if backend == nil {
+		writeFilesError(c, anthropic, http.StatusServiceUnavailable, "file store is not configured")
+		return
+	}
+	id := c.Param("id")
+	if _, err := ownedFile(c, backend); err != nil {
+		writeLookupError(c, anthropic, id, err)
+		return
+	}
+	if err := backend.Delete(c.Request.Context(), id); err != nil {
+		writeLookupError(c, anthropic, id, err)
+		return
+	}
+	out := []byte(`{}`)
+	out, _ = sjson.SetBytes(out, "id", id)
+	if anthropic {
+		out, _ = sjson.SetBytes(out, "type", "file_deleted")
+	} else {
+		out, _ = sjson.SetBytes(out, "object", "file")
+		out, _ = sjson.SetBytes(out, "deleted", true)
+	}
+	c.Data(http.StatusOK, "application/json", out)
+}
+
+// ownedFile returns the metadata of the requested file, or ErrNotFound when it was uploaded with
+// another client key.
+func ownedFile(c *gin.Context, backend filestore.Backend) (filestore.File, error) {
+	file, err := backend.Stat(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		return filestore.File{}, err
+	}
+	if file.Owner != handlers.ClientKeyOwner(c) {
+		return filestore.File{}, filestore.ErrNotFound
+	}
+	return file, nil
+}
+
+func fileObject(anthropic bool, file filestore.File) []byte {
+	out := []byte(`{}`)
+	out, _ = sjson.SetBytes(out, "id", file.ID)
+	if anthropic {
+		out, _ = sjson.SetBytes(out, "type", "file")
+		out, _ = sjson.SetBytes(out, "filename", file.Filename)
+		out, _ = sjson.SetBytes(out, "mime_type", file.MimeType)
+		out, _ = sjson.SetBytes(out, "size_bytes", file.Bytes)
+		out, _ = sjson.SetBytes(out, "created_at", file.CreatedAt.UTC().Format(time.RFC3339))
+		out, _ = sjson.SetBytes(out, "downloadable", true)
+		return out
+	}
+	purpose := file.Purpose
+	if purpose == "" {
+		purpose = purposeUserData
+	}
+	out, _ = sjson.SetBytes(out, "object", "file")
+	out, _ = sjson.SetBytes(out, "bytes", file.Bytes)
+	out, _ = sjson.SetBytes(out, "created_at", file.CreatedAt.Unix())
+	out, _ = sjson.SetBytes(out, "filename", file.Filename)
+	out, _ = sjson.SetBytes(out, "purpose", purpose)
+	out, _ = sjson.SetBytes(out, "status", "processed")
+	return out
+}
+
+// paginate returns up to limit files after afterID or before beforeID in list order.
+func paginate(all []filestore.File, afterID, beforeID string, limit int) ([]filestore.File, bool) {
+	start, end := 0, len(all)
+	for i, file := range all {
+		if afterID != "" && file.ID == afterID {
+			start = i + 1
+		}
+		if beforeID != "" && file.ID == beforeID {
+			end = i
+		}
+	}
+	if start > end {
+		return nil, false
+	}
+	window := all[start:end]
+	if beforeID != "" && afterID == "" {
+		if len(window) > limit {
+			return window[len(window)-limit:], true
+		}
+		return window, false
+	}
+	if len(window) > limit {
+		return window[:limit], true
+	}
+	return window, false
+}
+
+// detectMimeType prefers the extension, then a specific part Content-Type, then sniffing.
+func detectMimeType(filename, declared string, data []byte) string {
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(filename)), ".")
+	if mimeType, ok := misc.MimeTypes[ext]; ok {
+		return mimeType
+	}
+	if declared != "" && declared != "application/octet-stream" {
+		if mediaType, _, err := mime.ParseMediaType(declared); err == nil {
+			return mediaType
+		}
+	}
+	mediaType, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+	return mediaType
+}
+
+func writeLookupError(c *gin.Context, anthropic bool, id string, err error) {
+	if errors.Is(err, filestore.ErrNotFound) {
+		writeFilesError(c, anthropic, http.StatusNotFound, fmt.Sprintf("file %s not found", id))
+		return
+	}
+	log.Errorf("files: lookup %s failed: %v", id, err)
+	writeFilesError(c, anthropic, http.StatusInternalServerError, "failed to read file")
+}
+
+func writeFilesError(c *gin.Context, anthropic bool, status int, message string) {
+	if anthropic {
+		errType := "invalid_request_error"
+		switch status {
+		case http.StatusNotFound:
+			errType = "not_found_error"
+		case http.StatusRequestEntityTooLarge:
+			errType = "request_too_large"
+		case http.StatusInternalServerError, http.StatusServiceUnavailable:
+			errType = "api_error"
+		}
+		body := []byte(`{"type":"error","error":{}}`)
+		body, _ = sjson.SetBytes(body, "error.type", errType)
+		body, _ = sjson.SetBytes(body, "error.message", message)
+		c.Data(status, "application/json", body)
+		return
+	}
+	c.Data(status, "application/json", handlers.BuildErrorResponseBody(status, message))
+}
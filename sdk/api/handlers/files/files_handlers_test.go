@@ -0,0 +1,184 @@
+package files
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/filestore"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func newFilesTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	filestore.SetBackend(filestore.NewDiskBackend(t.TempDir()))
+	t.Cleanup(func() {
+		filestore.SetBackend(nil)
+		filestore.SetMaxSize(0)
+	})
+	h := NewFilesAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, nil))
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if key := c.GetHeader("X-Test-Key"); key != "" {
+			c.Set("apiKey", key)
+		}
+	})
+	router.POST("/v1/files", h.Upload)
+	router.GET("/v1/files", h.List)
+	router.GET("/v1/files/:id", h.Get)
+	router.GET("/v1/files/:id/content", h.Content)
+	router.DELETE("/v1/files/:id", h.Delete)
+	return router
+}
+
+func uploadRequest(t *testing.T, filename string, content []byte, fields map[string]string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for key, value := range fields {
+		_ = writer.WriteField(key, value)
+	}
+	part, err := writer.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatalf("CreateFormFile: %v", err)
+	}
+	_, _ = part.Write(content)
+	_ = writer.Close()
+	req := httptest.NewRequest(http.MethodPost, "/v1/files", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func serve(router *gin.Engine, req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestFilesAnthropicLifecycle(t *testing.T) {
+	router := newFilesTestRouter(t)
+
+	req := uploadRequest(t, "report.pdf", []byte("%PDF-1.4"), nil)
+	req.Header.Set("anthropic-version", "2023-06-01")
+	rec := serve(router, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("upload status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	uploaded := gjson.ParseBytes(rec.Body.Bytes())
+	id := uploaded.Get("id").String()
+	if uploaded.Get("type").String() != "file" || uploaded.Get("mime_type").String() != "application/pdf" || uploaded.Get("size_bytes").Int() != 8 {
+		t.Fatalf("upload body = %s", rec.Body.String())
+	}
+	if len(id) < 6 || id[:5] != "file_" {
+		t.Fatalf("anthropic id = %q, want file_ prefix", id)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/files?limit=1", nil)
+	req.Header.Set("anthropic-version", "2023-06-01")
+	rec = serve(router, req)
+	list := gjson.ParseBytes(rec.Body.Bytes())
+	if list.Get("data.0.id").String() != id || list.Get("first_id").String() != id || list.Get("has_more").Bool() {
+		t.Fatalf("list body = %s", rec.Body.String())
+	}
+
+	rec = serve(router, httptest.NewRequest(http.MethodGet, "/v1/files/"+id+"/content", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "%PDF-1.4" {
+		t.Fatalf("content status = %d, body = %q", rec.Code, rec.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/v1/files/"+id, nil)
+	req.Header.Set("anthropic-version", "2023-06-01")
+	rec = serve(router, req)
+	if gjson.Get(rec.Body.String(), "type").String() != "file_deleted" {
+		t.Fatalf("delete body = %s", rec.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/files/"+id, nil)
+	req.Header.Set("anthropic-version", "2023-06-01")
+	rec = serve(router, req)
+	if rec.Code != http.StatusNotFound || gjson.Get(rec.Body.String(), "error.type").String() != "not_found_error" {
+		t.Fatalf("get after delete status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestFilesOpenAIUpload(t *testing.T) {
+	router := newFilesTestRouter(t)
+
+	rec := serve(router, uploadRequest(t, "notes.txt", []byte("hello"), map[string]string{"purpose": "fine-tune"}))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("unsupported purpose status = %d, want 400", rec.Code)
+	}
+
+	rec = serve(router, uploadRequest(t, "notes.txt", []byte("hello"), map[string]string{"purpose": "user_data"}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("upload status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	uploaded := gjson.ParseBytes(rec.Body.Bytes())
+	id := uploaded.Get("id").String()
+	if uploaded.Get("object").String() != "file" || uploaded.Get("purpose").String() != "user_data" || uploaded.Get("bytes").Int() != 5 {
+		t.Fatalf("upload body = %s", rec.Body.String())
+	}
+	if len(id) < 6 || id[:5] != "file-" {
+		t.Fatalf("openai id = %q, want file- prefix", id)
+	}
+
+	rec = serve(router, httptest.NewRequest(http.MethodGet, "/v1/files?purpose=user_data", nil))
+	if gjson.Get(rec.Body.String(), "object").String() != "list" || gjson.Get(rec.Body.String(), "data.0.id").String() != id {
+		t.Fatalf("list body = %s", rec.Body.String())
+	}
+
+	rec = serve(router, httptest.NewRequest(http.MethodDelete, "/v1/files/"+id, nil))
+	if !gjson.Get(rec.Body.String(), "deleted").Bool() {
+		t.Fatalf("delete body = %s", rec.Body.String())
+	}
+}
+
+func TestFilesUploadTooLarge(t *testing.T) {
+	router := newFilesTestRouter(t)
+	filestore.SetMaxSize(4)
+
+	rec := serve(router, uploadRequest(t, "big.txt", []byte("too large"), map[string]string{"purpose": "user_data"}))
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want 413, body = %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestFilesScopedToClientKey(t *testing.T) {
+	router := newFilesTestRouter(t)
+	req := uploadRequest(t, "notes.txt", []byte("secret notes"), map[string]string{"purpose": "user_data"})
+	req.Header.Set("X-Test-Key", "key-a")
+	rec := serve(router, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("upload status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	id := gjson.Get(rec.Body.String(), "id").String()
+
+	as := func(key, method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("X-Test-Key", key)
+		return serve(router, req)
+	}
+	if rec = as("key-b", http.MethodGet, "/v1/files"); gjson.Get(rec.Body.String(), "data.#").Int() != 0 {
+		t.Fatalf("other key lists the file: %s", rec.Body.String())
+	}
+	for _, path := range []string{"/v1/files/" + id, "/v1/files/" + id + "/content"} {
+		if rec = as("key-b", http.MethodGet, path); rec.Code != http.StatusNotFound {
+			t.Errorf("GET %s by other key status = %d, want 404", path, rec.Code)
+		}
+	}
+	if rec = as("key-b", http.MethodDelete, "/v1/files/"+id); rec.Code != http.StatusNotFound {
+		t.Errorf("delete by other key status = %d, want 404", rec.Code)
+	}
+	if rec = as("key-a", http.MethodGet, "/v1/files"); gjson.Get(rec.Body.String(), "data.0.id").String() != id {
+		t.Fatalf("owner list = %s", rec.Body.String())
+	}
+	if rec = as("key-a", http.MethodDelete, "/v1/files/"+id); rec.Code != http.StatusOK {
+		t.Errorf("owner delete status = %d", rec.Code)
+	}
+}
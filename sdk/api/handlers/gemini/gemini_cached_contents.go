@@ -0,0 +1,583 @@
+package gemini
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	cachedContentsPrefix = "cachedContents/"
+	// defaultCachedContentTTL matches the Gemini API default when neither ttl nor expireTime is set.
+	defaultCachedContentTTL = time.Hour
+	// maxCachedContentResponse caps upstream cachedContents responses read into memory.
+	maxCachedContentResponse = 32 << 20
+	// maxCachedContentsPerOwner and maxCachedContentBytesPerOwner cap the caches one client key
+	// may hold; maxCachedContentBytes caps the whole store.
+	maxCachedContentsPerOwner     = 100
+	maxCachedContentBytesPerOwner = 64 << 20
+	maxCachedContentBytes         = 512 << 20
+)
+
+// cachedContentFields are the request fields a cache stands in for. They are kept for emulated
+// caches and never echoed back in metadata responses.
+var cachedContentFields = []string{"contents", "systemInstruction", "system_instruction", "tools", "toolConfig", "tool_config"}
+
+// cachedContentEntry tracks a cache created through the proxy.
+type cachedContentEntry struct {
+	// Name is the client-facing resource name, always "cachedContents/<id>".
+	Name string
+	// Owner identifies the client key that created the cache (see handlers.ClientKeyOwner).
+	Owner string
+	// UpstreamName is the resource name on the owning credential; empty for emulated caches.
+	UpstreamName string
+	// AuthID and Provider identify the credential that owns a forwarded cache.
+	AuthID   string
+	Provider string
+	// Model is the bare model ID the cache was created for.
+	Model      string
+	ExpireTime time.Time
+	// Emulated caches live only in the proxy and are expanded into each request.
+	Emulated bool
+	// Resource is the client-facing JSON resource; emulated caches keep the cached fields here.
+	Resource []byte
+}
+
+// cachedContentStore indexes caches by client-facing name. It is shared by every Gemini handler
+// instance so caches created on one route can be referenced from another. Every lookup is
+// scoped to the owning client key.
+type cachedContentStore struct {
+	mu      sync.Mutex
+	entries map[string]*cachedContentEntry
+}
+
+var cachedContents = &cachedContentStore{entries: make(map[string]*cachedContentEntry)}
+
+var (
+	// errCachedContentQuota is returned by put when the owner or the store is full.
+	errCachedContentQuota = errors.New("cached content quota exceeded")
+	// errCachedContentNotFound is returned for a cache owned by another client key.
+	errCachedContentNotFound = errors.New("cached content not found")
+)
+
+// put stores entry, replacing an entry of the same name, unless that exceeds the owner's or the
+// store's limits.
+func (s *cachedContentStore) put(entry *cachedContentEntry) error {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	count, ownerBytes, totalBytes := 0, len(entry.Resource), len(entry.Resource)
+	for name, existing := range s.entries {
+		if existing.expired(now) {
+			delete(s.entries, name)
+			continue
+		}
+		if name == entry.Name {
+			continue
+		}
+		totalBytes += len(existing.Resource)
+		if existing.Owner == entry.Owner {
+			count++
+			ownerBytes += len(existing.Resource)
+		}
+	}
+	if count >= maxCachedContentsPerOwner || ownerBytes > maxCachedContentBytesPerOwner || totalBytes > maxCachedContentBytes {
+		return errCachedContentQuota
+	}
+	s.entries[entry.Name] = entry
+	return nil
+}
+
+// get returns a copy of the named entry of owner, dropping it when expired.
+func (s *cachedContentStore) get(owner, name string) (cachedContentEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[name]
+	if !ok || entry.Owner != owner {
+		return cachedContentEntry{}, false
+	}
+	if entry.expired(time.Now()) {
+		delete(s.entries, name)
+		return cachedContentEntry{}, false
+	}
+	return *entry, true
+}
+
+// exists reports whether any client key holds a cache named name.
+func (s *cachedContentStore) exists(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.entries[name]
+	return ok
+}
+
+func (s *cachedContentStore) remove(name string) {
+	s.mu.Lock()
+	delete(s.entries, name)
+	s.mu.Unlock()
+}
+
+// list returns the live entries of owner sorted by name and prunes expired ones.
+func (s *cachedContentStore) list(owner string) []cachedContentEntry {
+	now := time.Now()
+	s.mu.Lock()
+	out := make([]cachedContentEntry, 0)
+	for name, entry := range s.entries {
+		if entry.expired(now) {
+			delete(s.entries, name)
+			continue
+		}
+		if entry.Owner == owner {
+			out = append(out, *entry)
+		}
+	}
+	s.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func (e *cachedContentEntry) expired(now time.Time) bool {
+	return !e.ExpireTime.IsZero() && now.After(e.ExpireTime)
+}
+
+// normalizeCachedContentName maps "cachedContents/<id>", "<id>" or an upstream resource name to
+// the client-facing name.
+func normalizeCachedContentName(name string) string {
+	name = strings.Trim(strings.TrimSpace(name), "/")
+	if name == "" {
+		return ""
+	}
+	if idx := strings.LastIndex(name, "/"); idx >= 0 {
+		name = name[idx+1:]
+	}
+	return cachedContentsPrefix + name
+}
+
+// CreateCachedContent handles POST /v1beta/cachedContents. Models served by a Gemini API-key or
+// Vertex service-account credential create the cache upstream on that credential; other models
+// get a proxy-side cache that is expanded into each request referencing it.
+func (h *GeminiAPIHandler) CreateCachedContent(c *gin.Context) {
+	rawJSON, _ := c.GetRawData()
+	if !gjson.ValidBytes(rawJSON) {
+		writeCachedContentError(c, http.StatusBadRequest, "Invalid request: body must be a JSON object")
+		return
+	}
+	model := strings.TrimPrefix(strings.TrimSpace(gjson.GetBytes(rawJSON, "model").String()), "models/")
+	if model == "" {
+		writeCachedContentError(c, http.StatusBadRequest, "Invalid request: model is required")
+		return
+	}
+	if len(util.GetProviderName(model)) == 0 {
+		writeCachedContentError(c, http.StatusBadRequest, fmt.Sprintf("Unknown model: %s", model))
+		return
+	}
+
+	if auth, endpoint := h.cachedContentAuth(model); auth != nil {
+		h.createUpstreamCachedContent(c, auth, endpoint, model, rawJSON)
+		return
+	}
+
+	expireTime, errExpire := cachedContentExpiry(rawJSON, time.Now())
+	if errExpire != nil {
+		writeCachedContentError(c, http.StatusBadRequest, errExpire.Error())
+		return
+	}
+	id, errID := newCachedContentID()
+	if errID != nil {
+		writeCachedContentError(c, http.StatusInternalServerError, errID.Error())
+		return
+	}
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	resource, _ := sjson.DeleteBytes(rawJSON, "ttl")
+	resource, _ = sjson.SetBytes(resource, "name", cachedContentsPrefix+id)
+	resource, _ = sjson.SetBytes(resource, "model", "models/"+model)
+	resource, _ = sjson.SetBytes(resource, "createTime", now)
+	resource, _ = sjson.SetBytes(resource, "updateTime", now)
+	resource, _ = sjson.SetBytes(resource, "expireTime", expireTime.UTC().Format(time.RFC3339Nano))
+	entry := &cachedContentEntry{
+		Name:       cachedContentsPrefix + id,
+		Owner:      handlers.ClientKeyOwner(c),
+		Model:      model,
+		ExpireTime: expireTime,
+		Emulated:   true,
+		Resource:   resource,
+	}
+	if errPut := cachedContents.put(entry); errPut != nil {
+		writeCachedContentError(c, http.StatusTooManyRequests, errPut.Error())
+		return
+	}
+	writeCachedContentResource(c, http.StatusOK, entry.Resource)
+}
+
+// ListCachedContents handles GET /v1beta/cachedContents, listing the caches the client key
+// created through the proxy.
+func (h *GeminiAPIHandler) ListCachedContents(c *gin.Context) {
+	out := []byte(`{"cachedContents":[]}`)
+	for _, entry := range cachedContents.list(handlers.ClientKeyOwner(c)) {
+		out, _ = sjson.SetRawBytes(out, "cachedContents.-1", cachedContentMetadata(entry.Resource))
+	}
+	c.Data(http.StatusOK, "application/json", out)
+}
+
+// GetCachedContent handles GET /v1beta/cachedContents/:id.
+func (h *GeminiAPIHandler) GetCachedContent(c *gin.Context) {
+	entry, ok := cachedContents.get(handlers.ClientKeyOwner(c), normalizeCachedContentName(c.Param("id")))
+	if !ok {
+		writeCachedContentError(c, http.StatusNotFound, "Cached content not found")
+		return
+	}
+	if entry.Emulated {
+		writeCachedContentResource(c, http.StatusOK, entry.Resource)
+		return
+	}
+	h.forwardCachedContent(c, entry, http.MethodGet, nil)
+}
+
+// UpdateCachedContent handles PATCH /v1beta/cachedContents/:id. Only the expiration can change.
+func (h *GeminiAPIHandler) UpdateCachedContent(c *gin.Context) {
+	entry, ok := cachedContents.get(handlers.ClientKeyOwner(c), normalizeCachedContentName(c.Param("id")))
+	if !ok {
+		writeCachedContentError(c, http.StatusNotFound, "Cached content not found")
+		return
+	}
+	rawJSON, _ := c.GetRawData()
+	if !entry.Emulated {
+		h.forwardCachedContent(c, entry, http.MethodPatch, rawJSON)
+		return
+	}
+	expireTime, errExpire := cachedContentExpiry(rawJSON, time.Now())
+	if errExpire != nil {
+		writeCachedContentError(c, http.StatusBadRequest, errExpire.Error())
+		return
+	}
+	entry.ExpireTime = expireTime
+	entry.Resource, _ = sjson.SetBytes(entry.Resource, "expireTime", expireTime.UTC().Format(time.RFC3339Nano))
+	entry.Resource, _ = sjson.SetBytes(entry.Resource, "updateTime", time.Now().UTC().Format(time.RFC3339Nano))
+	_ = cachedContents.put(&entry)
+	writeCachedContentResource(c, http.StatusOK, entry.Resource)
+}
+
+// DeleteCachedContent handles DELETE /v1beta/cachedContents/:id.
+func (h *GeminiAPIHandler) DeleteCachedContent(c *gin.Context) {
+	name := normalizeCachedContentName(c.Param("id"))
+	entry, ok := cachedContents.get(handlers.ClientKeyOwner(c), name)
+	if !ok {
+		writeCachedContentError(c, http.StatusNotFound, "Cached content not found")
+		return
+	}
+	if entry.Emulated {
+		cachedContents.remove(name)
+		c.Data(http.StatusOK, "application/json", []byte("{}"))
+		return
+	}
+	h.forwardCachedContent(c, entry, http.MethodDelete, nil)
+}
+
+// cachedContentAuth picks an available credential that can hold caches for model.
+func (h *GeminiAPIHandler) cachedContentAuth(model string) (*coreauth.Auth, coreauth.CachedContentEndpoint) {
+	if h.AuthManager == nil {
+		return nil, nil
+	}
+	reg := registry.GetGlobalRegistry()
+	auths := h.AuthManager.List()
+	sort.Slice(auths, func(i, j int) bool { return auths[i].ID < auths[j].ID })
+	for _, auth := range auths {
+		if auth == nil || auth.Disabled || auth.Status == coreauth.StatusDisabled {
+			continue
+		}
+		if reg != nil && !reg.ClientSupportsModel(auth.ID, model) {
+			continue
+		}
+		exec, ok := h.AuthManager.Executor(auth.Provider)
+		if !ok {
+			continue
+		}
+		endpoint, ok := exec.(coreauth.CachedContentEndpoint)
+		if !ok {
+			continue
+		}
+		if _, errURL := endpoint.CachedContentsURL(auth); errURL != nil {
+			continue
+		}
+		return auth, endpoint
+	}
+	return nil, nil
+}
+
+func (h *GeminiAPIHandler) createUpstreamCachedContent(c *gin.Context, auth *coreauth.Auth, endpoint coreauth.CachedContentEndpoint, model string, rawJSON []byte) {
+	target, errURL := endpoint.CachedContentsURL(auth)
+	if errURL != nil {
+		writeCachedContentError(c, http.StatusBadGateway, errURL.Error())
+		return
+	}
+	upstreamModel, errModel := endpoint.CachedContentModel(auth, model)
+	if errModel != nil {
+		writeCachedContentError(c, http.StatusBadGateway, errModel.Error())
+		return
+	}
+	body, _ := sjson.SetBytes(rawJSON, "model", upstreamModel)
+	status, resp, errDo := h.doCachedContentRequest(c.Request.Context(), auth, http.MethodPost, target, body)
+	if errDo != nil {
+		writeCachedContentError(c, http.StatusBadGateway, errDo.Error())
+		return
+	}
+	if status < 200 || status >= 300 {
+		c.Data(status, "application/json", resp)
+		return
+	}
+	upstreamName := gjson.GetBytes(resp, "name").String()
+	if upstreamName == "" {
+		writeCachedContentError(c, http.StatusBadGateway, "upstream returned a cache without name")
+		return
+	}
+	entry := &cachedContentEntry{
+		Name:         normalizeCachedContentName(upstreamName),
+		Owner:        handlers.ClientKeyOwner(c),
+		UpstreamName: upstreamName,
+		AuthID:       auth.ID,
+		Provider:     auth.Provider,
+		Model:        model,
+	}
+	entry.applyUpstream(resp)
+	if errPut := cachedContents.put(entry); errPut != nil {
+		// The cache exists upstream but cannot be tracked; delete it rather than leak it.
+		if collection, errCollection := endpoint.CachedContentsURL(auth); errCollection == nil {
+			_, _, _ = h.doCachedContentRequest(c.Request.Context(), auth, http.MethodDelete, collection+"/"+strings.TrimPrefix(entry.Name, cachedContentsPrefix), nil)
+		}
+		writeCachedContentError(c, http.StatusTooManyRequests, errPut.Error())
+		return
+	}
+	writeCachedContentResource(c, status, entry.Resource)
+}
+
+// forwardCachedContent relays a get, update or delete to the credential owning the cache.
+func (h *GeminiAPIHandler) forwardCachedContent(c *gin.Context, entry cachedContentEntry, method string, body []byte) {
+	auth, ok := h.AuthManager.GetByID(entry.AuthID)
+	if !ok {
+		cachedContents.remove(entry.Name)
+		writeCachedContentError(c, http.StatusNotFound, "Cached content not found: owning credential was removed")
+		return
+	}
+	exec, ok := h.AuthManager.Executor(auth.Provider)
+	endpoint, okEndpoint := exec.(coreauth.CachedContentEndpoint)
+	if !ok || !okEndpoint {
+		writeCachedContentError(c, http.StatusBadGateway, "owning credential no longer supports cachedContents")
+		return
+	}
+	collection, errURL := endpoint.CachedContentsURL(auth)
+	if errURL != nil {
+		writeCachedContentError(c, http.StatusBadGateway, errURL.Error())
+		return
+	}
+	target := collection + "/" + strings.TrimPrefix(entry.Name, cachedContentsPrefix)
+	if query := forwardedCachedContentQuery(c.Request.URL.Query()); query != "" {
+		target += "?" + query
+	}
+	status, resp, errDo := h.doCachedContentRequest(c.Request.Context(), auth, method, target, body)
+	if errDo != nil {
+		writeCachedContentError(c, http.StatusBadGateway, errDo.Error())
+		return
+	}
+	if method == http.MethodDelete && (status == http.StatusNotFound || (status >= 200 && status < 300)) {
+		cachedContents.remove(entry.Name)
+	}
+	if status < 200 || status >= 300 {
+		if status == http.StatusNotFound {
+			cachedContents.remove(entry.Name)
+		}
+		c.Data(status, "application/json", resp)
+		return
+	}
+	if method == http.MethodDelete {
+		c.Data(status, "application/json", []byte("{}"))
+		return
+	}
+	entry.applyUpstream(resp)
+	_ = cachedContents.put(&entry)
+	writeCachedContentResource(c, status, entry.Resource)
+}
+
+// forwardedCachedContentQueryParams are the client query parameters relayed to the upstream.
+// Everything else is dropped: "key" may carry the proxy API key and would override the
+// credential's own key.
+var forwardedCachedContentQueryParams = []string{"pageSize", "pageToken", "updateMask"}
+
+// forwardedCachedContentQuery encodes the allowed parameters of query.
+func forwardedCachedContentQuery(query url.Values) string {
+	forwarded := url.Values{}
+	for _, name := range forwardedCachedContentQueryParams {
+		if values, ok := query[name]; ok {
+			forwarded[name] = values
+		}
+	}
+	return forwarded.Encode()
+}
+
+func (h *GeminiAPIHandler) doCachedContentRequest(ctx context.Context, auth *coreauth.Auth, method, target string, body []byte) (int, []byte, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, errReq := http.NewRequestWithContext(ctx, method, target, reader)
+	if errReq != nil {
+		return 0, nil, errReq
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, errDo := h.AuthManager.HttpRequest(ctx, auth, req)
+	if errDo != nil {
+		return 0, nil, errDo
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, errRead := io.ReadAll(io.LimitReader(resp.Body, maxCachedContentResponse))
+	if errRead != nil {
+		return 0, nil, errRead
+	}
+	return resp.StatusCode, data, nil
+}
+
+// applyUpstream refreshes the entry from an upstream resource, rewriting names to the
+// client-facing form.
+func (e *cachedContentEntry) applyUpstream(resp []byte) {
+	resource, _ := sjson.SetBytes(resp, "name", e.Name)
+	resource, _ = sjson.SetBytes(resource, "model", "models/"+e.Model)
+	if expire := gjson.GetBytes(resp, "expireTime").String(); expire != "" {
+		if parsed, errParse := time.Parse(time.RFC3339Nano, expire); errParse == nil {
+			e.ExpireTime = parsed
+		}
+	}
+	e.Resource = resource
+}
+
+// applyCachedContent resolves the request's cachedContent reference. Forwarded caches pin the
+// request to the credential that owns them; emulated caches are expanded in place and the
+// returned context carries the prefix length for cache breakpoint placement. Only caches of owner
+// are resolved and caches of other client keys fail with errCachedContentNotFound; unknown names
+// are left untouched for the upstream to resolve.
+func applyCachedContent(ctx context.Context, owner string, rawJSON []byte) (context.Context, []byte, error) {
+	ref := gjson.GetBytes(rawJSON, "cachedContent")
+	if !ref.Exists() || ref.String() == "" {
+		return ctx, rawJSON, nil
+	}
+	name := normalizeCachedContentName(ref.String())
+	entry, ok := cachedContents.get(owner, name)
+	if !ok {
+		if cachedContents.exists(name) {
+			return ctx, rawJSON, errCachedContentNotFound
+		}
+		return ctx, rawJSON, nil
+	}
+	if !entry.Emulated {
+		out, _ := sjson.SetBytes(rawJSON, "cachedContent", entry.UpstreamName)
+		return handlers.WithPinnedAuthID(ctx, entry.AuthID), out, nil
+	}
+	ctx, out := expandCachedContent(ctx, rawJSON, entry.Resource)
+	return ctx, out, nil
+}
+
+// expandCachedContent prepends the cached contents and fills system instruction, tools and tool
+// config when the request does not set its own.
+func expandCachedContent(ctx context.Context, rawJSON, resource []byte) (context.Context, []byte) {
+	out, _ := sjson.DeleteBytes(rawJSON, "cachedContent")
+	cached := gjson.GetBytes(resource, "contents").Array()
+	if len(cached) > 0 {
+		contents := make([]string, 0, len(cached))
+		for _, content := range cached {
+			contents = append(contents, content.Raw)
+		}
+		for _, content := range gjson.GetBytes(rawJSON, "contents").Array() {
+			contents = append(contents, content.Raw)
+		}
+		out, _ = sjson.SetRawBytes(out, "contents", []byte("["+strings.Join(contents, ",")+"]"))
+	}
+	for _, keys := range [][2]string{{"systemInstruction", "system_instruction"}, {"tools", ""}, {"toolConfig", "tool_config"}} {
+		if gjson.GetBytes(out, keys[0]).Exists() || (keys[1] != "" && gjson.GetBytes(out, keys[1]).Exists()) {
+			continue
+		}
+		for _, key := range keys {
+			if key == "" {
+				continue
+			}
+			if value := gjson.GetBytes(resource, key); value.Exists() {
+				out, _ = sjson.SetRawBytes(out, key, []byte(value.Raw))
+				break
+			}
+		}
+	}
+	return handlers.WithCachedContentPrefix(ctx, len(cached)), out
+}
+
+// cachedContentExpiry resolves ttl ("3600s") or expireTime, defaulting to one hour.
+func cachedContentExpiry(rawJSON []byte, now time.Time) (time.Time, error) {
+	if expire := gjson.GetBytes(rawJSON, "expireTime").String(); expire != "" {
+		parsed, errParse := time.Parse(time.RFC3339Nano, expire)
+		if errParse != nil {
+			return time.Time{}, fmt.Errorf("Invalid expireTime: %v", errParse)
+		}
+		return parsed, nil
+	}
+	if ttl := gjson.GetBytes(rawJSON, "ttl").String(); ttl != "" {
+		parsed, errParse := time.ParseDuration(ttl)
+		if errParse != nil || parsed <= 0 {
+			return time.Time{}, fmt.Errorf("Invalid ttl: %q", ttl)
+		}
+		return now.Add(parsed), nil
+	}
+	return now.Add(defaultCachedContentTTL), nil
+}
+
+// cachedContentMetadata strips the cached payload from a resource, as the Gemini API does.
+func cachedContentMetadata(resource []byte) []byte {
+	out := resource
+	for _, field := range cachedContentFields {
+		out, _ = sjson.DeleteBytes(out, field)
+	}
+	return out
+}
+
+func writeCachedContentResource(c *gin.Context, status int, resource []byte) {
+	c.Data(status, "application/json", cachedContentMetadata(resource))
+}
+
+func writeCachedContentError(c *gin.Context, status int, message string) {
+	errType := "invalid_request_error"
+	switch {
+	case status == http.StatusNotFound:
+		errType = "not_found"
+	case status >= http.StatusInternalServerError:
+		errType = "server_error"
+	}
+	c.JSON(status, handlers.ErrorResponse{
+		Error: handlers.ErrorDetail{
+			Message: message,
+			Type:    errType,
+		},
+	})
+}
+
+func newCachedContentID() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
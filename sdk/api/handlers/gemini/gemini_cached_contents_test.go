@@ -0,0 +1,284 @@
+package gemini
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/tidwall/gjson"
+)
+
+func newCachedContentsRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	h := NewGeminiAPIHandler(&handlers.BaseAPIHandler{})
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if key := c.GetHeader("X-Test-Key"); key != "" {
+			c.Set("apiKey", key)
+		}
+	})
+	r.POST("/v1beta/cachedContents", h.CreateCachedContent)
+	r.GET("/v1beta/cachedContents", h.ListCachedContents)
+	r.GET("/v1beta/cachedContents/:id", h.GetCachedContent)
+	r.PATCH("/v1beta/cachedContents/:id", h.UpdateCachedContent)
+	r.DELETE("/v1beta/cachedContents/:id", h.DeleteCachedContent)
+	return r
+}
+
+func serveCachedContents(r *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+	return serveCachedContentsAs(r, "", method, path, body)
+}
+
+func serveCachedContentsAs(r *gin.Engine, key, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set("X-Test-Key", key)
+	}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCachedContents_EmulatedLifecycle(t *testing.T) {
+	modelRegistry := registry.GetGlobalRegistry()
+	modelRegistry.RegisterClient("test-cached-contents-claude", "claude", []*registry.ModelInfo{{ID: "claude-cache-test"}})
+	t.Cleanup(func() { modelRegistry.UnregisterClient("test-cached-contents-claude") })
+	r := newCachedContentsRouter()
+
+	rec := serveCachedContents(r, http.MethodPost, "/v1beta/cachedContents", `{"model":"models/claude-cache-test","ttl":"600s",
+		"systemInstruction":{"parts":[{"text":"sys"}]},"contents":[{"role":"user","parts":[{"text":"doc"}]}]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("create status = %d, body %s", rec.Code, rec.Body.String())
+	}
+	name := gjson.Get(rec.Body.String(), "name").String()
+	if !strings.HasPrefix(name, cachedContentsPrefix) {
+		t.Fatalf("unexpected name %q", name)
+	}
+	if gjson.Get(rec.Body.String(), "contents").Exists() {
+		t.Fatalf("metadata response must not echo contents: %s", rec.Body.String())
+	}
+
+	ctx, expanded, _ := applyCachedContent(context.Background(), "", []byte(`{"cachedContent":"`+name+`","contents":[{"role":"user","parts":[{"text":"q"}]}]}`))
+	if gjson.GetBytes(expanded, "cachedContent").Exists() {
+		t.Fatalf("cachedContent should be removed after expansion: %s", expanded)
+	}
+	if got := gjson.GetBytes(expanded, "contents.#").Int(); got != 2 {
+		t.Fatalf("expanded contents = %d, want 2", got)
+	}
+	if got := gjson.GetBytes(expanded, "contents.0.parts.0.text").String(); got != "doc" {
+		t.Fatalf("cached prefix not first: %s", expanded)
+	}
+	if got := gjson.GetBytes(expanded, "systemInstruction.parts.0.text").String(); got != "sys" {
+		t.Fatalf("system instruction not restored: %s", expanded)
+	}
+	if ctx == context.Background() {
+		t.Fatal("expected context to carry the cached prefix")
+	}
+
+	id := strings.TrimPrefix(name, cachedContentsPrefix)
+	rec = serveCachedContents(r, http.MethodPatch, "/v1beta/cachedContents/"+id, `{"ttl":"7200s"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("patch status = %d, body %s", rec.Code, rec.Body.String())
+	}
+	expire, _ := time.Parse(time.RFC3339Nano, gjson.Get(rec.Body.String(), "expireTime").String())
+	if time.Until(expire) < time.Hour {
+		t.Fatalf("expireTime not extended: %s", rec.Body.String())
+	}
+
+	rec = serveCachedContents(r, http.MethodGet, "/v1beta/cachedContents", "")
+	if !strings.Contains(rec.Body.String(), name) {
+		t.Fatalf("list missing cache: %s", rec.Body.String())
+	}
+
+	rec = serveCachedContents(r, http.MethodDelete, "/v1beta/cachedContents/"+id, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("delete status = %d", rec.Code)
+	}
+	rec = serveCachedContents(r, http.MethodGet, "/v1beta/cachedContents/"+id, "")
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("get after delete status = %d", rec.Code)
+	}
+}
+
+func TestCachedContents_RejectsUnknownModel(t *testing.T) {
+	r := newCachedContentsRouter()
+	rec := serveCachedContents(r, http.MethodPost, "/v1beta/cachedContents", `{"model":"models/no-such-model-for-cache"}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", rec.Code)
+	}
+}
+
+func TestApplyCachedContent_PinsForwardedCache(t *testing.T) {
+	cachedContents.put(&cachedContentEntry{
+		Name:         "cachedContents/fwd-test",
+		Owner:        "owner-a",
+		UpstreamName: "projects/p/locations/l/cachedContents/fwd-test",
+		AuthID:       "vertex-auth",
+		Provider:     "vertex",
+		Model:        "gemini-2.5-flash",
+		ExpireTime:   time.Now().Add(time.Hour),
+	})
+	t.Cleanup(func() { cachedContents.remove("cachedContents/fwd-test") })
+
+	_, out, err := applyCachedContent(context.Background(), "owner-a", []byte(`{"cachedContent":"cachedContents/fwd-test","contents":[]}`))
+	if got := gjson.GetBytes(out, "cachedContent").String(); err != nil || got != "projects/p/locations/l/cachedContents/fwd-test" {
+		t.Fatalf("cachedContent = %q, %v; want upstream name", got, err)
+	}
+	if _, _, err = applyCachedContent(context.Background(), "owner-b", []byte(`{"cachedContent":"cachedContents/fwd-test"}`)); err == nil {
+		t.Fatal("expected another client key's cache to be rejected")
+	}
+}
+
+func TestCachedContents_ScopedToClientKey(t *testing.T) {
+	modelRegistry := registry.GetGlobalRegistry()
+	modelRegistry.RegisterClient("test-cached-contents-owner", "claude", []*registry.ModelInfo{{ID: "claude-cache-owner"}})
+	t.Cleanup(func() { modelRegistry.UnregisterClient("test-cached-contents-owner") })
+	r := newCachedContentsRouter()
+
+	rec := serveCachedContentsAs(r, "key-a", http.MethodPost, "/v1beta/cachedContents", `{"model":"claude-cache-owner","contents":[]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("create status = %d, body %s", rec.Code, rec.Body.String())
+	}
+	name := gjson.Get(rec.Body.String(), "name").String()
+	id := strings.TrimPrefix(name, cachedContentsPrefix)
+	t.Cleanup(func() { cachedContents.remove(name) })
+
+	if rec = serveCachedContentsAs(r, "key-b", http.MethodGet, "/v1beta/cachedContents", ""); strings.Contains(rec.Body.String(), name) {
+		t.Fatalf("other key lists the cache: %s", rec.Body.String())
+	}
+	for _, method := range []string{http.MethodGet, http.MethodPatch, http.MethodDelete} {
+		if rec = serveCachedContentsAs(r, "key-b", method, "/v1beta/cachedContents/"+id, `{"ttl":"60s"}`); rec.Code != http.StatusNotFound {
+			t.Errorf("%s by other key status = %d, want 404", method, rec.Code)
+		}
+	}
+	if rec = serveCachedContentsAs(r, "key-a", http.MethodGet, "/v1beta/cachedContents/"+id, ""); rec.Code != http.StatusOK {
+		t.Errorf("owner get status = %d", rec.Code)
+	}
+}
+
+func TestCachedContentStore_Quota(t *testing.T) {
+	store := &cachedContentStore{entries: make(map[string]*cachedContentEntry)}
+	for i := range maxCachedContentsPerOwner {
+		if err := store.put(&cachedContentEntry{Name: fmt.Sprintf("cachedContents/q%d", i), Owner: "a"}); err != nil {
+			t.Fatalf("put %d: %v", i, err)
+		}
+	}
+	if err := store.put(&cachedContentEntry{Name: "cachedContents/over", Owner: "a"}); err == nil {
+		t.Fatal("expected entry limit to be enforced")
+	}
+	if err := store.put(&cachedContentEntry{Name: "cachedContents/q0", Owner: "a"}); err != nil {
+		t.Fatalf("replacing an entry should not count against the limit: %v", err)
+	}
+	if err := store.put(&cachedContentEntry{Name: "cachedContents/other", Owner: "b"}); err != nil {
+		t.Fatalf("other owner blocked: %v", err)
+	}
+	if err := store.put(&cachedContentEntry{Name: "cachedContents/big", Owner: "c", Resource: make([]byte, maxCachedContentBytesPerOwner+1)}); err == nil {
+		t.Fatal("expected byte limit to be enforced")
+	}
+}
+
+// cachedContentTestExecutor owns forwarded caches and records the upstream URLs it is asked for.
+type cachedContentTestExecutor struct {
+	urls []string
+}
+
+func (e *cachedContentTestExecutor) Identifier() string { return "cached-content-test" }
+
+func (e *cachedContentTestExecutor) Execute(context.Context, *coreauth.Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, nil
+}
+
+func (e *cachedContentTestExecutor) ExecuteStream(context.Context, *coreauth.Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error) {
+	return nil, nil
+}
+
+func (e *cachedContentTestExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *cachedContentTestExecutor) CountTokens(context.Context, *coreauth.Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, nil
+}
+
+func (e *cachedContentTestExecutor) HttpRequest(_ context.Context, _ *coreauth.Auth, req *http.Request) (*http.Response, error) {
+	e.urls = append(e.urls, req.URL.String())
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(`{"name":"cachedContents/upstream-1"}`)),
+	}, nil
+}
+
+func (e *cachedContentTestExecutor) CachedContentsURL(*coreauth.Auth) (string, error) {
+	return "https://upstream.example/v1beta/cachedContents", nil
+}
+
+func (e *cachedContentTestExecutor) CachedContentModel(_ *coreauth.Auth, model string) (string, error) {
+	return "models/" + model, nil
+}
+
+func TestCachedContents_ForwardDropsClientCredentialsFromQuery(t *testing.T) {
+	executor := &cachedContentTestExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	if _, err := manager.Register(context.Background(), &coreauth.Auth{ID: "cached-content-forward", Provider: executor.Identifier(), Status: coreauth.StatusActive}); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	h := NewGeminiAPIHandler(&handlers.BaseAPIHandler{AuthManager: manager})
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("apiKey", "proxy-key")
+	})
+	r.GET("/v1beta/cachedContents/:id", h.GetCachedContent)
+	r.PATCH("/v1beta/cachedContents/:id", h.UpdateCachedContent)
+
+	owner := sha256.Sum256([]byte("proxy-key"))
+	name := cachedContentsPrefix + "forwarded-query"
+	if err := cachedContents.put(&cachedContentEntry{
+		Name:         name,
+		Owner:        hex.EncodeToString(owner[:]),
+		UpstreamName: "cachedContents/upstream-1",
+		AuthID:       "cached-content-forward",
+		Provider:     executor.Identifier(),
+		Model:        "gemini-cache-forward",
+		ExpireTime:   time.Now().Add(time.Hour),
+	}); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	t.Cleanup(func() { cachedContents.remove(name) })
+
+	path := "/v1beta/cachedContents/forwarded-query"
+	if rec := serveCachedContents(r, http.MethodGet, path+"?key=proxy-key&auth_token=proxy-key&pageSize=5", ""); rec.Code != http.StatusOK {
+		t.Fatalf("get status = %d, body %s", rec.Code, rec.Body.String())
+	}
+	if rec := serveCachedContents(r, http.MethodPatch, path+"?updateMask=ttl&key=proxy-key", `{"ttl":"60s"}`); rec.Code != http.StatusOK {
+		t.Fatalf("patch status = %d, body %s", rec.Code, rec.Body.String())
+	}
+
+	want := []string{
+		"https://upstream.example/v1beta/cachedContents/forwarded-query?pageSize=5",
+		"https://upstream.example/v1beta/cachedContents/forwarded-query?updateMask=ttl",
+	}
+	if fmt.Sprint(executor.urls) != fmt.Sprint(want) {
+		t.Fatalf("upstream urls = %v, want %v", executor.urls, want)
+	}
+	for _, target := range executor.urls {
+		if strings.Contains(target, "key=") || strings.Contains(target, "auth_token") {
+			t.Fatalf("client credentials reached the upstream url %q", target)
+		}
+	}
+}
@@ -152,14 +152,19 @@ func (h *GeminiAPIHandler) GeminiHandler(c *gin.Context) {
 
 	method := action[1]
 	rawJSON, _ := c.GetRawData()
+	ctx, rawJSON, errCache := applyCachedContent(context.Background(), handlers.ClientKeyOwner(c), rawJSON)
+	if errCache != nil {
+		writeCachedContentError(c, http.StatusNotFound, "Cached content not found")
+		return
+	}
 
 	switch method {
 	case "generateContent":
-		h.handleGenerateContent(c, action[0], rawJSON)
+		h.handleGenerateContent(c, ctx, action[0], rawJSON)
 	case "streamGenerateContent":
-		h.handleStreamGenerateContent(c, action[0], rawJSON)
+		h.handleStreamGenerateContent(c, ctx, action[0], rawJSON)
 	case "countTokens":
-		h.handleCountTokens(c, action[0], rawJSON)
+		h.handleCountTokens(c, ctx, action[0], rawJSON)
 	}
 }
 
@@ -170,9 +175,10 @@ func (h *GeminiAPIHandler) GeminiHandler(c *gin.Context) {
 //
 // Parameters:
 //   - c: The Gin context for the request
+//   - ctx: The parent context carrying cached content routing
 //   - modelName: The name of the Gemini model to use for content generation
 //   - rawJSON: The raw JSON request body containing generation parameters
-func (h *GeminiAPIHandler) handleStreamGenerateContent(c *gin.Context, modelName string, rawJSON []byte) {
+func (h *GeminiAPIHandler) handleStreamGenerateContent(c *gin.Context, ctx context.Context, modelName string, rawJSON []byte) {
 	alt := h.GetAlt(c)
 
 	// Get the http.Flusher interface to manually flush the response.
@@ -187,14 +193,14 @@ func (h *GeminiAPIHandler) handleStreamGenerateContent(c *gin.Context, modelName
 		return
 	}
 
-	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, ctx)
 	dataChan, upstreamHeaders, errChan := h.ExecuteStreamWithAuthManager(cliCtx, h.HandlerType(), modelName, rawJSON, alt)
 
 	setSSEHeaders := func() {
 		c.Header("Content-Type", "text/event-stream")
 		c.Header("Cache-Control", "no-cache")
 		c.Header("Connection", "keep-alive")
-		c.Header("Access-Control-Allow-Origin", "*")
+		handlers.SetStreamCORSHeader(c)
 	}
 
 	// Peek at the first chunk
@@ -258,12 +264,13 @@ func (h *GeminiAPIHandler) handleStreamGenerateContent(c *gin.Context, modelName
 //
 // Parameters:
 //   - c: The Gin context for the request
+//   - ctx: The parent context carrying cached content routing
 //   - modelName: The name of the Gemini model to use for token counting
 //   - rawJSON: The raw JSON request body containing the content to count
-func (h *GeminiAPIHandler) handleCountTokens(c *gin.Context, modelName string, rawJSON []byte) {
+func (h *GeminiAPIHandler) handleCountTokens(c *gin.Context, ctx context.Context, modelName string, rawJSON []byte) {
 	c.Header("Content-Type", "application/json")
 	alt := h.GetAlt(c)
-	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, ctx)
 	resp, upstreamHeaders, errMsg := h.ExecuteCountWithAuthManager(cliCtx, h.HandlerType(), modelName, rawJSON, alt)
 	if errMsg != nil {
 		h.WriteErrorResponse(c, errMsg)
@@ -282,12 +289,13 @@ func (h *GeminiAPIHandler) handleCountTokens(c *gin.Context, modelName string, r
 //
 // Parameters:
 //   - c: The Gin context for the request
+//   - ctx: The parent context carrying cached content routing
 //   - modelName: The name of the Gemini model to use for content generation
 //   - rawJSON: The raw JSON request body containing generation parameters and content
-func (h *GeminiAPIHandler) handleGenerateContent(c *gin.Context, modelName string, rawJSON []byte) {
+func (h *GeminiAPIHandler) handleGenerateContent(c *gin.Context, ctx context.Context, modelName string, rawJSON []byte) {
 	c.Header("Content-Type", "application/json")
 	alt := h.GetAlt(c)
-	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, ctx)
 	stopKeepAlive := h.StartNonStreamingKeepAlive(c, cliCtx)
 	resp, upstreamHeaders, errMsg := h.ExecuteWithAuthManager(cliCtx, h.HandlerType(), modelName, rawJSON, alt)
 	stopKeepAlive()
@@ -0,0 +1,292 @@
+package gemini
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	liveSetupTimeout     = 30 * time.Second
+	liveWriteTimeout     = 30 * time.Second
+	liveCloseReasonLimit = 120
+)
+
+// liveAuthCheckInterval controls how often an open Live session re-checks its credential.
+var liveAuthCheckInterval = 5 * time.Second
+
+// liveClientReadLimit caps the size of a single client frame.
+var liveClientReadLimit int64 = 16 << 20
+
+var liveWebsocketUpgrader = websocket.Upgrader{
+	ReadBufferSize:  4096,
+	WriteBufferSize: 4096,
+	CheckOrigin:     sdkaccess.CheckWebsocketOrigin,
+}
+
+// liveUsage accumulates the usageMetadata reported by the upstream during a session.
+type liveUsage struct {
+	detail usage.Detail
+}
+
+func (u *liveUsage) add(payload []byte) {
+	meta := gjson.GetBytes(payload, "usageMetadata")
+	if !meta.Exists() {
+		return
+	}
+	output := meta.Get("responseTokenCount").Int()
+	if output == 0 {
+		output = meta.Get("candidatesTokenCount").Int()
+	}
+	u.detail.InputTokens += meta.Get("promptTokenCount").Int()
+	u.detail.OutputTokens += output
+	u.detail.ReasoningTokens += meta.Get("thoughtsTokenCount").Int()
+	u.detail.CachedTokens += meta.Get("cachedContentTokenCount").Int()
+	u.detail.TotalTokens += meta.Get("totalTokenCount").Int()
+}
+
+// LiveWebsocket proxies the Gemini Live BidiGenerateContent protocol. The first client frame
+// must be the setup message; its model selects a Gemini or Vertex credential through the auth
+// manager and all later frames are relayed unchanged in both directions.
+func (h *GeminiAPIHandler) LiveWebsocket(c *gin.Context) {
+	conn, err := liveWebsocketUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+	sessionID := uuid.NewString()
+	log.Infof("gemini live: client connected id=%s remote=%s", sessionID, strings.TrimSpace(c.Request.RemoteAddr))
+	defer func() {
+		if errClose := conn.Close(); errClose != nil && !errors.Is(errClose, net.ErrClosed) {
+			log.Debugf("gemini live: close client connection error: %v", errClose)
+		}
+		log.Infof("gemini live: session closed id=%s", sessionID)
+	}()
+	conn.SetReadLimit(liveClientReadLimit)
+
+	_ = conn.SetReadDeadline(time.Now().Add(liveSetupTimeout))
+	msgType, setup, err := conn.ReadMessage()
+	if err != nil {
+		return
+	}
+	_ = conn.SetReadDeadline(time.Time{})
+	model := liveSetupModel(setup)
+	if model == "" {
+		closeLiveConn(conn, websocket.ClosePolicyViolation, "first message must be a setup message with a model")
+		return
+	}
+	providers := util.GetProviderName(model)
+	if len(providers) == 0 || h.AuthManager == nil {
+		closeLiveConn(conn, websocket.ClosePolicyViolation, "unknown provider for model "+model)
+		return
+	}
+
+	ctx, cancel := h.GetContextWithCancel(h, c, context.Background())
+	defer cancel()
+	opts := cliproxyexecutor.Options{Metadata: map[string]any{
+		cliproxyexecutor.LiveAPIVersionMetadataKey: liveAPIVersion(c.Request.URL.Path),
+	}}
+	session, err := h.AuthManager.DialLive(ctx, providers, model, opts)
+	if err != nil {
+		log.Warnf("gemini live: open upstream failed id=%s model=%s: %v", sessionID, model, err)
+		closeLiveConn(conn, liveCloseCodeForError(err), err.Error())
+		return
+	}
+	upstream := session.Conn
+	log.Infof("gemini live: upstream connected id=%s model=%s auth=%s", sessionID, model, session.Auth.ID)
+
+	setup, _ = sjson.SetBytes(setup, "setup.model", session.Model)
+	if err = upstream.WriteMessage(msgType, setup); err != nil {
+		_ = upstream.Close()
+		session.PublishUsage(ctx, usage.Detail{}, true)
+		closeLiveConn(conn, websocket.CloseInternalServerErr, "failed to send setup upstream")
+		return
+	}
+
+	result := h.relayLive(ctx, conn, session)
+	detail := result.usage.detail
+	if result.upstreamErr != nil {
+		h.AuthManager.MarkLiveResult(ctx, session.Auth.ID, session.Provider, session.RouteModel, result.upstreamErr)
+	}
+	session.PublishUsage(ctx, detail, result.upstreamErr != nil)
+	log.Infof("gemini live: session finished id=%s input_tokens=%d output_tokens=%d", sessionID, detail.InputTokens, detail.OutputTokens)
+}
+
+// liveRelayResult is only read after both pumps have exited.
+type liveRelayResult struct {
+	usage       liveUsage
+	upstreamErr error
+}
+
+// relayLive pumps frames until either side closes or the credential becomes unavailable.
+// Each pump writes a frame before reading the next one, so a slow reader applies backpressure
+// to the opposite connection instead of buffering in the proxy.
+func (h *GeminiAPIHandler) relayLive(ctx context.Context, client *websocket.Conn, session *coreauth.LiveSession) *liveRelayResult {
+	upstream := session.Conn
+	result := &liveRelayResult{}
+	done := make(chan struct{})
+	var stopOnce sync.Once
+	stop := func(code int, reason string) {
+		stopOnce.Do(func() {
+			deadline := time.Now().Add(time.Second)
+			_ = client.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, truncateLiveReason(reason)), deadline)
+			_ = upstream.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), deadline)
+			_ = upstream.Close()
+			_ = client.Close()
+			close(done)
+		})
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for {
+			msgType, payload, err := client.ReadMessage()
+			if err != nil {
+				code, reason := liveCloseFromError(err, websocket.CloseNormalClosure)
+				stop(code, reason)
+				return
+			}
+			_ = upstream.SetWriteDeadline(time.Now().Add(liveWriteTimeout))
+			if err = upstream.WriteMessage(msgType, payload); err != nil {
+				stop(websocket.CloseInternalServerErr, "upstream write failed")
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for {
+			msgType, payload, err := upstream.ReadMessage()
+			if err != nil {
+				code, reason := liveCloseFromError(err, websocket.CloseInternalServerErr)
+				if status := liveUpstreamCloseStatus(code, reason); status != 0 {
+					result.upstreamErr = &coreauth.Error{Message: reason, HTTPStatus: status}
+				}
+				stop(code, reason)
+				return
+			}
+			result.usage.add(payload)
+			_ = client.SetWriteDeadline(time.Now().Add(liveWriteTimeout))
+			if err = client.WriteMessage(msgType, payload); err != nil {
+				stop(websocket.CloseNormalClosure, "")
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(liveAuthCheckInterval)
+	defer ticker.Stop()
+	ctxDone := ctx.Done()
+	for {
+		select {
+		case <-done:
+			wg.Wait()
+			return result
+		case <-ctxDone:
+			ctxDone = nil
+			stop(websocket.CloseGoingAway, "server shutting down")
+		case <-ticker.C:
+			if !h.AuthManager.LiveAuthAvailable(session.Auth.ID, session.RouteModel) {
+				log.Infof("gemini live: closing session on credential cooldown auth=%s model=%s", session.Auth.ID, session.RouteModel)
+				stop(websocket.CloseTryAgainLater, "credential cooling down; reconnect to continue")
+			}
+		}
+	}
+}
+
+// liveAPIVersion returns the Gemini API version named in the Live route path.
+func liveAPIVersion(path string) string {
+	if strings.Contains(path, ".v1alpha.") {
+		return "v1alpha"
+	}
+	return "v1beta"
+}
+
+// liveSetupModel extracts the bare model name from a setup message. Both "models/<id>" and
+// fully qualified Vertex publisher names are accepted.
+func liveSetupModel(payload []byte) string {
+	model := strings.TrimSpace(gjson.GetBytes(payload, "setup.model").String())
+	if idx := strings.LastIndex(model, "models/"); idx >= 0 {
+		model = model[idx+len("models/"):]
+	}
+	return model
+}
+
+// liveCloseFromError converts a read error into the close code and reason to forward.
+func liveCloseFromError(err error, fallback int) (int, string) {
+	var closeErr *websocket.CloseError
+	if errors.As(err, &closeErr) {
+		if closeErr.Code == websocket.CloseNoStatusReceived || closeErr.Code == websocket.CloseAbnormalClosure {
+			return websocket.CloseNormalClosure, ""
+		}
+		return closeErr.Code, closeErr.Text
+	}
+	return fallback, ""
+}
+
+// liveUpstreamCloseStatus maps an upstream close that blames the credential onto the HTTP
+// status used for cooldown bookkeeping. Zero means the close does not reflect on the credential.
+func liveUpstreamCloseStatus(code int, reason string) int {
+	if code == websocket.CloseNormalClosure || code == websocket.CloseGoingAway {
+		return 0
+	}
+	upper := strings.ToUpper(reason)
+	switch {
+	case strings.Contains(upper, "RESOURCE_EXHAUSTED"), strings.Contains(upper, "QUOTA"), strings.Contains(upper, "RATE LIMIT"):
+		return http.StatusTooManyRequests
+	case strings.Contains(upper, "PERMISSION_DENIED"):
+		return http.StatusForbidden
+	case strings.Contains(upper, "UNAUTHENTICATED"), strings.Contains(upper, "API KEY NOT VALID"):
+		return http.StatusUnauthorized
+	default:
+		return 0
+	}
+}
+
+func liveCloseCodeForError(err error) int {
+	status := 0
+	if se, ok := errors.AsType[cliproxyexecutor.StatusError](err); ok && se != nil {
+		status = se.StatusCode()
+	}
+	switch {
+	case status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable:
+		return websocket.CloseTryAgainLater
+	case status >= 400 && status < 500:
+		return websocket.ClosePolicyViolation
+	default:
+		var authErr *coreauth.Error
+		if errors.As(err, &authErr) && authErr.Code == "auth_not_found" {
+			return websocket.CloseTryAgainLater
+		}
+		return websocket.CloseInternalServerErr
+	}
+}
+
+func closeLiveConn(conn *websocket.Conn, code int, reason string) {
+	_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, truncateLiveReason(reason)), time.Now().Add(time.Second))
+}
+
+// truncateLiveReason keeps close reasons within the 123 byte control frame limit.
+func truncateLiveReason(reason string) string {
+	if len(reason) <= liveCloseReasonLimit {
+		return reason
+	}
+	return reason[:liveCloseReasonLimit]
+}
@@ -0,0 +1,302 @@
+package gemini
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+type liveTestExecutor struct {
+	url string
+
+	mu          sync.Mutex
+	reported    []usage.Detail
+	failed      bool
+	apiVersions []string
+}
+
+func (e *liveTestExecutor) Identifier() string { return "live-test" }
+
+func (e *liveTestExecutor) Execute(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, errors.New("not implemented")
+}
+
+func (e *liveTestExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *liveTestExecutor) Refresh(ctx context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *liveTestExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, errors.New("not implemented")
+}
+
+func (e *liveTestExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *liveTestExecutor) DialLive(ctx context.Context, auth *coreauth.Auth, model string, opts coreexecutor.Options) (*coreauth.LiveUpstream, error) {
+	e.mu.Lock()
+	version, _ := opts.Metadata[coreexecutor.LiveAPIVersionMetadataKey].(string)
+	e.apiVersions = append(e.apiVersions, version)
+	e.mu.Unlock()
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, e.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &coreauth.LiveUpstream{
+		Conn:  conn,
+		Model: "models/upstream-" + model,
+		Report: func(_ context.Context, detail usage.Detail, failed bool) {
+			e.mu.Lock()
+			e.reported = append(e.reported, detail)
+			e.failed = failed
+			e.mu.Unlock()
+		},
+	}, nil
+}
+
+// newLiveUpstreamServer answers the setup with the model it received and replies to every
+// client frame with a turn carrying usage metadata.
+func newLiveUpstreamServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		_, setup, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"setupComplete":{},"model":"`+gjson.GetBytes(setup, "setup.model").String()+`"}`))
+		for {
+			if _, _, err = conn.ReadMessage(); err != nil {
+				return
+			}
+			_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"serverContent":{"turnComplete":true},"usageMetadata":{"promptTokenCount":3,"responseTokenCount":5,"totalTokenCount":8}}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newLiveTestProxy(t *testing.T, authID string) (*liveTestExecutor, *coreauth.Manager, string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	upstream := newLiveUpstreamServer(t)
+	executor := &liveTestExecutor{url: "ws" + strings.TrimPrefix(upstream.URL, "http")}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: authID, Provider: executor.Identifier(), Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register auth: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(authID, executor.Identifier(), []*registry.ModelInfo{{ID: "live-test-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(authID) })
+
+	h := NewGeminiAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager))
+	router := gin.New()
+	router.GET("/ws/live", h.LiveWebsocket)
+	router.GET("/ws/google.ai.generativelanguage.v1alpha.GenerativeService.BidiGenerateContent", h.LiveWebsocket)
+	proxy := httptest.NewServer(router)
+	t.Cleanup(proxy.Close)
+	return executor, manager, "ws" + strings.TrimPrefix(proxy.URL, "http") + "/ws/live"
+}
+
+func TestLiveWebsocket_RelaysFramesAndReportsUsage(t *testing.T) {
+	executor, _, url := newLiveTestProxy(t, "live-auth-relay")
+	client, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial proxy: %v", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	if err = client.WriteMessage(websocket.TextMessage, []byte(`{"setup":{"model":"models/live-test-model"}}`)); err != nil {
+		t.Fatalf("write setup: %v", err)
+	}
+	_, msg, err := client.ReadMessage()
+	if err != nil {
+		t.Fatalf("read setupComplete: %v", err)
+	}
+	if got := gjson.GetBytes(msg, "model").String(); got != "models/upstream-live-test-model" {
+		t.Fatalf("upstream setup model = %q", got)
+	}
+	for i := 0; i < 2; i++ {
+		if err = client.WriteMessage(websocket.TextMessage, []byte(`{"realtimeInput":{"text":"hi"}}`)); err != nil {
+			t.Fatalf("write input: %v", err)
+		}
+		if _, msg, err = client.ReadMessage(); err != nil {
+			t.Fatalf("read turn: %v", err)
+		}
+		if !gjson.GetBytes(msg, "serverContent.turnComplete").Bool() {
+			t.Fatalf("unexpected frame %s", msg)
+		}
+	}
+	_ = client.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	_, _, _ = client.ReadMessage()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		executor.mu.Lock()
+		reported := append([]usage.Detail(nil), executor.reported...)
+		failed := executor.failed
+		executor.mu.Unlock()
+		if len(reported) == 1 {
+			if reported[0].InputTokens != 6 || reported[0].OutputTokens != 10 || reported[0].TotalTokens != 16 {
+				t.Fatalf("usage = %+v", reported[0])
+			}
+			if failed {
+				t.Fatal("normal session reported as failed")
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("usage reports = %d, want 1", len(reported))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestLiveWebsocket_ClosesOnCredentialCooldown(t *testing.T) {
+	previous := liveAuthCheckInterval
+	liveAuthCheckInterval = 20 * time.Millisecond
+	t.Cleanup(func() { liveAuthCheckInterval = previous })
+
+	_, manager, url := newLiveTestProxy(t, "live-auth-cooldown")
+	client, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial proxy: %v", err)
+	}
+	defer func() { _ = client.Close() }()
+	if err = client.WriteMessage(websocket.TextMessage, []byte(`{"setup":{"model":"live-test-model"}}`)); err != nil {
+		t.Fatalf("write setup: %v", err)
+	}
+	if _, _, err = client.ReadMessage(); err != nil {
+		t.Fatalf("read setupComplete: %v", err)
+	}
+
+	manager.MarkResult(context.Background(), coreauth.Result{
+		AuthID:   "live-auth-cooldown",
+		Provider: "live-test",
+		Model:    "live-test-model",
+		Error:    &coreauth.Error{Message: "quota", HTTPStatus: http.StatusTooManyRequests},
+	})
+
+	_ = client.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, _, err = client.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseTryAgainLater) {
+		t.Fatalf("read error = %v, want close %d", err, websocket.CloseTryAgainLater)
+	}
+}
+
+func TestLiveWebsocket_RejectsMissingSetup(t *testing.T) {
+	_, _, url := newLiveTestProxy(t, "live-auth-setup")
+	client, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial proxy: %v", err)
+	}
+	defer func() { _ = client.Close() }()
+	if err = client.WriteMessage(websocket.TextMessage, []byte(`{"realtimeInput":{}}`)); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	_, _, err = client.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.ClosePolicyViolation) {
+		t.Fatalf("read error = %v, want policy violation close", err)
+	}
+}
+
+func TestLiveWebsocket_ForwardsRequestedAPIVersion(t *testing.T) {
+	executor, _, url := newLiveTestProxy(t, "live-auth-version")
+	url = strings.TrimSuffix(url, "/ws/live") + "/ws/google.ai.generativelanguage.v1alpha.GenerativeService.BidiGenerateContent"
+	client, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial proxy: %v", err)
+	}
+	defer func() { _ = client.Close() }()
+	if err = client.WriteMessage(websocket.TextMessage, []byte(`{"setup":{"model":"live-test-model"}}`)); err != nil {
+		t.Fatalf("write setup: %v", err)
+	}
+	if _, _, err = client.ReadMessage(); err != nil {
+		t.Fatalf("read setupComplete: %v", err)
+	}
+	executor.mu.Lock()
+	versions := append([]string(nil), executor.apiVersions...)
+	executor.mu.Unlock()
+	if len(versions) != 1 || versions[0] != "v1alpha" {
+		t.Fatalf("api versions = %q, want [v1alpha]", versions)
+	}
+}
+
+func TestLiveWebsocket_RejectsForeignOrigin(t *testing.T) {
+	_, _, url := newLiveTestProxy(t, "live-auth-origin")
+	_, resp, err := websocket.DefaultDialer.Dial(url, http.Header{"Origin": {"https://evil.test"}})
+	if err == nil || resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("dial with foreign origin: err=%v resp=%v, want 403", err, resp)
+	}
+}
+
+func TestLiveWebsocket_ClosesOversizedClientFrames(t *testing.T) {
+	previous := liveClientReadLimit
+	liveClientReadLimit = 1024
+	t.Cleanup(func() { liveClientReadLimit = previous })
+
+	_, _, url := newLiveTestProxy(t, "live-auth-limit")
+	client, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial proxy: %v", err)
+	}
+	defer func() { _ = client.Close() }()
+	if err = client.WriteMessage(websocket.TextMessage, []byte(`{"setup":{"model":"live-test-model"}}`)); err != nil {
+		t.Fatalf("write setup: %v", err)
+	}
+	if _, _, err = client.ReadMessage(); err != nil {
+		t.Fatalf("read setupComplete: %v", err)
+	}
+	large := `{"realtimeInput":{"text":"` + strings.Repeat("a", 4096) + `"}}`
+	if err = client.WriteMessage(websocket.TextMessage, []byte(large)); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+	_ = client.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, _, err = client.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseMessageTooBig) {
+		t.Fatalf("read error = %v, want close %d", err, websocket.CloseMessageTooBig)
+	}
+}
+
+func TestLiveUpstreamCloseStatus(t *testing.T) {
+	cases := []struct {
+		code   int
+		reason string
+		want   int
+	}{
+		{websocket.CloseInternalServerErr, "RESOURCE_EXHAUSTED: quota exceeded", http.StatusTooManyRequests},
+		{websocket.ClosePolicyViolation, "API key not valid. Please pass a valid API key.", http.StatusUnauthorized},
+		{websocket.CloseNormalClosure, "quota", 0},
+		{websocket.CloseInternalServerErr, "internal error", 0},
+	}
+	for _, tc := range cases {
+		if got := liveUpstreamCloseStatus(tc.code, tc.reason); got != tc.want {
+			t.Errorf("liveUpstreamCloseStatus(%d, %q) = %d, want %d", tc.code, tc.reason, got, tc.want)
+		}
+	}
+}
@@ -14,8 +14,12 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/filestore"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/hooks"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/mcp"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
@@ -55,6 +59,9 @@ const (
 type pinnedAuthContextKey struct{}
 type selectedAuthCallbackContextKey struct{}
 type executionSessionContextKey struct{}
+type cachedContentPrefixContextKey struct{}
+type providerFilterContextKey struct{}
+type audioEndpointContextKey struct{}
 
 // WithPinnedAuthID returns a child context that requests execution on a specific auth ID.
 func WithPinnedAuthID(ctx context.Context, authID string) context.Context {
@@ -91,6 +98,66 @@ func WithExecutionSessionID(ctx context.Context, sessionID string) context.Conte
 	return context.WithValue(ctx, executionSessionContextKey{}, sessionID)
 }
 
+// WithCachedContentPrefix returns a child context recording that the first contents entries of
+// the request were expanded from an emulated cachedContent.
+func WithCachedContentPrefix(ctx context.Context, contents int) context.Context {
+	if contents <= 0 {
+		return ctx
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, cachedContentPrefixContextKey{}, contents)
+}
+
+// WithProviderFilter returns a child context restricting execution to the providers allow accepts,
+// for endpoints whose request format only some of a model's providers understand.
+func WithProviderFilter(ctx context.Context, allow func(provider string) bool) context.Context {
+	if allow == nil {
+		return ctx
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, providerFilterContextKey{}, allow)
+}
+
+// WithAudioEndpoint returns a child context marking a request made by the audio endpoints, the
+// only ones allowed to execute audio-only models such as whisper-1 or tts-1.
+func WithAudioEndpoint(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, audioEndpointContextKey{}, true)
+}
+
+// filterProviders applies the endpoint restrictions carried by the context: audio-only models
+// are rejected outside the audio endpoints, and the provider filter must leave a provider.
+func filterProviders(ctx context.Context, modelName string, providers []string) ([]string, *interfaces.ErrorMessage) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if audio, _ := ctx.Value(audioEndpointContextKey{}).(bool); !audio {
+		if baseModel := thinking.ParseSuffix(modelName).ModelName; registry.GetGlobalRegistry().IsAudioOnlyModel(baseModel) {
+			return nil, &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: fmt.Errorf("model %s is only available on the audio endpoints", modelName)}
+		}
+	}
+	allow, ok := ctx.Value(providerFilterContextKey{}).(func(string) bool)
+	if !ok || allow == nil {
+		return providers, nil
+	}
+	filtered := make([]string, 0, len(providers))
+	for _, provider := range providers {
+		if allow(provider) {
+			filtered = append(filtered, provider)
+		}
+	}
+	if len(filtered) == 0 {
+		return nil, &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: fmt.Errorf("no provider of model %s supports this endpoint", modelName)}
+	}
+	return filtered, nil
+}
+
 // BuildErrorResponseBody builds an OpenAI-compatible JSON error response body.
 // If errText is already valid JSON, it is returned as-is to preserve upstream error payloads.
 func BuildErrorResponseBody(status int, errText string) []byte {
@@ -179,6 +246,14 @@ func StreamingBootstrapRetries(cfg *config.SDKConfig) int {
 	return retries
 }
 
+// StreamingMidStreamRetries returns how many times a streaming request may be resumed after bytes were sent.
+func StreamingMidStreamRetries(cfg *config.SDKConfig) int {
+	if cfg == nil || cfg.Streaming.MidStreamRetries < 0 {
+		return 0
+	}
+	return cfg.Streaming.MidStreamRetries
+}
+
 // PassthroughHeadersEnabled returns whether upstream response headers should be forwarded to clients.
 // Default is false.
 func PassthroughHeadersEnabled(cfg *config.SDKConfig) bool {
@@ -216,6 +291,11 @@ func requestExecutionMetadata(ctx context.Context) map[string]any {
 	if executionSessionID := executionSessionIDFromContext(ctx); executionSessionID != "" {
 		meta[coreexecutor.ExecutionSessionMetadataKey] = executionSessionID
 	}
+	if ctx != nil {
+		if prefix, ok := ctx.Value(cachedContentPrefixContextKey{}).(int); ok && prefix > 0 {
+			meta[coreexecutor.CachedContentPrefixMetadataKey] = prefix
+		}
+	}
 	return meta
 }
 
@@ -269,6 +349,12 @@ type BaseAPIHandler struct {
 
 	// Cfg holds the current application configuration.
 	Cfg *config.SDKConfig
+
+	mcpMu    sync.Mutex
+	mcpTools *mcp.Manager
+
+	hooksMu sync.Mutex
+	hooks   *hooks.Manager
 }
 
 // NewBaseAPIHandlers creates a new API handlers instance.
@@ -294,7 +380,35 @@ func NewBaseAPIHandlers(cfg *config.SDKConfig, authManager *coreauth.Manager) *B
 // Parameters:
 //   - clients: The new slice of AI service clients
 //   - cfg: The new application configuration
-func (h *BaseAPIHandler) UpdateClients(cfg *config.SDKConfig) { h.Cfg = cfg }
+func (h *BaseAPIHandler) UpdateClients(cfg *config.SDKConfig) {
+	h.Cfg = cfg
+	h.hooksMu.Lock()
+	if h.hooks != nil && cfg != nil {
+		h.hooks.Update(cfg.Hooks)
+	}
+	h.hooksMu.Unlock()
+	h.mcpMu.Lock()
+	defer h.mcpMu.Unlock()
+	if h.mcpTools == nil {
+		return
+	}
+	if cfg == nil || !cfg.MCP.Enabled() {
+		h.mcpTools.Close()
+		h.mcpTools = nil
+		return
+	}
+	h.mcpTools.Update(cfg.MCP)
+}
+
+// Close releases the MCP server connections opened by the tool bridge.
+func (h *BaseAPIHandler) Close() {
+	h.mcpMu.Lock()
+	defer h.mcpMu.Unlock()
+	if h.mcpTools != nil {
+		h.mcpTools.Close()
+		h.mcpTools = nil
+	}
+}
 
 // GetAlt extracts the 'alt' parameter from the request query string.
 // It checks both 'alt' and '$alt' parameters and returns the appropriate value.
@@ -475,13 +589,44 @@ func appendAPIResponse(c *gin.Context, data []byte) {
 	c.Set("API_RESPONSE", bytes.Clone(data))
 }
 
+// resolveFileReferences inlines files from the proxy file store that the request references by
+// file_id, since no backend can see proxy-issued IDs. Only files uploaded with the request's
+// client key are inlined.
+func resolveFileReferences(ctx context.Context, handlerType string, rawJSON []byte) ([]byte, *interfaces.ErrorMessage) {
+	ginCtx, _ := ctx.Value("gin").(*gin.Context)
+	resolved, err := filestore.ResolveReferences(ctx, ClientKeyOwner(ginCtx), handlerType, rawJSON)
+	if err != nil {
+		return rawJSON, &interfaces.ErrorMessage{StatusCode: http.StatusInternalServerError, Error: err}
+	}
+	return resolved, nil
+}
+
 // ExecuteWithAuthManager executes a non-streaming request via the core auth manager.
 // This path is the only supported execution route.
 func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, http.Header, *interfaces.ErrorMessage) {
+	rawJSON, errMsg := resolveFileReferences(ctx, handlerType, rawJSON)
+	if errMsg != nil {
+		return nil, nil, errMsg
+	}
+	if rh := h.hooksFor(ctx, handlerType, modelName, false); rh != nil {
+		return h.executeWithHooks(ctx, rh, rawJSON, func(hookedCtx context.Context, payload []byte) ([]byte, http.Header, *interfaces.ErrorMessage) {
+			return h.ExecuteWithAuthManager(hookedCtx, handlerType, modelName, payload, alt)
+		})
+	}
+	if bridge := h.mcpBridgeFor(ctx, handlerType, modelName, rawJSON); bridge != nil {
+		return h.executeWithMCPTools(ctx, bridge, handlerType, modelName, rawJSON, alt)
+	}
 	providers, normalizedModel, errMsg := h.getRequestDetails(modelName)
 	if errMsg != nil {
 		return nil, nil, errMsg
 	}
+	setModelLifecycleHeaders(ctx, modelName)
+	if providers, errMsg = filterProviders(ctx, modelName, providers); errMsg != nil {
+		return nil, nil, errMsg
+	}
+	if providers, errMsg = h.checkTranslation(ctx, handlerType, providers, rawJSON); errMsg != nil {
+		return nil, nil, errMsg
+	}
 	reqMeta := requestExecutionMetadata(ctx)
 	reqMeta[coreexecutor.RequestedModelMetadataKey] = normalizedModel
 	payload := rawJSON
@@ -524,10 +669,18 @@ func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType
 // ExecuteCountWithAuthManager executes a non-streaming request via the core auth manager.
 // This path is the only supported execution route.
 func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, http.Header, *interfaces.ErrorMessage) {
+	rawJSON, errMsg := resolveFileReferences(ctx, handlerType, rawJSON)
+	if errMsg != nil {
+		return nil, nil, errMsg
+	}
 	providers, normalizedModel, errMsg := h.getRequestDetails(modelName)
 	if errMsg != nil {
 		return nil, nil, errMsg
 	}
+	setModelLifecycleHeaders(ctx, modelName)
+	if providers, errMsg = filterProviders(ctx, modelName, providers); errMsg != nil {
+		return nil, nil, errMsg
+	}
 	reqMeta := requestExecutionMetadata(ctx)
 	reqMeta[coreexecutor.RequestedModelMetadataKey] = normalizedModel
 	payload := rawJSON
@@ -571,7 +724,27 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 // This path is the only supported execution route.
 // The returned http.Header carries upstream response headers captured before streaming begins.
 func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) (<-chan []byte, http.Header, <-chan *interfaces.ErrorMessage) {
+	rawJSON, errMsg := resolveFileReferences(ctx, handlerType, rawJSON)
+	if errMsg != nil {
+		errChan := make(chan *interfaces.ErrorMessage, 1)
+		errChan <- errMsg
+		close(errChan)
+		return nil, nil, errChan
+	}
+	if rh := h.hooksFor(ctx, handlerType, modelName, true); rh != nil {
+		return h.executeStreamWithHooks(ctx, rh, handlerType, modelName, rawJSON, alt)
+	}
+	if bridge := h.mcpBridgeFor(ctx, handlerType, modelName, rawJSON); bridge != nil {
+		return h.executeStreamWithMCPTools(ctx, bridge, handlerType, modelName, rawJSON, alt)
+	}
 	providers, normalizedModel, errMsg := h.getRequestDetails(modelName)
+	if errMsg == nil {
+		setModelLifecycleHeaders(ctx, modelName)
+		providers, errMsg = filterProviders(ctx, modelName, providers)
+	}
+	if errMsg == nil {
+		providers, errMsg = h.checkTranslation(ctx, handlerType, providers, rawJSON)
+	}
 	if errMsg != nil {
 		errChan := make(chan *interfaces.ErrorMessage, 1)
 		errChan <- errMsg
@@ -633,6 +806,13 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 		sentPayload := false
 		bootstrapRetries := 0
 		maxBootstrapRetries := StreamingBootstrapRetries(h.Cfg)
+		midStreamRetries := 0
+		maxMidStreamRetries := StreamingMidStreamRetries(h.Cfg)
+		var continuation *streamContinuation
+		if maxMidStreamRetries > 0 && providersSupportContinuation(providers) {
+			continuation = newStreamContinuation(handlerType)
+		}
+		var splicer streamSplicer
 
 		sendErr := func(msg *interfaces.ErrorMessage) bool {
 			if ctx == nil {
@@ -708,6 +888,23 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 							}
 							streamErr = retryErr
 						}
+					} else if midStreamRetries < maxMidStreamRetries && continuation.canResume() && bootstrapEligible(streamErr) {
+						// Mid-stream failover: resume the partial answer on another credential
+						// and splice the continuation into the client's stream.
+						midStreamRetries++
+						resumed, preamble, retryErr := h.resumeStream(ctx, providers, req, opts, continuation, streamErr)
+						if retryErr == nil {
+							if len(preamble) > 0 {
+								continuation.observe(preamble)
+								if okSendData := sendData(preamble); !okSendData {
+									return
+								}
+							}
+							splicer = resumed.splicer
+							chunks = resumed.chunks
+							continue outer
+						}
+						streamErr = retryErr
 					}
 
 					status := http.StatusInternalServerError
@@ -725,7 +922,11 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 					_ = sendErr(&interfaces.ErrorMessage{StatusCode: status, Error: streamErr, Addon: addon})
 					return
 				}
+				if len(chunk.Payload) > 0 && splicer != nil {
+					chunk.Payload = splicer.splice(chunk.Payload)
+				}
 				if len(chunk.Payload) > 0 {
+					continuation.observe(chunk.Payload)
 					if handlerType == "openai-response" {
 						if err := validateSSEDataJSON(chunk.Payload); err != nil {
 							_ = sendErr(&interfaces.ErrorMessage{StatusCode: http.StatusBadGateway, Error: err})
@@ -801,6 +1002,19 @@ func (h *BaseAPIHandler) getRequestDetails(modelName string) (providers []string
 	parsed := thinking.ParseSuffix(resolvedModelName)
 	baseModel := strings.TrimSpace(parsed.ModelName)
 
+	replacement, errMsg := h.resolveRetiredModel(baseModel, time.Now())
+	if errMsg != nil {
+		return nil, "", errMsg
+	}
+	if replacement != baseModel {
+		baseModel = replacement
+		if parsed.HasSuffix {
+			resolvedModelName = fmt.Sprintf("%s(%s)", replacement, parsed.RawSuffix)
+		} else {
+			resolvedModelName = replacement
+		}
+	}
+
 	providers = util.GetProviderName(baseModel)
 	// Fallback: if baseModel has no provider but differs from resolvedModelName,
 	// try using the full model name. This handles edge cases where custom models
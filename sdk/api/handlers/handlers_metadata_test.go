@@ -83,3 +83,15 @@ func TestRequestExecutionMetadata_UsesProvidedIdempotencyKeyForRetries(t *testin
 		t.Fatalf("idempotency key should be stable for retry requests: got %q and %q", firstKey, secondKey)
 	}
 }
+
+func TestRequestExecutionMetadata_IncludesCachedContentPrefix(t *testing.T) {
+	meta := requestExecutionMetadata(WithCachedContentPrefix(context.Background(), 3))
+	if got, _ := meta[coreexecutor.CachedContentPrefixMetadataKey].(int); got != 3 {
+		t.Fatalf("cached content prefix = %v, want 3", meta[coreexecutor.CachedContentPrefixMetadataKey])
+	}
+
+	meta = requestExecutionMetadata(WithCachedContentPrefix(context.Background(), 0))
+	if _, ok := meta[coreexecutor.CachedContentPrefixMetadataKey]; ok {
+		t.Fatal("zero prefix should not be recorded")
+	}
+}
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"context"
+	"net/http"
 	"reflect"
 	"testing"
 	"time"
@@ -116,3 +118,32 @@ func TestGetRequestDetails_PreservesSuffix(t *testing.T) {
 		})
 	}
 }
+
+func TestFilterProviders(t *testing.T) {
+	providers := []string{"gemini", "openai-compatibility"}
+
+	if got, errMsg := filterProviders(context.Background(), "m", providers); errMsg != nil || !reflect.DeepEqual(got, providers) {
+		t.Fatalf("unfiltered = %v, %v", got, errMsg)
+	}
+	ctx := WithProviderFilter(context.Background(), func(provider string) bool { return provider == "gemini" })
+	if got, errMsg := filterProviders(ctx, "m", providers); errMsg != nil || !reflect.DeepEqual(got, []string{"gemini"}) {
+		t.Fatalf("filtered = %v, %v", got, errMsg)
+	}
+	ctx = WithProviderFilter(context.Background(), func(string) bool { return false })
+	if _, errMsg := filterProviders(ctx, "m", providers); errMsg == nil || errMsg.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 when no provider is left, got %+v", errMsg)
+	}
+}
+
+func TestFilterProviders_AudioOnlyModels(t *testing.T) {
+	modelRegistry := registry.GetGlobalRegistry()
+	modelRegistry.RegisterClient("test-audio-only", "codex", registry.GetOpenAIAudioModels())
+	t.Cleanup(func() { modelRegistry.UnregisterClient("test-audio-only") })
+
+	if _, errMsg := filterProviders(context.Background(), "whisper-1", []string{"codex"}); errMsg == nil || errMsg.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an audio-only model outside the audio endpoints, got %+v", errMsg)
+	}
+	if got, errMsg := filterProviders(WithAudioEndpoint(context.Background()), "whisper-1", []string{"codex"}); errMsg != nil || !reflect.DeepEqual(got, []string{"codex"}) {
+		t.Fatalf("audio endpoint = %v, %v", got, errMsg)
+	}
+}
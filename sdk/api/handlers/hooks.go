@@ -0,0 +1,333 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/hooks"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+// hooksContextKey marks the execution wrapped by request hooks so it is not hooked again.
+type hooksContextKey struct{}
+
+// requestHooks holds the hooks applying to one request.
+type requestHooks struct {
+	manager *hooks.Manager
+	pre     []config.HookConfig
+	post    []config.HookConfig
+	call    hooks.Call
+}
+
+// hookManager returns the hook manager, creating it on first use.
+func (h *BaseAPIHandler) hookManager() *hooks.Manager {
+	h.hooksMu.Lock()
+	defer h.hooksMu.Unlock()
+	if h.hooks == nil {
+		h.hooks = hooks.NewManager(h.Cfg.Hooks)
+	}
+	return h.hooks
+}
+
+// hooksFor resolves the hooks for a request, or nil when none apply.
+func (h *BaseAPIHandler) hooksFor(ctx context.Context, handlerType, modelName string, stream bool) *requestHooks {
+	if ctx == nil || h.Cfg == nil || len(h.Cfg.Hooks) == 0 {
+		return nil
+	}
+	if ctx.Value(hooksContextKey{}) != nil {
+		return nil
+	}
+	call := hooks.Call{SourceFormat: handlerType, Model: modelName, Stream: stream}
+	var apiKey string
+	if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil {
+		call.RequestID = logging.GetGinRequestID(ginCtx)
+		if v, exists := ginCtx.Get("apiKey"); exists {
+			if s, okString := v.(string); okString {
+				apiKey = s
+			}
+		}
+		call.KeyName = hookKeyName(h.Cfg, ginCtx, apiKey)
+	}
+	manager := h.hookManager()
+	rh := &requestHooks{
+		manager: manager,
+		pre:     manager.Matching(config.HookStagePreRequest, handlerType, modelName, apiKey),
+		post:    manager.Matching(config.HookStagePostResponse, handlerType, modelName, apiKey),
+		call:    call,
+	}
+	if len(rh.pre) == 0 && len(rh.post) == 0 {
+		return nil
+	}
+	return rh
+}
+
+// hookKeyName identifies the client key to hooks without revealing it: the key_name recorded by
+// the access provider, else the api-key-names entry or fingerprint of the key.
+func hookKeyName(cfg *config.SDKConfig, ginCtx *gin.Context, apiKey string) string {
+	if v, exists := ginCtx.Get("accessMetadata"); exists {
+		if metadata, ok := v.(map[string]string); ok && metadata["key_name"] != "" {
+			return metadata["key_name"]
+		}
+	}
+	return cfg.APIKeyName(apiKey)
+}
+
+// before runs the pre-request hooks and returns the request body to execute.
+func (rh *requestHooks) before(ctx context.Context, rawJSON []byte) ([]byte, *interfaces.ErrorMessage) {
+	if len(rh.pre) == 0 {
+		return rawJSON, nil
+	}
+	call := rh.call
+	call.Stage = config.HookStagePreRequest
+	body, rejection := rh.manager.Apply(ctx, rh.pre, call, rawJSON)
+	if rejection != nil {
+		return nil, rejection.ErrorMessage()
+	}
+	return body, nil
+}
+
+// after runs post-response hooks on a complete response body.
+func (rh *requestHooks) after(ctx context.Context, post []config.HookConfig, rawJSON, body []byte, usage *coreusage.Detail) ([]byte, *interfaces.ErrorMessage) {
+	if len(post) == 0 {
+		return body, nil
+	}
+	call := rh.call
+	call.Stage = config.HookStagePostResponse
+	call.Body = nil
+	if len(rawJSON) > 0 {
+		call.Body = rawJSON
+	}
+	call.Usage = usage
+	result, rejection := rh.manager.Apply(ctx, post, call, body)
+	if rejection != nil {
+		return nil, rejection.ErrorMessage()
+	}
+	return result, nil
+}
+
+// usageFromContext returns the token usage the executor recorded for the request, if any.
+func usageFromContext(ctx context.Context) *coreusage.Detail {
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil {
+		return nil
+	}
+	value, exists := ginCtx.Get(logging.UsageRecordContextKey)
+	if !exists {
+		return nil
+	}
+	record, ok := value.(coreusage.Record)
+	if !ok {
+		return nil
+	}
+	detail := record.Detail
+	return &detail
+}
+
+// hookedExecution executes a request body whose hooks have already run.
+type hookedExecution func(ctx context.Context, payload []byte) ([]byte, http.Header, *interfaces.ErrorMessage)
+
+// executeWithHooks runs a non-streaming request between its pre-request and post-response hooks.
+// execute gets a context marked as hooked, so the executions it makes (structured-output repairs,
+// fan-out branches and judge, MCP bridge iterations) do not run the hooks again.
+func (h *BaseAPIHandler) executeWithHooks(ctx context.Context, rh *requestHooks, rawJSON []byte, execute hookedExecution) ([]byte, http.Header, *interfaces.ErrorMessage) {
+	payload, errMsg := rh.before(ctx, rawJSON)
+	if errMsg != nil {
+		return nil, nil, errMsg
+	}
+	hookedCtx := context.WithValue(ctx, hooksContextKey{}, true)
+	body, headers, errMsg := execute(hookedCtx, payload)
+	if errMsg != nil {
+		return nil, nil, errMsg
+	}
+	body, errMsg = rh.after(ctx, rh.post, payload, body, usageFromContext(ctx))
+	if errMsg != nil {
+		return nil, nil, errMsg
+	}
+	return body, headers, nil
+}
+
+// executeStreamWithHooks runs a streaming request between its hooks. Post-response hooks in
+// buffer mode hold the stream until they have approved, rejected or replaced it; hooks in observe
+// mode are notified in the background once the stream has been delivered. Neither runs when the
+// stream fails.
+func (h *BaseAPIHandler) executeStreamWithHooks(ctx context.Context, rh *requestHooks, handlerType, modelName string, rawJSON []byte, alt string) (<-chan []byte, http.Header, <-chan *interfaces.ErrorMessage) {
+	payload, errMsg := rh.before(ctx, rawJSON)
+	if errMsg != nil {
+		dataChan := make(chan []byte)
+		close(dataChan)
+		errChan := make(chan *interfaces.ErrorMessage, 1)
+		errChan <- errMsg
+		close(errChan)
+		return dataChan, nil, errChan
+	}
+	hookedCtx := context.WithValue(ctx, hooksContextKey{}, true)
+	upstreamData, headers, upstreamErrs := h.ExecuteStreamWithAuthManager(hookedCtx, handlerType, modelName, payload, alt)
+	if len(rh.post) == 0 {
+		return upstreamData, headers, upstreamErrs
+	}
+
+	var buffered, observed []config.HookConfig
+	for _, hook := range rh.post {
+		if hook.StreamMode == config.HookStreamBuffer {
+			buffered = append(buffered, hook)
+		} else {
+			observed = append(observed, hook)
+		}
+	}
+
+	dataChan := make(chan []byte)
+	errChan := make(chan *interfaces.ErrorMessage, 1)
+	go func() {
+		defer close(dataChan)
+		defer close(errChan)
+		send := func(chunk []byte) bool {
+			select {
+			case <-ctx.Done():
+				return false
+			case dataChan <- chunk:
+				return true
+			}
+		}
+
+		var chunks [][]byte
+		if upstreamData != nil {
+			for chunk := range upstreamData {
+				chunks = append(chunks, chunk)
+				if len(buffered) == 0 && !send(chunk) {
+					return
+				}
+			}
+		}
+		for errMsg := range upstreamErrs {
+			if errMsg != nil {
+				errChan <- errMsg
+				return
+			}
+		}
+
+		framing := hookStreamFramingFor(handlerType, alt)
+		body := joinHookStream(framing, chunks)
+		usage := usageFromContext(ctx)
+		if len(buffered) > 0 {
+			result, errMsg := rh.after(ctx, buffered, payload, body, usage)
+			if errMsg != nil {
+				errChan <- errMsg
+				return
+			}
+			if bytes.Equal(result, body) {
+				for _, chunk := range chunks {
+					if !send(chunk) {
+						return
+					}
+				}
+			} else {
+				for _, chunk := range splitHookStream(framing, result) {
+					if !send(chunk) {
+						return
+					}
+				}
+				body = result
+			}
+		}
+		if len(observed) > 0 {
+			// The Gin context may be recycled once the response is done, so the observers
+			// only get what was captured above.
+			go func() {
+				_, _ = rh.after(context.WithoutCancel(ctx), observed, payload, body, usage)
+			}()
+		}
+	}()
+	return dataChan, headers, errChan
+}
+
+// hookStreamFraming describes how the chunks of a handler's stream map onto the SSE body shown
+// to post-response hooks.
+type hookStreamFraming int
+
+const (
+	// hookStreamRaw chunks concatenate to the body, e.g. Gemini streams requested with alt.
+	hookStreamRaw hookStreamFraming = iota
+	// hookStreamData chunks are bare JSON payloads the handler frames as "data:" events.
+	hookStreamData
+	// hookStreamEvents chunks are complete SSE events ending with a blank line.
+	hookStreamEvents
+	// hookStreamEventLines chunks are SSE event lines the handler separates with blank lines.
+	hookStreamEventLines
+)
+
+// hookStreamFramingFor returns the framing of the chunks streamed for handlerType.
+func hookStreamFramingFor(handlerType, alt string) hookStreamFraming {
+	if alt != "" {
+		return hookStreamRaw
+	}
+	switch handlerType {
+	case "openai", "gemini", "gemini-cli":
+		return hookStreamData
+	case "claude":
+		return hookStreamEvents
+	case "openai-response":
+		return hookStreamEventLines
+	default:
+		return hookStreamRaw
+	}
+}
+
+// joinHookStream renders chunks as the SSE body the client would receive.
+func joinHookStream(framing hookStreamFraming, chunks [][]byte) []byte {
+	if framing == hookStreamRaw {
+		return bytes.Join(chunks, nil)
+	}
+	var body bytes.Buffer
+	for _, chunk := range chunks {
+		event := bytes.TrimRight(chunk, "\r\n")
+		if len(bytes.TrimSpace(event)) == 0 {
+			continue
+		}
+		if framing == hookStreamData && !bytes.HasPrefix(event, []byte("data:")) {
+			body.WriteString("data: ")
+		}
+		body.Write(event)
+		body.WriteString("\n\n")
+	}
+	return body.Bytes()
+}
+
+// splitHookStream turns an SSE body returned by a hook back into chunks of the handler's framing.
+func splitHookStream(framing hookStreamFraming, body []byte) [][]byte {
+	if framing == hookStreamRaw {
+		return [][]byte{body}
+	}
+	normalized := bytes.ReplaceAll(body, []byte("\r\n"), []byte("\n"))
+	var chunks [][]byte
+	for _, event := range bytes.Split(normalized, []byte("\n\n")) {
+		event = bytes.Trim(event, "\n")
+		if len(bytes.TrimSpace(event)) == 0 {
+			continue
+		}
+		switch framing {
+		case hookStreamData:
+			var data [][]byte
+			for _, line := range bytes.Split(event, []byte("\n")) {
+				if payload, ok := bytes.CutPrefix(line, []byte("data:")); ok {
+					data = append(data, bytes.TrimSpace(payload))
+				}
+			}
+			payload := bytes.Join(data, []byte("\n"))
+			// The handler writes its own terminator.
+			if len(payload) == 0 || bytes.Equal(payload, []byte("[DONE]")) {
+				continue
+			}
+			chunks = append(chunks, payload)
+		case hookStreamEvents:
+			chunks = append(chunks, append(bytes.Clone(event), "\n\n"...))
+		default:
+			chunks = append(chunks, event)
+		}
+	}
+	return chunks
+}
@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/hooks"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+type hookTestExecutor struct {
+	mu       sync.Mutex
+	payloads [][]byte
+	// streamChunks replaces the default Claude SSE chunks returned by ExecuteStream.
+	streamChunks []string
+}
+
+func (e *hookTestExecutor) Identifier() string { return "claude" }
+
+func (e *hookTestExecutor) record(payload []byte) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.payloads = append(e.payloads, payload)
+}
+
+func (e *hookTestExecutor) Execute(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	e.record(req.Payload)
+	return coreexecutor.Response{Payload: []byte(`{"content":"secret answer"}`)}, nil
+}
+
+func (e *hookTestExecutor) ExecuteStream(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	e.record(req.Payload)
+	chunks := e.streamChunks
+	if chunks == nil {
+		chunks = []string{"data: {\"text\":\"secret\"}\n\n", "data: {\"text\":\"answer\"}\n\n"}
+	}
+	ch := make(chan coreexecutor.StreamChunk, len(chunks))
+	for _, chunk := range chunks {
+		ch <- coreexecutor.StreamChunk{Payload: []byte(chunk)}
+	}
+	close(ch)
+	return &coreexecutor.StreamResult{Chunks: ch}, nil
+}
+
+func (e *hookTestExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *hookTestExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func (e *hookTestExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "HttpRequest not implemented", HTTPStatus: http.StatusNotImplemented}
+}
+
+func (e *hookTestExecutor) Payloads() [][]byte {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([][]byte(nil), e.payloads...)
+}
+
+// newHookTestServer tags requests with a tenant, rejects prompts mentioning "forbidden" and
+// redacts "secret" from responses.
+func newHookTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var call hooks.Call
+		_ = json.NewDecoder(r.Body).Decode(&call)
+		if call.Stage == sdkconfig.HookStagePreRequest {
+			if strings.Contains(string(call.Body), "forbidden") {
+				_, _ = w.Write([]byte(`{"action":"reject","message":"forbidden prompt"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"action":"modify","body":{"model":"hook-model","tenant":"acme"}}`))
+			return
+		}
+		response := string(call.Response)
+		if call.Stream {
+			_ = json.Unmarshal(call.Response, &response)
+		}
+		redacted, _ := json.Marshal(strings.ReplaceAll(response, "secret", "[redacted]"))
+		if !call.Stream {
+			redacted = []byte(strings.ReplaceAll(response, "secret", "[redacted]"))
+		}
+		_, _ = w.Write([]byte(`{"action":"modify","body":` + string(redacted) + `}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newHooksTestHandler(t *testing.T, authID, streamMode string) (*BaseAPIHandler, *hookTestExecutor) {
+	t.Helper()
+	server := newHookTestServer(t)
+	executor := &hookTestExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	if _, err := manager.Register(context.Background(), &coreauth.Auth{ID: authID, Provider: "claude", Status: coreauth.StatusActive}); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(authID, "claude", []*registry.ModelInfo{{ID: "hook-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(authID) })
+
+	cfg := &sdkconfig.Config{}
+	cfg.Hooks = []sdkconfig.HookConfig{{Name: "policy", URL: server.URL, StreamMode: streamMode}}
+	cfg.SanitizeHooks()
+	return NewBaseAPIHandlers(&cfg.SDKConfig, manager), executor
+}
+
+func TestExecuteWithAuthManager_HooksModifyAndReject(t *testing.T) {
+	handler, executor := newHooksTestHandler(t, "hooks-auth1", "")
+
+	body, _, errMsg := handler.ExecuteWithAuthManager(context.Background(), "claude", "hook-model", []byte(`{"model":"hook-model"}`), "")
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %+v", errMsg)
+	}
+	if string(body) != `{"content":"[redacted] answer"}` {
+		t.Fatalf("body = %s", body)
+	}
+	if payloads := executor.Payloads(); len(payloads) != 1 || string(payloads[0]) != `{"model":"hook-model","tenant":"acme"}` {
+		t.Fatalf("executed payloads = %q", payloads)
+	}
+
+	_, _, errMsg = handler.ExecuteWithAuthManager(context.Background(), "claude", "hook-model", []byte(`{"prompt":"forbidden"}`), "")
+	if errMsg == nil || errMsg.StatusCode != http.StatusForbidden || errMsg.Error.Error() != "forbidden prompt" {
+		t.Fatalf("expected rejection, got %+v", errMsg)
+	}
+	if len(executor.Payloads()) != 1 {
+		t.Fatalf("rejected request reached the executor")
+	}
+}
+
+func TestExecuteStreamWithAuthManager_HooksBufferReplacesStream(t *testing.T) {
+	handler, _ := newHooksTestHandler(t, "hooks-auth2", sdkconfig.HookStreamBuffer)
+
+	dataChan, _, errChan := handler.ExecuteStreamWithAuthManager(context.Background(), "claude", "hook-model", []byte(`{"model":"hook-model"}`), "")
+	var out strings.Builder
+	for chunk := range dataChan {
+		out.Write(chunk)
+	}
+	for msg := range errChan {
+		if msg != nil {
+			t.Fatalf("unexpected error: %+v", msg)
+		}
+	}
+	if got := out.String(); got != "data: {\"text\":\"[redacted]\"}\n\ndata: {\"text\":\"answer\"}\n\n" {
+		t.Fatalf("stream = %q", got)
+	}
+}
+
+func TestExecuteStreamWithAuthManager_HooksBufferReplacesOpenAIChunks(t *testing.T) {
+	handler, executor := newHooksTestHandler(t, "hooks-auth4", sdkconfig.HookStreamBuffer)
+	executor.streamChunks = []string{`{"text":"secret"}`, `{"text":"answer"}`}
+
+	dataChan, _, errChan := handler.ExecuteStreamWithAuthManager(context.Background(), "openai", "hook-model", []byte(`{"model":"hook-model"}`), "")
+	var chunks []string
+	for chunk := range dataChan {
+		chunks = append(chunks, string(chunk))
+	}
+	for msg := range errChan {
+		if msg != nil {
+			t.Fatalf("unexpected error: %+v", msg)
+		}
+	}
+	if len(chunks) != 2 || chunks[0] != `{"text":"[redacted]"}` || chunks[1] != `{"text":"answer"}` {
+		t.Fatalf("chunks = %q", chunks)
+	}
+}
+
+func TestSplitHookStreamFramesOpenAIEvents(t *testing.T) {
+	chunks := [][]byte{[]byte(`{"id":"1"}`), []byte(`{"id":"2"}`)}
+	body := joinHookStream(hookStreamData, chunks)
+	if string(body) != "data: {\"id\":\"1\"}\n\ndata: {\"id\":\"2\"}\n\n" {
+		t.Fatalf("joined body = %q", body)
+	}
+	split := splitHookStream(hookStreamData, append(body, "data: [DONE]\n\n"...))
+	if len(split) != 2 || string(split[0]) != `{"id":"1"}` || string(split[1]) != `{"id":"2"}` {
+		t.Fatalf("split chunks = %q", split)
+	}
+}
+
+func TestExecuteStreamWithAuthManager_HooksObserveStreamsUnchanged(t *testing.T) {
+	handler, _ := newHooksTestHandler(t, "hooks-auth3", sdkconfig.HookStreamObserve)
+
+	dataChan, _, errChan := handler.ExecuteStreamWithAuthManager(context.Background(), "claude", "hook-model", []byte(`{"model":"hook-model"}`), "")
+	var out strings.Builder
+	for chunk := range dataChan {
+		out.Write(chunk)
+	}
+	for msg := range errChan {
+		if msg != nil {
+			t.Fatalf("unexpected error: %+v", msg)
+		}
+	}
+	if got := out.String(); got != "data: {\"text\":\"secret\"}\n\ndata: {\"text\":\"answer\"}\n\n" {
+		t.Fatalf("stream = %q", got)
+	}
+}
+
+func TestExecuteWithStructuredOutput_RunsHooksOncePerRequest(t *testing.T) {
+	var mu sync.Mutex
+	var calls []hooks.Call
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var call hooks.Call
+		_ = json.NewDecoder(r.Body).Decode(&call)
+		mu.Lock()
+		calls = append(calls, call)
+		mu.Unlock()
+	}))
+	t.Cleanup(server.Close)
+
+	handler, executor := newStructuredOutputTestHandler(t, "hooks-structured", 1, `{"colour":"red"}`, `{"name":"red"}`)
+	handler.Cfg.Hooks = []sdkconfig.HookConfig{{Name: "audit", Transport: sdkconfig.HookTransportHTTP, URL: server.URL}}
+
+	ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ginCtx.Set("apiKey", "sk-client-secret")
+	ginCtx.Set("accessMetadata", map[string]string{"key_name": "team-a"})
+	ctx := context.WithValue(context.Background(), "gin", ginCtx)
+
+	if _, _, errMsg := handler.ExecuteWithStructuredOutput(ctx, "openai", "structured-model", []byte(structuredOutputTestRequest), ""); errMsg != nil {
+		t.Fatalf("unexpected error: %v", errMsg.Error)
+	}
+	if len(executor.payloads) != 2 {
+		t.Fatalf("executor calls = %d, want 2", len(executor.payloads))
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(calls) != 2 || calls[0].Stage != sdkconfig.HookStagePreRequest || calls[1].Stage != sdkconfig.HookStagePostResponse {
+		t.Fatalf("hook calls = %+v, want one pre-request and one post-response", calls)
+	}
+	for _, call := range calls {
+		if call.KeyName != "team-a" {
+			t.Fatalf("hook key name = %q, want team-a", call.KeyName)
+		}
+	}
+}
@@ -0,0 +1,777 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/mcp"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+	"golang.org/x/net/context"
+)
+
+// mcpBridgeContextKey marks model calls issued by the MCP tool loop so they are not bridged again.
+type mcpBridgeContextKey struct{}
+
+// mcpToolCall is a tool call requested by the model.
+type mcpToolCall struct {
+	id        string
+	name      string
+	arguments json.RawMessage
+}
+
+// mcpTurn is one model response inspected by the tool loop.
+type mcpTurn struct {
+	calls []mcpToolCall
+	// assistant holds the format-specific JSON that replays the model turn in the next request.
+	assistant []byte
+}
+
+// mcpDialect adapts the tool loop to one client-facing API format.
+type mcpDialect interface {
+	clientToolNames(payload []byte) map[string]struct{}
+	attachTools(payload []byte, tools []mcp.Tool) ([]byte, error)
+	parseResponse(body []byte) mcpTurn
+	parseStream(events [][]byte) mcpTurn
+	appendTurn(payload []byte, turn mcpTurn, results []mcp.CallResult) ([]byte, error)
+	// stripCalls removes the tool calls matched by drop from a response body.
+	stripCalls(body []byte, drop func(name string) bool) []byte
+	// streamStripper returns a stateful filter that removes the tool calls matched by drop from
+	// the events of one stream; it returns nil for events to leave out.
+	streamStripper(drop func(name string) bool) func(event []byte) []byte
+}
+
+func mcpDialectFor(handlerType string) mcpDialect {
+	switch handlerType {
+	case "openai":
+		return openAIChatMCPDialect{}
+	case "claude":
+		return claudeMCPDialect{}
+	case "openai-response":
+		return responsesMCPDialect{}
+	default:
+		return nil
+	}
+}
+
+// mcpBridge holds the bridged tools and dialect for one request.
+type mcpBridge struct {
+	dialect mcpDialect
+	tools   *mcp.Toolset
+}
+
+// mcpManager returns the MCP client manager, creating it on first use.
+func (h *BaseAPIHandler) mcpManager() *mcp.Manager {
+	h.mcpMu.Lock()
+	defer h.mcpMu.Unlock()
+	if h.mcpTools == nil {
+		h.mcpTools = mcp.NewManager(h.Cfg.MCP)
+	}
+	return h.mcpTools
+}
+
+// mcpBridgeFor resolves the bridged tools for a request, or nil when none apply.
+func (h *BaseAPIHandler) mcpBridgeFor(ctx context.Context, handlerType, modelName string, rawJSON []byte) *mcpBridge {
+	if ctx == nil || h.Cfg == nil || !h.Cfg.MCP.Enabled() {
+		return nil
+	}
+	if ctx.Value(mcpBridgeContextKey{}) != nil {
+		return nil
+	}
+	dialect := mcpDialectFor(handlerType)
+	if dialect == nil {
+		return nil
+	}
+	apiKey := ""
+	if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil {
+		if v, exists := ginCtx.Get("apiKey"); exists {
+			if s, okString := v.(string); okString {
+				apiKey = s
+			}
+		}
+	}
+	tools := h.mcpManager().Toolset(ctx, modelName, apiKey, dialect.clientToolNames(rawJSON))
+	if tools == nil {
+		return nil
+	}
+	return &mcpBridge{dialect: dialect, tools: tools}
+}
+
+// handles reports whether every tool call of the turn targets a bridged tool.
+func (b *mcpBridge) handles(turn mcpTurn) bool {
+	if len(turn.calls) == 0 {
+		return false
+	}
+	for _, call := range turn.calls {
+		if !b.tools.Has(call.name) {
+			return false
+		}
+	}
+	return true
+}
+
+// bridgedCalls reports whether the turn calls any bridged tool.
+func (b *mcpBridge) bridgedCalls(turn mcpTurn) bool {
+	for _, call := range turn.calls {
+		if b.tools.Has(call.name) {
+			return true
+		}
+	}
+	return false
+}
+
+// finalTurnError is returned when the loop ends on a turn that still calls bridged tools only,
+// which the client could not answer.
+func (b *mcpBridge) finalTurnError(maxIterations int) *interfaces.ErrorMessage {
+	return &interfaces.ErrorMessage{
+		StatusCode: http.StatusBadGateway,
+		Error:      fmt.Errorf("mcp bridge: model still calls bridged tools after %d iterations", maxIterations),
+	}
+}
+
+func (b *mcpBridge) run(ctx context.Context, turn mcpTurn) []mcp.CallResult {
+	results := make([]mcp.CallResult, len(turn.calls))
+	for i, call := range turn.calls {
+		log.Debugf("mcp bridge: calling tool %s", call.name)
+		results[i] = b.tools.Call(ctx, call.name, call.arguments)
+	}
+	return results
+}
+
+// executeWithMCPTools runs the model/tool loop for a non-streaming request and returns the
+// first response that does not call only bridged tools. Bridged calls mixed with client tool calls
+// are removed from that response, since the client cannot answer them; reaching the iteration cap
+// with bridged calls pending fails the request.
+func (h *BaseAPIHandler) executeWithMCPTools(ctx context.Context, bridge *mcpBridge, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, http.Header, *interfaces.ErrorMessage) {
+	payload, err := bridge.dialect.attachTools(rawJSON, bridge.tools.Tools())
+	if err != nil {
+		return nil, nil, &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: err}
+	}
+	loopCtx := context.WithValue(ctx, mcpBridgeContextKey{}, true)
+	maxIterations := h.Cfg.MCP.Iterations()
+	for iteration := 1; ; iteration++ {
+		body, headers, errMsg := h.ExecuteWithAuthManager(loopCtx, handlerType, modelName, payload, alt)
+		if errMsg != nil {
+			return nil, nil, errMsg
+		}
+		turn := bridge.dialect.parseResponse(body)
+		if !bridge.handles(turn) {
+			if bridge.bridgedCalls(turn) {
+				body = bridge.dialect.stripCalls(body, bridge.tools.Has)
+			}
+			return body, headers, nil
+		}
+		if iteration >= maxIterations {
+			return nil, nil, bridge.finalTurnError(maxIterations)
+		}
+		results := bridge.run(ctx, turn)
+		if payload, err = bridge.dialect.appendTurn(payload, turn, results); err != nil {
+			return nil, nil, &interfaces.ErrorMessage{StatusCode: http.StatusInternalServerError, Error: err}
+		}
+	}
+}
+
+// executeStreamWithMCPTools runs the model/tool loop for a streaming request. Each model turn is
+// buffered; the turn that does not call only bridged tools is replayed to the client, without its
+// bridged calls. Upstream headers are not passed through because they are only known once the
+// loop has finished.
+func (h *BaseAPIHandler) executeStreamWithMCPTools(ctx context.Context, bridge *mcpBridge, handlerType, modelName string, rawJSON []byte, alt string) (<-chan []byte, http.Header, <-chan *interfaces.ErrorMessage) {
+	dataChan := make(chan []byte)
+	errChan := make(chan *interfaces.ErrorMessage, 1)
+	payload, err := bridge.dialect.attachTools(rawJSON, bridge.tools.Tools())
+	if err != nil {
+		close(dataChan)
+		errChan <- &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: err}
+		close(errChan)
+		return dataChan, nil, errChan
+	}
+	loopCtx := context.WithValue(ctx, mcpBridgeContextKey{}, true)
+	maxIterations := h.Cfg.MCP.Iterations()
+	go func() {
+		defer close(dataChan)
+		defer close(errChan)
+		for iteration := 1; ; iteration++ {
+			chunks, errMsg := collectStream(h.ExecuteStreamWithAuthManager(loopCtx, handlerType, modelName, payload, alt))
+			if errMsg != nil {
+				errChan <- errMsg
+				return
+			}
+			turn := bridge.dialect.parseStream(streamEvents(chunks))
+			if bridge.handles(turn) && iteration >= maxIterations {
+				errChan <- bridge.finalTurnError(maxIterations)
+				return
+			}
+			if !bridge.handles(turn) {
+				if bridge.bridgedCalls(turn) {
+					chunks = rewriteStreamEvents(chunks, bridge.dialect.streamStripper(bridge.tools.Has))
+				}
+				for _, chunk := range chunks {
+					select {
+					case <-ctx.Done():
+						return
+					case dataChan <- chunk:
+					}
+				}
+				return
+			}
+			results := bridge.run(ctx, turn)
+			var errAppend error
+			if payload, errAppend = bridge.dialect.appendTurn(payload, turn, results); errAppend != nil {
+				errChan <- &interfaces.ErrorMessage{StatusCode: http.StatusInternalServerError, Error: errAppend}
+				return
+			}
+		}
+	}()
+	return dataChan, nil, errChan
+}
+
+// collectStream drains a stream, returning its chunks or the first error.
+func collectStream(data <-chan []byte, _ http.Header, errs <-chan *interfaces.ErrorMessage) ([][]byte, *interfaces.ErrorMessage) {
+	var chunks [][]byte
+	if data != nil {
+		for chunk := range data {
+			chunks = append(chunks, chunk)
+		}
+	}
+	for errMsg := range errs {
+		if errMsg != nil {
+			return nil, errMsg
+		}
+	}
+	return chunks, nil
+}
+
+// streamEvents extracts the JSON payloads of SSE "data:" lines, or of bare JSON chunks.
+func streamEvents(chunks [][]byte) [][]byte {
+	var events [][]byte
+	for _, chunk := range chunks {
+		for _, line := range bytes.Split(chunk, []byte("\n")) {
+			line = bytes.TrimSpace(line)
+			if bytes.HasPrefix(line, []byte("data:")) {
+				line = bytes.TrimSpace(line[5:])
+			}
+			if len(line) == 0 || line[0] != '{' {
+				continue
+			}
+			events = append(events, line)
+		}
+	}
+	return events
+}
+
+// rewriteStreamEvents applies rewrite to the JSON payload of every event of the chunks, keeping
+// SSE framing. Events rewritten to nil are dropped together with their "event:" line.
+func rewriteStreamEvents(chunks [][]byte, rewrite func(event []byte) []byte) [][]byte {
+	out := make([][]byte, 0, len(chunks))
+	for _, chunk := range chunks {
+		blocks := bytes.Split(chunk, []byte("\n\n"))
+		kept := make([][]byte, 0, len(blocks))
+		changed := false
+		for _, block := range blocks {
+			lines := bytes.Split(block, []byte("\n"))
+			drop := false
+			for i, line := range lines {
+				prefix, data := []byte(nil), bytes.TrimSpace(line)
+				if bytes.HasPrefix(data, []byte("data:")) {
+					prefix, data = []byte("data: "), bytes.TrimSpace(data[5:])
+				}
+				if len(data) == 0 || data[0] != '{' {
+					continue
+				}
+				rewritten := rewrite(data)
+				if rewritten == nil {
+					drop = true
+					break
+				}
+				if !bytes.Equal(rewritten, data) {
+					lines[i] = append(prefix, rewritten...)
+					changed = true
+				}
+			}
+			if drop {
+				changed = true
+				continue
+			}
+			kept = append(kept, bytes.Join(lines, []byte("\n")))
+		}
+		if !changed {
+			out = append(out, chunk)
+			continue
+		}
+		if rebuilt := bytes.Join(kept, []byte("\n\n")); len(bytes.TrimSpace(rebuilt)) > 0 {
+			out = append(out, rebuilt)
+		}
+	}
+	return out
+}
+
+// indexRemap renumbers the indexes of a stream whose dropped entries are left out.
+type indexRemap struct {
+	dropped map[int64]bool
+	next    map[int64]int64
+	count   int64
+}
+
+func newIndexRemap() *indexRemap {
+	return &indexRemap{dropped: make(map[int64]bool), next: make(map[int64]int64)}
+}
+
+// see registers index the first time it appears and returns its new index, or false when it is
+// dropped.
+func (r *indexRemap) see(index int64, drop bool) (int64, bool) {
+	if r.dropped[index] {
+		return 0, false
+	}
+	if mapped, ok := r.next[index]; ok {
+		return mapped, true
+	}
+	if drop {
+		r.dropped[index] = true
+		return 0, false
+	}
+	r.next[index] = r.count
+	r.count++
+	return r.next[index], true
+}
+
+// filterArray keeps the elements of the JSON array at path for which keep returns true.
+func filterArray(body []byte, path string, keep func(item gjson.Result) bool) []byte {
+	items := gjson.GetBytes(body, path)
+	if !items.IsArray() {
+		return body
+	}
+	filtered := []byte(`[]`)
+	for _, item := range items.Array() {
+		if keep(item) {
+			filtered, _ = sjson.SetRawBytes(filtered, "-1", []byte(item.Raw))
+		}
+	}
+	out, err := sjson.SetRawBytes(body, path, filtered)
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+func toolSchema(tool mcp.Tool) []byte {
+	if len(bytes.TrimSpace(tool.InputSchema)) == 0 {
+		return []byte(`{"type":"object","properties":{}}`)
+	}
+	return tool.InputSchema
+}
+
+func toolArguments(raw string) json.RawMessage {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || !json.Valid([]byte(raw)) {
+		return json.RawMessage(`{}`)
+	}
+	return json.RawMessage(raw)
+}
+
+func appendRawItem(payload []byte, path string, item []byte) ([]byte, error) {
+	return sjson.SetRawBytes(payload, path+".-1", item)
+}
+
+func toolNames(payload []byte, path string) map[string]struct{} {
+	names := make(map[string]struct{})
+	gjson.GetBytes(payload, path).ForEach(func(_, value gjson.Result) bool {
+		if name := value.String(); name != "" {
+			names[name] = struct{}{}
+		}
+		return true
+	})
+	return names
+}
+
+// openAIChatMCPDialect handles /v1/chat/completions.
+type openAIChatMCPDialect struct{}
+
+func (openAIChatMCPDialect) clientToolNames(payload []byte) map[string]struct{} {
+	return toolNames(payload, "tools.#.function.name")
+}
+
+func (openAIChatMCPDialect) attachTools(payload []byte, tools []mcp.Tool) ([]byte, error) {
+	var err error
+	for _, tool := range tools {
+		item := []byte(`{"type":"function","function":{}}`)
+		item, _ = sjson.SetBytes(item, "function.name", tool.Name)
+		if tool.Description != "" {
+			item, _ = sjson.SetBytes(item, "function.description", tool.Description)
+		}
+		item, _ = sjson.SetRawBytes(item, "function.parameters", toolSchema(tool))
+		if payload, err = appendRawItem(payload, "tools", item); err != nil {
+			return nil, err
+		}
+	}
+	return payload, nil
+}
+
+func (openAIChatMCPDialect) parseResponse(body []byte) mcpTurn {
+	message := gjson.GetBytes(body, "choices.0.message")
+	var turn mcpTurn
+	message.Get("tool_calls").ForEach(func(_, call gjson.Result) bool {
+		turn.calls = append(turn.calls, mcpToolCall{
+			id:        call.Get("id").String(),
+			name:      call.Get("function.name").String(),
+			arguments: toolArguments(call.Get("function.arguments").String()),
+		})
+		return true
+	})
+	assistant := []byte(`{"role":"assistant","content":null}`)
+	if content := message.Get("content"); content.Exists() {
+		assistant, _ = sjson.SetRawBytes(assistant, "content", []byte(content.Raw))
+	}
+	if calls := message.Get("tool_calls"); calls.Exists() {
+		assistant, _ = sjson.SetRawBytes(assistant, "tool_calls", []byte(calls.Raw))
+	}
+	turn.assistant = assistant
+	return turn
+}
+
+func (d openAIChatMCPDialect) parseStream(events [][]byte) mcpTurn {
+	type partialCall struct {
+		id, name  string
+		arguments strings.Builder
+	}
+	var content strings.Builder
+	calls := make(map[int64]*partialCall)
+	for _, event := range events {
+		delta := gjson.GetBytes(event, "choices.0.delta")
+		content.WriteString(delta.Get("content").String())
+		delta.Get("tool_calls").ForEach(func(_, call gjson.Result) bool {
+			index := call.Get("index").Int()
+			partial := calls[index]
+			if partial == nil {
+				partial = &partialCall{}
+				calls[index] = partial
+			}
+			if id := call.Get("id").String(); id != "" {
+				partial.id = id
+			}
+			partial.name += call.Get("function.name").String()
+			partial.arguments.WriteString(call.Get("function.arguments").String())
+			return true
+		})
+	}
+	message := []byte(`{"role":"assistant"}`)
+	if content.Len() > 0 {
+		message, _ = sjson.SetBytes(message, "content", content.String())
+	}
+	indexes := make([]int64, 0, len(calls))
+	for index := range calls {
+		indexes = append(indexes, index)
+	}
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i] < indexes[j] })
+	for _, index := range indexes {
+		call := []byte(`{"type":"function","function":{}}`)
+		call, _ = sjson.SetBytes(call, "id", calls[index].id)
+		call, _ = sjson.SetBytes(call, "function.name", calls[index].name)
+		call, _ = sjson.SetBytes(call, "function.arguments", calls[index].arguments.String())
+		message, _ = sjson.SetRawBytes(message, "tool_calls.-1", call)
+	}
+	return d.parseResponse(append(append([]byte(`{"choices":[{"message":`), message...), []byte(`}]}`)...))
+}
+
+func (openAIChatMCPDialect) appendTurn(payload []byte, turn mcpTurn, results []mcp.CallResult) ([]byte, error) {
+	payload, err := appendRawItem(payload, "messages", turn.assistant)
+	if err != nil {
+		return nil, err
+	}
+	for i, call := range turn.calls {
+		message := []byte(`{"role":"tool"}`)
+		message, _ = sjson.SetBytes(message, "tool_call_id", call.id)
+		message, _ = sjson.SetBytes(message, "content", results[i].Text)
+		if payload, err = appendRawItem(payload, "messages", message); err != nil {
+			return nil, err
+		}
+	}
+	return payload, nil
+}
+
+func (openAIChatMCPDialect) stripCalls(body []byte, drop func(name string) bool) []byte {
+	return filterArray(body, "choices.0.message.tool_calls", func(call gjson.Result) bool {
+		return !drop(call.Get("function.name").String())
+	})
+}
+
+func (openAIChatMCPDialect) streamStripper(drop func(name string) bool) func(event []byte) []byte {
+	remap := newIndexRemap()
+	return func(event []byte) []byte {
+		calls := gjson.GetBytes(event, "choices.0.delta.tool_calls")
+		if !calls.IsArray() {
+			return event
+		}
+		kept := []byte(`[]`)
+		for _, call := range calls.Array() {
+			name := call.Get("function.name").String()
+			index, ok := remap.see(call.Get("index").Int(), name != "" && drop(name))
+			if !ok {
+				continue
+			}
+			item, _ := sjson.SetBytes([]byte(call.Raw), "index", index)
+			kept, _ = sjson.SetRawBytes(kept, "-1", item)
+		}
+		var out []byte
+		if len(gjson.ParseBytes(kept).Array()) == 0 {
+			out, _ = sjson.DeleteBytes(event, "choices.0.delta.tool_calls")
+		} else {
+			out, _ = sjson.SetRawBytes(event, "choices.0.delta.tool_calls", kept)
+		}
+		return out
+	}
+}
+
+// claudeMCPDialect handles /v1/messages.
+type claudeMCPDialect struct{}
+
+func (claudeMCPDialect) clientToolNames(payload []byte) map[string]struct{} {
+	return toolNames(payload, "tools.#.name")
+}
+
+func (claudeMCPDialect) attachTools(payload []byte, tools []mcp.Tool) ([]byte, error) {
+	var err error
+	for _, tool := range tools {
+		item := []byte(`{}`)
+		item, _ = sjson.SetBytes(item, "name", tool.Name)
+		if tool.Description != "" {
+			item, _ = sjson.SetBytes(item, "description", tool.Description)
+		}
+		item, _ = sjson.SetRawBytes(item, "input_schema", toolSchema(tool))
+		if payload, err = appendRawItem(payload, "tools", item); err != nil {
+			return nil, err
+		}
+	}
+	return payload, nil
+}
+
+func (claudeMCPDialect) parseResponse(body []byte) mcpTurn {
+	content := gjson.GetBytes(body, "content")
+	var turn mcpTurn
+	content.ForEach(func(_, block gjson.Result) bool {
+		if block.Get("type").String() == "tool_use" {
+			input := json.RawMessage(`{}`)
+			if raw := block.Get("input"); raw.IsObject() {
+				input = json.RawMessage(raw.Raw)
+			}
+			turn.calls = append(turn.calls, mcpToolCall{id: block.Get("id").String(), name: block.Get("name").String(), arguments: input})
+		}
+		return true
+	})
+	assistant := []byte(`{"role":"assistant","content":[]}`)
+	if content.IsArray() {
+		assistant, _ = sjson.SetRawBytes(assistant, "content", []byte(content.Raw))
+	}
+	turn.assistant = assistant
+	return turn
+}
+
+func (d claudeMCPDialect) parseStream(events [][]byte) mcpTurn {
+	type partialBlock struct {
+		block []byte
+		text  strings.Builder
+		input strings.Builder
+	}
+	blocks := make(map[int64]*partialBlock)
+	var indexes []int64
+	for _, event := range events {
+		index := gjson.GetBytes(event, "index").Int()
+		switch gjson.GetBytes(event, "type").String() {
+		case "content_block_start":
+			blocks[index] = &partialBlock{block: []byte(gjson.GetBytes(event, "content_block").Raw)}
+			indexes = append(indexes, index)
+		case "content_block_delta":
+			partial := blocks[index]
+			if partial == nil {
+				continue
+			}
+			delta := gjson.GetBytes(event, "delta")
+			switch delta.Get("type").String() {
+			case "text_delta":
+				partial.text.WriteString(delta.Get("text").String())
+			case "thinking_delta":
+				partial.text.WriteString(delta.Get("thinking").String())
+			case "signature_delta":
+				partial.block, _ = sjson.SetBytes(partial.block, "signature", delta.Get("signature").String())
+			case "input_json_delta":
+				partial.input.WriteString(delta.Get("partial_json").String())
+			}
+		}
+	}
+	content := []byte(`[]`)
+	for _, index := range indexes {
+		partial := blocks[index]
+		block := partial.block
+		switch gjson.GetBytes(block, "type").String() {
+		case "text":
+			block, _ = sjson.SetBytes(block, "text", partial.text.String())
+		case "thinking":
+			block, _ = sjson.SetBytes(block, "thinking", partial.text.String())
+		case "tool_use", "server_tool_use":
+			block, _ = sjson.SetRawBytes(block, "input", toolArguments(partial.input.String()))
+		}
+		content, _ = sjson.SetRawBytes(content, "-1", block)
+	}
+	return d.parseResponse(append(append([]byte(`{"content":`), content...), '}'))
+}
+
+func (claudeMCPDialect) appendTurn(payload []byte, turn mcpTurn, results []mcp.CallResult) ([]byte, error) {
+	payload, err := appendRawItem(payload, "messages", turn.assistant)
+	if err != nil {
+		return nil, err
+	}
+	message := []byte(`{"role":"user","content":[]}`)
+	for i, call := range turn.calls {
+		block := []byte(`{"type":"tool_result"}`)
+		block, _ = sjson.SetBytes(block, "tool_use_id", call.id)
+		block, _ = sjson.SetBytes(block, "content", results[i].Text)
+		if results[i].IsError {
+			block, _ = sjson.SetBytes(block, "is_error", true)
+		}
+		message, _ = sjson.SetRawBytes(message, "content.-1", block)
+	}
+	return appendRawItem(payload, "messages", message)
+}
+
+func (claudeMCPDialect) stripCalls(body []byte, drop func(name string) bool) []byte {
+	return filterArray(body, "content", func(block gjson.Result) bool {
+		return block.Get("type").String() != "tool_use" || !drop(block.Get("name").String())
+	})
+}
+
+func (claudeMCPDialect) streamStripper(drop func(name string) bool) func(event []byte) []byte {
+	remap := newIndexRemap()
+	return func(event []byte) []byte {
+		index := gjson.GetBytes(event, "index")
+		if !index.Exists() {
+			return event
+		}
+		block := gjson.GetBytes(event, "content_block")
+		bridged := gjson.GetBytes(event, "type").String() == "content_block_start" &&
+			block.Get("type").String() == "tool_use" && drop(block.Get("name").String())
+		mapped, ok := remap.see(index.Int(), bridged)
+		if !ok {
+			return nil
+		}
+		out, _ := sjson.SetBytes(event, "index", mapped)
+		return out
+	}
+}
+
+// responsesMCPDialect handles /v1/responses.
+type responsesMCPDialect struct{}
+
+func (responsesMCPDialect) clientToolNames(payload []byte) map[string]struct{} {
+	return toolNames(payload, "tools.#.name")
+}
+
+func (responsesMCPDialect) attachTools(payload []byte, tools []mcp.Tool) ([]byte, error) {
+	var err error
+	for _, tool := range tools {
+		item := []byte(`{"type":"function"}`)
+		item, _ = sjson.SetBytes(item, "name", tool.Name)
+		if tool.Description != "" {
+			item, _ = sjson.SetBytes(item, "description", tool.Description)
+		}
+		item, _ = sjson.SetRawBytes(item, "parameters", toolSchema(tool))
+		if payload, err = appendRawItem(payload, "tools", item); err != nil {
+			return nil, err
+		}
+	}
+	return payload, nil
+}
+
+func (responsesMCPDialect) parseResponse(body []byte) mcpTurn {
+	var turn mcpTurn
+	items := []byte(`[]`)
+	gjson.GetBytes(body, "output").ForEach(func(_, item gjson.Result) bool {
+		switch item.Get("type").String() {
+		case "function_call":
+			turn.calls = append(turn.calls, mcpToolCall{
+				id:        item.Get("call_id").String(),
+				name:      item.Get("name").String(),
+				arguments: toolArguments(item.Get("arguments").String()),
+			})
+			items, _ = sjson.SetRawBytes(items, "-1", []byte(item.Raw))
+		case "message", "reasoning":
+			// Reasoning items are replayed so models that require them keep their chain of thought.
+			items, _ = sjson.SetRawBytes(items, "-1", []byte(item.Raw))
+		}
+		return true
+	})
+	turn.assistant = items
+	return turn
+}
+
+func (d responsesMCPDialect) parseStream(events [][]byte) mcpTurn {
+	for i := len(events) - 1; i >= 0; i-- {
+		if gjson.GetBytes(events[i], "type").String() == "response.completed" {
+			return d.parseResponse([]byte(gjson.GetBytes(events[i], "response").Raw))
+		}
+	}
+	return mcpTurn{}
+}
+
+func (responsesMCPDialect) appendTurn(payload []byte, turn mcpTurn, results []mcp.CallResult) ([]byte, error) {
+	var err error
+	input := gjson.GetBytes(payload, "input")
+	if input.Type == gjson.String {
+		message := []byte(`{"role":"user"}`)
+		message, _ = sjson.SetBytes(message, "content", input.String())
+		if payload, err = sjson.SetRawBytes(payload, "input", append(append([]byte(`[`), message...), ']')); err != nil {
+			return nil, err
+		}
+	} else if !input.IsArray() {
+		if payload, err = sjson.SetRawBytes(payload, "input", []byte(`[]`)); err != nil {
+			return nil, err
+		}
+	}
+	for _, item := range gjson.ParseBytes(turn.assistant).Array() {
+		if payload, err = appendRawItem(payload, "input", []byte(item.Raw)); err != nil {
+			return nil, err
+		}
+	}
+	for i, call := range turn.calls {
+		item := []byte(`{"type":"function_call_output"}`)
+		item, _ = sjson.SetBytes(item, "call_id", call.id)
+		item, _ = sjson.SetBytes(item, "output", results[i].Text)
+		if payload, err = appendRawItem(payload, "input", item); err != nil {
+			return nil, err
+		}
+	}
+	return payload, nil
+}
+
+func (responsesMCPDialect) stripCalls(body []byte, drop func(name string) bool) []byte {
+	return filterArray(body, "output", func(item gjson.Result) bool {
+		return item.Get("type").String() != "function_call" || !drop(item.Get("name").String())
+	})
+}
+
+func (d responsesMCPDialect) streamStripper(drop func(name string) bool) func(event []byte) []byte {
+	remap := newIndexRemap()
+	return func(event []byte) []byte {
+		if response := gjson.GetBytes(event, "response"); response.Get("output").IsArray() {
+			out, _ := sjson.SetRawBytes(event, "response", d.stripCalls([]byte(response.Raw), drop))
+			return out
+		}
+		index := gjson.GetBytes(event, "output_index")
+		if !index.Exists() {
+			return event
+		}
+		item := gjson.GetBytes(event, "item")
+		bridged := item.Get("type").String() == "function_call" && drop(item.Get("name").String())
+		mapped, ok := remap.see(index.Int(), bridged)
+		if !ok {
+			return nil
+		}
+		out, _ := sjson.SetBytes(event, "output_index", mapped)
+		return out
+	}
+}
@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+// newTestMCPServer serves a streamable HTTP MCP server exposing a single "lookup" tool.
+func newTestMCPServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(req.ID) == 0 {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		var result string
+		switch req.Method {
+		case "initialize":
+			w.Header().Set("Mcp-Session-Id", "session-1")
+			result = `{"protocolVersion":"2025-06-18","capabilities":{"tools":{}},"serverInfo":{"name":"test","version":"1"}}`
+		case "tools/list":
+			result = `{"tools":[{"name":"lookup","description":"Look up a value","inputSchema":{"type":"object","properties":{"q":{"type":"string"}}}}]}`
+		case "tools/call":
+			if r.Header.Get("Mcp-Session-Id") != "session-1" {
+				http.Error(w, "missing session", http.StatusBadRequest)
+				return
+			}
+			query := gjson.GetBytes(req.Params, "arguments.q").String()
+			result = `{"content":[{"type":"text","text":"value of ` + query + `"}]}`
+		default:
+			http.Error(w, "unknown method", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("event: message\ndata: {\"jsonrpc\":\"2.0\",\"id\":" + string(req.ID) + ",\"result\":" + result + "}\n\n"))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+type mcpLoopExecutor struct {
+	mu       sync.Mutex
+	payloads [][]byte
+}
+
+func (e *mcpLoopExecutor) Identifier() string { return "claude" }
+
+func (e *mcpLoopExecutor) record(payload []byte) int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.payloads = append(e.payloads, payload)
+	return len(e.payloads)
+}
+
+func (e *mcpLoopExecutor) Execute(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	if e.record(req.Payload) == 1 {
+		return coreexecutor.Response{Payload: []byte(`{"id":"c1","choices":[{"index":0,"message":{"role":"assistant","content":null,"tool_calls":[{"id":"call_1","type":"function","function":{"name":"lookup","arguments":"{\"q\":\"x\"}"}}]},"finish_reason":"tool_calls"}]}`)}, nil
+	}
+	return coreexecutor.Response{Payload: []byte(`{"id":"c2","choices":[{"index":0,"message":{"role":"assistant","content":"done"},"finish_reason":"stop"}]}`)}, nil
+}
+
+func (e *mcpLoopExecutor) ExecuteStream(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	ch := make(chan coreexecutor.StreamChunk, 8)
+	if e.record(req.Payload) == 1 {
+		ch <- coreexecutor.StreamChunk{Payload: []byte("event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"tool_use\",\"id\":\"toolu_1\",\"name\":\"lookup\",\"input\":{}}}\n\n")}
+		ch <- coreexecutor.StreamChunk{Payload: []byte("event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"input_json_delta\",\"partial_json\":\"{\\\"q\\\":\\\"y\\\"}\"}}\n\n")}
+		ch <- coreexecutor.StreamChunk{Payload: []byte("event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n")}
+	} else {
+		ch <- coreexecutor.StreamChunk{Payload: []byte("event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"text\",\"text\":\"\"}}\n\n")}
+		ch <- coreexecutor.StreamChunk{Payload: []byte("event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"final\"}}\n\n")}
+	}
+	close(ch)
+	return &coreexecutor.StreamResult{Chunks: ch}, nil
+}
+
+func (e *mcpLoopExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *mcpLoopExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func (e *mcpLoopExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "HttpRequest not implemented", HTTPStatus: http.StatusNotImplemented}
+}
+
+func (e *mcpLoopExecutor) Payloads() [][]byte {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([][]byte(nil), e.payloads...)
+}
+
+func newMCPBridgeTestHandler(t *testing.T, authID string) (*BaseAPIHandler, *mcpLoopExecutor) {
+	t.Helper()
+	server := newTestMCPServer(t)
+	executor := &mcpLoopExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	if _, err := manager.Register(context.Background(), &coreauth.Auth{ID: authID, Provider: "claude", Status: coreauth.StatusActive}); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(authID, "claude", []*registry.ModelInfo{{ID: "mcp-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(authID) })
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{
+		MCP: sdkconfig.MCPConfig{Servers: []sdkconfig.MCPServer{{Name: "test", URL: server.URL, Models: []string{"mcp-*"}}}},
+	}, manager)
+	t.Cleanup(func() { handler.mcpManager().Close() })
+	return handler, executor
+}
+
+func TestExecuteWithAuthManager_MCPBridgeRunsToolLoop(t *testing.T) {
+	handler, executor := newMCPBridgeTestHandler(t, "mcp-bridge-auth1")
+
+	raw := []byte(`{"model":"mcp-model","messages":[{"role":"user","content":"hi"}]}`)
+	body, _, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", "mcp-model", raw, "")
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %+v", errMsg)
+	}
+	if got := gjson.GetBytes(body, "choices.0.message.content").String(); got != "done" {
+		t.Fatalf("final content = %q, want %q", got, "done")
+	}
+
+	payloads := executor.Payloads()
+	if len(payloads) != 2 {
+		t.Fatalf("expected 2 model calls, got %d", len(payloads))
+	}
+	if name := gjson.GetBytes(payloads[0], "tools.0.function.name").String(); name != "lookup" {
+		t.Fatalf("bridged tool not attached: %s", payloads[0])
+	}
+	messages := gjson.GetBytes(payloads[1], "messages").Array()
+	if len(messages) != 3 {
+		t.Fatalf("expected user, assistant and tool messages, got %s", gjson.GetBytes(payloads[1], "messages").Raw)
+	}
+	if messages[1].Get("tool_calls.0.id").String() != "call_1" {
+		t.Fatalf("assistant tool call not replayed: %s", messages[1].Raw)
+	}
+	if messages[2].Get("role").String() != "tool" || messages[2].Get("content").String() != "value of x" {
+		t.Fatalf("tool result = %s", messages[2].Raw)
+	}
+}
+
+func TestExecuteStreamWithAuthManager_MCPBridgeReplaysFinalTurn(t *testing.T) {
+	handler, executor := newMCPBridgeTestHandler(t, "mcp-bridge-auth2")
+
+	raw := []byte(`{"model":"mcp-model","stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+	dataChan, _, errChan := handler.ExecuteStreamWithAuthManager(context.Background(), "claude", "mcp-model", raw, "")
+	var out strings.Builder
+	for chunk := range dataChan {
+		out.Write(chunk)
+	}
+	for msg := range errChan {
+		if msg != nil {
+			t.Fatalf("unexpected error: %+v", msg)
+		}
+	}
+	if strings.Contains(out.String(), "tool_use") || !strings.Contains(out.String(), `"text":"final"`) {
+		t.Fatalf("expected only the final turn to be streamed, got %q", out.String())
+	}
+
+	payloads := executor.Payloads()
+	if len(payloads) != 2 {
+		t.Fatalf("expected 2 model calls, got %d", len(payloads))
+	}
+	assistant := gjson.GetBytes(payloads[1], "messages.1")
+	if assistant.Get("content.0.type").String() != "tool_use" || assistant.Get("content.0.input.q").String() != "y" {
+		t.Fatalf("assistant tool_use not replayed: %s", assistant.Raw)
+	}
+	result := gjson.GetBytes(payloads[1], "messages.2.content.0")
+	if result.Get("type").String() != "tool_result" || result.Get("tool_use_id").String() != "toolu_1" || result.Get("content").String() != "value of y" {
+		t.Fatalf("tool result = %s", result.Raw)
+	}
+}
+
+func TestExecuteWithAuthManager_MCPBridgeFailsAtIterationCap(t *testing.T) {
+	handler, executor := newMCPBridgeTestHandler(t, "mcp-bridge-auth3")
+	handler.Cfg.MCP.MaxIterations = 1
+
+	raw := []byte(`{"model":"mcp-model","messages":[{"role":"user","content":"hi"}]}`)
+	_, _, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", "mcp-model", raw, "")
+	if errMsg == nil || errMsg.StatusCode != http.StatusBadGateway {
+		t.Fatalf("expected 502 at the iteration cap, got %+v", errMsg)
+	}
+	if n := len(executor.Payloads()); n != 1 {
+		t.Fatalf("expected 1 model call, got %d", n)
+	}
+}
+
+func isLookup(name string) bool { return name == "lookup" }
+
+func TestMCPDialects_StripBridgedCalls(t *testing.T) {
+	chat := []byte(`{"choices":[{"message":{"tool_calls":[{"id":"a","function":{"name":"lookup"}},{"id":"b","function":{"name":"client_tool"}}]}}]}`)
+	if calls := gjson.GetBytes(openAIChatMCPDialect{}.stripCalls(chat, isLookup), "choices.0.message.tool_calls").Array(); len(calls) != 1 || calls[0].Get("id").String() != "b" {
+		t.Fatalf("chat calls after strip = %v", calls)
+	}
+
+	chunks := [][]byte{
+		[]byte("event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"tool_use\",\"id\":\"t1\",\"name\":\"lookup\",\"input\":{}}}\n\n"),
+		[]byte("event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"input_json_delta\",\"partial_json\":\"{}\"}}\n\n"),
+		[]byte("event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":1,\"content_block\":{\"type\":\"tool_use\",\"id\":\"t2\",\"name\":\"client_tool\",\"input\":{}}}\n\n"),
+		[]byte("event: content_block_stop\ndata: {\"type\":\"content_block_stop\",\"index\":1}\n\n"),
+	}
+	out := rewriteStreamEvents(chunks, claudeMCPDialect{}.streamStripper(isLookup))
+	if len(out) != 2 {
+		t.Fatalf("expected the bridged block to be dropped, got %q", out)
+	}
+	turn := claudeMCPDialect{}.parseStream(streamEvents(out))
+	if len(turn.calls) != 1 || turn.calls[0].name != "client_tool" || gjson.GetBytes(streamEvents(out)[0], "index").Int() != 0 {
+		t.Fatalf("remaining stream = %q", out)
+	}
+
+	events := [][]byte{
+		[]byte(`data: {"type":"response.output_item.added","output_index":0,"item":{"type":"function_call","name":"lookup"}}`),
+		[]byte(`data: {"type":"response.output_item.added","output_index":1,"item":{"type":"function_call","name":"client_tool"}}`),
+		[]byte(`data: {"type":"response.completed","response":{"output":[{"type":"function_call","name":"lookup"},{"type":"function_call","name":"client_tool"}]}}`),
+	}
+	out = rewriteStreamEvents(events, responsesMCPDialect{}.streamStripper(isLookup))
+	if len(out) != 2 || gjson.GetBytes(streamEvents(out)[0], "output_index").Int() != 0 {
+		t.Fatalf("responses stream after strip = %q", out)
+	}
+	if names := gjson.GetBytes(streamEvents(out)[1], "response.output.#.name").Array(); len(names) != 1 || names[0].String() != "client_tool" {
+		t.Fatalf("completed output = %v", names)
+	}
+}
+
+func TestResponsesMCPDialect_KeepsReasoningItems(t *testing.T) {
+	body := []byte(`{"output":[{"type":"reasoning","id":"rs_1","encrypted_content":"x"},{"type":"function_call","call_id":"c1","name":"lookup","arguments":"{}"}]}`)
+	turn := responsesMCPDialect{}.parseResponse(body)
+	items := gjson.ParseBytes(turn.assistant).Array()
+	if len(items) != 2 || items[0].Get("type").String() != "reasoning" {
+		t.Fatalf("assistant items = %s", turn.assistant)
+	}
+}
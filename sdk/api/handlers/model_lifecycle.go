@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+)
+
+// ModelReplacementHeader names the replacement of a deprecated or retired model.
+const ModelReplacementHeader = "X-CPA-Model-Replacement"
+
+// maxReplacementHops bounds the replacement chain followed for a retired model.
+const maxReplacementHops = 4
+
+// resolveRetiredModel returns the model to execute instead of a retired one, following
+// replacements that are themselves retired. Active and deprecated models are returned unchanged.
+// Retired models fail with 410 Gone when they have no replacement or retired models are
+// configured to be rejected, and when no provider serves the replacement. A model that some
+// provider still registers without a retirement, such as an openai-compatibility or user-defined
+// model sharing the catalog ID, is not redirected.
+func (h *BaseAPIHandler) resolveRetiredModel(model string, now time.Time) (string, *interfaces.ErrorMessage) {
+	lifecycle := registry.LookupModelLifecycle(model, now)
+	if lifecycle.State != registry.LifecycleRetired || servedUnretired(model, now) {
+		return model, nil
+	}
+	reject := h.Cfg != nil && h.Cfg.ModelLifecycle.RetiredModels == config.RetiredModelReject
+	resolved := lifecycle
+	for hops := 0; resolved.State == registry.LifecycleRetired; hops++ {
+		if reject || resolved.Replacement == "" || hops == maxReplacementHops {
+			return "", retiredModelError(model, lifecycle)
+		}
+		resolved = registry.LookupModelLifecycle(resolved.Replacement, now)
+	}
+	if len(registry.GetGlobalRegistry().GetModelProviders(resolved.ID)) == 0 {
+		unavailable := lifecycle
+		unavailable.Replacement = resolved.ID
+		return "", retiredModelError(model, unavailable)
+	}
+	log.Infof("model %s was retired on %s, redirecting to %s", model, lifecycle.RetireAt.Format(time.DateOnly), resolved.ID)
+	return resolved.ID, nil
+}
+
+// servedUnretired reports whether a provider registers model with a definition that is not retired.
+func servedUnretired(model string, now time.Time) bool {
+	for _, provider := range registry.GetGlobalRegistry().GetModelProviders(model) {
+		if registry.LookupModelInfo(model, provider).Lifecycle(now).State != registry.LifecycleRetired {
+			return true
+		}
+	}
+	return false
+}
+
+func retiredModelError(model string, lifecycle registry.ModelLifecycle) *interfaces.ErrorMessage {
+	err := fmt.Errorf("model %s was retired on %s", model, lifecycle.RetireAt.Format(time.DateOnly))
+	if lifecycle.Replacement != "" {
+		err = fmt.Errorf("%w; use %s instead", err, lifecycle.Replacement)
+	}
+	return &interfaces.ErrorMessage{StatusCode: http.StatusGone, Error: err}
+}
+
+// setModelLifecycleHeaders announces deprecated and retired models with the Deprecation
+// (RFC 9745) and Sunset (RFC 8594) response headers and names their replacement.
+func setModelLifecycleHeaders(ctx context.Context, modelName string) {
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil {
+		return
+	}
+	lifecycle := registry.LookupModelLifecycle(thinking.ParseSuffix(modelName).ModelName, time.Now())
+	if lifecycle.State == registry.LifecycleActive {
+		return
+	}
+	deprecatedAt := lifecycle.DeprecatedAt
+	if deprecatedAt.IsZero() {
+		deprecatedAt = lifecycle.RetireAt
+	}
+	ginCtx.Header("Deprecation", fmt.Sprintf("@%d", deprecatedAt.Unix()))
+	if !lifecycle.RetireAt.IsZero() {
+		ginCtx.Header("Sunset", lifecycle.RetireAt.UTC().Format(http.TimeFormat))
+	}
+	if lifecycle.Replacement != "" {
+		ginCtx.Header(ModelReplacementHeader, lifecycle.Replacement)
+	}
+}
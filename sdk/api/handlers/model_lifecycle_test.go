@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"golang.org/x/net/context"
+)
+
+func registerLifecycleModels(t *testing.T) {
+	t.Helper()
+	modelRegistry := registry.GetGlobalRegistry()
+	modelRegistry.RegisterClient("test-model-lifecycle", "openai", []*registry.ModelInfo{
+		{ID: "lifecycle-old", RetireAt: "2020-01-01", Replacement: "lifecycle-mid"},
+		{ID: "lifecycle-mid", RetireAt: "2021-01-01", Replacement: "lifecycle-new"},
+		{ID: "lifecycle-gone", RetireAt: "2020-01-01"},
+		{ID: "lifecycle-sunsetting", DeprecatedAt: "2020-01-01", RetireAt: "2999-01-01", Replacement: "lifecycle-new"},
+		{ID: "lifecycle-new"},
+	})
+	t.Cleanup(func() { modelRegistry.UnregisterClient("test-model-lifecycle") })
+}
+
+func TestGetRequestDetailsRetiredModels(t *testing.T) {
+	registerLifecycleModels(t)
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, coreauth.NewManager(nil, nil, nil))
+
+	_, model, errMsg := handler.getRequestDetails("lifecycle-old(high)")
+	if errMsg != nil || model != "lifecycle-new(high)" {
+		t.Fatalf("redirect = %q, %v; want lifecycle-new(high)", model, errMsg)
+	}
+	if _, model, errMsg = handler.getRequestDetails("lifecycle-sunsetting"); errMsg != nil || model != "lifecycle-sunsetting" {
+		t.Errorf("deprecated model should still be served, got %q, %v", model, errMsg)
+	}
+	if _, _, errMsg = handler.getRequestDetails("lifecycle-gone"); errMsg == nil || errMsg.StatusCode != http.StatusGone {
+		t.Errorf("retired model without replacement: %+v", errMsg)
+	}
+
+	handler.Cfg.ModelLifecycle.RetiredModels = sdkconfig.RetiredModelReject
+	_, _, errMsg = handler.getRequestDetails("lifecycle-old")
+	if errMsg == nil || errMsg.StatusCode != http.StatusGone {
+		t.Fatalf("expected 410 in reject mode, got %+v", errMsg)
+	}
+	if want := "model lifecycle-old was retired on 2020-01-01; use lifecycle-mid instead"; errMsg.Error.Error() != want {
+		t.Errorf("error = %q, want %q", errMsg.Error, want)
+	}
+}
+
+func TestGetRequestDetailsRetiredModelProviders(t *testing.T) {
+	registerLifecycleModels(t)
+	modelRegistry := registry.GetGlobalRegistry()
+	modelRegistry.RegisterClient("test-model-lifecycle-orphan", "openai", []*registry.ModelInfo{
+		{ID: "lifecycle-orphaned", RetireAt: "2020-01-01", Replacement: "lifecycle-unserved"},
+	})
+	t.Cleanup(func() { modelRegistry.UnregisterClient("test-model-lifecycle-orphan") })
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, coreauth.NewManager(nil, nil, nil))
+
+	_, _, errMsg := handler.getRequestDetails("lifecycle-orphaned")
+	if errMsg == nil || errMsg.StatusCode != http.StatusGone {
+		t.Fatalf("expected 410 for an unserved replacement, got %+v", errMsg)
+	}
+	if want := "model lifecycle-orphaned was retired on 2020-01-01; use lifecycle-unserved instead"; errMsg.Error.Error() != want {
+		t.Errorf("error = %q, want %q", errMsg.Error, want)
+	}
+
+	modelRegistry.RegisterClient("test-model-lifecycle-compat", "my-compat", []*registry.ModelInfo{{ID: "lifecycle-old"}})
+	t.Cleanup(func() { modelRegistry.UnregisterClient("test-model-lifecycle-compat") })
+	_, model, errMsg := handler.getRequestDetails("lifecycle-old")
+	if errMsg != nil || model != "lifecycle-old" {
+		t.Fatalf("model served by a compat provider = %q, %v; want lifecycle-old", model, errMsg)
+	}
+}
+
+func TestSetModelLifecycleHeaders(t *testing.T) {
+	registerLifecycleModels(t)
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	ginCtx, _ := gin.CreateTestContext(recorder)
+	ctx := context.WithValue(context.Background(), "gin", ginCtx)
+
+	setModelLifecycleHeaders(ctx, "lifecycle-sunsetting(high)")
+	headers := recorder.Header()
+	if got := headers.Get("Deprecation"); got != "@1577836800" {
+		t.Errorf("Deprecation = %q", got)
+	}
+	if got := headers.Get("Sunset"); got != "Tue, 01 Jan 2999 00:00:00 GMT" {
+		t.Errorf("Sunset = %q", got)
+	}
+	if got := headers.Get(ModelReplacementHeader); got != "lifecycle-new" {
+		t.Errorf("%s = %q", ModelReplacementHeader, got)
+	}
+
+	recorder = httptest.NewRecorder()
+	ginCtx, _ = gin.CreateTestContext(recorder)
+	setModelLifecycleHeaders(context.WithValue(context.Background(), "gin", ginCtx), "lifecycle-new")
+	if got := recorder.Header().Get("Deprecation"); got != "" {
+		t.Errorf("active model got Deprecation %q", got)
+	}
+}
@@ -0,0 +1,453 @@
+// Package openai provides HTTP handlers for OpenAI API endpoints.
+// This file implements the OpenAI Audio API for transcription and speech synthesis.
+package openai
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/misc"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// OpenAIAudioFormat represents the OpenAI Audio API format identifier.
+const OpenAIAudioFormat = "openai-audio"
+
+const (
+	// maxAudioUploadBytes matches the OpenAI transcription upload limit.
+	maxAudioUploadBytes = 25 << 20
+	// defaultGeminiVoice is used when the request names an OpenAI voice Gemini does not have.
+	defaultGeminiVoice = "Kore"
+	// defaultGeminiSampleRate is the PCM rate Gemini TTS models emit.
+	defaultGeminiSampleRate = 24000
+)
+
+// openAIVoices are the OpenAI speech voices; they are mapped to defaultGeminiVoice.
+var openAIVoices = map[string]struct{}{
+	"alloy": {}, "ash": {}, "ballad": {}, "coral": {}, "echo": {}, "fable": {},
+	"onyx": {}, "nova": {}, "sage": {}, "shimmer": {}, "verse": {},
+}
+
+// geminiAudioProviders serve audio through generateContent rather than the OpenAI endpoints.
+// Antigravity accepts Gemini requests with inline audio, so it transcribes like the others.
+var geminiAudioProviders = map[string]struct{}{
+	"gemini": {}, "vertex": {}, "gemini-cli": {}, "aistudio": {}, "antigravity": {},
+}
+
+// OpenAIAudioAPIHandler contains the handlers for OpenAI Audio API endpoints.
+type OpenAIAudioAPIHandler struct {
+	*handlers.BaseAPIHandler
+}
+
+// NewOpenAIAudioAPIHandler creates a new OpenAI Audio API handlers instance.
+//
+// Parameters:
+//   - apiHandlers: The base API handlers instance
+//
+// Returns:
+//   - *OpenAIAudioAPIHandler: A new OpenAI Audio API handlers instance
+func NewOpenAIAudioAPIHandler(apiHandlers *handlers.BaseAPIHandler) *OpenAIAudioAPIHandler {
+	return &OpenAIAudioAPIHandler{
+		BaseAPIHandler: apiHandlers,
+	}
+}
+
+// HandlerType returns the identifier for this handler implementation.
+func (h *OpenAIAudioAPIHandler) HandlerType() string {
+	return OpenAIAudioFormat
+}
+
+// Models returns the models supported by this handler.
+func (h *OpenAIAudioAPIHandler) Models() []map[string]any {
+	return nil
+}
+
+// AudioTranscriptions handles the /v1/audio/transcriptions endpoint.
+// OpenAI and OpenAI-compatible models receive the multipart upload unchanged; Gemini models
+// receive the audio as an inline part of a generateContent request.
+//
+// Request format (multipart/form-data):
+//
+//	file=@meeting.mp3 model=whisper-1 | gemini-2.5-flash
+//	language=en prompt=... response_format=json | text | verbose_json | srt | vtt
+func (h *OpenAIAudioAPIHandler) AudioTranscriptions(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxAudioUploadBytes+(1<<20))
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		status := http.StatusBadRequest
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		writeAudioError(c, status, fmt.Sprintf("file is required: %v", err), "missing_file")
+		return
+	}
+	if fileHeader.Size > maxAudioUploadBytes {
+		writeAudioError(c, http.StatusRequestEntityTooLarge, "audio file exceeds 25 MB", "file_too_large")
+		return
+	}
+	modelName := strings.TrimSpace(c.PostForm("model"))
+	if modelName == "" {
+		writeAudioError(c, http.StatusBadRequest, "model is required", "missing_model")
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		writeAudioError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", err), "")
+		return
+	}
+	audio, err := io.ReadAll(file)
+	_ = file.Close()
+	if err != nil || len(audio) == 0 {
+		writeAudioError(c, http.StatusBadRequest, "file is empty", "missing_file")
+		return
+	}
+	mimeType := detectAudioMimeType(fileHeader.Filename, fileHeader.Header.Get("Content-Type"), audio)
+	responseFormat := strings.TrimSpace(c.PostForm("response_format"))
+	if responseFormat == "" {
+		responseFormat = "json"
+	}
+
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	cliCtx = handlers.WithAudioEndpoint(cliCtx)
+	geminiFormat := responseFormat == "json" || responseFormat == "text" || responseFormat == "verbose_json"
+	if useGeminiAudio(modelName, geminiFormat) {
+		if !geminiFormat {
+			writeAudioError(c, http.StatusBadRequest, fmt.Sprintf("response_format %q is not supported for Gemini models", responseFormat), "unsupported_response_format")
+			cliCancel()
+			return
+		}
+		payload := buildGeminiTranscriptionRequest(audio, mimeType, c.PostForm("language"), c.PostForm("prompt"), c.PostForm("temperature"))
+		resp, _, errMsg := h.ExecuteWithAuthManager(handlers.WithProviderFilter(cliCtx, isGeminiAudioProvider), constant.Gemini, modelName, payload, "")
+		if errMsg != nil {
+			h.WriteErrorResponse(c, errMsg)
+			cliCancel(errMsg.Error)
+			return
+		}
+		contentType, body := convertGeminiTranscription(resp, responseFormat, c.PostForm("language"))
+		c.Data(http.StatusOK, contentType, body)
+		cliCancel()
+		return
+	}
+
+	payload := []byte(`{}`)
+	payload, _ = sjson.SetBytes(payload, "model", modelName)
+	payload, _ = sjson.SetBytes(payload, "file", base64.StdEncoding.EncodeToString(audio))
+	payload, _ = sjson.SetBytes(payload, "filename", filepath.Base(fileHeader.Filename))
+	payload, _ = sjson.SetBytes(payload, "mime_type", mimeType)
+	if form := c.Request.MultipartForm; form != nil {
+		for key, values := range form.Value {
+			if key == "model" || len(values) == 0 {
+				continue
+			}
+			if name, isArray := strings.CutSuffix(key, "[]"); isArray {
+				payload, _ = sjson.SetBytes(payload, name, values)
+				continue
+			}
+			payload, _ = sjson.SetBytes(payload, key, values[0])
+		}
+	}
+	resp, upstreamHeaders, errMsg := h.ExecuteWithAuthManager(handlers.WithProviderFilter(cliCtx, isOpenAIAudioProvider), OpenAIAudioFormat, modelName, payload, "audio/transcriptions")
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+	handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
+	c.Data(http.StatusOK, transcriptionContentType(responseFormat), resp)
+	cliCancel()
+}
+
+// AudioSpeech handles the /v1/audio/speech endpoint.
+// OpenAI and OpenAI-compatible models return the upstream audio as-is. Gemini TTS models return
+// 24 kHz PCM, served as WAV unless response_format is "pcm".
+//
+// Request format:
+//
+//	{
+//	  "model": "tts-1" | "gemini-2.5-flash-preview-tts",
+//	  "input": "Hello there",
+//	  "voice": "alloy" | "Kore",
+//	  "instructions": "Speak cheerfully",
+//	  "response_format": "mp3" | "opus" | "aac" | "flac" | "wav" | "pcm"
+//	}
+func (h *OpenAIAudioAPIHandler) AudioSpeech(c *gin.Context) {
+	rawJSON, err := c.GetRawData()
+	if err != nil || !gjson.ValidBytes(rawJSON) {
+		writeAudioError(c, http.StatusBadRequest, "Invalid request: body must be JSON", "")
+		return
+	}
+	modelName := strings.TrimSpace(gjson.GetBytes(rawJSON, "model").String())
+	if modelName == "" {
+		writeAudioError(c, http.StatusBadRequest, "model is required", "missing_model")
+		return
+	}
+	input := gjson.GetBytes(rawJSON, "input").String()
+	if strings.TrimSpace(input) == "" {
+		writeAudioError(c, http.StatusBadRequest, "input is required", "missing_input")
+		return
+	}
+	responseFormat := strings.TrimSpace(gjson.GetBytes(rawJSON, "response_format").String())
+
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	cliCtx = handlers.WithAudioEndpoint(cliCtx)
+	geminiFormat := responseFormat == "" || responseFormat == "wav" || responseFormat == "pcm"
+	if useGeminiAudio(modelName, geminiFormat) {
+		if !geminiFormat {
+			writeAudioError(c, http.StatusBadRequest, fmt.Sprintf("response_format %q is not supported for Gemini models; use wav or pcm", responseFormat), "unsupported_response_format")
+			cliCancel()
+			return
+		}
+		payload := buildGeminiSpeechRequest(input, gjson.GetBytes(rawJSON, "voice").String(), gjson.GetBytes(rawJSON, "instructions").String())
+		resp, _, errMsg := h.ExecuteWithAuthManager(handlers.WithProviderFilter(cliCtx, isGeminiAudioProvider), constant.Gemini, modelName, payload, "")
+		if errMsg != nil {
+			h.WriteErrorResponse(c, errMsg)
+			cliCancel(errMsg.Error)
+			return
+		}
+		contentType, audio, errConvert := convertGeminiSpeech(resp, responseFormat)
+		if errConvert != nil {
+			writeAudioError(c, http.StatusBadGateway, errConvert.Error(), "")
+			cliCancel(errConvert)
+			return
+		}
+		c.Data(http.StatusOK, contentType, audio)
+		cliCancel()
+		return
+	}
+
+	resp, upstreamHeaders, errMsg := h.ExecuteWithAuthManager(handlers.WithProviderFilter(cliCtx, isOpenAIAudioProvider), OpenAIAudioFormat, modelName, rawJSON, "audio/speech")
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+	handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
+	contentType := ""
+	if upstreamHeaders != nil {
+		contentType = upstreamHeaders.Get("Content-Type")
+	}
+	if contentType == "" {
+		contentType = speechContentType(responseFormat)
+	}
+	c.Data(http.StatusOK, contentType, resp)
+	cliCancel()
+}
+
+// isGeminiAudioProvider reports whether provider speaks the Gemini API.
+func isGeminiAudioProvider(provider string) bool {
+	_, ok := geminiAudioProviders[provider]
+	return ok
+}
+
+// isOpenAIAudioProvider reports whether provider serves the OpenAI audio endpoints.
+func isOpenAIAudioProvider(provider string) bool {
+	return !isGeminiAudioProvider(provider)
+}
+
+// useGeminiAudio reports whether a request for modelName takes the Gemini path. A model served by
+// both kinds of provider takes it when Gemini supports the requested response format; either way
+// execution is then restricted to the providers of the chosen path.
+func useGeminiAudio(modelName string, geminiFormat bool) bool {
+	var gemini, other bool
+	for _, provider := range util.GetProviderName(modelName) {
+		if isGeminiAudioProvider(provider) {
+			gemini = true
+		} else {
+			other = true
+		}
+	}
+	return gemini && (!other || geminiFormat)
+}
+
+// detectAudioMimeType resolves the upload's MIME type from its extension, then the part header,
+// then content sniffing. Container types registered as video/* are mapped to audio/*.
+func detectAudioMimeType(filename, partType string, data []byte) string {
+	mimeType := ""
+	if ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(filename), ".")); ext != "" {
+		mimeType = misc.MimeTypes[ext]
+	}
+	if mimeType == "" && strings.HasPrefix(partType, "audio/") {
+		mimeType = partType
+	}
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+	if idx := strings.Index(mimeType, ";"); idx >= 0 {
+		mimeType = strings.TrimSpace(mimeType[:idx])
+	}
+	if rest, ok := strings.CutPrefix(mimeType, "video/"); ok {
+		mimeType = "audio/" + rest
+	}
+	switch mimeType {
+	case "audio/x-wav", "audio/wave":
+		return "audio/wav"
+	case "audio/x-flac":
+		return "audio/flac"
+	case "audio/x-aac":
+		return "audio/aac"
+	case "audio/x-aiff":
+		return "audio/aiff"
+	}
+	return mimeType
+}
+
+func buildGeminiTranscriptionRequest(audio []byte, mimeType, language, prompt, temperature string) []byte {
+	instruction := "Generate a verbatim transcript of the speech in this audio. Respond with the transcript text only."
+	if language = strings.TrimSpace(language); language != "" {
+		instruction += " The spoken language is " + language + "."
+	}
+	if prompt = strings.TrimSpace(prompt); prompt != "" {
+		instruction += " Context and spelling hints: " + prompt
+	}
+	out := []byte(`{"contents":[{"role":"user","parts":[{"text":""},{"inlineData":{"mimeType":"","data":""}}]}]}`)
+	out, _ = sjson.SetBytes(out, "contents.0.parts.0.text", instruction)
+	out, _ = sjson.SetBytes(out, "contents.0.parts.1.inlineData.mimeType", mimeType)
+	out, _ = sjson.SetBytes(out, "contents.0.parts.1.inlineData.data", base64.StdEncoding.EncodeToString(audio))
+	if value, err := strconv.ParseFloat(strings.TrimSpace(temperature), 64); err == nil {
+		out, _ = sjson.SetBytes(out, "generationConfig.temperature", value)
+	}
+	return out
+}
+
+func convertGeminiTranscription(resp []byte, responseFormat, language string) (string, []byte) {
+	var text strings.Builder
+	for _, part := range gjson.GetBytes(resp, "candidates.0.content.parts").Array() {
+		if part.Get("thought").Bool() {
+			continue
+		}
+		text.WriteString(part.Get("text").String())
+	}
+	transcript := strings.TrimSpace(text.String())
+	if responseFormat == "text" {
+		return "text/plain; charset=utf-8", []byte(transcript)
+	}
+	out := []byte(`{"text":""}`)
+	out, _ = sjson.SetBytes(out, "text", transcript)
+	if responseFormat == "verbose_json" {
+		out, _ = sjson.SetBytes(out, "task", "transcribe")
+		out, _ = sjson.SetBytes(out, "language", language)
+	}
+	if usage := gjson.GetBytes(resp, "usageMetadata"); usage.Exists() {
+		out, _ = sjson.SetBytes(out, "usage.type", "tokens")
+		out, _ = sjson.SetBytes(out, "usage.input_tokens", usage.Get("promptTokenCount").Int())
+		out, _ = sjson.SetBytes(out, "usage.output_tokens", usage.Get("candidatesTokenCount").Int())
+		out, _ = sjson.SetBytes(out, "usage.total_tokens", usage.Get("totalTokenCount").Int())
+	}
+	return "application/json", out
+}
+
+func buildGeminiSpeechRequest(input, voice, instructions string) []byte {
+	voice = strings.TrimSpace(voice)
+	if _, isOpenAIVoice := openAIVoices[strings.ToLower(voice)]; isOpenAIVoice || voice == "" {
+		voice = defaultGeminiVoice
+	}
+	if instructions = strings.TrimSpace(instructions); instructions != "" {
+		input = instructions + ":\n" + input
+	}
+	out := []byte(`{"contents":[{"role":"user","parts":[{"text":""}]}],"generationConfig":{"responseModalities":["AUDIO"]}}`)
+	out, _ = sjson.SetBytes(out, "contents.0.parts.0.text", input)
+	out, _ = sjson.SetBytes(out, "generationConfig.speechConfig.voiceConfig.prebuiltVoiceConfig.voiceName", voice)
+	return out
+}
+
+// convertGeminiSpeech extracts the PCM audio and wraps it in a WAV container unless raw PCM
+// was requested.
+func convertGeminiSpeech(resp []byte, responseFormat string) (string, []byte, error) {
+	var inline gjson.Result
+	for _, part := range gjson.GetBytes(resp, "candidates.0.content.parts").Array() {
+		if data := part.Get("inlineData"); data.Exists() {
+			inline = data
+			break
+		}
+	}
+	if !inline.Exists() {
+		return "", nil, fmt.Errorf("upstream returned no audio")
+	}
+	pcm, err := base64.StdEncoding.DecodeString(inline.Get("data").String())
+	if err != nil {
+		return "", nil, fmt.Errorf("decode upstream audio: %w", err)
+	}
+	if responseFormat == "pcm" {
+		return "audio/pcm", pcm, nil
+	}
+	rate := defaultGeminiSampleRate
+	for _, param := range strings.Split(inline.Get("mimeType").String(), ";") {
+		if value, ok := strings.CutPrefix(strings.TrimSpace(param), "rate="); ok {
+			if parsed, errParse := strconv.Atoi(value); errParse == nil && parsed > 0 {
+				rate = parsed
+			}
+		}
+	}
+	return "audio/wav", wrapPCMAsWAV(pcm, rate), nil
+}
+
+// wrapPCMAsWAV prepends a RIFF header for 16-bit mono little-endian PCM.
+func wrapPCMAsWAV(pcm []byte, sampleRate int) []byte {
+	const channels, bitsPerSample = 1, 16
+	out := make([]byte, 44, 44+len(pcm))
+	copy(out[0:], "RIFF")
+	binary.LittleEndian.PutUint32(out[4:], uint32(36+len(pcm)))
+	copy(out[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(out[16:], 16)
+	binary.LittleEndian.PutUint16(out[20:], 1)
+	binary.LittleEndian.PutUint16(out[22:], channels)
+	binary.LittleEndian.PutUint32(out[24:], uint32(sampleRate))
+	binary.LittleEndian.PutUint32(out[28:], uint32(sampleRate*channels*bitsPerSample/8))
+	binary.LittleEndian.PutUint16(out[32:], channels*bitsPerSample/8)
+	binary.LittleEndian.PutUint16(out[34:], bitsPerSample)
+	copy(out[36:], "data")
+	binary.LittleEndian.PutUint32(out[40:], uint32(len(pcm)))
+	return append(out, pcm...)
+}
+
+func transcriptionContentType(responseFormat string) string {
+	switch responseFormat {
+	case "json", "verbose_json":
+		return "application/json"
+	case "vtt":
+		return "text/vtt; charset=utf-8"
+	default:
+		return "text/plain; charset=utf-8"
+	}
+}
+
+func speechContentType(responseFormat string) string {
+	switch responseFormat {
+	case "opus":
+		return "audio/opus"
+	case "aac":
+		return "audio/aac"
+	case "flac":
+		return "audio/flac"
+	case "wav":
+		return "audio/wav"
+	case "pcm":
+		return "audio/pcm"
+	default:
+		return "audio/mpeg"
+	}
+}
+
+func writeAudioError(c *gin.Context, status int, message, code string) {
+	c.JSON(status, handlers.ErrorResponse{
+		Error: handlers.ErrorDetail{
+			Message: message,
+			Type:    "invalid_request_error",
+			Code:    code,
+		},
+	})
+}
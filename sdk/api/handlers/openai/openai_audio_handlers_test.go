@@ -0,0 +1,108 @@
+package openai
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/tidwall/gjson"
+)
+
+func TestDetectAudioMimeType(t *testing.T) {
+	cases := []struct {
+		filename, partType, want string
+	}{
+		{"meeting.mp3", "", "audio/mpeg"},
+		{"memo.WAV", "application/octet-stream", "audio/wav"},
+		{"clip.webm", "", "audio/webm"},
+		{"upload", "audio/ogg; codecs=opus", "audio/ogg"},
+	}
+	for _, tc := range cases {
+		if got := detectAudioMimeType(tc.filename, tc.partType, []byte("data")); got != tc.want {
+			t.Errorf("detectAudioMimeType(%q, %q) = %q, want %q", tc.filename, tc.partType, got, tc.want)
+		}
+	}
+}
+
+func TestBuildGeminiSpeechRequest_MapsOpenAIVoice(t *testing.T) {
+	out := buildGeminiSpeechRequest("hello", "alloy", "Say warmly")
+	if got := gjson.GetBytes(out, "generationConfig.speechConfig.voiceConfig.prebuiltVoiceConfig.voiceName").String(); got != defaultGeminiVoice {
+		t.Fatalf("voice = %q, want %q", got, defaultGeminiVoice)
+	}
+	if got := gjson.GetBytes(out, "generationConfig.responseModalities.0").String(); got != "AUDIO" {
+		t.Fatalf("responseModalities = %q", got)
+	}
+	if got := gjson.GetBytes(out, "contents.0.parts.0.text").String(); got != "Say warmly:\nhello" {
+		t.Fatalf("text = %q", got)
+	}
+
+	out = buildGeminiSpeechRequest("hello", "Puck", "")
+	if got := gjson.GetBytes(out, "generationConfig.speechConfig.voiceConfig.prebuiltVoiceConfig.voiceName").String(); got != "Puck" {
+		t.Fatalf("Gemini voice should pass through, got %q", got)
+	}
+}
+
+func TestConvertGeminiSpeech_WrapsPCMAsWAV(t *testing.T) {
+	pcm := []byte{1, 2, 3, 4}
+	resp := []byte(`{"candidates":[{"content":{"parts":[{"inlineData":{"mimeType":"audio/L16;codec=pcm;rate=16000","data":"` + base64.StdEncoding.EncodeToString(pcm) + `"}}]}}]}`)
+
+	contentType, audio, err := convertGeminiSpeech(resp, "")
+	if err != nil {
+		t.Fatalf("convertGeminiSpeech error: %v", err)
+	}
+	if contentType != "audio/wav" || string(audio[:4]) != "RIFF" || len(audio) != 44+len(pcm) {
+		t.Fatalf("unexpected wav output %s (%d bytes)", contentType, len(audio))
+	}
+	if rate := binary.LittleEndian.Uint32(audio[24:28]); rate != 16000 {
+		t.Fatalf("sample rate = %d, want 16000", rate)
+	}
+
+	contentType, audio, err = convertGeminiSpeech(resp, "pcm")
+	if err != nil || contentType != "audio/pcm" || string(audio) != string(pcm) {
+		t.Fatalf("pcm output = %s %v %v", contentType, audio, err)
+	}
+}
+
+func TestConvertGeminiTranscription(t *testing.T) {
+	resp := []byte(`{"candidates":[{"content":{"parts":[{"text":"thinking","thought":true},{"text":" Hello world. "}]}}],"usageMetadata":{"promptTokenCount":30,"candidatesTokenCount":3,"totalTokenCount":33}}`)
+
+	contentType, body := convertGeminiTranscription(resp, "json", "")
+	if contentType != "application/json" {
+		t.Fatalf("content type = %q", contentType)
+	}
+	if got := gjson.GetBytes(body, "text").String(); got != "Hello world." {
+		t.Fatalf("text = %q", got)
+	}
+	if got := gjson.GetBytes(body, "usage.total_tokens").Int(); got != 33 {
+		t.Fatalf("usage.total_tokens = %d", got)
+	}
+
+	_, body = convertGeminiTranscription(resp, "text", "")
+	if string(body) != "Hello world." {
+		t.Fatalf("text body = %q", body)
+	}
+}
+
+func TestUseGeminiAudio_MixedProviders(t *testing.T) {
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient("audio-gemini-client", "gemini", []*registry.ModelInfo{{ID: "audio-mixed-model"}, {ID: "audio-gemini-model"}})
+	reg.RegisterClient("audio-compat-client", "openai-compatibility", []*registry.ModelInfo{{ID: "audio-mixed-model"}})
+	t.Cleanup(func() {
+		reg.UnregisterClient("audio-gemini-client")
+		reg.UnregisterClient("audio-compat-client")
+	})
+
+	if !useGeminiAudio("audio-gemini-model", false) {
+		t.Fatalf("expected a Gemini-only model to take the Gemini path")
+	}
+	if !useGeminiAudio("audio-mixed-model", true) {
+		t.Fatalf("expected a mixed model to take the Gemini path for a format Gemini supports")
+	}
+	if useGeminiAudio("audio-mixed-model", false) {
+		t.Fatalf("expected a mixed model to fall back to the OpenAI path for other formats")
+	}
+	if !isGeminiAudioProvider("vertex") || isOpenAIAudioProvider("vertex") || !isOpenAIAudioProvider("openai-compatibility") {
+		t.Fatalf("unexpected audio provider classification")
+	}
+}
@@ -0,0 +1,459 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// continuationProviders lists upstream providers whose API continues a trailing assistant message
+// (prefill) instead of starting a new turn. Mid-stream failover is limited to models served only by them:
+// OpenAI-compatible, Codex and Gemini upstreams treat a trailing assistant message as a finished turn (or
+// reject it), so a continuation there would restart or duplicate the answer rather than extend it.
+var continuationProviders = map[string]struct{}{
+	"claude": {},
+}
+
+func providersSupportContinuation(providers []string) bool {
+	if len(providers) == 0 {
+		return false
+	}
+	for _, provider := range providers {
+		if _, ok := continuationProviders[strings.ToLower(strings.TrimSpace(provider))]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// streamSplicer rewrites chunks of a continuation stream so they extend the stream already
+// delivered to the client. A nil return drops the chunk.
+type streamSplicer interface {
+	splice(chunk []byte) []byte
+}
+
+// streamContinuation records what an interrupted stream already delivered to the client so the
+// answer can be resumed on another credential with an assistant prefill.
+type streamContinuation struct {
+	format    string
+	resumable bool
+	text      strings.Builder
+	lineBuf   []byte
+
+	// Claude Messages block tracking.
+	nextIndex  int
+	openIndex  int
+	openIsText bool
+
+	// OpenAI Chat Completions chunk identity.
+	chunkID string
+
+	// authID is the credential serving the current continuation; failedAuths are excluded from
+	// reselection so a resume never lands on a credential that already broke this stream.
+	authID      string
+	failedAuths []string
+}
+
+// newStreamContinuation returns a tracker for handlerType, or nil when the format cannot be resumed.
+func newStreamContinuation(handlerType string) *streamContinuation {
+	switch handlerType {
+	case "claude", "openai":
+		return &streamContinuation{format: handlerType, resumable: true, openIndex: -1}
+	default:
+		return nil
+	}
+}
+
+// canResume reports whether the delivered output is plain text that a prefill can continue.
+func (s *streamContinuation) canResume() bool {
+	return s != nil && s.resumable
+}
+
+// deliveredLen returns the number of text bytes delivered to the client so far.
+func (s *streamContinuation) deliveredLen() int {
+	if s == nil {
+		return 0
+	}
+	return s.text.Len()
+}
+
+// observe inspects a chunk sent to the client.
+func (s *streamContinuation) observe(chunk []byte) {
+	if s == nil || !s.resumable || len(chunk) == 0 {
+		return
+	}
+	if s.format == "openai" {
+		s.observeOpenAI(chunk)
+		return
+	}
+	s.lineBuf = append(s.lineBuf, chunk...)
+	for {
+		idx := bytes.IndexByte(s.lineBuf, '\n')
+		if idx < 0 {
+			return
+		}
+		line := bytes.TrimSpace(s.lineBuf[:idx])
+		s.lineBuf = s.lineBuf[idx+1:]
+		if data, ok := sseData(line); ok {
+			s.observeClaudeEvent(gjson.ParseBytes(data))
+		}
+	}
+}
+
+func (s *streamContinuation) observeClaudeEvent(event gjson.Result) {
+	switch event.Get("type").String() {
+	case "content_block_start":
+		index := int(event.Get("index").Int())
+		s.nextIndex = max(s.nextIndex, index+1)
+		s.openIndex = index
+		switch event.Get("content_block.type").String() {
+		case "text":
+			s.openIsText = true
+		case "thinking", "redacted_thinking":
+			s.openIsText = false
+		default:
+			// Tool calls cannot be continued reliably from a prefill.
+			s.resumable = false
+		}
+	case "content_block_delta":
+		switch event.Get("delta.type").String() {
+		case "text_delta":
+			s.text.WriteString(event.Get("delta.text").String())
+		case "input_json_delta":
+			s.resumable = false
+		}
+	case "content_block_stop":
+		s.openIndex = -1
+		s.openIsText = false
+	case "message_delta", "message_stop":
+		// The answer is complete; a later error is not a truncation.
+		s.resumable = false
+	}
+}
+
+func (s *streamContinuation) observeOpenAI(chunk []byte) {
+	data, ok := sseData(bytes.TrimSpace(chunk))
+	if !ok {
+		data = bytes.TrimSpace(chunk)
+	}
+	if !gjson.ValidBytes(data) {
+		return
+	}
+	root := gjson.ParseBytes(data)
+	if id := root.Get("id").String(); id != "" && s.chunkID == "" {
+		s.chunkID = id
+	}
+	choices := root.Get("choices").Array()
+	if len(choices) > 1 {
+		s.resumable = false
+		return
+	}
+	for _, choice := range choices {
+		if choice.Get("delta.tool_calls").Exists() || choice.Get("delta.function_call").Exists() {
+			s.resumable = false
+			return
+		}
+		s.text.WriteString(choice.Get("delta.content").String())
+		if reason := choice.Get("finish_reason"); reason.Exists() && reason.Type != gjson.Null && reason.String() != "" {
+			s.resumable = false
+		}
+	}
+}
+
+// continuationPayload returns the original request extended with the delivered text as an assistant
+// prefill, together with a splicer for the continuation stream and a preamble to send before it.
+func (s *streamContinuation) continuationPayload(raw []byte) ([]byte, streamSplicer, []byte, error) {
+	delivered := s.text.String()
+	prefill := strings.TrimRight(delivered, " \t\r\n")
+	skip := delivered[len(prefill):]
+	payload := raw
+	if prefill != "" {
+		var errPrefill error
+		payload, errPrefill = appendAssistantPrefill(raw, prefill, s.format == "claude")
+		if errPrefill != nil {
+			return nil, nil, nil, errPrefill
+		}
+	}
+	if s.format == "openai" {
+		return payload, &openAIChatSplicer{chunkID: s.chunkID, skip: skip}, nil, nil
+	}
+	splicer := &claudeMessagesSplicer{
+		indexMap:    make(map[int]int),
+		nextIndex:   s.nextIndex,
+		resumeIndex: -1,
+		skip:        skip,
+	}
+	var preamble []byte
+	if s.openIndex >= 0 {
+		if s.openIsText {
+			splicer.resumeIndex = s.openIndex
+		} else {
+			// Close the interrupted thinking block; the continuation starts a fresh one.
+			stop, _ := sjson.SetBytes([]byte(`{"type":"content_block_stop"}`), "index", s.openIndex)
+			preamble = formatSSEEvent("content_block_stop", stop)
+			s.openIndex = -1
+		}
+	}
+	return payload, splicer, preamble, nil
+}
+
+// appendAssistantPrefill appends (or extends) a trailing assistant message with prefill. Extended
+// thinking is disabled because upstreams reject it together with an assistant prefill.
+func appendAssistantPrefill(raw []byte, prefill string, claudeFormat bool) ([]byte, error) {
+	out := raw
+	messages := gjson.GetBytes(out, "messages").Array()
+	if n := len(messages); n > 0 && messages[n-1].Get("role").String() == "assistant" {
+		last := messages[n-1]
+		path := "messages." + strconv.Itoa(n-1) + ".content"
+		content := last.Get("content")
+		var errSet error
+		if content.IsArray() {
+			out, errSet = sjson.SetBytes(out, path+".-1", map[string]any{"type": "text", "text": prefill})
+		} else {
+			out, errSet = sjson.SetBytes(out, path, content.String()+prefill)
+		}
+		if errSet != nil {
+			return nil, errSet
+		}
+	} else {
+		message := map[string]any{"role": "assistant", "content": prefill}
+		if claudeFormat {
+			message["content"] = []map[string]any{{"type": "text", "text": prefill}}
+		}
+		var errSet error
+		out, errSet = sjson.SetBytes(out, "messages.-1", message)
+		if errSet != nil {
+			return nil, errSet
+		}
+	}
+	for _, path := range []string{"thinking", "reasoning_effort"} {
+		if gjson.GetBytes(out, path).Exists() {
+			out, _ = sjson.DeleteBytes(out, path)
+		}
+	}
+	return out, nil
+}
+
+// trimSkip drops leading whitespace of a continuation delta that duplicates the trailing
+// whitespace removed from the prefill.
+func trimSkip(text string, skip *string) string {
+	for *skip != "" && text != "" && text[0] == (*skip)[0] {
+		text = text[1:]
+		*skip = (*skip)[1:]
+	}
+	if text != "" {
+		*skip = ""
+	}
+	return text
+}
+
+// claudeMessagesSplicer merges a Claude Messages continuation stream into the delivered one: the
+// message preamble is dropped, the first text block extends the interrupted block, other block
+// indexes are shifted, and thinking blocks are skipped.
+type claudeMessagesSplicer struct {
+	lineBuf     []byte
+	eventName   string
+	indexMap    map[int]int
+	dropped     map[int]bool
+	nextIndex   int
+	resumeIndex int
+	merged      bool
+	skip        string
+}
+
+func (p *claudeMessagesSplicer) splice(chunk []byte) []byte {
+	p.lineBuf = append(p.lineBuf, chunk...)
+	var out []byte
+	for {
+		idx := bytes.IndexByte(p.lineBuf, '\n')
+		if idx < 0 {
+			return out
+		}
+		line := bytes.TrimRight(p.lineBuf[:idx], "\r")
+		p.lineBuf = p.lineBuf[idx+1:]
+		trimmed := bytes.TrimSpace(line)
+		switch {
+		case len(trimmed) == 0:
+			// Event boundaries are re-emitted with each rewritten data line.
+		case bytes.HasPrefix(trimmed, []byte("event:")):
+			p.eventName = strings.TrimSpace(string(trimmed[len("event:"):]))
+		default:
+			data, ok := sseData(trimmed)
+			if !ok {
+				out = append(out, line...)
+				out = append(out, '\n')
+				continue
+			}
+			if rewritten := p.rewrite(data); rewritten != nil {
+				name := p.eventName
+				if name == "" {
+					name = gjson.GetBytes(rewritten, "type").String()
+				}
+				out = append(out, formatSSEEvent(name, rewritten)...)
+			}
+			p.eventName = ""
+		}
+	}
+}
+
+func (p *claudeMessagesSplicer) rewrite(data []byte) []byte {
+	event := gjson.ParseBytes(data)
+	switch event.Get("type").String() {
+	case "message_start", "ping":
+		return nil
+	case "content_block_start":
+		index := int(event.Get("index").Int())
+		switch event.Get("content_block.type").String() {
+		case "thinking", "redacted_thinking":
+			if p.dropped == nil {
+				p.dropped = make(map[int]bool)
+			}
+			p.dropped[index] = true
+			return nil
+		case "text":
+			if !p.merged && p.resumeIndex >= 0 {
+				p.merged = true
+				p.indexMap[index] = p.resumeIndex
+				return nil
+			}
+		}
+		p.indexMap[index] = p.nextIndex
+		p.nextIndex++
+		out, _ := sjson.SetBytes(data, "index", p.indexMap[index])
+		return out
+	case "content_block_delta", "content_block_stop":
+		index := int(event.Get("index").Int())
+		if p.dropped[index] {
+			return nil
+		}
+		mapped, ok := p.indexMap[index]
+		if !ok {
+			mapped = index
+		}
+		out, _ := sjson.SetBytes(data, "index", mapped)
+		if event.Get("delta.type").String() == "text_delta" && p.skip != "" {
+			text := trimSkip(event.Get("delta.text").String(), &p.skip)
+			if text == "" {
+				return nil
+			}
+			out, _ = sjson.SetBytes(out, "delta.text", text)
+		}
+		return out
+	default:
+		return data
+	}
+}
+
+// openAIChatSplicer merges an OpenAI Chat Completions continuation stream into the delivered one:
+// chunks keep the original completion ID, the repeated role header and reasoning deltas are dropped.
+type openAIChatSplicer struct {
+	chunkID string
+	skip    string
+}
+
+func (p *openAIChatSplicer) splice(chunk []byte) []byte {
+	data := bytes.TrimSpace(chunk)
+	if !gjson.ValidBytes(data) {
+		return chunk
+	}
+	out := data
+	if p.chunkID != "" && gjson.GetBytes(out, "id").Exists() {
+		out, _ = sjson.SetBytes(out, "id", p.chunkID)
+	}
+	choices := gjson.GetBytes(out, "choices").Array()
+	if len(choices) == 0 {
+		return out
+	}
+	keep := false
+	for i, choice := range choices {
+		prefix := "choices." + strconv.Itoa(i) + ".delta."
+		out, _ = sjson.DeleteBytes(out, prefix+"role")
+		out, _ = sjson.DeleteBytes(out, prefix+"reasoning_content")
+		if content := choice.Get("delta.content"); content.Exists() && p.skip != "" {
+			out, _ = sjson.SetBytes(out, prefix+"content", trimSkip(content.String(), &p.skip))
+		}
+		for key, value := range gjson.GetBytes(out, "choices."+strconv.Itoa(i)+".delta").Map() {
+			if key != "content" || value.String() != "" {
+				keep = true
+			}
+		}
+		if reason := choice.Get("finish_reason"); reason.Exists() && reason.Type != gjson.Null {
+			keep = true
+		}
+	}
+	if !keep && !gjson.GetBytes(out, "usage").IsObject() {
+		return nil
+	}
+	return out
+}
+
+func sseData(line []byte) ([]byte, bool) {
+	if !bytes.HasPrefix(line, []byte("data:")) {
+		return nil, false
+	}
+	return bytes.TrimSpace(line[len("data:"):]), true
+}
+
+func formatSSEEvent(name string, data []byte) []byte {
+	out := make([]byte, 0, len(name)+len(data)+16)
+	out = append(out, "event: "...)
+	out = append(out, name...)
+	out = append(out, "\ndata: "...)
+	out = append(out, data...)
+	out = append(out, "\n\n"...)
+	return out
+}
+
+// resumedStream is a continuation stream together with the splicer merging it into the client stream.
+type resumedStream struct {
+	chunks  <-chan coreexecutor.StreamChunk
+	splicer streamSplicer
+}
+
+// resumeStream re-issues an interrupted streaming request with the delivered text as an assistant
+// prefill. The splice point is logged so truncated-then-resumed answers can be identified.
+func (h *BaseAPIHandler) resumeStream(ctx context.Context, providers []string, req coreexecutor.Request, opts coreexecutor.Options, continuation *streamContinuation, cause error) (*resumedStream, []byte, error) {
+	payload, splicer, preamble, errPayload := continuation.continuationPayload(req.Payload)
+	if errPayload != nil {
+		return nil, nil, cause
+	}
+	resumeReq := req
+	resumeReq.Payload = payload
+	failedAuth := continuation.authID
+	if failedAuth == "" {
+		failedAuth, _ = opts.Metadata[coreexecutor.SelectedAuthMetadataKey].(string)
+	}
+	if failedAuth != "" {
+		continuation.failedAuths = append(continuation.failedAuths, failedAuth)
+	}
+	resumeOpts := opts
+	resumeOpts.OriginalRequest = payload
+	resumeOpts.Metadata = make(map[string]any, len(opts.Metadata)+1)
+	for key, value := range opts.Metadata {
+		resumeOpts.Metadata[key] = value
+	}
+	delete(resumeOpts.Metadata, coreexecutor.SelectedAuthMetadataKey)
+	resumeOpts.Metadata[coreexecutor.ExcludedAuthsMetadataKey] = append([]string(nil), continuation.failedAuths...)
+	entry := log.WithField("model", req.Model)
+	if requestID := logging.GetRequestID(ctx); requestID != "" {
+		entry = entry.WithField("request_id", requestID)
+	}
+	entry.Warnf("mid-stream failover: upstream stream failed after %d delivered text bytes (%v), splicing continuation", continuation.deliveredLen(), cause)
+	result, errExec := h.AuthManager.ExecuteStream(ctx, providers, resumeReq, resumeOpts)
+	if errExec != nil {
+		entry.Warnf("mid-stream failover: continuation request failed: %v", errExec)
+		return nil, nil, errExec
+	}
+	if authID, ok := resumeOpts.Metadata[coreexecutor.SelectedAuthMetadataKey].(string); ok && authID != "" {
+		continuation.authID = authID
+		entry.Infof("mid-stream failover: continuation spliced from auth %s", authID)
+	}
+	return &resumedStream{chunks: result.Chunks, splicer: splicer}, preamble, nil
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+type midStreamFailoverExecutor struct {
+	mu       sync.Mutex
+	payloads [][]byte
+	authIDs  []string
+}
+
+func (e *midStreamFailoverExecutor) Identifier() string { return "claude" }
+
+func (e *midStreamFailoverExecutor) Execute(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "Execute not implemented"}
+}
+
+func (e *midStreamFailoverExecutor) ExecuteStream(_ context.Context, auth *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	e.mu.Lock()
+	e.payloads = append(e.payloads, req.Payload)
+	e.authIDs = append(e.authIDs, auth.ID)
+	call := len(e.payloads)
+	e.mu.Unlock()
+
+	ch := make(chan coreexecutor.StreamChunk, 4)
+	if call == 1 {
+		ch <- coreexecutor.StreamChunk{Payload: []byte(`{"id":"chatcmpl-1","choices":[{"index":0,"delta":{"role":"assistant","content":"Hello "},"finish_reason":null}]}`)}
+		ch <- coreexecutor.StreamChunk{Err: &coreauth.Error{Code: "upstream_closed", Message: "upstream closed", HTTPStatus: http.StatusBadGateway}}
+	} else {
+		ch <- coreexecutor.StreamChunk{Payload: []byte(`{"id":"chatcmpl-2","choices":[{"index":0,"delta":{"role":"assistant","content":""},"finish_reason":null}]}`)}
+		ch <- coreexecutor.StreamChunk{Payload: []byte(`{"id":"chatcmpl-2","choices":[{"index":0,"delta":{"content":" world"},"finish_reason":null}]}`)}
+		ch <- coreexecutor.StreamChunk{Payload: []byte(`{"id":"chatcmpl-2","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`)}
+	}
+	close(ch)
+	return &coreexecutor.StreamResult{Chunks: ch}, nil
+}
+
+func (e *midStreamFailoverExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *midStreamFailoverExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func (e *midStreamFailoverExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "HttpRequest not implemented", HTTPStatus: http.StatusNotImplemented}
+}
+
+func (e *midStreamFailoverExecutor) Payloads() [][]byte {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([][]byte(nil), e.payloads...)
+}
+
+func (e *midStreamFailoverExecutor) AuthIDs() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]string(nil), e.authIDs...)
+}
+
+func TestExecuteStreamWithAuthManager_MidStreamFailoverSplicesContinuation(t *testing.T) {
+	executor := &midStreamFailoverExecutor{}
+	// Fill-first would hand the continuation back to the failed credential unless it is excluded.
+	manager := coreauth.NewManager(nil, &coreauth.FillFirstSelector{}, nil)
+	manager.RegisterExecutor(executor)
+
+	for _, id := range []string{"midstream-auth1", "midstream-auth2"} {
+		auth := &coreauth.Auth{ID: id, Provider: "claude", Status: coreauth.StatusActive}
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("manager.Register(%s): %v", id, err)
+		}
+		registry.GetGlobalRegistry().RegisterClient(id, "claude", []*registry.ModelInfo{{ID: "midstream-model"}})
+	}
+	t.Cleanup(func() {
+		registry.GetGlobalRegistry().UnregisterClient("midstream-auth1")
+		registry.GetGlobalRegistry().UnregisterClient("midstream-auth2")
+	})
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{
+		Streaming: sdkconfig.StreamingConfig{MidStreamRetries: 1},
+	}, manager)
+	raw := []byte(`{"model":"midstream-model","reasoning_effort":"high","messages":[{"role":"user","content":"hi"}]}`)
+	dataChan, _, errChan := handler.ExecuteStreamWithAuthManager(context.Background(), "openai", "midstream-model", raw, "")
+
+	var chunks []string
+	for chunk := range dataChan {
+		chunks = append(chunks, string(chunk))
+	}
+	for msg := range errChan {
+		if msg != nil {
+			t.Fatalf("unexpected error: %+v", msg)
+		}
+	}
+
+	var text strings.Builder
+	for _, chunk := range chunks {
+		if id := gjson.Get(chunk, "id").String(); id != "chatcmpl-1" {
+			t.Fatalf("chunk id = %q, want chatcmpl-1 in %s", id, chunk)
+		}
+		if gjson.Get(chunk, "choices.0.delta.role").Exists() && text.Len() > 0 {
+			t.Fatalf("continuation repeated the role header: %s", chunk)
+		}
+		text.WriteString(gjson.Get(chunk, "choices.0.delta.content").String())
+	}
+	if text.String() != "Hello world" {
+		t.Fatalf("spliced text = %q, want %q", text.String(), "Hello world")
+	}
+
+	payloads := executor.Payloads()
+	if len(payloads) != 2 {
+		t.Fatalf("expected 2 upstream attempts, got %d", len(payloads))
+	}
+	if authIDs := executor.AuthIDs(); authIDs[0] == authIDs[1] {
+		t.Fatalf("continuation reused failed auth %s", authIDs[0])
+	}
+	last := gjson.GetBytes(payloads[1], "messages.@reverse.0")
+	if last.Get("role").String() != "assistant" || last.Get("content").String() != "Hello" {
+		t.Fatalf("continuation prefill = %s", last.Raw)
+	}
+	if gjson.GetBytes(payloads[1], "reasoning_effort").Exists() {
+		t.Fatalf("expected reasoning_effort to be removed from continuation request")
+	}
+}
+
+func TestClaudeMessagesSplicer_MergesIntoOpenTextBlock(t *testing.T) {
+	continuation := newStreamContinuation("claude")
+	continuation.observe([]byte("event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_1\"}}\n\n"))
+	continuation.observe([]byte("event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"thinking\",\"thinking\":\"\"}}\n\n"))
+	continuation.observe([]byte("event: content_block_stop\ndata: {\"type\":\"content_block_stop\",\"index\":0}\n\n"))
+	continuation.observe([]byte("event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":1,\"content_block\":{\"type\":\"text\",\"text\":\"\"}}\n\n"))
+	continuation.observe([]byte("event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":1,\"delta\":{\"type\":\"text_delta\",\"text\":\"Hello \"}}\n\n"))
+	if !continuation.canResume() {
+		t.Fatalf("expected text stream to be resumable")
+	}
+
+	payload, splicer, preamble, errPayload := continuation.continuationPayload([]byte(`{"thinking":{"type":"enabled"},"messages":[{"role":"user","content":"hi"}]}`))
+	if errPayload != nil {
+		t.Fatalf("continuationPayload error: %v", errPayload)
+	}
+	if len(preamble) != 0 {
+		t.Fatalf("unexpected preamble %q", preamble)
+	}
+	if got := gjson.GetBytes(payload, "messages.1.content.0.text").String(); got != "Hello" {
+		t.Fatalf("prefill = %q, want %q", got, "Hello")
+	}
+	if gjson.GetBytes(payload, "thinking").Exists() {
+		t.Fatalf("expected thinking to be removed from continuation request")
+	}
+
+	lines := []string{
+		"event: message_start\n", "data: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_2\"}}\n", "\n",
+		"event: content_block_start\n", "data: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"text\",\"text\":\"\"}}\n", "\n",
+		"event: content_block_delta\n", "data: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\" world\"}}\n", "\n",
+		"event: content_block_stop\n", "data: {\"type\":\"content_block_stop\",\"index\":0}\n", "\n",
+		"event: content_block_start\n", "data: {\"type\":\"content_block_start\",\"index\":1,\"content_block\":{\"type\":\"tool_use\",\"id\":\"t1\",\"name\":\"x\",\"input\":{}}}\n", "\n",
+		"event: message_stop\n", "data: {\"type\":\"message_stop\"}\n", "\n",
+	}
+	var out strings.Builder
+	for _, line := range lines {
+		out.Write(splicer.splice([]byte(line)))
+	}
+	got := out.String()
+	if strings.Contains(got, "message_start") {
+		t.Fatalf("expected message_start to be dropped, got %q", got)
+	}
+	if strings.Contains(got, `"index":0`) {
+		t.Fatalf("expected continuation indexes to be remapped, got %q", got)
+	}
+	for _, want := range []string{
+		`{"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"world"}}`,
+		`{"type":"content_block_stop","index":1}`,
+		`"type":"content_block_start","index":2`,
+		"event: message_stop\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("expected %q in spliced stream %q", want, got)
+		}
+	}
+}
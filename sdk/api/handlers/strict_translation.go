@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+)
+
+const (
+	// StrictTranslationHeader lets a client choose the strict translation mode of one request.
+	StrictTranslationHeader = "X-CPA-Strict-Translation"
+	// DroppedFeaturesHeader lists the request features dropped by translation in warn mode.
+	DroppedFeaturesHeader = "X-CPA-Dropped-Features"
+)
+
+// providerTargetFormats maps providers to the format their executor translates requests into.
+// Providers not listed speak OpenAI Chat Completions.
+var providerTargetFormats = map[string]string{
+	"gemini":      "gemini",
+	"vertex":      "gemini",
+	"aistudio":    "gemini",
+	"gemini-cli":  "gemini-cli",
+	"antigravity": "antigravity",
+	"claude":      "claude",
+	"codex":       "codex",
+	"kiro":        "kiro",
+}
+
+func providerTargetFormat(provider string) sdktranslator.Format {
+	if format, ok := providerTargetFormats[strings.ToLower(provider)]; ok {
+		return sdktranslator.FromString(format)
+	}
+	return sdktranslator.FromString("openai")
+}
+
+// checkTranslation applies the strict translation mode to a request about to be sent to one of
+// providers and returns the providers it may be sent to. Because the provider is picked later, warn
+// mode reports every feature any candidate would drop in the DroppedFeaturesHeader response header.
+// Reject mode narrows the candidates to the providers that keep every feature and fails with 400
+// only when none is left, so the request never reaches a provider that would drop a feature.
+func (h *BaseAPIHandler) checkTranslation(ctx context.Context, handlerType string, providers []string, rawJSON []byte) ([]string, *interfaces.ErrorMessage) {
+	if ctx == nil {
+		return providers, nil
+	}
+	ginCtx, _ := ctx.Value("gin").(*gin.Context)
+	mode := h.strictTranslationMode(ginCtx)
+	if mode == config.StrictTranslationOff {
+		return providers, nil
+	}
+
+	from := sdktranslator.FromString(handlerType)
+	seen := make(map[string]struct{})
+	var dropped []string
+	lossless := make([]string, 0, len(providers))
+	for _, provider := range providers {
+		features := sdktranslator.UnsupportedFeatures(from, providerTargetFormat(provider), rawJSON)
+		if len(features) == 0 {
+			lossless = append(lossless, provider)
+		}
+		for _, feature := range features {
+			if _, dup := seen[feature]; !dup {
+				seen[feature] = struct{}{}
+				dropped = append(dropped, feature)
+			}
+		}
+	}
+	if len(dropped) == 0 {
+		return providers, nil
+	}
+	sort.Strings(dropped)
+
+	if mode == config.StrictTranslationReject {
+		if len(lossless) > 0 {
+			log.Debugf("strict translation: %s request limited to %s, %s drop %s", handlerType, strings.Join(lossless, ", "), strings.Join(providers, ", "), strings.Join(dropped, ", "))
+			return lossless, nil
+		}
+		return nil, &interfaces.ErrorMessage{
+			StatusCode: http.StatusBadRequest,
+			Error: fmt.Errorf("request uses features not supported when translating %s requests for %s: %s",
+				handlerType, strings.Join(providers, ", "), strings.Join(dropped, ", ")),
+		}
+	}
+	log.Debugf("strict translation: %s request for %s drops %s", handlerType, strings.Join(providers, ", "), strings.Join(dropped, ", "))
+	if ginCtx != nil {
+		ginCtx.Header(DroppedFeaturesHeader, strings.Join(dropped, ", "))
+	}
+	return providers, nil
+}
+
+// strictTranslationModeRank orders modes from least to most strict.
+var strictTranslationModeRank = map[string]int{
+	config.StrictTranslationOff:    0,
+	config.StrictTranslationWarn:   1,
+	config.StrictTranslationReject: 2,
+}
+
+// strictTranslationMode resolves the mode configured for the client API key, falling back to the
+// global setting. The request header can only make the mode stricter, never relax it.
+func (h *BaseAPIHandler) strictTranslationMode(ginCtx *gin.Context) string {
+	mode := config.StrictTranslationOff
+	if h.Cfg != nil {
+		apiKey := ""
+		if ginCtx != nil {
+			if v, exists := ginCtx.Get("apiKey"); exists {
+				apiKey, _ = v.(string)
+			}
+		}
+		mode = h.Cfg.StrictTranslation.ModeFor(apiKey)
+	}
+	if ginCtx != nil && ginCtx.Request != nil {
+		requested := config.NormalizeStrictTranslationMode(ginCtx.GetHeader(StrictTranslationHeader))
+		if requested != "" && strictTranslationModeRank[requested] > strictTranslationModeRank[mode] {
+			mode = requested
+		}
+	}
+	return mode
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func init() {
+	// The structured-test provider speaks OpenAI; declare a source format whose translator to
+	// OpenAI drops "seed".
+	sdktranslator.RegisterFeatures(sdktranslator.FromString("strict-src"), sdktranslator.Feature{
+		Name:   "seed",
+		Detect: func(root gjson.Result) bool { return root.Get("seed").Exists() },
+	})
+	sdktranslator.RegisterCapabilities(sdktranslator.FromString("strict-src"), sdktranslator.FromString("openai"), "seed")
+}
+
+func newStrictTranslationContext(header string) (context.Context, *gin.Context) {
+	ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ginCtx.Request = httptest.NewRequest(http.MethodPost, "/v1/test", nil)
+	if header != "" {
+		ginCtx.Request.Header.Set(StrictTranslationHeader, header)
+	}
+	ginCtx.Set("apiKey", "client-key")
+	return context.WithValue(context.Background(), "gin", ginCtx), ginCtx
+}
+
+func TestCheckTranslation_RejectModeFromHeader(t *testing.T) {
+	handler, executor := newStructuredOutputTestHandler(t, "strict-reject", 0, "ok")
+	ctx, _ := newStrictTranslationContext("reject")
+
+	_, _, errMsg := handler.ExecuteWithAuthManager(ctx, "strict-src", "structured-model", []byte(`{"model":"structured-model","seed":7}`), "")
+	if errMsg == nil {
+		t.Fatal("expected strict translation to reject the request")
+	}
+	if errMsg.StatusCode != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", errMsg.StatusCode)
+	}
+	if !strings.Contains(errMsg.Error.Error(), "seed") {
+		t.Fatalf("error does not name the dropped feature: %v", errMsg.Error)
+	}
+	if len(executor.payloads) != 0 {
+		t.Fatalf("executor called %d times, want 0", len(executor.payloads))
+	}
+}
+
+func TestCheckTranslation_WarnModeFromAPIKeySetsHeader(t *testing.T) {
+	handler, executor := newStructuredOutputTestHandler(t, "strict-warn", 0, "ok")
+	handler.Cfg.StrictTranslation = sdkconfig.StrictTranslationConfig{
+		Mode:    sdkconfig.StrictTranslationReject,
+		APIKeys: map[string]string{"client-key": sdkconfig.StrictTranslationWarn},
+	}
+	ctx, ginCtx := newStrictTranslationContext("")
+
+	_, _, errMsg := handler.ExecuteWithAuthManager(ctx, "strict-src", "structured-model", []byte(`{"model":"structured-model","seed":7}`), "")
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %v", errMsg.Error)
+	}
+	if got := ginCtx.Writer.Header().Get(DroppedFeaturesHeader); got != "seed" {
+		t.Fatalf("%s = %q, want seed", DroppedFeaturesHeader, got)
+	}
+	if len(executor.payloads) != 1 {
+		t.Fatalf("executor called %d times, want 1", len(executor.payloads))
+	}
+}
+
+func TestCheckTranslation_OffByDefault(t *testing.T) {
+	handler, executor := newStructuredOutputTestHandler(t, "strict-off", 0, "ok")
+	ctx, ginCtx := newStrictTranslationContext("")
+
+	_, _, errMsg := handler.ExecuteWithAuthManager(ctx, "strict-src", "structured-model", []byte(`{"model":"structured-model","seed":7}`), "")
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %v", errMsg.Error)
+	}
+	if got := ginCtx.Writer.Header().Get(DroppedFeaturesHeader); got != "" {
+		t.Fatalf("%s = %q, want empty", DroppedFeaturesHeader, got)
+	}
+	if len(executor.payloads) != 1 {
+		t.Fatalf("executor called %d times, want 1", len(executor.payloads))
+	}
+}
+
+func TestStrictTranslationMode_HeaderOnlyTightens(t *testing.T) {
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{StrictTranslation: sdkconfig.StrictTranslationConfig{Mode: sdkconfig.StrictTranslationReject}}, nil)
+	_, ginCtx := newStrictTranslationContext("off")
+	if got := handler.strictTranslationMode(ginCtx); got != sdkconfig.StrictTranslationReject {
+		t.Fatalf("header relaxed the mode to %q", got)
+	}
+
+	handler.Cfg.StrictTranslation.Mode = sdkconfig.StrictTranslationWarn
+	_, ginCtx = newStrictTranslationContext("reject")
+	if got := handler.strictTranslationMode(ginCtx); got != sdkconfig.StrictTranslationReject {
+		t.Fatalf("header did not tighten the mode, got %q", got)
+	}
+}
+
+func TestCheckTranslation_RejectModeKeepsLosslessProviders(t *testing.T) {
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, nil)
+	ctx, _ := newStrictTranslationContext("reject")
+	payload := []byte(`{"model":"m","seed":7}`)
+
+	providers, errMsg := handler.checkTranslation(ctx, "strict-src", []string{"openai", "claude"}, payload)
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %v", errMsg.Error)
+	}
+	if len(providers) != 1 || providers[0] != "claude" {
+		t.Fatalf("providers = %v, want [claude]", providers)
+	}
+
+	if _, errMsg = handler.checkTranslation(ctx, "strict-src", []string{"openai"}, payload); errMsg == nil || errMsg.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 when every provider drops a feature, got %+v", errMsg)
+	}
+}
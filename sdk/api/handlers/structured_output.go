@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+	"golang.org/x/net/context"
+)
+
+// ExecuteWithStructuredOutput runs a non-streaming OpenAI Chat Completions or Responses request
+// like ExecuteWithAuthManager. When structured-output.validate is enabled and the request asks
+// for JSON output, the answer is checked against the requested schema; a nonconforming answer
+// is sent back to the model with the violations up to structured-output.repair-retries times,
+// and a 422 error is returned when it still does not conform. Streaming requests are not
+// validated because their output has already reached the client.
+func (h *BaseAPIHandler) ExecuteWithStructuredOutput(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, http.Header, *interfaces.ErrorMessage) {
+	if rh := h.hooksFor(ctx, handlerType, modelName, false); rh != nil {
+		return h.executeWithHooks(ctx, rh, rawJSON, func(hookedCtx context.Context, payload []byte) ([]byte, http.Header, *interfaces.ErrorMessage) {
+			return h.ExecuteWithStructuredOutput(hookedCtx, handlerType, modelName, payload, alt)
+		})
+	}
+	resp, headers, errMsg := h.ExecuteWithAuthManager(ctx, handlerType, modelName, rawJSON, alt)
+	if errMsg != nil || h.Cfg == nil || !h.Cfg.StructuredOutput.Validate {
+		return resp, headers, errMsg
+	}
+	var format util.StructuredOutput
+	var requested bool
+	switch handlerType {
+	case constant.OpenAI:
+		format, requested = util.OpenAIChatStructuredOutput(rawJSON)
+	case constant.OpenaiResponse:
+		format, requested = util.OpenAIResponsesStructuredOutput(rawJSON)
+	}
+	if !requested {
+		return resp, headers, nil
+	}
+
+	retries := h.Cfg.StructuredOutput.RepairRetries
+	payload := rawJSON
+	for attempt := 0; ; attempt++ {
+		text, answered := structuredOutputText(handlerType, resp)
+		if !answered {
+			// Tool calls or empty answers carry nothing to validate.
+			return resp, headers, nil
+		}
+		violations := validateStructuredOutput(format, text)
+		if len(violations) == 0 {
+			return resp, headers, nil
+		}
+		if attempt >= retries {
+			return nil, headers, &interfaces.ErrorMessage{
+				StatusCode: http.StatusUnprocessableEntity,
+				Error: fmt.Errorf("model output does not conform to the requested JSON schema after %d repair attempt(s): %s",
+					attempt, strings.Join(violations, "; ")),
+			}
+		}
+		log.Debugf("structured output of model %s does not conform (attempt %d): %s", modelName, attempt+1, strings.Join(violations, "; "))
+		payload = appendStructuredOutputRepair(handlerType, payload, text, violations)
+		resp, headers, errMsg = h.ExecuteWithAuthManager(ctx, handlerType, modelName, payload, alt)
+		if errMsg != nil {
+			return nil, headers, errMsg
+		}
+	}
+}
+
+// structuredOutputText returns the answer text of a Chat Completions or Responses body.
+func structuredOutputText(handlerType string, resp []byte) (string, bool) {
+	if handlerType == constant.OpenAI {
+		content := gjson.GetBytes(resp, "choices.0.message.content")
+		if content.Type != gjson.String || strings.TrimSpace(content.String()) == "" {
+			return "", false
+		}
+		return content.String(), true
+	}
+	var text strings.Builder
+	gjson.GetBytes(resp, "output").ForEach(func(_, item gjson.Result) bool {
+		if item.Get("type").String() != "message" {
+			return true
+		}
+		item.Get("content").ForEach(func(_, part gjson.Result) bool {
+			if part.Get("type").String() == "output_text" {
+				text.WriteString(part.Get("text").String())
+			}
+			return true
+		})
+		return true
+	})
+	if strings.TrimSpace(text.String()) == "" {
+		return "", false
+	}
+	return text.String(), true
+}
+
+// validateStructuredOutput checks text against the requested format. json_object requests only
+// require a JSON object.
+func validateStructuredOutput(format util.StructuredOutput, text string) []string {
+	text = strings.TrimSpace(text)
+	if format.HasSchema() {
+		return util.ValidateJSONSchema(format.Schema, []byte(text))
+	}
+	if !gjson.Valid(text) {
+		return []string{"$: output is not valid JSON"}
+	}
+	if !gjson.Parse(text).IsObject() {
+		return []string{"$: output is not a JSON object"}
+	}
+	return nil
+}
+
+// appendStructuredOutputRepair adds the nonconforming answer and a correction request to the
+// conversation so the model can produce a conforming answer.
+func appendStructuredOutputRepair(handlerType string, payload []byte, text string, violations []string) []byte {
+	instruction := "Your previous answer does not conform to the required JSON schema:\n- " +
+		strings.Join(violations, "\n- ") +
+		"\nReply again with only the corrected JSON."
+	assistant, _ := sjson.SetBytes([]byte(`{"role":"assistant","content":""}`), "content", text)
+	user, _ := sjson.SetBytes([]byte(`{"role":"user","content":""}`), "content", instruction)
+
+	path := "messages"
+	if handlerType == constant.OpenaiResponse {
+		path = "input"
+		if input := gjson.GetBytes(payload, "input"); input.Type == gjson.String {
+			first, _ := sjson.SetBytes([]byte(`{"role":"user","content":""}`), "content", input.String())
+			payload, _ = sjson.SetRawBytes(payload, "input", []byte("["+string(first)+"]"))
+		}
+	}
+	payload, _ = sjson.SetRawBytes(payload, path+".-1", assistant)
+	payload, _ = sjson.SetRawBytes(payload, path+".-1", user)
+	return payload
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// structuredOutputTestExecutor answers with answers[i] on the i-th call and repeats the last one.
+type structuredOutputTestExecutor struct {
+	answers []string
+
+	mu       sync.Mutex
+	payloads [][]byte
+}
+
+func (e *structuredOutputTestExecutor) Identifier() string { return "structured-test" }
+
+func (e *structuredOutputTestExecutor) Execute(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	answer := e.answers[min(len(e.payloads), len(e.answers)-1)]
+	e.payloads = append(e.payloads, append([]byte(nil), req.Payload...))
+	body, _ := sjson.SetBytes([]byte(`{"id":"chatcmpl-1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":""},"finish_reason":"stop"}]}`), "choices.0.message.content", answer)
+	return coreexecutor.Response{Payload: body}, nil
+}
+
+func (e *structuredOutputTestExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "ExecuteStream not implemented"}
+}
+
+func (e *structuredOutputTestExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *structuredOutputTestExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func (e *structuredOutputTestExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "HttpRequest not implemented", HTTPStatus: http.StatusNotImplemented}
+}
+
+func newStructuredOutputTestHandler(t *testing.T, authID string, repairRetries int, answers ...string) (*BaseAPIHandler, *structuredOutputTestExecutor) {
+	t.Helper()
+	executor := &structuredOutputTestExecutor{answers: answers}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	if _, err := manager.Register(context.Background(), &coreauth.Auth{ID: authID, Provider: "structured-test", Status: coreauth.StatusActive}); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(authID, "structured-test", []*registry.ModelInfo{{ID: "structured-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(authID) })
+
+	cfg := &sdkconfig.SDKConfig{StructuredOutput: sdkconfig.StructuredOutputConfig{Validate: true, RepairRetries: repairRetries}}
+	return NewBaseAPIHandlers(cfg, manager), executor
+}
+
+const structuredOutputTestRequest = `{"model":"structured-model","messages":[{"role":"user","content":"Name a color"}],` +
+	`"response_format":{"type":"json_schema","json_schema":{"name":"color","schema":{"type":"object","properties":{"name":{"type":"string"}},"required":["name"]}}}}`
+
+func TestExecuteWithStructuredOutput_RepairsNonconformingAnswer(t *testing.T) {
+	handler, executor := newStructuredOutputTestHandler(t, "structured-repair", 1, `{"colour":"red"}`, `{"name":"red"}`)
+
+	resp, _, errMsg := handler.ExecuteWithStructuredOutput(context.Background(), "openai", "structured-model", []byte(structuredOutputTestRequest), "")
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %v", errMsg.Error)
+	}
+	if got := gjson.GetBytes(resp, "choices.0.message.content").String(); got != `{"name":"red"}` {
+		t.Fatalf("content = %q", got)
+	}
+	if len(executor.payloads) != 2 {
+		t.Fatalf("executor calls = %d, want 2", len(executor.payloads))
+	}
+	repair := gjson.GetBytes(executor.payloads[1], "messages")
+	if n := len(repair.Array()); n != 3 {
+		t.Fatalf("repair request has %d messages, want 3", n)
+	}
+	if got := repair.Get("1.content").String(); got != `{"colour":"red"}` {
+		t.Fatalf("repair assistant message = %q", got)
+	}
+	if got := repair.Get("2.content").String(); !strings.Contains(got, `missing required property "name"`) {
+		t.Fatalf("repair instruction does not list the violation: %q", got)
+	}
+}
+
+func TestExecuteWithStructuredOutput_ReturnsErrorWhenStillNonconforming(t *testing.T) {
+	handler, executor := newStructuredOutputTestHandler(t, "structured-fail", 1, `not json`)
+
+	_, _, errMsg := handler.ExecuteWithStructuredOutput(context.Background(), "openai", "structured-model", []byte(structuredOutputTestRequest), "")
+	if errMsg == nil {
+		t.Fatal("expected an error")
+	}
+	if errMsg.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want 422", errMsg.StatusCode)
+	}
+	if !strings.Contains(errMsg.Error.Error(), "output is not valid JSON") {
+		t.Fatalf("error does not describe the violation: %v", errMsg.Error)
+	}
+	if len(executor.payloads) != 2 {
+		t.Fatalf("executor calls = %d, want 2", len(executor.payloads))
+	}
+}
+
+func TestExecuteWithStructuredOutput_SkipsRequestsWithoutFormat(t *testing.T) {
+	handler, executor := newStructuredOutputTestHandler(t, "structured-skip", 1, `plain text`)
+
+	request := `{"model":"structured-model","messages":[{"role":"user","content":"hi"}]}`
+	resp, _, errMsg := handler.ExecuteWithStructuredOutput(context.Background(), "openai", "structured-model", []byte(request), "")
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %v", errMsg.Error)
+	}
+	if got := gjson.GetBytes(resp, "choices.0.message.content").String(); got != "plain text" {
+		t.Fatalf("content = %q", got)
+	}
+	if len(executor.payloads) != 1 {
+		t.Fatalf("executor calls = %d, want 1", len(executor.payloads))
+	}
+}
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// runtimeStateDir holds runtime state sidecars below the auth directory. The files do not use the
+// .json extension so the auth watcher and List never pick them up.
+const (
+	runtimeStateDir = ".runtime-state"
+	runtimeStateExt = ".state"
+)
+
+// LoadRuntimeStates reads every persisted runtime state sidecar.
+func (s *FileTokenStore) LoadRuntimeStates(_ context.Context) (map[string]*cliproxyauth.RuntimeState, error) {
+	dir := s.runtimeStateDir()
+	if dir == "" {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("auth filestore: read runtime state dir: %w", err)
+	}
+	states := make(map[string]*cliproxyauth.RuntimeState, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), runtimeStateExt) {
+			continue
+		}
+		data, errRead := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if errRead != nil {
+			continue
+		}
+		var state cliproxyauth.RuntimeState
+		if errUnmarshal := json.Unmarshal(data, &state); errUnmarshal != nil || state.ID == "" {
+			// Unreadable sidecars are dropped rather than blocking startup.
+			_ = os.Remove(filepath.Join(dir, entry.Name()))
+			continue
+		}
+		states[state.ID] = &state
+	}
+	return states, nil
+}
+
+// SaveRuntimeState writes the runtime state sidecar of one auth.
+func (s *FileTokenStore) SaveRuntimeState(_ context.Context, state *cliproxyauth.RuntimeState) error {
+	if state == nil || state.ID == "" {
+		return nil
+	}
+	path := s.runtimeStatePath(state.ID)
+	if path == "" {
+		return fmt.Errorf("auth filestore: directory not configured")
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("auth filestore: marshal runtime state: %w", err)
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("auth filestore: create runtime state dir: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("auth filestore: write runtime state: %w", err)
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("auth filestore: write runtime state: %w", err)
+	}
+	return nil
+}
+
+// DeleteRuntimeState removes the runtime state sidecar of one auth.
+func (s *FileTokenStore) DeleteRuntimeState(_ context.Context, id string) error {
+	path := s.runtimeStatePath(id)
+	if path == "" {
+		return nil
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("auth filestore: delete runtime state: %w", err)
+	}
+	return nil
+}
+
+func (s *FileTokenStore) runtimeStateDir() string {
+	dir := s.baseDirSnapshot()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, runtimeStateDir)
+}
+
+func (s *FileTokenStore) runtimeStatePath(id string) string {
+	dir := s.runtimeStateDir()
+	id = strings.TrimSpace(id)
+	if dir == "" || id == "" {
+		return ""
+	}
+	// IDs may be relative paths or absolute paths; escape them into a single file name.
+	return filepath.Join(dir, url.QueryEscape(filepath.ToSlash(id))+runtimeStateExt)
+}
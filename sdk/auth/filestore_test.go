@@ -1,10 +1,14 @@
 package auth
 
 import (
+	"context"
 	"encoding/json"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 )
 
 func TestExtractAccessToken(t *testing.T) {
@@ -177,3 +181,39 @@ func TestFilestore_ReadAuthFile_Priority(t *testing.T) {
 		})
 	}
 }
+
+func TestFilestore_RuntimeStateRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	store := NewFileTokenStore()
+	store.SetBaseDir(dir)
+	ctx := context.Background()
+
+	state := &cliproxyauth.RuntimeState{ID: "team/claude.json", Unavailable: true, NextRetryAfter: time.Now().Add(time.Hour).UTC()}
+	if err := store.SaveRuntimeState(ctx, state); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	states, err := store.LoadRuntimeStates(ctx)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	got := states["team/claude.json"]
+	if got == nil || !got.Unavailable || !got.NextRetryAfter.Equal(state.NextRetryAfter) {
+		t.Fatalf("loaded state = %+v", got)
+	}
+
+	// Sidecars must stay invisible to auth listing (and the .json watcher).
+	auths, err := store.List(ctx)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(auths) != 0 {
+		t.Fatalf("runtime state listed as auth: %+v", auths)
+	}
+
+	if err = store.DeleteRuntimeState(ctx, "team/claude.json"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if states, _ = store.LoadRuntimeStates(ctx); len(states) != 0 {
+		t.Fatalf("expected no states after delete, got %+v", states)
+	}
+}
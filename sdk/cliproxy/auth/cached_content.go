@@ -0,0 +1,11 @@
+package auth
+
+// CachedContentEndpoint is implemented by executors whose upstream hosts the Gemini
+// cachedContents API, allowing the proxy to manage caches on a specific credential.
+type CachedContentEndpoint interface {
+	// CachedContentsURL returns the cachedContents collection URL for auth. Resource URLs are
+	// formed by appending the trailing ID segment of a cache name.
+	CachedContentsURL(auth *Auth) (string, error)
+	// CachedContentModel returns the model resource name the upstream expects in a cache.
+	CachedContentModel(auth *Auth, model string) (string, error)
+}
@@ -15,6 +15,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/alerting"
 	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
@@ -160,9 +161,25 @@ type Manager struct {
 	// Optional HTTP RoundTripper provider injected by host.
 	rtProvider RoundTripperProvider
 
+	// hedges tracks first-chunk latency history and budgets for hedged requests.
+	hedges *hedgeTracker
+
+	// cooldowns tracks backoff levels and circuit states for configured cooldown policies.
+	cooldowns *cooldownTracker
+
+	// pendingRuntimeStates holds persisted runtime state for auths not registered yet.
+	pendingRuntimeStates map[string]*RuntimeState
+	// savedRuntimeStates caches the last persisted runtime state per auth to skip redundant writes.
+	savedRuntimeStates map[string][]byte
+	// runtimeStates persists runtime-state snapshots off the request path.
+	runtimeStates runtimeStateWriter
+
 	// Auto refresh state
 	refreshCancel    context.CancelFunc
 	refreshSemaphore chan struct{}
+
+	// probeCancel stops the background health prober.
+	probeCancel context.CancelFunc
 }
 
 // NewManager constructs a manager with optional custom selector and hook.
@@ -182,6 +199,8 @@ func NewManager(store Store, selector Selector, hook Hook) *Manager {
 		providerOffsets:  make(map[string]int),
 		modelPoolOffsets: make(map[string]int),
 		refreshSemaphore: make(chan struct{}, refreshMaxConcurrency),
+		hedges:           newHedgeTracker(),
+		cooldowns:        newCooldownTracker(),
 	}
 	// atomic.Value requires non-nil initial value.
 	manager.runtimeConfig.Store(&internalconfig.Config{})
@@ -628,8 +647,13 @@ func (m *Manager) executeStreamWithModelPool(ctx context.Context, executor Provi
 	var lastErr error
 	for idx, execModel := range execModels {
 		resultModel := executionResultModel(routeModel, execModel, pooled)
+		if !m.cooldowns.admit(auth.ID, resultModel, time.Now()) {
+			lastErr = circuitProbeInFlightError()
+			continue
+		}
 		execReq := req
 		execReq.Model = execModel
+		execReq.Payload = m.fitContextWindow(ctx, provider, execModel, req.Payload, opts)
 		streamResult, errStream := executor.ExecuteStream(ctx, auth, execReq, opts)
 		if errStream != nil {
 			if errCtx := ctx.Err(); errCtx != nil {
@@ -901,6 +925,7 @@ func (m *Manager) Register(ctx context.Context, auth *Auth) (*Auth, error) {
 	auth.EnsureIndex()
 	authClone := auth.Clone()
 	m.mu.Lock()
+	m.restorePendingRuntimeStateLocked(authClone)
 	m.auths[auth.ID] = authClone
 	m.mu.Unlock()
 	m.rebuildAPIKeyModelAliasFromRuntimeConfig()
@@ -931,6 +956,7 @@ func (m *Manager) Update(ctx context.Context, auth *Auth) (*Auth, error) {
 	}
 	auth.EnsureIndex()
 	authClone := auth.Clone()
+	m.restorePendingRuntimeStateLocked(authClone)
 	m.auths[auth.ID] = authClone
 	m.mu.Unlock()
 	m.rebuildAPIKeyModelAliasFromRuntimeConfig()
@@ -968,6 +994,7 @@ func (m *Manager) Load(ctx context.Context) error {
 	}
 	m.rebuildAPIKeyModelAliasLocked(cfg)
 	m.mu.Unlock()
+	m.loadRuntimeStates(ctx)
 	m.syncScheduler()
 	return nil
 }
@@ -981,6 +1008,7 @@ func (m *Manager) Execute(ctx context.Context, providers []string, req cliproxye
 	}
 
 	_, maxRetryCredentials, maxWait := m.retrySettings()
+	ctx = withContextFitMemo(ctx)
 
 	var lastErr error
 	for attempt := 0; ; attempt++ {
@@ -1043,6 +1071,7 @@ func (m *Manager) ExecuteStream(ctx context.Context, providers []string, req cli
 	}
 
 	_, maxRetryCredentials, maxWait := m.retrySettings()
+	ctx = withContextFitMemo(ctx)
 
 	var lastErr error
 	for attempt := 0; ; attempt++ {
@@ -1071,7 +1100,8 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 	}
 	routeModel := req.Model
 	opts = ensureRequestedModelMetadata(opts, routeModel)
-	tried := make(map[string]struct{})
+	hedging, hedgeEnabled := m.hedgingPolicy(routeModel)
+	tried := excludedAuthSet(opts.Metadata)
 	attempted := make(map[string]struct{})
 	var lastErr error
 	for {
@@ -1094,53 +1124,85 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 		publishSelectedAuthMetadata(opts.Metadata, auth.ID)
 
 		tried[auth.ID] = struct{}{}
-		execCtx := ctx
-		if rt := m.roundTripperFor(auth); rt != nil {
-			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
-			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
-		}
-
 		models, pooled := m.preparedExecutionModels(auth, routeModel)
 		if len(models) == 0 {
 			continue
 		}
 		attempted[auth.ID] = struct{}{}
-		var authErr error
-		for _, upstreamModel := range models {
-			resultModel := executionResultModel(routeModel, upstreamModel, pooled)
-			execReq := req
-			execReq.Model = upstreamModel
-			resp, errExec := executor.Execute(execCtx, auth, execReq, opts)
-			result := Result{AuthID: auth.ID, Provider: provider, Model: resultModel, Success: errExec == nil}
-			if errExec != nil {
-				if errCtx := execCtx.Err(); errCtx != nil {
-					return cliproxyexecutor.Response{}, errCtx
-				}
-				result.Error = &Error{Message: errExec.Error()}
-				if se, ok := errors.AsType[cliproxyexecutor.StatusError](errExec); ok && se != nil {
-					result.Error.HTTPStatus = se.StatusCode()
-				}
-				if ra := retryAfterFromError(errExec); ra != nil {
-					result.RetryAfter = ra
-				}
-				m.MarkResult(execCtx, result)
-				if isRequestInvalidError(errExec) {
-					return cliproxyexecutor.Response{}, errExec
-				}
-				authErr = errExec
-				continue
+		var (
+			resp    cliproxyexecutor.Response
+			errExec error
+		)
+		if hedgeEnabled {
+			hedgeEnabled = false
+			resp, errExec = m.executeHedged(ctx, providers, req, opts, routeModel, hedging, tried, attempted, maxRetryCredentials, auth, executor, provider, models, pooled)
+		} else {
+			resp, errExec = m.executeWithModelPool(m.executionContext(ctx, auth), executor, auth, provider, req, opts, routeModel, models, pooled)
+		}
+		if errExec != nil {
+			if errCtx := ctx.Err(); errCtx != nil {
+				return cliproxyexecutor.Response{}, errCtx
 			}
-			m.MarkResult(execCtx, result)
-			return resp, nil
+			if isRequestInvalidError(errExec) {
+				return cliproxyexecutor.Response{}, errExec
+			}
+			lastErr = errExec
+			continue
 		}
-		if authErr != nil {
-			if isRequestInvalidError(authErr) {
-				return cliproxyexecutor.Response{}, authErr
+		return resp, nil
+	}
+}
+
+// executionContext attaches the auth-specific HTTP round tripper to ctx when one is available.
+func (m *Manager) executionContext(ctx context.Context, auth *Auth) context.Context {
+	if rt := m.roundTripperFor(auth); rt != nil {
+		ctx = context.WithValue(ctx, roundTripperContextKey{}, rt)
+		ctx = context.WithValue(ctx, "cliproxy.roundtripper", rt)
+	}
+	return ctx
+}
+
+func (m *Manager) executeWithModelPool(ctx context.Context, executor ProviderExecutor, auth *Auth, provider string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, routeModel string, execModels []string, pooled bool) (cliproxyexecutor.Response, error) {
+	if executor == nil {
+		return cliproxyexecutor.Response{}, &Error{Code: "executor_not_found", Message: "executor not registered"}
+	}
+	var lastErr error
+	for _, upstreamModel := range execModels {
+		resultModel := executionResultModel(routeModel, upstreamModel, pooled)
+		if !m.cooldowns.admit(auth.ID, resultModel, time.Now()) {
+			lastErr = circuitProbeInFlightError()
+			continue
+		}
+		execReq := req
+		execReq.Model = upstreamModel
+		execReq.Payload = m.fitContextWindow(ctx, provider, upstreamModel, req.Payload, opts)
+		resp, errExec := executor.Execute(ctx, auth, execReq, opts)
+		result := Result{AuthID: auth.ID, Provider: provider, Model: resultModel, Success: errExec == nil}
+		if errExec != nil {
+			if errCtx := ctx.Err(); errCtx != nil {
+				return cliproxyexecutor.Response{}, errCtx
 			}
-			lastErr = authErr
+			result.Error = &Error{Message: errExec.Error()}
+			if se, ok := errors.AsType[cliproxyexecutor.StatusError](errExec); ok && se != nil {
+				result.Error.HTTPStatus = se.StatusCode()
+			}
+			if ra := retryAfterFromError(errExec); ra != nil {
+				result.RetryAfter = ra
+			}
+			m.MarkResult(ctx, result)
+			if isRequestInvalidError(errExec) {
+				return cliproxyexecutor.Response{}, errExec
+			}
+			lastErr = errExec
 			continue
 		}
+		m.MarkResult(ctx, result)
+		return resp, nil
+	}
+	if lastErr == nil {
+		lastErr = &Error{Code: "auth_not_found", Message: "no upstream model available"}
 	}
+	return cliproxyexecutor.Response{}, lastErr
 }
 
 func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, maxRetryCredentials int) (cliproxyexecutor.Response, error) {
@@ -1149,7 +1211,7 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 	}
 	routeModel := req.Model
 	opts = ensureRequestedModelMetadata(opts, routeModel)
-	tried := make(map[string]struct{})
+	tried := excludedAuthSet(opts.Metadata)
 	attempted := make(map[string]struct{})
 	var lastErr error
 	for {
@@ -1186,6 +1248,10 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 		var authErr error
 		for _, upstreamModel := range models {
 			resultModel := executionResultModel(routeModel, upstreamModel, pooled)
+			if !m.cooldowns.admit(auth.ID, resultModel, time.Now()) {
+				authErr = circuitProbeInFlightError()
+				continue
+			}
 			execReq := req
 			execReq.Model = upstreamModel
 			resp, errExec := executor.CountTokens(execCtx, auth, execReq, opts)
@@ -1227,7 +1293,8 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 	}
 	routeModel := req.Model
 	opts = ensureRequestedModelMetadata(opts, routeModel)
-	tried := make(map[string]struct{})
+	hedging, hedgeEnabled := m.hedgingPolicy(routeModel)
+	tried := excludedAuthSet(opts.Metadata)
 	attempted := make(map[string]struct{})
 	var lastErr error
 	for {
@@ -1258,19 +1325,23 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 		publishSelectedAuthMetadata(opts.Metadata, auth.ID)
 
 		tried[auth.ID] = struct{}{}
-		execCtx := ctx
-		if rt := m.roundTripperFor(auth); rt != nil {
-			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
-			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
-		}
 		models, pooled := m.preparedExecutionModels(auth, routeModel)
 		if len(models) == 0 {
 			continue
 		}
 		attempted[auth.ID] = struct{}{}
-		streamResult, errStream := m.executeStreamWithModelPool(execCtx, executor, auth, provider, req, opts, routeModel, models, pooled)
+		var (
+			streamResult *cliproxyexecutor.StreamResult
+			errStream    error
+		)
+		if hedgeEnabled {
+			hedgeEnabled = false
+			streamResult, errStream = m.executeStreamHedged(ctx, providers, req, opts, routeModel, hedging, tried, attempted, maxRetryCredentials, auth, executor, provider, models, pooled)
+		} else {
+			streamResult, errStream = m.executeStreamWithModelPool(m.executionContext(ctx, auth), executor, auth, provider, req, opts, routeModel, models, pooled)
+		}
 		if errStream != nil {
-			if errCtx := execCtx.Err(); errCtx != nil {
+			if errCtx := ctx.Err(); errCtx != nil {
 				return nil, errCtx
 			}
 			if isRequestInvalidError(errStream) {
@@ -1340,6 +1411,18 @@ func pinnedAuthIDFromMetadata(meta map[string]any) string {
 	}
 }
 
+// excludedAuthSet seeds the tried set with the auth IDs listed under ExcludedAuthsMetadataKey.
+func excludedAuthSet(meta map[string]any) map[string]struct{} {
+	tried := make(map[string]struct{})
+	excluded, _ := meta[cliproxyexecutor.ExcludedAuthsMetadataKey].([]string)
+	for _, authID := range excluded {
+		if authID = strings.TrimSpace(authID); authID != "" {
+			tried[authID] = struct{}{}
+		}
+	}
+	return tried
+}
+
 func publishSelectedAuthMetadata(meta map[string]any, authID string) {
 	if len(meta) == 0 {
 		return
@@ -1717,6 +1800,7 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 		now := time.Now()
 
 		if result.Success {
+			m.cooldowns.reset(auth.ID, result.Model)
 			if result.Model != "" {
 				state := ensureModelState(auth, result.Model)
 				resetModelState(state, now)
@@ -1733,7 +1817,11 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 				clearAuthStateOnSuccess(auth, now)
 			}
 		} else {
-			if result.Model != "" {
+			outcome, hasPolicy := m.evaluateCooldownPolicy(auth, result.Model, result.Error, result.RetryAfter, now)
+			switch {
+			case hasPolicy && outcome.keepsSelectable():
+				// An ignore rule or a circuit below its threshold leaves the credential as it was.
+			case result.Model != "":
 				state := ensureModelState(auth, result.Model)
 				state.Unavailable = true
 				state.Status = StatusError
@@ -1746,7 +1834,9 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 				}
 
 				statusCode := statusCodeFromResult(result.Error)
-				if isModelSupportResultError(result.Error) {
+				if hasPolicy {
+					suspendReason, shouldSuspendModel, setModelQuota = applyPolicyToModel(auth, state, outcome, statusCode, now)
+				} else if isModelSupportResultError(result.Error) {
 					next := now.Add(12 * time.Hour)
 					state.NextRetryAfter = next
 					suspendReason = "model_not_supported"
@@ -1802,15 +1892,20 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 					}
 				}
 
-				auth.Status = StatusError
+				if !auth.Disabled {
+					auth.Status = StatusError
+				}
 				auth.UpdatedAt = now
 				updateAggregatedAvailability(auth, now)
-			} else {
+			case hasPolicy:
+				applyPolicyToAuth(auth, result.Error, outcome, now)
+			default:
 				applyAuthFailureState(auth, result.Error, result.RetryAfter, now)
 			}
 		}
 
 		_ = m.persist(ctx, auth)
+		m.queueRuntimeStateLocked(ctx, auth)
 		authSnapshot = auth.Clone()
 	}
 	m.mu.Unlock()
@@ -2818,6 +2913,15 @@ func (m *Manager) refreshAuth(ctx context.Context, id string) {
 	log.Debugf("refreshed %s, %s, %v", auth.Provider, auth.ID, err)
 	now := time.Now()
 	if err != nil {
+		alerting.Emit(alerting.Event{
+			Type:     internalconfig.AlertEventRefreshFailed,
+			Severity: alerting.SeverityWarning,
+			Provider: auth.Provider,
+			AuthID:   auth.ID,
+			Subject:  auth.Provider + " credential " + auth.ID + " failed to refresh",
+			Message:  err.Error(),
+			Time:     now,
+		})
 		m.mu.Lock()
 		if current := m.auths[id]; current != nil {
 			current.NextRefreshAfter = now.Add(refreshFailureBackoff)
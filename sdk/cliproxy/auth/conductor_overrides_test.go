@@ -447,3 +447,40 @@ func TestManager_MarkResult_RespectsAuthDisableCoolingOverride(t *testing.T) {
 		t.Fatalf("expected NextRetryAfter to be zero when disable_cooling=true, got %v", state.NextRetryAfter)
 	}
 }
+
+func TestManagerExecuteStream_SkipsExcludedAuths(t *testing.T) {
+	m := NewManager(nil, &FillFirstSelector{}, nil)
+	executor := &authFallbackExecutor{id: "claude"}
+	m.RegisterExecutor(executor)
+
+	model := "excluded-auth-model"
+	firstAuth := &Auth{ID: "aa-excluded-auth", Provider: "claude"}
+	secondAuth := &Auth{ID: "bb-allowed-auth", Provider: "claude"}
+
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient(firstAuth.ID, "claude", []*registry.ModelInfo{{ID: model}})
+	reg.RegisterClient(secondAuth.ID, "claude", []*registry.ModelInfo{{ID: model}})
+	t.Cleanup(func() {
+		reg.UnregisterClient(firstAuth.ID)
+		reg.UnregisterClient(secondAuth.ID)
+	})
+	for _, auth := range []*Auth{firstAuth, secondAuth} {
+		if _, errRegister := m.Register(context.Background(), auth); errRegister != nil {
+			t.Fatalf("register %s: %v", auth.ID, errRegister)
+		}
+	}
+
+	opts := cliproxyexecutor.Options{Metadata: map[string]any{
+		cliproxyexecutor.ExcludedAuthsMetadataKey: []string{firstAuth.ID},
+	}}
+	streamResult, errExecute := m.ExecuteStream(context.Background(), []string{"claude"}, cliproxyexecutor.Request{Model: model}, opts)
+	if errExecute != nil {
+		t.Fatalf("execute stream: %v", errExecute)
+	}
+	for range streamResult.Chunks {
+	}
+
+	if got := executor.StreamCalls(); len(got) != 1 || got[0] != secondAuth.ID {
+		t.Fatalf("stream calls = %v, want [%s]", got, secondAuth.ID)
+	}
+}
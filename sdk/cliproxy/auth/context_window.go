@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/contextwindow"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// contextSummaryKey marks summarization requests so they are not managed themselves.
+type contextSummaryKey struct{}
+
+const contextSummaryPrompt = "Summarize the following excerpt of a conversation between a user and an AI assistant. " +
+	"The summary replaces the excerpt in the assistant's context, so keep every fact needed to continue the work: " +
+	"goals, decisions, file names, commands, tool results and open questions. Answer with the summary only."
+
+// contextFitMemoKey carries the fitted payloads of one manager request.
+type contextFitMemoKey struct{}
+
+// contextFitMemo remembers the payload fitted for each (provider, upstream model) of a request,
+// so credential retries and hedged attempts reuse it instead of tokenizing and summarizing again.
+type contextFitMemo struct {
+	mu      sync.Mutex
+	entries map[string]*contextFitEntry
+}
+
+type contextFitEntry struct {
+	once   sync.Once
+	source []byte
+	fitted []byte
+}
+
+// withContextFitMemo attaches a memo to ctx unless it already carries one.
+func withContextFitMemo(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(contextFitMemoKey{}).(*contextFitMemo); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, contextFitMemoKey{}, &contextFitMemo{entries: make(map[string]*contextFitEntry)})
+}
+
+func (c *contextFitMemo) entry(provider, model string, payload []byte) *contextFitEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := provider + "\x00" + model
+	e, ok := c.entries[key]
+	if !ok {
+		e = &contextFitEntry{source: payload}
+		c.entries[key] = e
+	}
+	return e
+}
+
+// fitContextWindow shrinks the payload when it would exceed the context window of the upstream
+// model, using the context-management policies. What was done is reported in the
+// contextwindow.Header response header. The result is computed once per (provider, upstream
+// model) of a request and reused by every attempt.
+func (m *Manager) fitContextWindow(ctx context.Context, provider, model string, payload []byte, opts cliproxyexecutor.Options) []byte {
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil || !cfg.ContextManagement.Enable || ctx.Value(contextSummaryKey{}) != nil {
+		return payload
+	}
+	policy := cfg.ContextManagement
+	baseModel := thinking.ParseSuffix(model).ModelName
+	if !policy.AppliesTo(baseModel) {
+		return payload
+	}
+	memo, ok := ctx.Value(contextFitMemoKey{}).(*contextFitMemo)
+	if !ok {
+		return m.fitPayload(ctx, provider, baseModel, policy, payload, opts)
+	}
+	e := memo.entry(provider, baseModel, payload)
+	if !bytes.Equal(e.source, payload) {
+		// The caller rewrote the payload, e.g. to resume a stream; fit it afresh.
+		return m.fitPayload(ctx, provider, baseModel, policy, payload, opts)
+	}
+	e.once.Do(func() {
+		e.fitted = m.fitPayload(ctx, provider, baseModel, policy, payload, opts)
+	})
+	return e.fitted
+}
+
+func (m *Manager) fitPayload(ctx context.Context, provider, baseModel string, policy internalconfig.ContextManagementConfig, payload []byte, opts cliproxyexecutor.Options) []byte {
+	limit := contextTokenLimit(registry.LookupModelInfo(baseModel, provider), payload)
+	if limit <= 0 {
+		return payload
+	}
+	out, report := contextwindow.Fit(ctx, payload, contextwindow.Options{
+		Format:          opts.SourceFormat.String(),
+		Model:           baseModel,
+		Limit:           limit * policy.ThresholdPercent / 100,
+		Policies:        policy.Policies,
+		KeepRecentTurns: policy.KeepRecentTurns,
+		Summarize:       m.contextSummarizer(policy.SummaryModel),
+	})
+	if !report.Changed() {
+		return payload
+	}
+	log.Infof("context window: %s fitted for %s (%s)", opts.SourceFormat, baseModel, report)
+	if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil {
+		ginCtx.Header(contextwindow.Header, report.String())
+	}
+	return out
+}
+
+// contextTokenLimit returns the input budget of a model: its input token limit, or its context
+// length minus the output the request asks for.
+func contextTokenLimit(info *registry.ModelInfo, payload []byte) int {
+	if info == nil {
+		return 0
+	}
+	if info.InputTokenLimit > 0 {
+		return info.InputTokenLimit
+	}
+	if info.ContextLength <= 0 {
+		return 0
+	}
+	output := 0
+	for _, path := range []string{"max_tokens", "max_completion_tokens", "max_output_tokens"} {
+		if value := gjson.GetBytes(payload, path); value.Exists() {
+			output = int(value.Int())
+			break
+		}
+	}
+	if output <= 0 {
+		output = max(info.MaxCompletionTokens, info.OutputTokenLimit)
+	}
+	if output <= 0 || output >= info.ContextLength {
+		return info.ContextLength
+	}
+	return info.ContextLength - output
+}
+
+// contextSummarizer returns a summarizer that asks the summary model through the manager, so it
+// gets the usual credential selection and fallbacks.
+func (m *Manager) contextSummarizer(model string) contextwindow.Summarizer {
+	if model == "" {
+		return nil
+	}
+	return func(ctx context.Context, transcript string) (string, error) {
+		providers := util.GetProviderName(thinking.ParseSuffix(model).ModelName)
+		if len(providers) == 0 {
+			return "", fmt.Errorf("no provider for summary model %s", model)
+		}
+		payload := []byte(`{"messages":[{"role":"system"},{"role":"user"}]}`)
+		payload, _ = sjson.SetBytes(payload, "model", model)
+		payload, _ = sjson.SetBytes(payload, "messages.0.content", contextSummaryPrompt)
+		payload, _ = sjson.SetBytes(payload, "messages.1.content", transcript)
+		summaryOpts := cliproxyexecutor.Options{
+			OriginalRequest: payload,
+			SourceFormat:    sdktranslator.FormatOpenAI,
+			Metadata:        map[string]any{cliproxyexecutor.RequestedModelMetadataKey: model},
+		}
+		ctx = context.WithValue(ctx, contextSummaryKey{}, true)
+		resp, err := m.Execute(ctx, providers, cliproxyexecutor.Request{Model: model, Payload: payload}, summaryOpts)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(gjson.GetBytes(resp.Payload, "choices.0.message.content").String()), nil
+	}
+}
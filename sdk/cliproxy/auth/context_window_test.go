@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/contextwindow"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+func TestContextTokenLimit(t *testing.T) {
+	cases := []struct {
+		name    string
+		info    *registry.ModelInfo
+		payload string
+		want    int
+	}{
+		{"unknown model", nil, `{}`, 0},
+		{"input limit wins", &registry.ModelInfo{InputTokenLimit: 1000, ContextLength: 4000}, `{"max_tokens":100}`, 1000},
+		{"requested output", &registry.ModelInfo{ContextLength: 4000, MaxCompletionTokens: 2000}, `{"max_tokens":500}`, 3500},
+		{"model output", &registry.ModelInfo{ContextLength: 4000, MaxCompletionTokens: 1000}, `{}`, 3000},
+		{"no output known", &registry.ModelInfo{ContextLength: 4000}, `{}`, 4000},
+	}
+	for _, tc := range cases {
+		if got := contextTokenLimit(tc.info, []byte(tc.payload)); got != tc.want {
+			t.Errorf("%s: limit = %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
+
+// contextFitExecutor fails the main model on failingAuth, answers it elsewhere and answers
+// summary requests with a fixed summary.
+type contextFitExecutor struct {
+	failingAuth string
+
+	mu        sync.Mutex
+	summaries int
+	payloads  [][]byte
+}
+
+func (e *contextFitExecutor) Identifier() string { return "claude" }
+
+func (e *contextFitExecutor) Execute(_ context.Context, auth *Auth, req cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if req.Model == "ctx-summary" {
+		e.summaries++
+		return cliproxyexecutor.Response{Payload: []byte(`{"choices":[{"message":{"content":"earlier steps ran the shell"}}]}`)}, nil
+	}
+	e.payloads = append(e.payloads, req.Payload)
+	if auth.ID == e.failingAuth {
+		return cliproxyexecutor.Response{}, &Error{HTTPStatus: http.StatusInternalServerError, Message: "upstream failed"}
+	}
+	return cliproxyexecutor.Response{Payload: []byte(auth.ID)}, nil
+}
+
+func (e *contextFitExecutor) ExecuteStream(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error) {
+	return nil, &Error{HTTPStatus: http.StatusInternalServerError, Message: "not implemented"}
+}
+
+func (e *contextFitExecutor) Refresh(_ context.Context, auth *Auth) (*Auth, error) { return auth, nil }
+
+func (e *contextFitExecutor) CountTokens(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, &Error{HTTPStatus: http.StatusInternalServerError, Message: "not implemented"}
+}
+
+func (e *contextFitExecutor) HttpRequest(context.Context, *Auth, *http.Request) (*http.Response, error) {
+	return nil, nil
+}
+
+func TestManagerExecute_FitsContextOncePerRequest(t *testing.T) {
+	m := NewManager(nil, &FillFirstSelector{}, nil)
+	cfg := &internalconfig.Config{ContextManagement: internalconfig.ContextManagementConfig{
+		Enable:          true,
+		Policies:        []string{internalconfig.ContextPolicySummarize},
+		KeepRecentTurns: 1,
+		SummaryModel:    "ctx-summary",
+	}}
+	cfg.SanitizeContextManagement()
+	m.SetConfig(cfg)
+
+	baseID := uuid.NewString()
+	failingID, okID := baseID+"-a", baseID+"-b"
+	executor := &contextFitExecutor{failingAuth: failingID}
+	m.RegisterExecutor(executor)
+	reg := registry.GetGlobalRegistry()
+	for _, id := range []string{failingID, okID} {
+		reg.RegisterClient(id, "claude", []*registry.ModelInfo{{ID: "ctx-model", InputTokenLimit: 400}, {ID: "ctx-summary"}})
+		if _, errRegister := m.Register(context.Background(), &Auth{ID: id, Provider: "claude"}); errRegister != nil {
+			t.Fatalf("register %s: %v", id, errRegister)
+		}
+	}
+	t.Cleanup(func() {
+		reg.UnregisterClient(failingID)
+		reg.UnregisterClient(okID)
+	})
+
+	messages := make([]string, 0, 12)
+	for i := 0; i < 6; i++ {
+		messages = append(messages,
+			fmt.Sprintf(`{"role":"user","content":"step %d %s"}`, i, strings.Repeat("lorem ipsum dolor sit amet ", 20)),
+			fmt.Sprintf(`{"role":"assistant","content":"done %d"}`, i))
+	}
+	payload := []byte(`{"model":"ctx-model","messages":[` + strings.Join(messages, ",") + `]}`)
+
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	ginCtx, _ := gin.CreateTestContext(recorder)
+	ctx := context.WithValue(context.Background(), "gin", ginCtx)
+	opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FormatOpenAI, OriginalRequest: payload}
+	resp, errExecute := m.Execute(ctx, []string{"claude"}, cliproxyexecutor.Request{Model: "ctx-model", Payload: payload}, opts)
+	if errExecute != nil {
+		t.Fatalf("execute error = %v", errExecute)
+	}
+	if string(resp.Payload) != okID {
+		t.Fatalf("payload = %q, want %q", resp.Payload, okID)
+	}
+
+	if executor.summaries != 1 {
+		t.Fatalf("summaries = %d, want 1 across both credential attempts", executor.summaries)
+	}
+	if len(executor.payloads) != 2 || !bytes.Equal(executor.payloads[0], executor.payloads[1]) {
+		t.Fatalf("expected both attempts to send the same fitted payload, got %d payloads", len(executor.payloads))
+	}
+	if len(executor.payloads[0]) >= len(payload) {
+		t.Fatalf("payload was not fitted: %d >= %d bytes", len(executor.payloads[0]), len(payload))
+	}
+	if got := recorder.Header().Get(contextwindow.Header); !strings.HasPrefix(got, "summarize") {
+		t.Fatalf("%s = %q", contextwindow.Header, got)
+	}
+}
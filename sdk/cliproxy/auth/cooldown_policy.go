@@ -0,0 +1,247 @@
+package auth
+
+import (
+	"sync"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+const (
+	defaultPolicyCooldown   = time.Minute
+	modelUnsupportedTimeout = 12 * time.Hour
+)
+
+// policyState tracks consecutive failures of one auth (and model) for cooldown policies.
+type policyState struct {
+	failures  int
+	level     int
+	open      bool
+	openUntil time.Time
+	// openWindow is how long the circuit stays open; probeStarted is when the half-open probe
+	// was admitted, zero while none is in flight.
+	openWindow   time.Duration
+	probeStarted time.Time
+}
+
+// cooldownTracker holds the in-memory backoff levels and circuit states used by cooldown policies.
+type cooldownTracker struct {
+	mu     sync.Mutex
+	states map[string]*policyState
+}
+
+func newCooldownTracker() *cooldownTracker {
+	return &cooldownTracker{states: make(map[string]*policyState)}
+}
+
+func cooldownKey(authID, model string) string {
+	return authID + "\x00" + model
+}
+
+// policyOutcome is the effect of a matched cooldown rule.
+type policyOutcome struct {
+	action string
+	// next is when the auth (or model) becomes selectable again; zero keeps it selectable.
+	next time.Time
+	// reason labels the suspension in status messages and the model registry.
+	reason string
+}
+
+// keepsSelectable reports whether the outcome leaves the credential untouched: an ignore rule,
+// or a circuit breaker still below its failure threshold.
+func (o policyOutcome) keepsSelectable() bool {
+	switch o.action {
+	case internalconfig.CooldownActionIgnore:
+		return true
+	case internalconfig.CooldownActionCircuitBreaker:
+		return o.next.IsZero()
+	default:
+		return false
+	}
+}
+
+// evaluateCooldownPolicy finds the first configured rule matching the failure and computes its outcome.
+// It returns false when no policy applies so the caller falls back to the built-in handling.
+func (m *Manager) evaluateCooldownPolicy(auth *Auth, model string, resultErr *Error, retryAfter *time.Duration, now time.Time) (policyOutcome, bool) {
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil || len(cfg.CooldownPolicies) == 0 || auth == nil || m.cooldowns == nil {
+		return policyOutcome{}, false
+	}
+	statusCode := statusCodeFromResult(resultErr)
+	message := ""
+	if resultErr != nil {
+		message = resultErr.Message
+	}
+	for _, policy := range cfg.CooldownPolicies {
+		if !policy.MatchesProvider(auth.Provider, model) {
+			continue
+		}
+		for _, rule := range policy.Rules {
+			if rule.Matches(statusCode, message) {
+				return m.cooldowns.apply(cooldownKey(auth.ID, model), rule, retryAfter, now), true
+			}
+		}
+	}
+	return policyOutcome{}, false
+}
+
+func (t *cooldownTracker) apply(key string, rule internalconfig.CooldownRule, retryAfter *time.Duration, now time.Time) policyOutcome {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	state := t.states[key]
+	if state == nil {
+		state = &policyState{}
+		t.states[key] = state
+	}
+
+	outcome := policyOutcome{action: rule.Action}
+	switch rule.Action {
+	case internalconfig.CooldownActionCooldown:
+		outcome.reason = "cooldown"
+		if retryAfter != nil && rule.RetryAfterHonored() {
+			outcome.next = now.Add(*retryAfter)
+			break
+		}
+		base := time.Duration(rule.CooldownSeconds) * time.Second
+		if base <= 0 {
+			base = defaultPolicyCooldown
+		}
+		cooldown := base
+		if maxCooldown := time.Duration(rule.BackoffMaxSeconds) * time.Second; maxCooldown > base {
+			cooldown = base * time.Duration(1<<min(state.level, 30))
+			if cooldown >= maxCooldown || cooldown <= 0 {
+				cooldown = maxCooldown
+			} else {
+				state.level++
+			}
+		}
+		outcome.next = now.Add(cooldown)
+	case internalconfig.CooldownActionDisable:
+		outcome.reason = "disabled"
+	case internalconfig.CooldownActionModelUnsupported:
+		outcome.reason = "model_not_supported"
+		outcome.next = now.Add(modelUnsupportedTimeout)
+	case internalconfig.CooldownActionCircuitBreaker:
+		state.failures++
+		// A failure after the open window elapsed is a failed half-open probe and re-opens at once.
+		halfOpen := state.open && !now.Before(state.openUntil)
+		if halfOpen || state.failures >= rule.CircuitThreshold() {
+			state.open = true
+			state.failures = 0
+			state.openWindow = time.Duration(rule.CircuitOpenSeconds()) * time.Second
+			state.openUntil = now.Add(state.openWindow)
+			state.probeStarted = time.Time{}
+			outcome.reason = "circuit_open"
+			outcome.next = state.openUntil
+		}
+	}
+	return outcome
+}
+
+// admit reports whether auth may serve model at now. Once an open circuit's window has elapsed
+// it admits a single half-open probe and turns further requests away until the probe reports
+// back, or until another open window has passed should it never do so.
+func (t *cooldownTracker) admit(authID, model string, now time.Time) bool {
+	if t == nil {
+		return true
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	keys := []string{cooldownKey(authID, "")}
+	if model != "" {
+		keys = append(keys, cooldownKey(authID, model))
+	}
+	var probing []*policyState
+	for _, key := range keys {
+		state := t.states[key]
+		if state == nil || !state.open || now.Before(state.openUntil) {
+			continue
+		}
+		if !state.probeStarted.IsZero() && now.Sub(state.probeStarted) < state.openWindow {
+			return false
+		}
+		probing = append(probing, state)
+	}
+	for _, state := range probing {
+		state.probeStarted = now
+	}
+	return true
+}
+
+// reset clears policy state after a success, closing any half-open circuit.
+func (t *cooldownTracker) reset(authID, model string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.states, cooldownKey(authID, model))
+	if model != "" {
+		delete(t.states, cooldownKey(authID, ""))
+	}
+}
+
+// applyPolicyToModel applies a policy outcome to a model state and reports how the model registry
+// should be updated.
+func applyPolicyToModel(auth *Auth, state *ModelState, outcome policyOutcome, statusCode int, now time.Time) (suspendReason string, suspend bool, setQuota bool) {
+	if outcome.action == internalconfig.CooldownActionDisable {
+		disableAuthByPolicy(auth, now)
+		return "", false, false
+	}
+	state.NextRetryAfter = outcome.next
+	if outcome.next.IsZero() {
+		return "", false, false
+	}
+	if statusCode == 429 && outcome.action == internalconfig.CooldownActionCooldown {
+		state.Quota = QuotaState{
+			Exceeded:      true,
+			Reason:        "quota",
+			NextRecoverAt: outcome.next,
+			BackoffLevel:  state.Quota.BackoffLevel,
+		}
+		setQuota = true
+	}
+	return outcome.reason, true, setQuota
+}
+
+// applyPolicyToAuth applies a policy outcome to an auth-level failure. Outcomes that keep the
+// credential selectable leave it untouched.
+func applyPolicyToAuth(auth *Auth, resultErr *Error, outcome policyOutcome, now time.Time) {
+	if outcome.keepsSelectable() {
+		return
+	}
+	auth.Unavailable = true
+	auth.Status = StatusError
+	auth.UpdatedAt = now
+	if resultErr != nil {
+		auth.LastError = cloneError(resultErr)
+		if resultErr.Message != "" {
+			auth.StatusMessage = resultErr.Message
+		}
+	}
+	if outcome.action == internalconfig.CooldownActionDisable {
+		disableAuthByPolicy(auth, now)
+		return
+	}
+	auth.NextRetryAfter = outcome.next
+	if outcome.reason != "" {
+		auth.StatusMessage = outcome.reason
+	}
+	if statusCodeFromResult(resultErr) == 429 && outcome.action == internalconfig.CooldownActionCooldown && !outcome.next.IsZero() {
+		auth.Quota.Exceeded = true
+		auth.Quota.Reason = "quota"
+		auth.Quota.NextRecoverAt = outcome.next
+	}
+}
+
+// circuitProbeInFlightError reports a credential skipped because its half-open probe is running.
+func circuitProbeInFlightError() *Error {
+	return &Error{Code: "circuit_half_open", Message: "circuit half-open: probe in flight", Retryable: true, HTTPStatus: 503}
+}
+
+func disableAuthByPolicy(auth *Auth, now time.Time) {
+	auth.Disabled = true
+	auth.Status = StatusDisabled
+	auth.StatusMessage = "disabled by cooldown policy"
+	auth.UpdatedAt = now
+}
@@ -0,0 +1,171 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func newCooldownPolicyManager(t *testing.T, policies ...internalconfig.CooldownPolicy) *Manager {
+	t.Helper()
+	m := NewManager(nil, nil, nil)
+	m.SetConfig(&internalconfig.Config{CooldownPolicies: policies})
+	if _, err := m.Register(context.Background(), &Auth{ID: "policy-auth", Provider: "kiro"}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	return m
+}
+
+func modelRetryAfter(t *testing.T, m *Manager, model string) time.Duration {
+	t.Helper()
+	auth, ok := m.GetByID("policy-auth")
+	if !ok {
+		t.Fatalf("auth not found")
+	}
+	state := auth.ModelStates[model]
+	if state == nil || state.NextRetryAfter.IsZero() {
+		return 0
+	}
+	return time.Until(state.NextRetryAfter)
+}
+
+func TestMarkResult_CooldownPolicyBackoffCurve(t *testing.T) {
+	honor := false
+	m := newCooldownPolicyManager(t, internalconfig.CooldownPolicy{
+		Provider: "kiro",
+		Rules: []internalconfig.CooldownRule{{
+			StatusCodes:       []int{429},
+			Action:            internalconfig.CooldownActionCooldown,
+			CooldownSeconds:   10,
+			BackoffMaxSeconds: 15,
+			HonorRetryAfter:   &honor,
+		}},
+	})
+	retry := time.Hour
+	fail := Result{AuthID: "policy-auth", Provider: "kiro", Model: "m", RetryAfter: &retry, Error: &Error{HTTPStatus: 429, Message: "rate limited"}}
+
+	m.MarkResult(context.Background(), fail)
+	if got := modelRetryAfter(t, m, "m"); got < 9*time.Second || got > 10*time.Second {
+		t.Fatalf("first cooldown = %v, want ~10s", got)
+	}
+	m.MarkResult(context.Background(), fail)
+	if got := modelRetryAfter(t, m, "m"); got < 14*time.Second || got > 15*time.Second {
+		t.Fatalf("second cooldown = %v, want capped at ~15s", got)
+	}
+
+	m.MarkResult(context.Background(), Result{AuthID: "policy-auth", Provider: "kiro", Model: "m", Success: true})
+	m.MarkResult(context.Background(), fail)
+	if got := modelRetryAfter(t, m, "m"); got > 10*time.Second {
+		t.Fatalf("cooldown after success = %v, want curve reset to ~10s", got)
+	}
+}
+
+func TestMarkResult_CooldownPolicyDisablesOnMessage(t *testing.T) {
+	m := newCooldownPolicyManager(t, internalconfig.CooldownPolicy{
+		Provider: "*",
+		Rules: []internalconfig.CooldownRule{{
+			StatusCodes:    []int{403},
+			MessagePattern: "account .*suspended",
+			Action:         internalconfig.CooldownActionDisable,
+		}},
+	})
+
+	m.MarkResult(context.Background(), Result{AuthID: "policy-auth", Provider: "kiro", Model: "m", Error: &Error{HTTPStatus: 403, Message: "Forbidden"}})
+	if auth, _ := m.GetByID("policy-auth"); auth.Disabled {
+		t.Fatalf("expected unmatched message to keep the auth enabled")
+	}
+
+	m.MarkResult(context.Background(), Result{AuthID: "policy-auth", Provider: "kiro", Model: "m", Error: &Error{HTTPStatus: 403, Message: "Account has been SUSPENDED"}})
+	auth, _ := m.GetByID("policy-auth")
+	if !auth.Disabled || auth.Status != StatusDisabled {
+		t.Fatalf("expected auth to be disabled, got disabled=%t status=%s", auth.Disabled, auth.Status)
+	}
+}
+
+func TestMarkResult_CooldownPolicyCircuitBreaker(t *testing.T) {
+	m := newCooldownPolicyManager(t, internalconfig.CooldownPolicy{
+		Provider: "kiro",
+		Models:   []string{"m*"},
+		Rules: []internalconfig.CooldownRule{{
+			StatusCodes:      []int{500, 502},
+			Action:           internalconfig.CooldownActionCircuitBreaker,
+			FailureThreshold: 2,
+			OpenSeconds:      30,
+		}},
+	})
+	fail := Result{AuthID: "policy-auth", Provider: "kiro", Model: "m1", Error: &Error{HTTPStatus: 502, Message: "bad gateway"}}
+
+	m.MarkResult(context.Background(), fail)
+	if got := modelRetryAfter(t, m, "m1"); got != 0 {
+		t.Fatalf("circuit opened after one failure (retry in %v)", got)
+	}
+	if auth, _ := m.GetByID("policy-auth"); auth.Status == StatusError || auth.ModelStates["m1"] != nil {
+		t.Fatalf("expected a failure below the threshold to leave the auth untouched, got status=%s", auth.Status)
+	}
+	m.MarkResult(context.Background(), fail)
+	if got := modelRetryAfter(t, m, "m1"); got < 29*time.Second || got > 30*time.Second {
+		t.Fatalf("open window = %v, want ~30s", got)
+	}
+}
+
+func TestCooldownTracker_HalfOpenProbeFailureReopens(t *testing.T) {
+	tracker := newCooldownTracker()
+	rule := internalconfig.CooldownRule{Action: internalconfig.CooldownActionCircuitBreaker, FailureThreshold: 3, OpenSeconds: 10}
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		tracker.apply("k", rule, nil, now)
+	}
+	probe := tracker.apply("k", rule, nil, now.Add(11*time.Second))
+	if probe.reason != "circuit_open" || !probe.next.Equal(now.Add(21*time.Second)) {
+		t.Fatalf("failed probe outcome = %+v, want circuit re-opened", probe)
+	}
+
+	rule.FailureThreshold = 2
+	key := cooldownKey("a", "m")
+	tracker.apply(key, rule, nil, now)
+	tracker.reset("a", "m")
+	if outcome := tracker.apply(key, rule, nil, now); !outcome.next.IsZero() {
+		t.Fatalf("expected success to reset the failure count, got %+v", outcome)
+	}
+}
+
+func TestMarkResult_CooldownPolicyIgnoreLeavesAuthUntouched(t *testing.T) {
+	m := newCooldownPolicyManager(t, internalconfig.CooldownPolicy{
+		Provider: "kiro",
+		Rules:    []internalconfig.CooldownRule{{StatusCodes: []int{500}, Action: internalconfig.CooldownActionIgnore}},
+	})
+
+	m.MarkResult(context.Background(), Result{AuthID: "policy-auth", Provider: "kiro", Error: &Error{HTTPStatus: 500, Message: "boom"}})
+	auth, _ := m.GetByID("policy-auth")
+	if auth.Unavailable || auth.Status == StatusError || auth.LastError != nil {
+		t.Fatalf("expected ignored failure to leave the auth untouched, got unavailable=%t status=%s", auth.Unavailable, auth.Status)
+	}
+}
+
+func TestCooldownTracker_HalfOpenAdmitsSingleProbe(t *testing.T) {
+	tracker := newCooldownTracker()
+	rule := internalconfig.CooldownRule{Action: internalconfig.CooldownActionCircuitBreaker, FailureThreshold: 1, OpenSeconds: 10}
+	now := time.Now()
+	tracker.apply(cooldownKey("a", "m"), rule, nil, now)
+
+	if !tracker.admit("a", "other", now) {
+		t.Fatalf("expected other models to stay admitted")
+	}
+	halfOpen := now.Add(11 * time.Second)
+	if !tracker.admit("a", "m", halfOpen) {
+		t.Fatalf("expected the first half-open request to be admitted as the probe")
+	}
+	if tracker.admit("a", "m", halfOpen) {
+		t.Fatalf("expected a second request to wait for the probe")
+	}
+	if !tracker.admit("a", "m", halfOpen.Add(11*time.Second)) {
+		t.Fatalf("expected a probe that never reported back to expire after the open window")
+	}
+
+	tracker.reset("a", "m")
+	if !tracker.admit("a", "m", halfOpen) {
+		t.Fatalf("expected a successful probe to close the circuit")
+	}
+}
@@ -0,0 +1,327 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/oauth2"
+)
+
+// HealthChecker is implemented by executors that can validate a credential without serving a
+// user request, typically through a models list or token introspection call.
+type HealthChecker interface {
+	// CheckHealth returns nil when the credential is usable. Errors should expose the upstream
+	// status through a StatusCode() int method so revoked credentials can be told apart from
+	// transient failures.
+	CheckHealth(ctx context.Context, auth *Auth) error
+}
+
+// ProbeResult records the outcome of a credential health probe.
+type ProbeResult struct {
+	// AuthID references the probed auth.
+	AuthID string `json:"auth_id"`
+	// Provider is copied from the probed auth.
+	Provider string `json:"provider"`
+	// CheckedAt is when the probe finished.
+	CheckedAt time.Time `json:"checked_at"`
+	// Healthy reports whether the credential passed the probe.
+	Healthy bool `json:"healthy"`
+	// Skipped is set when the provider offers no way to validate the credential.
+	Skipped bool `json:"skipped,omitempty"`
+	// Method names the check performed: "health-check", "refresh", or "unchecked" when the
+	// executor has no health check and the token has not expired.
+	Method string `json:"method,omitempty"`
+	// Latency is the probe duration in milliseconds.
+	Latency int64 `json:"latency_ms"`
+	// StatusCode is the upstream HTTP status of a failed probe, when known.
+	StatusCode int `json:"status_code,omitempty"`
+	// Error holds the failure message.
+	Error string `json:"error,omitempty"`
+}
+
+const (
+	healthProbeCheckInterval  = 30 * time.Second
+	healthProbeMaxConcurrency = 4
+	healthProbeStatusPrefix   = "health probe failed"
+)
+
+// ErrAuthNotFound is returned when an operation references an unknown auth ID.
+var ErrAuthNotFound = errors.New("auth not found")
+
+// ProbeAuth validates one credential immediately and records the outcome on the auth.
+// Credentials rejected with 401/403 (or whose refresh grant is rejected) are marked StatusError and
+// kept out of rotation until a later probe or request succeeds.
+func (m *Manager) ProbeAuth(ctx context.Context, id string) (ProbeResult, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	m.mu.RLock()
+	current := m.auths[id]
+	var exec ProviderExecutor
+	if current != nil {
+		exec = m.executors[current.Provider]
+	}
+	m.mu.RUnlock()
+	if current == nil {
+		return ProbeResult{}, ErrAuthNotFound
+	}
+	auth := current.Clone()
+	result := ProbeResult{AuthID: auth.ID, Provider: auth.Provider}
+	if auth.Disabled || exec == nil {
+		result.Skipped = true
+		result.CheckedAt = time.Now()
+		return result, nil
+	}
+
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	timeout := time.Duration(internalconfig.DefaultHealthProbeTimeoutSeconds) * time.Second
+	if cfg != nil {
+		timeout = time.Duration(cfg.HealthProbe.Timeout()) * time.Second
+	}
+	probeCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := m.runProbe(probeCtx, exec, auth, &result)
+	result.CheckedAt = time.Now()
+	result.Latency = result.CheckedAt.Sub(start).Milliseconds()
+	if errors.Is(err, context.Canceled) && ctx.Err() != nil {
+		return result, err
+	}
+	if !result.Skipped {
+		result.Healthy = err == nil
+		if err != nil {
+			result.StatusCode = statusCodeFromError(err)
+			result.Error = err.Error()
+		}
+	}
+	m.recordProbe(ctx, result)
+	return result, nil
+}
+
+// runProbe refreshes expired OAuth tokens first, then uses the executor's HealthChecker.
+// Unexpired tokens are never refreshed by a probe: a refresh rotates the refresh token and races
+// the auto-refresh loop, so credentials without a checker are reported as unchecked.
+func (m *Manager) runProbe(ctx context.Context, exec ProviderExecutor, auth *Auth, result *ProbeResult) error {
+	accountType, _ := auth.AccountInfo()
+	oauth := accountType != "api_key"
+	checker, hasChecker := exec.(HealthChecker)
+	expiry, hasExpiry := auth.ExpirationTime()
+	needsRefresh := oauth && hasExpiry && !expiry.IsZero() && !expiry.After(time.Now())
+	if needsRefresh {
+		result.Method = "refresh"
+		updated, err := exec.Refresh(ctx, auth.Clone())
+		if err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			return refreshProbeError(err)
+		}
+		if updated != nil {
+			if updated.Runtime == nil {
+				updated.Runtime = auth.Runtime
+			}
+			updated.LastRefreshedAt = time.Now()
+			updated.LastError = nil
+			if _, errUpdate := m.Update(ctx, updated); errUpdate != nil {
+				log.Debugf("health probe: store refreshed auth %s failed: %v", auth.ID, errUpdate)
+			}
+			auth = updated
+		}
+	}
+	if hasChecker {
+		result.Method = "health-check"
+		return checker.CheckHealth(ctx, auth)
+	}
+	if !needsRefresh {
+		result.Method = "unchecked"
+		result.Skipped = true
+	}
+	return nil
+}
+
+// refreshStatusPattern matches the upstream status that refreshers embed in their error text,
+// e.g. "token refresh failed with status 400: ...".
+var refreshStatusPattern = regexp.MustCompile(`\bstatus (400|401)\b`)
+
+// refreshProbeError maps a failed token refresh to a probe error. Only a rejected grant
+// (invalid_grant, or an upstream 400/401) means the credential is bad; network failures and
+// server errors stay transient so the credential keeps serving requests.
+func refreshProbeError(err error) error {
+	status := statusCodeFromError(err)
+	var retrieveErr *oauth2.RetrieveError
+	if errors.As(err, &retrieveErr) {
+		if retrieveErr.ErrorCode == "invalid_grant" {
+			status = http.StatusUnauthorized
+		} else if retrieveErr.Response != nil {
+			status = retrieveErr.Response.StatusCode
+		}
+	}
+	if status == 0 {
+		if msg := err.Error(); strings.Contains(msg, "invalid_grant") || refreshStatusPattern.MatchString(msg) {
+			status = http.StatusUnauthorized
+		}
+	}
+	if status == http.StatusBadRequest || status == http.StatusUnauthorized {
+		return &Error{HTTPStatus: http.StatusUnauthorized, Message: err.Error()}
+	}
+	return fmt.Errorf("token refresh failed: %w", err)
+}
+
+// recordProbe stores the probe outcome and toggles the probe-driven error state.
+func (m *Manager) recordProbe(ctx context.Context, result ProbeResult) {
+	m.mu.Lock()
+	auth := m.auths[result.AuthID]
+	if auth == nil {
+		m.mu.Unlock()
+		return
+	}
+	recorded := result
+	auth.LastProbe = &recorded
+	switch {
+	case result.Skipped:
+	case result.Healthy:
+		if strings.HasPrefix(auth.StatusMessage, healthProbeStatusPrefix) && !auth.Disabled {
+			auth.Status = StatusActive
+			auth.StatusMessage = ""
+			auth.Unavailable = false
+			auth.NextRetryAfter = time.Time{}
+			auth.LastError = nil
+			updateAggregatedAvailability(auth, result.CheckedAt)
+		}
+	case result.StatusCode == http.StatusUnauthorized || result.StatusCode == http.StatusForbidden:
+		if !auth.Disabled {
+			auth.Status = StatusError
+			auth.StatusMessage = fmt.Sprintf("%s: %s", healthProbeStatusPrefix, result.Error)
+			auth.Unavailable = true
+			auth.NextRetryAfter = result.CheckedAt.Add(m.healthProbeInterval())
+			auth.LastError = &Error{HTTPStatus: result.StatusCode, Message: result.Error}
+		}
+	}
+	auth.UpdatedAt = result.CheckedAt
+	snapshot := auth.Clone()
+	m.mu.Unlock()
+
+	if m.scheduler != nil {
+		m.scheduler.upsertAuth(snapshot)
+	}
+	if !result.Skipped && !result.Healthy {
+		log.Warnf("health probe: %s (%s) failed: %s", result.AuthID, result.Provider, result.Error)
+	}
+	m.hook.OnAuthUpdated(ctx, snapshot.Clone())
+}
+
+// healthProbeInterval returns the configured background probe interval, or the refresh-failure
+// backoff when background probes are off.
+func (m *Manager) healthProbeInterval() time.Duration {
+	if cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config); cfg != nil && cfg.HealthProbe.IntervalSeconds > 0 {
+		return time.Duration(cfg.HealthProbe.IntervalSeconds) * time.Second
+	}
+	return refreshFailureBackoff
+}
+
+// ProbeAll probes every enabled credential concurrently and returns the outcomes.
+func (m *Manager) ProbeAll(ctx context.Context) []ProbeResult {
+	auths := m.snapshotAuths()
+	results := make([]ProbeResult, len(auths))
+	sem := make(chan struct{}, healthProbeMaxConcurrency)
+	done := make(chan struct{}, len(auths))
+	for i, auth := range auths {
+		go func(i int, id string) {
+			defer func() { done <- struct{}{} }()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			result, err := m.ProbeAuth(ctx, id)
+			if err != nil {
+				result.AuthID = id
+				result.Error = err.Error()
+			}
+			results[i] = result
+		}(i, auth.ID)
+	}
+	for range auths {
+		<-done
+	}
+	return results
+}
+
+// StartHealthProbes launches the background prober. Probe cadence follows health-probe in the
+// runtime config, so it can be enabled or tuned without restarting the loop.
+func (m *Manager) StartHealthProbes(parent context.Context) {
+	m.StopHealthProbes()
+	ctx, cancel := context.WithCancel(parent)
+	m.mu.Lock()
+	m.probeCancel = cancel
+	m.mu.Unlock()
+	go func() {
+		ticker := time.NewTicker(healthProbeCheckInterval)
+		defer ticker.Stop()
+		sem := make(chan struct{}, healthProbeMaxConcurrency)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.checkHealthProbes(ctx, sem)
+			}
+		}
+	}()
+}
+
+// StopHealthProbes cancels the background prober, if running.
+func (m *Manager) StopHealthProbes() {
+	m.mu.Lock()
+	cancel := m.probeCancel
+	m.probeCancel = nil
+	m.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+func (m *Manager) checkHealthProbes(ctx context.Context, sem chan struct{}) {
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil || cfg.HealthProbe.IntervalSeconds <= 0 {
+		return
+	}
+	interval := time.Duration(cfg.HealthProbe.IntervalSeconds) * time.Second
+	now := time.Now()
+	for _, auth := range m.snapshotAuths() {
+		if auth.Disabled || !cfg.HealthProbe.CoversProvider(auth.Provider) {
+			continue
+		}
+		if auth.LastProbe != nil && now.Sub(auth.LastProbe.CheckedAt) < interval {
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+		default:
+			// Busy; remaining credentials are picked up on the next tick.
+			return
+		}
+		m.mu.Lock()
+		if current := m.auths[auth.ID]; current != nil {
+			// Mark the probe as started so the next tick does not pick the auth up again.
+			started := ProbeResult{AuthID: auth.ID, Provider: auth.Provider, CheckedAt: now, Skipped: true}
+			if current.LastProbe != nil {
+				started = *current.LastProbe
+				started.CheckedAt = now
+			}
+			current.LastProbe = &started
+		}
+		m.mu.Unlock()
+		go func(id string) {
+			defer func() { <-sem }()
+			if _, err := m.ProbeAuth(ctx, id); err != nil && !errors.Is(err, context.Canceled) {
+				log.Debugf("health probe: %s: %v", id, err)
+			}
+		}(auth.ID)
+	}
+}
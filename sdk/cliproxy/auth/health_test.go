@@ -0,0 +1,193 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+type probeExecutor struct {
+	mu  sync.Mutex
+	err error
+}
+
+func (e *probeExecutor) Identifier() string { return "claude" }
+
+func (e *probeExecutor) Execute(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, nil
+}
+
+func (e *probeExecutor) ExecuteStream(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error) {
+	return nil, nil
+}
+
+func (e *probeExecutor) Refresh(_ context.Context, auth *Auth) (*Auth, error) { return auth, nil }
+
+func (e *probeExecutor) CountTokens(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, nil
+}
+
+func (e *probeExecutor) HttpRequest(context.Context, *Auth, *http.Request) (*http.Response, error) {
+	return nil, nil
+}
+
+func (e *probeExecutor) CheckHealth(context.Context, *Auth) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.err
+}
+
+func (e *probeExecutor) setErr(err error) {
+	e.mu.Lock()
+	e.err = err
+	e.mu.Unlock()
+}
+
+func TestProbeAuth_RevokedCredentialMarkedAndRecovered(t *testing.T) {
+	ctx := context.Background()
+	exec := &probeExecutor{err: &Error{HTTPStatus: http.StatusUnauthorized, Message: "invalid x-api-key"}}
+	m := NewManager(nil, nil, nil)
+	m.RegisterExecutor(exec)
+	if _, err := m.Register(ctx, &Auth{ID: "probe-auth", Provider: "claude", Status: StatusActive, Attributes: map[string]string{"api_key": "k"}}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	result, err := m.ProbeAuth(ctx, "probe-auth")
+	if err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+	if result.Healthy || result.StatusCode != http.StatusUnauthorized || result.Method != "health-check" {
+		t.Fatalf("unexpected probe result: %+v", result)
+	}
+	auth, _ := m.GetByID("probe-auth")
+	if auth.Status != StatusError || !auth.Unavailable || auth.NextRetryAfter.IsZero() {
+		t.Fatalf("revoked credential not taken out of rotation: status=%s unavailable=%t", auth.Status, auth.Unavailable)
+	}
+	if auth.LastProbe == nil || auth.LastProbe.Healthy {
+		t.Fatalf("probe outcome not recorded: %+v", auth.LastProbe)
+	}
+
+	exec.setErr(nil)
+	if result, _ = m.ProbeAuth(ctx, "probe-auth"); !result.Healthy {
+		t.Fatalf("expected healthy probe, got %+v", result)
+	}
+	auth, _ = m.GetByID("probe-auth")
+	if auth.Status != StatusActive || auth.Unavailable || auth.StatusMessage != "" {
+		t.Fatalf("credential not restored after healthy probe: status=%s unavailable=%t message=%q", auth.Status, auth.Unavailable, auth.StatusMessage)
+	}
+}
+
+func TestProbeAuth_TransientFailureKeepsCredential(t *testing.T) {
+	ctx := context.Background()
+	exec := &probeExecutor{err: &Error{HTTPStatus: http.StatusBadGateway, Message: "bad gateway"}}
+	m := NewManager(nil, nil, nil)
+	m.RegisterExecutor(exec)
+	if _, err := m.Register(ctx, &Auth{ID: "probe-auth", Provider: "claude", Status: StatusActive, Attributes: map[string]string{"api_key": "k"}}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	results := m.ProbeAll(ctx)
+	if len(results) != 1 || results[0].Healthy {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	if auth, _ := m.GetByID("probe-auth"); auth.Status != StatusActive || auth.Unavailable {
+		t.Fatalf("transient failure should not disable the credential: status=%s", auth.Status)
+	}
+	if _, err := m.ProbeAuth(ctx, "missing"); err != ErrAuthNotFound {
+		t.Fatalf("expected ErrAuthNotFound, got %v", err)
+	}
+}
+
+// refreshOnlyExecutor has no HealthChecker and counts token refreshes.
+type refreshOnlyExecutor struct {
+	probeExecutor
+	refreshes int
+}
+
+func (e *refreshOnlyExecutor) Identifier() string { return "codex" }
+
+func (e *refreshOnlyExecutor) Refresh(_ context.Context, auth *Auth) (*Auth, error) {
+	e.mu.Lock()
+	e.refreshes++
+	e.mu.Unlock()
+	return auth, nil
+}
+
+func TestProbeAuth_WithoutCheckerOnlyRefreshesExpiredTokens(t *testing.T) {
+	ctx := context.Background()
+	exec := &refreshOnlyExecutor{}
+	m := NewManager(nil, nil, nil)
+	m.RegisterExecutor(struct{ ProviderExecutor }{exec})
+	fresh := &Auth{ID: "fresh", Provider: "codex", Status: StatusActive, Metadata: map[string]any{"email": "a@example.com", "expired": time.Now().Add(time.Hour).Format(time.RFC3339)}}
+	expired := &Auth{ID: "expired", Provider: "codex", Status: StatusActive, Metadata: map[string]any{"email": "b@example.com", "expired": time.Now().Add(-time.Hour).Format(time.RFC3339)}}
+	for _, auth := range []*Auth{fresh, expired} {
+		if _, err := m.Register(ctx, auth); err != nil {
+			t.Fatalf("register: %v", err)
+		}
+	}
+
+	result, err := m.ProbeAuth(ctx, "fresh")
+	if err != nil || !result.Skipped || result.Method != "unchecked" || exec.refreshes != 0 {
+		t.Fatalf("fresh token: result=%+v err=%v refreshes=%d", result, err, exec.refreshes)
+	}
+	result, err = m.ProbeAuth(ctx, "expired")
+	if err != nil || !result.Healthy || result.Method != "refresh" || exec.refreshes != 1 {
+		t.Fatalf("expired token: result=%+v err=%v refreshes=%d", result, err, exec.refreshes)
+	}
+}
+
+// failingRefreshExecutor has no HealthChecker and fails every token refresh with err.
+type failingRefreshExecutor struct {
+	probeExecutor
+}
+
+func (e *failingRefreshExecutor) Identifier() string { return "codex" }
+
+func (e *failingRefreshExecutor) Refresh(context.Context, *Auth) (*Auth, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return nil, e.err
+}
+
+func TestProbeAuth_RefreshNetworkErrorKeepsCredentialSelectable(t *testing.T) {
+	ctx := context.Background()
+	networkErr := &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "auth.openai.com", IsNotFound: true}}
+	exec := &failingRefreshExecutor{probeExecutor{err: fmt.Errorf("token refresh request failed: %w", networkErr)}}
+	m := NewManager(nil, nil, nil)
+	m.RegisterExecutor(struct{ ProviderExecutor }{exec})
+	auth := &Auth{ID: "probe-refresh-network", Provider: "codex", Status: StatusActive, Metadata: map[string]any{"email": "a@example.com", "expired": time.Now().Add(-time.Hour).Format(time.RFC3339)}}
+	if _, err := m.Register(ctx, auth); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient(auth.ID, "codex", []*registry.ModelInfo{{ID: "probe-refresh-model"}})
+	t.Cleanup(func() { reg.UnregisterClient(auth.ID) })
+
+	result, err := m.ProbeAuth(ctx, auth.ID)
+	if err != nil || result.Healthy || result.StatusCode != 0 {
+		t.Fatalf("network failure: result=%+v err=%v", result, err)
+	}
+	if got, _ := m.GetByID(auth.ID); got.Status != StatusActive || got.Unavailable {
+		t.Fatalf("network failure should not disable the credential: status=%s unavailable=%t", got.Status, got.Unavailable)
+	}
+	picked, _, errPick := m.pickNext(ctx, "codex", "probe-refresh-model", cliproxyexecutor.Options{}, nil)
+	if errPick != nil || picked == nil || picked.ID != auth.ID {
+		t.Fatalf("pickNext() = %v, %v; want the probed credential", picked, errPick)
+	}
+
+	exec.setErr(errors.New(`token refresh failed with status 400: {"error":"invalid_grant"}`))
+	result, err = m.ProbeAuth(ctx, auth.ID)
+	if err != nil || result.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("rejected grant: result=%+v err=%v", result, err)
+	}
+	if got, _ := m.GetByID(auth.ID); got.Status != StatusError || !got.Unavailable {
+		t.Fatalf("rejected grant should take the credential out of rotation: status=%s unavailable=%t", got.Status, got.Unavailable)
+	}
+}
@@ -0,0 +1,368 @@
+package auth
+
+import (
+	"context"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+const (
+	// hedgeSampleSize bounds the number of recent first-chunk latencies kept per model.
+	hedgeSampleSize = 128
+	// hedgeMinSamples is the number of samples required before the adaptive p95 delay is used.
+	hedgeMinSamples = 20
+	// hedgeBudgetWindow is the period over which the extra-spend cap is enforced.
+	hedgeBudgetWindow = time.Minute
+)
+
+// hedgeTracker keeps per-model latency history and hedge budget counters.
+type hedgeTracker struct {
+	mu     sync.Mutex
+	models map[string]*hedgeModelState
+}
+
+type hedgeModelState struct {
+	samples     []time.Duration
+	next        int
+	windowStart time.Time
+	requests    int
+	hedges      int
+}
+
+func newHedgeTracker() *hedgeTracker {
+	return &hedgeTracker{models: make(map[string]*hedgeModelState)}
+}
+
+func (t *hedgeTracker) stateLocked(key string, now time.Time) *hedgeModelState {
+	state, ok := t.models[key]
+	if !ok {
+		state = &hedgeModelState{windowStart: now}
+		t.models[key] = state
+	}
+	if now.Sub(state.windowStart) >= hedgeBudgetWindow {
+		state.windowStart = now
+		state.requests = 0
+		state.hedges = 0
+	}
+	return state
+}
+
+// delay returns the hedge delay for key, preferring the observed p95 when adaptive is enabled.
+func (t *hedgeTracker) delay(key string, policy internalconfig.HedgingConfig) time.Duration {
+	fallback := policy.Delay()
+	if !policy.Adaptive {
+		return fallback
+	}
+	t.mu.Lock()
+	state, ok := t.models[key]
+	var samples []time.Duration
+	if ok && len(state.samples) >= hedgeMinSamples {
+		samples = slices.Clone(state.samples)
+	}
+	t.mu.Unlock()
+	if len(samples) == 0 {
+		return fallback
+	}
+	slices.Sort(samples)
+	idx := int(math.Ceil(float64(len(samples))*0.95)) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if p95 := samples[idx]; p95 > 0 {
+		return p95
+	}
+	return fallback
+}
+
+// observe records the time to first chunk of the winning attempt, measured from its own start.
+func (t *hedgeTracker) observe(key string, latency time.Duration) {
+	if latency <= 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	state := t.stateLocked(key, time.Now())
+	if len(state.samples) < hedgeSampleSize {
+		state.samples = append(state.samples, latency)
+		return
+	}
+	state.samples[state.next] = latency
+	state.next = (state.next + 1) % hedgeSampleSize
+}
+
+// admit counts a hedge-eligible request toward the budget window.
+func (t *hedgeTracker) admit(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stateLocked(key, time.Now()).requests++
+}
+
+// reserve consumes one hedge from the budget, allowing at most percent of the eligible requests
+// seen in the window to be hedged.
+func (t *hedgeTracker) reserve(key string, percent int) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	state := t.stateLocked(key, time.Now())
+	if (state.hedges+1)*100 > state.requests*percent {
+		return false
+	}
+	state.hedges++
+	return true
+}
+
+// hedgingPolicy returns the hedging configuration when it applies to routeModel.
+func (m *Manager) hedgingPolicy(routeModel string) (internalconfig.HedgingConfig, bool) {
+	if m == nil || m.hedges == nil {
+		return internalconfig.HedgingConfig{}, false
+	}
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil || !cfg.Routing.Hedging.MatchesModel(routeModel) {
+		return internalconfig.HedgingConfig{}, false
+	}
+	return cfg.Routing.Hedging, true
+}
+
+func hedgeTrackerKey(routeModel string, stream bool) string {
+	key := strings.ToLower(strings.TrimSpace(routeModel))
+	if stream {
+		return "stream:" + key
+	}
+	return "once:" + key
+}
+
+// cloneOptionsMetadata gives an attempt its own metadata map so concurrent hedged
+// attempts never write to the same map.
+func cloneOptionsMetadata(opts cliproxyexecutor.Options) cliproxyexecutor.Options {
+	if opts.Metadata == nil {
+		return opts
+	}
+	meta := make(map[string]any, len(opts.Metadata))
+	for k, v := range opts.Metadata {
+		meta[k] = v
+	}
+	opts.Metadata = meta
+	return opts
+}
+
+// hedgeAttempt executes one candidate of a hedged request bound to ctx.
+type hedgeAttempt[T any] func(ctx context.Context) (T, error)
+
+type hedgeOutcome[T any] struct {
+	value   T
+	err     error
+	hedged  bool
+	latency time.Duration
+	cancel  context.CancelFunc
+}
+
+// raceHedged runs primary and, when it has not completed after delay, the attempt returned by
+// nextHedge (nil means no hedge is available). The first successful attempt wins and the other is
+// cancelled; a late success is handed to release. The returned cancel func must be called once the
+// caller is done with the winning value. hedgeWon reports whether the hedged attempt produced it and
+// latency how long the winning attempt took from its own start. Attempts return once the first
+// chunk arrives, so for streams latency is the time to first token.
+func raceHedged[T any](ctx context.Context, delay time.Duration, primary hedgeAttempt[T], nextHedge func() hedgeAttempt[T], release func(T)) (value T, latency time.Duration, cancel context.CancelFunc, hedgeWon bool, err error) {
+	results := make(chan hedgeOutcome[T], 2)
+	// cancels[0] belongs to the primary attempt, cancels[1] to the hedge.
+	cancels := make([]context.CancelFunc, 0, 2)
+	start := func(run hedgeAttempt[T], hedged bool) {
+		attemptCtx, attemptCancel := context.WithCancel(ctx)
+		if hedged {
+			attemptCtx = coreusage.WithHedgedAttempt(attemptCtx)
+		}
+		cancels = append(cancels, attemptCancel)
+		go func() {
+			started := time.Now()
+			v, errRun := run(attemptCtx)
+			results <- hedgeOutcome[T]{value: v, err: errRun, hedged: hedged, latency: time.Since(started), cancel: attemptCancel}
+		}()
+	}
+	// releaseLate hands successful results of still-running attempts to release once they finish.
+	releaseLate := func(pending int) {
+		if pending <= 0 {
+			return
+		}
+		go func() {
+			for i := 0; i < pending; i++ {
+				late := <-results
+				late.cancel()
+				if late.err == nil && release != nil {
+					release(late.value)
+				}
+			}
+		}()
+	}
+
+	start(primary, false)
+	pending := 1
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	timerC := timer.C
+	for {
+		select {
+		case <-timerC:
+			timerC = nil
+			if run := nextHedge(); run != nil {
+				start(run, true)
+				pending++
+			}
+		case res := <-results:
+			pending--
+			if res.err == nil {
+				for i, c := range cancels {
+					if (i > 0) != res.hedged {
+						c()
+					}
+				}
+				releaseLate(pending)
+				return res.value, res.latency, res.cancel, res.hedged, nil
+			}
+			res.cancel()
+			err = res.err
+			if isRequestInvalidError(res.err) || pending == 0 {
+				for _, c := range cancels {
+					c()
+				}
+				releaseLate(pending)
+				var zero T
+				return zero, 0, func() {}, false, err
+			}
+		}
+	}
+}
+
+// nextHedgeCandidate picks a second credential (possibly of another provider) for a hedged
+// request. It honours the credential retry limit and reserves one hedge from the budget.
+func (m *Manager) nextHedgeCandidate(ctx context.Context, providers []string, routeModel string, opts cliproxyexecutor.Options, policy internalconfig.HedgingConfig, key string, tried, attempted map[string]struct{}, maxRetryCredentials int) (*Auth, ProviderExecutor, string, []string, bool, bool) {
+	if maxRetryCredentials > 0 && len(attempted) >= maxRetryCredentials {
+		return nil, nil, "", nil, false, false
+	}
+	for {
+		auth, executor, provider, errPick := m.pickNextMixed(ctx, providers, routeModel, opts, tried)
+		if errPick != nil {
+			return nil, nil, "", nil, false, false
+		}
+		tried[auth.ID] = struct{}{}
+		models, pooled := m.preparedExecutionModels(auth, routeModel)
+		if len(models) == 0 {
+			continue
+		}
+		if !m.hedges.reserve(key, policy.ExtraPercent()) {
+			// Leave the credential available for regular retries.
+			delete(tried, auth.ID)
+			return nil, nil, "", nil, false, false
+		}
+		attempted[auth.ID] = struct{}{}
+		debugLogAuthSelection(logEntryWithRequestID(ctx), auth, provider, routeModel)
+		return auth, executor, provider, models, pooled, true
+	}
+}
+
+// hedgeOptions returns a private copy of opts whose metadata names the hedged credential.
+func hedgeOptions(opts cliproxyexecutor.Options, authID string) cliproxyexecutor.Options {
+	opts = cloneOptionsMetadata(opts)
+	if len(opts.Metadata) > 0 {
+		opts.Metadata[cliproxyexecutor.SelectedAuthMetadataKey] = authID
+	}
+	return opts
+}
+
+// executeHedged runs a non-streaming request on auth and, when no response arrives within the
+// hedge delay, races it against the same request on a second credential.
+func (m *Manager) executeHedged(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, routeModel string, policy internalconfig.HedgingConfig, tried, attempted map[string]struct{}, maxRetryCredentials int, auth *Auth, executor ProviderExecutor, provider string, models []string, pooled bool) (cliproxyexecutor.Response, error) {
+	key := hedgeTrackerKey(routeModel, false)
+	m.hedges.admit(key)
+	primaryOpts := cloneOptionsMetadata(opts)
+	primary := func(attemptCtx context.Context) (cliproxyexecutor.Response, error) {
+		return m.executeWithModelPool(m.executionContext(attemptCtx, auth), executor, auth, provider, req, primaryOpts, routeModel, models, pooled)
+	}
+	hedgeAuthID := ""
+	nextHedge := func() hedgeAttempt[cliproxyexecutor.Response] {
+		hedgeAuth, hedgeExecutor, hedgeProvider, hedgeModels, hedgePooled, ok := m.nextHedgeCandidate(ctx, providers, routeModel, opts, policy, key, tried, attempted, maxRetryCredentials)
+		if !ok {
+			return nil
+		}
+		hedgeAuthID = hedgeAuth.ID
+		logEntryWithRequestID(ctx).Debugf("hedging request for model %s on %s", routeModel, hedgeProvider)
+		hedgeOpts := hedgeOptions(opts, hedgeAuth.ID)
+		return func(attemptCtx context.Context) (cliproxyexecutor.Response, error) {
+			return m.executeWithModelPool(m.executionContext(attemptCtx, hedgeAuth), hedgeExecutor, hedgeAuth, hedgeProvider, req, hedgeOpts, routeModel, hedgeModels, hedgePooled)
+		}
+	}
+	resp, latency, cancel, hedgeWon, errExec := raceHedged(ctx, m.hedges.delay(key, policy), primary, nextHedge, nil)
+	cancel()
+	if errExec != nil {
+		return cliproxyexecutor.Response{}, errExec
+	}
+	m.hedges.observe(key, latency)
+	if hedgeWon {
+		publishSelectedAuthMetadata(opts.Metadata, hedgeAuthID)
+	}
+	return resp, nil
+}
+
+// executeStreamHedged runs a streaming request on auth and, when no first chunk arrives within the
+// hedge delay, races it against the same request on a second credential. The losing stream is
+// cancelled and drained.
+func (m *Manager) executeStreamHedged(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, routeModel string, policy internalconfig.HedgingConfig, tried, attempted map[string]struct{}, maxRetryCredentials int, auth *Auth, executor ProviderExecutor, provider string, models []string, pooled bool) (*cliproxyexecutor.StreamResult, error) {
+	key := hedgeTrackerKey(routeModel, true)
+	m.hedges.admit(key)
+	primaryOpts := cloneOptionsMetadata(opts)
+	primary := func(attemptCtx context.Context) (*cliproxyexecutor.StreamResult, error) {
+		return m.executeStreamWithModelPool(m.executionContext(attemptCtx, auth), executor, auth, provider, req, primaryOpts, routeModel, models, pooled)
+	}
+	hedgeAuthID := ""
+	nextHedge := func() hedgeAttempt[*cliproxyexecutor.StreamResult] {
+		hedgeAuth, hedgeExecutor, hedgeProvider, hedgeModels, hedgePooled, ok := m.nextHedgeCandidate(ctx, providers, routeModel, opts, policy, key, tried, attempted, maxRetryCredentials)
+		if !ok {
+			return nil
+		}
+		hedgeAuthID = hedgeAuth.ID
+		logEntryWithRequestID(ctx).Debugf("hedging stream for model %s on %s", routeModel, hedgeProvider)
+		hedgeOpts := hedgeOptions(opts, hedgeAuth.ID)
+		return func(attemptCtx context.Context) (*cliproxyexecutor.StreamResult, error) {
+			return m.executeStreamWithModelPool(m.executionContext(attemptCtx, hedgeAuth), hedgeExecutor, hedgeAuth, hedgeProvider, req, hedgeOpts, routeModel, hedgeModels, hedgePooled)
+		}
+	}
+	release := func(result *cliproxyexecutor.StreamResult) {
+		if result != nil {
+			discardStreamChunks(result.Chunks)
+		}
+	}
+	result, latency, cancel, hedgeWon, errStream := raceHedged(ctx, m.hedges.delay(key, policy), primary, nextHedge, release)
+	if errStream != nil {
+		cancel()
+		return nil, errStream
+	}
+	m.hedges.observe(key, latency)
+	if hedgeWon {
+		publishSelectedAuthMetadata(opts.Metadata, hedgeAuthID)
+	}
+	return cancelOnStreamEnd(ctx, result, cancel), nil
+}
+
+// cancelOnStreamEnd forwards result's chunks and releases the attempt context once the stream
+// ends or the caller goes away.
+func cancelOnStreamEnd(ctx context.Context, result *cliproxyexecutor.StreamResult, cancel context.CancelFunc) *cliproxyexecutor.StreamResult {
+	out := make(chan cliproxyexecutor.StreamChunk)
+	go func() {
+		defer close(out)
+		defer cancel()
+		for chunk := range result.Chunks {
+			select {
+			case <-ctx.Done():
+				discardStreamChunks(result.Chunks)
+				return
+			case out <- chunk:
+			}
+		}
+	}()
+	return &cliproxyexecutor.StreamResult{Headers: result.Headers, Chunks: out}
+}
@@ -0,0 +1,270 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+// hedgeDelayExecutor responds after a per-auth delay, or fails when the context is cancelled first.
+type hedgeDelayExecutor struct {
+	delays map[string]time.Duration
+
+	mu        sync.Mutex
+	calls     []string
+	hedged    map[string]bool
+	cancelled map[string]bool
+}
+
+func (e *hedgeDelayExecutor) Identifier() string { return "claude" }
+
+func (e *hedgeDelayExecutor) wait(ctx context.Context, auth *Auth) error {
+	e.mu.Lock()
+	e.calls = append(e.calls, auth.ID)
+	e.hedged[auth.ID] = coreusage.IsHedgedAttempt(ctx)
+	e.mu.Unlock()
+	select {
+	case <-time.After(e.delays[auth.ID]):
+		return nil
+	case <-ctx.Done():
+		e.mu.Lock()
+		e.cancelled[auth.ID] = true
+		e.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+func (e *hedgeDelayExecutor) Execute(ctx context.Context, auth *Auth, _ cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	if errWait := e.wait(ctx, auth); errWait != nil {
+		return cliproxyexecutor.Response{}, errWait
+	}
+	return cliproxyexecutor.Response{Payload: []byte(auth.ID)}, nil
+}
+
+func (e *hedgeDelayExecutor) ExecuteStream(ctx context.Context, auth *Auth, _ cliproxyexecutor.Request, _ cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error) {
+	ch := make(chan cliproxyexecutor.StreamChunk, 1)
+	go func() {
+		defer close(ch)
+		if errWait := e.wait(ctx, auth); errWait != nil {
+			ch <- cliproxyexecutor.StreamChunk{Err: errWait}
+			return
+		}
+		ch <- cliproxyexecutor.StreamChunk{Payload: []byte(auth.ID)}
+	}()
+	return &cliproxyexecutor.StreamResult{Headers: http.Header{"X-Auth": {auth.ID}}, Chunks: ch}, nil
+}
+
+func (e *hedgeDelayExecutor) Refresh(_ context.Context, auth *Auth) (*Auth, error) {
+	return auth, nil
+}
+
+func (e *hedgeDelayExecutor) CountTokens(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, &Error{HTTPStatus: 500, Message: "not implemented"}
+}
+
+func (e *hedgeDelayExecutor) HttpRequest(context.Context, *Auth, *http.Request) (*http.Response, error) {
+	return nil, nil
+}
+
+func (e *hedgeDelayExecutor) Calls() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]string(nil), e.calls...)
+}
+
+func (e *hedgeDelayExecutor) WasCancelled(authID string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.cancelled[authID]
+}
+
+func (e *hedgeDelayExecutor) WasHedged(authID string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.hedged[authID]
+}
+
+// newHedgeTestManager registers a slow and a fast credential and returns them in selection order.
+func newHedgeTestManager(t *testing.T, hedging internalconfig.HedgingConfig) (*Manager, *hedgeDelayExecutor, string, string) {
+	t.Helper()
+
+	m := NewManager(nil, &FillFirstSelector{}, nil)
+	m.SetConfig(&internalconfig.Config{Routing: internalconfig.RoutingConfig{Hedging: hedging}})
+
+	baseID := uuid.NewString()
+	slowID := baseID + "-a-slow"
+	fastID := baseID + "-b-fast"
+	executor := &hedgeDelayExecutor{
+		delays:    map[string]time.Duration{slowID: 5 * time.Second, fastID: 10 * time.Millisecond},
+		hedged:    make(map[string]bool),
+		cancelled: make(map[string]bool),
+	}
+	m.RegisterExecutor(executor)
+
+	reg := registry.GetGlobalRegistry()
+	for _, id := range []string{slowID, fastID} {
+		reg.RegisterClient(id, "claude", []*registry.ModelInfo{{ID: "hedge-model"}})
+		if _, errRegister := m.Register(context.Background(), &Auth{ID: id, Provider: "claude"}); errRegister != nil {
+			t.Fatalf("register %s: %v", id, errRegister)
+		}
+	}
+	t.Cleanup(func() {
+		reg.UnregisterClient(slowID)
+		reg.UnregisterClient(fastID)
+	})
+	return m, executor, slowID, fastID
+}
+
+func waitForCancellation(t *testing.T, executor *hedgeDelayExecutor, authID string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !executor.WasCancelled(authID) {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected losing attempt on %s to be cancelled", authID)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestManagerExecute_HedgesSlowCredential(t *testing.T) {
+	m, executor, slowID, fastID := newHedgeTestManager(t, internalconfig.HedgingConfig{
+		Enable:          true,
+		Models:          []string{"hedge-*"},
+		DelayMs:         20,
+		MaxExtraPercent: 100,
+	})
+
+	resp, errExecute := m.Execute(context.Background(), []string{"claude"}, cliproxyexecutor.Request{Model: "hedge-model"}, cliproxyexecutor.Options{})
+	if errExecute != nil {
+		t.Fatalf("execute error = %v", errExecute)
+	}
+	if string(resp.Payload) != fastID {
+		t.Fatalf("payload = %q, want %q", string(resp.Payload), fastID)
+	}
+	if calls := executor.Calls(); len(calls) != 2 || calls[0] != slowID || calls[1] != fastID {
+		t.Fatalf("calls = %v, want [%s %s]", calls, slowID, fastID)
+	}
+	if executor.WasHedged(slowID) || !executor.WasHedged(fastID) {
+		t.Fatalf("expected only the second attempt to be marked as hedged")
+	}
+	waitForCancellation(t, executor, slowID)
+}
+
+func TestManagerExecuteStream_HedgesSlowCredential(t *testing.T) {
+	m, executor, slowID, fastID := newHedgeTestManager(t, internalconfig.HedgingConfig{
+		Enable:          true,
+		Models:          []string{"hedge-model"},
+		DelayMs:         20,
+		MaxExtraPercent: 100,
+	})
+
+	streamResult, errExecute := m.ExecuteStream(context.Background(), []string{"claude"}, cliproxyexecutor.Request{Model: "hedge-model"}, cliproxyexecutor.Options{})
+	if errExecute != nil {
+		t.Fatalf("execute stream error = %v", errExecute)
+	}
+	if got := streamResult.Headers.Get("X-Auth"); got != fastID {
+		t.Fatalf("X-Auth = %q, want %q", got, fastID)
+	}
+	var payload []byte
+	for chunk := range streamResult.Chunks {
+		if chunk.Err != nil {
+			t.Fatalf("unexpected stream error: %v", chunk.Err)
+		}
+		payload = append(payload, chunk.Payload...)
+	}
+	if string(payload) != fastID {
+		t.Fatalf("payload = %q, want %q", string(payload), fastID)
+	}
+	waitForCancellation(t, executor, slowID)
+}
+
+func TestManagerExecute_HedgingSkipsUnlistedModels(t *testing.T) {
+	m, executor, slowID, _ := newHedgeTestManager(t, internalconfig.HedgingConfig{
+		Enable:  true,
+		Models:  []string{"other-model"},
+		DelayMs: 20,
+	})
+	executor.delays[slowID] = 60 * time.Millisecond
+
+	resp, errExecute := m.Execute(context.Background(), []string{"claude"}, cliproxyexecutor.Request{Model: "hedge-model"}, cliproxyexecutor.Options{})
+	if errExecute != nil {
+		t.Fatalf("execute error = %v", errExecute)
+	}
+	if string(resp.Payload) != slowID {
+		t.Fatalf("payload = %q, want %q", string(resp.Payload), slowID)
+	}
+	if calls := executor.Calls(); len(calls) != 1 {
+		t.Fatalf("calls = %v, want a single attempt", calls)
+	}
+}
+
+func TestHedgeTracker_ReserveRespectsBudget(t *testing.T) {
+	tracker := newHedgeTracker()
+	for i := 0; i < 20; i++ {
+		tracker.admit("stream:m")
+	}
+	if !tracker.reserve("stream:m", 10) || !tracker.reserve("stream:m", 10) {
+		t.Fatalf("expected two hedges to fit a 10%% budget of 20 requests")
+	}
+	if tracker.reserve("stream:m", 10) {
+		t.Fatalf("expected third hedge to exceed the budget")
+	}
+	if tracker.reserve("stream:other", 10) {
+		t.Fatalf("expected no hedge before enough requests fill the budget")
+	}
+	for i := 0; i < 9; i++ {
+		tracker.admit("stream:other")
+	}
+	if tracker.reserve("stream:other", 10) {
+		t.Fatalf("expected no hedge for 9 requests at 10%%")
+	}
+	tracker.admit("stream:other")
+	if !tracker.reserve("stream:other", 10) {
+		t.Fatalf("expected one hedge for 10 requests at 10%%")
+	}
+}
+
+func TestRaceHedged_ReportsWinnerLatency(t *testing.T) {
+	slow := func(ctx context.Context) (string, error) {
+		select {
+		case <-time.After(5 * time.Second):
+			return "slow", nil
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	fast := func(context.Context) (string, error) {
+		time.Sleep(10 * time.Millisecond)
+		return "fast", nil
+	}
+	value, latency, cancel, hedgeWon, err := raceHedged(context.Background(), 100*time.Millisecond, slow, func() hedgeAttempt[string] { return fast }, nil)
+	cancel()
+	if err != nil || value != "fast" || !hedgeWon {
+		t.Fatalf("race = %q, %v, %v", value, hedgeWon, err)
+	}
+	if latency >= 100*time.Millisecond {
+		t.Fatalf("latency = %v, want the hedge's own time to first chunk, not including the hedge delay", latency)
+	}
+}
+
+func TestHedgeTracker_AdaptiveDelayUsesP95(t *testing.T) {
+	tracker := newHedgeTracker()
+	policy := internalconfig.HedgingConfig{Enable: true, DelayMs: 1000, Adaptive: true}
+	if got := tracker.delay("once:m", policy); got != time.Second {
+		t.Fatalf("delay without samples = %v, want %v", got, time.Second)
+	}
+	for i := 1; i <= 100; i++ {
+		tracker.observe("once:m", time.Duration(i)*time.Millisecond)
+	}
+	if got := tracker.delay("once:m", policy); got != 95*time.Millisecond {
+		t.Fatalf("adaptive delay = %v, want %v", got, 95*time.Millisecond)
+	}
+}
@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+// LiveEndpoint is implemented by executors whose upstream serves the Gemini Live
+// BidiGenerateContent websocket.
+type LiveEndpoint interface {
+	// DialLive opens the upstream Live websocket for auth. opts.Metadata may carry the API
+	// version the client requested under cliproxyexecutor.LiveAPIVersionMetadataKey.
+	DialLive(ctx context.Context, auth *Auth, model string, opts cliproxyexecutor.Options) (*LiveUpstream, error)
+}
+
+// LiveUpstream is an upstream Live connection opened by an executor.
+type LiveUpstream struct {
+	Conn *websocket.Conn
+	// Model is the model resource name the upstream expects in the setup message.
+	Model string
+	// Report publishes the session usage. The session duration is measured from the dial.
+	Report func(ctx context.Context, detail usage.Detail, failed bool)
+}
+
+// LiveSession is an upstream Live connection bound to the credential that opened it.
+type LiveSession struct {
+	*LiveUpstream
+	Auth     *Auth
+	Provider string
+	// RouteModel is the requested model used for selection and cooldown bookkeeping.
+	RouteModel string
+}
+
+// PublishUsage reports the session usage through the executor that opened it.
+func (s *LiveSession) PublishUsage(ctx context.Context, detail usage.Detail, failed bool) {
+	if s == nil || s.LiveUpstream == nil || s.Report == nil {
+		return
+	}
+	s.Report(ctx, detail, failed)
+}
+
+// DialLive opens a Live session for model on the first credential whose upstream accepts the
+// handshake. Rejected handshakes are recorded against the credential so cooldowns apply.
+func (m *Manager) DialLive(ctx context.Context, providers []string, model string, opts cliproxyexecutor.Options) (*LiveSession, error) {
+	normalized := m.normalizeProviders(providers)
+	liveProviders := make([]string, 0, len(normalized))
+	for _, provider := range normalized {
+		exec, ok := m.Executor(provider)
+		if !ok {
+			continue
+		}
+		if _, ok = exec.(LiveEndpoint); ok {
+			liveProviders = append(liveProviders, provider)
+		}
+	}
+	if len(liveProviders) == 0 {
+		return nil, &Error{Code: "provider_not_found", Message: "no provider supports live sessions for model " + model, HTTPStatus: http.StatusBadRequest}
+	}
+
+	_, maxRetryCredentials, _ := m.retrySettings()
+	opts = ensureRequestedModelMetadata(opts, model)
+	tried := make(map[string]struct{})
+	var lastErr error
+	for {
+		if maxRetryCredentials > 0 && len(tried) >= maxRetryCredentials {
+			break
+		}
+		auth, executor, provider, errPick := m.pickNextMixed(ctx, liveProviders, model, opts, tried)
+		if errPick != nil {
+			if lastErr != nil {
+				return nil, lastErr
+			}
+			return nil, errPick
+		}
+		tried[auth.ID] = struct{}{}
+		endpoint, ok := executor.(LiveEndpoint)
+		if !ok {
+			continue
+		}
+		models, _ := m.preparedExecutionModels(auth, model)
+		if len(models) == 0 {
+			continue
+		}
+		upstream, errDial := endpoint.DialLive(ctx, auth, models[0], opts)
+		if errDial != nil {
+			if errCtx := ctx.Err(); errCtx != nil {
+				return nil, errCtx
+			}
+			m.MarkLiveResult(ctx, auth.ID, provider, model, errDial)
+			if isRequestInvalidError(errDial) {
+				return nil, errDial
+			}
+			lastErr = errDial
+			continue
+		}
+		m.MarkLiveResult(ctx, auth.ID, provider, model, nil)
+		return &LiveSession{LiveUpstream: upstream, Auth: auth, Provider: provider, RouteModel: model}, nil
+	}
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	return nil, &Error{Code: "auth_not_found", Message: "no auth available"}
+}
+
+// MarkLiveResult records the outcome of a Live handshake or session against a credential.
+func (m *Manager) MarkLiveResult(ctx context.Context, authID, provider, model string, err error) {
+	result := Result{AuthID: authID, Provider: provider, Model: model, Success: err == nil}
+	if err != nil {
+		result.Error = &Error{Message: err.Error()}
+		if se, ok := errors.AsType[cliproxyexecutor.StatusError](err); ok && se != nil {
+			result.Error.HTTPStatus = se.StatusCode()
+		}
+		result.RetryAfter = retryAfterFromError(err)
+	}
+	m.MarkResult(ctx, result)
+}
+
+// LiveAuthAvailable reports whether the credential may keep serving model. Long-lived Live
+// sessions poll it so they can be closed once the credential enters cooldown.
+func (m *Manager) LiveAuthAvailable(authID, model string) bool {
+	auth, ok := m.GetByID(authID)
+	if !ok {
+		return false
+	}
+	now := time.Now()
+	if blocked, _, _ := isAuthBlockedForModel(auth, "", now); blocked {
+		return false
+	}
+	blocked, _, _ := isAuthBlockedForModel(auth, model, now)
+	return !blocked
+}
@@ -0,0 +1,291 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// RuntimeState is the persisted subset of an auth's scheduling state: cooldowns, quota and
+// per-model suspensions. It is stored apart from the auth contents so saving it never rewrites
+// credential files.
+type RuntimeState struct {
+	// ID references the auth the state belongs to.
+	ID string `json:"id"`
+	// Unavailable mirrors Auth.Unavailable.
+	Unavailable bool `json:"unavailable,omitempty"`
+	// StatusMessage mirrors Auth.StatusMessage.
+	StatusMessage string `json:"status_message,omitempty"`
+	// NextRetryAfter mirrors Auth.NextRetryAfter.
+	NextRetryAfter time.Time `json:"next_retry_after,omitempty"`
+	// Quota mirrors Auth.Quota.
+	Quota QuotaState `json:"quota"`
+	// ModelStates holds the model states that were still blocking when saved.
+	ModelStates map[string]*ModelState `json:"model_states,omitempty"`
+	// UpdatedAt records when the state was captured.
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// RuntimeStateStore is implemented by stores that can persist runtime state across restarts.
+type RuntimeStateStore interface {
+	// LoadRuntimeStates returns every persisted runtime state keyed by auth ID.
+	LoadRuntimeStates(ctx context.Context) (map[string]*RuntimeState, error)
+	// SaveRuntimeState persists the state of one auth, replacing any previous one.
+	SaveRuntimeState(ctx context.Context, state *RuntimeState) error
+	// DeleteRuntimeState removes the persisted state of one auth.
+	DeleteRuntimeState(ctx context.Context, id string) error
+}
+
+// captureRuntimeState extracts the parts of the auth state still blocking at now.
+// It returns nil when nothing would affect scheduling.
+func captureRuntimeState(auth *Auth, now time.Time) *RuntimeState {
+	if auth == nil || auth.ID == "" {
+		return nil
+	}
+	state := &RuntimeState{ID: auth.ID, UpdatedAt: now}
+	if auth.NextRetryAfter.After(now) || auth.Quota.NextRecoverAt.After(now) {
+		state.Unavailable = auth.Unavailable
+		state.StatusMessage = auth.StatusMessage
+		state.NextRetryAfter = auth.NextRetryAfter
+		state.Quota = auth.Quota
+	}
+	for model, modelState := range auth.ModelStates {
+		if modelState == nil || !modelStateBlocking(modelState, now) {
+			continue
+		}
+		if state.ModelStates == nil {
+			state.ModelStates = make(map[string]*ModelState)
+		}
+		copied := *modelState
+		copied.LastError = cloneError(modelState.LastError)
+		state.ModelStates[model] = &copied
+	}
+	if state.NextRetryAfter.IsZero() && state.Quota.NextRecoverAt.IsZero() && len(state.ModelStates) == 0 {
+		return nil
+	}
+	return state
+}
+
+func modelStateBlocking(state *ModelState, now time.Time) bool {
+	return state.Status != StatusDisabled && (state.NextRetryAfter.After(now) || state.Quota.NextRecoverAt.After(now))
+}
+
+// expire drops entries that are no longer blocking; it reports whether anything is left.
+func (s *RuntimeState) expire(now time.Time) bool {
+	if s == nil {
+		return false
+	}
+	if !s.NextRetryAfter.After(now) && !s.Quota.NextRecoverAt.After(now) {
+		s.Unavailable = false
+		s.StatusMessage = ""
+		s.NextRetryAfter = time.Time{}
+		s.Quota = QuotaState{}
+	}
+	for model, modelState := range s.ModelStates {
+		if modelState == nil || !modelStateBlocking(modelState, now) {
+			delete(s.ModelStates, model)
+		}
+	}
+	return !s.NextRetryAfter.IsZero() || len(s.ModelStates) > 0
+}
+
+// applyRuntimeState restores persisted state onto an auth that has no runtime state of its own.
+func applyRuntimeState(auth *Auth, state *RuntimeState, now time.Time) bool {
+	if auth == nil || state == nil || auth.Disabled || auth.Status == StatusDisabled {
+		return false
+	}
+	if len(auth.ModelStates) > 0 || auth.NextRetryAfter.After(now) {
+		return false
+	}
+	if !state.expire(now) {
+		return false
+	}
+	if !state.NextRetryAfter.IsZero() {
+		auth.Unavailable = state.Unavailable
+		auth.StatusMessage = state.StatusMessage
+		auth.NextRetryAfter = state.NextRetryAfter
+		auth.Quota = state.Quota
+		auth.Status = StatusError
+	}
+	if len(state.ModelStates) > 0 {
+		auth.ModelStates = make(map[string]*ModelState, len(state.ModelStates))
+		for model, modelState := range state.ModelStates {
+			copied := *modelState
+			auth.ModelStates[model] = &copied
+		}
+		updateAggregatedAvailability(auth, now)
+	}
+	return true
+}
+
+// loadRuntimeStates reads persisted runtime state, deleting expired entries. Entries whose auth
+// is not loaded yet stay pending and are applied when the auth is registered.
+func (m *Manager) loadRuntimeStates(ctx context.Context) {
+	stateStore, ok := m.store.(RuntimeStateStore)
+	if !ok {
+		return
+	}
+	states, err := stateStore.LoadRuntimeStates(ctx)
+	if err != nil {
+		log.Warnf("auth runtime state: load failed: %v", err)
+		return
+	}
+	now := time.Now()
+	pending := make(map[string]*RuntimeState, len(states))
+	for id, state := range states {
+		if state == nil || !state.expire(now) {
+			if errDelete := stateStore.DeleteRuntimeState(ctx, id); errDelete != nil {
+				log.Debugf("auth runtime state: delete expired %s failed: %v", id, errDelete)
+			}
+			continue
+		}
+		pending[id] = state
+	}
+	m.mu.Lock()
+	m.pendingRuntimeStates = pending
+	m.savedRuntimeStates = make(map[string][]byte, len(pending))
+	for id := range pending {
+		// Mark restored entries as saved so clearing them later deletes the persisted copy.
+		m.savedRuntimeStates[id] = []byte("restored")
+	}
+	for id, auth := range m.auths {
+		if state, okState := pending[id]; okState && applyRuntimeState(auth, state, now) {
+			delete(pending, id)
+		}
+	}
+	m.mu.Unlock()
+}
+
+// restorePendingRuntimeStateLocked applies a pending persisted state to a newly registered auth.
+// Callers must hold m.mu.
+func (m *Manager) restorePendingRuntimeStateLocked(auth *Auth) {
+	if auth == nil || len(m.pendingRuntimeStates) == 0 {
+		return
+	}
+	state, ok := m.pendingRuntimeStates[auth.ID]
+	if !ok {
+		return
+	}
+	delete(m.pendingRuntimeStates, auth.ID)
+	applyRuntimeState(auth, state, time.Now())
+}
+
+// runtimeStateWrite is a runtime-state snapshot waiting to be persisted. A nil state deletes the
+// persisted copy.
+type runtimeStateWrite struct {
+	seq   uint64
+	state *RuntimeState
+}
+
+// runtimeStateWriter persists runtime-state snapshots in the background. Only the latest
+// snapshot per auth is kept, and sequence numbers stop an older snapshot from overwriting a
+// newer one that was written first.
+type runtimeStateWriter struct {
+	mu      sync.Mutex
+	seq     uint64
+	pending map[string]runtimeStateWrite
+	running bool
+
+	// flushMu serialises store writes; written records the last sequence written per auth.
+	flushMu sync.Mutex
+	written map[string]uint64
+}
+
+// queueRuntimeStateLocked snapshots the auth's runtime state and hands it to the background
+// writer when it changed since the last snapshot. Callers must hold m.mu.
+func (m *Manager) queueRuntimeStateLocked(ctx context.Context, auth *Auth) {
+	stateStore, ok := m.store.(RuntimeStateStore)
+	if !ok || auth == nil || shouldSkipPersist(ctx) {
+		return
+	}
+	state := captureRuntimeState(auth, time.Now())
+	var encoded []byte
+	if state != nil {
+		snapshot := *state
+		snapshot.UpdatedAt = time.Time{}
+		encoded, _ = json.Marshal(snapshot)
+	}
+	if m.savedRuntimeStates == nil {
+		m.savedRuntimeStates = make(map[string][]byte)
+	}
+	previous, saved := m.savedRuntimeStates[auth.ID]
+	if (state == nil && !saved) || (saved && bytes.Equal(previous, encoded)) {
+		return
+	}
+	if state == nil {
+		delete(m.savedRuntimeStates, auth.ID)
+	} else {
+		m.savedRuntimeStates[auth.ID] = encoded
+	}
+
+	w := &m.runtimeStates
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.seq++
+	if w.pending == nil {
+		w.pending = make(map[string]runtimeStateWrite)
+	}
+	w.pending[auth.ID] = runtimeStateWrite{seq: w.seq, state: state}
+	if !w.running {
+		w.running = true
+		go m.runRuntimeStateWriter(stateStore)
+	}
+}
+
+// runRuntimeStateWriter drains queued snapshots until none are left.
+func (m *Manager) runRuntimeStateWriter(stateStore RuntimeStateStore) {
+	for m.writeRuntimeStates(stateStore, true) {
+	}
+}
+
+// FlushRuntimeStates writes every queued runtime-state snapshot before returning. Call it on
+// shutdown so the latest cooldowns survive a restart.
+func (m *Manager) FlushRuntimeStates() {
+	if stateStore, ok := m.store.(RuntimeStateStore); ok {
+		m.writeRuntimeStates(stateStore, false)
+	}
+}
+
+// writeRuntimeStates writes the queued snapshots and reports whether any were taken. The
+// background writer passes stopWhenIdle to mark itself finished once the queue is empty.
+func (m *Manager) writeRuntimeStates(stateStore RuntimeStateStore, stopWhenIdle bool) bool {
+	w := &m.runtimeStates
+	// Taking the batch under flushMu makes a flush wait for snapshots already being written.
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+	w.mu.Lock()
+	batch := w.pending
+	w.pending = nil
+	if len(batch) == 0 && stopWhenIdle {
+		w.running = false
+	}
+	w.mu.Unlock()
+	if len(batch) == 0 {
+		return false
+	}
+
+	if w.written == nil {
+		w.written = make(map[string]uint64)
+	}
+	ctx := context.Background()
+	for id, write := range batch {
+		if write.seq <= w.written[id] {
+			continue
+		}
+		w.written[id] = write.seq
+		var err error
+		if write.state == nil {
+			err = stateStore.DeleteRuntimeState(ctx, id)
+		} else {
+			err = stateStore.SaveRuntimeState(ctx, write.state)
+		}
+		if err != nil {
+			log.Warnf("auth runtime state: persist %s failed: %v", id, err)
+		}
+	}
+	return true
+}
@@ -0,0 +1,195 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+type runtimeStateMemoryStore struct {
+	mu     sync.Mutex
+	auths  []*Auth
+	states map[string]*RuntimeState
+	saves  int
+}
+
+func (s *runtimeStateMemoryStore) List(context.Context) ([]*Auth, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]*Auth, 0, len(s.auths))
+	for _, auth := range s.auths {
+		out = append(out, auth.Clone())
+	}
+	return out, nil
+}
+
+func (s *runtimeStateMemoryStore) Save(_ context.Context, auth *Auth) (string, error) {
+	return auth.ID, nil
+}
+
+func (s *runtimeStateMemoryStore) Delete(context.Context, string) error { return nil }
+
+func (s *runtimeStateMemoryStore) LoadRuntimeStates(context.Context) (map[string]*RuntimeState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]*RuntimeState, len(s.states))
+	for id, state := range s.states {
+		copied := *state
+		out[id] = &copied
+	}
+	return out, nil
+}
+
+func (s *runtimeStateMemoryStore) SaveRuntimeState(_ context.Context, state *RuntimeState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.saves++
+	s.states[state.ID] = state
+	return nil
+}
+
+func (s *runtimeStateMemoryStore) DeleteRuntimeState(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.states, id)
+	return nil
+}
+
+func (s *runtimeStateMemoryStore) state(id string) *RuntimeState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.states[id]
+}
+
+func TestManager_RuntimeStateSurvivesRestart(t *testing.T) {
+	store := &runtimeStateMemoryStore{
+		auths:  []*Auth{{ID: "state-auth", Provider: "claude", Status: StatusActive}},
+		states: make(map[string]*RuntimeState),
+	}
+	ctx := context.Background()
+	first := NewManager(store, nil, nil)
+	if err := first.Load(ctx); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	retry := 10 * time.Minute
+	fail := Result{AuthID: "state-auth", Provider: "claude", Model: "m", RetryAfter: &retry, Error: &Error{HTTPStatus: 429, Message: "quota"}}
+	first.MarkResult(ctx, fail)
+	first.FlushRuntimeStates()
+	if store.state("state-auth") == nil {
+		t.Fatalf("expected runtime state to be persisted")
+	}
+	success := Result{AuthID: "state-auth", Provider: "claude", Model: "other", Success: true}
+	first.MarkResult(ctx, success)
+	first.FlushRuntimeStates()
+	saves := store.saves
+	first.MarkResult(ctx, success)
+	first.FlushRuntimeStates()
+	if store.saves != saves {
+		t.Fatalf("expected unchanged state not to be rewritten, got %d writes after %d", store.saves, saves)
+	}
+
+	second := NewManager(store, nil, nil)
+	if err := second.Load(ctx); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	auth, _ := second.GetByID("state-auth")
+	state := auth.ModelStates["m"]
+	if state == nil || !state.Unavailable || time.Until(state.NextRetryAfter) < 9*time.Minute {
+		t.Fatalf("model cooldown not restored: %+v", state)
+	}
+	if !state.Quota.Exceeded {
+		t.Fatalf("quota state not restored: %+v", state.Quota)
+	}
+
+	second.MarkResult(ctx, Result{AuthID: "state-auth", Provider: "claude", Model: "m", Success: true})
+	second.FlushRuntimeStates()
+	if store.state("state-auth") != nil {
+		t.Fatalf("expected cleared state to be deleted from the store")
+	}
+}
+
+func TestManager_RuntimeStateExpiredEntriesDropped(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+	store := &runtimeStateMemoryStore{
+		auths: []*Auth{{ID: "expired-auth", Provider: "claude", Status: StatusActive}},
+		states: map[string]*RuntimeState{
+			"expired-auth": {ID: "expired-auth", NextRetryAfter: past, ModelStates: map[string]*ModelState{"m": {NextRetryAfter: past, Unavailable: true}}},
+			"future-auth":  {ID: "future-auth", NextRetryAfter: time.Now().Add(time.Hour), Unavailable: true},
+		},
+	}
+	m := NewManager(store, nil, nil)
+	if err := m.Load(context.Background()); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if store.state("expired-auth") != nil {
+		t.Fatalf("expected expired state to be deleted")
+	}
+	if auth, _ := m.GetByID("expired-auth"); len(auth.ModelStates) != 0 || auth.Unavailable {
+		t.Fatalf("expired state applied: %+v", auth)
+	}
+
+	// Auths registered after Load (e.g. config API keys) pick up their pending state.
+	if _, err := m.Register(context.Background(), &Auth{ID: "future-auth", Provider: "claude", Status: StatusActive}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	auth, _ := m.GetByID("future-auth")
+	if !auth.Unavailable || time.Until(auth.NextRetryAfter) < 59*time.Minute {
+		t.Fatalf("pending state not applied on register: %+v", auth)
+	}
+}
+
+// blockingRuntimeStateStore holds the first runtime-state save until release is closed.
+type blockingRuntimeStateStore struct {
+	runtimeStateMemoryStore
+	started chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func (s *blockingRuntimeStateStore) SaveRuntimeState(ctx context.Context, state *RuntimeState) error {
+	first := false
+	s.once.Do(func() { first = true })
+	if first {
+		close(s.started)
+		<-s.release
+	}
+	return s.runtimeStateMemoryStore.SaveRuntimeState(ctx, state)
+}
+
+func TestManager_RuntimeStateWritesLatestSnapshotInBackground(t *testing.T) {
+	store := &blockingRuntimeStateStore{
+		runtimeStateMemoryStore: runtimeStateMemoryStore{
+			auths:  []*Auth{{ID: "writer-auth", Provider: "claude", Status: StatusActive}},
+			states: make(map[string]*RuntimeState),
+		},
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	ctx := context.Background()
+	m := NewManager(store, nil, nil)
+	if err := m.Load(ctx); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	retry := 10 * time.Minute
+	m.MarkResult(ctx, Result{AuthID: "writer-auth", Provider: "claude", Model: "a", RetryAfter: &retry, Error: &Error{HTTPStatus: 429, Message: "quota"}})
+	<-store.started
+	// The store is stuck on the first write; MarkResult must not wait for it.
+	for _, model := range []string{"b", "c", "d"} {
+		m.MarkResult(ctx, Result{AuthID: "writer-auth", Provider: "claude", Model: model, RetryAfter: &retry, Error: &Error{HTTPStatus: 429, Message: "quota"}})
+	}
+	close(store.release)
+	m.FlushRuntimeStates()
+
+	state := store.state("writer-auth")
+	if state == nil || len(state.ModelStates) != 4 {
+		t.Fatalf("expected the latest snapshot with 4 blocked models, got %+v", state)
+	}
+	store.mu.Lock()
+	saves := store.saves
+	store.mu.Unlock()
+	if saves != 2 {
+		t.Fatalf("store writes = %d, want 2 (queued snapshots collapse to the latest)", saves)
+	}
+}
@@ -88,6 +88,8 @@ type Auth struct {
 	NextRetryAfter time.Time `json:"next_retry_after"`
 	// ModelStates tracks per-model runtime availability data.
 	ModelStates map[string]*ModelState `json:"model_states,omitempty"`
+	// LastProbe records the latest health probe outcome.
+	LastProbe *ProbeResult `json:"last_probe,omitempty"`
 
 	// Runtime carries non-serialisable data used during execution (in-memory only).
 	Runtime any `json:"-"`
@@ -149,6 +151,10 @@ func (a *Auth) Clone() *Auth {
 			copyAuth.ModelStates[key] = state.Clone()
 		}
 	}
+	if a.LastProbe != nil {
+		probe := *a.LastProbe
+		copyAuth.LastProbe = &probe
+	}
 	copyAuth.Runtime = a.Runtime
 	return &copyAuth
 }
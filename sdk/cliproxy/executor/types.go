@@ -16,10 +16,18 @@ const (
 	PinnedAuthMetadataKey = "pinned_auth_id"
 	// SelectedAuthMetadataKey stores the auth ID selected by the scheduler.
 	SelectedAuthMetadataKey = "selected_auth_id"
+	// ExcludedAuthsMetadataKey carries auth IDs ([]string) the scheduler must not select.
+	ExcludedAuthsMetadataKey = "excluded_auth_ids"
 	// SelectedAuthCallbackMetadataKey carries an optional callback invoked with the selected auth ID.
 	SelectedAuthCallbackMetadataKey = "selected_auth_callback"
 	// ExecutionSessionMetadataKey identifies a long-lived downstream execution session.
 	ExecutionSessionMetadataKey = "execution_session_id"
+	// CachedContentPrefixMetadataKey carries the number of leading request contents that were
+	// expanded from an emulated Gemini cachedContent, so executors can place cache breakpoints.
+	CachedContentPrefixMetadataKey = "cached_content_prefix"
+	// LiveAPIVersionMetadataKey carries the Gemini API version ("v1beta" or "v1alpha") of the
+	// Live websocket route the client connected to.
+	LiveAPIVersionMetadataKey = "live_api_version"
 )
 
 // Request encapsulates the translated payload that will be sent to a provider executor.
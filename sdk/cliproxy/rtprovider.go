@@ -5,13 +5,15 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/proxyutil"
 	log "github.com/sirupsen/logrus"
 )
 
 // defaultRoundTripperProvider returns a per-auth HTTP RoundTripper based on
-// the Auth.ProxyURL value. It caches transports per proxy URL string.
+// the Auth.ProxyURL value and the per-credential upstream TLS overrides stored in
+// its attributes. It caches transports per proxy URL and TLS settings.
 type defaultRoundTripperProvider struct {
 	mu    sync.RWMutex
 	cache map[string]http.RoundTripper
@@ -27,16 +29,21 @@ func (p *defaultRoundTripperProvider) RoundTripperFor(auth *coreauth.Auth) http.
 		return nil
 	}
 	proxyStr := strings.TrimSpace(auth.ProxyURL)
-	if proxyStr == "" {
+	tlsOpts := util.UpstreamTLSOptions(util.UpstreamTLSFromAttrs(auth.Attributes))
+	if proxyStr == "" && tlsOpts.IsZero() {
 		return nil
 	}
+	cacheKey := proxyStr
+	if tlsKey := tlsOpts.CacheKey(); tlsKey != "" {
+		cacheKey = proxyStr + "#tls:" + tlsKey
+	}
 	p.mu.RLock()
-	rt := p.cache[proxyStr]
+	rt := p.cache[cacheKey]
 	p.mu.RUnlock()
 	if rt != nil {
 		return rt
 	}
-	transport, _, errBuild := proxyutil.BuildHTTPTransport(proxyStr)
+	transport, _, errBuild := proxyutil.BuildHTTPTransportWithTLS(proxyStr, tlsOpts)
 	if errBuild != nil {
 		log.Errorf("%v", errBuild)
 		return nil
@@ -45,7 +52,7 @@ func (p *defaultRoundTripperProvider) RoundTripperFor(auth *coreauth.Auth) http.
 		return nil
 	}
 	p.mu.Lock()
-	p.cache[proxyStr] = transport
+	p.cache[cacheKey] = transport
 	p.mu.Unlock()
 	return transport
 }
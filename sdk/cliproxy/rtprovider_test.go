@@ -1,6 +1,7 @@
 package cliproxy
 
 import (
+	"crypto/tls"
 	"net/http"
 	"testing"
 
@@ -20,3 +21,30 @@ func TestRoundTripperForDirectBypassesProxy(t *testing.T) {
 		t.Fatal("expected direct transport to disable proxy function")
 	}
 }
+
+func TestRoundTripperForAppliesCredentialTLS(t *testing.T) {
+	t.Parallel()
+
+	provider := newDefaultRoundTripperProvider()
+	rt := provider.RoundTripperFor(&coreauth.Auth{Attributes: map[string]string{
+		"tls_server_name": "upstream.internal",
+		"tls_min_version": "1.3",
+	}})
+	transport, ok := rt.(*http.Transport)
+	if !ok {
+		t.Fatalf("transport type = %T, want *http.Transport", rt)
+	}
+	if transport.TLSClientConfig == nil {
+		t.Fatal("expected TLS config from credential attributes")
+	}
+	if transport.TLSClientConfig.ServerName != "upstream.internal" {
+		t.Fatalf("server name = %q, want %q", transport.TLSClientConfig.ServerName, "upstream.internal")
+	}
+	if transport.TLSClientConfig.MinVersion != tls.VersionTLS13 {
+		t.Fatalf("min version = %x, want %x", transport.TLSClientConfig.MinVersion, tls.VersionTLS13)
+	}
+
+	if plain := provider.RoundTripperFor(&coreauth.Auth{}); plain != nil {
+		t.Fatalf("expected nil round tripper without proxy or TLS settings, got %T", plain)
+	}
+}
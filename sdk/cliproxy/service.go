@@ -7,11 +7,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/alerting"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/api"
 	kiroauth "github.com/router-for-me/CLIProxyAPI/v6/internal/auth/kiro"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
@@ -676,6 +678,7 @@ func (s *Service) Run(ctx context.Context) error {
 
 		s.applyRetryConfig(newCfg)
 		s.applyPprofConfig(newCfg)
+		alerting.Configure(newCfg)
 		if s.server != nil {
 			s.server.UpdateClients(newCfg)
 		}
@@ -724,6 +727,13 @@ func (s *Service) Run(ctx context.Context) error {
 		interval := 15 * time.Minute
 		s.coreManager.StartAutoRefresh(context.Background(), interval)
 		log.Infof("core auth auto-refresh started (interval=%s)", interval)
+		// Probes run only while health-probe.interval-seconds is set; the loop follows config reloads.
+		s.coreManager.StartHealthProbes(context.Background())
+	}
+
+	alerting.Configure(s.cfg)
+	if s.coreManager != nil {
+		alerting.WatchCredentials(ctx, s.alertCredentials)
 	}
 
 	select {
@@ -735,6 +745,31 @@ func (s *Service) Run(ctx context.Context) error {
 	}
 }
 
+// alertCredentials snapshots the auth manager for the alerting credential monitor.
+// Credentials count as errored only when the upstream rejected them (401/403).
+func (s *Service) alertCredentials() []alerting.Credential {
+	auths := s.coreManager.List()
+	out := make([]alerting.Credential, 0, len(auths))
+	for _, auth := range auths {
+		errored := false
+		if auth.Status == coreauth.StatusError && auth.LastError != nil {
+			status := auth.LastError.StatusCode()
+			errored = status == http.StatusUnauthorized || status == http.StatusForbidden
+		}
+		out = append(out, alerting.Credential{
+			ID:             auth.ID,
+			Provider:       auth.Provider,
+			Label:          auth.Label,
+			Disabled:       auth.Disabled || auth.Status == coreauth.StatusDisabled,
+			Errored:        errored,
+			Unavailable:    auth.Unavailable,
+			NextRetryAfter: auth.NextRetryAfter,
+			StatusMessage:  auth.StatusMessage,
+		})
+	}
+	return out
+}
+
 // Shutdown gracefully stops background workers and the HTTP server.
 // It ensures all resources are properly cleaned up and connections are closed.
 // The shutdown is idempotent and can be called multiple times safely.
@@ -761,6 +796,8 @@ func (s *Service) Shutdown(ctx context.Context) error {
 		}
 		if s.coreManager != nil {
 			s.coreManager.StopAutoRefresh()
+			s.coreManager.StopHealthProbes()
+			s.coreManager.FlushRuntimeStates()
 		}
 		if s.watcher != nil {
 			if err := s.watcher.Stop(); err != nil {
@@ -869,7 +906,7 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 	var models []*ModelInfo
 	switch provider {
 	case "gemini":
-		models = registry.GetGeminiModels()
+		models = append(registry.GetGeminiModels(), registry.GetGeminiAudioModels()...)
 		if entry := s.resolveConfigGeminiKey(a); entry != nil {
 			if len(entry.Models) > 0 {
 				models = buildGeminiConfigModels(entry)
@@ -881,7 +918,7 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 		models = applyExcludedModels(models, excluded)
 	case "vertex":
 		// Vertex AI Gemini supports the same model identifiers as Gemini.
-		models = registry.GetGeminiVertexModels()
+		models = append(registry.GetGeminiVertexModels(), registry.GetGeminiAudioModels()...)
 		if entry := s.resolveConfigVertexCompatKey(a); entry != nil {
 			if len(entry.Models) > 0 {
 				models = buildVertexCompatConfigModels(entry)
@@ -928,6 +965,10 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 		default:
 			models = registry.GetCodexProModels()
 		}
+		if authKind == "apikey" {
+			// Platform API keys also reach the audio endpoints; OAuth sessions do not.
+			models = append(models, registry.GetOpenAIAudioModels()...)
+		}
 		if entry := s.resolveConfigCodexKey(a); entry != nil {
 			if len(entry.Models) > 0 {
 				models = buildCodexConfigModels(entry)
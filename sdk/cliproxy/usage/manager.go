@@ -9,6 +9,7 @@ import (
 )
 
 // Record contains the usage statistics captured for a single provider request.
+// Hedged marks records produced by a speculative duplicate launched by the hedging policy.
 type Record struct {
 	Provider    string
 	Model       string
@@ -19,7 +20,29 @@ type Record struct {
 	RequestedAt time.Time
 	Latency     time.Duration
 	Failed      bool
-	Detail      Detail
+	Hedged      bool
+	// SessionKey is the client-provided conversation key (X-Session-Key), if any.
+	SessionKey string
+	Detail     Detail
+}
+
+type hedgeContextKey struct{}
+
+// WithHedgedAttempt marks ctx as belonging to a speculative hedged request.
+func WithHedgedAttempt(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, hedgeContextKey{}, true)
+}
+
+// IsHedgedAttempt reports whether ctx belongs to a speculative hedged request.
+func IsHedgedAttempt(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	hedged, _ := ctx.Value(hedgeContextKey{}).(bool)
+	return hedged
 }
 
 // Detail holds the token usage breakdown.
@@ -29,6 +52,10 @@ type Detail struct {
 	ReasoningTokens int64
 	CachedTokens    int64
 	TotalTokens     int64
+	// CacheReadTokens and CacheCreationTokens break prompt caching down for backends that report
+	// reads and writes separately (Claude). Like Claude's own usage, they are not part of InputTokens.
+	CacheReadTokens     int64
+	CacheCreationTokens int64
 }
 
 // Plugin consumes usage records emitted by the proxy runtime.
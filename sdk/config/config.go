@@ -12,6 +12,17 @@ type Config = internalconfig.Config
 
 type StreamingConfig = internalconfig.StreamingConfig
 type TLSConfig = internalconfig.TLSConfig
+type UpstreamTLS = internalconfig.UpstreamTLS
+type MCPConfig = internalconfig.MCPConfig
+type MCPServer = internalconfig.MCPServer
+type FanOutModel = internalconfig.FanOutModel
+type StructuredOutputConfig = internalconfig.StructuredOutputConfig
+type StrictTranslationConfig = internalconfig.StrictTranslationConfig
+type HookConfig = internalconfig.HookConfig
+type PromptCachingConfig = internalconfig.PromptCachingConfig
+type PromptCachingModel = internalconfig.PromptCachingModel
+type ContextManagementConfig = internalconfig.ContextManagementConfig
+type ModelLifecycleConfig = internalconfig.ModelLifecycleConfig
 type RemoteManagement = internalconfig.RemoteManagement
 type AmpCode = internalconfig.AmpCode
 type OAuthModelAlias = internalconfig.OAuthModelAlias
@@ -46,8 +57,36 @@ type TLS = internalconfig.TLSConfig
 
 const (
 	DefaultPanelGitHubRepository = internalconfig.DefaultPanelGitHubRepository
+
+	FanOutModeAll   = internalconfig.FanOutModeAll
+	FanOutModeFirst = internalconfig.FanOutModeFirst
+	FanOutModeJudge = internalconfig.FanOutModeJudge
+
+	StrictTranslationOff    = internalconfig.StrictTranslationOff
+	StrictTranslationWarn   = internalconfig.StrictTranslationWarn
+	StrictTranslationReject = internalconfig.StrictTranslationReject
+
+	HookTransportHTTP     = internalconfig.HookTransportHTTP
+	HookTransportGRPC     = internalconfig.HookTransportGRPC
+	HookStagePreRequest   = internalconfig.HookStagePreRequest
+	HookStagePostResponse = internalconfig.HookStagePostResponse
+	HookFailOpen          = internalconfig.HookFailOpen
+	HookFailClosed        = internalconfig.HookFailClosed
+	HookStreamObserve     = internalconfig.HookStreamObserve
+	HookStreamBuffer      = internalconfig.HookStreamBuffer
+
+	ContextPolicyTruncate  = internalconfig.ContextPolicyTruncate
+	ContextPolicyStrip     = internalconfig.ContextPolicyStrip
+	ContextPolicySummarize = internalconfig.ContextPolicySummarize
+
+	RetiredModelRedirect = internalconfig.RetiredModelRedirect
+	RetiredModelReject   = internalconfig.RetiredModelReject
 )
 
+func NormalizeStrictTranslationMode(value string) string {
+	return internalconfig.NormalizeStrictTranslationMode(value)
+}
+
 func LoadConfig(configFile string) (*Config, error) { return internalconfig.LoadConfig(configFile) }
 
 func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
@@ -0,0 +1,149 @@
+package proxyutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// TLSOptions describes custom TLS settings for outbound upstream connections.
+// The zero value keeps the Go defaults (system roots, no client certificate).
+type TLSOptions struct {
+	// CAFiles lists PEM files whose certificates are trusted in addition to the system roots.
+	CAFiles []string
+	// ClientCertFile is the PEM client certificate presented for mutual TLS.
+	ClientCertFile string
+	// ClientKeyFile is the PEM private key matching ClientCertFile.
+	ClientKeyFile string
+	// ServerName overrides the SNI / verification host name.
+	ServerName string
+	// MinVersion is the minimum TLS version ("1.0", "1.1", "1.2", "1.3").
+	MinVersion string
+}
+
+// IsZero reports whether no custom TLS setting is configured.
+func (o TLSOptions) IsZero() bool {
+	return len(o.CAFiles) == 0 &&
+		strings.TrimSpace(o.ClientCertFile) == "" &&
+		strings.TrimSpace(o.ClientKeyFile) == "" &&
+		strings.TrimSpace(o.ServerName) == "" &&
+		strings.TrimSpace(o.MinVersion) == ""
+}
+
+// CacheKey returns a stable string identifying the options, suitable for transport caches.
+func (o TLSOptions) CacheKey() string {
+	if o.IsZero() {
+		return ""
+	}
+	return strings.Join([]string{
+		strings.Join(o.CAFiles, ","),
+		strings.TrimSpace(o.ClientCertFile),
+		strings.TrimSpace(o.ClientKeyFile),
+		strings.TrimSpace(o.ServerName),
+		strings.TrimSpace(o.MinVersion),
+	}, "|")
+}
+
+// ParseTLSVersion converts a version string such as "1.2" or "TLS1.3" into a crypto/tls constant.
+// An empty string returns 0, which keeps the Go default.
+func ParseTLSVersion(raw string) (uint16, error) {
+	trimmed := strings.ToLower(strings.TrimSpace(raw))
+	trimmed = strings.TrimPrefix(trimmed, "tls")
+	trimmed = strings.TrimPrefix(trimmed, "v")
+	switch trimmed {
+	case "":
+		return 0, nil
+	case "1.0", "10":
+		return tls.VersionTLS10, nil
+	case "1.1", "11":
+		return tls.VersionTLS11, nil
+	case "1.2", "12":
+		return tls.VersionTLS12, nil
+	case "1.3", "13":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unsupported TLS version: %s", raw)
+	}
+}
+
+// LoadCertPool returns the system certificate pool extended with the PEM files in caFiles.
+// It returns nil when caFiles is empty so callers keep the default verification roots.
+func LoadCertPool(caFiles []string) (*x509.CertPool, error) {
+	if len(caFiles) == 0 {
+		return nil, nil
+	}
+	pool, errPool := x509.SystemCertPool()
+	if errPool != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	for _, file := range caFiles {
+		path := strings.TrimSpace(file)
+		if path == "" {
+			continue
+		}
+		data, errRead := os.ReadFile(path)
+		if errRead != nil {
+			return nil, fmt.Errorf("read CA file %s failed: %w", path, errRead)
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("CA file %s contains no PEM certificates", path)
+		}
+	}
+	return pool, nil
+}
+
+// BuildTLSConfig constructs a crypto/tls configuration from the provided options.
+// It returns nil when no custom setting is configured.
+func BuildTLSConfig(opts TLSOptions) (*tls.Config, error) {
+	if opts.IsZero() {
+		return nil, nil
+	}
+	minVersion, errVersion := ParseTLSVersion(opts.MinVersion)
+	if errVersion != nil {
+		return nil, errVersion
+	}
+	pool, errPool := LoadCertPool(opts.CAFiles)
+	if errPool != nil {
+		return nil, errPool
+	}
+	tlsConfig := &tls.Config{
+		RootCAs:    pool,
+		ServerName: strings.TrimSpace(opts.ServerName),
+		MinVersion: minVersion,
+	}
+	certFile := strings.TrimSpace(opts.ClientCertFile)
+	keyFile := strings.TrimSpace(opts.ClientKeyFile)
+	if certFile != "" || keyFile != "" {
+		if certFile == "" || keyFile == "" {
+			return nil, fmt.Errorf("client certificate requires both cert and key files")
+		}
+		cert, errCert := tls.LoadX509KeyPair(certFile, keyFile)
+		if errCert != nil {
+			return nil, fmt.Errorf("load client certificate failed: %w", errCert)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// BuildHTTPTransportWithTLS constructs an HTTP transport for the provided proxy setting and
+// applies custom TLS options. Unlike BuildHTTPTransport, an inherited proxy setting still
+// yields a transport (honoring environment proxies) when TLS options are configured.
+func BuildHTTPTransportWithTLS(raw string, opts TLSOptions) (*http.Transport, Mode, error) {
+	transport, mode, errBuild := BuildHTTPTransport(raw)
+	if errBuild != nil || opts.IsZero() {
+		return transport, mode, errBuild
+	}
+	tlsConfig, errTLS := BuildTLSConfig(opts)
+	if errTLS != nil {
+		return nil, mode, errTLS
+	}
+	if transport == nil {
+		transport = cloneDefaultTransport()
+	}
+	transport.TLSClientConfig = tlsConfig
+	return transport, mode, nil
+}
@@ -0,0 +1,96 @@
+package proxyutil
+
+import (
+	"crypto/tls"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseTLSVersion(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input   string
+		want    uint16
+		wantErr bool
+	}{
+		{input: "", want: 0},
+		{input: "1.2", want: tls.VersionTLS12},
+		{input: "TLS1.3", want: tls.VersionTLS13},
+		{input: "tlsv1.1", want: tls.VersionTLS11},
+		{input: "2.0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, errParse := ParseTLSVersion(tt.input)
+		if tt.wantErr {
+			if errParse == nil {
+				t.Fatalf("ParseTLSVersion(%q) expected error, got nil", tt.input)
+			}
+			continue
+		}
+		if errParse != nil {
+			t.Fatalf("ParseTLSVersion(%q) unexpected error: %v", tt.input, errParse)
+		}
+		if got != tt.want {
+			t.Fatalf("ParseTLSVersion(%q) = %d, want %d", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestBuildHTTPTransportWithTLSInheritBuildsTransport(t *testing.T) {
+	t.Parallel()
+
+	transport, mode, errBuild := BuildHTTPTransportWithTLS("", TLSOptions{ServerName: "gateway.internal", MinVersion: "1.3"})
+	if errBuild != nil {
+		t.Fatalf("BuildHTTPTransportWithTLS returned error: %v", errBuild)
+	}
+	if mode != ModeInherit {
+		t.Fatalf("mode = %d, want %d", mode, ModeInherit)
+	}
+	if transport == nil || transport.TLSClientConfig == nil {
+		t.Fatal("expected transport with TLS config")
+	}
+	if transport.TLSClientConfig.ServerName != "gateway.internal" {
+		t.Fatalf("ServerName = %q, want gateway.internal", transport.TLSClientConfig.ServerName)
+	}
+	if transport.TLSClientConfig.MinVersion != tls.VersionTLS13 {
+		t.Fatalf("MinVersion = %d, want %d", transport.TLSClientConfig.MinVersion, tls.VersionTLS13)
+	}
+}
+
+func TestBuildHTTPTransportWithTLSZeroOptionsMatchesProxyOnly(t *testing.T) {
+	t.Parallel()
+
+	transport, mode, errBuild := BuildHTTPTransportWithTLS("", TLSOptions{})
+	if errBuild != nil {
+		t.Fatalf("BuildHTTPTransportWithTLS returned error: %v", errBuild)
+	}
+	if mode != ModeInherit {
+		t.Fatalf("mode = %d, want %d", mode, ModeInherit)
+	}
+	if transport != nil {
+		t.Fatal("expected nil transport for inherit mode without TLS options")
+	}
+}
+
+func TestBuildTLSConfigRejectsInvalidCAFile(t *testing.T) {
+	t.Parallel()
+
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	if errWrite := os.WriteFile(caPath, []byte("not a certificate"), 0o600); errWrite != nil {
+		t.Fatalf("write CA file: %v", errWrite)
+	}
+	if _, errBuild := BuildTLSConfig(TLSOptions{CAFiles: []string{caPath}}); errBuild == nil {
+		t.Fatal("expected error for CA file without PEM certificates")
+	}
+}
+
+func TestBuildTLSConfigRequiresCertAndKey(t *testing.T) {
+	t.Parallel()
+
+	if _, errBuild := BuildTLSConfig(TLSOptions{ClientCertFile: "/tmp/client.pem"}); errBuild == nil {
+		t.Fatal("expected error when client key is missing")
+	}
+}
@@ -0,0 +1,166 @@
+package translator
+
+import (
+	"sort"
+
+	"github.com/tidwall/gjson"
+)
+
+// Feature is an optional request field of a source format that a translator may not be able
+// to carry over to its target format.
+type Feature struct {
+	// Name identifies the feature in capability declarations, errors and the translation matrix.
+	Name string `json:"name"`
+	// Description explains which request fields make up the feature.
+	Description string `json:"description"`
+	// Detect reports whether a request uses the feature.
+	Detect func(root gjson.Result) bool `json:"-"`
+}
+
+// PairCapabilities lists the features of a source format carried over or dropped by the
+// translator to a target format.
+type PairCapabilities struct {
+	From        Format   `json:"from"`
+	To          Format   `json:"to"`
+	Declared    bool     `json:"declared"`
+	Supported   []string `json:"supported"`
+	Unsupported []string `json:"unsupported"`
+}
+
+// RegisterFeatures declares the optional features of a source format. Features registered
+// again under the same name replace the earlier definition.
+func (r *Registry) RegisterFeatures(from Format, features ...Feature) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.features == nil {
+		r.features = make(map[Format][]Feature)
+	}
+	existing := r.features[from]
+	for _, feature := range features {
+		replaced := false
+		for i := range existing {
+			if existing[i].Name == feature.Name {
+				existing[i] = feature
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			existing = append(existing, feature)
+		}
+	}
+	r.features[from] = existing
+}
+
+// RegisterCapabilities declares which features of from the translator to to drops. Pairs
+// without a declaration are treated as unknown and never reported as dropping anything.
+func (r *Registry) RegisterCapabilities(from, to Format, dropped ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.dropped == nil {
+		r.dropped = make(map[Format]map[Format]map[string]struct{})
+	}
+	if _, ok := r.dropped[from]; !ok {
+		r.dropped[from] = make(map[Format]map[string]struct{})
+	}
+	set := make(map[string]struct{}, len(dropped))
+	for _, name := range dropped {
+		set[name] = struct{}{}
+	}
+	r.dropped[from][to] = set
+}
+
+// UnsupportedFeatures returns the features used by rawJSON that the translator from from to
+// to drops, sorted by name. Requests between identical formats are forwarded unchanged.
+func (r *Registry) UnsupportedFeatures(from, to Format, rawJSON []byte) []string {
+	if from == to || len(rawJSON) == 0 {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	dropped := r.dropped[from][to]
+	if len(dropped) == 0 {
+		return nil
+	}
+	root := gjson.ParseBytes(rawJSON)
+	var used []string
+	for _, feature := range r.features[from] {
+		if _, ok := dropped[feature.Name]; !ok || feature.Detect == nil {
+			continue
+		}
+		if feature.Detect(root) {
+			used = append(used, feature.Name)
+		}
+	}
+	sort.Strings(used)
+	return used
+}
+
+// Features returns the optional features declared for a source format.
+func (r *Registry) Features(from Format) []Feature {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]Feature(nil), r.features[from]...)
+}
+
+// TranslationMatrix lists the capabilities of every registered request translator, ordered by
+// source and target format.
+func (r *Registry) TranslationMatrix() []PairCapabilities {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var out []PairCapabilities
+	for from, byTarget := range r.requests {
+		for to := range byTarget {
+			pair := PairCapabilities{From: from, To: to, Supported: []string{}, Unsupported: []string{}}
+			dropped, declared := r.dropped[from][to]
+			pair.Declared = declared || from == to
+			for _, feature := range r.features[from] {
+				if _, drop := dropped[feature.Name]; drop {
+					pair.Unsupported = append(pair.Unsupported, feature.Name)
+				} else if pair.Declared {
+					pair.Supported = append(pair.Supported, feature.Name)
+				}
+			}
+			sort.Strings(pair.Supported)
+			sort.Strings(pair.Unsupported)
+			out = append(out, pair)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].From != out[j].From {
+			return out[i].From < out[j].From
+		}
+		return out[i].To < out[j].To
+	})
+	return out
+}
+
+// RegisterFeatures declares source format features on the default registry.
+func RegisterFeatures(from Format, features ...Feature) {
+	defaultRegistry.RegisterFeatures(from, features...)
+}
+
+// RegisterCapabilities declares dropped features on the default registry.
+func RegisterCapabilities(from, to Format, dropped ...string) {
+	defaultRegistry.RegisterCapabilities(from, to, dropped...)
+}
+
+// UnsupportedFeatures inspects the default registry.
+func UnsupportedFeatures(from, to Format, rawJSON []byte) []string {
+	return defaultRegistry.UnsupportedFeatures(from, to, rawJSON)
+}
+
+// TranslationMatrix inspects the default registry.
+func TranslationMatrix() []PairCapabilities {
+	return defaultRegistry.TranslationMatrix()
+}
+
+// Features inspects the default registry.
+func Features(from Format) []Feature {
+	return defaultRegistry.Features(from)
+}
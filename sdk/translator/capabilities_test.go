@@ -0,0 +1,50 @@
+package translator
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestRegistry_UnsupportedFeaturesAndMatrix(t *testing.T) {
+	r := NewRegistry()
+	from, to := FromString("src"), FromString("dst")
+	r.Register(from, to, func(_ string, raw []byte, _ bool) []byte { return raw }, ResponseTransform{})
+	r.Register(from, FromString("undeclared"), func(_ string, raw []byte, _ bool) []byte { return raw }, ResponseTransform{})
+	r.RegisterFeatures(from,
+		Feature{Name: "seed", Detect: func(root gjson.Result) bool { return root.Get("seed").Exists() }},
+		Feature{Name: "n", Detect: func(root gjson.Result) bool { return root.Get("n").Int() > 1 }},
+		Feature{Name: "stop", Detect: func(root gjson.Result) bool { return root.Get("stop").Exists() }},
+	)
+	r.RegisterCapabilities(from, to, "seed", "n")
+
+	got := r.UnsupportedFeatures(from, to, []byte(`{"seed":1,"n":2,"stop":["x"]}`))
+	if want := []string{"n", "seed"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("UnsupportedFeatures = %v, want %v", got, want)
+	}
+	if got = r.UnsupportedFeatures(from, to, []byte(`{"n":1,"stop":["x"]}`)); len(got) != 0 {
+		t.Fatalf("UnsupportedFeatures for supported request = %v, want none", got)
+	}
+	if got = r.UnsupportedFeatures(from, FromString("undeclared"), []byte(`{"seed":1}`)); len(got) != 0 {
+		t.Fatalf("undeclared pair reported %v", got)
+	}
+	if got = r.UnsupportedFeatures(from, from, []byte(`{"seed":1}`)); len(got) != 0 {
+		t.Fatalf("identical formats reported %v", got)
+	}
+
+	matrix := r.TranslationMatrix()
+	if len(matrix) != 2 {
+		t.Fatalf("matrix has %d pairs, want 2", len(matrix))
+	}
+	declared := matrix[0]
+	if declared.To != to || !declared.Declared {
+		t.Fatalf("first pair = %+v, want declared src->dst", declared)
+	}
+	if !reflect.DeepEqual(declared.Supported, []string{"stop"}) || !reflect.DeepEqual(declared.Unsupported, []string{"n", "seed"}) {
+		t.Fatalf("declared pair = %+v", declared)
+	}
+	if undeclared := matrix[1]; undeclared.Declared || len(undeclared.Supported) != 0 || len(undeclared.Unsupported) != 0 {
+		t.Fatalf("undeclared pair = %+v", undeclared)
+	}
+}
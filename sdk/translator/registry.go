@@ -14,6 +14,9 @@ type Registry struct {
 	mu        sync.RWMutex
 	requests  map[Format]map[Format]RequestTransform
 	responses map[Format]map[Format]ResponseTransform
+	// features and dropped hold capability declarations used by strict translation.
+	features map[Format][]Feature
+	dropped  map[Format]map[Format]map[string]struct{}
 }
 
 // NewRegistry constructs an empty translator registry.